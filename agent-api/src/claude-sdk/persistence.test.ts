@@ -629,6 +629,89 @@ describe("persistence", () => {
 		});
 	});
 
+	describe("loadSessionMessages - usage", () => {
+		const testDir = join(tmpdir(), `claude-usage-test-${Date.now()}`);
+		const testCwd = join(testDir, "workspace");
+		const sessionDir = getSessionDirectoryForCwd(testCwd);
+
+		before(async () => {
+			await mkdir(sessionDir, { recursive: true });
+		});
+
+		after(async () => {
+			await rm(testDir, { recursive: true, force: true });
+		});
+
+		it("sums token usage across the API calls of a turn", async () => {
+			const sessionId = "test-usage";
+			const records = [
+				{
+					type: "user",
+					uuid: "user-1",
+					message: { role: "user", content: "Hello" },
+				},
+				{
+					type: "assistant",
+					uuid: "assistant-1",
+					message: {
+						id: "msg_1",
+						role: "assistant",
+						content: [
+							{ type: "tool_use", id: "tool_1", name: "Read", input: {} },
+						],
+						usage: {
+							input_tokens: 100,
+							output_tokens: 10,
+							cache_read_input_tokens: 50,
+						},
+					},
+				},
+				{
+					type: "user",
+					uuid: "user-2",
+					message: {
+						role: "user",
+						content: [
+							{ type: "tool_result", tool_use_id: "tool_1", content: "ok" },
+						],
+					},
+				},
+				{
+					type: "assistant",
+					uuid: "assistant-2",
+					message: {
+						id: "msg_2",
+						role: "assistant",
+						content: [{ type: "text", text: "Done" }],
+						usage: {
+							input_tokens: 200,
+							output_tokens: 20,
+							cache_creation_input_tokens: 5,
+						},
+					},
+				},
+			];
+			await writeFile(
+				join(sessionDir, `${sessionId}.jsonl`),
+				records.map((record) => JSON.stringify(record)).join("\n"),
+			);
+
+			const { loadSessionMessages } = await import("./persistence.js");
+			const messages = await loadSessionMessages(sessionId, testCwd);
+
+			assert.strictEqual(messages.length, 2);
+			assert.deepStrictEqual(messages[1].metadata, {
+				usage: {
+					inputTokens: 300,
+					outputTokens: 30,
+					cacheReadInputTokens: 50,
+					cacheCreationInputTokens: 5,
+				},
+			});
+			assert.strictEqual(messages[0].metadata, undefined);
+		});
+	});
+
 	// Note: Integration tests for discoverSessions, loadSessionMessages, etc.
 	// should be written as separate integration tests that use actual test
 	// session files, rather than complex mocking of fs/promises.
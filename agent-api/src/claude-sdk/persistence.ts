@@ -409,11 +409,48 @@ function mergeAssistantRecordsIntoOne(
 
 	if (parts.length === 0) return null;
 
+	const usage = sumRecordUsage(records);
+
 	return {
 		id: messageId,
 		role: "assistant",
 		parts,
+		...(usage && { metadata: { usage } }),
+	};
+}
+
+/**
+ * Token usage for an assistant turn, summed across its API calls.
+ */
+export interface TurnUsage {
+	inputTokens: number;
+	outputTokens: number;
+	cacheReadInputTokens: number;
+	cacheCreationInputTokens: number;
+}
+
+/**
+ * Sum the token usage reported on each (deduplicated) API call of a turn.
+ * Returns null if none of the records carry usage.
+ */
+function sumRecordUsage(records: SDKAssistantMessage[]): TurnUsage | null {
+	let found = false;
+	const total: TurnUsage = {
+		inputTokens: 0,
+		outputTokens: 0,
+		cacheReadInputTokens: 0,
+		cacheCreationInputTokens: 0,
 	};
+	for (const record of records) {
+		const usage = record.message.usage;
+		if (!usage) continue;
+		found = true;
+		total.inputTokens += usage.input_tokens ?? 0;
+		total.outputTokens += usage.output_tokens ?? 0;
+		total.cacheReadInputTokens += usage.cache_read_input_tokens ?? 0;
+		total.cacheCreationInputTokens += usage.cache_creation_input_tokens ?? 0;
+	}
+	return found ? total : null;
 }
 
 /**
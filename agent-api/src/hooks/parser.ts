@@ -6,6 +6,7 @@
  *
  * Hook types:
  * - session: Run once at container startup (executed by Go agent init)
+ * - shutdown: Run when the sandbox stops (executed by Go agent init)
  * - file: Run at end of LLM turn when matching files change
  * - pre-commit: Installed as git pre-commit hooks
  */
@@ -17,7 +18,7 @@ import { normalizeServiceId, parseFrontMatter } from "../services/parser.js";
 /**
  * Valid hook types
  */
-export type HookType = "session" | "file" | "pre-commit";
+export type HookType = "session" | "shutdown" | "file" | "pre-commit";
 
 /**
  * Hook configuration parsed from YAML front matter
@@ -29,7 +30,7 @@ export interface HookConfig {
 	type?: HookType;
 	/** Description */
 	description?: string;
-	/** Run as root or user (session hooks only, default: "user") */
+	/** Run as root or user (session and shutdown hooks only, default: "user") */
 	runAs?: "root" | "user";
 	/** Glob pattern for file matching (file hooks only, required for file hooks) */
 	pattern?: string;
@@ -59,7 +60,12 @@ export interface Hook {
 	notifyLlm: boolean;
 }
 
-const VALID_HOOK_TYPES = new Set<string>(["session", "file", "pre-commit"]);
+const VALID_HOOK_TYPES = new Set<string>([
+	"session",
+	"shutdown",
+	"file",
+	"pre-commit",
+]);
 
 /**
  * Parse hook-specific fields from simple YAML content.
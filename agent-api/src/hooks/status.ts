@@ -10,13 +10,23 @@ import { mkdir, readFile, rename, writeFile } from "node:fs/promises";
 import { dirname, join } from "node:path";
 import type { HookResult } from "./executor.js";
 
+/**
+ * One attempt of a hook run. Session hooks with retries record several.
+ */
+export interface HookAttempt {
+	startedAt: string;
+	durationMs: number;
+	exitCode: number;
+	result: "success" | "failure";
+}
+
 /**
  * Status of a single hook's runs
  */
 export interface HookRunStatus {
 	hookId: string;
 	hookName: string;
-	type: "session" | "file" | "pre-commit";
+	type: "session" | "shutdown" | "file" | "pre-commit";
 	lastRunAt: string;
 	lastResult: "success" | "failure" | "running";
 	lastExitCode: number;
@@ -25,6 +35,8 @@ export interface HookRunStatus {
 	runCount: number;
 	failCount: number;
 	consecutiveFailures: number;
+	/** Attempts of the last run, written by the agent for session hooks */
+	attempts?: HookAttempt[];
 }
 
 /**
@@ -0,0 +1,54 @@
+/**
+ * Unit tests for devcontainer forwarded port services
+ */
+
+import assert from "node:assert";
+import { describe, it } from "node:test";
+import {
+	DEVCONTAINER_ENV_VAR,
+	getForwardedPortServices,
+} from "./devcontainer.js";
+
+function encode(spec: unknown): Record<string, string | undefined> {
+	return {
+		[DEVCONTAINER_ENV_VAR]: Buffer.from(JSON.stringify(spec)).toString(
+			"base64",
+		),
+	};
+}
+
+describe("getForwardedPortServices", () => {
+	it("returns no services when the variable is unset", () => {
+		assert.deepStrictEqual(getForwardedPortServices({}), []);
+	});
+
+	it("returns no services for an invalid value", () => {
+		assert.deepStrictEqual(
+			getForwardedPortServices({ [DEVCONTAINER_ENV_VAR]: "not base64 json" }),
+			[],
+		);
+	});
+
+	it("maps forwarded ports to passive services", () => {
+		const services = getForwardedPortServices(
+			encode({
+				postCreate: [{ script: "make" }],
+				forwardPorts: [
+					{ port: 3000, label: "Web", protocol: "http" },
+					{ port: 8443, protocol: "https" },
+					{ port: 0 },
+				],
+			}),
+		);
+
+		assert.strictEqual(services.length, 2);
+		assert.strictEqual(services[0].id, "port-3000");
+		assert.strictEqual(services[0].name, "Web");
+		assert.strictEqual(services[0].http, 3000);
+		assert.strictEqual(services[0].passive, true);
+		assert.strictEqual(services[1].id, "port-8443");
+		assert.strictEqual(services[1].name, "Port 8443");
+		assert.strictEqual(services[1].https, 8443);
+		assert.strictEqual(services[1].http, undefined);
+	});
+});
@@ -0,0 +1,67 @@
+/**
+ * Devcontainer forwarded ports
+ *
+ * The server passes the workspace's devcontainer.json lifecycle commands and
+ * forwardPorts to the sandbox in the DISCOBOT_DEVCONTAINER environment
+ * variable (base64-encoded JSON, see devcontainer.Spec in the server). Each
+ * forwarded port is exposed as a passive service.
+ */
+
+import type { Service } from "../api/types.js";
+
+/** Environment variable carrying the devcontainer spec */
+export const DEVCONTAINER_ENV_VAR = "DISCOBOT_DEVCONTAINER";
+
+interface ForwardedPort {
+	port: number;
+	label?: string;
+	protocol?: string;
+}
+
+/**
+ * Get passive services for the devcontainer's forwarded ports.
+ * Returns an empty list if the variable is unset or invalid.
+ *
+ * Service IDs are "port-{port}". A service in .discobot/services with the
+ * same ID takes precedence, since it is listed first.
+ */
+export function getForwardedPortServices(
+	env: Record<string, string | undefined> = process.env,
+): Service[] {
+	const encoded = env[DEVCONTAINER_ENV_VAR];
+	if (!encoded) {
+		return [];
+	}
+
+	let ports: ForwardedPort[];
+	try {
+		const spec = JSON.parse(
+			Buffer.from(encoded, "base64").toString("utf-8"),
+		);
+		ports = Array.isArray(spec?.forwardPorts) ? spec.forwardPorts : [];
+	} catch {
+		console.error(`Invalid ${DEVCONTAINER_ENV_VAR} value`);
+		return [];
+	}
+
+	return ports
+		.filter(
+			(p) => Number.isInteger(p?.port) && p.port > 0 && p.port < 65536,
+		)
+		.map((p) => {
+			const service: Service = {
+				id: `port-${p.port}`,
+				name: p.label || `Port ${p.port}`,
+				description: "Forwarded port from devcontainer.json",
+				path: "",
+				status: "stopped",
+				passive: true,
+			};
+			if (p.protocol === "https") {
+				service.https = p.port;
+			} else {
+				service.http = p.port;
+			}
+			return service;
+		});
+}
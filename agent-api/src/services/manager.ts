@@ -29,6 +29,7 @@ import {
 	readEvents,
 	truncateIfNeeded,
 } from "./output.js";
+import { getForwardedPortServices } from "./devcontainer.js";
 import { discoverServices } from "./parser.js";
 
 /**
@@ -90,7 +91,8 @@ export async function getServices(workspaceRoot: string): Promise<Service[]> {
 	const servicesDir = join(workspaceRoot, SERVICES_DIR);
 	const discoveredServices = await discoverServices(servicesDir);
 
-	// Merge with runtime state, prepend built-in desktop service
+	// Merge with runtime state, prepend built-in desktop service and append
+	// devcontainer.json forwarded ports
 	return [
 		DESKTOP_SERVICE,
 		...discoveredServices.map((service) => {
@@ -100,6 +102,7 @@ export async function getServices(workspaceRoot: string): Promise<Service[]> {
 			}
 			return service;
 		}),
+		...getForwardedPortServices(),
 	];
 }
 
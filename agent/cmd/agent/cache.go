@@ -0,0 +1,464 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cacheVolumeDir is where the project cache volume is mounted.
+const cacheVolumeDir = dataDir + "/cache"
+
+// otherCacheEntry names usage that doesn't belong to a known cache entry,
+// such as BuildKit state and additional paths from .discobot/cache.json.
+const otherCacheEntry = "other"
+
+// errUnknownCacheEntry is returned for an ENTRY argument that is not a cache
+// path. The cache subcommand exits with cacheInvalidEntryExitCode for it, so
+// the server can tell a bad request from a failure.
+var errUnknownCacheEntry = errors.New("unknown cache path")
+
+// cacheInvalidEntryExitCode is the exit code for errUnknownCacheEntry.
+const cacheInvalidEntryExitCode = 2
+
+// cacheEntryNames gives the well-known cache paths short names for the
+// usage report and the clear/prune commands.
+var cacheEntryNames = map[string]string{
+	"/home/discobot/.cache":              "cache",
+	"/home/discobot/.npm":                "npm",
+	"/home/discobot/.pnpm-store":         "pnpm",
+	"/home/discobot/.yarn":               "yarn",
+	"/home/discobot/.local/share/uv":     "uv",
+	"/home/discobot/go/pkg/mod":          "go",
+	"/home/discobot/.cargo/registry":     "cargo-registry",
+	"/home/discobot/.cargo/git":          "cargo-git",
+	"/home/discobot/.bundle":             "bundler",
+	"/home/discobot/.gem":                "gem",
+	"/home/discobot/.m2/repository":      "maven",
+	"/home/discobot/.gradle/caches":      "gradle",
+	"/home/discobot/.gradle/wrapper":     "gradle-wrapper",
+	"/home/discobot/.nuget/packages":     "nuget",
+	"/home/discobot/.composer/cache":     "composer",
+	"/home/discobot/.bun/install/cache":  "bun",
+	"/home/discobot/.docker/buildx":      "buildx",
+	"/var/cache/apt":                     "apt",
+	"/home/discobot/.ccache":             "ccache",
+	"/home/discobot/.vscode-server":      "vscode-server",
+	"/home/discobot/.cursor-server":      "cursor-server",
+	"/home/discobot/.zed_server":         "zed-server",
+	cacheVolumeDir + "/" + proxyCacheDir: "proxy",
+}
+
+// proxyCacheDir is the proxy's cache directory, relative to the cache volume.
+const proxyCacheDir = "proxy"
+
+// cacheEntry is a cache directory inside the cache volume.
+type cacheEntry struct {
+	Name string
+	Path string // path inside the sandbox
+	Dir  string // directory relative to the cache volume root
+}
+
+// knownCacheEntries returns the well-known cache entries and the proxy cache.
+func knownCacheEntries() []cacheEntry {
+	paths := append(wellKnownCachePaths(), cacheVolumeDir+"/"+proxyCacheDir)
+	entries := make([]cacheEntry, 0, len(paths))
+	for _, p := range paths {
+		entries = append(entries, newCacheEntry(p))
+	}
+	return entries
+}
+
+// newCacheEntry returns the entry for a sandbox path. Paths inside the cache
+// volume itself (the proxy cache) map to their location in the volume; other
+// paths are stored under their absolute path, as mountCacheDirectories does.
+func newCacheEntry(path string) cacheEntry {
+	path = filepath.Clean(path)
+	dir := strings.TrimPrefix(path, "/")
+	if rel, err := filepath.Rel(cacheVolumeDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		dir = rel
+	}
+	name, ok := cacheEntryNames[path]
+	if !ok {
+		name = path
+	}
+	return cacheEntry{Name: name, Path: path, Dir: dir}
+}
+
+// resolveCacheEntry finds the cache entry for a name (e.g. "go"), a sandbox
+// path (e.g. "/home/discobot/go/pkg/mod") or a path relative to the home
+// directory (e.g. "go/pkg/mod").
+func resolveCacheEntry(arg string) (cacheEntry, error) {
+	known := knownCacheEntries()
+	for _, e := range known {
+		if e.Name == arg || e.Path == filepath.Clean(arg) {
+			return e, nil
+		}
+	}
+	if !filepath.IsAbs(arg) {
+		for _, e := range known {
+			if strings.HasSuffix(e.Path, "/"+filepath.Clean(arg)) {
+				return e, nil
+			}
+		}
+		arg = filepath.Join(mountHome, arg)
+	}
+	if !isValidCachePath(arg) {
+		return cacheEntry{}, fmt.Errorf("%w %q", errUnknownCacheEntry, arg)
+	}
+	return newCacheEntry(arg), nil
+}
+
+// cacheEntryUsage is the usage of one cache entry. The JSON form is decoded
+// by the server.
+type cacheEntryUsage struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`
+	Bytes    int64      `json:"bytes"`
+	Files    int64      `json:"files"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// cacheUsage is the usage of a cache volume.
+type cacheUsage struct {
+	TotalBytes int64             `json:"totalBytes"`
+	Entries    []cacheEntryUsage `json:"entries"`
+}
+
+// cachePruneResult reports what a prune or clear removed.
+type cachePruneResult struct {
+	Cleared    []string `json:"cleared"`
+	FreedBytes int64    `json:"freedBytes"`
+}
+
+// measureCache walks the cache volume at root once and attributes every
+// file to the cache entry containing it. Sizes are allocated disk usage.
+// Entries without any files are omitted.
+func measureCache(root string) (*cacheUsage, error) {
+	known := knownCacheEntries()
+	byDir := make(map[string]*cacheEntryUsage, len(known))
+	for _, e := range known {
+		byDir[e.Dir] = &cacheEntryUsage{Name: e.Name, Path: e.Path}
+	}
+	other := &cacheEntryUsage{Name: otherCacheEntry}
+
+	usage := &cacheUsage{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// Removed while walking
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		entry := other
+		for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+			if u, ok := byDir[dir]; ok {
+				entry = u
+				break
+			}
+		}
+
+		size, used := fileUsage(info)
+		entry.Bytes += size
+		entry.Files++
+		if entry.LastUsed == nil || used.After(*entry.LastUsed) {
+			entry.LastUsed = &used
+		}
+		usage.TotalBytes += size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range known {
+		if u := byDir[e.Dir]; u.Files > 0 {
+			usage.Entries = append(usage.Entries, *u)
+		}
+	}
+	if other.Files > 0 {
+		usage.Entries = append(usage.Entries, *other)
+	}
+	return usage, nil
+}
+
+// fileUsage returns a file's allocated size and when it was last used, the
+// later of its access and modification times.
+func fileUsage(info fs.FileInfo) (int64, time.Time) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size(), info.ModTime()
+	}
+	used := info.ModTime()
+	if atime := time.Unix(st.Atim.Unix()); atime.After(used) {
+		used = atime
+	}
+	return st.Blocks * 512, used
+}
+
+// clearCacheEntries removes the contents of each entry, keeping the
+// directories themselves so bind mounts in running sandboxes stay valid.
+func clearCacheEntries(root string, entries []cacheEntry) (*cachePruneResult, error) {
+	result := &cachePruneResult{Cleared: []string{}}
+	for _, e := range entries {
+		dir := filepath.Join(root, e.Dir)
+		freed, err := clearCacheDir(dir)
+		result.FreedBytes += freed
+		if err != nil {
+			return result, fmt.Errorf("clear %s: %w", e.Name, err)
+		}
+		result.Cleared = append(result.Cleared, e.Name)
+	}
+	return result, nil
+}
+
+// clearCacheDir removes everything inside dir and returns the bytes freed.
+// Read-only trees such as the Go module cache are removed without chmod
+// because the agent runs as root.
+func clearCacheDir(dir string) (int64, error) {
+	children, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	usage, err := measureCache(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range children {
+		if err := os.RemoveAll(filepath.Join(dir, c.Name())); err != nil {
+			return 0, err
+		}
+	}
+	return usage.TotalBytes, nil
+}
+
+// cachePruneOptions selects what pruneCache removes.
+type cachePruneOptions struct {
+	// Entries limits pruning to these entries. Empty means all known
+	// entries; files outside them are never pruned.
+	Entries []cacheEntry
+	// MaxAge clears entries not used within this duration (0 = no limit).
+	MaxAge time.Duration
+	// MaxBytes clears the least recently used entries until the volume is
+	// at most this size (0 = no limit).
+	MaxBytes int64
+}
+
+// pruneCache clears whole cache entries: first those unused for longer than
+// MaxAge, then the least recently used ones until the volume fits MaxBytes.
+// Entries are the unit of eviction because partially deleted package caches
+// (e.g. an extracted Go module missing files) are worse than empty ones.
+func pruneCache(root string, opts cachePruneOptions, now time.Time) (*cachePruneResult, error) {
+	usage, err := measureCache(root)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := opts.Entries
+	if len(candidates) == 0 {
+		candidates = knownCacheEntries()
+	}
+	byName := make(map[string]cacheEntryUsage, len(usage.Entries))
+	for _, u := range usage.Entries {
+		byName[u.Name] = u
+	}
+
+	var clear, lru []cacheEntry
+	for _, e := range candidates {
+		u, ok := byName[e.Name]
+		if !ok {
+			continue
+		}
+		if opts.MaxAge > 0 && u.LastUsed.Before(now.Add(-opts.MaxAge)) {
+			clear = append(clear, e)
+		} else {
+			lru = append(lru, e)
+		}
+	}
+
+	total := usage.TotalBytes
+	for _, e := range clear {
+		total -= byName[e.Name].Bytes
+	}
+	if opts.MaxBytes > 0 {
+		slices.SortFunc(lru, func(a, b cacheEntry) int {
+			return byName[a.Name].LastUsed.Compare(*byName[b.Name].LastUsed)
+		})
+		for _, e := range lru {
+			if total <= opts.MaxBytes {
+				break
+			}
+			clear = append(clear, e)
+			total -= byName[e.Name].Bytes
+		}
+	}
+
+	return clearCacheEntries(root, clear)
+}
+
+// pruneCacheFromEnv applies the project's cache limits, passed by the server
+// as CACHE_MAX_AGE (a Go duration) and CACHE_MAX_BYTES, before the cache is
+// mounted.
+func pruneCacheFromEnv(root string) error {
+	var opts cachePruneOptions
+	if v := os.Getenv("CACHE_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid CACHE_MAX_AGE %q: %w", v, err)
+		}
+		opts.MaxAge = d
+	}
+	if v := os.Getenv("CACHE_MAX_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CACHE_MAX_BYTES %q: %w", v, err)
+		}
+		opts.MaxBytes = n
+	}
+	if opts.MaxAge <= 0 && opts.MaxBytes <= 0 {
+		return nil
+	}
+
+	result, err := pruneCache(root, opts, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(result.Cleared) > 0 {
+		fmt.Printf("discobot-agent: pruned cache entries %s (%d bytes freed)\n",
+			strings.Join(result.Cleared, ", "), result.FreedBytes)
+	}
+	return nil
+}
+
+// runCache implements the "cache" subcommand, which the server runs against
+// a project cache volume to report usage and reclaim space. Results are
+// written to stdout as JSON.
+//
+//	discobot-agent cache usage
+//	discobot-agent cache prune [--max-age DURATION] [--max-bytes N] [--] [ENTRY...]
+//	discobot-agent cache clear [--] [ENTRY...]
+//
+// ENTRY is a name such as "go" or "npm", or a cache path such as
+// "go/pkg/mod". Arguments after "--" are always entries. clear without
+// entries clears every known entry.
+func runCache(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: discobot-agent cache usage | prune [--max-age DURATION] [--max-bytes N] [ENTRY...] | clear [ENTRY...]")
+	}
+
+	root := cacheVolumeDir
+	var result any
+	switch args[0] {
+	case "usage":
+		usage, err := measureCache(root)
+		if err != nil {
+			return err
+		}
+		result = usage
+
+	case "prune":
+		opts, err := parseCachePruneArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		pruned, err := pruneCache(root, opts, time.Now())
+		if err != nil {
+			return err
+		}
+		result = pruned
+
+	case "clear":
+		entries := knownCacheEntries()
+		rest := args[1:]
+		if len(rest) > 0 && rest[0] == "--" {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			var err error
+			if entries, err = resolveCacheEntries(rest); err != nil {
+				return err
+			}
+		}
+		cleared, err := clearCacheEntries(root, entries)
+		if err != nil {
+			return err
+		}
+		result = cleared
+
+	default:
+		return fmt.Errorf("unknown cache command %q", args[0])
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(result)
+}
+
+// parseCachePruneArgs parses the arguments of "cache prune": flags, then
+// entries. Arguments after "--" are entries even if they look like flags.
+func parseCachePruneArgs(args []string) (cachePruneOptions, error) {
+	var opts cachePruneOptions
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		if len(args) < 2 {
+			return opts, fmt.Errorf("missing value for %s", args[0])
+		}
+		switch args[0] {
+		case "--max-age":
+			d, err := time.ParseDuration(args[1])
+			if err != nil {
+				return opts, fmt.Errorf("invalid --max-age: %w", err)
+			}
+			opts.MaxAge = d
+		case "--max-bytes":
+			n, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return opts, fmt.Errorf("invalid --max-bytes: %w", err)
+			}
+			opts.MaxBytes = n
+		default:
+			return opts, fmt.Errorf("unknown flag %s", args[0])
+		}
+		args = args[2:]
+	}
+	entries, err := resolveCacheEntries(args)
+	if err != nil {
+		return opts, err
+	}
+	opts.Entries = entries
+	return opts, nil
+}
+
+// resolveCacheEntries resolves each argument with resolveCacheEntry.
+func resolveCacheEntries(args []string) ([]cacheEntry, error) {
+	entries := make([]cacheEntry, 0, len(args))
+	for _, arg := range args {
+		e, err := resolveCacheEntry(arg)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
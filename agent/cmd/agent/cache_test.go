@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeCacheFile creates a file of size bytes in the cache volume at root and
+// sets its access and modification times to used.
+func writeCacheFile(t *testing.T, root, rel string, size int, used time.Time) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, used, used); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func usageByName(u *cacheUsage) map[string]cacheEntryUsage {
+	m := make(map[string]cacheEntryUsage, len(u.Entries))
+	for _, e := range u.Entries {
+		m[e.Name] = e
+	}
+	return m
+}
+
+func TestResolveCacheEntry(t *testing.T) {
+	tests := []struct {
+		arg      string
+		wantName string
+		wantDir  string
+	}{
+		{"go", "go", "home/discobot/go/pkg/mod"},
+		{"go/pkg/mod", "go", "home/discobot/go/pkg/mod"},
+		{"/home/discobot/.npm", "npm", "home/discobot/.npm"},
+		{"apt", "apt", "var/cache/apt"},
+		{"proxy", "proxy", "proxy"},
+		{".custom-cache", "/home/discobot/.custom-cache", "home/discobot/.custom-cache"},
+	}
+	for _, tt := range tests {
+		e, err := resolveCacheEntry(tt.arg)
+		if err != nil {
+			t.Errorf("resolveCacheEntry(%q): %v", tt.arg, err)
+			continue
+		}
+		if e.Name != tt.wantName || e.Dir != tt.wantDir {
+			t.Errorf("resolveCacheEntry(%q) = %+v, want name %q dir %q", tt.arg, e, tt.wantName, tt.wantDir)
+		}
+	}
+
+	for _, arg := range []string{"/etc", "../etc", "/home/discobot"} {
+		if _, err := resolveCacheEntry(arg); !errors.Is(err, errUnknownCacheEntry) {
+			t.Errorf("resolveCacheEntry(%q) = %v, want errUnknownCacheEntry", arg, err)
+		}
+	}
+}
+
+func TestParseCachePruneArgs(t *testing.T) {
+	opts, err := parseCachePruneArgs([]string{"--max-age", "1h", "--", "--max-bytes", "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.MaxAge != time.Hour || opts.MaxBytes != 0 {
+		t.Errorf("got max age %v, max bytes %d; want 1h and no limit", opts.MaxAge, opts.MaxBytes)
+	}
+	// After "--", "--max-bytes" is an entry, not a flag
+	if len(opts.Entries) != 2 || opts.Entries[0].Path != "/home/discobot/--max-bytes" || opts.Entries[1].Name != "go" {
+		t.Errorf("entries = %+v", opts.Entries)
+	}
+
+	if _, err := parseCachePruneArgs([]string{"--", "/etc"}); !errors.Is(err, errUnknownCacheEntry) {
+		t.Errorf("got %v, want errUnknownCacheEntry", err)
+	}
+}
+
+func TestMeasureCache(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	writeCacheFile(t, root, "home/discobot/go/pkg/mod/example.com/a@v1/a.go", 5000, now.Add(-time.Hour))
+	writeCacheFile(t, root, "home/discobot/go/pkg/mod/cache/download/a.zip", 3000, now)
+	writeCacheFile(t, root, "home/discobot/.npm/_cacache/index", 100, now)
+	writeCacheFile(t, root, "proxy/data/ab/cdef", 100, now)
+	writeCacheFile(t, root, "home/discobot/.custom/file", 100, now)
+
+	usage, err := measureCache(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := usageByName(usage)
+
+	for _, name := range []string{"go", "npm", "proxy", otherCacheEntry} {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("missing usage for %s: %+v", name, usage.Entries)
+		}
+	}
+	if _, ok := byName["cargo-registry"]; ok {
+		t.Error("empty entries should be omitted")
+	}
+
+	goUsage := byName["go"]
+	if goUsage.Files != 2 || goUsage.Path != "/home/discobot/go/pkg/mod" {
+		t.Errorf("go usage = %+v, want 2 files under /home/discobot/go/pkg/mod", goUsage)
+	}
+	if goUsage.LastUsed == nil || !goUsage.LastUsed.Equal(now) {
+		t.Errorf("go last used = %v, want %v", goUsage.LastUsed, now)
+	}
+
+	var sum int64
+	for _, e := range usage.Entries {
+		sum += e.Bytes
+	}
+	if sum != usage.TotalBytes || usage.TotalBytes < 8300 {
+		t.Errorf("total = %d, entries sum to %d", usage.TotalBytes, sum)
+	}
+}
+
+func TestPruneCacheByAge(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	writeCacheFile(t, root, "home/discobot/.npm/old", 100, now.Add(-60*24*time.Hour))
+	writeCacheFile(t, root, "home/discobot/go/pkg/mod/new", 100, now.Add(-time.Hour))
+
+	result, err := pruneCache(root, cachePruneOptions{MaxAge: 30 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Cleared, []string{"npm"}) {
+		t.Errorf("cleared = %v, want [npm]", result.Cleared)
+	}
+	if result.FreedBytes <= 0 {
+		t.Errorf("freed = %d, want > 0", result.FreedBytes)
+	}
+
+	// The entry directory itself stays so running sandboxes keep their mount.
+	if _, err := os.Stat(filepath.Join(root, "home/discobot/.npm")); err != nil {
+		t.Errorf("npm directory removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "home/discobot/.npm/old")); !os.IsNotExist(err) {
+		t.Errorf("old npm file still present: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "home/discobot/go/pkg/mod/new")); err != nil {
+		t.Errorf("recent go file removed: %v", err)
+	}
+}
+
+func TestPruneCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	writeCacheFile(t, root, "home/discobot/.npm/a", 64<<10, now.Add(-3*time.Hour))
+	writeCacheFile(t, root, "home/discobot/.cargo/registry/b", 64<<10, now.Add(-2*time.Hour))
+	writeCacheFile(t, root, "home/discobot/go/pkg/mod/c", 64<<10, now.Add(-time.Hour))
+	writeCacheFile(t, root, "home/discobot/.custom/d", 64<<10, now.Add(-4*time.Hour))
+
+	before, err := measureCache(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goBytes := usageByName(before)["go"].Bytes
+
+	// Leave room for go and the unmanaged file only.
+	maxBytes := before.TotalBytes - usageByName(before)["npm"].Bytes - usageByName(before)["cargo-registry"].Bytes
+	result, err := pruneCache(root, cachePruneOptions{MaxBytes: maxBytes}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Cleared, []string{"npm", "cargo-registry"}) {
+		t.Errorf("cleared = %v, want [npm cargo-registry]", result.Cleared)
+	}
+
+	after, err := measureCache(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.TotalBytes > maxBytes {
+		t.Errorf("total after prune = %d, want <= %d", after.TotalBytes, maxBytes)
+	}
+	if got := usageByName(after)["go"].Bytes; got != goBytes {
+		t.Errorf("go bytes = %d, want %d", got, goBytes)
+	}
+	if _, ok := usageByName(after)[otherCacheEntry]; !ok {
+		t.Error("files outside known entries must not be pruned")
+	}
+}
+
+func TestPruneCacheLimitedToEntries(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour)
+	writeCacheFile(t, root, "home/discobot/.npm/a", 100, old)
+	writeCacheFile(t, root, "home/discobot/go/pkg/mod/b", 100, old)
+
+	entries, err := resolveCacheEntries([]string{"go/pkg/mod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := pruneCache(root, cachePruneOptions{Entries: entries, MaxAge: time.Hour}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Cleared, []string{"go"}) {
+		t.Errorf("cleared = %v, want [go]", result.Cleared)
+	}
+	if _, err := os.Stat(filepath.Join(root, "home/discobot/.npm/a")); err != nil {
+		t.Errorf("npm file removed: %v", err)
+	}
+}
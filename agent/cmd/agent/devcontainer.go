@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// devcontainerEnvVar carries the workspace's devcontainer.json lifecycle
+// commands and forwarded ports, as base64-encoded JSON.
+const devcontainerEnvVar = "DISCOBOT_DEVCONTAINER"
+
+// devcontainerSpec is the decoded DISCOBOT_DEVCONTAINER value.
+// Schema matches devcontainer.Spec in server/internal/devcontainer.
+type devcontainerSpec struct {
+	RemoteUser string                `json:"remoteUser"`
+	PostCreate []devcontainerCommand `json:"postCreate"`
+	PostStart  []devcontainerCommand `json:"postStart"`
+}
+
+// devcontainerCommand is one lifecycle command, run with /bin/sh.
+type devcontainerCommand struct {
+	Name   string `json:"name"`
+	Script string `json:"script"`
+}
+
+// loadDevcontainerSpec decodes DISCOBOT_DEVCONTAINER. Returns nil if it is
+// unset or invalid.
+func loadDevcontainerSpec() *devcontainerSpec {
+	value := os.Getenv(devcontainerEnvVar)
+	if value == "" {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "discobot-agent: invalid %s: %v\n", devcontainerEnvVar, err)
+		return nil
+	}
+	var spec devcontainerSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "discobot-agent: invalid %s: %v\n", devcontainerEnvVar, err)
+		return nil
+	}
+	return &spec
+}
+
+// devcontainerHooks turns devcontainer.json lifecycle commands into session
+// hooks. Each command is written as a script under {dataDir}/devcontainer/.
+//
+// postCreateCommand runs blocking, once per session: it is skipped when a
+// previous run succeeded. postStartCommand runs in the background on every
+// container start. Commands run as root when remoteUser is root, otherwise as
+// the discobot user.
+func devcontainerHooks(spec *devcontainerSpec, dataDir string, u *userInfo) ([]string, []hookConfig) {
+	if spec == nil || (len(spec.PostCreate) == 0 && len(spec.PostStart) == 0) {
+		return nil, nil
+	}
+
+	scriptDir := filepath.Join(dataDir, "devcontainer")
+	if err := os.MkdirAll(scriptDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "discobot-agent: failed to create devcontainer hooks dir: %v\n", err)
+		return nil, nil
+	}
+	_ = os.Chown(scriptDir, u.uid, u.gid)
+
+	runAs := "user"
+	if spec.RemoteUser == "root" {
+		runAs = "root"
+	}
+
+	status := loadHookStatus(dataDir)
+	var paths []string
+	var configs []hookConfig
+	add := func(property, filePrefix string, commands []devcontainerCommand, blocking, once bool) {
+		for _, c := range commands {
+			fileName, name := filePrefix, property
+			if c.Name != "" {
+				fileName += "-" + normalizeHookID(c.Name)
+				name += " (" + c.Name + ")"
+			}
+			fileName += ".sh"
+
+			if once {
+				if prev, ok := status.Hooks[normalizeHookID(fileName)]; ok && prev.LastResult == "success" {
+					continue
+				}
+			}
+
+			scriptPath := filepath.Join(scriptDir, fileName)
+			if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\n"+c.Script+"\n"), 0755); err != nil {
+				fmt.Fprintf(os.Stderr, "discobot-agent: failed to write %s hook: %v\n", property, err)
+				continue
+			}
+			paths = append(paths, scriptPath)
+			configs = append(configs, hookConfig{Name: name, Type: "session", RunAs: runAs, Blocking: blocking})
+		}
+	}
+	add("postCreateCommand", "devcontainer-post-create", spec.PostCreate, true, true)
+	add("postStartCommand", "devcontainer-post-start", spec.PostStart, false, false)
+	return paths, configs
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// hookNode is a session hook in the dependency graph.
+type hookNode struct {
+	id     string
+	path   string
+	config hookConfig
+	deps   []*hookNode
+	// after holds earlier hooks this one waits for only to keep file name
+	// order; unlike deps, their failure does not stop it
+	after []*hookNode
+
+	// invalid is set when the hook cannot run: an unknown or cyclic depends_on
+	invalid error
+
+	// Set once the hook has been handled
+	done    bool
+	skipped bool  // The when condition did not hold
+	err     error // Failure, or the reason the hook did not run
+}
+
+// failed reports whether dependents of the hook must not run.
+func (n *hookNode) failed() bool {
+	return n.done && n.err != nil
+}
+
+// buildHookGraph resolves depends_on references, by hook ID or name, into a
+// graph. Hooks with unknown dependencies or in a dependency cycle are marked
+// invalid. Nodes keep the order of paths.
+//
+// Hooks run one after another in path order unless they opt in to running
+// concurrently: a hook without depends_on waits for every earlier hook
+// outside its parallel_group, while a hook with depends_on waits only for
+// those hooks.
+func buildHookGraph(paths []string, configs []hookConfig) []*hookNode {
+	nodes := make([]*hookNode, len(paths))
+	byID := make(map[string]*hookNode, len(paths))
+	byName := make(map[string]*hookNode, len(paths))
+	for i, path := range paths {
+		n := &hookNode{id: normalizeHookID(filepath.Base(path)), path: path, config: configs[i]}
+		nodes[i] = n
+		byID[n.id] = n
+		if _, ok := byName[n.config.Name]; !ok {
+			byName[n.config.Name] = n
+		}
+	}
+
+	for _, n := range nodes {
+		for _, ref := range n.config.DependsOn {
+			dep := byID[ref]
+			if dep == nil {
+				dep = byID[normalizeHookID(ref)]
+			}
+			if dep == nil {
+				dep = byName[ref]
+			}
+			if dep == nil {
+				n.invalid = fmt.Errorf("unknown dependency %q", ref)
+				continue
+			}
+			n.deps = append(n.deps, dep)
+		}
+	}
+
+	// Mark hooks on a dependency cycle: those that can reach themselves
+	for _, n := range nodes {
+		if n.invalid == nil && reaches(n.deps, n, make(map[*hookNode]bool)) {
+			n.invalid = fmt.Errorf("dependency cycle")
+		}
+	}
+
+	for i, n := range nodes {
+		if len(n.config.DependsOn) > 0 {
+			continue
+		}
+		for _, m := range nodes[:i] {
+			if n.config.ParallelGroup != "" && m.config.ParallelGroup == n.config.ParallelGroup {
+				continue
+			}
+			// An earlier hook that depends on this one runs after it instead
+			if reaches(m.prerequisites(), n, make(map[*hookNode]bool)) {
+				continue
+			}
+			n.after = append(n.after, m)
+		}
+	}
+
+	return nodes
+}
+
+// prerequisites returns the hooks that must be done before the hook starts.
+func (n *hookNode) prerequisites() []*hookNode {
+	return append(slices.Clone(n.deps), n.after...)
+}
+
+// reaches reports whether target is reachable from nodes through depends_on
+// and file name order.
+func reaches(nodes []*hookNode, target *hookNode, seen map[*hookNode]bool) bool {
+	for _, n := range nodes {
+		if n == target {
+			return true
+		}
+		if !seen[n] {
+			seen[n] = true
+			if reaches(n.prerequisites(), target, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withDependencies returns the given nodes plus everything they depend on or
+// follow in file name order, in graph order.
+func withDependencies(all []*hookNode, roots []*hookNode) []*hookNode {
+	need := make(map[*hookNode]bool)
+	var mark func(n *hookNode)
+	mark = func(n *hookNode) {
+		if need[n] {
+			return
+		}
+		need[n] = true
+		for _, dep := range n.prerequisites() {
+			mark(dep)
+		}
+	}
+	for _, n := range roots {
+		mark(n)
+	}
+
+	var out []*hookNode
+	for _, n := range all {
+		if need[n] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// runHookGraph runs every node as soon as the hooks it waits for have
+// finished (see buildHookGraph), each in its own goroutine. Ready hooks start
+// in graph order; a hook whose parallel_group is at its limit waits for a hook
+// of that group to finish. Prerequisites outside nodes must already be done.
+// Hooks whose dependency failed, or that are invalid, are passed to fail
+// instead of run. Returns once every node has been handled.
+func runHookGraph(nodes []*hookNode, run func(n *hookNode) error, fail func(n *hookNode, reason error)) {
+	// A group is limited by the smallest limit any of its hooks sets
+	limits := make(map[string]int)
+	for _, n := range nodes {
+		g, limit := n.config.ParallelGroup, n.config.ParallelLimit
+		if limit > 0 && (limits[g] == 0 || limit < limits[g]) {
+			limits[g] = limit
+		}
+	}
+
+	type result struct {
+		n   *hookNode
+		err error
+	}
+	results := make(chan result)
+	running := make(map[string]int)
+	active := 0
+
+	pending := slices.Clone(nodes)
+	for {
+		// Start or fail every ready hook. Failing one marks it done, which
+		// can make later hooks ready, so repeat until nothing changes.
+		for changed := true; changed; {
+			changed = false
+			var rest []*hookNode
+			for _, n := range pending {
+				if !n.ready() {
+					rest = append(rest, n)
+					continue
+				}
+				if reason := n.blocked(); reason != nil {
+					n.done, n.err = true, reason
+					fail(n, reason)
+					changed = true
+					continue
+				}
+				g := n.config.ParallelGroup
+				if limit := limits[g]; limit > 0 && running[g] >= limit {
+					rest = append(rest, n)
+					continue
+				}
+				running[g]++
+				active++
+				go func() {
+					results <- result{n, run(n)}
+				}()
+			}
+			pending = rest
+		}
+
+		if active == 0 {
+			// Waiting on hooks outside nodes that never ran
+			for _, n := range pending {
+				n.done, n.err = true, fmt.Errorf("dependencies did not run")
+				fail(n, n.err)
+			}
+			return
+		}
+
+		r := <-results
+		active--
+		running[r.n.config.ParallelGroup]--
+		r.n.done, r.n.err = true, r.err
+	}
+}
+
+// ready reports whether all prerequisites of the hook have been handled, or
+// whether it cannot run at all.
+func (n *hookNode) ready() bool {
+	if n.invalid != nil {
+		return true
+	}
+	for _, dep := range n.prerequisites() {
+		if !dep.done {
+			return false
+		}
+	}
+	return true
+}
+
+// blocked returns why a ready hook must not run, or nil.
+func (n *hookNode) blocked() error {
+	if n.invalid != nil {
+		return fmt.Errorf("invalid depends_on: %w", n.invalid)
+	}
+	for _, dep := range n.deps {
+		if dep.failed() {
+			return fmt.Errorf("dependency %q failed", dep.config.Name)
+		}
+	}
+	return nil
+}
+
+// holds reports whether the condition holds, and if not, why.
+func (c hookCondition) holds(workspacePath string) (bool, string) {
+	for _, path := range c.FileExists {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspacePath, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return false, "file " + path + " does not exist"
+		}
+	}
+	for _, cond := range c.Env {
+		key, want, hasValue := strings.Cut(cond, "=")
+		value := os.Getenv(key)
+		if hasValue && value != want {
+			return false, "$" + key + " is not " + want
+		}
+		if !hasValue && value == "" {
+			return false, "$" + key + " is not set"
+		}
+	}
+	return true, ""
+}
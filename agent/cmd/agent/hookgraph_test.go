@@ -0,0 +1,320 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testHookGraph builds a graph from hook file names and configs.
+func testHookGraph(configs map[string]hookConfig, order ...string) []*hookNode {
+	paths := make([]string, len(order))
+	list := make([]hookConfig, len(order))
+	for i, file := range order {
+		paths[i] = "/hooks/" + file
+		list[i] = configs[file]
+		if list[i].Name == "" {
+			list[i].Name = file
+		}
+	}
+	return buildHookGraph(paths, list)
+}
+
+func TestBuildHookGraph(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{
+		"b.sh": {Name: "Build", DependsOn: []string{"a"}},
+		"c.sh": {DependsOn: []string{"Build", "a.sh"}},
+		"d.sh": {DependsOn: []string{"missing"}},
+		"e.sh": {DependsOn: []string{"f"}},
+		"f.sh": {DependsOn: []string{"e"}},
+		"g.sh": {DependsOn: []string{"e"}},
+	}, "a.sh", "b.sh", "c.sh", "d.sh", "e.sh", "f.sh", "g.sh")
+
+	if len(nodes[1].deps) != 1 || nodes[1].deps[0] != nodes[0] {
+		t.Errorf("b.sh deps = %v, want a.sh by ID", nodes[1].deps)
+	}
+	if len(nodes[2].deps) != 2 || nodes[2].deps[0] != nodes[1] || nodes[2].deps[1] != nodes[0] {
+		t.Errorf("c.sh deps should resolve by name and file name")
+	}
+	if nodes[3].invalid == nil || !strings.Contains(nodes[3].invalid.Error(), "unknown dependency") {
+		t.Errorf("d.sh invalid = %v, want unknown dependency", nodes[3].invalid)
+	}
+	for _, n := range nodes[4:6] {
+		if n.invalid == nil || n.invalid.Error() != "dependency cycle" {
+			t.Errorf("%s invalid = %v, want dependency cycle", n.id, n.invalid)
+		}
+	}
+	if nodes[6].invalid != nil {
+		t.Errorf("g.sh depends on a cycle but is not on it: %v", nodes[6].invalid)
+	}
+}
+
+func TestBuildHookGraphFileOrder(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{
+		"a.sh": {DependsOn: []string{"d"}},
+		"c.sh": {ParallelGroup: "deps"},
+		"d.sh": {ParallelGroup: "deps"},
+	}, "a.sh", "b.sh", "c.sh", "d.sh", "e.sh")
+
+	after := func(n *hookNode) string {
+		var ids []string
+		for _, m := range n.after {
+			ids = append(ids, m.id)
+		}
+		return strings.Join(ids, ",")
+	}
+	tests := []struct {
+		node *hookNode
+		want string
+	}{
+		{nodes[0], ""},    // depends_on replaces file name order
+		{nodes[1], "a"},   // the first hook that opted out
+		{nodes[2], "a,b"}, // waits for hooks outside its group only
+		{nodes[3], ""},    // a depends on d and b follows a, so d runs first
+		{nodes[4], "a,b,c,d"},
+	}
+	for _, tt := range tests {
+		if got := after(tt.node); got != tt.want {
+			t.Errorf("%s waits for [%s], want [%s]", tt.node.id, got, tt.want)
+		}
+	}
+}
+
+// hookRecorder runs hooks for runHookGraph tests, recording when each one
+// starts and finishes and how many run at once.
+type hookRecorder struct {
+	mu         sync.Mutex
+	started    map[string]time.Time
+	finished   map[string]time.Time
+	running    int
+	maxRunning int
+	failed     map[string]string
+}
+
+func newHookRecorder() *hookRecorder {
+	return &hookRecorder{started: map[string]time.Time{}, finished: map[string]time.Time{}, failed: map[string]string{}}
+}
+
+// run sleeps for the given duration per hook ID (default 20ms) and fails the
+// hooks listed in failing.
+func (r *hookRecorder) run(durations map[string]time.Duration, failing ...string) func(n *hookNode) error {
+	return func(n *hookNode) error {
+		r.mu.Lock()
+		r.started[n.id] = time.Now()
+		r.running++
+		r.maxRunning = max(r.maxRunning, r.running)
+		r.mu.Unlock()
+
+		d, ok := durations[n.id]
+		if !ok {
+			d = 20 * time.Millisecond
+		}
+		time.Sleep(d)
+
+		r.mu.Lock()
+		r.finished[n.id] = time.Now()
+		r.running--
+		r.mu.Unlock()
+		if slices.Contains(failing, n.id) {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+}
+
+func (r *hookRecorder) fail(n *hookNode, reason error) {
+	r.failed[n.id] = reason.Error()
+}
+
+// startedAfter reports whether hook a started after hook b finished.
+func (r *hookRecorder) startedAfter(a, b string) bool {
+	start, ok := r.started[a]
+	return ok && !start.Before(r.finished[b])
+}
+
+func TestRunHookGraph(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{
+		"10-pnpm.sh":   {ParallelGroup: "deps"},
+		"20-gomod.sh":  {ParallelGroup: "deps"},
+		"30-build.sh":  {DependsOn: []string{"10-pnpm", "20-gomod"}},
+		"05-lint.sh":   {DependsOn: []string{"30-build"}},
+		"40-broken.sh": {},
+		"50-after.sh":  {DependsOn: []string{"40-broken"}},
+		"60-last.sh":   {DependsOn: []string{"50-after"}},
+	}, "05-lint.sh", "10-pnpm.sh", "20-gomod.sh", "30-build.sh", "40-broken.sh", "50-after.sh", "60-last.sh")
+
+	r := newHookRecorder()
+	runHookGraph(nodes, r.run(nil, "40-broken"), r.fail)
+
+	// Only pnpm and go mod download opted in to running together
+	if r.maxRunning != 2 {
+		t.Errorf("max concurrent hooks = %d, want 2", r.maxRunning)
+	}
+	if !r.startedAfter("30-build", "10-pnpm") || !r.startedAfter("30-build", "20-gomod") {
+		t.Errorf("30-build started before its dependencies finished")
+	}
+	if !r.startedAfter("05-lint", "30-build") {
+		t.Errorf("05-lint started before 30-build finished")
+	}
+	// 40-broken sets neither field, so it waits for every earlier hook
+	if !r.startedAfter("40-broken", "05-lint") {
+		t.Errorf("40-broken started before the hooks ahead of it finished")
+	}
+	if len(r.started) != 5 {
+		t.Errorf("ran %d hooks, want 5", len(r.started))
+	}
+	if r.failed["50-after"] != `dependency "40-broken.sh" failed` {
+		t.Errorf("50-after reason = %q", r.failed["50-after"])
+	}
+	if _, ok := r.failed["60-last"]; !ok {
+		t.Errorf("expected the failure to propagate to 60-last, got %v", r.failed)
+	}
+	for _, n := range nodes {
+		if !n.done {
+			t.Errorf("%s not handled", n.id)
+		}
+	}
+}
+
+func TestRunHookGraphDefaultsToFileOrder(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{}, "10-install.sh", "20-setup.sh", "30-check.sh")
+
+	r := newHookRecorder()
+	runHookGraph(nodes, r.run(nil, "10-install"), r.fail)
+
+	if r.maxRunning != 1 {
+		t.Errorf("max concurrent hooks = %d, want 1", r.maxRunning)
+	}
+	// A failed earlier hook only delays later ones, as with sequential runs
+	if !r.startedAfter("20-setup", "10-install") || !r.startedAfter("30-check", "20-setup") {
+		t.Errorf("hooks did not run one after another in file name order")
+	}
+	if len(r.failed) != 0 {
+		t.Errorf("hooks not run: %v", r.failed)
+	}
+}
+
+func TestRunHookGraphOptInHooksOverlap(t *testing.T) {
+	// Two slow hooks in a group, and a short chain beside them
+	nodes := testHookGraph(map[string]hookConfig{
+		"slow1.sh": {ParallelGroup: "setup"},
+		"slow2.sh": {ParallelGroup: "setup"},
+		"b.sh":     {ParallelGroup: "setup"},
+		"c.sh":     {DependsOn: []string{"b"}},
+	}, "slow1.sh", "slow2.sh", "b.sh", "c.sh")
+
+	const slow = 200 * time.Millisecond
+	r := newHookRecorder()
+	start := time.Now()
+	runHookGraph(nodes, r.run(map[string]time.Duration{"slow1": slow, "slow2": slow}), r.fail)
+	elapsed := time.Since(start)
+
+	if elapsed >= 2*slow {
+		t.Errorf("took %v, want the slow hooks to overlap (< %v)", elapsed, 2*slow)
+	}
+	// c waits only for b, not for the slow hooks started alongside it
+	if !r.finished["c"].Before(r.finished["slow1"]) || !r.finished["c"].Before(r.finished["slow2"]) {
+		t.Errorf("c finished after the slow hooks; it should not wait for unrelated hooks")
+	}
+}
+
+func TestRunHookGraphParallelLimit(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{
+		"a.sh": {ParallelGroup: "heavy", ParallelLimit: 1},
+		"b.sh": {ParallelGroup: "heavy"},
+		"c.sh": {ParallelGroup: "heavy"},
+		"d.sh": {ParallelGroup: "light"},
+		"e.sh": {ParallelGroup: "light"},
+	}, "a.sh", "b.sh", "c.sh", "d.sh", "e.sh")
+
+	r := newHookRecorder()
+	runHookGraph(nodes, r.run(nil), r.fail)
+
+	// The unlimited group runs together once the hooks ahead of it are done
+	if r.maxRunning != 2 {
+		t.Errorf("max concurrent hooks = %d, want 2", r.maxRunning)
+	}
+	if !r.startedAfter("b", "a") || !r.startedAfter("c", "b") {
+		t.Errorf("hooks in a group limited to 1 should run one at a time in graph order")
+	}
+	if !r.startedAfter("d", "c") || !r.startedAfter("e", "c") {
+		t.Errorf("the light group started before the heavy group finished")
+	}
+}
+
+func TestRunHookGraphSkippedDependency(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{
+		"b.sh": {DependsOn: []string{"a"}},
+	}, "a.sh", "b.sh")
+
+	var ran []string
+	runHookGraph(nodes, func(n *hookNode) error {
+		if n.id == "a" {
+			n.skipped = true
+			return nil
+		}
+		ran = append(ran, n.id)
+		return nil
+	}, func(n *hookNode, reason error) {
+		t.Errorf("%s not run: %v", n.id, reason)
+	})
+
+	if fmt.Sprint(ran) != "[b]" {
+		t.Errorf("ran = %v, want a skipped dependency to count as satisfied", ran)
+	}
+}
+
+func TestWithDependencies(t *testing.T) {
+	nodes := testHookGraph(map[string]hookConfig{
+		"c.sh": {Blocking: true, DependsOn: []string{"a"}},
+		"d.sh": {Blocking: true},
+	}, "a.sh", "b.sh", "c.sh", "d.sh")
+
+	got := withDependencies(nodes, []*hookNode{nodes[2]})
+	if len(got) != 2 || got[0] != nodes[0] || got[1] != nodes[2] {
+		t.Errorf("withDependencies = %v, want a.sh and c.sh", got)
+	}
+	// Without depends_on a hook follows every hook ahead of it
+	if got := withDependencies(nodes, []*hookNode{nodes[3]}); len(got) != 4 {
+		t.Errorf("withDependencies = %v, want all hooks", got)
+	}
+}
+
+func TestHookConditionHolds(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOOK_TEST_MODE", "ci")
+
+	tests := []struct {
+		name string
+		cond hookCondition
+		want bool
+	}{
+		{"empty", hookCondition{}, true},
+		{"file exists", hookCondition{FileExists: []string{"go.mod"}}, true},
+		{"file missing", hookCondition{FileExists: []string{"go.mod", "package.json"}}, false},
+		{"env set", hookCondition{Env: []string{"HOOK_TEST_MODE"}}, true},
+		{"env unset", hookCondition{Env: []string{"HOOK_TEST_UNSET"}}, false},
+		{"env value", hookCondition{Env: []string{"HOOK_TEST_MODE=ci"}}, true},
+		{"env other value", hookCondition{Env: []string{"HOOK_TEST_MODE=dev"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := tt.cond.holds(dir)
+			if ok != tt.want {
+				t.Errorf("holds = %v (%s), want %v", ok, reason, tt.want)
+			}
+			if !ok && reason == "" {
+				t.Error("expected a reason when the condition does not hold")
+			}
+		})
+	}
+}
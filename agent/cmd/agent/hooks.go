@@ -7,30 +7,65 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 const (
 	// hooksDir is the directory within the workspace containing hook files
 	hooksDir = ".discobot/hooks"
 
-	// sessionHookTimeout is the maximum execution time per session hook
+	// sessionHookTimeout is the default maximum execution time per session hook attempt
 	sessionHookTimeout = 5 * time.Minute
+
+	// shutdownHooksTimeout bounds all shutdown hooks together, so a stopping
+	// sandbox is not held up. Matches sandbox.ShutdownHooksTimeout in the server.
+	shutdownHooksTimeout = 30 * time.Second
 )
 
+// hookRetryDelay is the pause before retrying a failed session hook.
+var hookRetryDelay = 2 * time.Second
+
 // hookConfig represents parsed hook front matter
 type hookConfig struct {
-	Name     string // Display name
-	Type     string // "session", "file", "pre-commit"
-	RunAs    string // "root" or "user" (default: "user")
-	Blocking bool   // If true, session hook blocks agent startup (default: false)
+	Name          string            // Display name
+	Type          string            // "session", "shutdown", "file", "pre-commit"
+	RunAs         string            // "root" or "user" (default: "user")
+	Blocking      bool              // If true, session hook blocks agent startup (default: false)
+	Timeout       time.Duration     // Per-attempt timeout (default: sessionHookTimeout)
+	Retries       int               // Extra attempts after a failure (default: 0)
+	DependsOn     []string          // Hook IDs or names that must succeed first
+	ParallelGroup string            // Hooks of a group run concurrently instead of in filename order
+	ParallelLimit int               // Max hooks of ParallelGroup running at once (0: no limit)
+	Env           map[string]string // Extra environment variables
+	When          hookCondition     // Run only if the condition holds
+}
+
+// hookCondition gates a session hook on the state of the sandbox. All listed
+// conditions must hold; an empty condition always holds.
+type hookCondition struct {
+	FileExists []string // Paths (relative to the workspace) that must exist
+	Env        []string // "VAR" (set and non-empty) or "VAR=value"
+}
+
+// hookAttempt is the result of one attempt of a session hook run.
+// Schema matches the TypeScript HookAttempt in agent-api/src/hooks/status.ts.
+type hookAttempt struct {
+	StartedAt  string `json:"startedAt"`
+	DurationMs int64  `json:"durationMs"`
+	ExitCode   int    `json:"exitCode"`
+	Result     string `json:"result"` // "success" or "failure"
 }
 
 // hookRunStatus represents the persisted status of a single hook's runs.
@@ -46,6 +81,8 @@ type hookRunStatus struct {
 	RunCount            int    `json:"runCount"`
 	FailCount           int    `json:"failCount"`
 	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	// Attempts of the last run (one per retry)
+	Attempts []hookAttempt `json:"attempts,omitempty"`
 }
 
 // hookStatusFile represents the top-level status file schema.
@@ -147,8 +184,21 @@ func saveHookStatus(dataDir string, status hookStatusFile) error {
 	return os.Rename(tmpPath, filePath)
 }
 
+// hookStatusMu serializes status.json updates from concurrently running hooks.
+var hookStatusMu sync.Mutex
+
 // updateSessionHookStatus updates the status for a session hook after execution.
-func updateSessionHookStatus(dataDir, hookID, hookName string, success bool, exitCode int, outputPath string) {
+// attempts lists the attempts of this run; it is nil for a hook that never ran.
+func updateSessionHookStatus(dataDir, hookID, hookName string, success bool, exitCode int, outputPath string, attempts []hookAttempt) {
+	updateHookRunStatus(dataDir, "session", hookID, hookName, success, exitCode, outputPath, attempts)
+}
+
+// updateHookRunStatus updates the status for a hook run by the agent
+// (session or shutdown) after execution.
+func updateHookRunStatus(dataDir, hookType, hookID, hookName string, success bool, exitCode int, outputPath string, attempts []hookAttempt) {
+	hookStatusMu.Lock()
+	defer hookStatusMu.Unlock()
+
 	status := loadHookStatus(dataDir)
 
 	existing, exists := status.Hooks[hookID]
@@ -177,7 +227,7 @@ func updateSessionHookStatus(dataDir, hookID, hookName string, success bool, exi
 	status.Hooks[hookID] = hookRunStatus{
 		HookID:              hookID,
 		HookName:            hookName,
-		Type:                "session",
+		Type:                hookType,
 		LastRunAt:           time.Now().UTC().Format(time.RFC3339Nano),
 		LastResult:          resultStr,
 		LastExitCode:        exitCode,
@@ -185,6 +235,7 @@ func updateSessionHookStatus(dataDir, hookID, hookName string, success bool, exi
 		RunCount:            runCount,
 		FailCount:           failCount,
 		ConsecutiveFailures: consecutiveFailures,
+		Attempts:            attempts,
 	}
 
 	if err := saveHookStatus(dataDir, status); err != nil {
@@ -194,13 +245,32 @@ func updateSessionHookStatus(dataDir, hookID, hookName string, success bool, exi
 
 // parseHookFrontMatter extracts hook configuration from file content.
 // Supports the same #--- delimited YAML front matter as the TypeScript services parser.
+// Front matter that is not valid YAML falls back to simple key: value parsing
+// of the basic fields, which is how hooks were parsed before.
 func parseHookFrontMatter(content string) hookConfig {
-	config := hookConfig{}
-	lines := strings.Split(content, "\n")
+	yamlLines, ok := extractFrontMatter(content)
+	if !ok {
+		return hookConfig{}
+	}
+
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(strings.Join(dedentLines(yamlLines), "\n")), &fields); err != nil {
+		return parseSimpleHookFields(yamlLines)
+	}
 
-	if len(lines) == 0 {
-		return config
+	config := hookConfig{}
+	for key, value := range fields {
+		if err := config.setField(key, value); err != nil {
+			fmt.Fprintf(os.Stderr, "discobot-agent: ignoring hook field %q: %v\n", key, err)
+		}
 	}
+	return config
+}
+
+// extractFrontMatter returns the lines between the front matter delimiters,
+// with the comment prefix removed and indentation preserved.
+func extractFrontMatter(content string) ([]string, bool) {
+	lines := strings.Split(content, "\n")
 
 	// Determine where front matter starts (skip shebang)
 	startLine := 0
@@ -209,7 +279,7 @@ func parseHookFrontMatter(content string) hookConfig {
 	}
 
 	if startLine >= len(lines) {
-		return config
+		return nil, false
 	}
 
 	// Detect delimiter — only support #--- for shell scripts (most common for hooks)
@@ -228,32 +298,54 @@ func parseHookFrontMatter(content string) hookConfig {
 		delimiter = "//---"
 		prefix = "//"
 	default:
-		return config // No front matter
+		return nil, false // No front matter
 	}
 
 	// Extract YAML lines between delimiters
 	var yamlLines []string
-	found := false
 	for i := startLine + 1; i < len(lines); i++ {
 		if strings.TrimSpace(lines[i]) == delimiter {
-			found = true
-			break
+			return yamlLines, true
 		}
-		line := lines[i]
+		line := strings.TrimRight(lines[i], " \t\r")
 		if prefix != "" {
-			idx := strings.Index(line, prefix)
-			if idx != -1 {
-				line = strings.TrimSpace(line[idx+len(prefix):])
+			if idx := strings.Index(line, prefix); idx != -1 {
+				line = line[idx+len(prefix):]
 			}
 		}
 		yamlLines = append(yamlLines, line)
 	}
 
-	if !found {
-		return config // No closing delimiter
+	return nil, false // No closing delimiter
+}
+
+// dedentLines removes the indentation common to all non-blank lines, so
+// "# key: value" comment lines become valid YAML while nested keys keep
+// their relative indentation. Tabs are expanded since YAML forbids them.
+func dedentLines(lines []string) []string {
+	out := make([]string, len(lines))
+	indent := -1
+	for i, line := range lines {
+		out[i] = strings.ReplaceAll(line, "\t", "  ")
+		if strings.TrimSpace(out[i]) == "" {
+			continue
+		}
+		n := len(out[i]) - len(strings.TrimLeft(out[i], " "))
+		if indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range out {
+		if len(line) >= indent && indent > 0 {
+			out[i] = line[indent:]
+		}
 	}
+	return out
+}
 
-	// Parse simple key: value pairs
+// parseSimpleHookFields parses simple key: value pairs for the basic fields.
+func parseSimpleHookFields(yamlLines []string) hookConfig {
+	config := hookConfig{}
 	for _, line := range yamlLines {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -289,8 +381,129 @@ func parseHookFrontMatter(content string) hookConfig {
 	return config
 }
 
+// setField applies one front matter field. Unknown keys are ignored, since
+// the same front matter also carries fields for other hook types.
+func (c *hookConfig) setField(key string, value any) error {
+	var err error
+	switch key {
+	case "name":
+		c.Name = yamlScalar(value)
+	case "type":
+		c.Type = yamlScalar(value)
+	case "run_as":
+		c.RunAs = yamlScalar(value)
+	case "blocking":
+		c.Blocking = strings.EqualFold(yamlScalar(value), "true")
+	case "timeout":
+		c.Timeout, err = parseHookTimeout(value)
+	case "retries":
+		c.Retries, err = strconv.Atoi(yamlScalar(value))
+		if err == nil && c.Retries < 0 {
+			c.Retries, err = 0, fmt.Errorf("must not be negative")
+		}
+	case "depends_on":
+		c.DependsOn, err = yamlStringList(value)
+	case "parallel_group":
+		c.ParallelGroup, c.ParallelLimit, err = parseParallelGroup(value)
+	case "env":
+		m, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("must be a map")
+		}
+		c.Env = make(map[string]string, len(m))
+		for k, v := range m {
+			c.Env[k] = yamlScalar(v)
+		}
+	case "when":
+		m, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("must be a map")
+		}
+		for k, v := range m {
+			switch k {
+			case "file_exists":
+				c.When.FileExists, err = yamlStringList(v)
+			case "env":
+				c.When.Env, err = yamlStringList(v)
+			default:
+				err = fmt.Errorf("unknown condition %q", k)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// parseParallelGroup accepts a group name, optionally followed by the number
+// of hooks in the group that may run at once ("deps:2").
+func parseParallelGroup(value any) (string, int, error) {
+	s := yamlScalar(value)
+	group, limit, hasLimit := strings.Cut(s, ":")
+	if !hasLimit {
+		return s, 0, nil
+	}
+	if group == "" {
+		return "", 0, fmt.Errorf("missing group name")
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return "", 0, fmt.Errorf("invalid limit %q", limit)
+	}
+	return group, n, nil
+}
+
+// parseHookTimeout accepts a Go duration ("90s", "10m") or a number of seconds.
+func parseHookTimeout(value any) (time.Duration, error) {
+	s := yamlScalar(value)
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(secs) * time.Second
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
+
+// yamlScalar formats a decoded YAML scalar as a string.
+func yamlScalar(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// yamlStringList accepts a single string or a list of strings.
+func yamlStringList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			list = append(list, yamlScalar(item))
+		}
+		return list, nil
+	case map[string]any:
+		return nil, fmt.Errorf("must be a string or a list")
+	default:
+		return []string{yamlScalar(v)}, nil
+	}
+}
+
 // discoverSessionHooks scans the hooks directory and returns session hooks sorted by filename.
 func discoverSessionHooks(workspacePath string) ([]string, []hookConfig) {
+	return discoverHooks(workspacePath, "session")
+}
+
+// discoverHooks scans the hooks directory and returns hooks of the given type sorted by filename.
+func discoverHooks(workspacePath, hookType string) ([]string, []hookConfig) {
 	dir := filepath.Join(workspacePath, hooksDir)
 
 	entries, err := os.ReadDir(dir)
@@ -332,7 +545,7 @@ func discoverSessionHooks(workspacePath string) ([]string, []hookConfig) {
 		}
 
 		config := parseHookFrontMatter(contentStr)
-		if config.Type != "session" {
+		if config.Type != hookType {
 			continue
 		}
 
@@ -366,25 +579,90 @@ func discoverSessionHooks(workspacePath string) ([]string, []hookConfig) {
 	return paths, configs
 }
 
-// runSessionHook executes a single session hook, captures output, and updates status.json.
-// Returns true if the hook succeeded, false otherwise.
-func runSessionHook(hookPath string, config hookConfig, workspacePath, sessionID, dataDir string, u *userInfo) bool {
+// runSessionHook executes a single session (or shutdown) hook, captures output, and updates status.json.
+// A failed attempt is retried up to config.Retries times; each attempt gets
+// config.Timeout (default sessionHookTimeout).
+// Returns nil if the hook succeeded, or an error describing the last failure.
+func runSessionHook(hookPath string, config hookConfig, workspacePath, sessionID, dataDir string, u *userInfo) error {
 	name := config.Name
 	hookID := normalizeHookID(filepath.Base(hookPath))
+	if config.Type == "" {
+		config.Type = "session"
+	}
 
 	runAs := config.RunAs
 	if runAs == "" {
 		runAs = "user"
 	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = sessionHookTimeout
+	}
 
-	fmt.Printf("discobot-agent: running session hook %q (run_as: %s)\n", name, runAs)
+	fmt.Printf("discobot-agent: running %s hook %q (run_as: %s)\n", config.Type, name, runAs)
 
-	ctx, cancel := context.WithTimeout(context.Background(), sessionHookTimeout)
+	// Capture output of all attempts for status tracking while streaming to stdout/stderr
+	var outputBuf bytes.Buffer
+	var attempts []hookAttempt
+	var hookErr error
+	exitCode := 0
+	for attempt := 0; attempt <= config.Retries; attempt++ {
+		if attempt > 0 {
+			fmt.Printf("discobot-agent: retrying %s hook %q (attempt %d of %d)\n", config.Type, name, attempt+1, config.Retries+1)
+			fmt.Fprintf(&outputBuf, "\n--- attempt %d of %d ---\n", attempt+1, config.Retries+1)
+			time.Sleep(hookRetryDelay)
+		}
+
+		startTime := time.Now()
+		exitCode, hookErr = runSessionHookAttempt(hookPath, config, runAs, timeout, workspacePath, sessionID, u, &outputBuf)
+		duration := time.Since(startTime)
+
+		result := "success"
+		if hookErr != nil {
+			result = "failure"
+			fmt.Fprintf(os.Stderr, "discobot-agent: %s hook %q failed (%.1fs): %v\n", config.Type, name, duration.Seconds(), hookErr)
+		} else {
+			fmt.Printf("discobot-agent: %s hook %q completed (%.1fs)\n", config.Type, name, duration.Seconds())
+		}
+		attempts = append(attempts, hookAttempt{
+			StartedAt:  startTime.UTC().Format(time.RFC3339Nano),
+			DurationMs: duration.Milliseconds(),
+			ExitCode:   exitCode,
+			Result:     result,
+		})
+		if hookErr == nil {
+			break
+		}
+	}
+
+	// Save output to log file
+	outPath := hookOutputPath(dataDir, hookID)
+	if err := os.WriteFile(outPath, outputBuf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "discobot-agent: failed to save hook output: %v\n", err)
+	} else {
+		_ = os.Chown(outPath, u.uid, u.gid)
+	}
+
+	// Update status.json
+	updateHookRunStatus(dataDir, config.Type, hookID, name, hookErr == nil, exitCode, outPath, attempts)
+	// Chown status file so agent-api can update it later
+	_ = os.Chown(filepath.Join(dataDir, "status.json"), u.uid, u.gid)
+
+	return hookErr
+}
+
+// runSessionHookAttempt runs a session hook once, appending its output to
+// output. Returns the exit code (124 on timeout) and an error on failure.
+func runSessionHookAttempt(hookPath string, config hookConfig, runAs string, timeout time.Duration, workspacePath, sessionID string, u *userInfo, output *bytes.Buffer) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, hookPath)
 	cmd.Dir = workspacePath
-	cmd.Env = buildHookEnv(u, sessionID, workspacePath)
+	cmd.Env = buildHookEnv(u, config.Type, sessionID, workspacePath)
+	for _, key := range slices.Sorted(maps.Keys(config.Env)) {
+		cmd.Env = append(cmd.Env, key+"="+config.Env[key])
+	}
 
 	// Run as root or discobot user
 	if runAs == "user" {
@@ -398,117 +676,104 @@ func runSessionHook(hookPath string, config hookConfig, workspacePath, sessionID
 	}
 	// run_as: root — no credential switching needed (already running as root)
 
-	// Capture output for status tracking while streaming to stdout/stderr
-	var outputBuf bytes.Buffer
-	cmd.Stdout = io.MultiWriter(&outputBuf, &prefixWriter{prefix: fmt.Sprintf("  [%s] ", name), w: os.Stdout})
-	cmd.Stderr = io.MultiWriter(&outputBuf, &prefixWriter{prefix: fmt.Sprintf("  [%s] ", name), w: os.Stderr})
+	cmd.Stdout = io.MultiWriter(output, &prefixWriter{prefix: fmt.Sprintf("  [%s] ", config.Name), w: os.Stdout})
+	cmd.Stderr = io.MultiWriter(output, &prefixWriter{prefix: fmt.Sprintf("  [%s] ", config.Name), w: os.Stderr})
 
-	startTime := time.Now()
 	runErr := cmd.Run()
-	duration := time.Since(startTime)
-
-	// Determine exit code
-	exitCode := 0
-	hookSuccess := true
-	if runErr != nil {
-		hookSuccess = false
-		if ctx.Err() == context.DeadlineExceeded {
-			exitCode = 124
-			fmt.Fprintf(os.Stderr, "discobot-agent: session hook %q timed out after %s\n", name, sessionHookTimeout)
-		} else if exitErr, ok := runErr.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-			fmt.Fprintf(os.Stderr, "discobot-agent: session hook %q failed (%.1fs): %v\n", name, duration.Seconds(), runErr)
-		} else {
-			exitCode = 1
-			fmt.Fprintf(os.Stderr, "discobot-agent: session hook %q failed (%.1fs): %v\n", name, duration.Seconds(), runErr)
-		}
-	} else {
-		fmt.Printf("discobot-agent: session hook %q completed (%.1fs)\n", name, duration.Seconds())
+	if runErr == nil {
+		return 0, nil
 	}
-
-	// Save output to log file
-	outPath := hookOutputPath(dataDir, hookID)
-	if err := os.WriteFile(outPath, outputBuf.Bytes(), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "discobot-agent: failed to save hook output: %v\n", err)
-	} else {
-		_ = os.Chown(outPath, u.uid, u.gid)
+	if ctx.Err() == context.DeadlineExceeded {
+		return 124, fmt.Errorf("timed out after %s", timeout)
 	}
-
-	// Update status.json
-	updateSessionHookStatus(dataDir, hookID, name, hookSuccess, exitCode, outPath)
-	// Chown status file so agent-api can update it later
-	_ = os.Chown(filepath.Join(dataDir, "status.json"), u.uid, u.gid)
-
-	return hookSuccess
+	if exitErr, ok := runErr.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), runErr
+	}
+	return 1, runErr
 }
 
-// runSessionHooks discovers and executes session hooks from .discobot/hooks/.
-// Hooks with type: session run at container startup.
-// By default, hooks are non-blocking: they run in a background goroutine sequentially
-// but do not block the agent from starting. Hooks with blocking: true in their front
-// matter run synchronously before the agent starts.
+// runSessionHooks discovers and executes session hooks from .discobot/hooks/,
+// plus the lifecycle commands of the workspace's devcontainer.json (see
+// devcontainerHooks). Hooks with type: session run at container startup.
+// By default, hooks are non-blocking: they run in a background goroutine
+// and do not block the agent from starting. Hooks with blocking: true in their front
+// matter run synchronously before the agent starts, along with the hooks they
+// depend on. Hooks run one after another in filename order unless they opt in
+// to concurrency with depends_on or parallel_group (see buildHookGraph and
+// runHookGraph); hooks whose when condition does not hold are skipped.
 // Failures are logged and persisted to ~/.discobot/{sessionId}/hooks/status.json.
+// Each hook is also recorded as a "session hook: {name}" step in the startup
+// timeline, which may be nil.
 //
 // Returns a wait function that blocks until all background (non-blocking) hooks
 // have completed. Callers that exit shortly after (e.g. oneshot systemd services)
 // must call the returned function to avoid killing in-flight hooks.
-func runSessionHooks(workspacePath string, u *userInfo) func() {
+func runSessionHooks(workspacePath string, u *userInfo, timeline *startupTimeline) func() {
 	noop := func() {}
 
-	paths, configs := discoverSessionHooks(workspacePath)
+	sessionID := os.Getenv("SESSION_ID")
+	dataDir := hooksDataDir(u.homeDir, sessionID)
+
+	// devcontainer.json lifecycle commands run ahead of the workspace's own hooks
+	paths, configs := devcontainerHooks(loadDevcontainerSpec(), dataDir, u)
+	devcontainerCount := len(paths)
+	hookPaths, hookConfigs := discoverSessionHooks(workspacePath)
+	paths = append(paths, hookPaths...)
+	configs = append(configs, hookConfigs...)
 	if len(paths) == 0 {
 		return noop
 	}
 
 	fmt.Printf("discobot-agent: found %d session hook(s)\n", len(paths))
+	ensureHooksDataDir(dataDir, sessionID, u)
+
+	// Blocking hooks and everything they depend on gate startup; the rest
+	// run in the background. Both keep filename order.
+	nodes := buildHookGraph(paths, configs)
+	var blockingRoots []*hookNode
+	for _, n := range nodes {
+		if n.config.Blocking {
+			blockingRoots = append(blockingRoots, n)
+		}
+	}
+	blockingHooks := withDependencies(nodes, blockingRoots)
+	var backgroundHooks []*hookNode
+	for _, n := range nodes {
+		if !slices.Contains(blockingHooks, n) {
+			backgroundHooks = append(backgroundHooks, n)
+		}
+	}
 
-	sessionID := os.Getenv("SESSION_ID")
-	dataDir := hooksDataDir(u.homeDir, sessionID)
-
-	// Ensure hooks data dir and output dir exist, owned by the discobot user
-	// so the agent-api (which runs as discobot) can also write to it later.
-	outputDir := filepath.Join(dataDir, "output")
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "discobot-agent: failed to create hooks data dir: %v\n", err)
-	} else {
-		// Chown the entire tree to discobot user
-		for _, dir := range []string{
-			filepath.Join(u.homeDir, ".discobot"),
-			filepath.Join(u.homeDir, ".discobot", sessionID),
-			dataDir,
-			outputDir,
-		} {
-			_ = os.Chown(dir, u.uid, u.gid)
-		}
-	}
-
-	// Separate blocking and non-blocking hooks (preserving filename order within each group)
-	type hookEntry struct {
-		path   string
-		config hookConfig
-	}
-	var blockingHooks, backgroundHooks []hookEntry
-	for i, hookPath := range paths {
-		entry := hookEntry{path: hookPath, config: configs[i]}
-		if configs[i].Blocking {
-			blockingHooks = append(blockingHooks, entry)
-		} else {
-			backgroundHooks = append(backgroundHooks, entry)
+	run := func(n *hookNode) error {
+		if ok, reason := n.config.When.holds(workspacePath); !ok {
+			fmt.Printf("discobot-agent: skipping session hook %q: %s\n", n.config.Name, reason)
+			n.skipped = true
+			return nil
+		}
+		step := timeline.begin("session hook: " + n.config.Name)
+		err := runSessionHook(n.path, n.config, workspacePath, sessionID, dataDir, u)
+		step.end(err)
+		return err
+	}
+	fail := func(n *hookNode, reason error) {
+		recordHookNotRun(dataDir, "session", n.id, n.config.Name, reason, u)
+	}
+	// Devcontainer commands come first in nodes and finish before any
+	// workspace hook of the same phase starts.
+	runPhase := func(hooks []*hookNode) {
+		split := 0
+		for split < len(hooks) && slices.Index(nodes, hooks[split]) < devcontainerCount {
+			split++
 		}
+		runHookGraph(hooks[:split], run, fail)
+		runHookGraph(hooks[split:], run, fail)
 	}
 
 	// Phase 1: Run blocking hooks synchronously — these gate startup
 	if len(blockingHooks) > 0 {
 		fmt.Printf("discobot-agent: running %d blocking session hook(s)\n", len(blockingHooks))
-		succeeded, failed := 0, 0
-		for _, h := range blockingHooks {
-			if runSessionHook(h.path, h.config, workspacePath, sessionID, dataDir, u) {
-				succeeded++
-			} else {
-				failed++
-			}
-		}
-		fmt.Printf("discobot-agent: blocking session hooks completed (%d succeeded, %d failed)\n", succeeded, failed)
+		runPhase(blockingHooks)
+		fmt.Printf("discobot-agent: blocking session hooks completed (%s)\n", summarizeHookRuns(blockingHooks))
 	}
 
 	// Phase 2: Launch non-blocking hooks in a background goroutine
@@ -521,25 +786,112 @@ func runSessionHooks(workspacePath string, u *userInfo) func() {
 	fmt.Printf("discobot-agent: launching %d non-blocking session hook(s) in background\n", len(backgroundHooks))
 	go func() {
 		defer wg.Done()
-		succeeded, failed := 0, 0
-		for _, h := range backgroundHooks {
-			if runSessionHook(h.path, h.config, workspacePath, sessionID, dataDir, u) {
-				succeeded++
-			} else {
-				failed++
-			}
-		}
-		fmt.Printf("discobot-agent: background session hooks completed (%d succeeded, %d failed)\n", succeeded, failed)
+		runPhase(backgroundHooks)
+		fmt.Printf("discobot-agent: background session hooks completed (%s)\n", summarizeHookRuns(backgroundHooks))
 	}()
 
 	return wg.Wait
 }
 
-// buildHookEnv creates the environment for session hooks.
-func buildHookEnv(u *userInfo, sessionID, workspacePath string) []string {
+// runShutdownHooks runs hooks with type: shutdown, in filename order, when
+// the sandbox is stopping and before the agent API and Docker daemon are torn
+// down. All hooks share shutdownHooksTimeout: each gets its own timeout capped
+// to what remains, and hooks left without time are recorded as not run.
+// Retries and the scheduling fields (blocking, depends_on, parallel_group) do
+// not apply. Results are persisted to status.json like session hooks, so they
+// show up in hook status on the next start.
+func runShutdownHooks(workspacePath string, u *userInfo) {
+	paths, configs := discoverHooks(workspacePath, "shutdown")
+	if len(paths) == 0 {
+		return
+	}
+
+	sessionID := os.Getenv("SESSION_ID")
+	dataDir := hooksDataDir(u.homeDir, sessionID)
+	ensureHooksDataDir(dataDir, sessionID, u)
+
+	fmt.Printf("discobot-agent: running %d shutdown hook(s)\n", len(paths))
+	deadline := time.Now().Add(shutdownHooksTimeout)
+	failed := 0
+	for i, path := range paths {
+		config := configs[i]
+		hookID := normalizeHookID(filepath.Base(path))
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			recordHookNotRun(dataDir, config.Type, hookID, config.Name, fmt.Errorf("shutdown hooks timed out after %s", shutdownHooksTimeout), u)
+			failed++
+			continue
+		}
+		if ok, reason := config.When.holds(workspacePath); !ok {
+			fmt.Printf("discobot-agent: skipping shutdown hook %q: %s\n", config.Name, reason)
+			continue
+		}
+
+		config.Retries = 0
+		if config.Timeout == 0 || config.Timeout > remaining {
+			config.Timeout = remaining
+		}
+		if err := runSessionHook(path, config, workspacePath, sessionID, dataDir, u); err != nil {
+			failed++
+		}
+	}
+	fmt.Printf("discobot-agent: shutdown hooks completed (%d failed)\n", failed)
+}
+
+// ensureHooksDataDir creates the hooks data and output directories, owned by
+// the discobot user so the agent-api (which runs as discobot) can also write
+// to them later.
+func ensureHooksDataDir(dataDir, sessionID string, u *userInfo) {
+	outputDir := filepath.Join(dataDir, "output")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "discobot-agent: failed to create hooks data dir: %v\n", err)
+		return
+	}
+	// Chown the entire tree to discobot user
+	for _, dir := range []string{
+		filepath.Join(u.homeDir, ".discobot"),
+		filepath.Join(u.homeDir, ".discobot", sessionID),
+		dataDir,
+		outputDir,
+	} {
+		_ = os.Chown(dir, u.uid, u.gid)
+	}
+}
+
+// recordHookNotRun records a hook that was not run as failed, with the reason
+// as its output.
+func recordHookNotRun(dataDir, hookType, hookID, hookName string, reason error, u *userInfo) {
+	fmt.Fprintf(os.Stderr, "discobot-agent: %s hook %q not run: %v\n", hookType, hookName, reason)
+	outPath := hookOutputPath(dataDir, hookID)
+	if err := os.WriteFile(outPath, []byte("Not run: "+reason.Error()+"\n"), 0644); err == nil {
+		_ = os.Chown(outPath, u.uid, u.gid)
+	}
+	updateHookRunStatus(dataDir, hookType, hookID, hookName, false, -1, outPath, nil)
+	_ = os.Chown(filepath.Join(dataDir, "status.json"), u.uid, u.gid)
+}
+
+// summarizeHookRuns counts the outcomes of handled hooks for logging.
+func summarizeHookRuns(nodes []*hookNode) string {
+	succeeded, failed, skipped := 0, 0, 0
+	for _, n := range nodes {
+		switch {
+		case n.err != nil:
+			failed++
+		case n.skipped:
+			skipped++
+		default:
+			succeeded++
+		}
+	}
+	return fmt.Sprintf("%d succeeded, %d failed, %d skipped", succeeded, failed, skipped)
+}
+
+// buildHookEnv creates the environment for session and shutdown hooks.
+func buildHookEnv(u *userInfo, hookType, sessionID, workspacePath string) []string {
 	env := os.Environ()
 	env = append(env,
-		"DISCOBOT_HOOK_TYPE=session",
+		"DISCOBOT_HOOK_TYPE="+hookType,
 		"DISCOBOT_SESSION_ID="+sessionID,
 		"DISCOBOT_WORKSPACE="+workspacePath,
 		"HOME="+u.homeDir,
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseHookFrontMatter(t *testing.T) {
@@ -188,6 +191,183 @@ echo hello`,
 	}
 }
 
+func TestParseHookFrontMatterEngineFields(t *testing.T) {
+	config := parseHookFrontMatter(`#!/bin/bash
+#---
+# name: Build
+# type: session
+# blocking: true
+# timeout: 10m
+# retries: 2
+# depends_on: [install-deps, "Go modules"]
+# parallel_group: build
+# env:
+#   NODE_ENV: production
+#   JOBS: 4
+# when:
+#   file_exists: package.json
+#   env:
+#     - CI
+#     - MODE=full
+#---
+pnpm build`)
+
+	if config.Name != "Build" || config.Type != "session" || !config.Blocking {
+		t.Errorf("basic fields: got %+v", config)
+	}
+	if config.Timeout != 10*time.Minute {
+		t.Errorf("Timeout: got %v, want 10m", config.Timeout)
+	}
+	if config.Retries != 2 {
+		t.Errorf("Retries: got %d, want 2", config.Retries)
+	}
+	if !slices.Equal(config.DependsOn, []string{"install-deps", "Go modules"}) {
+		t.Errorf("DependsOn: got %q", config.DependsOn)
+	}
+	if config.ParallelGroup != "build" {
+		t.Errorf("ParallelGroup: got %q", config.ParallelGroup)
+	}
+	if config.Env["NODE_ENV"] != "production" || config.Env["JOBS"] != "4" {
+		t.Errorf("Env: got %v", config.Env)
+	}
+	if !slices.Equal(config.When.FileExists, []string{"package.json"}) || !slices.Equal(config.When.Env, []string{"CI", "MODE=full"}) {
+		t.Errorf("When: got %+v", config.When)
+	}
+
+	t.Run("timeout in seconds", func(t *testing.T) {
+		config := parseHookFrontMatter("#---\n# timeout: 90\n#---\n")
+		if config.Timeout != 90*time.Second {
+			t.Errorf("Timeout: got %v, want 90s", config.Timeout)
+		}
+	})
+
+	t.Run("parallel group limit", func(t *testing.T) {
+		config := parseHookFrontMatter("#---\n# parallel_group: deps:2\n#---\n")
+		if config.ParallelGroup != "deps" || config.ParallelLimit != 2 {
+			t.Errorf("got group %q limit %d, want deps and 2", config.ParallelGroup, config.ParallelLimit)
+		}
+	})
+
+	t.Run("invalid values are ignored", func(t *testing.T) {
+		config := parseHookFrontMatter("#---\n# name: Bad\n# timeout: soon\n# retries: -1\n# parallel_group: deps:0\n#---\n")
+		if config.Name != "Bad" || config.Timeout != 0 || config.Retries != 0 || config.ParallelGroup != "" {
+			t.Errorf("got %+v", config)
+		}
+	})
+
+	t.Run("invalid YAML falls back to simple fields", func(t *testing.T) {
+		config := parseHookFrontMatter("#---\n# name: Deploy: prod\n# type: session\n# blocking: true\n#---\n")
+		if config.Name != "Deploy: prod" || config.Type != "session" || !config.Blocking {
+			t.Errorf("got %+v", config)
+		}
+	})
+}
+
+func TestRunSessionHookRetries(t *testing.T) {
+	defer func(d time.Duration) { hookRetryDelay = d }(hookRetryDelay)
+	hookRetryDelay = 0
+
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	if err := os.MkdirAll(filepath.Join(dataDir, "output"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	u := &userInfo{uid: os.Getuid(), gid: os.Getgid(), homeDir: dir}
+
+	// Fails on the first two attempts, then succeeds
+	hookPath := filepath.Join(dir, "flaky.sh")
+	script := "#!/bin/sh\nn=$(cat count 2>/dev/null || echo 0)\nn=$((n+1))\necho $n > count\necho \"run $n $GREETING\"\n[ $n -ge 3 ]\n"
+	if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config := hookConfig{Name: "Flaky", RunAs: "root", Retries: 2, Env: map[string]string{"GREETING": "hello"}}
+	if err := runSessionHook(hookPath, config, dir, "s1", dataDir, u); err != nil {
+		t.Fatalf("expected the hook to succeed on the last attempt: %v", err)
+	}
+
+	hs := loadHookStatus(dataDir).Hooks["flaky"]
+	if hs.LastResult != "success" || hs.RunCount != 1 {
+		t.Errorf("status: got %+v", hs)
+	}
+	if len(hs.Attempts) != 3 {
+		t.Fatalf("attempts: got %d, want 3", len(hs.Attempts))
+	}
+	for i, want := range []string{"failure", "failure", "success"} {
+		if hs.Attempts[i].Result != want {
+			t.Errorf("attempt %d: got %q, want %q", i+1, hs.Attempts[i].Result, want)
+		}
+	}
+	output, err := os.ReadFile(hookOutputPath(dataDir, "flaky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "run 3 hello") || !strings.Contains(string(output), "--- attempt 3 of 3 ---") {
+		t.Errorf("output: got %q", output)
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		hookPath := filepath.Join(dir, "slow.sh")
+		if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		config := hookConfig{Name: "Slow", RunAs: "root", Timeout: 100 * time.Millisecond}
+		if err := runSessionHook(hookPath, config, dir, "s1", dataDir, u); err == nil {
+			t.Fatal("expected the hook to time out")
+		}
+		hs := loadHookStatus(dataDir).Hooks["slow"]
+		if hs.LastResult != "failure" || hs.LastExitCode != 124 || len(hs.Attempts) != 1 {
+			t.Errorf("status: got %+v", hs)
+		}
+	})
+}
+
+func TestRunShutdownHooks(t *testing.T) {
+	dir := t.TempDir()
+	workspace := filepath.Join(dir, "workspace")
+	hooks := filepath.Join(workspace, hooksDir)
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SESSION_ID", "s1")
+
+	writeHook := func(name, frontMatter, body string) {
+		t.Helper()
+		content := "#!/bin/sh\n#---\n" + frontMatter + "#---\n" + body + "\n"
+		if err := os.WriteFile(filepath.Join(hooks, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeHook("01-setup.sh", "# type: session\n# run_as: root\n", "echo session >> order")
+	writeHook("02-flush.sh", "# name: Flush\n# type: shutdown\n# run_as: root\n", "echo $DISCOBOT_HOOK_TYPE >> order")
+	writeHook("03-export.sh", "# type: shutdown\n# run_as: root\n", "echo export >> order; exit 3")
+	writeHook("04-skipped.sh", "# type: shutdown\n# run_as: root\n# when:\n#   file_exists: missing\n", "echo skipped >> order")
+
+	u := &userInfo{uid: os.Getuid(), gid: os.Getgid(), homeDir: dir}
+	runShutdownHooks(workspace, u)
+
+	order, err := os.ReadFile(filepath.Join(workspace, "order"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(order) != "shutdown\nexport\n" {
+		t.Errorf("hooks run: got %q", order)
+	}
+
+	status := loadHookStatus(hooksDataDir(dir, "s1"))
+	if len(status.Hooks) != 2 {
+		t.Fatalf("expected 2 hook entries, got %v", status.Hooks)
+	}
+	flush := status.Hooks["02-flush"]
+	if flush.Type != "shutdown" || flush.HookName != "Flush" || flush.LastResult != "success" {
+		t.Errorf("02-flush status: got %+v", flush)
+	}
+	export := status.Hooks["03-export"]
+	if export.Type != "shutdown" || export.LastResult != "failure" || export.LastExitCode != 3 {
+		t.Errorf("03-export status: got %+v", export)
+	}
+}
+
 func TestNormalizeHookID(t *testing.T) {
 	tests := []struct {
 		filename string
@@ -284,7 +464,7 @@ func TestHookStatusPersistence(t *testing.T) {
 
 	t.Run("updateSessionHookStatus creates new hook entry", func(t *testing.T) {
 		dir := t.TempDir()
-		updateSessionHookStatus(dir, "my-hook", "My Hook", true, 0, "/tmp/out.log")
+		updateSessionHookStatus(dir, "my-hook", "My Hook", true, 0, "/tmp/out.log", nil)
 
 		status := loadHookStatus(dir)
 		h, ok := status.Hooks["my-hook"]
@@ -307,8 +487,8 @@ func TestHookStatusPersistence(t *testing.T) {
 
 	t.Run("updateSessionHookStatus increments failure counts", func(t *testing.T) {
 		dir := t.TempDir()
-		updateSessionHookStatus(dir, "fail-hook", "Fail Hook", false, 1, "/tmp/out.log")
-		updateSessionHookStatus(dir, "fail-hook", "Fail Hook", false, 1, "/tmp/out.log")
+		updateSessionHookStatus(dir, "fail-hook", "Fail Hook", false, 1, "/tmp/out.log", nil)
+		updateSessionHookStatus(dir, "fail-hook", "Fail Hook", false, 1, "/tmp/out.log", nil)
 
 		status := loadHookStatus(dir)
 		h := status.Hooks["fail-hook"]
@@ -325,9 +505,9 @@ func TestHookStatusPersistence(t *testing.T) {
 
 	t.Run("updateSessionHookStatus resets consecutive failures on success", func(t *testing.T) {
 		dir := t.TempDir()
-		updateSessionHookStatus(dir, "reset-hook", "Reset Hook", false, 1, "/tmp/out.log")
-		updateSessionHookStatus(dir, "reset-hook", "Reset Hook", false, 1, "/tmp/out.log")
-		updateSessionHookStatus(dir, "reset-hook", "Reset Hook", true, 0, "/tmp/out.log")
+		updateSessionHookStatus(dir, "reset-hook", "Reset Hook", false, 1, "/tmp/out.log", nil)
+		updateSessionHookStatus(dir, "reset-hook", "Reset Hook", false, 1, "/tmp/out.log", nil)
+		updateSessionHookStatus(dir, "reset-hook", "Reset Hook", true, 0, "/tmp/out.log", nil)
 
 		status := loadHookStatus(dir)
 		h := status.Hooks["reset-hook"]
@@ -344,7 +524,7 @@ func TestHookStatusPersistence(t *testing.T) {
 
 	t.Run("status.json schema matches TypeScript", func(t *testing.T) {
 		dir := t.TempDir()
-		updateSessionHookStatus(dir, "schema-hook", "Schema Hook", true, 0, "/tmp/out.log")
+		updateSessionHookStatus(dir, "schema-hook", "Schema Hook", true, 0, "/tmp/out.log", nil)
 
 		data, err := os.ReadFile(filepath.Join(dir, "status.json"))
 		if err != nil {
@@ -390,3 +570,76 @@ func TestHookStatusPersistence(t *testing.T) {
 		}
 	})
 }
+
+func TestDevcontainerHooks(t *testing.T) {
+	dataDir := t.TempDir()
+	u := &userInfo{uid: os.Getuid(), gid: os.Getgid(), homeDir: dataDir}
+	spec := &devcontainerSpec{
+		RemoteUser: "root",
+		PostCreate: []devcontainerCommand{{Script: "npm install"}},
+		PostStart: []devcontainerCommand{
+			{Name: "db", Script: "pg_ctl start"},
+			{Name: "Watch Mode", Script: "npm run watch"},
+		},
+	}
+
+	paths, configs := devcontainerHooks(spec, dataDir, u)
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 hooks, got %d", len(paths))
+	}
+
+	wantFiles := []string{"devcontainer-post-create.sh", "devcontainer-post-start-db.sh", "devcontainer-post-start-watchmode.sh"}
+	wantNames := []string{"postCreateCommand", "postStartCommand (db)", "postStartCommand (Watch Mode)"}
+	for i, p := range paths {
+		if filepath.Base(p) != wantFiles[i] {
+			t.Errorf("hook %d: file %q, want %q", i, filepath.Base(p), wantFiles[i])
+		}
+		if configs[i].Name != wantNames[i] || configs[i].RunAs != "root" || configs[i].Type != "session" {
+			t.Errorf("hook %d: unexpected config %+v", i, configs[i])
+		}
+		if configs[i].Blocking != (i == 0) {
+			t.Errorf("hook %d: blocking = %v", i, configs[i].Blocking)
+		}
+	}
+	content, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("failed to read hook script: %v", err)
+	}
+	if string(content) != "#!/bin/sh\nnpm install\n" {
+		t.Errorf("unexpected script %q", content)
+	}
+
+	// A successful postCreateCommand does not run again for the session
+	updateSessionHookStatus(dataDir, normalizeHookID("devcontainer-post-create.sh"), "postCreateCommand", true, 0, "", nil)
+	paths, configs = devcontainerHooks(spec, dataDir, u)
+	if len(paths) != 2 || configs[0].Blocking {
+		t.Errorf("expected only the postStartCommand hooks after a successful postCreateCommand, got %v", paths)
+	}
+
+	spec.RemoteUser = "vscode"
+	if _, configs := devcontainerHooks(spec, dataDir, u); configs[0].RunAs != "user" {
+		t.Errorf("expected non-root remoteUser to run as user, got %q", configs[0].RunAs)
+	}
+
+	if paths, _ := devcontainerHooks(nil, dataDir, u); paths != nil {
+		t.Errorf("expected no hooks without a spec, got %v", paths)
+	}
+}
+
+func TestLoadDevcontainerSpec(t *testing.T) {
+	t.Setenv(devcontainerEnvVar, "")
+	if spec := loadDevcontainerSpec(); spec != nil {
+		t.Errorf("expected nil spec when unset, got %+v", spec)
+	}
+
+	t.Setenv(devcontainerEnvVar, "bm90IGpzb24=") // "not json"
+	if spec := loadDevcontainerSpec(); spec != nil {
+		t.Errorf("expected nil spec for invalid JSON, got %+v", spec)
+	}
+
+	t.Setenv(devcontainerEnvVar, "eyJyZW1vdGVVc2VyIjoicm9vdCIsInBvc3RDcmVhdGUiOlt7InNjcmlwdCI6Im1ha2UifV19")
+	spec := loadDevcontainerSpec()
+	if spec == nil || spec.RemoteUser != "root" || len(spec.PostCreate) != 1 || spec.PostCreate[0].Script != "make" {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+}
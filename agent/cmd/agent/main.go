@@ -86,6 +86,27 @@ func main() {
 				os.Exit(1)
 			}
 			return
+		case "tunnel":
+			if err := runTunnel(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "discobot-agent-tunnel: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "cache":
+			if err := runCache(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "discobot-agent-cache: %v\n", err)
+				if errors.Is(err, errUnknownCacheEntry) {
+					os.Exit(cacheInvalidEntryExitCode)
+				}
+				os.Exit(1)
+			}
+			return
+		case "shutdown-hooks":
+			if err := runShutdown(); err != nil {
+				fmt.Fprintf(os.Stderr, "discobot-agent: shutdown hooks failed: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
 	}
 
@@ -99,10 +120,14 @@ func main() {
 	}
 }
 
-func run() error {
+func run() (retErr error) {
 	startupStart := time.Now()
 	fmt.Printf("discobot-agent: container startup beginning at %s\n", startupStart.Format(time.RFC3339))
 
+	// Record setup steps for the server; a failure marks the running step failed
+	timeline := newStartupTimeline(startupTimelinePath)
+	defer func() { timeline.complete(retErr) }()
+
 	// Change to root directory to avoid issues with overlayfs mounting
 	// The current directory might be inside /home/discobot which will be mounted over
 	if err := os.Chdir("/"); err != nil {
@@ -144,79 +169,98 @@ func run() error {
 	// Step 0: Setup git safe.directory for all workspace paths (system-wide)
 	// This must happen early so git commands work for all users
 	stepStart := time.Now()
+	step := timeline.begin("git safe.directory")
 	if err := setupGitSafeDirectories(workspacePath); err != nil {
 		return fmt.Errorf("git safe.directory setup failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] git safe.directory setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 1: Setup base home directory (copy from /home/discobot if needed)
 	stepStart = time.Now()
+	step = timeline.begin("base home")
 	if err := setupBaseHome(userInfo); err != nil {
 		return fmt.Errorf("base home setup failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] base home setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 2: Clone workspace (must complete before overlayfs mount)
 	// The overlayfs captures the lower layer state at mount time, so the workspace
 	// must be fully cloned into /.data/discobot/workspace before we mount overlayfs.
 	stepStart = time.Now()
+	step = timeline.begin("workspace")
 	if err := setupWorkspace(workspacePath, workspaceCommit, userInfo); err != nil {
 		return fmt.Errorf("workspace setup failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] workspace setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 3: Setup and mount OverlayFS for copy-on-write session isolation
 	stepStart = time.Now()
 	fmt.Printf("discobot-agent: using OverlayFS\n")
-
+	step = timeline.begin("overlayfs")
 	if err := setupOverlayFS(sessionID, userInfo); err != nil {
 		return fmt.Errorf("overlayfs setup failed: %w", err)
 	}
 	if err := mountOverlayFS(sessionID); err != nil {
 		return fmt.Errorf("overlayfs mount failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] filesystem setup completed (overlayfs)\n", time.Since(stepStart).Seconds())
 
 	// Step 4.5: Mount cache directories on top of the overlay
 	stepStart = time.Now()
-	if err := mountCacheDirectories(); err != nil {
+	step = timeline.begin("cache directories")
+	err = mountCacheDirectories()
+	if err != nil {
 		// Log but don't fail - cache mounting is optional
 		fmt.Printf("discobot-agent: Cache mount failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] cache directories mounted\n", time.Since(stepStart).Seconds())
 
 	// Step 5: Create /workspace symlink to /home/discobot/workspace
 	stepStart = time.Now()
+	step = timeline.begin("workspace symlink")
 	if err := createWorkspaceSymlink(); err != nil {
 		return fmt.Errorf("symlink creation failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] workspace symlink created\n", time.Since(stepStart).Seconds())
 
 	// Step 5.5: Run session hooks from .discobot/hooks/
 	// Blocking hooks run synchronously here; non-blocking hooks launch in background goroutines.
 	// The process stays alive as PID 1, so background hooks can complete without waiting.
 	stepStart = time.Now()
-	_ = runSessionHooks(filepath.Join(mountHome, "workspace"), userInfo)
+	_ = runSessionHooks(filepath.Join(mountHome, "workspace"), userInfo, timeline)
 	fmt.Printf("discobot-agent: [%.3fs] session hooks dispatched\n", time.Since(stepStart).Seconds())
 
 	// Step 6: Setup proxy configuration (uses embedded defaults only for security)
 	stepStart = time.Now()
-	if err := setupProxyConfig(userInfo); err != nil {
+	step = timeline.begin("proxy config")
+	err = setupProxyConfig(userInfo)
+	if err != nil {
 		// Log but don't fail - proxy config is optional
 		fmt.Printf("discobot-agent: Proxy config setup failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] proxy config setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 7: Generate CA certificate and install in system trust store
 	stepStart = time.Now()
-	if err := setupProxyCertificate(); err != nil {
+	step = timeline.begin("CA certificate")
+	err = setupProxyCertificate()
+	if err != nil {
 		// Log but don't fail - proxy cert is optional
 		fmt.Printf("discobot-agent: Proxy certificate setup failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] CA certificate setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 8: Start proxy daemon with embedded defaults
 	stepStart = time.Now()
+	step = timeline.begin("proxy daemon")
 	proxyCmd, err := startProxyDaemon(userInfo)
 	proxyEnabled := (err == nil && proxyCmd != nil)
 	if err != nil {
@@ -225,20 +269,25 @@ func run() error {
 	} else {
 		fmt.Printf("discobot-agent: [%.3fs] proxy daemon started\n", time.Since(stepStart).Seconds())
 	}
+	step.warn(err)
 
 	// Step 8.5: Configure BuildKit remote builder if BUILDKIT_HOST is set
 	// This writes buildx config files directly (no Docker daemon needed)
 	if buildkitAddr := os.Getenv("BUILDKIT_HOST"); buildkitAddr != "" {
 		stepStart = time.Now()
-		if bkErr := configureBuildxRemoteBuilder(buildkitAddr, userInfo); bkErr != nil {
+		step = timeline.begin("BuildKit remote builder")
+		bkErr := configureBuildxRemoteBuilder(buildkitAddr, userInfo)
+		if bkErr != nil {
 			fmt.Printf("discobot-agent: warning: failed to configure BuildKit remote builder: %v\n", bkErr)
 		} else {
 			fmt.Printf("discobot-agent: [%.3fs] BuildKit remote builder configured at %s\n", time.Since(stepStart).Seconds(), buildkitAddr)
 		}
+		step.warn(bkErr)
 	}
 
 	// Step 9: Start Docker daemon if available (after proxy so Docker can use it)
 	stepStart = time.Now()
+	step = timeline.begin("Docker daemon")
 	dockerCmd, err := startDockerDaemon(proxyEnabled)
 	if err != nil {
 		// Log but don't fail - Docker is optional
@@ -246,8 +295,10 @@ func run() error {
 	} else {
 		fmt.Printf("discobot-agent: [%.3fs] Docker daemon started\n", time.Since(stepStart).Seconds())
 	}
+	step.warn(err)
 
 	// Step 10: Run the agent API
+	timeline.complete(nil)
 	fmt.Printf("discobot-agent: [%.3fs] total startup time\n", time.Since(startupStart).Seconds())
 	fmt.Printf("discobot-agent: starting agent API\n")
 	return runAgent(agentBinary, userInfo, dockerCmd, proxyCmd)
@@ -256,10 +307,14 @@ func run() error {
 // runSetup performs container initialization as a oneshot systemd service.
 // It does all setup steps (workspace, overlayfs, certs, etc.) then writes
 // environment files for other systemd services and exits.
-func runSetup() error {
+func runSetup() (retErr error) {
 	startupStart := time.Now()
 	fmt.Printf("discobot-agent: setup beginning at %s\n", startupStart.Format(time.RFC3339))
 
+	// Record setup steps for the server; a failure marks the running step failed
+	timeline := newStartupTimeline(startupTimelinePath)
+	defer func() { timeline.complete(retErr) }()
+
 	// Change to root directory to avoid issues with overlayfs mounting
 	if err := os.Chdir("/"); err != nil {
 		return fmt.Errorf("failed to chdir to /: %w", err)
@@ -292,100 +347,127 @@ func runSetup() error {
 
 	// Step 0: Setup git safe.directory
 	stepStart := time.Now()
+	step := timeline.begin("git safe.directory")
 	if err := setupGitSafeDirectories(workspacePath); err != nil {
 		return fmt.Errorf("git safe.directory setup failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] git safe.directory setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 1: Setup base home directory
 	stepStart = time.Now()
+	step = timeline.begin("base home")
 	if err := setupBaseHome(userInfo); err != nil {
 		return fmt.Errorf("base home setup failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] base home setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 2: Clone workspace
 	stepStart = time.Now()
+	step = timeline.begin("workspace")
 	if err := setupWorkspace(workspacePath, workspaceCommit, userInfo); err != nil {
 		return fmt.Errorf("workspace setup failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] workspace setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 3: Setup and mount OverlayFS for copy-on-write session isolation
 	stepStart = time.Now()
 	fmt.Printf("discobot-agent: using OverlayFS\n")
-
+	step = timeline.begin("overlayfs")
 	if err := setupOverlayFS(sessionID, userInfo); err != nil {
 		return fmt.Errorf("overlayfs setup failed: %w", err)
 	}
 	if err := mountOverlayFS(sessionID); err != nil {
 		return fmt.Errorf("overlayfs mount failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] filesystem setup completed (overlayfs)\n", time.Since(stepStart).Seconds())
 
 	// Step 4: Mount cache directories
 	stepStart = time.Now()
-	if err := mountCacheDirectories(); err != nil {
+	step = timeline.begin("cache directories")
+	err = mountCacheDirectories()
+	if err != nil {
 		fmt.Printf("discobot-agent: Cache mount failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] cache directories mounted\n", time.Since(stepStart).Seconds())
 
 	// Step 4.5: Configure BuildKit remote builder if BUILDKIT_HOST is set
 	if buildkitAddr := os.Getenv("BUILDKIT_HOST"); buildkitAddr != "" {
 		stepStart = time.Now()
-		if bkErr := configureBuildxRemoteBuilder(buildkitAddr, userInfo); bkErr != nil {
+		step = timeline.begin("BuildKit remote builder")
+		bkErr := configureBuildxRemoteBuilder(buildkitAddr, userInfo)
+		if bkErr != nil {
 			fmt.Printf("discobot-agent: warning: failed to configure BuildKit remote builder: %v\n", bkErr)
 		} else {
 			fmt.Printf("discobot-agent: [%.3fs] BuildKit remote builder configured at %s\n", time.Since(stepStart).Seconds(), buildkitAddr)
 		}
+		step.warn(bkErr)
 	}
 
 	// Step 5: Create /workspace symlink
 	stepStart = time.Now()
+	step = timeline.begin("workspace symlink")
 	if err := createWorkspaceSymlink(); err != nil {
 		return fmt.Errorf("symlink creation failed: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] workspace symlink created\n", time.Since(stepStart).Seconds())
 
 	// Step 5.5: Run session hooks
 	// In oneshot mode we must wait for background hooks before the process exits.
 	stepStart = time.Now()
-	waitHooks := runSessionHooks(filepath.Join(mountHome, "workspace"), userInfo)
+	waitHooks := runSessionHooks(filepath.Join(mountHome, "workspace"), userInfo, timeline)
 	fmt.Printf("discobot-agent: [%.3fs] session hooks dispatched\n", time.Since(stepStart).Seconds())
 
 	// Step 6: Setup proxy configuration
 	stepStart = time.Now()
-	if err := setupProxyConfig(userInfo); err != nil {
+	step = timeline.begin("proxy config")
+	err = setupProxyConfig(userInfo)
+	if err != nil {
 		fmt.Printf("discobot-agent: Proxy config setup failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] proxy config setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 7: Generate CA certificate
 	stepStart = time.Now()
-	if err := setupProxyCertificate(); err != nil {
+	step = timeline.begin("CA certificate")
+	err = setupProxyCertificate()
+	if err != nil {
 		fmt.Printf("discobot-agent: Proxy certificate setup failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] CA certificate setup completed\n", time.Since(stepStart).Seconds())
 
 	// Step 8: Write Docker daemon configuration
 	stepStart = time.Now()
-	if err := writeDockerDaemonConfig(); err != nil {
+	step = timeline.begin("Docker daemon config")
+	err = writeDockerDaemonConfig()
+	if err != nil {
 		fmt.Printf("discobot-agent: Docker daemon config failed: %v\n", err)
 	}
+	step.warn(err)
 	fmt.Printf("discobot-agent: [%.3fs] Docker daemon config written\n", time.Since(stepStart).Seconds())
 
 	// Step 9: Write environment files for systemd services
 	stepStart = time.Now()
+	step = timeline.begin("environment files")
 	if err := writeProxyEnvironmentFile(); err != nil {
 		fmt.Printf("discobot-agent: warning: failed to write proxy env file: %v\n", err)
 	}
 	if err := writeAgentEnvironmentFile(userInfo); err != nil {
 		return fmt.Errorf("failed to write agent environment file: %w", err)
 	}
+	step.end(nil)
 	fmt.Printf("discobot-agent: [%.3fs] environment files written\n", time.Since(stepStart).Seconds())
 
 	// Notify systemd that setup is complete so dependent services can start
 	// while background session hooks continue running.
+	timeline.complete(nil)
 	fmt.Printf("discobot-agent: [%.3fs] setup completed successfully\n", time.Since(startupStart).Seconds())
 	if err := sdNotifyReady(); err != nil {
 		fmt.Printf("discobot-agent: warning: sd_notify failed: %v\n", err)
@@ -1572,6 +1654,18 @@ func setupProxyConfig(userInfo *userInfo) error {
 	return nil
 }
 
+// runShutdown runs shutdown hooks under systemd. It is the ExecStop of
+// discobot-shutdown-hooks.service, which is ordered after the agent API and
+// Docker so systemd stops it, and runs the hooks, before stopping them.
+func runShutdown() error {
+	u, err := lookupUser(envOrDefault("AGENT_USER", defaultUser))
+	if err != nil {
+		return fmt.Errorf("failed to lookup user: %w", err)
+	}
+	runShutdownHooks(filepath.Join(mountHome, "workspace"), u)
+	return nil
+}
+
 // runAgent starts the agent API process and manages its lifecycle
 func runAgent(agentBinary string, u *userInfo, dockerCmd, proxyCmd *exec.Cmd) error {
 	// Check if we're running as PID 1
@@ -1625,12 +1719,20 @@ func runAgent(agentBinary string, u *userInfo, dockerCmd, proxyCmd *exec.Cmd) er
 		childDone <- cmd.Wait()
 	}()
 
+	// Shutdown hooks run on the first termination signal, while the agent API
+	// and Docker daemon are still up
+	beforeShutdown := func() {
+		runShutdownHooks(workDir, u)
+	}
+
 	// Main event loop
-	return eventLoop(cmd, dockerCmd, proxyCmd, signals, childDone, isPID1)
+	return eventLoop(cmd, dockerCmd, proxyCmd, signals, childDone, isPID1, beforeShutdown)
 }
 
-// eventLoop handles signals and waits for child process exit
-func eventLoop(cmd *exec.Cmd, dockerCmd, proxyCmd *exec.Cmd, signals chan os.Signal, childDone chan error, isPID1 bool) error {
+// eventLoop handles signals and waits for child process exit.
+// beforeShutdown, if set, runs on the first termination signal before it is
+// forwarded to the child.
+func eventLoop(cmd *exec.Cmd, dockerCmd, proxyCmd *exec.Cmd, signals chan os.Signal, childDone chan error, isPID1 bool, beforeShutdown func()) error {
 	shuttingDown := false
 
 	for {
@@ -1648,6 +1750,11 @@ func eventLoop(cmd *exec.Cmd, dockerCmd, proxyCmd *exec.Cmd, signals chan os.Sig
 					shuttingDown = true
 					fmt.Printf("discobot-agent: received %v, shutting down...\n", sig)
 
+					// Bounded by shutdownHooksTimeout. Zombies are reaped once it returns.
+					if beforeShutdown != nil {
+						beforeShutdown()
+					}
+
 					// Forward signal to child process group
 					if cmd.Process != nil {
 						// Send to process group (negative pid)
@@ -1976,7 +2083,7 @@ func isValidCachePath(path string) bool {
 
 	// Must be within /home/discobot (not equal to it, must be a subdirectory)
 	homePrefix := "/home/discobot/"
-	if !strings.HasPrefix(cleanPath+"/", homePrefix) {
+	if !strings.HasPrefix(cleanPath, homePrefix) {
 		return false
 	}
 
@@ -2005,6 +2112,11 @@ func mountCacheDirectories() error {
 		return nil
 	}
 
+	// Enforce the project's cache quota before anything starts using it
+	if err := pruneCacheFromEnv(cacheVolumeBase); err != nil {
+		fmt.Printf("discobot-agent: warning: cache pruning failed: %v\n", err)
+	}
+
 	// Load cache configuration
 	cfg := loadCacheConfig()
 
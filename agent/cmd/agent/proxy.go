@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
-	"os/exec"
 	"os/signal"
+	"slices"
 	"strconv"
 	"sync"
 	"syscall"
@@ -15,11 +17,14 @@ import (
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+
+	"github.com/obot-platform/discobot/agent/tunnel"
 )
 
 // runProxy implements the VSOCK port proxy for VZ VMs.
-// It watches Docker events for containers with published ports and creates
-// socat VSOCK listeners that forward those ports to the host.
+// It serves multiplexed tunnel sessions on tunnel.VsockPort so the host can
+// reach ports published by managed containers, and watches Docker events to
+// keep the set of reachable ports in sync with running containers.
 func runProxy() error {
 	fmt.Println("discobot-agent-proxy: starting VSOCK port proxy")
 
@@ -44,9 +49,7 @@ func runProxy() error {
 
 	fmt.Println("discobot-agent-proxy: connected to Docker")
 
-	// Track socat processes: containerID -> []*exec.Cmd
-	mu := &sync.Mutex{}
-	socatProcs := make(map[string][]*exec.Cmd)
+	ports := newPublishedPorts()
 
 	// Handle existing containers
 	containers, err := cli.ContainerList(ctx, container.ListOptions{
@@ -60,12 +63,17 @@ func runProxy() error {
 	}
 
 	for _, c := range containers {
-		ports := extractPublishedPorts(c.Ports)
-		if len(ports) > 0 {
-			startSocatForContainer(mu, socatProcs, c.ID, ports)
+		if p := extractPublishedPorts(c.Ports); len(p) > 0 {
+			ports.set(c.ID, p)
 		}
 	}
 
+	ln, err := listenVsock(tunnel.VsockPort)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("discobot-agent-proxy: serving tunnel on VSOCK port %d\n", tunnel.VsockPort)
+
 	// Handle shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
@@ -76,25 +84,85 @@ func runProxy() error {
 		cancel()
 	}()
 
+	ts := newTunnelServer(ports.dial)
+	serveDone := make(chan error, 1)
+	go func() {
+		serveDone <- ts.serve(ctx, ln)
+	}()
+
 	// Watch Docker events with auto-reconnect
-	watchDockerEventsProxy(ctx, cli, mu, socatProcs)
-
-	// Cleanup all socat processes
-	mu.Lock()
-	for containerID, cmds := range socatProcs {
-		for _, cmd := range cmds {
-			if cmd.Process != nil {
-				_ = cmd.Process.Kill()
-			}
-		}
-		delete(socatProcs, containerID)
+	watchDockerEventsProxy(ctx, cli, ports)
+
+	// serve drains active streams once ctx is cancelled.
+	if err := <-serveDone; err != nil {
+		return fmt.Errorf("tunnel server: %w", err)
 	}
-	mu.Unlock()
 
 	fmt.Println("discobot-agent-proxy: stopped")
 	return nil
 }
 
+// publishedPorts tracks the host ports published by each managed container.
+// Tunnel streams may only target ports in this set.
+type publishedPorts struct {
+	mu          sync.Mutex
+	byContainer map[string][]int
+}
+
+func newPublishedPorts() *publishedPorts {
+	return &publishedPorts{byContainer: make(map[string][]int)}
+}
+
+// set replaces the published ports of a container.
+func (p *publishedPorts) set(containerID string, ports []int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byContainer[containerID] = ports
+	fmt.Printf("discobot-agent-proxy: forwarding ports %v (container %s)\n", ports, containerID[:12])
+}
+
+// remove forgets a container's published ports.
+func (p *publishedPorts) remove(containerID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.byContainer[containerID]; !exists {
+		return
+	}
+	delete(p.byContainer, containerID)
+	fmt.Printf("discobot-agent-proxy: stopped forwarding for container %s\n", containerID[:12])
+}
+
+func (p *publishedPorts) contains(port int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ports := range p.byContainer {
+		if slices.Contains(ports, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// dial connects a tunnel stream to a published port on localhost. The proxy
+// runs with host networking, so published ports are reachable there.
+func (p *publishedPorts) dial(ctx context.Context, target string) (io.ReadWriteCloser, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+	if host != "localhost" && host != "127.0.0.1" {
+		return nil, fmt.Errorf("target host %q is not allowed", host)
+	}
+	if !p.contains(port) {
+		return nil, fmt.Errorf("port %d is not published by a managed container", port)
+	}
+	return dialTCP(ctx, net.JoinHostPort("127.0.0.1", portStr))
+}
+
 // waitForDockerReady polls Docker until it responds to ping.
 func waitForDockerReady(ctx context.Context, cli *client.Client) error {
 	deadline := time.Now().Add(60 * time.Second)
@@ -143,69 +211,9 @@ func extractPublishedPortsFromInspect(info container.InspectResponse) []int {
 	return result
 }
 
-// startSocatForContainer starts socat VSOCK listeners for the given ports.
-func startSocatForContainer(mu *sync.Mutex, socatProcs map[string][]*exec.Cmd, containerID string, ports []int) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	// Kill any existing socat processes for this container
-	if cmds, exists := socatProcs[containerID]; exists {
-		for _, cmd := range cmds {
-			if cmd.Process != nil {
-				_ = cmd.Process.Kill()
-			}
-		}
-	}
-
-	var cmds []*exec.Cmd
-	for _, port := range ports {
-		cmd := exec.Command("socat",
-			"-b131072",
-			fmt.Sprintf("VSOCK-LISTEN:%d,reuseaddr,fork,shut-down", port),
-			fmt.Sprintf("TCP:localhost:%d,shut-down", port),
-		)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		if err := cmd.Start(); err != nil {
-			fmt.Fprintf(os.Stderr, "discobot-agent-proxy: failed to start socat for port %d: %v\n", port, err)
-			continue
-		}
-
-		fmt.Printf("discobot-agent-proxy: forwarding VSOCK port %d -> localhost:%d (container %s)\n", port, port, containerID[:12])
-		cmds = append(cmds, cmd)
-
-		// Reap socat process when it exits
-		go func(c *exec.Cmd, p int) {
-			_ = c.Wait()
-		}(cmd, port)
-	}
-
-	socatProcs[containerID] = cmds
-}
-
-// stopSocatForContainer kills all socat processes for a container.
-func stopSocatForContainer(mu *sync.Mutex, socatProcs map[string][]*exec.Cmd, containerID string) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	cmds, exists := socatProcs[containerID]
-	if !exists {
-		return
-	}
-
-	for _, cmd := range cmds {
-		if cmd.Process != nil {
-			_ = cmd.Process.Kill()
-		}
-	}
-	delete(socatProcs, containerID)
-	fmt.Printf("discobot-agent-proxy: stopped forwarding for container %s\n", containerID[:12])
-}
-
-// watchDockerEventsProxy watches Docker events and manages socat processes.
-// Auto-reconnects on stream errors.
-func watchDockerEventsProxy(ctx context.Context, cli *client.Client, mu *sync.Mutex, socatProcs map[string][]*exec.Cmd) {
+// watchDockerEventsProxy watches Docker events and keeps the published port
+// set up to date. Auto-reconnects on stream errors.
+func watchDockerEventsProxy(ctx context.Context, cli *client.Client, ports *publishedPorts) {
 	filterArgs := filters.NewArgs(
 		filters.Arg("type", string(events.ContainerEventType)),
 		filters.Arg("event", "start"),
@@ -225,7 +233,7 @@ func watchDockerEventsProxy(ctx context.Context, cli *client.Client, mu *sync.Mu
 			Filters: filterArgs,
 		})
 
-		done := processProxyEvents(ctx, cli, mu, socatProcs, msgCh, errCh)
+		done := processProxyEvents(ctx, cli, ports, msgCh, errCh)
 		if !done {
 			return
 		}
@@ -242,7 +250,7 @@ func watchDockerEventsProxy(ctx context.Context, cli *client.Client, mu *sync.Mu
 
 // processProxyEvents processes Docker events from channels.
 // Returns true if reconnection should be attempted, false if we should exit.
-func processProxyEvents(ctx context.Context, cli *client.Client, mu *sync.Mutex, socatProcs map[string][]*exec.Cmd, msgCh <-chan events.Message, errCh <-chan error) bool {
+func processProxyEvents(ctx context.Context, cli *client.Client, ports *publishedPorts, msgCh <-chan events.Message, errCh <-chan error) bool {
 	for {
 		select {
 		case <-ctx.Done():
@@ -278,13 +286,12 @@ func processProxyEvents(ctx context.Context, cli *client.Client, mu *sync.Mutex,
 					continue
 				}
 
-				ports := extractPublishedPortsFromInspect(info)
-				if len(ports) > 0 {
-					startSocatForContainer(mu, socatProcs, containerID, ports)
+				if p := extractPublishedPortsFromInspect(info); len(p) > 0 {
+					ports.set(containerID, p)
 				}
 
 			case "die", "stop", "destroy":
-				stopSocatForContainer(mu, socatProcs, containerID)
+				ports.remove(containerID)
 			}
 		}
 	}
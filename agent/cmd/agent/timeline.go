@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// startupTimelinePath is where the agent records its setup steps. The server
+// reads this file while the session is starting to report progress.
+const startupTimelinePath = "/run/discobot/startup.json"
+
+// Step and timeline outcomes.
+// Schema matches sandboxapi.StartupTimeline in the server.
+const (
+	stepRunning = "running"
+	stepSuccess = "success"
+	stepWarning = "warning"
+	stepFailure = "failure"
+)
+
+// startupStep is one setup step in the startup timeline.
+type startupStep struct {
+	Name      string     `json:"name"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Outcome   string     `json:"outcome"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// startupTimelineFile is the persisted startup timeline.
+type startupTimelineFile struct {
+	StartedAt   time.Time      `json:"startedAt"`
+	CompletedAt *time.Time     `json:"completedAt,omitempty"`
+	Outcome     string         `json:"outcome"`
+	Error       string         `json:"error,omitempty"`
+	Steps       []*startupStep `json:"steps"`
+}
+
+// startupTimeline records setup steps and persists them to a JSON file after
+// every change. A nil timeline is valid and records nothing, so helpers can
+// take one optionally. Write failures are logged once and otherwise ignored:
+// the timeline is diagnostic and never blocks startup.
+type startupTimeline struct {
+	mu          sync.Mutex
+	path        string
+	file        startupTimelineFile
+	writeFailed bool
+}
+
+// newStartupTimeline creates a running timeline persisted at path.
+func newStartupTimeline(path string) *startupTimeline {
+	t := &startupTimeline{
+		path: path,
+		file: startupTimelineFile{StartedAt: time.Now(), Outcome: stepRunning, Steps: []*startupStep{}},
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.save()
+	return t
+}
+
+// timelineStep is a handle to a running step.
+type timelineStep struct {
+	t    *startupTimeline
+	step *startupStep
+}
+
+// begin starts a new step. Steps may overlap (e.g. background session hooks).
+func (t *startupTimeline) begin(name string) *timelineStep {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	step := &startupStep{Name: name, StartedAt: time.Now(), Outcome: stepRunning}
+	t.file.Steps = append(t.file.Steps, step)
+	t.save()
+	return &timelineStep{t: t, step: step}
+}
+
+// end completes the step: failure if err is set, success otherwise.
+func (s *timelineStep) end(err error) {
+	if err != nil {
+		s.finish(stepFailure, err)
+	} else {
+		s.finish(stepSuccess, nil)
+	}
+}
+
+// warn completes a best-effort step: warning if err is set, success otherwise.
+func (s *timelineStep) warn(err error) {
+	if err != nil {
+		s.finish(stepWarning, err)
+	} else {
+		s.finish(stepSuccess, nil)
+	}
+}
+
+func (s *timelineStep) finish(outcome string, err error) {
+	if s == nil {
+		return
+	}
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	s.step.finish(outcome, err)
+	s.t.save()
+}
+
+func (s *startupStep) finish(outcome string, err error) {
+	if s.Outcome != stepRunning {
+		return
+	}
+	now := time.Now()
+	s.EndedAt = &now
+	s.Outcome = outcome
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// complete marks setup as finished. A non-nil err fails the timeline along
+// with any step still running; otherwise running steps (background hooks)
+// are left to finish on their own. Only the first call has an effect.
+func (t *startupTimeline) complete(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file.CompletedAt != nil {
+		return
+	}
+	now := time.Now()
+	t.file.CompletedAt = &now
+	t.file.Outcome = stepSuccess
+	if err != nil {
+		t.file.Outcome = stepFailure
+		t.file.Error = err.Error()
+		for _, s := range t.file.Steps {
+			s.finish(stepFailure, err)
+		}
+	}
+	t.save()
+}
+
+// save writes the timeline atomically. Callers must hold t.mu.
+func (t *startupTimeline) save() {
+	if t.path == "" {
+		return
+	}
+	err := t.write()
+	if err != nil && !t.writeFailed {
+		fmt.Fprintf(os.Stderr, "discobot-agent: warning: failed to write startup timeline: %v\n", err)
+	}
+	t.writeFailed = err != nil
+}
+
+func (t *startupTimeline) write() error {
+	data, err := json.Marshal(t.file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
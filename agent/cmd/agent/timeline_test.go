@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTimeline(t *testing.T, path string) startupTimelineFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read timeline: %v", err)
+	}
+	var tl startupTimelineFile
+	if err := json.Unmarshal(data, &tl); err != nil {
+		t.Fatalf("invalid timeline JSON: %v", err)
+	}
+	return tl
+}
+
+func TestStartupTimeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "startup.json")
+	timeline := newStartupTimeline(path)
+
+	if got := readTimeline(t, path); got.Outcome != stepRunning || len(got.Steps) != 0 {
+		t.Fatalf("initial timeline = %+v", got)
+	}
+
+	timeline.begin("workspace").end(nil)
+	timeline.begin("proxy config").warn(errors.New("no config"))
+	hook := timeline.begin("session hook: setup")
+	timeline.begin("overlayfs")
+
+	got := readTimeline(t, path)
+	if len(got.Steps) != 4 || got.Steps[3].Outcome != stepRunning || got.Steps[3].EndedAt != nil {
+		t.Fatalf("expected a running overlayfs step, got %+v", got.Steps)
+	}
+
+	timeline.complete(errors.New("overlayfs mount failed"))
+	hook.end(nil) // ignored: already failed with the timeline
+
+	got = readTimeline(t, path)
+	if got.Outcome != stepFailure || got.Error != "overlayfs mount failed" || got.CompletedAt == nil {
+		t.Errorf("timeline = %+v", got)
+	}
+	want := []struct{ outcome, err string }{
+		{stepSuccess, ""},
+		{stepWarning, "no config"},
+		{stepFailure, "overlayfs mount failed"},
+		{stepFailure, "overlayfs mount failed"},
+	}
+	for i, w := range want {
+		s := got.Steps[i]
+		if s.Outcome != w.outcome || s.Error != w.err || s.EndedAt == nil {
+			t.Errorf("step %d (%s) = %s %q, want %s %q", i, s.Name, s.Outcome, s.Error, w.outcome, w.err)
+		}
+	}
+
+	// Only the first completion counts
+	timeline.complete(nil)
+	if got := readTimeline(t, path); got.Outcome != stepFailure {
+		t.Errorf("Outcome = %s after a second complete, want failure", got.Outcome)
+	}
+}
+
+func TestStartupTimelineSuccessLeavesBackgroundStepsRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.json")
+	timeline := newStartupTimeline(path)
+	hook := timeline.begin("session hook: watch")
+	timeline.complete(nil)
+
+	got := readTimeline(t, path)
+	if got.Outcome != stepSuccess || got.Steps[0].Outcome != stepRunning {
+		t.Fatalf("timeline = %+v", got)
+	}
+
+	hook.end(errors.New("exit status 1"))
+	got = readTimeline(t, path)
+	if got.Steps[0].Outcome != stepFailure || got.Steps[0].Error != "exit status 1" {
+		t.Errorf("background step = %+v", got.Steps[0])
+	}
+}
+
+func TestStartupTimelineNil(t *testing.T) {
+	var timeline *startupTimeline
+	step := timeline.begin("workspace")
+	step.end(errors.New("ignored"))
+	step.warn(nil)
+	timeline.complete(nil)
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/obot-platform/discobot/agent/tunnel"
+)
+
+// tunnelShutdownTimeout bounds how long active streams may keep running
+// after a tunnel is asked to stop.
+const tunnelShutdownTimeout = 10 * time.Second
+
+// runTunnel implements the "tunnel" subcommand, a multiplexed TCP forwarder
+// that replaces per-connection socat processes.
+//
+//	discobot-agent tunnel stdio
+//	    Serve one tunnel session on stdin/stdout. The SSH server runs this
+//	    through an exec stream and multiplexes direct-tcpip channels over it.
+//	discobot-agent tunnel listen vsock:PORT|tcp:ADDR
+//	    Accept transport connections and serve a tunnel session on each.
+//
+// Streams may target any host:port reachable from the sandbox.
+func runTunnel(args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	ts := newTunnelServer(dialTCP)
+
+	switch {
+	case len(args) == 1 && args[0] == "stdio":
+		return ts.serveConn(ctx, stdioConn{})
+	case len(args) == 2 && args[0] == "listen":
+		ln, err := listenTunnel(args[1])
+		if err != nil {
+			return err
+		}
+		logTunnel("listening on %s", args[1])
+		return ts.serve(ctx, ln)
+	default:
+		return errors.New("usage: discobot-agent tunnel stdio | listen vsock:PORT|tcp:ADDR")
+	}
+}
+
+// listenTunnel parses a vsock:PORT or tcp:ADDR listen address.
+func listenTunnel(addr string) (net.Listener, error) {
+	network, rest, ok := strings.Cut(addr, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid listen address %q", addr)
+	}
+	switch network {
+	case "vsock":
+		port, err := strconv.ParseUint(rest, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vsock port %q: %w", rest, err)
+		}
+		return listenVsock(uint32(port))
+	case "tcp":
+		return net.Listen("tcp", rest)
+	default:
+		return nil, fmt.Errorf("unsupported listen network %q", network)
+	}
+}
+
+// dialTCP connects a tunnel stream to a TCP target.
+func dialTCP(ctx context.Context, target string) (io.ReadWriteCloser, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", target)
+}
+
+// tunnelServer serves tunnel sessions and logs per-stream statistics.
+type tunnelServer struct {
+	dial tunnel.DialFunc
+
+	mu       sync.Mutex
+	sessions map[*tunnel.Session]struct{}
+}
+
+func newTunnelServer(dial tunnel.DialFunc) *tunnelServer {
+	return &tunnelServer{
+		dial:     dial,
+		sessions: make(map[*tunnel.Session]struct{}),
+	}
+}
+
+// serve accepts connections from ln until ctx is cancelled, then shuts
+// down all sessions, letting active streams finish within
+// tunnelShutdownTimeout.
+func (ts *tunnelServer) serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if errors.Is(err, net.ErrClosed) {
+				wg.Wait()
+				return err
+			}
+			logTunnel("accept error: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ts.serveConn(ctx, conn); err != nil {
+				logTunnel("session from %s ended: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// serveConn serves a single tunnel session on conn until the peer goes away
+// or ctx is cancelled.
+func (ts *tunnelServer) serveConn(ctx context.Context, conn io.ReadWriteCloser) error {
+	sess := tunnel.Server(conn, &tunnel.Config{
+		Dial:          ts.dial,
+		OnStreamClose: logStreamStats,
+	})
+
+	ts.mu.Lock()
+	ts.sessions[sess] = struct{}{}
+	ts.mu.Unlock()
+	defer func() {
+		ts.mu.Lock()
+		delete(ts.sessions, sess)
+		ts.mu.Unlock()
+	}()
+
+	select {
+	case <-sess.Done():
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tunnelShutdownTimeout)
+		defer cancel()
+		if err := sess.Shutdown(shutdownCtx); err != nil {
+			logTunnel("forced shutdown with %d active streams", sess.Stats().ActiveStreams)
+		}
+	}
+
+	st := sess.Stats()
+	logTunnel("session closed: %d streams, %d dial errors, %d bytes in, %d bytes out",
+		st.TotalStreams, st.DialErrors, st.BytesIn, st.BytesOut)
+	return sess.Err()
+}
+
+// logStreamStats logs a finished stream's statistics.
+func logStreamStats(s tunnel.StreamStats) {
+	status := "ok"
+	if s.Err != nil {
+		status = s.Err.Error()
+	}
+	logTunnel("stream %d -> %s closed after %s (in %d B, out %d B): %s",
+		s.ID, s.Target, s.Duration.Round(time.Millisecond), s.BytesIn, s.BytesOut, status)
+}
+
+// logTunnel writes a tunnel log line to stderr. Stdout is reserved for the
+// tunnel protocol in stdio mode.
+func logTunnel(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "discobot-agent-tunnel: "+format+"\n", args...)
+}
+
+// stdioConn joins stdin and stdout into a single transport.
+type stdioConn struct{}
+
+func (stdioConn) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdioConn) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+
+func (stdioConn) Close() error {
+	_ = os.Stdin.Close()
+	return os.Stdout.Close()
+}
+
+// listenVsock listens for VSOCK connections on port from any CID. The
+// standard library cannot wrap AF_VSOCK sockets in a net.Listener, so the
+// socket is driven through the runtime poller via os.File.
+func listenVsock(port uint32) (net.Listener, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("vsock socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrVM{CID: unix.VMADDR_CID_ANY, Port: port}); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("vsock bind port %d: %w", port, err)
+	}
+	if err := unix.Listen(fd, unix.SOMAXCONN); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("vsock listen port %d: %w", port, err)
+	}
+
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("vsock-listener:%d", port))
+	rc, err := f.SyscallConn()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &vsockListener{file: f, rc: rc, addr: vsockAddr{cid: unix.VMADDR_CID_ANY, port: port}}, nil
+}
+
+// vsockListener is a net.Listener for AF_VSOCK sockets.
+type vsockListener struct {
+	file *os.File
+	rc   syscall.RawConn
+	addr vsockAddr
+}
+
+// Accept waits for the next VSOCK connection.
+func (l *vsockListener) Accept() (net.Conn, error) {
+	var (
+		nfd       int
+		sa        unix.Sockaddr
+		acceptErr error
+	)
+	err := l.rc.Read(func(fd uintptr) bool {
+		nfd, sa, acceptErr = unix.Accept4(int(fd), unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC)
+		return !errors.Is(acceptErr, unix.EAGAIN)
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrClosed) {
+			return nil, net.ErrClosed
+		}
+		return nil, err
+	}
+	if acceptErr != nil {
+		return nil, acceptErr
+	}
+
+	remote := vsockAddr{}
+	if vm, ok := sa.(*unix.SockaddrVM); ok {
+		remote = vsockAddr{cid: vm.CID, port: vm.Port}
+	}
+	f := os.NewFile(uintptr(nfd), "vsock:"+remote.String())
+	rc, err := f.SyscallConn()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &vsockConn{File: f, rc: rc, local: l.addr, remote: remote}, nil
+}
+
+// Close stops the listener, unblocking any pending Accept.
+func (l *vsockListener) Close() error { return l.file.Close() }
+
+// Addr returns the listening address.
+func (l *vsockListener) Addr() net.Addr { return l.addr }
+
+// vsockConn is a net.Conn over an accepted AF_VSOCK socket.
+type vsockConn struct {
+	*os.File
+	rc     syscall.RawConn
+	local  vsockAddr
+	remote vsockAddr
+}
+
+func (c *vsockConn) LocalAddr() net.Addr  { return c.local }
+func (c *vsockConn) RemoteAddr() net.Addr { return c.remote }
+
+// CloseWrite shuts down the sending side of the socket.
+func (c *vsockConn) CloseWrite() error {
+	var shutdownErr error
+	if err := c.rc.Control(func(fd uintptr) {
+		shutdownErr = unix.Shutdown(int(fd), unix.SHUT_WR)
+	}); err != nil {
+		return err
+	}
+	return shutdownErr
+}
+
+// vsockAddr implements net.Addr for VSOCK endpoints.
+type vsockAddr struct {
+	cid  uint32
+	port uint32
+}
+
+func (a vsockAddr) Network() string { return "vsock" }
+func (a vsockAddr) String() string  { return fmt.Sprintf("vsock://%d:%d", a.cid, a.port) }
@@ -0,0 +1,88 @@
+package tunnel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	protoVersion uint8 = 0
+
+	// headerSize is the size of a frame header on the wire.
+	headerSize = 12
+
+	// maxFrameData caps the payload of a single data frame so one busy
+	// stream cannot monopolise the underlying connection.
+	maxFrameData = 32 * 1024
+
+	// maxTargetLen bounds the target address carried by a SYN frame.
+	maxTargetLen = 1024
+
+	// maxResetLen bounds the error message carried by a RST frame.
+	maxResetLen = 4096
+)
+
+// frameType identifies the kind of frame.
+type frameType uint8
+
+const (
+	// typeData carries stream payload. With flagSYN the payload is the
+	// target address; with flagRST it is an error message.
+	typeData frameType = iota
+	// typeWindowUpdate grants the peer more send window. The length field
+	// holds the window delta. With flagACK it also confirms a stream open.
+	typeWindowUpdate
+	// typeGoAway tells the peer no new streams will be accepted.
+	typeGoAway
+)
+
+// Frame flags.
+const (
+	flagSYN uint16 = 1 << iota // open a new stream
+	flagACK                    // acknowledge a stream open
+	flagFIN                    // half-close: sender will write no more data
+	flagRST                    // abort the stream
+)
+
+// header is a decoded frame header.
+//
+// Wire layout (big endian):
+//
+//	version(1) type(1) flags(2) streamID(4) length(4)
+type header struct {
+	version  uint8
+	typ      frameType
+	flags    uint16
+	streamID uint32
+	length   uint32
+}
+
+func (h header) encode(buf []byte) {
+	buf[0] = h.version
+	buf[1] = byte(h.typ)
+	binary.BigEndian.PutUint16(buf[2:4], h.flags)
+	binary.BigEndian.PutUint32(buf[4:8], h.streamID)
+	binary.BigEndian.PutUint32(buf[8:12], h.length)
+}
+
+// readHeader reads and validates the next frame header from r.
+func readHeader(r io.Reader, buf []byte) (header, error) {
+	if _, err := io.ReadFull(r, buf[:headerSize]); err != nil {
+		return header{}, err
+	}
+	h := header{
+		version:  buf[0],
+		typ:      frameType(buf[1]),
+		flags:    binary.BigEndian.Uint16(buf[2:4]),
+		streamID: binary.BigEndian.Uint32(buf[4:8]),
+		length:   binary.BigEndian.Uint32(buf[8:12]),
+	}
+	if h.version != protoVersion {
+		return header{}, fmt.Errorf("%w: unsupported version %d", ErrProtocol, h.version)
+	}
+	if h.typ > typeGoAway {
+		return header{}, fmt.Errorf("%w: unknown frame type %d", ErrProtocol, h.typ)
+	}
+	return h, nil
+}
@@ -0,0 +1,51 @@
+package tunnel
+
+import (
+	"io"
+	"sync"
+)
+
+// closeWriter is implemented by connections that support half-close.
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// Join copies data in both directions between a and b until both
+// directions reach EOF, then closes both. EOF on one side is propagated to
+// the other with CloseWrite when supported (and Close otherwise), so
+// half-closed connections keep working. If either direction fails, both
+// connections are closed and the first error is returned.
+//
+// It returns the number of bytes copied from a to b and from b to a.
+func Join(a, b io.ReadWriteCloser) (aToB, bToA int64, err error) {
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	pipe := func(dst, src io.ReadWriteCloser, n *int64) {
+		defer wg.Done()
+		var copyErr error
+		*n, copyErr = io.Copy(dst, src)
+		if copyErr != nil {
+			errOnce.Do(func() { firstErr = copyErr })
+			_ = a.Close()
+			_ = b.Close()
+			return
+		}
+		if cw, ok := dst.(closeWriter); ok {
+			_ = cw.CloseWrite()
+		} else {
+			_ = dst.Close()
+		}
+	}
+
+	wg.Add(2)
+	go pipe(b, a, &aToB)
+	go pipe(a, b, &bToA)
+	wg.Wait()
+
+	_ = a.Close()
+	_ = b.Close()
+	return aToB, bToA, firstErr
+}
@@ -0,0 +1,454 @@
+// Package tunnel implements a small multiplexed stream protocol, in the
+// spirit of yamux, for forwarding TCP connections into sandboxes over a
+// single transport such as an exec stream's stdio or a VSOCK connection.
+//
+// One side opens a stream by naming a target address. The other side dials
+// the target with its Config.Dial function and splices the two together.
+// Every stream has its own flow-control window and supports half-close, and
+// per-stream statistics are reported when a stream finishes.
+//
+// The package is shared by the discobot-agent (which serves tunnels inside
+// the sandbox) and the server (which opens streams through them).
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VsockPort is the VSOCK port on which the port proxy inside a VZ project
+// VM serves tunnel sessions to the host.
+const VsockPort = 2376
+
+const (
+	// streamWindow is the per-stream receive window. Both sides use the
+	// same value, so a sender may have this much unacknowledged data.
+	streamWindow = 256 * 1024
+
+	// dialTimeout bounds how long the accepting side waits for a target.
+	dialTimeout = 10 * time.Second
+)
+
+var (
+	// ErrSessionClosed is returned for operations on a closed session.
+	ErrSessionClosed = errors.New("tunnel: session closed")
+
+	// ErrSessionShutdown is returned by Open once either side has started
+	// a graceful shutdown.
+	ErrSessionShutdown = errors.New("tunnel: session shutting down")
+
+	// ErrStreamReset is wrapped by errors reported when the peer aborts a stream.
+	ErrStreamReset = errors.New("tunnel: stream reset")
+
+	// ErrProtocol is wrapped by errors caused by malformed frames.
+	ErrProtocol = errors.New("tunnel: protocol error")
+)
+
+// DialFunc connects an incoming stream to its target address.
+type DialFunc func(ctx context.Context, target string) (io.ReadWriteCloser, error)
+
+// Config configures a Session.
+type Config struct {
+	// Dial connects streams opened by the peer. When nil, the session
+	// rejects incoming streams.
+	Dial DialFunc
+
+	// OnStreamClose, if set, is called once per stream with its final
+	// statistics after the stream has finished.
+	OnStreamClose func(StreamStats)
+}
+
+// Stats is a snapshot of session-wide counters.
+type Stats struct {
+	ActiveStreams int    `json:"activeStreams"`
+	TotalStreams  uint64 `json:"totalStreams"`
+	DialErrors    uint64 `json:"dialErrors"`
+	BytesIn       uint64 `json:"bytesIn"`
+	BytesOut      uint64 `json:"bytesOut"`
+}
+
+// StreamStats describes a finished stream.
+type StreamStats struct {
+	ID       uint32
+	Target   string
+	Inbound  bool // opened by the peer
+	Opened   time.Time
+	Duration time.Duration
+	BytesIn  uint64 // payload received from the peer
+	BytesOut uint64 // payload sent to the peer
+	Err      error  // nil when the stream closed cleanly
+}
+
+// Session multiplexes streams over a single connection.
+type Session struct {
+	conn          io.ReadWriteCloser
+	dial          DialFunc
+	onStreamClose func(StreamStats)
+
+	writeMu  sync.Mutex
+	writeBuf []byte
+
+	mu           sync.Mutex
+	streams      map[uint32]*Stream
+	nextID       uint32
+	localGoAway  bool
+	remoteGoAway bool
+	closed       bool
+	err          error
+	changed      chan struct{} // closed and replaced whenever a stream is removed
+	done         chan struct{}
+
+	totalStreams atomic.Uint64
+	dialErrors   atomic.Uint64
+	bytesIn      atomic.Uint64
+	bytesOut     atomic.Uint64
+}
+
+// Client starts a session on conn for the side that dialed the transport.
+// Both sides may open streams; Client and Server only differ in the stream
+// IDs they allocate.
+func Client(conn io.ReadWriteCloser, cfg *Config) *Session {
+	return newSession(conn, cfg, 1)
+}
+
+// Server starts a session on conn for the side that accepted the transport.
+func Server(conn io.ReadWriteCloser, cfg *Config) *Session {
+	return newSession(conn, cfg, 2)
+}
+
+func newSession(conn io.ReadWriteCloser, cfg *Config, firstID uint32) *Session {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	s := &Session{
+		conn:          conn,
+		dial:          cfg.Dial,
+		onStreamClose: cfg.OnStreamClose,
+		writeBuf:      make([]byte, headerSize+max(maxFrameData, maxResetLen)),
+		streams:       make(map[uint32]*Stream),
+		nextID:        firstID,
+		changed:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.recvLoop()
+	return s
+}
+
+// Open opens a stream to target on the peer's side and waits for the peer
+// to connect it. Dial failures on the peer are returned as errors wrapping
+// ErrStreamReset.
+func (s *Session) Open(ctx context.Context, target string) (*Stream, error) {
+	if target == "" || len(target) > maxTargetLen {
+		return nil, fmt.Errorf("tunnel: invalid target %q", target)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrSessionClosed
+	}
+	if s.localGoAway || s.remoteGoAway {
+		s.mu.Unlock()
+		return nil, ErrSessionShutdown
+	}
+	id := s.nextID
+	s.nextID += 2
+	st := newStream(s, id, target, false)
+	s.streams[id] = st
+	s.mu.Unlock()
+	s.totalStreams.Add(1)
+
+	if err := s.writeFrame(header{typ: typeData, flags: flagSYN, streamID: id}, []byte(target)); err != nil {
+		st.handleReset(err)
+		return nil, err
+	}
+
+	select {
+	case <-st.established:
+		if err := st.resetError(); err != nil {
+			return nil, err
+		}
+		return st, nil
+	case <-ctx.Done():
+		st.abort(ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the session counters.
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	active := len(s.streams)
+	s.mu.Unlock()
+	return Stats{
+		ActiveStreams: active,
+		TotalStreams:  s.totalStreams.Load(),
+		DialErrors:    s.dialErrors.Load(),
+		BytesIn:       s.bytesIn.Load(),
+		BytesOut:      s.bytesOut.Load(),
+	}
+}
+
+// Done returns a channel that is closed when the session terminates.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that terminated the session, or nil if it was
+// closed locally or the peer disconnected cleanly.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Shutdown stops the session gracefully: the peer is told not to open new
+// streams, incoming opens are refused, and Shutdown waits for active streams
+// to finish before closing. If ctx ends first the remaining streams are reset.
+func (s *Session) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.localGoAway = true
+	s.mu.Unlock()
+	_ = s.writeFrame(header{typ: typeGoAway}, nil)
+
+	for {
+		s.mu.Lock()
+		active := len(s.streams)
+		changed := s.changed
+		s.mu.Unlock()
+		if active == 0 {
+			return s.Close()
+		}
+		select {
+		case <-changed:
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			_ = s.Close()
+			return ctx.Err()
+		}
+	}
+}
+
+// Close terminates the session immediately, resetting all active streams.
+func (s *Session) Close() error {
+	_ = s.writeFrame(header{typ: typeGoAway}, nil)
+	s.closeWithError(nil)
+	return nil
+}
+
+// closeWithError tears down the session. err is recorded unless it merely
+// reports that the peer went away.
+func (s *Session) closeWithError(err error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+		s.err = err
+	}
+	streams := make([]*Stream, 0, len(s.streams))
+	for _, st := range s.streams {
+		streams = append(streams, st)
+	}
+	s.mu.Unlock()
+
+	_ = s.conn.Close()
+
+	for _, st := range streams {
+		st.handleReset(ErrSessionClosed)
+	}
+	close(s.done)
+}
+
+// writeFrame writes a single frame. Frames are written whole so concurrent
+// streams never interleave on the wire.
+func (s *Session) writeFrame(h header, payload []byte) error {
+	h.version = protoVersion
+	h.length = uint32(len(payload)) + h.length
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+
+	h.encode(s.writeBuf)
+	n := copy(s.writeBuf[headerSize:], payload)
+	if _, err := s.conn.Write(s.writeBuf[:headerSize+n]); err != nil {
+		go s.closeWithError(err)
+		return err
+	}
+	return nil
+}
+
+// sendReset aborts stream id on the peer, carrying msg as the reason.
+func (s *Session) sendReset(id uint32, msg string) error {
+	if len(msg) > maxResetLen {
+		msg = msg[:maxResetLen]
+	}
+	return s.writeFrame(header{typ: typeData, flags: flagRST, streamID: id}, []byte(msg))
+}
+
+func (s *Session) stream(id uint32) *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[id]
+}
+
+// removeStream drops a finished stream and reports its statistics.
+func (s *Session) removeStream(st *Stream) {
+	s.mu.Lock()
+	if s.streams[st.id] == st {
+		delete(s.streams, st.id)
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+	s.mu.Unlock()
+
+	if s.onStreamClose != nil {
+		s.onStreamClose(st.stats())
+	}
+}
+
+// recvLoop reads frames until the transport fails. It never writes to the
+// transport itself so a peer that is slow to read cannot deadlock it.
+func (s *Session) recvLoop() {
+	buf := make([]byte, headerSize)
+	for {
+		h, err := readHeader(s.conn, buf)
+		if err == nil {
+			err = s.handleFrame(h)
+		}
+		if err != nil {
+			s.closeWithError(err)
+			return
+		}
+	}
+}
+
+func (s *Session) handleFrame(h header) error {
+	switch h.typ {
+	case typeGoAway:
+		s.mu.Lock()
+		s.remoteGoAway = true
+		s.mu.Unlock()
+		return nil
+
+	case typeWindowUpdate:
+		if st := s.stream(h.streamID); st != nil {
+			st.handleWindowUpdate(h)
+		}
+		return nil
+	}
+
+	switch {
+	case h.flags&flagSYN != 0:
+		return s.handleOpen(h)
+
+	case h.flags&flagRST != 0:
+		msg, err := s.readPayload(h.length, maxResetLen)
+		if err != nil {
+			return err
+		}
+		if st := s.stream(h.streamID); st != nil {
+			reason := string(msg)
+			if reason == "" {
+				reason = "closed by peer"
+			}
+			st.handleReset(fmt.Errorf("%w: %s", ErrStreamReset, reason))
+		}
+		return nil
+	}
+
+	st := s.stream(h.streamID)
+	if st == nil {
+		// Late data for a stream that was already reset locally.
+		_, err := io.CopyN(io.Discard, s.conn, int64(h.length))
+		return err
+	}
+	if h.length > 0 {
+		if err := st.handleData(h.length); err != nil {
+			return err
+		}
+	}
+	if h.flags&flagFIN != 0 {
+		st.handleFIN()
+	}
+	return nil
+}
+
+// handleOpen accepts a stream opened by the peer and dials its target.
+func (s *Session) handleOpen(h header) error {
+	target, err := s.readPayload(h.length, maxTargetLen)
+	if err != nil {
+		return err
+	}
+	if h.streamID%2 == s.nextID%2 {
+		return fmt.Errorf("%w: peer opened stream %d with local parity", ErrProtocol, h.streamID)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	if _, exists := s.streams[h.streamID]; exists {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: duplicate stream %d", ErrProtocol, h.streamID)
+	}
+	if s.localGoAway || s.dial == nil {
+		s.mu.Unlock()
+		go func() { _ = s.sendReset(h.streamID, "not accepting streams") }()
+		return nil
+	}
+	st := newStream(s, h.streamID, string(target), true)
+	s.streams[h.streamID] = st
+	s.mu.Unlock()
+	s.totalStreams.Add(1)
+
+	go s.serveStream(st)
+	return nil
+}
+
+// serveStream dials the target of an inbound stream and splices them.
+func (s *Session) serveStream(st *Stream) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	conn, err := s.dial(ctx, st.target)
+	cancel()
+	if err != nil {
+		s.dialErrors.Add(1)
+		st.abort(fmt.Errorf("dial %s: %w", st.target, err))
+		return
+	}
+
+	st.establish()
+	if err := s.writeFrame(header{typ: typeWindowUpdate, flags: flagACK, streamID: st.id}, nil); err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	_, _, _ = Join(st, conn)
+}
+
+// readPayload reads a control payload of at most limit bytes.
+func (s *Session) readPayload(length uint32, limit int) ([]byte, error) {
+	if int(length) > limit {
+		return nil, fmt.Errorf("%w: payload of %d bytes exceeds %d", ErrProtocol, length, limit)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(s.conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
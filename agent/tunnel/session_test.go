@@ -0,0 +1,272 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startEcho starts a TCP server that echoes everything it reads and
+// half-closes once the client does.
+func startEcho(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+				_ = conn.(*net.TCPConn).CloseWrite()
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func tcpDial(ctx context.Context, target string) (io.ReadWriteCloser, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", target)
+}
+
+// newPair connects a client and server session over an in-memory pipe.
+func newPair(t *testing.T, serverCfg *Config) (client, server *Session) {
+	t.Helper()
+	c, s := net.Pipe()
+	client = Client(c, nil)
+	server = Server(s, serverCfg)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestOpenEchoWithHalfClose(t *testing.T) {
+	addr := startEcho(t)
+	client, _ := newPair(t, &Config{Dial: tcpDial})
+
+	st, err := client.Open(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer st.Close()
+
+	if _, err := st.Write([]byte("hello tunnel")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := st.CloseWrite(); err != nil {
+		t.Fatalf("CloseWrite: %v", err)
+	}
+
+	got, err := io.ReadAll(st)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "hello tunnel" {
+		t.Errorf("echo = %q, want %q", got, "hello tunnel")
+	}
+}
+
+func TestLargeTransferRespectsFlowControl(t *testing.T) {
+	addr := startEcho(t)
+	client, _ := newPair(t, &Config{Dial: tcpDial})
+
+	st, err := client.Open(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer st.Close()
+
+	// Several windows worth of data so both directions must wait for
+	// window updates.
+	payload := make([]byte, 4*streamWindow+123)
+	_, _ = rand.Read(payload)
+
+	go func() {
+		_, _ = st.Write(payload)
+		_ = st.CloseWrite()
+	}()
+
+	got, err := io.ReadAll(st)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("received %d bytes, want %d identical bytes", len(got), len(payload))
+	}
+}
+
+func TestOpenReportsDialError(t *testing.T) {
+	client, server := newPair(t, &Config{
+		Dial: func(context.Context, string) (io.ReadWriteCloser, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+
+	_, err := client.Open(context.Background(), "127.0.0.1:1")
+	if !errors.Is(err, ErrStreamReset) {
+		t.Fatalf("Open error = %v, want ErrStreamReset", err)
+	}
+	if want := "dial 127.0.0.1:1: connection refused"; !bytes.Contains([]byte(err.Error()), []byte(want)) {
+		t.Errorf("Open error = %q, want it to contain %q", err, want)
+	}
+	if got := server.Stats().DialErrors; got != 1 {
+		t.Errorf("DialErrors = %d, want 1", got)
+	}
+}
+
+func TestServerWithoutDialRejectsStreams(t *testing.T) {
+	client, _ := newPair(t, nil)
+
+	if _, err := client.Open(context.Background(), "127.0.0.1:1"); !errors.Is(err, ErrStreamReset) {
+		t.Fatalf("Open error = %v, want ErrStreamReset", err)
+	}
+}
+
+func TestStreamStatsReported(t *testing.T) {
+	addr := startEcho(t)
+
+	var mu sync.Mutex
+	var stats []StreamStats
+	closed := make(chan struct{})
+	client, _ := newPair(t, &Config{
+		Dial: tcpDial,
+		OnStreamClose: func(s StreamStats) {
+			mu.Lock()
+			stats = append(stats, s)
+			mu.Unlock()
+			close(closed)
+		},
+	})
+
+	st, err := client.Open(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	_, _ = st.Write([]byte("12345"))
+	_ = st.CloseWrite()
+	_, _ = io.ReadAll(st)
+	st.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnStreamClose was not called")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	s := stats[0]
+	if !s.Inbound || s.Target != addr {
+		t.Errorf("stats = %+v, want inbound stream to %s", s, addr)
+	}
+	if s.BytesIn != 5 || s.BytesOut != 5 {
+		t.Errorf("bytes in/out = %d/%d, want 5/5", s.BytesIn, s.BytesOut)
+	}
+	if s.Err != nil {
+		t.Errorf("Err = %v, want nil", s.Err)
+	}
+}
+
+func TestReadDeadline(t *testing.T) {
+	addr := startEcho(t)
+	client, _ := newPair(t, &Config{Dial: tcpDial})
+
+	st, err := client.Open(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer st.Close()
+
+	_ = st.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	var ne net.Error
+	if _, err := st.Read(make([]byte, 1)); !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("Read error = %v, want timeout", err)
+	}
+}
+
+func TestShutdownWaitsForStreamsAndRefusesNew(t *testing.T) {
+	addr := startEcho(t)
+	client, server := newPair(t, &Config{Dial: tcpDial})
+
+	st, err := client.Open(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- server.Shutdown(context.Background()) }()
+
+	// Wait for the go-away to reach the client.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		extra, err := client.Open(context.Background(), addr)
+		if errors.Is(err, ErrSessionShutdown) {
+			break
+		}
+		if extra != nil {
+			extra.Close()
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Open during shutdown = %v, want ErrSessionShutdown", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("Shutdown returned %v with an active stream", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The in-flight stream still works.
+	_, _ = st.Write([]byte("bye"))
+	_ = st.CloseWrite()
+	if got, _ := io.ReadAll(st); string(got) != "bye" {
+		t.Errorf("echo = %q, want %q", got, "bye")
+	}
+	st.Close()
+
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not return after streams finished")
+	}
+	<-client.Done()
+}
+
+func TestCloseResetsActiveStreams(t *testing.T) {
+	addr := startEcho(t)
+	client, server := newPair(t, &Config{Dial: tcpDial})
+
+	st, err := client.Open(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	server.Close()
+
+	if _, err := st.Read(make([]byte, 1)); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("Read after peer close = %v, want ErrSessionClosed", err)
+	}
+	if _, err := client.Open(context.Background(), addr); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("Open after close = %v, want ErrSessionClosed", err)
+	}
+}
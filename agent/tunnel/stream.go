@@ -0,0 +1,394 @@
+package tunnel
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+var _ net.Conn = (*Stream)(nil)
+
+// Stream is one multiplexed connection within a Session. It implements
+// net.Conn and supports half-close via CloseWrite.
+type Stream struct {
+	session *Session
+	id      uint32
+	target  string
+	inbound bool
+	opened  time.Time
+
+	established   chan struct{}
+	establishOnce sync.Once
+
+	// writeMu serialises Write and CloseWrite so a FIN never overtakes data.
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	notify        chan struct{} // closed and replaced on every state change
+	recvBuf       bytes.Buffer
+	recvUnacked   uint32 // bytes consumed but not yet returned to the peer
+	sendWindow    uint32
+	readClosed    bool // peer sent FIN
+	writeClosed   bool // we sent FIN
+	localClosed   bool // Close was called
+	finished      bool
+	resetErr      error
+	readDeadline  time.Time
+	writeDeadline time.Time
+	bytesIn       uint64
+	bytesOut      uint64
+	closedAt      time.Time
+}
+
+func newStream(s *Session, id uint32, target string, inbound bool) *Stream {
+	return &Stream{
+		session:     s,
+		id:          id,
+		target:      target,
+		inbound:     inbound,
+		opened:      time.Now(),
+		established: make(chan struct{}),
+		notify:      make(chan struct{}),
+		sendWindow:  streamWindow,
+	}
+}
+
+// ID returns the stream identifier.
+func (st *Stream) ID() uint32 { return st.id }
+
+// Target returns the address the stream was opened to.
+func (st *Stream) Target() string { return st.target }
+
+// Read reads data sent by the peer. It returns io.EOF after the peer
+// half-closes and an error wrapping ErrStreamReset if the peer aborts.
+func (st *Stream) Read(p []byte) (int, error) {
+	st.mu.Lock()
+	for {
+		if st.recvBuf.Len() > 0 {
+			n, _ := st.recvBuf.Read(p)
+			st.bytesIn += uint64(n)
+			st.recvUnacked += uint32(n)
+			var delta uint32
+			if st.recvUnacked >= streamWindow/2 && !st.readClosed {
+				delta = st.recvUnacked
+				st.recvUnacked = 0
+			}
+			st.mu.Unlock()
+			if delta > 0 {
+				_ = st.session.writeFrame(header{typ: typeWindowUpdate, streamID: st.id, length: delta}, nil)
+			}
+			return n, nil
+		}
+		if st.resetErr != nil {
+			err := st.resetErr
+			st.mu.Unlock()
+			return 0, err
+		}
+		if st.readClosed {
+			st.mu.Unlock()
+			return 0, io.EOF
+		}
+		if st.localClosed {
+			st.mu.Unlock()
+			return 0, net.ErrClosed
+		}
+		if err := st.waitLocked(st.readDeadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write sends p to the peer, blocking while the peer's receive window is full.
+func (st *Stream) Write(p []byte) (int, error) {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+
+	total := 0
+	st.mu.Lock()
+	for len(p) > 0 {
+		if st.resetErr != nil {
+			err := st.resetErr
+			st.mu.Unlock()
+			return total, err
+		}
+		if st.writeClosed || st.localClosed {
+			st.mu.Unlock()
+			return total, net.ErrClosed
+		}
+		if st.sendWindow == 0 {
+			if err := st.waitLocked(st.writeDeadline); err != nil {
+				return total, err
+			}
+			continue
+		}
+
+		n := min(uint32(len(p)), st.sendWindow, maxFrameData)
+		st.sendWindow -= n
+		st.mu.Unlock()
+
+		if err := st.session.writeFrame(header{typ: typeData, streamID: st.id}, p[:n]); err != nil {
+			return total, err
+		}
+		st.session.bytesOut.Add(uint64(n))
+		total += int(n)
+		p = p[n:]
+
+		st.mu.Lock()
+		st.bytesOut += uint64(n)
+	}
+	st.mu.Unlock()
+	return total, nil
+}
+
+// CloseWrite half-closes the stream: the peer reads io.EOF once it has
+// consumed all data written so far, while this side may keep reading.
+func (st *Stream) CloseWrite() error {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+
+	st.mu.Lock()
+	if st.writeClosed || st.resetErr != nil || st.finished {
+		st.mu.Unlock()
+		return nil
+	}
+	st.writeClosed = true
+	st.mu.Unlock()
+
+	err := st.session.writeFrame(header{typ: typeWindowUpdate, flags: flagFIN, streamID: st.id}, nil)
+	st.finishIfDone()
+	return err
+}
+
+// Close closes the stream. If the peer has already finished sending, the
+// stream is half-closed gracefully; otherwise the peer is told to abort.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.finished || st.localClosed {
+		st.mu.Unlock()
+		return nil
+	}
+	st.localClosed = true
+	st.notifyLocked()
+	var h header
+	send := false
+	switch {
+	case st.resetErr != nil:
+	case !st.readClosed:
+		h = header{typ: typeData, flags: flagRST, streamID: st.id}
+		send = true
+	case !st.writeClosed:
+		st.writeClosed = true
+		h = header{typ: typeWindowUpdate, flags: flagFIN, streamID: st.id}
+		send = true
+	}
+	st.mu.Unlock()
+
+	var err error
+	if send {
+		err = st.session.writeFrame(h, nil)
+	}
+	st.finish()
+	return err
+}
+
+// LocalAddr returns a synthetic address naming the stream.
+func (st *Stream) LocalAddr() net.Addr { return Addr(fmt.Sprintf("stream-%d", st.id)) }
+
+// RemoteAddr returns the stream's target address.
+func (st *Stream) RemoteAddr() net.Addr { return Addr(st.target) }
+
+// SetDeadline sets both the read and write deadlines.
+func (st *Stream) SetDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.writeDeadline = t
+	st.notifyLocked()
+	st.mu.Unlock()
+	return nil
+}
+
+// SetReadDeadline sets the deadline for future and pending Read calls.
+func (st *Stream) SetReadDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.notifyLocked()
+	st.mu.Unlock()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future and pending Write calls.
+func (st *Stream) SetWriteDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.writeDeadline = t
+	st.notifyLocked()
+	st.mu.Unlock()
+	return nil
+}
+
+// waitLocked releases st.mu and blocks until the stream state changes or
+// the deadline passes. On success it returns with st.mu held again; on
+// timeout it returns os.ErrDeadlineExceeded with st.mu released.
+func (st *Stream) waitLocked(deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			st.mu.Unlock()
+			return os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	notify := st.notify
+	st.mu.Unlock()
+
+	select {
+	case <-notify:
+		st.mu.Lock()
+		return nil
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+func (st *Stream) notifyLocked() {
+	close(st.notify)
+	st.notify = make(chan struct{})
+}
+
+func (st *Stream) establish() {
+	st.establishOnce.Do(func() { close(st.established) })
+}
+
+func (st *Stream) resetError() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.resetErr
+}
+
+// handleData reads a data payload of length bytes from the transport into
+// the receive buffer.
+func (st *Stream) handleData(length uint32) error {
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(st.session.conn, buf); err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.localClosed || st.resetErr != nil {
+		return nil
+	}
+	if uint32(st.recvBuf.Len())+length > streamWindow {
+		return fmt.Errorf("%w: stream %d exceeded receive window", ErrProtocol, st.id)
+	}
+	st.recvBuf.Write(buf)
+	st.session.bytesIn.Add(uint64(length))
+	st.notifyLocked()
+	return nil
+}
+
+func (st *Stream) handleWindowUpdate(h header) {
+	if h.flags&flagACK != 0 {
+		st.establish()
+	}
+	if h.length > 0 {
+		st.mu.Lock()
+		st.sendWindow += h.length
+		st.notifyLocked()
+		st.mu.Unlock()
+	}
+	if h.flags&flagFIN != 0 {
+		st.handleFIN()
+	}
+	if h.flags&flagRST != 0 {
+		st.handleReset(fmt.Errorf("%w: closed by peer", ErrStreamReset))
+	}
+}
+
+func (st *Stream) handleFIN() {
+	st.mu.Lock()
+	st.readClosed = true
+	st.notifyLocked()
+	st.mu.Unlock()
+	st.finishIfDone()
+}
+
+// handleReset marks the stream as aborted with err.
+func (st *Stream) handleReset(err error) {
+	st.mu.Lock()
+	if st.resetErr == nil {
+		st.resetErr = err
+	}
+	st.notifyLocked()
+	st.mu.Unlock()
+	st.establish()
+	st.finish()
+}
+
+// abort resets the stream locally and tells the peer why.
+func (st *Stream) abort(err error) {
+	st.mu.Lock()
+	alreadyReset := st.resetErr != nil
+	if !alreadyReset {
+		st.resetErr = err
+	}
+	st.notifyLocked()
+	st.mu.Unlock()
+	if !alreadyReset {
+		_ = st.session.sendReset(st.id, err.Error())
+	}
+	st.establish()
+	st.finish()
+}
+
+// finishIfDone finishes the stream once both directions are closed.
+func (st *Stream) finishIfDone() {
+	st.mu.Lock()
+	done := st.readClosed && st.writeClosed
+	st.mu.Unlock()
+	if done {
+		st.finish()
+	}
+}
+
+func (st *Stream) finish() {
+	st.mu.Lock()
+	if st.finished {
+		st.mu.Unlock()
+		return
+	}
+	st.finished = true
+	st.closedAt = time.Now()
+	st.mu.Unlock()
+	st.session.removeStream(st)
+}
+
+func (st *Stream) stats() StreamStats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return StreamStats{
+		ID:       st.id,
+		Target:   st.target,
+		Inbound:  st.inbound,
+		Opened:   st.opened,
+		Duration: st.closedAt.Sub(st.opened),
+		BytesIn:  st.bytesIn,
+		BytesOut: st.bytesOut,
+		Err:      st.resetErr,
+	}
+}
+
+// Addr is the net.Addr of a tunnel stream endpoint.
+type Addr string
+
+// Network returns "tunnel".
+func (a Addr) Network() string { return "tunnel" }
+
+// String returns the address.
+func (a Addr) String() string { return string(a) }
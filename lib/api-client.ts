@@ -54,6 +54,7 @@ import type {
 	SessionDiffResponse,
 	SessionSingleFileDiffResponse,
 	StartServiceResponse,
+	StartupTimeline,
 	StopServiceResponse,
 	Suggestion,
 	SupportedAgentType,
@@ -622,6 +623,14 @@ class ApiClient {
 		);
 	}
 
+	/**
+	 * Get the startup timeline of a session's sandbox.
+	 * @param sessionId Session ID
+	 */
+	async getSessionStartup(sessionId: string): Promise<StartupTimeline> {
+		return this.fetch<StartupTimeline>(`/sessions/${sessionId}/startup`);
+	}
+
 	// Hooks
 	/**
 	 * Get hook evaluation status for a session's sandbox.
@@ -20,6 +20,7 @@ export const CommitStatus = {
 	NONE: "",
 	PENDING: "pending",
 	COMMITTING: "committing",
+	REVIEW: "review",
 	COMPLETED: "completed",
 	FAILED: "failed",
 } as const;
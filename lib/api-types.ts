@@ -59,6 +59,8 @@ export interface Session {
 	status: SessionStatus;
 	/** Commit status (orthogonal to session status) */
 	commitStatus?: CommitStatus;
+	/** Whether the commit stops in "review" so the user can select patches before they are applied */
+	commitReview?: boolean;
 	/** Error message if commit status is "failed" */
 	commitError?: string;
 	/** Workspace commit SHA when commit started (expected parent) */
@@ -107,6 +109,10 @@ export interface Workspace {
 	commit?: string;
 	/** Working directory path on disk (if initialized) */
 	workDir?: string;
+	/** Sandbox image built from the workspace's devcontainer.json */
+	sandboxImage?: string;
+	/** Why devcontainer.json could not be (fully) applied */
+	devcontainerError?: string;
 }
 
 export interface Agent {
@@ -704,7 +710,7 @@ export interface AnswerQuestionResponse {
 export interface HookRunStatus {
 	hookId: string;
 	hookName: string;
-	type: "session" | "file" | "pre-commit";
+	type: "session" | "shutdown" | "file" | "pre-commit";
 	lastRunAt: string;
 	lastResult: "success" | "failure" | "running";
 	lastExitCode: number;
@@ -712,6 +718,16 @@ export interface HookRunStatus {
 	runCount: number;
 	failCount: number;
 	consecutiveFailures: number;
+	/** Attempts of the last run of a session hook */
+	attempts?: HookAttempt[];
+}
+
+/** One attempt of a hook run */
+export interface HookAttempt {
+	startedAt: string;
+	durationMs: number;
+	exitCode: number;
+	result: "success" | "failure";
 }
 
 /** Hook evaluation status for a session */
@@ -721,6 +737,24 @@ export interface HooksStatusResponse {
 	lastEvaluatedAt: string;
 }
 
+/** One setup step of a sandbox's startup */
+export interface StartupStep {
+	name: string;
+	startedAt: string;
+	endedAt?: string;
+	outcome: "running" | "success" | "warning" | "failure";
+	error?: string;
+}
+
+/** Sandbox startup timeline recorded by the agent */
+export interface StartupTimeline {
+	startedAt: string;
+	completedAt?: string;
+	outcome: "running" | "success" | "failure";
+	error?: string;
+	steps: StartupStep[];
+}
+
 /** Hook output log response */
 export interface HookOutputResponse {
 	output: string;
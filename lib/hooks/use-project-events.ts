@@ -18,6 +18,9 @@ export interface ProjectEvent {
 export interface SessionUpdatedData {
 	sessionId: string;
 	status: string;
+	commitStatus?: string;
+	/** Sandbox startup step currently running, if any */
+	subStatus?: string;
 }
 
 export interface WorkspaceUpdatedData {
@@ -2,16 +2,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	proxyapi "github.com/obot-platform/discobot/proxy/internal/api"
 	"github.com/obot-platform/discobot/proxy/internal/config"
 	"github.com/obot-platform/discobot/proxy/internal/logger"
+	"github.com/obot-platform/discobot/proxy/internal/metrics"
 	"github.com/obot-platform/discobot/proxy/internal/proxy"
+	"github.com/obot-platform/discobot/proxy/internal/tracing"
 )
 
 func main() {
@@ -33,6 +37,13 @@ func main() {
 	}
 	defer func() { _ = log.Close() }()
 
+	// Set up tracing to continue traces started in the sandbox
+	shutdownTracing, err := tracing.Setup(context.Background(), "discobot-proxy")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting up tracing: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create proxy server
 	proxyServer, err := proxy.New(cfg, log)
 	if err != nil {
@@ -40,6 +51,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	metrics.Registry.MustRegister(metrics.NewCacheCollector(proxyServer.GetCache()))
+
 	// Create API server
 	apiServer := proxyapi.New(proxyServer, log)
 
@@ -87,6 +100,12 @@ func main() {
 		log.Error("error during shutdown")
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Warn("failed to flush traces")
+	}
+
 	log.Info("shutdown complete")
 }
 
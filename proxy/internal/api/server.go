@@ -14,6 +14,7 @@ import (
 	"github.com/obot-platform/discobot/proxy/internal/cache"
 	"github.com/obot-platform/discobot/proxy/internal/config"
 	"github.com/obot-platform/discobot/proxy/internal/logger"
+	"github.com/obot-platform/discobot/proxy/internal/metrics"
 	"github.com/obot-platform/discobot/proxy/internal/proxy"
 )
 
@@ -46,6 +47,9 @@ func (s *Server) setupRoutes() {
 	// Health check
 	r.Get("/health", s.handleHealth)
 
+	// Prometheus metrics
+	r.Handle("/metrics", metrics.Handler())
+
 	// Configuration endpoint
 	r.Post("/api/config", s.handleSetConfig)
 	r.Patch("/api/config", s.handlePatchConfig)
@@ -178,6 +182,7 @@ func (s *Server) handleCacheStats(w http.ResponseWriter, _ *http.Request) {
 		"evictions":    stats.Evictions,
 		"errors":       stats.Errors,
 		"current_size": stats.CurrentSize,
+		"hit_bytes":    stats.HitBytes,
 		"hit_rate":     calculateHitRate(stats),
 	}
 
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/obot-platform/discobot/proxy/internal/config"
 	"github.com/obot-platform/discobot/proxy/internal/logger"
+	"github.com/obot-platform/discobot/proxy/internal/metrics"
 	"github.com/obot-platform/discobot/proxy/internal/proxy"
 )
 
@@ -318,3 +320,29 @@ func TestAPI_POSTConfig_DisableAllowlist(t *testing.T) {
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
+
+func TestAPI_Metrics(t *testing.T) {
+	proxyServer := createTestProxyServer(t)
+	log := testLogger(t)
+	apiServer := New(proxyServer, log)
+
+	metrics.ObserveBlocked("blocked.example.com:443", "https")
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+
+	apiServer.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`discobot_proxy_requests_total{host="blocked.example.com",outcome="blocked"}`,
+		`discobot_proxy_blocked_connections_total{protocol="https"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}
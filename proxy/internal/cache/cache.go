@@ -44,6 +44,7 @@ type Stats struct {
 	Evictions   int64
 	Errors      int64
 	CurrentSize int64
+	HitBytes    int64 // Body bytes served from cache
 }
 
 // Entry represents a cached HTTP response.
@@ -107,6 +108,7 @@ func (c *Cache) Get(key string) (*Entry, error) {
 	// Update LRU
 	c.index.access(key)
 	c.stats.Hits++
+	c.stats.HitBytes += entry.Size
 
 	return entry, nil
 }
@@ -0,0 +1,132 @@
+// Package metrics defines the proxy's Prometheus metrics.
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/obot-platform/discobot/proxy/internal/cache"
+)
+
+const namespace = "discobot_proxy"
+
+// Request outcomes.
+const (
+	OutcomeForwarded = "forwarded" // Sent upstream and answered
+	OutcomeCacheHit  = "cache_hit" // Served from the cache
+	OutcomeBlocked   = "blocked"   // Rejected by the allowlist
+	OutcomeTunneled  = "tunneled"  // SOCKS5 connection opened
+	OutcomeError     = "error"     // Upstream request failed
+)
+
+// Registry holds every proxy metric.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Requests counts proxied requests and SOCKS5 connections.
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Proxied requests by destination host and outcome.",
+	}, []string{"host", "outcome"})
+
+	// BlockedConnections counts connections the allowlist rejected.
+	BlockedConnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocked_connections_total",
+		Help:      "Connections rejected by the allowlist by protocol (http, https or socks5).",
+	}, []string{"protocol"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Requests,
+		BlockedConnections,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest counts a request to host with the given outcome.
+func ObserveRequest(host, outcome string) {
+	Requests.WithLabelValues(normalizeHost(host), outcome).Inc()
+}
+
+// ObserveBlocked counts a request to host rejected by the allowlist.
+func ObserveBlocked(host, protocol string) {
+	ObserveRequest(host, OutcomeBlocked)
+	BlockedConnections.WithLabelValues(protocol).Inc()
+}
+
+// normalizeHost strips the port and lowercases host so that one
+// destination maps to one series.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// cacheCollector reports cache.Stats at scrape time.
+type cacheCollector struct {
+	cache     *cache.Cache
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	stores    *prometheus.Desc
+	evictions *prometheus.Desc
+	errors    *prometheus.Desc
+	hitBytes  *prometheus.Desc
+	sizeBytes *prometheus.Desc
+}
+
+// NewCacheCollector returns a collector for the response cache's counters.
+// Counters restart from zero when the cache is cleared.
+func NewCacheCollector(c *cache.Cache) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help, nil, nil)
+	}
+	return &cacheCollector{
+		cache:     c,
+		hits:      desc("hits_total", "Requests served from the cache."),
+		misses:    desc("misses_total", "Cacheable requests not found in the cache."),
+		stores:    desc("stores_total", "Responses written to the cache."),
+		evictions: desc("evictions_total", "Entries evicted to stay under the size limit."),
+		errors:    desc("errors_total", "Cache read and write errors."),
+		hitBytes:  desc("hit_bytes_total", "Response body bytes served from the cache."),
+		sizeBytes: desc("size_bytes", "Current size of the cache."),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.stores
+	ch <- c.evictions
+	ch <- c.errors
+	ch <- c.hitBytes
+	ch <- c.sizeBytes
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.cache.GetStats()
+	counter := func(desc *prometheus.Desc, v int64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(v))
+	}
+	counter(c.hits, stats.Hits)
+	counter(c.misses, stats.Misses)
+	counter(c.stores, stats.Stores)
+	counter(c.evictions, stats.Evictions)
+	counter(c.errors, stats.Errors)
+	counter(c.hitBytes, stats.HitBytes)
+	ch <- prometheus.MustNewConstMetric(c.sizeBytes, prometheus.GaugeValue, float64(stats.CurrentSize))
+}
@@ -8,12 +8,18 @@ import (
 	"time"
 
 	"github.com/elazarl/goproxy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/obot-platform/discobot/proxy/internal/cache"
 	"github.com/obot-platform/discobot/proxy/internal/cert"
 	"github.com/obot-platform/discobot/proxy/internal/filter"
 	"github.com/obot-platform/discobot/proxy/internal/injector"
 	"github.com/obot-platform/discobot/proxy/internal/logger"
+	"github.com/obot-platform/discobot/proxy/internal/metrics"
+	"github.com/obot-platform/discobot/proxy/internal/tracing"
 )
 
 // HTTPProxy wraps goproxy for HTTP/HTTPS proxying.
@@ -30,7 +36,51 @@ type HTTPProxy struct {
 // between the request and response handlers.
 type requestMeta struct {
 	startTime time.Time
-	cacheHit  bool
+	span      trace.Span // nil unless the request carried trace context
+	done      bool       // outcome recorded
+}
+
+// startSpan starts a span for a request that carries trace context from the
+// sandbox and passes the span's context upstream in its place. Untraced
+// requests are forwarded unchanged.
+func (m *requestMeta) startSpan(req *http.Request) {
+	propagator := tracing.Propagator()
+	parent := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		return
+	}
+	ctx, span := tracing.Tracer().Start(parent, "proxy "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	m.span = span
+}
+
+// finish marks the request's outcome as recorded and ends its span.
+// goproxy also runs response handlers for responses produced by request
+// handlers, and may run them twice when the upstream request fails.
+func (m *requestMeta) finish(outcome string, status int) {
+	if m == nil {
+		return
+	}
+	m.done = true
+	if m.span == nil {
+		return
+	}
+	m.span.SetAttributes(attribute.String("proxy.outcome", outcome))
+	if status != 0 {
+		m.span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+	if outcome == metrics.OutcomeError || outcome == metrics.OutcomeBlocked {
+		m.span.SetStatus(codes.Error, outcome)
+	}
+	m.span.End()
+	m.span = nil
 }
 
 // NewHTTPProxy creates a new HTTP proxy.
@@ -106,6 +156,7 @@ func (h *HTTPProxy) setupHandlers() {
 	h.proxy.OnRequest().HandleConnectFunc(func(host string, _ *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
 		if !h.filter.AllowHost(host) {
 			h.logger.LogBlocked(host, "filter")
+			metrics.ObserveBlocked(host, "https")
 			return goproxy.RejectConnect, host
 		}
 		return goproxy.MitmConnect, host
@@ -115,10 +166,13 @@ func (h *HTTPProxy) setupHandlers() {
 	h.proxy.OnRequest().DoFunc(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
 		meta := &requestMeta{startTime: time.Now()}
 		ctx.UserData = meta
+		meta.startSpan(req)
 
 		// Filter check (for plain HTTP)
 		if !h.filter.AllowHost(req.Host) {
 			h.logger.LogBlocked(req.Host, "filter")
+			metrics.ObserveBlocked(req.Host, "http")
+			meta.finish(metrics.OutcomeBlocked, http.StatusForbidden)
 			return req, goproxy.NewResponse(req, goproxy.ContentTypeText, http.StatusForbidden, "Blocked by proxy")
 		}
 
@@ -126,14 +180,16 @@ func (h *HTTPProxy) setupHandlers() {
 		if h.cacheMatcher != nil && h.cacheMatcher.ShouldCache(req) {
 			key := h.cacheMatcher.GenerateKey(req)
 			if entry, err := h.cache.Get(key); err == nil {
-				meta.cacheHit = true
+				metrics.ObserveRequest(req.Host, metrics.OutcomeCacheHit)
 				h.logger.Info("cache hit",
 					"host", req.Host,
 					"path", req.URL.Path,
 					"size", entry.Size,
 					"cached_at", entry.CachedAt.Format(time.RFC3339),
 				)
-				return req, cache.RestoreResponse(entry, req)
+				resp := cache.RestoreResponse(entry, req)
+				meta.finish(metrics.OutcomeCacheHit, resp.StatusCode)
+				return req, resp
 			}
 			h.logger.Debug("cache miss", "host", req.Host, "path", req.URL.Path)
 		}
@@ -152,15 +208,21 @@ func (h *HTTPProxy) setupHandlers() {
 
 	// Log responses and cache if applicable
 	h.proxy.OnResponse().DoFunc(func(resp *http.Response, ctx *goproxy.ProxyCtx) *http.Response {
-		if resp == nil || ctx.Req == nil {
+		if ctx.Req == nil {
 			return resp
 		}
 
 		meta, _ := ctx.UserData.(*requestMeta)
 
-		// Cache hits were already logged in the request handler and never
-		// contacted upstream — nothing more to do here.
-		if meta != nil && meta.cacheHit {
+		// Blocked requests and cache hits were already recorded in the
+		// request handler and never contacted upstream — nothing more to do.
+		if meta != nil && meta.done {
+			return resp
+		}
+		if resp == nil {
+			// The upstream round trip failed
+			metrics.ObserveRequest(ctx.Req.Host, metrics.OutcomeError)
+			meta.finish(metrics.OutcomeError, 0)
 			return resp
 		}
 
@@ -169,6 +231,8 @@ func (h *HTTPProxy) setupHandlers() {
 			duration = time.Since(meta.startTime)
 		}
 		h.logger.LogResponse(resp, ctx.Req, duration)
+		metrics.ObserveRequest(ctx.Req.Host, metrics.OutcomeForwarded)
+		meta.finish(metrics.OutcomeForwarded, resp.StatusCode)
 
 		// Cache response if applicable
 		if h.cacheMatcher != nil && h.cacheMatcher.ShouldCache(ctx.Req) {
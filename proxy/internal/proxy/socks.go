@@ -9,6 +9,7 @@ import (
 
 	"github.com/obot-platform/discobot/proxy/internal/filter"
 	"github.com/obot-platform/discobot/proxy/internal/logger"
+	"github.com/obot-platform/discobot/proxy/internal/metrics"
 )
 
 // SOCKSProxy wraps go-socks5 for SOCKS5 proxying.
@@ -59,6 +60,11 @@ func (r *filterRule) Allow(ctx context.Context, req *socks5.Request) (context.Co
 
 	allowed := r.filter.AllowHost(host)
 	r.logger.LogSOCKSConnect(host, req.DestAddr.Port, allowed)
+	if allowed {
+		metrics.ObserveRequest(host, metrics.OutcomeTunneled)
+	} else {
+		metrics.ObserveBlocked(host, "socks5")
+	}
 
 	return ctx, allowed
 }
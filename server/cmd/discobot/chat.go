@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+// askUserQuestionTool is the tool the agent calls to ask the user clarifying
+// questions. The agent blocks until the questions are answered.
+const askUserQuestionTool = "AskUserQuestion"
+
+// chatEvent is the subset of an AI SDK UI message stream chunk the CLI
+// renders.
+type chatEvent struct {
+	Type       string          `json:"type"`
+	Delta      string          `json:"delta,omitempty"`
+	ToolName   string          `json:"toolName,omitempty"`
+	ToolCallID string          `json:"toolCallId,omitempty"`
+	Input      json.RawMessage `json:"input,omitempty"`
+	ErrorText  string          `json:"errorText,omitempty"`
+}
+
+// streamOptions controls how a chat stream is rendered.
+type streamOptions struct {
+	sessionID     string
+	interactive   bool
+	showReasoning bool
+}
+
+func (a *app) runPrompt(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("prompt", "[<session> | --workspace ID] [flags] <text...|->")
+	workspaceID := fs.String("workspace", "", "Start a new session in this workspace")
+	agentID := fs.String("agent", "", "Agent for a new session (project default if empty)")
+	modelID := fs.String("model", "", "Model ID (session or agent default if empty)")
+	plan := fs.Bool("plan", false, "Run in plan mode")
+	noInput := fs.Bool("no-input", false, "Do not prompt for answers to questions; answer them with 'discobot answer'")
+	showReasoning := fs.Bool("reasoning", false, "Print reasoning to stderr")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var sessionID string
+	if *workspaceID == "" {
+		if len(rest) == 0 {
+			fs.Usage()
+			return errors.New("prompt: expected a session ID or --workspace")
+		}
+		sessionID, rest = rest[0], rest[1:]
+	}
+	if len(rest) == 0 {
+		fs.Usage()
+		return errors.New("prompt: expected prompt text")
+	}
+
+	interactive := !*noInput
+	text := strings.Join(rest, " ")
+	if text == "-" {
+		data, err := io.ReadAll(a.stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt from stdin: %w", err)
+		}
+		text = string(data)
+		// Stdin is exhausted, so questions cannot be answered here.
+		interactive = false
+	}
+	if strings.TrimSpace(text) == "" {
+		return errors.New("prompt: prompt text is empty")
+	}
+
+	body := map[string]any{
+		"messages": []map[string]any{{
+			"id":    uuid.NewString(),
+			"role":  "user",
+			"parts": []map[string]any{{"type": "text", "text": text}},
+		}},
+	}
+	if sessionID == "" {
+		// The chat endpoint creates the session on its first message.
+		sessionID = uuid.NewString()
+		if *agentID == "" {
+			if *agentID, err = a.defaultAgent(ctx); err != nil {
+				return err
+			}
+		}
+		body["workspaceId"] = *workspaceID
+		body["agentId"] = *agentID
+		fmt.Fprintf(a.stderr, "Session %s\n", sessionID)
+	}
+	body["id"] = sessionID
+	if *modelID != "" {
+		body["model"] = *modelID
+	}
+	if *plan {
+		body["mode"] = "plan"
+	}
+
+	opts := streamOptions{sessionID: sessionID, interactive: interactive, showReasoning: *showReasoning}
+	_, err = a.client.stream(ctx, "POST", a.client.projectPath("/chat"), body, a.renderChat(ctx, opts))
+	return err
+}
+
+func (a *app) runAttach(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("attach", "[flags] <session>")
+	noInput := fs.Bool("no-input", false, "Do not prompt for answers to questions")
+	showReasoning := fs.Bool("reasoning", false, "Print reasoning to stderr")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 1); err != nil {
+		return err
+	}
+
+	opts := streamOptions{sessionID: rest[0], interactive: !*noInput, showReasoning: *showReasoning}
+	streamed, err := a.client.stream(ctx, "GET", a.client.projectPath("/chat/%s/stream", rest[0]), nil, a.renderChat(ctx, opts))
+	if err != nil {
+		return err
+	}
+	if !streamed {
+		fmt.Fprintln(a.stderr, "No response in progress")
+	}
+	return nil
+}
+
+// renderChat returns an SSE callback that prints a chat stream. In JSON mode
+// each chunk is written as a line of JSON; otherwise text goes to stdout and
+// tool activity to stderr.
+func (a *app) renderChat(ctx context.Context, opts streamOptions) func([]byte) error {
+	midLine := false
+	return func(data []byte) error {
+		var ev chatEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return fmt.Errorf("invalid stream event: %w", err)
+		}
+
+		if a.jsonOutput {
+			if _, err := fmt.Fprintf(a.stdout, "%s\n", data); err != nil {
+				return err
+			}
+		} else {
+			switch ev.Type {
+			case "text-delta":
+				fmt.Fprint(a.stdout, ev.Delta)
+				midLine = !strings.HasSuffix(ev.Delta, "\n")
+			case "reasoning-delta":
+				if opts.showReasoning {
+					fmt.Fprint(a.stderr, ev.Delta)
+				}
+			case "tool-input-available":
+				if midLine {
+					fmt.Fprintln(a.stdout)
+					midLine = false
+				}
+				fmt.Fprintf(a.stderr, "[%s] %s\n", ev.ToolName, summarizeInput(ev.Input))
+			case "tool-output-error":
+				fmt.Fprintf(a.stderr, "[tool error] %s\n", ev.ErrorText)
+			case "finish":
+				if midLine {
+					fmt.Fprintln(a.stdout)
+					midLine = false
+				}
+			}
+		}
+
+		switch ev.Type {
+		case "error":
+			return fmt.Errorf("agent error: %s", ev.ErrorText)
+		case "tool-input-available":
+			if ev.ToolName != askUserQuestionTool {
+				return nil
+			}
+			if !opts.interactive {
+				fmt.Fprintf(a.stderr, "Waiting for an answer: run 'discobot answer %s'\n", opts.sessionID)
+				return nil
+			}
+			return a.answerQuestion(ctx, opts.sessionID, ev.ToolCallID, nil)
+		}
+		return nil
+	}
+}
+
+// summarizeInput returns a one-line preview of a tool call's input.
+func summarizeInput(input json.RawMessage) string {
+	const maxLen = 120
+	s := strings.Join(strings.Fields(string(input)), " ")
+	if len(s) > maxLen {
+		s = s[:maxLen] + "..."
+	}
+	return s
+}
+
+func (a *app) runAnswer(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("answer", "[flags] <session> [answer...]")
+	toolUseID := fs.String("tool-use-id", "", "Answer a specific question (the pending one if empty)")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		fs.Usage()
+		return errors.New("answer: expected a session ID")
+	}
+	sessionID, answers := rest[0], rest[1:]
+
+	// With no answers, JSON mode prints the pending question for scripts.
+	if a.jsonOutput && len(answers) == 0 {
+		resp, err := a.getQuestion(ctx, sessionID, *toolUseID)
+		if err != nil {
+			return err
+		}
+		return a.printJSON(resp)
+	}
+	return a.answerQuestion(ctx, sessionID, *toolUseID, answers)
+}
+
+func (a *app) getQuestion(ctx context.Context, sessionID, toolUseID string) (*sandboxapi.PendingQuestionResponse, error) {
+	path := a.client.projectPath("/chat/%s/question", sessionID)
+	if toolUseID != "" {
+		path += "?toolUseID=" + url.QueryEscape(toolUseID)
+	}
+	var resp sandboxapi.PendingQuestionResponse
+	if err := a.client.do(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// answerQuestion answers the session's pending question. Answers are taken
+// from answers, one per question, or read interactively when answers is
+// empty.
+func (a *app) answerQuestion(ctx context.Context, sessionID, toolUseID string, answers []string) error {
+	question, err := a.waitForQuestion(ctx, sessionID, toolUseID)
+	if err != nil {
+		return err
+	}
+	if question == nil {
+		if toolUseID == "" {
+			return errors.New("no question is pending")
+		}
+		// Already answered, e.g. from the web UI.
+		return nil
+	}
+	if len(answers) > 0 && len(answers) != len(question.Questions) {
+		return fmt.Errorf("expected %d answer(s), got %d", len(question.Questions), len(answers))
+	}
+
+	req := sandboxapi.AnswerQuestionRequest{ToolUseID: question.ToolUseID, Answers: map[string]string{}}
+	for i, q := range question.Questions {
+		var answer string
+		if len(answers) > 0 {
+			answer = resolveAnswer(q, answers[i])
+		} else if answer, err = a.promptQuestion(q); err != nil {
+			return err
+		}
+		req.Answers[q.Question] = answer
+	}
+
+	var resp sandboxapi.AnswerQuestionResponse
+	if err := a.client.do(ctx, "POST", a.client.projectPath("/chat/%s/answer", sessionID), req, &resp); err != nil {
+		return fmt.Errorf("failed to submit answer: %w", err)
+	}
+	if a.jsonOutput && len(answers) > 0 {
+		return a.printJSON(resp)
+	}
+	return nil
+}
+
+// waitForQuestion fetches the pending question. When toolUseID is set it
+// retries briefly, since the stream can announce the tool call before the
+// agent registers the question. It returns nil if the question was already
+// answered or never appeared.
+func (a *app) waitForQuestion(ctx context.Context, sessionID, toolUseID string) (*sandboxapi.PendingQuestion, error) {
+	const attempts = 20
+	for i := 0; ; i++ {
+		resp, err := a.getQuestion(ctx, sessionID, toolUseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pending question: %w", err)
+		}
+		if resp.Question != nil || toolUseID == "" || resp.Status == "answered" || i == attempts-1 {
+			return resp.Question, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// promptQuestion asks one question on the terminal and returns the answer in
+// the form the web UI submits: an option label, labels joined by ", " for
+// multi-select questions, or free text.
+func (a *app) promptQuestion(q sandboxapi.AskUserQuestion) (string, error) {
+	fmt.Fprintln(a.stderr)
+	if q.Header != "" {
+		fmt.Fprintf(a.stderr, "[%s] ", q.Header)
+	}
+	fmt.Fprintln(a.stderr, q.Question)
+	for i, opt := range q.Options {
+		fmt.Fprintf(a.stderr, "  %d. %s", i+1, opt.Label)
+		if opt.Description != "" {
+			fmt.Fprintf(a.stderr, " - %s", opt.Description)
+		}
+		fmt.Fprintln(a.stderr)
+	}
+
+	hint := "Answer"
+	switch {
+	case len(q.Options) > 0 && q.MultiSelect:
+		hint = "Choose one or more numbers separated by commas, or type an answer"
+	case len(q.Options) > 0:
+		hint = "Choose a number, or type an answer"
+	}
+
+	for {
+		fmt.Fprintf(a.stderr, "%s: ", hint)
+		line, err := a.stdin.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return resolveAnswer(q, line), nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("no answer given")
+			}
+			return "", err
+		}
+	}
+}
+
+// resolveAnswer maps option numbers to their labels. Anything that is not a
+// valid option number is treated as a free-text answer.
+func resolveAnswer(q sandboxapi.AskUserQuestion, input string) string {
+	if len(q.Options) == 0 {
+		return input
+	}
+
+	parts := []string{input}
+	if q.MultiSelect {
+		parts = strings.Split(input, ",")
+	}
+	labels := make([]string, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > len(q.Options) {
+			return input
+		}
+		labels = append(labels, q.Options[n-1].Label)
+	}
+	return strings.Join(labels, ", ")
+}
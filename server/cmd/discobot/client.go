@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// errStreamDone is returned by SSE callbacks to stop reading a stream early.
+var errStreamDone = errors.New("stream done")
+
+// apiError is a non-2xx response from the server.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("server returned %d: %s", e.Status, e.Message)
+}
+
+// client talks to the Discobot server API.
+type client struct {
+	baseURL string
+	token   string
+	project string
+	http    *http.Client
+}
+
+func newClient(baseURL, token, project string) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		project: project,
+		http:    &http.Client{},
+	}
+}
+
+// projectPath returns an API path scoped to the client's project.
+func (c *client) projectPath(format string, args ...any) string {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		escaped[i] = url.PathEscape(fmt.Sprint(arg))
+	}
+	return "/api/projects/" + url.PathEscape(c.project) + fmt.Sprintf(format, escaped...)
+}
+
+func (c *client) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// send performs the request and returns the response, converting non-2xx
+// responses into an *apiError.
+func (c *client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var errBody struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &errBody) == nil {
+		switch {
+		case errBody.Message != "":
+			msg = errBody.Message
+		case errBody.Error != "":
+			msg = errBody.Error
+		}
+	}
+	return nil, &apiError{Status: resp.StatusCode, Message: msg}
+}
+
+// do sends a JSON request and decodes the JSON response into out, if non-nil.
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// stream sends a request and calls fn with the payload of every SSE data
+// event until the server sends [DONE], closes the stream, or fn returns an
+// error. It reports whether the server had anything to stream; a 204 response
+// returns false without calling fn.
+func (c *client) stream(ctx context.Context, method, path string, body any, fn func(data []byte) error) (bool, error) {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if err := readSSE(resp.Body, fn); err != nil && !errors.Is(err, errStreamDone) {
+		return true, err
+	}
+	return true, nil
+}
+
+// readSSE reads Server-Sent Events from r, joining multi-line data fields, and
+// calls fn once per event. It stops at a [DONE] event.
+func readSSE(r io.Reader, fn func(data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var data []byte
+	hasData := false
+	dispatch := func() error {
+		if !hasData {
+			return nil
+		}
+		payload := data
+		data, hasData = nil, false
+		if string(payload) == "[DONE]" {
+			return errStreamDone
+		}
+		return fn(payload)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			// Comments, event names and ids are not used by the API.
+			continue
+		}
+		value = strings.TrimPrefix(value, " ")
+		if hasData {
+			data = append(data, '\n')
+		}
+		data = append(data, value...)
+		hasData = true
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
@@ -0,0 +1,189 @@
+// Command discobot is a command-line client for the Discobot server API.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+)
+
+const usage = `Usage: discobot [global flags] <command> [arguments]
+
+Commands:
+  workspaces list                      List workspaces
+  workspaces create <path|git-url>     Create a workspace
+  sessions list [--workspace ID]       List sessions
+  sessions create --workspace ID       Create a session
+  sessions show <session>              Show a session
+  prompt <session> <text...>           Send a prompt and stream the response
+  prompt --workspace ID <text...>      Start a new session with a prompt
+  attach <session>                     Resume an in-progress response stream
+  answer <session> [answer...]         Answer a pending AskUserQuestion prompt
+  services list <session>              List services
+  services logs <session> <service>    Tail service output
+  hooks list <session>                 Show hook status
+  hooks logs <session> <hook>          Show hook output
+  diff <session>                       Show the session diff
+  commit <session>                     Commit the session to its workspace
+  ssh <session> [-- ssh-args...]       Open an SSH shell in the session
+
+Global flags:
+`
+
+// app holds the shared state for a CLI invocation.
+type app struct {
+	client     *client
+	jsonOutput bool
+	stdin      *bufio.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "discobot: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("discobot", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	server := fs.String("server", envOr("DISCOBOT_SERVER", "http://localhost:3001"), "Server URL (env DISCOBOT_SERVER)")
+	token := fs.String("token", os.Getenv("DISCOBOT_TOKEN"), "API token sent as a bearer token (env DISCOBOT_TOKEN)")
+	project := fs.String("project", envOr("DISCOBOT_PROJECT", "local"), "Project ID (env DISCOBOT_PROJECT)")
+	jsonOutput := fs.Bool("json", false, "Print machine-readable JSON output")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	a := &app{
+		client:     newClient(*server, *token, *project),
+		jsonOutput: *jsonOutput,
+		stdin:      bufio.NewReader(stdin),
+		stdout:     stdout,
+		stderr:     stderr,
+	}
+
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "workspaces", "workspace", "ws":
+		return a.runWorkspaces(ctx, rest)
+	case "sessions", "session":
+		return a.runSessions(ctx, rest)
+	case "prompt":
+		return a.runPrompt(ctx, rest)
+	case "attach":
+		return a.runAttach(ctx, rest)
+	case "answer":
+		return a.runAnswer(ctx, rest)
+	case "services", "service":
+		return a.runServices(ctx, rest)
+	case "hooks", "hook":
+		return a.runHooks(ctx, rest)
+	case "diff":
+		return a.runDiff(ctx, rest)
+	case "commit":
+		return a.runCommit(ctx, rest)
+	case "ssh":
+		return a.runSSH(ctx, rest)
+	case "help":
+		fs.Usage()
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+// subcommand splits args into a subcommand name and its arguments.
+func subcommand(args []string, name string, subs ...string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%s: expected a subcommand (%v)", name, subs)
+	}
+	for _, sub := range subs {
+		if args[0] == sub {
+			return sub, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s: unknown subcommand %q (expected one of %v)", name, args[0], subs)
+}
+
+// newFlagSet returns a flag set for a command that reports errors instead of
+// exiting.
+func (a *app) newFlagSet(name, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: discobot %s %s\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before or after positional
+// arguments and returns the positional arguments. Arguments after "--" are
+// always positional.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var tail []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, tail = args[:i], args[i+1:]
+	}
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(positional, tail...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// exactArgs checks the number of positional arguments.
+func exactArgs(fs *flag.FlagSet, args []string, n int) error {
+	if len(args) != n {
+		fs.Usage()
+		return fmt.Errorf("%s: expected %d argument(s), got %d", fs.Name(), n, len(args))
+	}
+	return nil
+}
+
+// printJSON writes v as indented JSON.
+func (a *app) printJSON(v any) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+func TestReadSSE(t *testing.T) {
+	input := ": comment\n" +
+		"data: {\"a\":1}\n\n" +
+		"event: message\n" +
+		"data: line one\n" +
+		"data: line two\n\n" +
+		"data: [DONE]\n\n" +
+		"data: after done\n\n"
+
+	var events []string
+	err := readSSE(strings.NewReader(input), func(data []byte) error {
+		events = append(events, string(data))
+		return nil
+	})
+	if err != errStreamDone {
+		t.Fatalf("Expected errStreamDone, got %v", err)
+	}
+	want := []string{`{"a":1}`, "line one\nline two"}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("Expected events %q, got %q", want, events)
+	}
+}
+
+func TestResolveAnswer(t *testing.T) {
+	q := sandboxapi.AskUserQuestion{
+		Question: "Which database?",
+		Options:  []sandboxapi.AskUserQuestionOption{{Label: "Postgres"}, {Label: "SQLite"}, {Label: "MySQL"}},
+	}
+	multi := q
+	multi.MultiSelect = true
+
+	tests := []struct {
+		name  string
+		q     sandboxapi.AskUserQuestion
+		input string
+		want  string
+	}{
+		{"option number", q, "2", "SQLite"},
+		{"out of range is free text", q, "9", "9"},
+		{"free text", q, "Something else", "Something else"},
+		{"multi select", multi, "1, 3", "Postgres, MySQL"},
+		{"multi select with text", multi, "1, other", "1, other"},
+		{"no options", sandboxapi.AskUserQuestion{Question: "Name?"}, "3", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveAnswer(tt.q, tt.input); got != tt.want {
+				t.Errorf("resolveAnswer(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptAnswersQuestion(t *testing.T) {
+	answered := make(chan sandboxapi.AnswerQuestionRequest, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/projects/local/chat", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			ID       string `json:"id"`
+			Messages []struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID != "sess-1" || body.Messages[0].Parts[0].Text != "pick a db" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, "data: {\"type\":\"text-delta\",\"delta\":\"Let me ask.\"}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"tool-input-available\",\"toolName\":\"AskUserQuestion\",\"toolCallId\":\"tool-1\",\"input\":{}}\n\n")
+		flusher.Flush()
+
+		select {
+		case <-answered:
+		case <-time.After(5 * time.Second):
+			return
+		}
+		fmt.Fprint(w, "data: {\"type\":\"text-delta\",\"delta\":\"Using SQLite.\"}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"finish\"}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	var answer sandboxapi.AnswerQuestionRequest
+	mux.HandleFunc("GET /api/projects/local/chat/sess-1/question", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("toolUseID") != "tool-1" {
+			http.Error(w, "missing toolUseID", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(sandboxapi.PendingQuestionResponse{
+			Status: "pending",
+			Question: &sandboxapi.PendingQuestion{
+				ToolUseID: "tool-1",
+				Questions: []sandboxapi.AskUserQuestion{{
+					Question: "Which database?",
+					Header:   "Database",
+					Options:  []sandboxapi.AskUserQuestionOption{{Label: "Postgres"}, {Label: "SQLite"}},
+				}},
+			},
+		})
+	})
+	mux.HandleFunc("POST /api/projects/local/chat/sess-1/answer", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&answer); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		answered <- answer
+		_ = json.NewEncoder(w).Encode(sandboxapi.AnswerQuestionResponse{Success: true})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--server", server.URL, "--token", "secret", "prompt", "sess-1", "pick", "a", "db"}
+	if err := run(context.Background(), args, strings.NewReader("\n2\n"), &stdout, &stderr); err != nil {
+		t.Fatalf("prompt failed: %v\nstderr:\n%s", err, stderr.String())
+	}
+
+	if answer.ToolUseID != "tool-1" || answer.Answers["Which database?"] != "SQLite" {
+		t.Errorf("Unexpected answer %+v", answer)
+	}
+	if got := stdout.String(); got != "Let me ask.\nUsing SQLite.\n" {
+		t.Errorf("Unexpected stdout %q", got)
+	}
+	if !strings.Contains(stderr.String(), "1. Postgres") {
+		t.Errorf("Expected options on stderr, got:\n%s", stderr.String())
+	}
+}
+
+func TestWorkspacesListJSON(t *testing.T) {
+	const body = `{"workspaces":[{"id":"ws-1","path":"/code","sourceType":"local","status":"ready","sessions":[]}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/projects/team/workspaces" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--server", server.URL, "--project", "team", "--json", "workspaces", "list"}
+	if err := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatalf("workspaces list failed: %v", err)
+	}
+
+	var got, want any
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("Output is not JSON: %v\n%s", err, stdout.String())
+	}
+	_ = json.Unmarshal([]byte(body), &want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error":"Permission denied"}`)
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"--server", server.URL, "sessions", "show", "s1"}, strings.NewReader(""), &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "403: Permission denied") {
+		t.Errorf("Expected permission error, got %v", err)
+	}
+}
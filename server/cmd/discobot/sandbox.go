@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+func (a *app) runServices(ctx context.Context, args []string) error {
+	sub, args, err := subcommand(args, "services", "list", "logs")
+	if err != nil {
+		return err
+	}
+	if sub == "logs" {
+		return a.serviceLogs(ctx, args)
+	}
+
+	fs := a.newFlagSet("services list", "<session>")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 1); err != nil {
+		return err
+	}
+
+	var resp sandboxapi.ListServicesResponse
+	if printed, err := a.call(ctx, "GET", a.client.projectPath("/sessions/%s/services", rest[0]), nil, &resp); err != nil || printed {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tPORT\tPID")
+	for _, svc := range resp.Services {
+		port := ""
+		switch {
+		case svc.HTTPS != 0:
+			port = fmt.Sprintf("%d (https)", svc.HTTPS)
+		case svc.HTTP != 0:
+			port = fmt.Sprintf("%d", svc.HTTP)
+		}
+		pid := ""
+		if svc.PID != 0 {
+			pid = fmt.Sprintf("%d", svc.PID)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", svc.ID, svc.Name, svc.Status, port, pid)
+	}
+	return tw.Flush()
+}
+
+// serviceLogs streams a service's output until the service exits or the
+// user interrupts.
+func (a *app) serviceLogs(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("services logs", "<session> <service>")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 2); err != nil {
+		return err
+	}
+
+	path := a.client.projectPath("/sessions/%s/services/%s/output", rest[0], rest[1])
+	_, err = a.client.stream(ctx, "GET", path, nil, func(data []byte) error {
+		if a.jsonOutput {
+			_, err := fmt.Fprintf(a.stdout, "%s\n", data)
+			return err
+		}
+
+		var ev sandboxapi.ServiceOutputEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return fmt.Errorf("invalid output event: %w", err)
+		}
+		switch ev.Type {
+		case "stdout":
+			fmt.Fprint(a.stdout, ev.Data)
+		case "stderr":
+			fmt.Fprint(a.stderr, ev.Data)
+		case "exit":
+			if ev.ExitCode != nil {
+				fmt.Fprintf(a.stderr, "[service exited with code %d]\n", *ev.ExitCode)
+			} else {
+				fmt.Fprintln(a.stderr, "[service exited]")
+			}
+		case "error":
+			return fmt.Errorf("service output: %s", ev.Error)
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func (a *app) runHooks(ctx context.Context, args []string) error {
+	sub, args, err := subcommand(args, "hooks", "list", "logs")
+	if err != nil {
+		return err
+	}
+	if sub == "logs" {
+		return a.hookLogs(ctx, args)
+	}
+
+	fs := a.newFlagSet("hooks list", "<session>")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 1); err != nil {
+		return err
+	}
+
+	var resp sandboxapi.HooksStatusResponse
+	if printed, err := a.call(ctx, "GET", a.client.projectPath("/sessions/%s/hooks/status", rest[0]), nil, &resp); err != nil || printed {
+		return err
+	}
+
+	ids := make([]string, 0, len(resp.Hooks))
+	for id := range resp.Hooks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tTYPE\tRESULT\tEXIT\tRUNS\tFAILS\tLAST RUN")
+	for _, id := range ids {
+		hook := resp.Hooks[id]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			id, hook.HookName, hook.Type, hook.LastResult, hook.LastExitCode, hook.RunCount, hook.FailCount, hook.LastRunAt)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(resp.PendingHooks) > 0 {
+		fmt.Fprintf(a.stdout, "\nPending: %s\n", strings.Join(resp.PendingHooks, ", "))
+	}
+	return nil
+}
+
+// hookLogs prints a hook's output log. Hook output is not streamed by the
+// server, so --follow polls and prints whatever was appended.
+func (a *app) hookLogs(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("hooks logs", "[flags] <session> <hook>")
+	follow := fs.Bool("f", false, "Keep polling for new output")
+	interval := fs.Duration("interval", 2*time.Second, "Polling interval with -f")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 2); err != nil {
+		return err
+	}
+
+	path := a.client.projectPath("/sessions/%s/hooks/%s/output", rest[0], rest[1])
+	if a.jsonOutput && !*follow {
+		_, err := a.call(ctx, "GET", path, nil, nil)
+		return err
+	}
+
+	var printed string
+	for {
+		var resp sandboxapi.HookOutputResponse
+		if err := a.client.do(ctx, "GET", path, nil, &resp); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		// A rerun truncates the log, so print it again from the start.
+		next := resp.Output
+		if !strings.HasPrefix(next, printed) {
+			printed = ""
+		}
+		if added := next[len(printed):]; added != "" {
+			if a.jsonOutput {
+				if err := json.NewEncoder(a.stdout).Encode(sandboxapi.HookOutputResponse{Output: added}); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprint(a.stdout, added)
+			}
+		}
+		printed = next
+
+		if !*follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func (a *app) runDiff(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("diff", "[flags] <session> [path]")
+	stat := fs.Bool("stat", false, "Show per-file line counts instead of patches")
+	nameOnly := fs.Bool("name-status", false, "Show only changed file names and status")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 && len(rest) != 2 {
+		fs.Usage()
+		return fmt.Errorf("diff: expected a session ID and an optional path")
+	}
+
+	query := url.Values{}
+	if len(rest) == 2 {
+		query.Set("path", rest[1])
+	} else if *nameOnly {
+		query.Set("format", "files")
+	}
+	path := a.client.projectPath("/sessions/%s/diff", rest[0])
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	if len(rest) == 2 {
+		var file sandboxapi.SingleFileDiffResponse
+		if printed, err := a.call(ctx, "GET", path, nil, &file); err != nil || printed {
+			return err
+		}
+		a.printFileDiff(sandboxapi.FileDiffEntry{
+			Path: file.Path, Status: file.Status, OldPath: file.OldPath,
+			Additions: file.Additions, Deletions: file.Deletions, Binary: file.Binary, Patch: file.Patch,
+		}, *stat, *nameOnly)
+		return nil
+	}
+
+	var resp sandboxapi.DiffResponse
+	if printed, err := a.call(ctx, "GET", path, nil, &resp); err != nil || printed {
+		return err
+	}
+	for _, file := range resp.Files {
+		a.printFileDiff(file, *stat, *nameOnly)
+	}
+	if *stat {
+		fmt.Fprintf(a.stdout, " %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n",
+			resp.Stats.FilesChanged, resp.Stats.Additions, resp.Stats.Deletions)
+	}
+	return nil
+}
+
+func (a *app) printFileDiff(file sandboxapi.FileDiffEntry, stat, nameOnly bool) {
+	name := file.Path
+	if file.OldPath != "" {
+		name = file.OldPath + " => " + file.Path
+	}
+	switch {
+	case nameOnly:
+		fmt.Fprintf(a.stdout, "%s\t%s\n", diffStatusLetter(file.Status), name)
+	case stat:
+		if file.Binary {
+			fmt.Fprintf(a.stdout, " %s | Bin\n", name)
+		} else {
+			fmt.Fprintf(a.stdout, " %s | +%d -%d\n", name, file.Additions, file.Deletions)
+		}
+	case file.Binary:
+		fmt.Fprintf(a.stdout, "Binary file %s differs\n", name)
+	default:
+		fmt.Fprint(a.stdout, file.Patch)
+		if file.Patch != "" && !strings.HasSuffix(file.Patch, "\n") {
+			fmt.Fprintln(a.stdout)
+		}
+	}
+}
+
+func diffStatusLetter(status string) string {
+	switch status {
+	case "added":
+		return "A"
+	case "deleted":
+		return "D"
+	case "renamed":
+		return "R"
+	default:
+		return "M"
+	}
+}
+
+func (a *app) runCommit(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("commit", "[flags] <session>")
+	review := fs.Bool("review", false, "Stop for review before applying commits to the workspace")
+	wait := fs.Bool("wait", false, "Wait for the commit to finish")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 1); err != nil {
+		return err
+	}
+	sessionID := rest[0]
+
+	body := map[string]bool{"review": *review}
+	if err := a.client.do(ctx, "POST", a.client.projectPath("/sessions/%s/commit", sessionID), body, nil); err != nil {
+		return err
+	}
+	if !*wait {
+		if a.jsonOutput {
+			return a.printJSON(map[string]any{"sessionId": sessionID, "commitStatus": "pending"})
+		}
+		fmt.Fprintf(a.stdout, "Commit started for session %s\n", sessionID)
+		return nil
+	}
+
+	for {
+		var s session
+		if err := a.client.do(ctx, "GET", a.client.projectPath("/sessions/%s", sessionID), nil, &s); err != nil {
+			return err
+		}
+		switch s.CommitStatus {
+		case "pending", "committing":
+		default:
+			if a.jsonOutput {
+				if err := a.printJSON(map[string]any{"sessionId": sessionID, "commitStatus": s.CommitStatus}); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(a.stdout, "Commit %s\n", s.CommitStatus)
+			}
+			if s.CommitStatus == "failed" {
+				return fmt.Errorf("commit failed: %s", s.CommitError)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// runSSH opens an interactive SSH session. The server's SSH gateway uses the
+// session ID as the username.
+func (a *app) runSSH(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("ssh", "<session> [-- ssh-args...]")
+	host := fs.String("host", "", "SSH host (the server's host if empty)")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		fs.Usage()
+		return fmt.Errorf("ssh: expected a session ID")
+	}
+	sessionID, sshArgs := rest[0], rest[1:]
+
+	var cfg struct {
+		SSHPort int `json:"ssh_port"`
+	}
+	if err := a.client.do(ctx, "GET", "/api/server-config", nil, &cfg); err != nil {
+		return fmt.Errorf("failed to get server config: %w", err)
+	}
+
+	if *host == "" {
+		serverURL, err := url.Parse(a.client.baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid server URL: %w", err)
+		}
+		*host = serverURL.Hostname()
+	}
+
+	cmdArgs := append([]string{"-p", fmt.Sprint(cfg.SSHPort)}, sshArgs...)
+	cmdArgs = append(cmdArgs, sessionID+"@"+*host)
+	if a.jsonOutput {
+		return a.printJSON(map[string]any{"command": append([]string{"ssh"}, cmdArgs...), "address": net.JoinHostPort(*host, fmt.Sprint(cfg.SSHPort))})
+	}
+
+	cmd := exec.CommandContext(ctx, "ssh", cmdArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
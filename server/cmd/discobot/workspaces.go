@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+)
+
+// workspace is the subset of the workspace API response the CLI displays.
+type workspace struct {
+	ID          string     `json:"id"`
+	Path        string     `json:"path"`
+	DisplayName *string    `json:"displayName,omitempty"`
+	SourceType  string     `json:"sourceType"`
+	Provider    string     `json:"provider,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"errorMessage,omitempty"`
+	Sessions    []*session `json:"sessions"`
+}
+
+// session is the subset of the session API response the CLI displays.
+type session struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName,omitempty"`
+	Status       string `json:"status"`
+	CommitStatus string `json:"commitStatus,omitempty"`
+	CommitError  string `json:"commitError,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	WorkspaceID  string `json:"workspaceId,omitempty"`
+	AgentID      string `json:"agentId,omitempty"`
+	Model        string `json:"model,omitempty"`
+}
+
+func (s *session) title() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Name
+}
+
+type agent struct {
+	ID        string `json:"id"`
+	AgentType string `json:"agentType"`
+	IsDefault bool   `json:"isDefault,omitempty"`
+}
+
+// call performs an API request. In JSON mode the response is printed verbatim
+// and printed is true; otherwise it is decoded into out.
+func (a *app) call(ctx context.Context, method, path string, body, out any) (printed bool, err error) {
+	var raw json.RawMessage
+	if err := a.client.do(ctx, method, path, body, &raw); err != nil {
+		return false, err
+	}
+	if a.jsonOutput {
+		return true, a.printJSON(raw)
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return false, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return false, nil
+}
+
+func (a *app) runWorkspaces(ctx context.Context, args []string) error {
+	sub, args, err := subcommand(args, "workspaces", "list", "create")
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "create":
+		return a.createWorkspace(ctx, args)
+	default:
+		return a.listWorkspaces(ctx, args)
+	}
+}
+
+func (a *app) listWorkspaces(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("workspaces list", "")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 0); err != nil {
+		return err
+	}
+
+	var resp struct {
+		Workspaces []*workspace `json:"workspaces"`
+	}
+	if printed, err := a.call(ctx, "GET", a.client.projectPath("/workspaces"), nil, &resp); err != nil || printed {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSOURCE\tSTATUS\tSESSIONS\tPATH")
+	for _, ws := range resp.Workspaces {
+		name := ""
+		if ws.DisplayName != nil {
+			name = *ws.DisplayName
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", ws.ID, name, ws.SourceType, ws.Status, len(ws.Sessions), ws.Path)
+	}
+	return tw.Flush()
+}
+
+func (a *app) createWorkspace(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("workspaces create", "[flags] <path|git-url>")
+	name := fs.String("name", "", "Display name")
+	sourceType := fs.String("source", "", `Source type: "local" or "git" (detected from the path by default)`)
+	provider := fs.String("provider", "", "Sandbox provider (server default if empty)")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 1); err != nil {
+		return err
+	}
+
+	path := rest[0]
+	if *sourceType == "" {
+		*sourceType = "local"
+		if isGitURL(path) {
+			*sourceType = "git"
+		}
+	}
+	if *sourceType == "local" {
+		if path, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+
+	body := map[string]any{"path": path, "sourceType": *sourceType}
+	if *name != "" {
+		body["displayName"] = *name
+	}
+	if *provider != "" {
+		body["provider"] = *provider
+	}
+
+	var ws workspace
+	if printed, err := a.call(ctx, "POST", a.client.projectPath("/workspaces"), body, &ws); err != nil || printed {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Created workspace %s (%s)\n", ws.ID, ws.Status)
+	return nil
+}
+
+// isGitURL reports whether path looks like a remote git repository rather than
+// a local directory.
+func isGitURL(path string) bool {
+	return strings.Contains(path, "://") || strings.HasPrefix(path, "git@")
+}
+
+func (a *app) runSessions(ctx context.Context, args []string) error {
+	sub, args, err := subcommand(args, "sessions", "list", "create", "show")
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "create":
+		return a.createSession(ctx, args)
+	case "show":
+		return a.showSession(ctx, args)
+	default:
+		return a.listSessions(ctx, args)
+	}
+}
+
+func (a *app) listSessions(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("sessions list", "[flags]")
+	workspaceID := fs.String("workspace", "", "Only list sessions in this workspace")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 0); err != nil {
+		return err
+	}
+
+	var sessions []*session
+	if *workspaceID != "" {
+		var resp struct {
+			Sessions []*session `json:"sessions"`
+		}
+		if printed, err := a.call(ctx, "GET", a.client.projectPath("/workspaces/%s/sessions", *workspaceID), nil, &resp); err != nil || printed {
+			return err
+		}
+		sessions = resp.Sessions
+	} else {
+		// Workspaces embed their sessions, so one request covers the project.
+		var resp struct {
+			Workspaces []*workspace `json:"workspaces"`
+		}
+		if err := a.client.do(ctx, "GET", a.client.projectPath("/workspaces"), nil, &resp); err != nil {
+			return err
+		}
+		for _, ws := range resp.Workspaces {
+			for _, s := range ws.Sessions {
+				if s.WorkspaceID == "" {
+					s.WorkspaceID = ws.ID
+				}
+				sessions = append(sessions, s)
+			}
+		}
+		if a.jsonOutput {
+			return a.printJSON(map[string]any{"sessions": sessions})
+		}
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tCOMMIT\tWORKSPACE")
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.ID, s.title(), s.Status, s.CommitStatus, s.WorkspaceID)
+	}
+	return tw.Flush()
+}
+
+func (a *app) createSession(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("sessions create", "--workspace ID [flags]")
+	workspaceID := fs.String("workspace", "", "Workspace ID (required)")
+	agentID := fs.String("agent", "", "Agent ID (project default if empty)")
+	modelID := fs.String("model", "", "Model ID (agent default if empty)")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 0); err != nil {
+		return err
+	}
+	if *workspaceID == "" {
+		fs.Usage()
+		return errors.New("sessions create: --workspace is required")
+	}
+
+	if *agentID == "" {
+		if *agentID, err = a.defaultAgent(ctx); err != nil {
+			return err
+		}
+	}
+
+	body := map[string]any{
+		"id":          uuid.NewString(),
+		"workspaceId": *workspaceID,
+		"agentId":     *agentID,
+	}
+	if *modelID != "" {
+		body["model"] = *modelID
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if printed, err := a.call(ctx, "POST", a.client.projectPath("/sessions"), body, &resp); err != nil || printed {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Created session %s\n", resp.ID)
+	return nil
+}
+
+func (a *app) showSession(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("sessions show", "<session>")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs(fs, rest, 1); err != nil {
+		return err
+	}
+
+	var s session
+	if printed, err := a.call(ctx, "GET", a.client.projectPath("/sessions/%s", rest[0]), nil, &s); err != nil || printed {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", s.ID)
+	fmt.Fprintf(tw, "Name:\t%s\n", s.title())
+	fmt.Fprintf(tw, "Status:\t%s\n", s.Status)
+	if s.CommitStatus != "" {
+		fmt.Fprintf(tw, "Commit:\t%s\n", s.CommitStatus)
+	}
+	if s.ErrorMessage != "" {
+		fmt.Fprintf(tw, "Error:\t%s\n", s.ErrorMessage)
+	}
+	fmt.Fprintf(tw, "Workspace:\t%s\n", s.WorkspaceID)
+	fmt.Fprintf(tw, "Agent:\t%s\n", s.AgentID)
+	if s.Model != "" {
+		fmt.Fprintf(tw, "Model:\t%s\n", s.Model)
+	}
+	return tw.Flush()
+}
+
+// defaultAgent returns the project's default agent, or its only agent.
+func (a *app) defaultAgent(ctx context.Context) (string, error) {
+	var resp struct {
+		Agents []agent `json:"agents"`
+	}
+	if err := a.client.do(ctx, "GET", a.client.projectPath("/agents"), nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to list agents: %w", err)
+	}
+	for _, ag := range resp.Agents {
+		if ag.IsDefault {
+			return ag.ID, nil
+		}
+	}
+	if len(resp.Agents) == 1 {
+		return resp.Agents[0].ID, nil
+	}
+	return "", errors.New("no default agent configured; pass --agent")
+}
@@ -3,8 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
-	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -25,6 +24,8 @@ import (
 	"github.com/obot-platform/discobot/server/internal/handler"
 	"github.com/obot-platform/discobot/server/internal/jobs"
 	"github.com/obot-platform/discobot/server/internal/logfile"
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/metrics"
 	"github.com/obot-platform/discobot/server/internal/middleware"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/routes"
@@ -37,10 +38,19 @@ import (
 	"github.com/obot-platform/discobot/server/internal/ssh"
 	"github.com/obot-platform/discobot/server/internal/startup"
 	"github.com/obot-platform/discobot/server/internal/store"
+	"github.com/obot-platform/discobot/server/internal/tracing"
 	"github.com/obot-platform/discobot/server/internal/version"
-	"github.com/obot-platform/discobot/server/static"
 )
 
+// serverLog is the logger for server startup and shutdown messages.
+var serverLog = logging.Component("server")
+
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	serverLog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
 	// Load .env file if present
 	_ = godotenv.Load()
@@ -48,46 +58,64 @@ func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("failed to load configuration", "error", err)
 	}
 
 	// Redirect stdout/stderr to log file if configured (must be before any logging)
 	if cfg.LogFile != "" {
 		if err := logfile.Truncate(cfg.LogFile); err != nil {
-			log.Printf("Warning: failed to truncate log file: %v", err)
+			serverLog.Warn("failed to truncate log file", "error", err)
 		}
 		if err := logfile.RedirectStdoutStderr(cfg.LogFile); err != nil {
-			log.Printf("Warning: failed to redirect output to %s: %v", cfg.LogFile, err)
+			serverLog.Warn("failed to redirect output to log file", "path", cfg.LogFile, "error", err)
 		}
 	}
 
+	// Set up structured logging (stderr may have been redirected above)
+	if err := logging.Setup(os.Stderr, logging.Options{
+		Format:     logging.Format(cfg.LogFormat),
+		Level:      cfg.LogLevel,
+		Components: cfg.LogLevels,
+	}); err != nil {
+		fatal("failed to set up logging", "error", err)
+	}
+
 	// Log version
-	log.Printf("Discobot Server version %s", version.Get())
+	serverLog.Info("Discobot Server starting", "version", version.Get())
+
+	// Set up tracing before anything that creates spans
+	shutdownTracing, err := tracing.Setup(context.Background(), "discobot-server")
+	if err != nil {
+		fatal("failed to set up tracing", "error", err)
+	}
+	if tracing.Enabled() {
+		serverLog.Info("OpenTelemetry trace export enabled")
+	}
 
 	// Connect to database
 	db, err := database.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("failed to connect to database", "error", err)
 	}
 	defer func() { _ = db.Close() }()
 
 	// Run migrations
-	log.Println("Running database migrations...")
+	serverLog.Info("running database migrations")
 	if err := db.Migrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		fatal("failed to run migrations", "error", err)
 	}
-	log.Println("Migrations completed successfully")
+	serverLog.Info("migrations completed")
 
 	// Seed database with anonymous user and default project
 	if err := db.Seed(); err != nil {
-		log.Fatalf("Failed to seed database: %v", err)
+		fatal("failed to seed database", "error", err)
 	}
 
 	// Log auth mode
 	if cfg.AuthEnabled {
-		log.Println("Authentication enabled - users must log in")
+		serverLog.Info("authentication enabled, users must log in")
 	} else {
-		log.Println("Authentication disabled - using anonymous user mode")
+		serverLog.Info("authentication disabled, using anonymous user mode")
 	}
 
 	// Create store with separate read/write pools for SQLite
@@ -98,9 +126,9 @@ func main() {
 	workspaceSource := git.NewStoreWorkspaceSource(s)
 	gitProvider, err := git.NewLocalProvider(cfg.WorkspaceDir, git.WithWorkspaceSource(workspaceSource))
 	if err != nil {
-		log.Fatalf("Failed to initialize git provider: %v", err)
+		fatal("failed to initialize git provider", "error", err)
 	}
-	log.Printf("Git provider initialized at %s", cfg.WorkspaceDir)
+	serverLog.Info("git provider initialized", "workspace_dir", cfg.WorkspaceDir)
 
 	// Initialize sandbox providers
 	// Create a manager that can route to different providers based on workspace configuration
@@ -109,7 +137,7 @@ func main() {
 	// Create event poller and broker for SSE (needed by startup manager)
 	eventPoller := events.NewPoller(s, events.DefaultPollerConfig())
 	if err := eventPoller.Start(context.Background()); err != nil {
-		log.Fatalf("Failed to start event poller: %v", err)
+		fatal("failed to start event poller", "error", err)
 	}
 	eventBroker := events.NewBroker(s, eventPoller)
 
@@ -142,32 +170,32 @@ func main() {
 			DataDiskGB:    cfg.VZDataDiskGB,
 		}
 		if vmProvider, vzErr := vz.NewProvider(cfg, vzCfg, sessionProjectResolver, systemManager); vzErr != nil {
-			log.Printf("Warning: Failed to initialize VZ sandbox provider: %v", vzErr)
+			serverLog.Warn("failed to initialize VZ sandbox provider", "error", vzErr)
 		} else {
 			sandboxManager.RegisterProvider("vz", vmProvider)
 			if vmProvider.IsReady() {
-				log.Printf("VZ sandbox provider initialized and ready")
+				serverLog.Info("VZ sandbox provider initialized and ready")
 			} else {
-				log.Printf("VZ sandbox provider registered (images downloading in background)")
+				serverLog.Info("VZ sandbox provider registered, images downloading in background")
 			}
 		}
 	} else {
 		// On non-macOS, use Docker provider
 		if dockerProvider, dockerErr := docker.NewProvider(cfg, sessionProjectResolver, docker.WithSystemManager(systemManager)); dockerErr != nil {
-			log.Printf("Warning: Failed to initialize Docker sandbox provider: %v", dockerErr)
+			serverLog.Warn("failed to initialize Docker sandbox provider", "error", dockerErr)
 		} else {
 			sandboxManager.RegisterProvider("docker", dockerProvider)
-			log.Printf("Docker sandbox provider initialized (image: %s)", cfg.SandboxImage)
+			serverLog.Info("Docker sandbox provider initialized", "image", cfg.SandboxImage)
 		}
 	}
 	//
 	// Initialize local provider (only if enabled via config)
 	if cfg.LocalProviderEnabled {
 		if localProvider, localErr := local.NewProvider(cfg); localErr != nil {
-			log.Printf("Warning: Failed to initialize local sandbox provider: %v", localErr)
+			serverLog.Warn("failed to initialize local sandbox provider", "error", localErr)
 		} else {
 			sandboxManager.RegisterProvider("local", localProvider)
-			log.Printf("Local sandbox provider initialized")
+			serverLog.Info("local sandbox provider initialized")
 		}
 	}
 
@@ -175,7 +203,7 @@ func main() {
 	// The proxy will look up the session's workspace and use its provider setting
 	var sandboxProvider sandbox.Provider
 	if sandboxManager.EnsureDefaultAvailable() {
-		log.Printf("Default sandbox provider: %s", sandboxManager.DefaultProviderName())
+		serverLog.Info("default sandbox provider selected", "provider", sandboxManager.DefaultProviderName())
 
 		// Create a sandbox service for the provider getter function
 		// This is a bit of a chicken-and-egg problem, so we'll pass the store directly
@@ -201,7 +229,7 @@ func main() {
 		}
 
 		sandboxProvider = sandbox.NewProviderProxy(sandboxManager, providerGetter)
-		log.Printf("Sandbox provider proxy initialized with %d providers", len(sandboxManager.ListProviders()))
+		serverLog.Info("sandbox provider proxy initialized", "providers", len(sandboxManager.ListProviders()))
 	}
 
 	// Create job queue early so it can be passed to services
@@ -216,7 +244,7 @@ func main() {
 		watcherCtx, sandboxWatcherCancel = context.WithCancel(context.Background())
 		go func() {
 			if err := sandboxWatcher.Start(watcherCtx); err != nil && err != context.Canceled {
-				log.Printf("Sandbox watcher stopped with error: %v", err)
+				serverLog.Error("sandbox watcher stopped", "error", err)
 			}
 		}()
 	}
@@ -227,31 +255,46 @@ func main() {
 	if sandboxProvider != nil {
 		// Create a temporary sandbox service for the poller (will be replaced later)
 		pollerSandboxSvc := service.NewSandboxService(s, sandboxProvider, cfg, nil, nil, nil)
-		sessionStatusPoller = service.NewSessionStatusPoller(s, pollerSandboxSvc, eventBroker, slog.Default())
+		sessionStatusPoller = service.NewSessionStatusPoller(s, pollerSandboxSvc, eventBroker, logging.Component("status-poller"))
 		sessionStatusPoller.Start(context.Background())
-		log.Println("Session status poller started")
+		serverLog.Info("session status poller started")
+	}
+
+	// Start audit log retention monitor to prune old entries
+	auditSvc := service.NewAuditService(s)
+	var auditRetentionMonitor *service.AuditRetentionMonitor
+	if cfg.AuditLogRetention > 0 {
+		auditRetentionMonitor = service.NewAuditRetentionMonitor(auditSvc, logging.Component("audit"), cfg.AuditLogRetention, time.Hour)
+		auditRetentionMonitor.Start(context.Background())
+		serverLog.Info("audit log retention monitor started", "retention", cfg.AuditLogRetention)
 	}
 
+	// Start invitation cleanup monitor to delete expired invitations
+	invitationCleanupMonitor := service.NewInvitationCleanupMonitor(service.NewProjectService(s, nil), logging.Component("invitations"), time.Hour)
+	invitationCleanupMonitor.Start(context.Background())
+	serverLog.Info("invitation cleanup monitor started")
+
 	// Start SSH server for VS Code Remote SSH and other SSH-based workflows
 	var sshServer *ssh.Server
 	if sandboxProvider != nil && cfg.SSHEnabled {
 		// Create sandbox service for UserInfoFetcher
 		sshSandboxSvc := service.NewSandboxService(s, sandboxProvider, cfg, nil, nil, nil)
 		sshServer, err = ssh.New(&ssh.Config{
-			Address:         fmt.Sprintf(":%d", cfg.SSHPort),
-			HostKeyPath:     cfg.SSHHostKeyPath,
-			SandboxProvider: sandboxProvider,
-			UserInfoFetcher: &sshUserInfoAdapter{svc: sshSandboxSvc},
+			Address:            fmt.Sprintf(":%d", cfg.SSHPort),
+			HostKeyPath:        cfg.SSHHostKeyPath,
+			SandboxProvider:    sandboxProvider,
+			UserInfoFetcher:    &sshUserInfoAdapter{svc: sshSandboxSvc},
+			ConnectionRecorder: &sshAuditAdapter{store: s, audit: auditSvc},
 		})
 		if err != nil {
-			log.Printf("Warning: Failed to create SSH server: %v", err)
+			serverLog.Warn("failed to create SSH server", "error", err)
 		} else {
 			go func() {
 				if err := sshServer.Start(); err != nil {
-					log.Printf("SSH server stopped: %v", err)
+					serverLog.Error("SSH server stopped", "error", err)
 				}
 			}()
-			log.Printf("SSH server started on port %d", cfg.SSHPort)
+			serverLog.Info("SSH server started", "port", cfg.SSHPort)
 		}
 	}
 
@@ -260,19 +303,23 @@ func main() {
 	var sessionSvc *service.SessionService
 	var dispSandboxSvc *service.SandboxService
 	var sandboxIdleMonitor *service.SandboxIdleMonitor
+	var workspaceSyncMonitor *service.WorkspaceSyncMonitor
 	if cfg.DispatcherEnabled {
 		disp = dispatcher.NewService(s, cfg, eventBroker)
 
 		// Register workspace init executor
 		workspaceSvc := service.NewWorkspaceService(s, gitProvider, eventBroker)
+		if sandboxProvider != nil {
+			workspaceSvc.SetSandboxManager(sandboxManager)
+		}
 		disp.RegisterExecutor(dispatcher.NewWorkspaceInitExecutor(workspaceSvc))
 
-		// Register session init, delete, and commit executors if sandbox provider is available
+		// Register session init, delete, commit, rebase, batch run, schedule, and headless run executors if sandbox provider is available
 		if sandboxProvider != nil {
 			gitSvc := service.NewGitService(s, gitProvider)
 			credSvc, err := service.NewCredentialService(s, cfg)
 			if err != nil {
-				log.Fatalf("Failed to create credential service for dispatcher: %v", err)
+				fatal("failed to create credential service for dispatcher", "error", err)
 			}
 			credFetcher := service.MakeCredentialFetcher(s, credSvc)
 			dispSandboxSvc = service.NewSandboxService(s, sandboxProvider, cfg, credFetcher, eventBroker, jobQueue)
@@ -281,10 +328,17 @@ func main() {
 			disp.RegisterExecutor(dispatcher.NewSessionInitExecutor(sessionSvc))
 			disp.RegisterExecutor(dispatcher.NewSessionDeleteExecutor(sessionSvc))
 			disp.RegisterExecutor(dispatcher.NewSessionCommitExecutor(sessionSvc))
+			disp.RegisterExecutor(dispatcher.NewSessionRebaseExecutor(sessionSvc))
+			chatSvc := service.NewChatService(s, sessionSvc, jobQueue, eventBroker, dispSandboxSvc, gitSvc)
+			disp.RegisterExecutor(dispatcher.NewBatchRunItemExecutor(service.NewBatchRunService(s, sessionSvc, chatSvc, eventBroker, jobQueue)))
+			scheduleSvc := service.NewScheduleService(s, sessionSvc, chatSvc, eventBroker, jobQueue)
+			disp.RegisterExecutor(dispatcher.NewScheduleRunExecutor(scheduleSvc))
+			disp.SetScheduler(scheduleSvc)
+			disp.RegisterExecutor(dispatcher.NewHeadlessRunExecutor(service.NewHeadlessRunService(s, sessionSvc, chatSvc, jobQueue, cfg.DispatcherJobTimeout)))
 		}
 
 		disp.Start(context.Background())
-		log.Printf("Job dispatcher started (server ID: %s)", disp.ServerID())
+		serverLog.Info("job dispatcher started", "server_id", disp.ServerID())
 
 		// Start sandbox idle monitor to auto-stop idle sessions
 		if sandboxProvider != nil && sessionSvc != nil && cfg.SandboxIdleTimeout > 0 {
@@ -292,53 +346,69 @@ func main() {
 				s,
 				dispSandboxSvc,
 				sessionSvc,
-				slog.Default(),
+				logging.Component("idle-monitor"),
 				cfg.SandboxIdleTimeout,
 				cfg.IdleCheckInterval,
 			)
+			if cfg.IdleCPUThreshold > 0 {
+				sandboxIdleMonitor.SetCPUActivity(float64(cfg.IdleCPUThreshold), cfg.IdleCPUDuration)
+			}
 			sandboxIdleMonitor.Start(context.Background())
-			log.Printf("Sandbox idle monitor started (timeout: %s, check interval: %s)",
-				cfg.SandboxIdleTimeout, cfg.IdleCheckInterval)
+			serverLog.Info("sandbox idle monitor started",
+				"timeout", cfg.SandboxIdleTimeout, "check_interval", cfg.IdleCheckInterval)
+		}
+
+		// Start workspace sync monitor to track sessions falling behind upstream
+		if sessionSvc != nil && cfg.WorkspaceSyncInterval > 0 {
+			workspaceSyncMonitor = service.NewWorkspaceSyncMonitor(
+				s,
+				service.NewGitService(s, gitProvider),
+				sessionSvc,
+				logging.Component("workspace-sync"),
+				cfg.WorkspaceSyncInterval,
+			)
+			workspaceSyncMonitor.Start(context.Background())
+			serverLog.Info("workspace sync monitor started", "interval", cfg.WorkspaceSyncInterval)
 		}
 
 		// Start all reconciliation in background after dispatcher is ready
 		// This ensures all reconciliation can properly enqueue jobs if needed
 		if dispSandboxSvc != nil && sessionSvc != nil {
 			go func() {
-				log.Println("Starting reconciliation in background...")
+				serverLog.Info("starting reconciliation in background")
 
 				// 1. Reconcile sandboxes to ensure they use the correct image
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 				if err := dispSandboxSvc.ReconcileSandboxes(ctx); err != nil {
-					log.Printf("Warning: Failed to reconcile sandboxes: %v", err)
+					serverLog.Warn("failed to reconcile sandboxes", "error", err)
 				} else {
-					log.Println("Sandbox reconciliation completed successfully")
+					serverLog.Info("sandbox reconciliation completed")
 				}
 				cancel()
 
 				// 2. Reconcile session states with actual sandbox states
 				ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
 				if err := dispSandboxSvc.ReconcileSessionStates(ctx); err != nil {
-					log.Printf("Warning: Failed to reconcile session states: %v", err)
+					serverLog.Warn("failed to reconcile session states", "error", err)
 				} else {
-					log.Println("Session state reconciliation completed successfully")
+					serverLog.Info("session state reconciliation completed")
 				}
 				cancel()
 
 				// 3. Reconcile commit states to re-enqueue stuck commits
 				ctx, cancel = context.WithTimeout(context.Background(), 5*time.Minute)
 				if err := sessionSvc.ReconcileCommitStates(ctx); err != nil {
-					log.Printf("Warning: Failed to reconcile commit states: %v", err)
+					serverLog.Warn("failed to reconcile commit states", "error", err)
 				} else {
-					log.Println("Commit state reconciliation completed successfully")
+					serverLog.Info("commit state reconciliation completed")
 				}
 				cancel()
 
-				log.Println("All reconciliation completed")
+				serverLog.Info("all reconciliation completed")
 			}()
 		}
 	} else {
-		log.Println("Job dispatcher disabled")
+		serverLog.Info("job dispatcher disabled")
 	}
 
 	// Create router
@@ -347,6 +417,8 @@ func main() {
 	// Global middleware
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.Metrics)
+	r.Use(middleware.Tracing)
 	r.Use(middleware.SanitizedLogger)
 	r.Use(chimiddleware.Recoverer)
 	// Note: No global timeout - SSE endpoints need long-lived connections
@@ -383,1080 +455,20 @@ func main() {
 		h.JobQueue().SetNotifyFunc(disp.NotifyNewJob)
 	}
 
-	// Route registry for metadata
-	reg := routes.GetRegistry()
-
-	// ===== Health & Status (no auth) =====
-	reg.Register(r, routes.Route{
-		Method: "GET", Pattern: "/health",
-		Handler: func(w http.ResponseWriter, _ *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`{"status":"ok"}`))
-		},
-		Meta: routes.Meta{Group: "Health", Description: "Health check"},
-	})
-
-	reg.Register(r, routes.Route{
-		Method: "GET", Pattern: "/api/status",
-		Handler: h.GetSystemStatus,
-		Meta:    routes.Meta{Group: "Health", Description: "System status (Docker, Git checks)"},
-	})
-
-	reg.Register(r, routes.Route{
-		Method: "GET", Pattern: "/api/server-config",
-		Handler: h.GetServerConfig,
-		Meta:    routes.Meta{Group: "Health", Description: "Public server configuration (SSH port, etc.)"},
-	})
-
-	reg.Register(r, routes.Route{
-		Method: "GET", Pattern: "/api/support-info",
-		Handler: h.GetSupportInfo,
-		Meta:    routes.Meta{Group: "Health", Description: "Diagnostic information for debugging (version, config, logs)"},
-	})
-
-	// API UI - serve the embedded static HTML file
-	r.Get("/api/ui", func(w http.ResponseWriter, _ *http.Request) {
-		content, err := static.Files.ReadFile("api-ui.html")
-		if err != nil {
-			http.Error(w, "API UI not found", http.StatusInternalServerError)
-			return
+	// Metrics read from the store and providers at scrape time
+	if cfg.MetricsEnabled {
+		metrics.Registry.MustRegister(metrics.NewJobQueueCollector(s))
+		if sandboxProvider != nil {
+			metrics.Registry.MustRegister(metrics.NewSandboxCollector(sandboxManager))
 		}
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		_, _ = w.Write(content)
-	})
-
-	// API Routes endpoint (returns route metadata for API UI)
-	r.Get("/api/routes", h.GetRoutes)
-
-	// ===== Auth routes (no auth required) =====
-	r.Route("/auth", func(r chi.Router) {
-		authReg := reg.WithPrefix("/auth")
-
-		authReg.Register(r, routes.Route{
-			Method: "GET", Pattern: "/login/{provider}",
-			Handler: h.AuthLogin,
-			Meta: routes.Meta{
-				Group:       "Auth",
-				Description: "Start OAuth login",
-				Params:      []routes.Param{{Name: "provider", Example: "github"}},
-			},
-		})
-
-		authReg.Register(r, routes.Route{
-			Method: "GET", Pattern: "/callback/{provider}",
-			Handler: h.AuthCallback,
-			Meta: routes.Meta{
-				Group:       "Auth",
-				Description: "OAuth callback",
-				Params:      []routes.Param{{Name: "code", In: "query"}, {Name: "state", In: "query"}},
-			},
-		})
-
-		authReg.Register(r, routes.Route{
-			Method: "POST", Pattern: "/logout",
-			Handler: h.AuthLogout,
-			Meta:    routes.Meta{Group: "Auth", Description: "Logout"},
-		})
-
-		authReg.Register(r, routes.Route{
-			Method: "GET", Pattern: "/me",
-			Handler: h.AuthMe,
-			Meta:    routes.Meta{Group: "Auth", Description: "Get current user"},
-		})
-	})
-
-	// ===== API routes (auth required) =====
-	r.Route("/api", func(r chi.Router) {
-		r.Use(middleware.Auth(s, cfg))
-		apiReg := reg.WithPrefix("/api")
-
-		// User Preferences (user-scoped, not project-scoped)
-		r.Route("/preferences", func(r chi.Router) {
-			prefReg := apiReg.WithPrefix("/preferences")
-
-			prefReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/",
-				Handler: h.ListPreferences,
-				Meta:    routes.Meta{Group: "Preferences", Description: "List all user preferences"},
-			})
-
-			prefReg.Register(r, routes.Route{
-				Method: "PUT", Pattern: "/",
-				Handler: h.SetPreferences,
-				Meta: routes.Meta{
-					Group:       "Preferences",
-					Description: "Set multiple preferences",
-					Body:        map[string]any{"preferences": map[string]string{"theme": "dark", "editor": "vim"}},
-				},
-			})
-
-			prefReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/{key}",
-				Handler: h.GetPreference,
-				Meta: routes.Meta{
-					Group:       "Preferences",
-					Description: "Get preference by key",
-					Params:      []routes.Param{{Name: "key", Example: "theme"}},
-				},
-			})
-
-			prefReg.Register(r, routes.Route{
-				Method: "PUT", Pattern: "/{key}",
-				Handler: h.SetPreference,
-				Meta: routes.Meta{
-					Group:       "Preferences",
-					Description: "Set preference",
-					Params:      []routes.Param{{Name: "key", Example: "theme"}},
-					Body:        map[string]any{"value": "dark"},
-				},
-			})
-
-			prefReg.Register(r, routes.Route{
-				Method: "DELETE", Pattern: "/{key}",
-				Handler: h.DeletePreference,
-				Meta: routes.Meta{
-					Group:       "Preferences",
-					Description: "Delete preference",
-					Params:      []routes.Param{{Name: "key", Example: "theme"}},
-				},
-			})
-		})
-
-		// Project list
-		apiReg.Register(r, routes.Route{
-			Method: "GET", Pattern: "/projects",
-			Handler: h.ListProjects,
-			Meta:    routes.Meta{Group: "Projects", Description: "List projects"},
-		})
+	}
 
-		apiReg.Register(r, routes.Route{
-			Method: "POST", Pattern: "/projects",
-			Handler: h.CreateProject,
-			Meta: routes.Meta{
-				Group:       "Projects",
-				Description: "Create project",
-				Body:        map[string]any{"name": "My Project", "slug": "my-project"},
-			},
-		})
+	// Route registry for metadata and project permission checks
+	reg := routes.GetRegistry()
+	reg.SetAuthorizer(middleware.HasProjectPermission)
+	reg.SetGuard(middleware.TokenScopeGuard(s))
 
-		// Project-specific routes
-		r.Route("/projects/{projectId}", func(r chi.Router) {
-			r.Use(middleware.ProjectMember(s))
-			projReg := apiReg.WithPrefix("/projects/{projectId}")
-
-			// SSE events
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/events",
-				Handler: h.Events,
-				Meta: routes.Meta{
-					Group:       "Events",
-					Description: "SSE event stream",
-					Params: []routes.Param{
-						{Name: "projectId", Example: "local"},
-						{Name: "since", In: "query", Example: "2024-01-15T10:30:00Z"},
-						{Name: "after", In: "query"},
-					},
-				},
-			})
-
-			// Project CRUD
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/",
-				Handler: h.GetProject,
-				Meta: routes.Meta{
-					Group:       "Projects",
-					Description: "Get project",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			projReg.Register(r, routes.Route{
-				Method: "PUT", Pattern: "/",
-				Handler: h.UpdateProject,
-				Meta: routes.Meta{
-					Group:       "Projects",
-					Description: "Update project",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					Body:        map[string]any{"name": "Updated Name"},
-				},
-			})
-
-			projReg.Register(r, routes.Route{
-				Method: "DELETE", Pattern: "/",
-				Handler: h.DeleteProject,
-				Meta: routes.Meta{
-					Group:       "Projects",
-					Description: "Delete project",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			// Members
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/members",
-				Handler: h.ListProjectMembers,
-				Meta: routes.Meta{
-					Group:       "Members",
-					Description: "List members",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			projReg.Register(r, routes.Route{
-				Method: "DELETE", Pattern: "/members/{userId}",
-				Handler: h.RemoveProjectMember,
-				Meta: routes.Meta{
-					Group:       "Members",
-					Description: "Remove member",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			// Invitations
-			projReg.Register(r, routes.Route{
-				Method: "POST", Pattern: "/invitations",
-				Handler: h.CreateInvitation,
-				Meta: routes.Meta{
-					Group:       "Members",
-					Description: "Create invitation",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					Body:        map[string]any{"email": "user@example.com", "role": "member"},
-				},
-			})
-
-			projReg.Register(r, routes.Route{
-				Method: "POST", Pattern: "/invitations/{token}/accept",
-				Handler: h.AcceptInvitation,
-				Meta: routes.Meta{
-					Group:       "Members",
-					Description: "Accept invitation",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			// Cache Volumes
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/cache",
-				Handler: h.ListProjectCacheVolumes,
-				Meta: routes.Meta{
-					Group:       "Cache",
-					Description: "List cache volumes for project",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			projReg.Register(r, routes.Route{
-				Method: "DELETE", Pattern: "/cache",
-				Handler: h.DeleteProjectCacheVolume,
-				Meta: routes.Meta{
-					Group:       "Cache",
-					Description: "Delete cache volume for project (clears all caches)",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-				},
-			})
-
-			// Workspaces
-			r.Route("/workspaces", func(r chi.Router) {
-				wsReg := projReg.WithPrefix("/workspaces")
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/providers",
-					Handler: h.GetProviders,
-					Meta: routes.Meta{
-						Group:       "Providers",
-						Description: "List sandbox providers with status",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/providers/{provider}",
-					Handler: h.GetProvider,
-					Meta: routes.Meta{
-						Group:       "Providers",
-						Description: "Get sandbox provider status",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "provider", Example: "vz"},
-						},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/",
-					Handler: h.ListWorkspaces,
-					Meta: routes.Meta{
-						Group:       "Workspaces",
-						Description: "List workspaces",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/",
-					Handler: h.CreateWorkspace,
-					Meta: routes.Meta{
-						Group:       "Workspaces",
-						Description: "Create workspace",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"name": "My Workspace", "path": "/home/user/code", "source_type": "local"},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}",
-					Handler: h.GetWorkspace,
-					Meta: routes.Meta{
-						Group:       "Workspaces",
-						Description: "Get workspace",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "PUT", Pattern: "/{workspaceId}",
-					Handler: h.UpdateWorkspace,
-					Meta: routes.Meta{
-						Group:       "Workspaces",
-						Description: "Update workspace",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"name": "Updated Name"},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "DELETE", Pattern: "/{workspaceId}",
-					Handler: h.DeleteWorkspace,
-					Meta: routes.Meta{
-						Group:       "Workspaces",
-						Description: "Delete workspace",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				// Sessions within workspace
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/sessions",
-					Handler: h.ListSessionsByWorkspace,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "List sessions",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				// Git operations
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/git/status",
-					Handler: h.GetWorkspaceGitStatus,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Get git status",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{workspaceId}/git/fetch",
-					Handler: h.FetchWorkspace,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Fetch from remote",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{workspaceId}/git/checkout",
-					Handler: h.CheckoutWorkspace,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Checkout branch/ref",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"ref": "main"},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/git/branches",
-					Handler: h.GetWorkspaceBranches,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "List branches",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/git/diff",
-					Handler: h.GetWorkspaceDiff,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Get diff",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "base", In: "query", Example: "HEAD~1"},
-							{Name: "target", In: "query", Example: "HEAD"},
-						},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/git/files",
-					Handler: h.GetWorkspaceFileTree,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Get file tree",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "ref", In: "query", Example: "HEAD"},
-						},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/git/file",
-					Handler: h.GetWorkspaceFileContent,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Get file content",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "path", In: "query", Required: true, Example: "README.md"},
-							{Name: "ref", In: "query", Example: "HEAD"},
-						},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{workspaceId}/git/file",
-					Handler: h.WriteWorkspaceFile,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Write file",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"path": "README.md", "content": "# Hello"},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{workspaceId}/git/stage",
-					Handler: h.StageWorkspaceFiles,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Stage files",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"paths": []string{"README.md"}},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{workspaceId}/git/commit",
-					Handler: h.CommitWorkspace,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Commit changes",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"message": "Initial commit"},
-					},
-				})
-
-				wsReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{workspaceId}/git/log",
-					Handler: h.GetWorkspaceLog,
-					Meta: routes.Meta{
-						Group:       "Git",
-						Description: "Get commit log",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "limit", In: "query", Example: "10"},
-						},
-					},
-				})
-			})
-
-			// Sessions (direct access)
-			r.Route("/sessions", func(r chi.Router) {
-				sessReg := projReg.WithPrefix("/sessions")
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/",
-					Handler: h.CreateSession,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Create session (without chat message)",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"id": "abc123", "workspaceId": "", "agentId": ""},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}",
-					Handler: h.GetSession,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Get session",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "PUT", Pattern: "/{sessionId}",
-					Handler: h.UpdateSession,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Update session",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"name": "Updated Session", "status": "stopped"},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "PATCH", Pattern: "/{sessionId}",
-					Handler: h.UpdateSession,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Patch session (partial update)",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"displayName": "My Custom Name"},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "DELETE", Pattern: "/{sessionId}",
-					Handler: h.DeleteSession,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Delete session",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{sessionId}/commit",
-					Handler: h.CommitSession,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Commit session changes",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/files",
-					Handler: h.ListSessionFiles,
-					Meta: routes.Meta{
-						Group:       "Files",
-						Description: "List session files",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "sessionId", Example: "abc123"},
-							{Name: "path", In: "query", Example: "."},
-							{Name: "hidden", In: "query", Example: "true"},
-						},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/files/read",
-					Handler: h.ReadSessionFile,
-					Meta: routes.Meta{
-						Group:       "Files",
-						Description: "Read session file",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "sessionId", Example: "abc123"},
-							{Name: "path", In: "query", Required: true, Example: "README.md"},
-						},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "PUT", Pattern: "/{sessionId}/files/write",
-					Handler: h.WriteSessionFile,
-					Meta: routes.Meta{
-						Group:       "Files",
-						Description: "Write session file",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "sessionId", Example: "abc123"},
-						},
-						Body: map[string]any{"path": "README.md", "content": "# Hello"},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{sessionId}/files/delete",
-					Handler: h.DeleteSessionFile,
-					Meta: routes.Meta{
-						Group:       "Files",
-						Description: "Delete session file or directory",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "sessionId", Example: "abc123"},
-						},
-						Body: map[string]any{"path": "old-file.txt"},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{sessionId}/files/rename",
-					Handler: h.RenameSessionFile,
-					Meta: routes.Meta{
-						Group:       "Files",
-						Description: "Rename/move session file or directory",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "sessionId", Example: "abc123"},
-						},
-						Body: map[string]any{"oldPath": "old-name.txt", "newPath": "new-name.txt"},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/diff",
-					Handler: h.GetSessionDiff,
-					Meta: routes.Meta{
-						Group:       "Files",
-						Description: "Get session diff",
-						Params: []routes.Param{
-							{Name: "projectId", Example: "local"},
-							{Name: "sessionId", Example: "abc123"},
-							{Name: "path", In: "query", Example: "README.md"},
-							{Name: "format", In: "query", Example: "files"},
-						},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/messages",
-					Handler: h.ListMessages,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "List messages",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				// Terminal (session-specific)
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/terminal/ws",
-					Handler: h.TerminalWebSocket,
-					Meta: routes.Meta{
-						Group:       "Terminal",
-						Description: "Terminal WebSocket",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/terminal/history",
-					Handler: h.GetTerminalHistory,
-					Meta: routes.Meta{
-						Group:       "Terminal",
-						Description: "Terminal history",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/terminal/status",
-					Handler: h.GetTerminalStatus,
-					Meta: routes.Meta{
-						Group:       "Terminal",
-						Description: "Terminal status",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				// Hooks
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/hooks/status",
-					Handler: h.GetHooksStatus,
-					Meta: routes.Meta{
-						Group:       "Hooks",
-						Description: "Get hook evaluation status",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/hooks/{hookId}/output",
-					Handler: h.GetHookOutput,
-					Meta: routes.Meta{
-						Group:       "Hooks",
-						Description: "Get hook output log",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "hookId", Example: "biome-check"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{sessionId}/hooks/{hookId}/rerun",
-					Handler: h.RerunHook,
-					Meta: routes.Meta{
-						Group:       "Hooks",
-						Description: "Rerun a hook",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "hookId", Example: "biome-check"}},
-					},
-				})
-
-				// Services
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/services",
-					Handler: h.ListServices,
-					Meta: routes.Meta{
-						Group:       "Services",
-						Description: "List services",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{sessionId}/services/{serviceId}/start",
-					Handler: h.StartService,
-					Meta: routes.Meta{
-						Group:       "Services",
-						Description: "Start service",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "serviceId", Example: "my-server"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{sessionId}/services/{serviceId}/stop",
-					Handler: h.StopService,
-					Meta: routes.Meta{
-						Group:       "Services",
-						Description: "Stop service",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "serviceId", Example: "my-server"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/services/{serviceId}/output",
-					Handler: h.GetServiceOutput,
-					Meta: routes.Meta{
-						Group:       "Services",
-						Description: "Stream service output (SSE)",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "serviceId", Example: "my-server"}},
-					},
-				})
-
-				sessReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{sessionId}/models",
-					Handler: h.GetSessionModels,
-					Meta: routes.Meta{
-						Group:       "Sessions",
-						Description: "Get available models for session",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
-					},
-				})
-			})
-
-			// Agents
-			r.Route("/agents", func(r chi.Router) {
-				agentReg := projReg.WithPrefix("/agents")
-
-				agentReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/",
-					Handler: h.ListAgents,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "List agents",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/",
-					Handler: h.CreateAgent,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Create agent",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"name": "My Agent", "agent_type": "claude-code"},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/types",
-					Handler: h.GetAgentTypes,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Get agent types",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/auth-providers",
-					Handler: h.GetAuthProviders,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Get auth providers",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/default",
-					Handler: h.SetDefaultAgent,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Set default agent",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"agent_id": ""},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{agentId}",
-					Handler: h.GetAgent,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Get agent",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "PUT", Pattern: "/{agentId}",
-					Handler: h.UpdateAgent,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Update agent",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"name": "Updated Agent"},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "DELETE", Pattern: "/{agentId}",
-					Handler: h.DeleteAgent,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Delete agent",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				agentReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{agentId}/models",
-					Handler: h.GetAgentModels,
-					Meta: routes.Meta{
-						Group:       "Agents",
-						Description: "Get available models for agent",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "agentId", Example: ""}},
-					},
-				})
-			})
-
-			// Suggestions
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/suggestions",
-				Handler: h.GetSuggestions,
-				Meta: routes.Meta{
-					Group:       "Other",
-					Description: "Get suggestions",
-					Params: []routes.Param{
-						{Name: "projectId", Example: "local"},
-						{Name: "q", In: "query", Example: "/home"},
-					},
-				},
-			})
-
-			// Credentials
-			r.Route("/credentials", func(r chi.Router) {
-				credReg := projReg.WithPrefix("/credentials")
-
-				credReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/",
-					Handler: h.ListCredentials,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "List credentials",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/",
-					Handler: h.CreateCredential,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Create credential",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"provider": "anthropic", "name": "My API Key", "api_key": "sk-..."},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "GET", Pattern: "/{provider}",
-					Handler: h.GetCredential,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Get credential",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "provider", Example: "anthropic"}},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "DELETE", Pattern: "/{provider}",
-					Handler: h.DeleteCredential,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Delete credential",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/{provider}/refresh",
-					Handler: h.RefreshCredential,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Refresh OAuth tokens",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "provider", Example: "anthropic"}},
-					},
-				})
-
-				// Anthropic OAuth
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/anthropic/authorize",
-					Handler: h.AnthropicAuthorize,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Anthropic OAuth authorize",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"redirect_uri": "http://localhost:3000/callback"},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/anthropic/exchange",
-					Handler: h.AnthropicExchange,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Anthropic OAuth exchange",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"code": "", "redirect_uri": "", "code_verifier": ""},
-					},
-				})
-
-				// GitHub Copilot OAuth
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/github-copilot/device-code",
-					Handler: h.GitHubCopilotDeviceCode,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "GitHub Copilot device code",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/github-copilot/poll",
-					Handler: h.GitHubCopilotPoll,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "GitHub Copilot poll",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"device_code": ""},
-					},
-				})
-
-				// Codex OAuth
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/codex/authorize",
-					Handler: h.CodexAuthorize,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Codex OAuth authorize",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"redirect_uri": "http://localhost:3000/callback"},
-					},
-				})
-
-				credReg.Register(r, routes.Route{
-					Method: "POST", Pattern: "/codex/exchange",
-					Handler: h.CodexExchange,
-					Meta: routes.Meta{
-						Group:       "Credentials",
-						Description: "Codex OAuth exchange",
-						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-						Body:        map[string]any{"code": "", "redirect_uri": "", "code_verifier": ""},
-					},
-				})
-			})
-
-			// Chat endpoint
-			projReg.Register(r, routes.Route{
-				Method: "POST", Pattern: "/chat",
-				Handler: h.Chat,
-				Meta: routes.Meta{
-					Group:       "Chat",
-					Description: "AI Chat (streaming)",
-					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
-					Body:        map[string]any{"messages": []map[string]any{{"role": "user", "content": "Hello"}}},
-				},
-			})
-
-			// Chat stream resume endpoint
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/chat/{sessionId}/stream",
-				Handler: h.ChatStream,
-				Meta: routes.Meta{
-					Group:       "Chat",
-					Description: "Resume in-progress chat stream (SSE)",
-					Params: []routes.Param{
-						{Name: "projectId", Example: "local"},
-						{Name: "sessionId", Example: "abc123"},
-					},
-				},
-			})
-
-			// Chat cancel endpoint
-			projReg.Register(r, routes.Route{
-				Method: "POST", Pattern: "/chat/{sessionId}/cancel",
-				Handler: h.ChatCancel,
-				Meta: routes.Meta{
-					Group:       "Chat",
-					Description: "Cancel in-progress chat completion",
-					Params: []routes.Param{
-						{Name: "projectId", Example: "local"},
-						{Name: "sessionId", Example: "abc123"},
-					},
-				},
-			})
-
-			// Chat question endpoint - poll for pending AskUserQuestion
-			projReg.Register(r, routes.Route{
-				Method: "GET", Pattern: "/chat/{sessionId}/question",
-				Handler: h.ChatQuestion,
-				Meta: routes.Meta{
-					Group:       "Chat",
-					Description: "Get pending AskUserQuestion (null if none)",
-					Params: []routes.Param{
-						{Name: "projectId", Example: "local"},
-						{Name: "sessionId", Example: "abc123"},
-					},
-				},
-			})
-
-			// Chat answer endpoint - submit answer to pending AskUserQuestion
-			projReg.Register(r, routes.Route{
-				Method: "POST", Pattern: "/chat/{sessionId}/answer",
-				Handler: h.ChatAnswer,
-				Meta: routes.Meta{
-					Group:       "Chat",
-					Description: "Submit answers to a pending AskUserQuestion",
-					Params: []routes.Param{
-						{Name: "projectId", Example: "local"},
-						{Name: "sessionId", Example: "abc123"},
-					},
-				},
-			})
-		})
-	})
+	registerRoutes(r, reg, h, s, cfg)
 
 	// Start debug Docker proxy if enabled
 	var debugDockerServer *handler.DebugDockerServer
@@ -1464,7 +476,7 @@ func main() {
 		var err error
 		debugDockerServer, err = handler.NewDebugDockerServer(sandboxManager, "local", cfg.DebugDockerPort)
 		if err != nil {
-			log.Printf("Warning: Failed to create debug Docker proxy: %v", err)
+			serverLog.Warn("failed to create debug Docker proxy", "error", err)
 		} else {
 			debugDockerServer.Start()
 		}
@@ -1479,9 +491,9 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %d", cfg.Port)
+		serverLog.Info("server starting", "port", cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			fatal("server failed", "error", err)
 		}
 	}()
 
@@ -1496,7 +508,7 @@ func main() {
 			buf := make([]byte, 1)
 			for {
 				if _, err := os.Stdin.Read(buf); err != nil {
-					log.Println("Stdin closed, shutting down (parent process died)")
+					serverLog.Info("stdin closed, shutting down (parent process died)")
 					quit <- syscall.SIGTERM
 					return
 				}
@@ -1509,11 +521,11 @@ func main() {
 	// Hard deadline: if graceful shutdown takes longer than 10s, force exit.
 	go func() {
 		time.Sleep(10 * time.Second)
-		log.Println("Graceful shutdown timed out, forcing exit")
+		serverLog.Error("graceful shutdown timed out, forcing exit")
 		os.Exit(1)
 	}()
 
-	log.Println("Shutting down server...")
+	serverLog.Info("shutting down server")
 
 	// Stop debug Docker proxy
 	if debugDockerServer != nil {
@@ -1527,16 +539,16 @@ func main() {
 
 	// Shutdown sandbox manager (gracefully stop all VMs and providers)
 	if sandboxManager != nil {
-		log.Println("Shutting down sandbox providers...")
+		serverLog.Info("shutting down sandbox providers")
 		sandboxManager.Shutdown()
-		log.Println("Sandbox providers stopped")
+		serverLog.Info("sandbox providers stopped")
 	}
 
 	// Stop session status poller
 	if sessionStatusPoller != nil {
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 		if err := sessionStatusPoller.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Warning: failed to stop session status poller: %v", err)
+			serverLog.Warn("failed to stop session status poller", "error", err)
 		}
 		shutdownCancel()
 	}
@@ -1545,7 +557,34 @@ func main() {
 	if sandboxIdleMonitor != nil {
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 		if err := sandboxIdleMonitor.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Warning: failed to stop sandbox idle monitor: %v", err)
+			serverLog.Warn("failed to stop sandbox idle monitor", "error", err)
+		}
+		shutdownCancel()
+	}
+
+	// Stop workspace sync monitor
+	if workspaceSyncMonitor != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := workspaceSyncMonitor.Shutdown(shutdownCtx); err != nil {
+			serverLog.Warn("failed to stop workspace sync monitor", "error", err)
+		}
+		shutdownCancel()
+	}
+
+	// Stop audit log retention monitor
+	if auditRetentionMonitor != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := auditRetentionMonitor.Shutdown(shutdownCtx); err != nil {
+			serverLog.Warn("failed to stop audit log retention monitor", "error", err)
+		}
+		shutdownCancel()
+	}
+
+	// Stop invitation cleanup monitor
+	if invitationCleanupMonitor != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := invitationCleanupMonitor.Shutdown(shutdownCtx); err != nil {
+			serverLog.Warn("failed to stop invitation cleanup monitor", "error", err)
 		}
 		shutdownCancel()
 	}
@@ -1553,7 +592,7 @@ func main() {
 	// Stop SSH server
 	if sshServer != nil {
 		if err := sshServer.Stop(); err != nil {
-			log.Printf("Warning: failed to stop SSH server: %v", err)
+			serverLog.Warn("failed to stop SSH server", "error", err)
 		}
 	}
 
@@ -1573,10 +612,15 @@ func main() {
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("server forced to shutdown", "error", err)
+	}
+
+	// Flush spans of in-flight requests and jobs
+	if err := shutdownTracing(ctx); err != nil {
+		serverLog.Warn("failed to flush traces", "error", err)
 	}
 
-	log.Println("Server stopped")
+	serverLog.Info("server stopped")
 }
 
 // sshUserInfoAdapter adapts SandboxService.GetClient to the ssh.UserInfoFetcher interface.
@@ -1595,3 +639,26 @@ func (a *sshUserInfoAdapter) GetUserInfo(ctx context.Context, sessionID string)
 	}
 	return userInfo.Username, userInfo.UID, userInfo.GID, nil
 }
+
+// sshAuditAdapter records SSH connections in the audit log. SSH connections
+// are unauthenticated, so the entry carries no actor.
+type sshAuditAdapter struct {
+	store *store.Store
+	audit *service.AuditService
+}
+
+func (a *sshAuditAdapter) RecordConnection(ctx context.Context, sessionID, remoteAddr string) {
+	entry := service.AuditEntry{
+		Action:     model.AuditActionSSHConnect,
+		TargetType: "session",
+		TargetID:   sessionID,
+		IP:         remoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		entry.IP = host
+	}
+	if session, err := a.store.GetSessionByID(ctx, sessionID); err == nil {
+		entry.ProjectID = session.ProjectID
+	}
+	a.audit.Record(ctx, entry)
+}
@@ -0,0 +1,2127 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/handler"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/oauth"
+	"github.com/obot-platform/discobot/server/internal/routes"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+	"github.com/obot-platform/discobot/server/internal/service"
+	"github.com/obot-platform/discobot/server/internal/startup"
+	"github.com/obot-platform/discobot/server/internal/store"
+	"github.com/obot-platform/discobot/server/static"
+)
+
+// registerRoutes registers every HTTP route on r, recording its metadata in
+// reg for /api/routes and /api/openapi.json.
+func registerRoutes(r chi.Router, reg *routes.Registry, h *handler.Handler, s *store.Store, cfg *config.Config) {
+	// ===== Health & Status (no auth) =====
+	reg.Register(r, routes.Route{
+		Method: "GET", Pattern: "/health",
+		Handler: func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		},
+		Meta: routes.Meta{Group: "Health", Description: "Health check"},
+	})
+
+	if cfg.MetricsEnabled {
+		reg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/metrics",
+			Handler: h.Metrics,
+			Meta:    routes.Meta{Group: "Health", Description: "Prometheus metrics (bearer METRICS_TOKEN if set)"},
+		})
+	}
+
+	reg.Register(r, routes.Route{
+		Method: "GET", Pattern: "/api/status",
+		Handler: h.GetSystemStatus,
+		Meta: routes.Meta{
+			Group:       "Health",
+			Description: "System status (Docker, Git checks)",
+			Response:    startup.SystemStatusResponse{},
+		},
+	})
+
+	reg.Register(r, routes.Route{
+		Method: "GET", Pattern: "/api/server-config",
+		Handler: h.GetServerConfig,
+		Meta: routes.Meta{
+			Group:       "Health",
+			Description: "Public server configuration (SSH port, etc.)",
+			Response:    handler.ServerConfigResponse{},
+		},
+	})
+
+	reg.Register(r, routes.Route{
+		Method: "GET", Pattern: "/api/support-info",
+		Handler: h.GetSupportInfo,
+		Meta: routes.Meta{
+			Group:       "Health",
+			Description: "Diagnostic information for debugging (version, config, logs)",
+			Response:    handler.SupportInfoResponse{},
+		},
+	})
+
+	// ===== Admin (ADMIN_TOKEN, or open when auth is disabled) =====
+	reg.Register(r, routes.Route{
+		Method: "GET", Pattern: "/api/admin/log-levels",
+		Handler: h.GetLogLevels,
+		Meta: routes.Meta{
+			Group:       "Admin",
+			Description: "Get log levels",
+			Response:    handler.LogLevels{},
+		},
+	})
+
+	reg.Register(r, routes.Route{
+		Method: "PUT", Pattern: "/api/admin/log-levels",
+		Handler: h.SetLogLevels,
+		Meta: routes.Meta{
+			Group:       "Admin",
+			Description: "Set log levels (components not listed use the default level)",
+			Request:     handler.LogLevels{},
+			Response:    handler.LogLevels{},
+		},
+	})
+
+	// API UI - serve the embedded static HTML file
+	r.Get("/api/ui", func(w http.ResponseWriter, _ *http.Request) {
+		content, err := static.Files.ReadFile("api-ui.html")
+		if err != nil {
+			http.Error(w, "API UI not found", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(content)
+	})
+
+	// API Routes endpoint (returns route metadata for API UI)
+	r.Get("/api/routes", h.GetRoutes)
+
+	// OpenAPI 3.1 document generated from the route registry
+	r.Get("/api/openapi.json", h.GetOpenAPI)
+
+	// ===== Auth routes (no auth required) =====
+	r.Route("/auth", func(r chi.Router) {
+		authReg := reg.WithPrefix("/auth")
+
+		authReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/login/{provider}",
+			Handler: h.AuthLogin,
+			Meta: routes.Meta{
+				Group:       "Auth",
+				Description: "Start OAuth login",
+				Params:      []routes.Param{{Name: "provider", Example: "github"}},
+			},
+		})
+
+		authReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/callback/{provider}",
+			Handler: h.AuthCallback,
+			Meta: routes.Meta{
+				Group:       "Auth",
+				Description: "OAuth callback",
+				Params:      []routes.Param{{Name: "code", In: "query"}, {Name: "state", In: "query"}},
+			},
+		})
+
+		authReg.Register(r, routes.Route{
+			Method: "POST", Pattern: "/logout",
+			Handler: h.AuthLogout,
+			Meta: routes.Meta{
+				Group:       "Auth",
+				Description: "Logout",
+				Response:    map[string]bool{"success": true},
+			},
+		})
+
+		authReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/me",
+			Handler: h.AuthMe,
+			Meta: routes.Meta{
+				Group:       "Auth",
+				Description: "Get current user",
+				Response:    service.User{},
+			},
+		})
+	})
+
+	// ===== Shared session routes (share token instead of auth) =====
+	// Read-only views of a single session for holders of a share link. The
+	// SessionShare middleware stands in for Auth and ProjectMember, and only
+	// GET routes may be registered here.
+	r.Route("/api/share/{shareToken}", func(r chi.Router) {
+		r.Use(middleware.SessionShare(s))
+		shareReg := reg.WithPrefix("/api/share/{shareToken}")
+		shareParams := []routes.Param{{Name: "shareToken", Example: "dss_abc123"}}
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/",
+			Handler: h.GetSharedSession,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "Get the shared session",
+				Params:      shareParams,
+				Response:    service.SharedSession{},
+			},
+		})
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/messages",
+			Handler: h.ListSharedMessages,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "List the shared session's messages",
+				Params:      shareParams,
+				Response:    map[string]any{"messages": []sandboxapi.UIMessage{}},
+			},
+		})
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/diff",
+			Handler: h.GetSharedSessionDiff,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "Get the shared session's diff",
+				Params: []routes.Param{
+					{Name: "shareToken", Example: "dss_abc123"},
+					{Name: "path", In: "query", Example: "README.md"},
+					{Name: "format", In: "query", Example: "files"},
+				},
+			},
+		})
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/hooks/status",
+			Handler: h.GetSharedHooksStatus,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "Get the shared session's hook status",
+				Params:      shareParams,
+				Response:    sandboxapi.HooksStatusResponse{},
+			},
+		})
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/hooks/{hookId}/output",
+			Handler: h.GetSharedHookOutput,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "Get a hook's output",
+				Params: []routes.Param{
+					{Name: "shareToken", Example: "dss_abc123"},
+					{Name: "hookId", Example: "biome-check"},
+				},
+				Response: sandboxapi.HookOutputResponse{},
+			},
+		})
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/services",
+			Handler: h.ListSharedServices,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "List the shared session's services",
+				Params:      shareParams,
+				Response:    sandboxapi.ListServicesResponse{},
+			},
+		})
+
+		shareReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/services/{serviceId}/output",
+			Handler: h.GetSharedServiceOutput,
+			Meta: routes.Meta{
+				Group:       "Shared Sessions",
+				Description: "Stream a service's output (SSE)",
+				Params: []routes.Param{
+					{Name: "shareToken", Example: "dss_abc123"},
+					{Name: "serviceId", Example: "my-server"},
+				},
+			},
+		})
+	})
+
+	// ===== API routes (auth required) =====
+	r.Route("/api", func(r chi.Router) {
+		r.Use(middleware.Auth(s, cfg))
+		apiReg := reg.WithPrefix("/api").Authenticated()
+
+		// User Preferences (user-scoped, not project-scoped)
+		r.Route("/preferences", func(r chi.Router) {
+			prefReg := apiReg.WithPrefix("/preferences")
+
+			prefReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/",
+				Handler: h.ListPreferences,
+				Meta: routes.Meta{
+					Group:       "Preferences",
+					Description: "List all user preferences",
+					Response:    map[string]any{"preferences": []service.UserPreference{}},
+				},
+			})
+
+			prefReg.Register(r, routes.Route{
+				Method: "PUT", Pattern: "/",
+				Handler: h.SetPreferences,
+				Meta: routes.Meta{
+					Group:       "Preferences",
+					Description: "Set multiple preferences",
+					Body:        map[string]any{"preferences": map[string]string{"theme": "dark", "editor": "vim"}},
+					Response:    map[string]any{"preferences": []service.UserPreference{}},
+				},
+			})
+
+			prefReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/{key}",
+				Handler: h.GetPreference,
+				Meta: routes.Meta{
+					Group:       "Preferences",
+					Description: "Get preference by key",
+					Params:      []routes.Param{{Name: "key", Example: "theme"}},
+					Response:    service.UserPreference{},
+				},
+			})
+
+			prefReg.Register(r, routes.Route{
+				Method: "PUT", Pattern: "/{key}",
+				Handler: h.SetPreference,
+				Meta: routes.Meta{
+					Group:       "Preferences",
+					Description: "Set preference",
+					Params:      []routes.Param{{Name: "key", Example: "theme"}},
+					Body:        map[string]any{"value": "dark"},
+					Response:    service.UserPreference{},
+				},
+			})
+
+			prefReg.Register(r, routes.Route{
+				Method: "DELETE", Pattern: "/{key}",
+				Handler: h.DeletePreference,
+				Meta: routes.Meta{
+					Group:       "Preferences",
+					Description: "Delete preference",
+					Params:      []routes.Param{{Name: "key", Example: "theme"}},
+					Response:    map[string]bool{"success": true},
+				},
+			})
+		})
+
+		// API tokens (user-scoped)
+		r.Route("/tokens", func(r chi.Router) {
+			tokenReg := apiReg.WithPrefix("/tokens")
+
+			tokenReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/",
+				Handler: h.ListTokens,
+				Meta: routes.Meta{
+					Group:       "Tokens",
+					Description: "List API tokens",
+					Response:    map[string]any{"tokens": []service.APIToken{}},
+				},
+			})
+
+			tokenReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/",
+				Handler: h.CreateToken,
+				Meta: routes.Meta{
+					Group:       "Tokens",
+					Description: "Create API token (the token is only returned once)",
+					Body:        map[string]any{"name": "CI", "scopes": []string{"read", "chat"}, "expiresInDays": 90},
+					Request:     service.CreateTokenRequest{},
+					Response:    service.APIToken{},
+					Status:      http.StatusCreated,
+				},
+			})
+
+			tokenReg.Register(r, routes.Route{
+				Method: "DELETE", Pattern: "/{tokenId}",
+				Handler: h.RevokeToken,
+				Meta: routes.Meta{
+					Group:       "Tokens",
+					Description: "Revoke API token",
+					Response:    map[string]bool{"success": true},
+				},
+			})
+		})
+
+		// Project list
+		apiReg.Register(r, routes.Route{
+			Method: "GET", Pattern: "/projects",
+			Handler: h.ListProjects,
+			Meta: routes.Meta{
+				Group:       "Projects",
+				Description: "List projects",
+				Response:    []service.Project{},
+			},
+		})
+
+		apiReg.Register(r, routes.Route{
+			Method: "POST", Pattern: "/projects",
+			Handler: h.CreateProject,
+			Meta: routes.Meta{
+				Group:       "Projects",
+				Description: "Create project",
+				Body:        map[string]any{"name": "My Project", "slug": "my-project"},
+				Response:    service.Project{},
+				Status:      http.StatusCreated,
+			},
+		})
+
+		// Project-specific routes
+		r.Route("/projects/{projectId}", func(r chi.Router) {
+			r.Use(middleware.ProjectMember(s))
+			projReg := apiReg.WithPrefix("/projects/{projectId}")
+
+			// SSE events
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/events",
+				Handler: h.Events,
+				Meta: routes.Meta{
+					Group:       "Events",
+					Description: "SSE event stream",
+					Permission:  model.PermissionProjectView,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "since", In: "query", Example: "2024-01-15T10:30:00Z"},
+						{Name: "after", In: "query"},
+					},
+				},
+			})
+
+			// Project CRUD
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/",
+				Handler: h.GetProject,
+				Meta: routes.Meta{
+					Group:       "Projects",
+					Description: "Get project",
+					Permission:  model.PermissionProjectView,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    service.Project{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "PUT", Pattern: "/",
+				Handler: h.UpdateProject,
+				Meta: routes.Meta{
+					Group:       "Projects",
+					Description: "Update project",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Body:        map[string]any{"name": "Updated Name"},
+					Response:    service.Project{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "DELETE", Pattern: "/",
+				Handler: h.DeleteProject,
+				Meta: routes.Meta{
+					Group:       "Projects",
+					Description: "Delete project",
+					Permission:  model.PermissionProjectDelete,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    map[string]bool{"success": true},
+				},
+			})
+
+			// Members
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/members",
+				Handler: h.ListProjectMembers,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "List members",
+					Permission:  model.PermissionProjectView,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    []service.ProjectMember{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "DELETE", Pattern: "/members/{userId}",
+				Handler: h.RemoveProjectMember,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "Remove member",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    map[string]bool{"success": true},
+				},
+			})
+
+			// Invitations
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/invitations",
+				Handler: h.CreateInvitation,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "Create invitation",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Body:        map[string]any{"email": "user@example.com", "role": "member"},
+					Response:    service.ProjectInvitation{},
+					Status:      http.StatusCreated,
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/invitations",
+				Handler: h.ListInvitations,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "List pending invitations",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    map[string]any{"invitations": []service.ProjectInvitation{}},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/invitations/{invitationId}/resend",
+				Handler: h.ResendInvitation,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "Resend invitation email",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "invitationId", Example: "inv-123"}},
+					Response:    service.ProjectInvitation{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "DELETE", Pattern: "/invitations/{invitationId}",
+				Handler: h.RevokeInvitation,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "Revoke invitation",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "invitationId", Example: "inv-123"}},
+					Response:    map[string]bool{"success": true},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/invitations/{token}/accept",
+				Handler: h.AcceptInvitation,
+				Meta: routes.Meta{
+					Group:       "Members",
+					Description: "Accept invitation",
+					Permission:  model.PermissionProjectView,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    map[string]bool{"success": true},
+				},
+			})
+
+			// Service Accounts
+			r.Route("/service-accounts", func(r chi.Router) {
+				saReg := projReg.WithPrefix("/service-accounts")
+
+				saReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListServiceAccounts,
+					Meta: routes.Meta{
+						Group:       "Service Accounts",
+						Description: "List service accounts",
+						Permission:  model.PermissionProjectManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"serviceAccounts": []service.ServiceAccount{}},
+					},
+				})
+
+				saReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateServiceAccount,
+					Meta: routes.Meta{
+						Group:       "Service Accounts",
+						Description: "Create service account",
+						Permission:  model.PermissionProjectManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "ci-bot", "role": "member"},
+						Request:     service.CreateServiceAccountRequest{},
+						Response:    service.ServiceAccount{},
+						Status:      http.StatusCreated,
+					},
+				})
+
+				saReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{serviceAccountId}",
+					Handler: h.DeleteServiceAccount,
+					Meta: routes.Meta{
+						Group:       "Service Accounts",
+						Description: "Delete service account and revoke its tokens",
+						Permission:  model.PermissionProjectManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				saReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{serviceAccountId}/tokens",
+					Handler: h.ListServiceAccountTokens,
+					Meta: routes.Meta{
+						Group:       "Service Accounts",
+						Description: "List service account tokens",
+						Permission:  model.PermissionProjectManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"tokens": []service.APIToken{}},
+					},
+				})
+
+				saReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{serviceAccountId}/tokens",
+					Handler: h.CreateServiceAccountToken,
+					Meta: routes.Meta{
+						Group:       "Service Accounts",
+						Description: "Create service account token",
+						Permission:  model.PermissionProjectManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "deploy", "scopes": []string{"chat"}, "expiresInDays": 30},
+						Request:     service.CreateTokenRequest{},
+						Response:    service.APIToken{},
+						Status:      http.StatusCreated,
+					},
+				})
+
+				saReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{serviceAccountId}/tokens/{tokenId}",
+					Handler: h.RevokeServiceAccountToken,
+					Meta: routes.Meta{
+						Group:       "Service Accounts",
+						Description: "Revoke service account token",
+						Permission:  model.PermissionProjectManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+			})
+
+			// Audit log
+			r.Route("/audit-logs", func(r chi.Router) {
+				auditReg := projReg.WithPrefix("/audit-logs")
+
+				auditReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListAuditLogs,
+					Meta: routes.Meta{
+						Group:       "Audit",
+						Description: "List audit log entries, newest first",
+						Permission:  model.PermissionAuditView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "action", In: "query", Example: "credential.create"},
+							{Name: "actorId", In: "query"},
+							{Name: "since", In: "query", Example: "2024-01-15T00:00:00Z"},
+							{Name: "until", In: "query", Example: "2024-01-16T00:00:00Z"},
+							{Name: "limit", In: "query", Example: "100"},
+						},
+						Response: map[string]any{"entries": []model.AuditLog{}},
+					},
+				})
+
+				auditReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/export",
+					Handler: h.ExportAuditLogs,
+					Meta: routes.Meta{
+						Group:       "Audit",
+						Description: "Export audit log entries as JSONL or CSV",
+						Permission:  model.PermissionAuditView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "format", In: "query", Example: "jsonl"},
+							{Name: "action", In: "query", Example: "credential.create"},
+							{Name: "actorId", In: "query"},
+							{Name: "since", In: "query", Example: "2024-01-15T00:00:00Z"},
+							{Name: "until", In: "query", Example: "2024-01-16T00:00:00Z"},
+						},
+					},
+				})
+			})
+
+			// Cache Volumes
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/cache",
+				Handler: h.GetProjectCache,
+				Meta: routes.Meta{
+					Group:       "Cache",
+					Description: "Get per-entry cache usage and cache limits for project",
+					Permission:  model.PermissionProjectView,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    service.ProjectCache{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "PUT", Pattern: "/cache/settings",
+				Handler: h.UpdateProjectCacheSettings,
+				Meta: routes.Meta{
+					Group:       "Cache",
+					Description: "Set the project's cache quota and max entry age (0 = no limit)",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Body:        service.CacheSettings{QuotaBytes: 20 << 30, MaxAgeDays: 30},
+					Response:    service.CacheSettings{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/cache/prune",
+				Handler: h.PruneProjectCache,
+				Meta: routes.Meta{
+					Group:       "Cache",
+					Description: "Clear least recently used and expired cache entries to fit the cache limits",
+					Permission:  model.PermissionProjectManage,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Body:        map[string]any{"entries": []string{"go", "npm"}, "maxAgeDays": 7},
+					Response:    sandbox.CachePruneResult{},
+				},
+			})
+
+			projReg.Register(r, routes.Route{
+				Method: "DELETE", Pattern: "/cache",
+				Handler: h.ClearProjectCache,
+				Meta: routes.Meta{
+					Group:       "Cache",
+					Description: "Clear the project's caches, or only the given entries",
+					Permission:  model.PermissionProjectManage,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "entry", In: "query", Example: "go/pkg/mod"},
+					},
+					Response: sandbox.CachePruneResult{},
+				},
+			})
+
+			// Resource Metrics
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/metrics",
+				Handler: h.GetProjectMetrics,
+				Meta: routes.Meta{
+					Group:       "Metrics",
+					Description: "Get resource usage of the project's active sandboxes, busiest first",
+					Permission:  model.PermissionProjectView,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Response:    service.ProjectMetrics{},
+				},
+			})
+
+			// Workspaces
+			r.Route("/workspaces", func(r chi.Router) {
+				wsReg := projReg.WithPrefix("/workspaces")
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/providers",
+					Handler: h.GetProviders,
+					Meta: routes.Meta{
+						Group:       "Providers",
+						Description: "List sandbox providers with status",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"providers": map[string]sandbox.ProviderStatus{}, "default": ""},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/providers/{provider}",
+					Handler: h.GetProvider,
+					Meta: routes.Meta{
+						Group:       "Providers",
+						Description: "Get sandbox provider status",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "provider", Example: "vz"},
+						},
+						Response: sandbox.ProviderStatus{},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListWorkspaces,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "List workspaces",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"workspaces": []service.Workspace{}},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateWorkspace,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Create workspace",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "My Workspace", "path": "/home/user/code", "source_type": "local"},
+						Response:    service.Workspace{},
+						Status:      http.StatusCreated,
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}",
+					Handler: h.GetWorkspace,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Get workspace",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    service.Workspace{},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{workspaceId}",
+					Handler: h.UpdateWorkspace,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Update workspace",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "Updated Name"},
+						Response:    service.Workspace{},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{workspaceId}",
+					Handler: h.DeleteWorkspace,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Delete workspace",
+						Permission:  model.PermissionWorkspaceDelete,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				// Question policy
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/question-policy",
+					Handler: h.GetWorkspaceQuestionPolicy,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Get the policy for answering agent questions in the workspace's sessions",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"policy": &model.QuestionPolicy{}},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{workspaceId}/question-policy",
+					Handler: h.SetWorkspaceQuestionPolicy,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Set the policy for answering agent questions in the workspace's sessions",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"mode": "recommended", "defaultAnswer": "Use your best judgement.", "timeoutMinutes": 30},
+						Request:     model.QuestionPolicy{},
+						Response:    map[string]any{"policy": &model.QuestionPolicy{}},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{workspaceId}/question-policy",
+					Handler: h.DeleteWorkspaceQuestionPolicy,
+					Meta: routes.Meta{
+						Group:       "Workspaces",
+						Description: "Clear the workspace's question policy",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				// Sessions within workspace
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/sessions",
+					Handler: h.ListSessionsByWorkspace,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "List sessions",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"sessions": []service.Session{}},
+					},
+				})
+
+				// Git operations
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/git/status",
+					Handler: h.GetWorkspaceGitStatus,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Get git status",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    git.Status{},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{workspaceId}/git/fetch",
+					Handler: h.FetchWorkspace,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Fetch from remote",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{workspaceId}/git/checkout",
+					Handler: h.CheckoutWorkspace,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Checkout branch/ref",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"ref": "main"},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/git/branches",
+					Handler: h.GetWorkspaceBranches,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "List branches",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"branches": []git.Branch{}},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/git/diff",
+					Handler: h.GetWorkspaceDiff,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Get diff",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "base", In: "query", Example: "HEAD~1"},
+							{Name: "target", In: "query", Example: "HEAD"},
+						},
+						Response: map[string]any{"diffs": []git.FileDiff{}},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/git/files",
+					Handler: h.GetWorkspaceFileTree,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Get file tree",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "ref", In: "query", Example: "HEAD"},
+						},
+						Response: map[string]any{"files": []git.FileEntry{}},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/git/file",
+					Handler: h.GetWorkspaceFileContent,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Get file content",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "path", In: "query", Required: true, Example: "README.md"},
+							{Name: "ref", In: "query", Example: "HEAD"},
+						},
+						Response: map[string]any{"path": "", "ref": "", "content": ""},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{workspaceId}/git/file",
+					Handler: h.WriteWorkspaceFile,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Write file",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"path": "README.md", "content": "# Hello"},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{workspaceId}/git/stage",
+					Handler: h.StageWorkspaceFiles,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Stage files",
+						Permission:  model.PermissionWorkspaceWrite,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"paths": []string{"README.md"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{workspaceId}/git/commit",
+					Handler: h.CommitWorkspace,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Commit changes",
+						Permission:  model.PermissionSessionCommit,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"message": "Initial commit"},
+						Response:    git.Commit{},
+						Status:      http.StatusCreated,
+					},
+				})
+
+				wsReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{workspaceId}/git/log",
+					Handler: h.GetWorkspaceLog,
+					Meta: routes.Meta{
+						Group:       "Git",
+						Description: "Get commit log",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "limit", In: "query", Example: "10"},
+						},
+						Response: map[string]any{"commits": []git.Commit{}},
+					},
+				})
+			})
+
+			// Sessions (direct access)
+			r.Route("/sessions", func(r chi.Router) {
+				sessReg := projReg.WithPrefix("/sessions")
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Create session (without chat message)",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"id": "abc123", "workspaceId": "", "agentId": ""},
+						Request:     handler.CreateSessionRequest{},
+						Response:    map[string]any{"id": ""},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}",
+					Handler: h.GetSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Get session",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    service.Session{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{sessionId}",
+					Handler: h.UpdateSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Update session",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "Updated Session", "status": "stopped"},
+						Response:    service.Session{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "PATCH", Pattern: "/{sessionId}",
+					Handler: h.UpdateSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Patch session (partial update)",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"displayName": "My Custom Name"},
+						Response:    service.Session{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{sessionId}",
+					Handler: h.DeleteSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Delete session",
+						Permission:  model.PermissionSessionDelete,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/question-policy",
+					Handler: h.GetSessionQuestionPolicy,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Get the session's question policy and the policy in effect",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    service.SessionQuestionPolicy{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{sessionId}/question-policy",
+					Handler: h.SetSessionQuestionPolicy,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Set the session's question policy, overriding the workspace's",
+						Permission:  model.PermissionSessionChat,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"mode": "first_option"},
+						Request:     model.QuestionPolicy{},
+						Response:    service.SessionQuestionPolicy{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{sessionId}/question-policy",
+					Handler: h.DeleteSessionQuestionPolicy,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Clear the session's question policy so the workspace's applies",
+						Permission:  model.PermissionSessionChat,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    service.SessionQuestionPolicy{},
+					},
+				})
+
+				// Resource metrics
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/metrics",
+					Handler: h.GetSessionMetrics,
+					Meta: routes.Meta{
+						Group:       "Metrics",
+						Description: "Sample CPU, memory, disk, network and process usage of the session's sandbox",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    sandbox.ResourceMetrics{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/metrics/stream",
+					Handler: h.StreamSessionMetrics,
+					Meta: routes.Meta{
+						Group:       "Metrics",
+						Description: "Stream resource usage samples of the session's sandbox (SSE)",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "interval", In: "query", Example: "5s"},
+						},
+					},
+				})
+
+				// Read-only share links
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/shares",
+					Handler: h.ListSessionShares,
+					Meta: routes.Meta{
+						Group:       "Shares",
+						Description: "List the session's share links",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"shares": []service.SessionShare{}},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/shares",
+					Handler: h.CreateSessionShare,
+					Meta: routes.Meta{
+						Group:       "Shares",
+						Description: "Create a read-only share link (token returned once)",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"expiresInDays": 7},
+						Request:     service.CreateShareRequest{},
+						Response:    service.SessionShare{},
+						Status:      http.StatusCreated,
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{sessionId}/shares/{shareId}",
+					Handler: h.RevokeSessionShare,
+					Meta: routes.Meta{
+						Group:       "Shares",
+						Description: "Revoke a share link",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/commit",
+					Handler: h.CommitSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Commit session changes",
+						Permission:  model.PermissionSessionCommit,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Request:     handler.CommitSessionRequest{},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/commit/patches",
+					Handler: h.GetCommitReview,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Get commits awaiting review",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Response:    service.CommitReview{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/commit/apply",
+					Handler: h.ApplyCommitReview,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Apply reviewed commits",
+						Permission:  model.PermissionSessionCommit,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Body:        map[string]any{"excludeCommits": []string{}, "squash": false, "feedback": ""},
+						Request:     handler.ApplyCommitReviewRequest{},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/commit/reject",
+					Handler: h.RejectCommitReview,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Reject reviewed commits",
+						Permission:  model.PermissionSessionCommit,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Body:        map[string]any{"feedback": "Please split the refactor into its own commit"},
+						Request:     handler.RejectCommitReviewRequest{},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/rebase",
+					Handler: h.RebaseSession,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Rebase session onto latest upstream commit",
+						Permission:  model.PermissionSessionCommit,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/files",
+					Handler: h.ListSessionFiles,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "List session files",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+							{Name: "path", In: "query", Example: "."},
+							{Name: "hidden", In: "query", Example: "true"},
+						},
+						Response: sandboxapi.ListFilesResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/files/read",
+					Handler: h.ReadSessionFile,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "Read session file",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+							{Name: "path", In: "query", Required: true, Example: "README.md"},
+						},
+						Response: sandboxapi.ReadFileResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{sessionId}/files/write",
+					Handler: h.WriteSessionFile,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "Write session file",
+						Permission:  model.PermissionSessionFiles,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+						},
+						Body:     map[string]any{"path": "README.md", "content": "# Hello"},
+						Request:  sandboxapi.WriteFileRequest{},
+						Response: sandboxapi.WriteFileResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/files/delete",
+					Handler: h.DeleteSessionFile,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "Delete session file or directory",
+						Permission:  model.PermissionSessionFiles,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+						},
+						Body:     map[string]any{"path": "old-file.txt"},
+						Request:  sandboxapi.DeleteFileRequest{},
+						Response: sandboxapi.DeleteFileResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/files/rename",
+					Handler: h.RenameSessionFile,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "Rename/move session file or directory",
+						Permission:  model.PermissionSessionFiles,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+						},
+						Body:     map[string]any{"oldPath": "old-name.txt", "newPath": "new-name.txt"},
+						Request:  sandboxapi.RenameFileRequest{},
+						Response: sandboxapi.RenameFileResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/diff",
+					Handler: h.GetSessionDiff,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "Get session diff",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+							{Name: "path", In: "query", Example: "README.md"},
+							{Name: "format", In: "query", Example: "files"},
+						},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/compare/{otherSessionId}",
+					Handler: h.CompareSessions,
+					Meta: routes.Meta{
+						Group:       "Files",
+						Description: "Compare two sessions",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "sessionId", Example: "abc123"},
+							{Name: "otherSessionId", Example: "def456"},
+							{Name: "mode", In: "query", Example: "commits"},
+						},
+						Response: service.SessionComparison{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/messages",
+					Handler: h.ListMessages,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "List messages",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"messages": []sandboxapi.UIMessage{}},
+					},
+				})
+
+				// Terminal (session-specific)
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/terminal/ws",
+					Handler: h.TerminalWebSocket,
+					Meta: routes.Meta{
+						Group:       "Terminal",
+						Description: "Terminal WebSocket",
+						Permission:  model.PermissionSessionTerminal,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/terminal/history",
+					Handler: h.GetTerminalHistory,
+					Meta: routes.Meta{
+						Group:       "Terminal",
+						Description: "Terminal history",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"history": []model.TerminalHistory{}},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/terminal/status",
+					Handler: h.GetTerminalStatus,
+					Meta: routes.Meta{
+						Group:       "Terminal",
+						Description: "Terminal status",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/startup",
+					Handler: h.GetSessionStartup,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Get the sandbox startup timeline",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Response:    sandboxapi.StartupTimeline{},
+					},
+				})
+
+				// Hooks
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/hooks/status",
+					Handler: h.GetHooksStatus,
+					Meta: routes.Meta{
+						Group:       "Hooks",
+						Description: "Get hook evaluation status",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Response:    sandboxapi.HooksStatusResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/hooks/{hookId}/output",
+					Handler: h.GetHookOutput,
+					Meta: routes.Meta{
+						Group:       "Hooks",
+						Description: "Get hook output log",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "hookId", Example: "biome-check"}},
+						Response:    sandboxapi.HookOutputResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/hooks/{hookId}/rerun",
+					Handler: h.RerunHook,
+					Meta: routes.Meta{
+						Group:       "Hooks",
+						Description: "Rerun a hook",
+						Permission:  model.PermissionSessionChat,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "hookId", Example: "biome-check"}},
+						Response:    sandboxapi.HookRerunResponse{},
+					},
+				})
+
+				// Services
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/services",
+					Handler: h.ListServices,
+					Meta: routes.Meta{
+						Group:       "Services",
+						Description: "List services",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Response:    sandboxapi.ListServicesResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/services/{serviceId}/start",
+					Handler: h.StartService,
+					Meta: routes.Meta{
+						Group:       "Services",
+						Description: "Start service",
+						Permission:  model.PermissionSessionChat,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "serviceId", Example: "my-server"}},
+						Response:    sandboxapi.StartServiceResponse{},
+						Status:      http.StatusAccepted,
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{sessionId}/services/{serviceId}/stop",
+					Handler: h.StopService,
+					Meta: routes.Meta{
+						Group:       "Services",
+						Description: "Stop service",
+						Permission:  model.PermissionSessionChat,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "serviceId", Example: "my-server"}},
+						Response:    sandboxapi.StopServiceResponse{},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/services/{serviceId}/output",
+					Handler: h.GetServiceOutput,
+					Meta: routes.Meta{
+						Group:       "Services",
+						Description: "Stream service output (SSE)",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}, {Name: "serviceId", Example: "my-server"}},
+					},
+				})
+
+				sessReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{sessionId}/models",
+					Handler: h.GetSessionModels,
+					Meta: routes.Meta{
+						Group:       "Sessions",
+						Description: "Get available models for session",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "sessionId", Example: "abc123"}},
+						Response:    handler.ModelsResponse{},
+					},
+				})
+			})
+
+			// Batch runs
+			r.Route("/batch-runs", func(r chi.Router) {
+				batchReg := projReg.WithPrefix("/batch-runs")
+
+				batchReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListBatchRuns,
+					Meta: routes.Meta{
+						Group:       "Batch Runs",
+						Description: "List batch runs",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"batchRuns": []service.BatchRun{}},
+					},
+				})
+
+				batchReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateBatchRun,
+					Meta: routes.Meta{
+						Group:       "Batch Runs",
+						Description: "Run one prompt across a matrix of workspaces, agents, models and reasoning modes",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body: map[string]any{
+							"name":   "Fix flaky test",
+							"prompt": "Fix the flaky test in server/internal/store",
+							"matrix": map[string]any{
+								"workspaceIds": []string{"ws-abc123"},
+								"agentIds":     []string{"agent-abc123"},
+								"models":       []string{"anthropic:claude-sonnet-4", "anthropic:claude-opus-4"},
+								"reasoning":    []string{"enabled", "disabled"},
+							},
+							"concurrency": 2,
+							"autoCommit":  false,
+						},
+						Request:  service.CreateBatchRunRequest{},
+						Response: service.BatchRun{},
+						Status:   http.StatusCreated,
+					},
+				})
+
+				batchReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{batchRunId}",
+					Handler: h.GetBatchRun,
+					Meta: routes.Meta{
+						Group:       "Batch Runs",
+						Description: "Get batch run with items and aggregate status",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "batchRunId", Example: "abc123"}},
+						Response:    service.BatchRun{},
+					},
+				})
+
+				batchReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{batchRunId}/cancel",
+					Handler: h.CancelBatchRun,
+					Meta: routes.Meta{
+						Group:       "Batch Runs",
+						Description: "Cancel batch run items that have not started",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "batchRunId", Example: "abc123"}},
+						Response:    service.BatchRun{},
+					},
+				})
+			})
+
+			// Schedules
+			r.Route("/schedules", func(r chi.Router) {
+				schedReg := projReg.WithPrefix("/schedules")
+
+				schedReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListSchedules,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "List schedules",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"schedules": []model.Schedule{}},
+					},
+				})
+
+				schedReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateSchedule,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "Create a schedule that runs a prompt in a new session on a cron schedule",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body: map[string]any{
+							"name":        "Nightly dependency bump",
+							"workspaceId": "ws-abc123",
+							"agentId":     "agent-abc123",
+							"prompt":      "Update all dependencies to their latest minor versions and fix any breakage",
+							"cron":        "0 2 * * *",
+							"timezone":    "America/New_York",
+							"autoCommit":  true,
+							"autoDelete":  true,
+						},
+						Request:  service.CreateScheduleRequest{},
+						Response: model.Schedule{},
+						Status:   http.StatusCreated,
+					},
+				})
+
+				schedReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{scheduleId}",
+					Handler: h.GetSchedule,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "Get schedule",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "scheduleId", Example: "abc123"}},
+						Response:    model.Schedule{},
+					},
+				})
+
+				schedReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{scheduleId}",
+					Handler: h.UpdateSchedule,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "Update schedule",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "scheduleId", Example: "abc123"}},
+						Body:        map[string]any{"enabled": false},
+						Request:     service.UpdateScheduleRequest{},
+						Response:    model.Schedule{},
+					},
+				})
+
+				schedReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{scheduleId}",
+					Handler: h.DeleteSchedule,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "Delete schedule and its run history",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "scheduleId", Example: "abc123"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				schedReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{scheduleId}/run",
+					Handler: h.TriggerSchedule,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "Run schedule now",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "scheduleId", Example: "abc123"}},
+						Response:    model.ScheduleRun{},
+						Status:      http.StatusAccepted,
+					},
+				})
+
+				schedReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{scheduleId}/runs",
+					Handler: h.ListScheduleRuns,
+					Meta: routes.Meta{
+						Group:       "Schedules",
+						Description: "List recent runs of a schedule",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "scheduleId", Example: "abc123"}},
+						Response:    map[string]any{"runs": []model.ScheduleRun{}},
+					},
+				})
+			})
+
+			// Headless runs
+			r.Route("/runs", func(r chi.Router) {
+				runReg := projReg.WithPrefix("/runs")
+
+				runReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListHeadlessRuns,
+					Meta: routes.Meta{
+						Group:       "Headless Runs",
+						Description: "List recent headless runs",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"runs": []service.HeadlessRun{}},
+					},
+				})
+
+				runReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateHeadlessRun,
+					Meta: routes.Meta{
+						Group:       "Headless Runs",
+						Description: "Run a prompt in a new session to completion without a client attached",
+						Permission:  model.PermissionSessionCreate,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body: map[string]any{
+							"workspaceId":        "ws-abc123",
+							"prompt":             "Fix the failing tests",
+							"answerPolicy":       "first_option",
+							"maxDurationSeconds": 900,
+							"requireHooks":       true,
+						},
+						Request:  service.CreateHeadlessRunRequest{},
+						Response: service.HeadlessRun{},
+						Status:   http.StatusCreated,
+					},
+				})
+
+				runReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{runId}",
+					Handler: h.GetHeadlessRun,
+					Meta: routes.Meta{
+						Group:       "Headless Runs",
+						Description: "Get headless run",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "runId", Example: "abc123"}},
+						Response:    service.HeadlessRun{},
+					},
+				})
+
+				runReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{runId}/result",
+					Handler: h.GetHeadlessRunResult,
+					Meta: routes.Meta{
+						Group:       "Headless Runs",
+						Description: "Wait for a headless run to finish (200 when finished, 202 if still running after wait)",
+						Permission:  model.PermissionProjectView,
+						Params: []routes.Param{
+							{Name: "projectId", Example: "local"},
+							{Name: "runId", Example: "abc123"},
+							{Name: "wait", In: "query", Example: "60s"},
+						},
+						Response: service.HeadlessRun{},
+					},
+				})
+			})
+
+			// Agents
+			r.Route("/agents", func(r chi.Router) {
+				agentReg := projReg.WithPrefix("/agents")
+
+				agentReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListAgents,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "List agents",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"agents": []service.Agent{}},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateAgent,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Create agent",
+						Permission:  model.PermissionAgentManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "My Agent", "agent_type": "claude-code"},
+						Response:    service.Agent{},
+						Status:      http.StatusCreated,
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/types",
+					Handler: h.GetAgentTypes,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Get agent types",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"agentTypes": []handler.AgentType{}},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/auth-providers",
+					Handler: h.GetAuthProviders,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Get auth providers",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/default",
+					Handler: h.SetDefaultAgent,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Set default agent",
+						Permission:  model.PermissionAgentManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"agent_id": ""},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{agentId}",
+					Handler: h.GetAgent,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Get agent",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    service.Agent{},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "PUT", Pattern: "/{agentId}",
+					Handler: h.UpdateAgent,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Update agent",
+						Permission:  model.PermissionAgentManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"name": "Updated Agent"},
+						Response:    service.Agent{},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{agentId}",
+					Handler: h.DeleteAgent,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Delete agent",
+						Permission:  model.PermissionAgentManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]bool{"success": true},
+					},
+				})
+
+				agentReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{agentId}/models",
+					Handler: h.GetAgentModels,
+					Meta: routes.Meta{
+						Group:       "Agents",
+						Description: "Get available models for agent",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "agentId", Example: ""}},
+						Response:    handler.ModelsResponse{},
+					},
+				})
+			})
+
+			// Suggestions
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/suggestions",
+				Handler: h.GetSuggestions,
+				Meta: routes.Meta{
+					Group:       "Other",
+					Description: "Get suggestions",
+					Permission:  model.PermissionProjectView,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "q", In: "query", Example: "/home"},
+					},
+					Response: map[string]any{"suggestions": []handler.Suggestion{}},
+				},
+			})
+
+			// Credentials
+			r.Route("/credentials", func(r chi.Router) {
+				credReg := projReg.WithPrefix("/credentials")
+
+				credReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/",
+					Handler: h.ListCredentials,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "List credentials",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"credentials": []service.CredentialInfo{}},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/",
+					Handler: h.CreateCredential,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Create credential",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"provider": "anthropic", "name": "My API Key", "api_key": "sk-..."},
+						Request:     handler.CreateCredentialRequest{},
+						Response:    service.CredentialInfo{},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "GET", Pattern: "/{provider}",
+					Handler: h.GetCredential,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Get credential",
+						Permission:  model.PermissionProjectView,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "provider", Example: "anthropic"}},
+						Response:    service.CredentialInfo{},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "DELETE", Pattern: "/{provider}",
+					Handler: h.DeleteCredential,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Delete credential",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Response:    map[string]any{"status": "deleted"},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/{provider}/refresh",
+					Handler: h.RefreshCredential,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Refresh OAuth tokens",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}, {Name: "provider", Example: "anthropic"}},
+					},
+				})
+
+				// Anthropic OAuth
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/anthropic/authorize",
+					Handler: h.AnthropicAuthorize,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Anthropic OAuth authorize",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"redirect_uri": "http://localhost:3000/callback"},
+						Response:    oauth.AuthorizeResponse{},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/anthropic/exchange",
+					Handler: h.AnthropicExchange,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Anthropic OAuth exchange",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"code": "", "redirect_uri": "", "code_verifier": ""},
+						Request:     handler.AnthropicExchangeRequest{},
+					},
+				})
+
+				// GitHub Copilot OAuth
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/github-copilot/device-code",
+					Handler: h.GitHubCopilotDeviceCode,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "GitHub Copilot device code",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Request:     handler.GitHubCopilotDeviceCodeRequest{},
+						Response:    handler.GitHubCopilotDeviceCodeResponse{},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/github-copilot/poll",
+					Handler: h.GitHubCopilotPoll,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "GitHub Copilot poll",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"device_code": ""},
+						Request:     handler.GitHubCopilotPollRequest{},
+						Response:    handler.GitHubCopilotPollResponse{},
+					},
+				})
+
+				// Codex OAuth
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/codex/authorize",
+					Handler: h.CodexAuthorize,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Codex OAuth authorize",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"redirect_uri": "http://localhost:3000/callback"},
+						Request:     handler.CodexAuthorizeRequest{},
+						Response:    oauth.AuthorizeResponse{},
+					},
+				})
+
+				credReg.Register(r, routes.Route{
+					Method: "POST", Pattern: "/codex/exchange",
+					Handler: h.CodexExchange,
+					Meta: routes.Meta{
+						Group:       "Credentials",
+						Description: "Codex OAuth exchange",
+						Permission:  model.PermissionCredentialManage,
+						Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+						Body:        map[string]any{"code": "", "redirect_uri": "", "code_verifier": ""},
+						Request:     handler.CodexExchangeRequest{},
+					},
+				})
+			})
+
+			// Chat endpoint
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/chat",
+				Handler: h.Chat,
+				Meta: routes.Meta{
+					Group:       "Chat",
+					Description: "AI Chat (streaming)",
+					Permission:  model.PermissionSessionChat,
+					Params:      []routes.Param{{Name: "projectId", Example: "local"}},
+					Body:        map[string]any{"messages": []map[string]any{{"role": "user", "content": "Hello"}}},
+					Request:     handler.ChatRequest{},
+				},
+			})
+
+			// Chat stream resume endpoint
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/chat/{sessionId}/stream",
+				Handler: h.ChatStream,
+				Meta: routes.Meta{
+					Group:       "Chat",
+					Description: "Resume in-progress chat stream (SSE)",
+					Permission:  model.PermissionProjectView,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "sessionId", Example: "abc123"},
+					},
+				},
+			})
+
+			// Chat cancel endpoint
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/chat/{sessionId}/cancel",
+				Handler: h.ChatCancel,
+				Meta: routes.Meta{
+					Group:       "Chat",
+					Description: "Cancel in-progress chat completion",
+					Permission:  model.PermissionSessionChat,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "sessionId", Example: "abc123"},
+					},
+					Response: service.CancelCompletionResponse{},
+				},
+			})
+
+			// Chat question endpoint - poll for pending AskUserQuestion
+			projReg.Register(r, routes.Route{
+				Method: "GET", Pattern: "/chat/{sessionId}/question",
+				Handler: h.ChatQuestion,
+				Meta: routes.Meta{
+					Group:       "Chat",
+					Description: "Get pending AskUserQuestion (null if none)",
+					Permission:  model.PermissionProjectView,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "sessionId", Example: "abc123"},
+					},
+					Response: sandboxapi.PendingQuestionResponse{},
+				},
+			})
+
+			// Chat answer endpoint - submit answer to pending AskUserQuestion
+			projReg.Register(r, routes.Route{
+				Method: "POST", Pattern: "/chat/{sessionId}/answer",
+				Handler: h.ChatAnswer,
+				Meta: routes.Meta{
+					Group:       "Chat",
+					Description: "Submit answers to a pending AskUserQuestion",
+					Permission:  model.PermissionSessionChat,
+					Params: []routes.Param{
+						{Name: "projectId", Example: "local"},
+						{Name: "sessionId", Example: "abc123"},
+					},
+					Request:  sandboxapi.AnswerQuestionRequest{},
+					Response: sandboxapi.AnswerQuestionResponse{},
+				},
+			})
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/handler"
+	"github.com/obot-platform/discobot/server/internal/routes"
+)
+
+// newTestRegistry registers every route against a fresh registry.
+func newTestRegistry(t *testing.T) *routes.Registry {
+	t.Helper()
+	reg := routes.NewRegistry()
+	registerRoutes(chi.NewRouter(), reg, &handler.Handler{}, nil, &config.Config{})
+	return reg
+}
+
+func TestRoutesHaveMetadata(t *testing.T) {
+	if err := newTestRegistry(t).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := newTestRegistry(t).OpenAPI(routes.OpenAPIInfo{Title: "Discobot API", Version: "test"})
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+
+	// Every $ref must resolve to a component schema.
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Failed to unmarshal document: %v", err)
+	}
+	walkRefs(raw, func(ref string) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("Unresolved reference %s", ref)
+		}
+	})
+
+	operationIDs := make(map[string]bool)
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			if operationIDs[op.OperationID] {
+				t.Errorf("%s %s: duplicate operation ID %q", method, path, op.OperationID)
+			}
+			operationIDs[op.OperationID] = true
+			if strings.Contains(path, "{projectId}") && op.Permission == "" {
+				t.Errorf("%s %s: missing x-permission", method, path)
+			}
+			if strings.HasPrefix(path, "/api/projects") && len(op.Security) == 0 {
+				t.Errorf("%s %s: missing security requirement", method, path)
+			}
+		}
+	}
+
+	create := doc.Paths["/api/projects/{projectId}/workspaces"]["post"]
+	if create == nil {
+		t.Fatal("Missing POST /api/projects/{projectId}/workspaces")
+	}
+	if create.OperationID != "CreateWorkspace" {
+		t.Errorf("OperationID = %q, want CreateWorkspace", create.OperationID)
+	}
+	if create.RequestBody == nil {
+		t.Error("Expected CreateWorkspace to have a request body")
+	}
+	resp := create.Responses["201"]
+	if resp == nil || resp.Content["application/json"].Schema.Ref != "#/components/schemas/Workspace" {
+		t.Errorf("Expected 201 response referencing Workspace, got %+v", resp)
+	}
+	if _, ok := doc.Components.Schemas["Workspace"].Properties["path"]; !ok {
+		t.Error("Expected Workspace schema to have a path property")
+	}
+}
+
+func walkRefs(v any, fn func(string)) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				fn(ref)
+				continue
+			}
+			walkRefs(value, fn)
+		}
+	case []any:
+		for _, value := range v {
+			walkRefs(value, fn)
+		}
+	}
+}
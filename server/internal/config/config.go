@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/adrg/xdg"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/version"
 )
 
@@ -36,6 +39,16 @@ type Config struct {
 	CORSDebug          bool // Enable CORS debug logging (default: false)
 	SuggestionsEnabled bool // Enable filesystem suggestions API (default: false)
 
+	// Metrics
+	MetricsEnabled bool   // Serve Prometheus metrics at /metrics (default: true)
+	MetricsToken   string // Bearer token required to read /metrics (default: none)
+
+	// Logging
+	LogFormat  string                // "text" (default) or "json"
+	LogLevel   slog.Level            // Default minimum level (default: info)
+	LogLevels  map[string]slog.Level // Per-component levels, e.g. "dispatcher=debug"
+	AdminToken string                // Bearer token required by /api/admin routes (default: none)
+
 	// Database
 	DatabaseDSN    string
 	DatabaseDriver string // "postgres" or "sqlite3", auto-detected from DSN
@@ -54,6 +67,14 @@ type Config struct {
 	SandboxImage       string        // Default sandbox image
 	SandboxIdleTimeout time.Duration // Auto-stop sandboxes after idle period
 	IdleCheckInterval  time.Duration // How often to check for idle sessions
+	IdleCPUThreshold   int           // CPU percent counted as activity by the idle monitor (0 disables)
+	IdleCPUDuration    time.Duration // How long CPU usage must stay above IdleCPUThreshold
+
+	// Workspace sync settings
+	WorkspaceSyncInterval time.Duration // How often to fetch workspaces and check if sessions are behind (0 disables)
+
+	// Audit log settings
+	AuditLogRetention time.Duration // Delete audit log entries older than this (0 keeps them forever)
 
 	// Docker-specific settings
 	DockerHost    string // Docker socket/host (default: unix:///var/run/docker.sock)
@@ -90,6 +111,7 @@ type Config struct {
 	DispatcherImmediateExecution bool          // Try to execute jobs immediately when enqueued (default: true)
 	JobRetryBackoff              time.Duration // Base backoff between job retries, multiplied by attempt number (default: 5s)
 	JobMaxAttempts               int           // Default max attempts for jobs (default: 3)
+	ScheduleCheckInterval        time.Duration // How often the leader checks for due schedules (default: 30s)
 
 	// OAuth providers (for user login)
 	GitHubClientID     string
@@ -97,6 +119,27 @@ type Config struct {
 	GoogleClientID     string
 	GoogleClientSecret string
 
+	// Generic OpenID Connect provider (e.g. Okta, Keycloak). Enabled when
+	// OIDCIssuerURL and OIDCClientID are set; logs in via /auth/login/oidc.
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCScopes        []string           // Requested scopes (default: openid, email, profile)
+	OIDCEmailClaim    string             // Claim holding the user's email (default: email)
+	OIDCNameClaim     string             // Claim holding the display name (default: name)
+	OIDCAvatarClaim   string             // Claim holding the avatar URL (default: picture)
+	OIDCGroupsClaim   string             // Claim holding group names (default: groups)
+	OIDCGroupMappings []OIDCGroupMapping // Groups that auto-join users to projects
+
+	// Email delivery (invitations). Emails are only logged when SMTPHost is empty.
+	PublicURL    string // Externally reachable base URL for links in emails (default: derived from the request)
+	SMTPHost     string
+	SMTPPort     int    // Default: 587
+	SMTPUsername string // Optional; enables PLAIN auth
+	SMTPPassword string
+	SMTPFrom     string // Sender address (default: discobot@<SMTPHost>)
+	SMTPTLS      string // "starttls" (default), "tls" (implicit TLS) or "none"
+
 	// AI Provider OAuth (client IDs are public for PKCE flows)
 	AnthropicClientID     string
 	GitHubCopilotClientID string
@@ -124,6 +167,23 @@ func Load() (*Config, error) {
 	cfg.CORSOrigins = getEnvList("CORS_ORIGINS", []string{"http://*.localhost:3001", "http://localhost:3000", "http://*.localhost:3000"})
 	cfg.CORSDebug = getEnvBool("CORS_DEBUG", false)
 	cfg.SuggestionsEnabled = getEnvBool("SUGGESTIONS_ENABLED", false)
+	cfg.MetricsEnabled = getEnvBool("METRICS_ENABLED", true)
+	cfg.MetricsToken = getEnv("METRICS_TOKEN", "")
+
+	// Logging
+	cfg.LogFormat = getEnv("LOG_FORMAT", "text")
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		return nil, fmt.Errorf("LOG_FORMAT must be text or json, got %q", cfg.LogFormat)
+	}
+	if err := cfg.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	logLevels, err := logging.ParseLevels(getEnv("LOG_LEVELS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVELS: %w", err)
+	}
+	cfg.LogLevels = logLevels
+	cfg.AdminToken = getEnv("ADMIN_TOKEN", "")
 
 	// Database - defaults to XDG_DATA_HOME/discobot/discobot.db
 	cfg.DatabaseDSN = getEnv("DATABASE_DSN", "sqlite3://"+filepath.Join(xdg.DataHome, appName, "discobot.db"))
@@ -168,6 +228,14 @@ func Load() (*Config, error) {
 	cfg.SandboxImage = getEnv("SANDBOX_IMAGE", DefaultSandboxImage())
 	cfg.SandboxIdleTimeout = getEnvDuration("SANDBOX_IDLE_TIMEOUT", 1*time.Hour)
 	cfg.IdleCheckInterval = getEnvDuration("IDLE_CHECK_INTERVAL", 5*time.Minute)
+	cfg.IdleCPUThreshold = getEnvInt("SANDBOX_IDLE_CPU_THRESHOLD", 0)
+	cfg.IdleCPUDuration = getEnvDuration("SANDBOX_IDLE_CPU_DURATION", 10*time.Minute)
+
+	// Workspace sync settings
+	cfg.WorkspaceSyncInterval = getEnvDuration("WORKSPACE_SYNC_INTERVAL", 5*time.Minute)
+
+	// Audit log settings
+	cfg.AuditLogRetention = getEnvDuration("AUDIT_LOG_RETENTION", 90*24*time.Hour)
 
 	// Docker-specific settings
 	// Empty default lets the Docker SDK auto-detect (works on Linux, macOS, and Windows)
@@ -206,6 +274,7 @@ func Load() (*Config, error) {
 	cfg.DispatcherJobTimeout = getEnvDuration("DISPATCHER_JOB_TIMEOUT", 20*time.Minute)
 	cfg.DispatcherStaleJobTimeout = getEnvDuration("DISPATCHER_STALE_JOB_TIMEOUT", 10*time.Minute)
 	cfg.DispatcherImmediateExecution = getEnvBool("DISPATCHER_IMMEDIATE_EXECUTION", true)
+	cfg.ScheduleCheckInterval = getEnvDuration("SCHEDULE_CHECK_INTERVAL", 30*time.Second)
 	cfg.JobRetryBackoff = getEnvDuration("JOB_RETRY_BACKOFF", 5*time.Second)
 	cfg.JobMaxAttempts = getEnvInt("JOB_MAX_ATTEMPTS", 3)
 
@@ -215,6 +284,36 @@ func Load() (*Config, error) {
 	cfg.GoogleClientID = getEnv("GOOGLE_CLIENT_ID", "")
 	cfg.GoogleClientSecret = getEnv("GOOGLE_CLIENT_SECRET", "")
 
+	// Generic OIDC provider for user login
+	cfg.OIDCIssuerURL = strings.TrimSuffix(getEnv("OIDC_ISSUER_URL", ""), "/")
+	cfg.OIDCClientID = getEnv("OIDC_CLIENT_ID", "")
+	cfg.OIDCClientSecret = getEnv("OIDC_CLIENT_SECRET", "")
+	cfg.OIDCScopes = getEnvList("OIDC_SCOPES", []string{"openid", "email", "profile"})
+	cfg.OIDCEmailClaim = getEnv("OIDC_EMAIL_CLAIM", "email")
+	cfg.OIDCNameClaim = getEnv("OIDC_NAME_CLAIM", "name")
+	cfg.OIDCAvatarClaim = getEnv("OIDC_AVATAR_CLAIM", "picture")
+	cfg.OIDCGroupsClaim = getEnv("OIDC_GROUPS_CLAIM", "groups")
+	mappings, err := ParseOIDCGroupMappings(getEnv("OIDC_GROUP_MAPPINGS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC_GROUP_MAPPINGS: %w", err)
+	}
+	cfg.OIDCGroupMappings = mappings
+
+	// Email delivery
+	cfg.PublicURL = strings.TrimRight(getEnv("PUBLIC_URL", ""), "/")
+	cfg.SMTPHost = getEnv("SMTP_HOST", "")
+	cfg.SMTPPort = getEnvInt("SMTP_PORT", 587)
+	cfg.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	cfg.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	cfg.SMTPFrom = getEnv("SMTP_FROM", "")
+	if cfg.SMTPFrom == "" && cfg.SMTPHost != "" {
+		cfg.SMTPFrom = "discobot@" + cfg.SMTPHost
+	}
+	cfg.SMTPTLS = getEnv("SMTP_TLS", "starttls")
+	if cfg.SMTPTLS != "starttls" && cfg.SMTPTLS != "tls" && cfg.SMTPTLS != "none" {
+		return nil, fmt.Errorf("SMTP_TLS must be starttls, tls or none, got %q", cfg.SMTPTLS)
+	}
+
 	// AI Provider OAuth client IDs (public, used in PKCE flows)
 	cfg.AnthropicClientID = getEnv("ANTHROPIC_CLIENT_ID", "9d1c250a-e61b-44d9-88ed-5944d1962f5e")
 	cfg.GitHubCopilotClientID = getEnv("GITHUB_COPILOT_CLIENT_ID", "Iv1.b507a08c87ecfe98")
@@ -238,6 +337,44 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// OIDCGroupMapping adds members of an OIDC group to a project with a role.
+type OIDCGroupMapping struct {
+	Group     string
+	ProjectID string
+	Role      string // Any project role except "owner"
+}
+
+// OIDCEnabled reports whether the generic OIDC login provider is configured.
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuerURL != "" && c.OIDCClientID != ""
+}
+
+// ParseOIDCGroupMappings parses a comma-separated list of
+// "group=projectID[:role]" entries. The role defaults to "member" and may be
+// any project role except "owner".
+func ParseOIDCGroupMappings(value string) ([]OIDCGroupMapping, error) {
+	var mappings []OIDCGroupMapping
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, target, ok := strings.Cut(entry, "=")
+		if !ok || group == "" || target == "" {
+			return nil, fmt.Errorf("expected group=projectID[:role], got %q", entry)
+		}
+		projectID, role, _ := strings.Cut(target, ":")
+		if role == "" {
+			role = model.RoleMember
+		}
+		if projectID == "" || !model.IsAssignableRole(role) {
+			return nil, fmt.Errorf("expected group=projectID[:admin|developer|member|viewer], got %q", entry)
+		}
+		mappings = append(mappings, OIDCGroupMapping{Group: group, ProjectID: projectID, Role: role})
+	}
+	return mappings, nil
+}
+
 // detectDriver determines the database driver from DSN
 func detectDriver(dsn string) string {
 	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
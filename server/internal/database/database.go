@@ -14,9 +14,13 @@ import (
 	"gorm.io/gorm/logger"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
 )
 
+// databaseLog is the logger for migration and seeding messages.
+var databaseLog = logging.Component("database")
+
 // DB wraps the GORM DB connection with additional context.
 // For SQLite, separate read and write pools are used to avoid contention:
 // the write pool has a single connection (SQLite only supports one writer),
@@ -57,6 +61,9 @@ func New(cfg *config.Config) (*DB, error) {
 		}
 		sqlDB.SetMaxOpenConns(25)
 		sqlDB.SetMaxIdleConns(5)
+		if err := useTracing(driver, db); err != nil {
+			return nil, err
+		}
 		return &DB{DB: db, Driver: driver}, nil
 
 	case "sqlite":
@@ -138,6 +145,9 @@ func newSQLite(dsn string, dbLogger logger.Interface) (*DB, error) {
 	// For in-memory databases, a second Open creates a separate database,
 	// so skip the read pool and reuse the write pool.
 	if isMemory {
+		if err := useTracing("sqlite", writeDB); err != nil {
+			return nil, err
+		}
 		return &DB{DB: writeDB, Driver: "sqlite"}, nil
 	}
 
@@ -156,12 +166,16 @@ func newSQLite(dsn string, dbLogger logger.Interface) (*DB, error) {
 	readSQLDB.SetMaxOpenConns(4)
 	readSQLDB.SetMaxIdleConns(4)
 
+	if err := useTracing("sqlite", writeDB, readDB); err != nil {
+		return nil, err
+	}
+
 	return &DB{DB: writeDB, ReadDB: readDB, Driver: "sqlite"}, nil
 }
 
 // Migrate runs database migrations using GORM's AutoMigrate
 func (db *DB) Migrate() error {
-	log.Println("Running GORM AutoMigrate...")
+	databaseLog.Info("running GORM AutoMigrate")
 
 	// First run AutoMigrate to add new columns/tables
 	if err := db.AutoMigrate(model.AllModels()...); err != nil {
@@ -188,7 +202,7 @@ func (db *DB) Migrate() error {
 			db.Exec("PRAGMA foreign_keys = OFF")
 		}
 		for _, col := range agentColsToDrop {
-			log.Printf("Dropping obsolete Agent.%s column...\n", col)
+			databaseLog.Info("dropping obsolete column", "table", "Agent", "column", col)
 			if err := migrator.DropColumn(&model.Agent{}, col); err != nil {
 				if db.IsSQLite() {
 					db.Exec("PRAGMA foreign_keys = ON")
@@ -214,7 +228,7 @@ func (db *DB) Migrate() error {
 			db.Exec("PRAGMA foreign_keys = OFF")
 		}
 		for _, col := range workspaceColsToDrop {
-			log.Printf("Dropping obsolete Workspace.%s column...\n", col)
+			databaseLog.Info("dropping obsolete column", "table", "Workspace", "column", col)
 			if err := migrator.DropColumn(&model.Workspace{}, col); err != nil {
 				if db.IsSQLite() {
 					db.Exec("PRAGMA foreign_keys = ON")
@@ -233,7 +247,7 @@ func (db *DB) Migrate() error {
 // Seed creates the anonymous user and default project for no-auth mode.
 // This is idempotent - it will not create duplicates if called multiple times.
 func (db *DB) Seed() error {
-	log.Println("Seeding database with anonymous user and default project...")
+	databaseLog.Info("seeding database with anonymous user and default project")
 
 	// Create anonymous user if not exists
 	anonUser := model.NewAnonymousUser()
@@ -242,7 +256,7 @@ func (db *DB) Seed() error {
 		return fmt.Errorf("failed to create anonymous user: %w", result.Error)
 	}
 	if result.RowsAffected > 0 {
-		log.Println("Created anonymous user")
+		databaseLog.Info("created anonymous user")
 	}
 
 	// Create default project if not exists
@@ -252,7 +266,7 @@ func (db *DB) Seed() error {
 		return fmt.Errorf("failed to create default project: %w", result.Error)
 	}
 	if result.RowsAffected > 0 {
-		log.Println("Created default project")
+		databaseLog.Info("created default project")
 	}
 
 	// Create project membership for anonymous user if not exists
@@ -266,10 +280,10 @@ func (db *DB) Seed() error {
 		return fmt.Errorf("failed to create project membership: %w", result.Error)
 	}
 	if result.RowsAffected > 0 {
-		log.Println("Added anonymous user to default project")
+		databaseLog.Info("added anonymous user to default project")
 	}
 
-	log.Println("Database seeding completed")
+	databaseLog.Info("database seeding completed")
 	return nil
 }
 
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/obot-platform/discobot/server/internal/tracing"
+)
+
+// spanKey is the instance key a statement's span is stored under.
+const spanKey = "discobot:span"
+
+// tracingPlugin starts a client span for every statement run with a traced
+// context, e.g. store.Store calls made with db.WithContext(ctx) while
+// serving a request.
+type tracingPlugin struct {
+	system string
+}
+
+func (p *tracingPlugin) Name() string {
+	return "discobot:tracing"
+}
+
+func (p *tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("discobot:trace_before_create", p.before("insert")),
+		cb.Create().After("gorm:create").Register("discobot:trace_after_create", p.after),
+		cb.Query().Before("gorm:query").Register("discobot:trace_before_query", p.before("select")),
+		cb.Query().After("gorm:query").Register("discobot:trace_after_query", p.after),
+		cb.Update().Before("gorm:update").Register("discobot:trace_before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("discobot:trace_after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("discobot:trace_before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("discobot:trace_after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("discobot:trace_before_row", p.before("select")),
+		cb.Row().After("gorm:row").Register("discobot:trace_after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("discobot:trace_before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("discobot:trace_after_raw", p.after),
+	)
+}
+
+func (p *tracingPlugin) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		// Only trace statements that are part of a trace; background
+		// pollers would otherwise start a root span per query.
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		name := op
+		if table := db.Statement.Table; table != "" {
+			name += " " + table
+		}
+		_, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", p.system),
+				attribute.String("db.operation.name", op),
+				attribute.String("db.collection.name", db.Statement.Table),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func (p *tracingPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	defer span.End()
+
+	// The statement holds placeholders only; bound values are not recorded.
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(attribute.String("db.query.text", sql))
+	}
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// useTracing registers the tracing plugin on each non-nil pool.
+func useTracing(system string, pools ...*gorm.DB) error {
+	for _, pool := range pools {
+		if pool == nil {
+			continue
+		}
+		if err := pool.Use(&tracingPlugin{system: system}); err != nil {
+			return fmt.Errorf("failed to register tracing plugin: %w", err)
+		}
+	}
+	return nil
+}
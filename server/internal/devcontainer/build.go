@@ -0,0 +1,271 @@
+package devcontainer
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+)
+
+// ImageRepository is the repository derived devcontainer images are tagged in.
+const ImageRepository = "discobot-devcontainer"
+
+const (
+	// baseStageName names the devcontainer stage when the user's Dockerfile
+	// leaves its final stage unnamed.
+	baseStageName = "devcontainer-base"
+
+	// featuresContextName is the named build context holding feature files.
+	featuresContextName = "discobot-features"
+)
+
+var (
+	fromLineRe   = regexp.MustCompile(`(?i)^\s*FROM\s+(.*)$`)
+	stageNameRe  = regexp.MustCompile(`(?i)\s+AS\s+(\S+)\s*$`)
+	envKeyRe     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	envSubstRe   = regexp.MustCompile(`\$\{(containerEnv|localEnv):([^}:]*)(?::[^}]*)?\}`)
+	mustQuoteEnv = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// Build is a derived sandbox image build.
+type Build struct {
+	// Tag identifies the build. It is derived from the build context and the
+	// sandbox image, so an unchanged config maps to an existing image.
+	Tag string
+	// Context is the sandbox.ImageBuildRequest context archive.
+	Context []byte
+	// Args are the build arguments from build.args.
+	Args map[string]string
+}
+
+// PrepareBuild resolves features and assembles the context for an image that
+// layers the discobot runtime (and the config's features and containerEnv)
+// on top of the devcontainer image.
+func (c *Config) PrepareBuild(ctx context.Context, fetcher *FeatureFetcher, sandboxImage string) (*Build, error) {
+	features, err := c.ResolveFeatures(ctx, fetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	base := "FROM " + c.Image + " AS " + baseStageName + "\n"
+	stage := baseStageName
+	var args map[string]string
+	if c.Image == "" {
+		dockerfilePath, err := c.resolvePath(c.Build.Dockerfile)
+		if err != nil {
+			return nil, fmt.Errorf("build.dockerfile: %w", err)
+		}
+		dockerfile, err := os.ReadFile(dockerfilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read build.dockerfile: %w", err)
+		}
+		base, stage = nameBaseStage(string(dockerfile), c.Build.Target)
+
+		contextDir, err := c.resolvePath(cmp.Or(c.Build.Context, "."))
+		if err != nil {
+			return nil, fmt.Errorf("build.context: %w", err)
+		}
+		if files, err = readDirFiles(contextDir, "context"); err != nil {
+			return nil, fmt.Errorf("failed to read build.context: %w", err)
+		}
+		args = c.Build.Args
+	}
+
+	derived, err := c.derivedStage(stage, features)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, File{Name: "Dockerfile", Mode: 0o644, Data: []byte(base + "\n" + derived)})
+	for i, feature := range features {
+		for _, f := range feature.Files {
+			f.Name = fmt.Sprintf("%s/%d/%s", featuresContextName, i, f.Name)
+			files = append(files, f)
+		}
+	}
+
+	archive, err := writeTar(files)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(archive)
+	h.Write([]byte{0})
+	h.Write([]byte(sandboxImage))
+	argNames := make([]string, 0, len(args))
+	for name := range args {
+		argNames = append(argNames, name)
+	}
+	sort.Strings(argNames)
+	for _, name := range argNames {
+		fmt.Fprintf(h, "\x00%s=%s", name, args[name])
+	}
+
+	return &Build{
+		Tag:     ImageRepository + ":" + hex.EncodeToString(h.Sum(nil))[:16],
+		Context: archive,
+		Args:    args,
+	}, nil
+}
+
+// nameBaseStage returns the Dockerfile with its base stage named, and that
+// name. The base stage is build.target if set, otherwise the last stage.
+func nameBaseStage(dockerfile, target string) (string, string) {
+	if target != "" {
+		return dockerfile, target
+	}
+
+	lines := strings.Split(dockerfile, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		m := fromLineRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		if name := stageNameRe.FindStringSubmatch(m[1]); name != nil {
+			return dockerfile, name[1]
+		}
+		lines[i] = strings.TrimRight(lines[i], " \t\r") + " AS " + baseStageName
+		return strings.Join(lines, "\n"), baseStageName
+	}
+	return dockerfile, baseStageName
+}
+
+// derivedStage returns the Dockerfile stage that turns the devcontainer image
+// into a discobot sandbox. The agent runs as PID 1 (no systemd), as the
+// discobot user, with features installed for remoteUser.
+func (c *Config) derivedStage(base string, features []Feature) (string, error) {
+	remoteUser := c.RemoteUser
+	if remoteUser == "" {
+		remoteUser = "discobot"
+	}
+	remoteHome := "/home/" + remoteUser
+	if remoteUser == "root" {
+		remoteHome = "/root"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "FROM %s\n", base)
+	b.WriteString("USER root\n")
+	b.WriteString(`LABEL io.discobot.sandbox-image="false" io.discobot.devcontainer="true"` + "\n")
+	fmt.Fprintf(&b, "COPY --from=%s / /\n", sandbox.RuntimeBuildContext)
+	b.WriteString("RUN if ! id discobot >/dev/null 2>&1; then \\\n" +
+		"      useradd -m -u 1000 -s /bin/sh discobot 2>/dev/null \\\n" +
+		"      || useradd -m -s /bin/sh discobot 2>/dev/null \\\n" +
+		"      || adduser -D -s /bin/sh discobot; \\\n" +
+		"    fi \\\n" +
+		"    && mkdir -p /.data /.workspace\n")
+
+	for i, feature := range features {
+		env, err := envInstruction(feature.ContainerEnv)
+		if err != nil {
+			return "", fmt.Errorf("feature %s: %w", feature.Ref, err)
+		}
+		b.WriteString(env)
+
+		dir := fmt.Sprintf("/tmp/discobot-features/%d", i)
+		fmt.Fprintf(&b, "COPY --from=%s /%d/ %s/\n", featuresContextName, i, dir)
+
+		vars := []string{
+			"_REMOTE_USER=" + shellQuote(remoteUser),
+			"_REMOTE_USER_HOME=" + shellQuote(remoteHome),
+			"_CONTAINER_USER=root",
+			"_CONTAINER_USER_HOME=/root",
+		}
+		names := make([]string, 0, len(feature.Options))
+		for name := range feature.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			vars = append(vars, name+"="+shellQuote(feature.Options[name]))
+		}
+		fmt.Fprintf(&b, "RUN cd %s && chmod +x install.sh && env %s ./install.sh && rm -rf %s\n",
+			dir, strings.Join(vars, " "), dir)
+	}
+
+	env, err := envInstruction(c.ContainerEnv)
+	if err != nil {
+		return "", fmt.Errorf("%w: containerEnv: %v", ErrInvalidConfig, err)
+	}
+	b.WriteString(env)
+
+	b.WriteString("WORKDIR /\n")
+	b.WriteString("ENTRYPOINT []\n")
+	b.WriteString(`CMD ["/opt/discobot/bin/discobot-agent"]` + "\n")
+	return b.String(), nil
+}
+
+// envInstruction renders an ENV instruction. ${containerEnv:VAR} references
+// become Dockerfile ${VAR} references; ${localEnv:VAR} refers to the machine
+// running the editor and has no meaning here, so it expands to its default.
+func envInstruction(env map[string]string) (string, error) {
+	if len(env) == 0 {
+		return "", nil
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		if !envKeyRe.MatchString(key) {
+			return "", fmt.Errorf("invalid variable name %q", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("ENV")
+	for _, key := range keys {
+		value := envSubstRe.ReplaceAllStringFunc(env[key], func(ref string) string {
+			m := envSubstRe.FindStringSubmatch(ref)
+			if m[1] == "containerEnv" {
+				return "${" + m[2] + "}"
+			}
+			if _, def, ok := strings.Cut(strings.TrimSuffix(ref, "}"), m[2]+":"); ok {
+				return def
+			}
+			return ""
+		})
+		fmt.Fprintf(&b, " %s=\"%s\"", key, mustQuoteEnv.Replace(value))
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// writeTar writes files into a deterministic tar archive: entries are sorted
+// and carry no timestamps or ownership.
+func writeTar(files []File) ([]byte, error) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.Name, Mode: f.Mode, Format: tar.FormatPAX}
+		if f.Linkname != "" {
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = f.Linkname
+		} else {
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = int64(len(f.Data))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", strconv.Quote(f.Name), err)
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,280 @@
+package devcontainer
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDevcontainer writes .devcontainer/devcontainer.json plus extra files
+// (relative to .devcontainer) and loads it.
+func writeDevcontainer(t *testing.T, config string, files map[string]string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	dcDir := filepath.Join(dir, ".devcontainer")
+	files["devcontainer.json"] = config
+	for name, content := range files {
+		path := filepath.Join(dcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return cfg
+}
+
+// readTar returns the regular files of a tar archive by name.
+func readTar(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(tr)
+		files[hdr.Name] = buf.String()
+	}
+	return files
+}
+
+func TestPrepareBuildImage(t *testing.T) {
+	cfg := writeDevcontainer(t, `{
+		"image": "mcr.microsoft.com/devcontainers/base:ubuntu",
+		"remoteUser": "vscode",
+		"containerEnv": {"PATH": "/opt/tools:${containerEnv:PATH}", "TOKEN": "${localEnv:TOKEN:none}"},
+		"features": {"./local-feature": {"greeting": "hi there"}}
+	}`, map[string]string{
+		"local-feature/install.sh":                "#!/bin/sh\necho $GREETING\n",
+		"local-feature/devcontainer-feature.json": `{"id": "local-feature", "options": {"greeting": {"default": "hello"}, "loud": {"default": false}}}`,
+	})
+
+	build, err := cfg.PrepareBuild(context.Background(), nil, "ghcr.io/obot-platform/discobot:v1")
+	if err != nil {
+		t.Fatalf("PrepareBuild failed: %v", err)
+	}
+	if !strings.HasPrefix(build.Tag, ImageRepository+":") {
+		t.Errorf("Tag = %q", build.Tag)
+	}
+
+	files := readTar(t, build.Context)
+	dockerfile := files["Dockerfile"]
+	for _, want := range []string{
+		"FROM mcr.microsoft.com/devcontainers/base:ubuntu AS devcontainer-base\n",
+		"FROM devcontainer-base\n",
+		"COPY --from=discobot-runtime / /\n",
+		"COPY --from=discobot-features /0/ /tmp/discobot-features/0/\n",
+		"_REMOTE_USER=vscode _REMOTE_USER_HOME=/home/vscode",
+		"GREETING='hi there' LOUD=false ./install.sh",
+		`ENV PATH="/opt/tools:${PATH}" TOKEN="none"`,
+		`CMD ["/opt/discobot/bin/discobot-agent"]`,
+	} {
+		if !strings.Contains(dockerfile, want) {
+			t.Errorf("Dockerfile missing %q:\n%s", want, dockerfile)
+		}
+	}
+	if files["discobot-features/0/install.sh"] == "" {
+		t.Errorf("feature files missing from context: %v", files)
+	}
+
+	again, err := cfg.PrepareBuild(context.Background(), nil, "ghcr.io/obot-platform/discobot:v1")
+	if err != nil {
+		t.Fatalf("PrepareBuild failed: %v", err)
+	}
+	if again.Tag != build.Tag {
+		t.Errorf("Tag not deterministic: %q != %q", again.Tag, build.Tag)
+	}
+	other, err := cfg.PrepareBuild(context.Background(), nil, "ghcr.io/obot-platform/discobot:v2")
+	if err != nil {
+		t.Fatalf("PrepareBuild failed: %v", err)
+	}
+	if other.Tag == build.Tag {
+		t.Error("Tag should change with the sandbox image")
+	}
+}
+
+func TestPrepareBuildDockerfile(t *testing.T) {
+	cfg := writeDevcontainer(t, `{
+		"build": {"dockerfile": "Dockerfile", "context": ".", "args": {"VARIANT": "3.12"}}
+	}`, map[string]string{
+		"Dockerfile": "ARG VARIANT\nFROM golang:1 AS tools\nFROM python:${VARIANT}\nCOPY --from=tools /usr/local/go /usr/local/go\n",
+	})
+
+	build, err := cfg.PrepareBuild(context.Background(), nil, "discobot:test")
+	if err != nil {
+		t.Fatalf("PrepareBuild failed: %v", err)
+	}
+	if build.Args["VARIANT"] != "3.12" {
+		t.Errorf("Args = %v", build.Args)
+	}
+
+	files := readTar(t, build.Context)
+	dockerfile := files["Dockerfile"]
+	if !strings.Contains(dockerfile, "FROM python:${VARIANT} AS devcontainer-base\n") {
+		t.Errorf("last stage not named:\n%s", dockerfile)
+	}
+	if !strings.Contains(dockerfile, "FROM golang:1 AS tools\n") {
+		t.Errorf("earlier stages changed:\n%s", dockerfile)
+	}
+	if _, ok := files["context/Dockerfile"]; !ok {
+		t.Errorf("build context missing from archive: %v", files)
+	}
+
+	base, stage := nameBaseStage("FROM a AS first\nFROM b AS final\n", "")
+	if stage != "final" || base != "FROM a AS first\nFROM b AS final\n" {
+		t.Errorf("nameBaseStage = %q, %q", base, stage)
+	}
+	if _, stage := nameBaseStage("FROM a AS first\nFROM b\n", "first"); stage != "first" {
+		t.Errorf("target not used as base stage: %q", stage)
+	}
+}
+
+func TestPrepareBuildRejectsPathsOutsideWorkspace(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"context", `{"build": {"dockerfile": "Dockerfile", "context": "../../../.."}}`},
+		{"dockerfile", `{"build": {"dockerfile": "../../../../etc/passwd"}}`},
+		{"absolute dockerfile", `{"build": {"dockerfile": "/etc/passwd"}}`},
+		{"symlinked context", `{"build": {"dockerfile": "Dockerfile", "context": "escape"}}`},
+		{"local feature", `{"image": "debian", "features": {"../../feature": {}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := writeDevcontainer(t, tt.config, map[string]string{"Dockerfile": "FROM debian\n"})
+			if err := os.Symlink(outside, filepath.Join(cfg.dir, "escape")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cfg.PrepareBuild(context.Background(), nil, "discobot:test"); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+
+	// The workspace root itself is a valid context
+	cfg := writeDevcontainer(t, `{"build": {"dockerfile": "Dockerfile", "context": ".."}}`,
+		map[string]string{"Dockerfile": "FROM debian\n"})
+	build, err := cfg.PrepareBuild(context.Background(), nil, "discobot:test")
+	if err != nil {
+		t.Fatalf("PrepareBuild failed: %v", err)
+	}
+	if _, ok := readTar(t, build.Context)["context/.devcontainer/Dockerfile"]; !ok {
+		t.Error("workspace root context missing from archive")
+	}
+}
+
+func TestResolveOCIFeature(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"./install.sh":                "#!/bin/sh\n",
+		"./devcontainer-feature.json": `{"id": "node", "options": {"version": {"default": "lts"}}, "containerEnv": {"NVM_DIR": "/usr/local/nvm"}}`,
+	} {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	sum := sha256.Sum256(archive.Bytes())
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:features/node:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "anon"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anon" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:features/node:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/features/node/manifests/1":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"layers": []map[string]string{{"mediaType": featureLayerMediaType, "digest": digest}},
+			})
+		case "/v2/features/node/blobs/" + digest:
+			_, _ = w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ref := strings.TrimPrefix(srv.URL, "https://") + "/features/node:1"
+	cfg, err := Parse([]byte(fmt.Sprintf(`{"image": "ubuntu", "features": {%q: "20"}}`, ref)))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	features, err := cfg.ResolveFeatures(context.Background(), &FeatureFetcher{Client: srv.Client()})
+	if err != nil {
+		t.Fatalf("ResolveFeatures failed: %v", err)
+	}
+	if len(features) != 1 {
+		t.Fatalf("got %d features, want 1", len(features))
+	}
+	f := features[0]
+	if f.Options["VERSION"] != "20" {
+		t.Errorf("VERSION = %q, want the string shorthand to set it", f.Options["VERSION"])
+	}
+	if f.ContainerEnv["NVM_DIR"] != "/usr/local/nvm" {
+		t.Errorf("ContainerEnv = %v", f.ContainerEnv)
+	}
+	if len(f.Files) != 2 {
+		t.Errorf("Files = %d, want 2", len(f.Files))
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want ociReference
+	}{
+		{"ghcr.io/devcontainers/features/node:1", ociReference{"ghcr.io", "devcontainers/features/node", "1"}},
+		{"ghcr.io/devcontainers/features/go", ociReference{"ghcr.io", "devcontainers/features/go", "latest"}},
+		{"localhost:5000/f/x@sha256:abc", ociReference{"localhost:5000", "f/x", "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := parseOCIReference(tt.ref)
+		if err != nil {
+			t.Errorf("parseOCIReference(%q) failed: %v", tt.ref, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parseOCIReference(%q) = %+v, want %+v", tt.ref, *got, tt.want)
+		}
+	}
+	if _, err := parseOCIReference("node:1"); err == nil {
+		t.Error("expected an error for a reference without a registry")
+	}
+}
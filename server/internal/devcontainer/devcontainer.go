@@ -0,0 +1,370 @@
+// Package devcontainer reads a workspace's devcontainer.json and turns it into
+// the pieces discobot needs to provision a session from it: a derived sandbox
+// image (the devcontainer image plus the discobot runtime and any features),
+// and a Spec of lifecycle commands and forwarded ports for the in-sandbox agent.
+//
+// Only the subset of the Dev Container specification that maps onto a
+// discobot session is supported: image, build, features, containerEnv,
+// remoteUser, forwardPorts, portsAttributes, postCreateCommand and
+// postStartCommand. Other properties are ignored.
+package devcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvVar is the sandbox environment variable that carries the encoded Spec.
+const EnvVar = "DISCOBOT_DEVCONTAINER"
+
+// ErrInvalidConfig is returned when devcontainer.json cannot be used.
+var ErrInvalidConfig = errors.New("invalid devcontainer.json")
+
+// configPaths are the locations searched for devcontainer.json, in order,
+// relative to the workspace root.
+var configPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// Config is the parsed subset of devcontainer.json.
+type Config struct {
+	Image             string                     `json:"image"`
+	Build             *BuildConfig               `json:"build"`
+	DockerFile        string                     `json:"dockerFile"` // legacy top-level form of build.dockerfile
+	Context           string                     `json:"context"`    // legacy top-level form of build.context
+	Features          map[string]json.RawMessage `json:"features"`
+	ContainerEnv      map[string]string          `json:"containerEnv"`
+	RemoteUser        string                     `json:"remoteUser"`
+	ForwardPorts      []json.RawMessage          `json:"forwardPorts"`
+	PortsAttributes   map[string]PortAttributes  `json:"portsAttributes"`
+	PostCreateCommand json.RawMessage            `json:"postCreateCommand"`
+	PostStartCommand  json.RawMessage            `json:"postStartCommand"`
+
+	// dir is the directory containing devcontainer.json. Relative paths
+	// (build.dockerfile, build.context, local features) resolve against it.
+	dir string
+	// root is the workspace directory; resolved paths must stay inside it.
+	root string
+}
+
+// BuildConfig is the "build" property of devcontainer.json.
+type BuildConfig struct {
+	Dockerfile string            `json:"dockerfile"`
+	Context    string            `json:"context"`
+	Args       map[string]string `json:"args"`
+	Target     string            `json:"target"`
+}
+
+// PortAttributes is an entry of "portsAttributes".
+type PortAttributes struct {
+	Label    string `json:"label"`
+	Protocol string `json:"protocol"` // "http" or "https"
+}
+
+// Spec is the part of a devcontainer config the in-sandbox agent acts on.
+// It is passed to the sandbox as base64-encoded JSON in EnvVar.
+type Spec struct {
+	RemoteUser   string    `json:"remoteUser,omitempty"`
+	PostCreate   []Command `json:"postCreate,omitempty"`
+	PostStart    []Command `json:"postStart,omitempty"`
+	ForwardPorts []Port    `json:"forwardPorts,omitempty"`
+}
+
+// Command is one normalized lifecycle command, run with /bin/sh.
+type Command struct {
+	// Name is empty for the string and array forms, and the key for the
+	// object (parallel) form.
+	Name   string `json:"name,omitempty"`
+	Script string `json:"script"`
+}
+
+// Port is a forwarded port exposed as a passive session service.
+type Port struct {
+	Port     int    `json:"port"`
+	Label    string `json:"label,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// Load finds and parses devcontainer.json in the workspace directory.
+// It returns nil, nil if the workspace has no devcontainer.json.
+func Load(workspaceDir string) (*Config, error) {
+	for _, rel := range configPaths {
+		path := filepath.Join(workspaceDir, rel)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		cfg, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		cfg.dir = filepath.Dir(path)
+		cfg.root = workspaceDir
+		return cfg, nil
+	}
+	return nil, nil
+}
+
+// resolvePath resolves a path from devcontainer.json against the config's
+// directory. The workspace comes from a possibly untrusted repository, so
+// absolute paths and paths leaving the workspace, including through
+// symlinks, are rejected.
+func (c *Config) resolvePath(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("%w: path %q must be relative", ErrInvalidConfig, name)
+	}
+	root := c.root
+	if root == "" {
+		root = c.dir
+	}
+	path := filepath.Join(c.dir, filepath.FromSlash(name))
+	if !within(root, path) {
+		return "", fmt.Errorf("%w: path %q is outside the workspace", ErrInvalidConfig, name)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if !within(realRoot, realPath) {
+		return "", fmt.Errorf("%w: path %q is outside the workspace", ErrInvalidConfig, name)
+	}
+	return path, nil
+}
+
+// within reports whether path is root or inside it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
+
+// Parse parses devcontainer.json content. Comments and trailing commas
+// (JSONC, as accepted by VS Code) are allowed.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(standardizeJSONC(data), &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if cfg.Build == nil && cfg.DockerFile != "" {
+		cfg.Build = &BuildConfig{Dockerfile: cfg.DockerFile, Context: cfg.Context}
+	}
+	if cfg.Image == "" && (cfg.Build == nil || cfg.Build.Dockerfile == "") {
+		return nil, fmt.Errorf("%w: one of image or build.dockerfile is required", ErrInvalidConfig)
+	}
+	return &cfg, nil
+}
+
+// Spec returns the lifecycle commands and forwarded ports of the config.
+func (c *Config) Spec() (*Spec, error) {
+	postCreate, err := parseLifecycleCommand(c.PostCreateCommand)
+	if err != nil {
+		return nil, fmt.Errorf("%w: postCreateCommand: %v", ErrInvalidConfig, err)
+	}
+	postStart, err := parseLifecycleCommand(c.PostStartCommand)
+	if err != nil {
+		return nil, fmt.Errorf("%w: postStartCommand: %v", ErrInvalidConfig, err)
+	}
+	ports, err := c.forwardPorts()
+	if err != nil {
+		return nil, err
+	}
+	return &Spec{
+		RemoteUser:   c.RemoteUser,
+		PostCreate:   postCreate,
+		PostStart:    postStart,
+		ForwardPorts: ports,
+	}, nil
+}
+
+// parseLifecycleCommand normalizes the string, array and object forms of a
+// lifecycle command into shell scripts. The array form is an argv that is
+// executed without a shell, so each element is quoted.
+func parseLifecycleCommand(raw json.RawMessage) ([]Command, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	script, err := commandScript(raw)
+	if err == nil {
+		if script == "" {
+			return nil, nil
+		}
+		return []Command{{Script: script}}, nil
+	}
+
+	var named map[string]json.RawMessage
+	if json.Unmarshal(raw, &named) != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var commands []Command
+	for _, name := range names {
+		script, err := commandScript(named[name])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", name, err)
+		}
+		if script != "" {
+			commands = append(commands, Command{Name: name, Script: script})
+		}
+	}
+	return commands, nil
+}
+
+// commandScript converts a string or argv array into a shell script.
+func commandScript(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s), nil
+	}
+	var argv []string
+	if err := json.Unmarshal(raw, &argv); err != nil {
+		return "", errors.New("must be a string, an array of strings or an object")
+	}
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+// forwardPorts resolves forwardPorts against portsAttributes. Entries of the
+// "host:port" form are only kept when the host is the container itself.
+func (c *Config) forwardPorts() ([]Port, error) {
+	var ports []Port
+	seen := make(map[int]bool)
+	for _, raw := range c.ForwardPorts {
+		port, ok, err := parsePort(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: forwardPorts: %v", ErrInvalidConfig, err)
+		}
+		if !ok || seen[port] {
+			continue
+		}
+		seen[port] = true
+
+		attrs := c.PortsAttributes[strconv.Itoa(port)]
+		protocol := strings.ToLower(attrs.Protocol)
+		if protocol != "https" {
+			protocol = "http"
+		}
+		ports = append(ports, Port{Port: port, Label: attrs.Label, Protocol: protocol})
+	}
+	return ports, nil
+}
+
+// parsePort parses one forwardPorts entry. ok is false for ports on other
+// hosts (e.g. "db:5432" in a compose setup), which discobot cannot reach.
+func parsePort(raw json.RawMessage) (port int, ok bool, err error) {
+	var n int
+	if json.Unmarshal(raw, &n) == nil {
+		if n < 1 || n > 65535 {
+			return 0, false, fmt.Errorf("port %d out of range", n)
+		}
+		return n, true, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, false, fmt.Errorf("invalid entry %s", raw)
+	}
+	host, portStr := "", s
+	if h, p, splitErr := net.SplitHostPort(s); splitErr == nil {
+		host, portStr = h, p
+	}
+	n, err = strconv.Atoi(portStr)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, false, fmt.Errorf("invalid port %q", s)
+	}
+	switch host {
+	case "", "localhost", "127.0.0.1", "0.0.0.0":
+		return n, true, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+// shellQuote quotes s for /bin/sh unless it consists only of safe characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,+@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// standardizeJSONC strips comments and trailing commas so the result can be
+// decoded with encoding/json. String contents are left untouched.
+func standardizeJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && (data[i] != '*' || data[i+1] != '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Drop a trailing comma before the closing bracket.
+			j := len(out) - 1
+			for j >= 0 && isJSONSpace(out[j]) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,149 @@
+package devcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONC(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		// The image to use
+		"image": "mcr.microsoft.com/devcontainers/go:1", /* inline */
+		"containerEnv": {
+			"URL": "http://example.com/*not-a-comment*/", // trailing
+			"ESCAPED": "a \"quoted\" // value",
+		},
+		"forwardPorts": [3000, 8080,],
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.Image != "mcr.microsoft.com/devcontainers/go:1" {
+		t.Errorf("Image = %q", cfg.Image)
+	}
+	if got := cfg.ContainerEnv["URL"]; got != "http://example.com/*not-a-comment*/" {
+		t.Errorf("URL = %q", got)
+	}
+	if got := cfg.ContainerEnv["ESCAPED"]; got != `a "quoted" // value` {
+		t.Errorf("ESCAPED = %q", got)
+	}
+	if len(cfg.ForwardPorts) != 2 {
+		t.Errorf("ForwardPorts = %d entries, want 2", len(cfg.ForwardPorts))
+	}
+}
+
+func TestParseRequiresImageOrDockerfile(t *testing.T) {
+	if _, err := Parse([]byte(`{"remoteUser": "vscode"}`)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+	cfg, err := Parse([]byte(`{"dockerFile": "Dockerfile", "context": ".."}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.Build == nil || cfg.Build.Dockerfile != "Dockerfile" || cfg.Build.Context != ".." {
+		t.Errorf("legacy dockerFile not mapped to build: %+v", cfg.Build)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil || cfg != nil {
+		t.Fatalf("Load without devcontainer.json = %v, %v; want nil, nil", cfg, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".devcontainer.json"), []byte(`{"image": "root"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".devcontainer", "devcontainer.json"), []byte(`{"image": "nested"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Image != "nested" {
+		t.Errorf("Image = %q, want .devcontainer/devcontainer.json to take precedence", cfg.Image)
+	}
+	if cfg.dir != filepath.Join(dir, ".devcontainer") {
+		t.Errorf("dir = %q", cfg.dir)
+	}
+}
+
+func TestSpecLifecycleCommands(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"image": "ubuntu",
+		"remoteUser": "root",
+		"postCreateCommand": ["npm", "install", "--prefix", "my dir"],
+		"postStartCommand": {
+			"watch": "npm run watch",
+			"db": ["pg_ctl", "start"],
+			"empty": ""
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	spec, err := cfg.Spec()
+	if err != nil {
+		t.Fatalf("Spec failed: %v", err)
+	}
+
+	if spec.RemoteUser != "root" {
+		t.Errorf("RemoteUser = %q", spec.RemoteUser)
+	}
+	wantCreate := []Command{{Script: "npm install --prefix 'my dir'"}}
+	if !reflect.DeepEqual(spec.PostCreate, wantCreate) {
+		t.Errorf("PostCreate = %+v, want %+v", spec.PostCreate, wantCreate)
+	}
+	wantStart := []Command{
+		{Name: "db", Script: "pg_ctl start"},
+		{Name: "watch", Script: "npm run watch"},
+	}
+	if !reflect.DeepEqual(spec.PostStart, wantStart) {
+		t.Errorf("PostStart = %+v, want %+v", spec.PostStart, wantStart)
+	}
+
+	cfg.PostCreateCommand = []byte(`42`)
+	if _, err := cfg.Spec(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for a numeric command, got %v", err)
+	}
+}
+
+func TestSpecForwardPorts(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"image": "ubuntu",
+		"forwardPorts": [3000, "localhost:8443", "db:5432", 3000],
+		"portsAttributes": {
+			"3000": {"label": "Web"},
+			"8443": {"label": "API", "protocol": "https"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	spec, err := cfg.Spec()
+	if err != nil {
+		t.Fatalf("Spec failed: %v", err)
+	}
+	want := []Port{
+		{Port: 3000, Label: "Web", Protocol: "http"},
+		{Port: 8443, Label: "API", Protocol: "https"},
+	}
+	if !reflect.DeepEqual(spec.ForwardPorts, want) {
+		t.Errorf("ForwardPorts = %+v, want %+v", spec.ForwardPorts, want)
+	}
+
+	cfg.ForwardPorts = cfg.ForwardPorts[:0]
+	cfg.ForwardPorts = append(cfg.ForwardPorts, []byte(`70000`))
+	if _, err := cfg.Spec(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for an out-of-range port, got %v", err)
+	}
+}
@@ -0,0 +1,447 @@
+package devcontainer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxFeatureSize bounds the size of a downloaded feature archive.
+const maxFeatureSize = 64 << 20
+
+// featureLayerMediaType is the media type of the layer holding a feature's files.
+const featureLayerMediaType = "application/vnd.devcontainers.layer.v1+tar"
+
+// Feature is a resolved Dev Container Feature ready to be installed.
+type Feature struct {
+	// Ref is the feature reference as written in devcontainer.json.
+	Ref string
+	// Options are the install.sh environment variables, with defaults from
+	// devcontainer-feature.json applied.
+	Options map[string]string
+	// ContainerEnv is the containerEnv declared by the feature.
+	ContainerEnv map[string]string
+	// Files are the feature's files (install.sh, devcontainer-feature.json, ...).
+	Files []File
+}
+
+// File is a file in a feature or build context archive.
+type File struct {
+	Name string
+	Mode int64
+	Data []byte
+	// Linkname is set for symbolic links, which have no Data.
+	Linkname string
+}
+
+// featureMetadata is the subset of devcontainer-feature.json discobot uses.
+type featureMetadata struct {
+	ID      string `json:"id"`
+	Options map[string]struct {
+		Default any `json:"default"`
+	} `json:"options"`
+	ContainerEnv map[string]string `json:"containerEnv"`
+}
+
+// FeatureFetcher downloads features from OCI registries and tarball URLs.
+type FeatureFetcher struct {
+	// Client is the HTTP client used for downloads. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// ResolveFeatures fetches every feature referenced by the config. Features are
+// returned in a stable order (sorted by reference); installsAfter ordering is
+// not applied.
+func (c *Config) ResolveFeatures(ctx context.Context, fetcher *FeatureFetcher) ([]Feature, error) {
+	refs := make([]string, 0, len(c.Features))
+	for ref := range c.Features {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	features := make([]Feature, 0, len(refs))
+	for _, ref := range refs {
+		files, err := c.featureFiles(ctx, fetcher, ref)
+		if err != nil {
+			return nil, fmt.Errorf("feature %s: %w", ref, err)
+		}
+		feature, err := newFeature(ref, c.Features[ref], files)
+		if err != nil {
+			return nil, fmt.Errorf("feature %s: %w", ref, err)
+		}
+		features = append(features, *feature)
+	}
+	return features, nil
+}
+
+// featureFiles returns the files of a local, tarball or OCI feature.
+func (c *Config) featureFiles(ctx context.Context, fetcher *FeatureFetcher, ref string) ([]File, error) {
+	switch {
+	case strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../"):
+		dir, err := c.resolvePath(ref)
+		if err != nil {
+			return nil, err
+		}
+		return readDirFiles(dir, "")
+	case strings.HasPrefix(ref, "https://"):
+		data, err := fetcher.get(ctx, ref, "", maxFeatureSize)
+		if err != nil {
+			return nil, err
+		}
+		return untar(data)
+	default:
+		return fetcher.fetchOCI(ctx, ref)
+	}
+}
+
+// newFeature combines the user's options with the feature's metadata.
+func newFeature(ref string, rawOptions json.RawMessage, files []File) (*Feature, error) {
+	var meta featureMetadata
+	hasInstall := false
+	for _, f := range files {
+		switch f.Name {
+		case "devcontainer-feature.json":
+			if err := json.Unmarshal(standardizeJSONC(f.Data), &meta); err != nil {
+				return nil, fmt.Errorf("invalid devcontainer-feature.json: %w", err)
+			}
+		case "install.sh":
+			hasInstall = true
+		}
+	}
+	if !hasInstall {
+		return nil, errors.New("install.sh not found")
+	}
+
+	options := make(map[string]string)
+	for name, opt := range meta.Options {
+		if opt.Default != nil {
+			options[optionEnvName(name)] = fmt.Sprint(opt.Default)
+		}
+	}
+
+	// Options are an object, or a bare string as shorthand for "version".
+	var userOptions map[string]any
+	var version string
+	switch {
+	case json.Unmarshal(rawOptions, &userOptions) == nil:
+		for name, value := range userOptions {
+			options[optionEnvName(name)] = fmt.Sprint(value)
+		}
+	case json.Unmarshal(rawOptions, &version) == nil:
+		options["VERSION"] = version
+	}
+
+	return &Feature{
+		Ref:          ref,
+		Options:      options,
+		ContainerEnv: meta.ContainerEnv,
+		Files:        files,
+	}, nil
+}
+
+// optionEnvName converts a feature option name into the environment variable
+// name install.sh receives, following the Dev Container Features spec.
+func optionEnvName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	s := b.String()
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	return s
+}
+
+// ociReference is a parsed feature reference like ghcr.io/devcontainers/features/node:1.
+type ociReference struct {
+	Registry   string
+	Repository string
+	Reference  string // tag or digest
+}
+
+func parseOCIReference(ref string) (*ociReference, error) {
+	registry, rest, ok := strings.Cut(ref, "/")
+	if !ok || !(strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		return nil, fmt.Errorf("%q is not a registry reference", ref)
+	}
+
+	repo, reference := rest, "latest"
+	if name, digest, ok := strings.Cut(rest, "@"); ok {
+		repo, reference = name, digest
+	} else if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		repo, reference = rest[:i], rest[i+1:]
+	}
+	if repo == "" || reference == "" {
+		return nil, fmt.Errorf("invalid reference %q", ref)
+	}
+	return &ociReference{Registry: registry, Repository: repo, Reference: reference}, nil
+}
+
+// fetchOCI downloads a feature published as an OCI artifact.
+func (f *FeatureFetcher) fetchOCI(ctx context.Context, ref string) ([]File, error) {
+	oci, err := parseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	base := "https://" + oci.Registry + "/v2/" + oci.Repository
+
+	data, err := f.get(ctx, base+"/manifests/"+oci.Reference, "application/vnd.oci.image.manifest.v1+json", 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	var manifest struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != featureLayerMediaType {
+			continue
+		}
+		blob, err := f.get(ctx, base+"/blobs/"+layer.Digest, "", maxFeatureSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch layer: %w", err)
+		}
+		sum := sha256.Sum256(blob)
+		if layer.Digest != "sha256:"+hex.EncodeToString(sum[:]) {
+			return nil, fmt.Errorf("layer digest mismatch for %s", layer.Digest)
+		}
+		return untar(blob)
+	}
+	return nil, errors.New("manifest has no feature layer")
+}
+
+// get performs a GET, answering a registry's anonymous Bearer challenge if one
+// is returned.
+func (f *FeatureFetcher) get(ctx context.Context, rawURL, accept string, limit int64) ([]byte, error) {
+	client := http.DefaultClient
+	if f != nil && f.Client != nil {
+		client = f.Client
+	}
+
+	do := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return client.Do(req)
+	}
+
+	resp, err := do("")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		token, err := f.anonymousToken(ctx, client, challenge)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = do(token); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", rawURL, limit)
+	}
+	return data, nil
+}
+
+// anonymousToken requests a pull token for a `Bearer realm=...` challenge.
+func (f *FeatureFetcher) anonymousToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported registry authentication %q", scheme)
+	}
+	attrs := parseChallengeParams(params)
+	if attrs["realm"] == "" {
+		return "", errors.New("registry challenge has no realm")
+	}
+
+	u, err := url.Parse(attrs["realm"])
+	if err != nil {
+		return "", fmt.Errorf("invalid realm: %w", err)
+	}
+	q := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if attrs[key] != "" {
+			q.Set(key, attrs[key])
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request failed: %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid registry token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallengeParams parses `key="value",key2="value2"`.
+func parseChallengeParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(s, " ,"), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, s = rest[1:end+1], rest[end+2:]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return params
+}
+
+// untar reads a plain or gzip-compressed tar archive into memory.
+func untar(data []byte) ([]File, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var files []File
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid feature archive: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			body, err := io.ReadAll(io.LimitReader(tr, maxFeatureSize))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, File{Name: name, Mode: hdr.Mode & 0o777, Data: body})
+		case tar.TypeSymlink:
+			files = append(files, File{Name: name, Mode: 0o777, Linkname: hdr.Linkname})
+		}
+	}
+	return files, nil
+}
+
+// readDirFiles reads the regular files and symlinks below dir, with names
+// relative to dir and prefixed by prefix. .git and node_modules directories
+// are skipped.
+func readDirFiles(dir, prefix string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(prefix, filepath.ToSlash(rel))
+		if d.IsDir() {
+			if p != dir && (d.Name() == ".git" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			files = append(files, File{Name: name, Mode: 0o777, Linkname: target})
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			files = append(files, File{Name: name, Mode: int64(info.Mode().Perm()), Data: data})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,50 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// BatchRunItemExecutor handles batch_run_item jobs.
+type BatchRunItemExecutor struct {
+	batchRunService *service.BatchRunService
+}
+
+// NewBatchRunItemExecutor creates a new batch run item executor.
+func NewBatchRunItemExecutor(batchRunSvc *service.BatchRunService) *BatchRunItemExecutor {
+	return &BatchRunItemExecutor{batchRunService: batchRunSvc}
+}
+
+// Type returns the job type this executor handles.
+func (e *BatchRunItemExecutor) Type() jobs.JobType {
+	return jobs.JobTypeBatchRunItem
+}
+
+// Execute processes the job.
+func (e *BatchRunItemExecutor) Execute(ctx context.Context, job *model.Job) error {
+	if e.batchRunService == nil {
+		return fmt.Errorf("batch run service not available")
+	}
+
+	var payload jobs.BatchRunItemPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if payload.ItemID == "" {
+		return fmt.Errorf("itemId is required")
+	}
+	if payload.BatchRunID == "" {
+		return fmt.Errorf("batchRunId is required")
+	}
+	if payload.ProjectID == "" {
+		return fmt.Errorf("projectId is required")
+	}
+
+	return e.batchRunService.RunItem(ctx, payload.ProjectID, payload.BatchRunID, payload.ItemID)
+}
@@ -3,17 +3,23 @@ package dispatcher
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/obot-platform/discobot/server/internal/config"
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/metrics"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/store"
+	"github.com/obot-platform/discobot/server/internal/tracing"
 )
 
 // Service manages job processing with leader election.
@@ -23,10 +29,14 @@ type Service struct {
 	serverID    string
 	eventBroker *events.Broker
 	singleNode  bool // true when using SQLite (single-host mode)
+	logger      *slog.Logger
 
 	// Registered executors by job type
 	executors map[jobs.JobType]JobExecutor
 
+	// Optional scheduler run periodically by the leader
+	scheduler Scheduler
+
 	// Concurrency tracking per job type
 	runningJobs   map[jobs.JobType]int
 	runningJobsMu sync.Mutex
@@ -53,12 +63,25 @@ func NewService(s *store.Store, cfg *config.Config, eventBroker *events.Broker)
 		serverID:    uuid.New().String(),
 		eventBroker: eventBroker,
 		singleNode:  cfg.DatabaseDriver == "sqlite",
+		logger:      logging.Component("dispatcher"),
 		executors:   make(map[jobs.JobType]JobExecutor),
 		runningJobs: make(map[jobs.JobType]int),
 		notifyCh:    make(chan struct{}, 100), // Buffered to avoid blocking enqueuers
 	}
 }
 
+// Scheduler enqueues work that has come due. Only the leader calls it, so
+// each occurrence is enqueued once across all servers.
+type Scheduler interface {
+	EnqueueDue(ctx context.Context, now time.Time) error
+}
+
+// SetScheduler sets the scheduler the leader runs every ScheduleCheckInterval.
+// Must be called before Start.
+func (d *Service) SetScheduler(scheduler Scheduler) {
+	d.scheduler = scheduler
+}
+
 // RegisterExecutor registers an executor for a job type.
 func (d *Service) RegisterExecutor(executor JobExecutor) {
 	d.executors[executor.Type()] = executor
@@ -93,7 +116,7 @@ func (d *Service) NotifyNewJob() {
 func (d *Service) Start(parentCtx context.Context) {
 	d.ctx, d.cancel = context.WithCancel(parentCtx)
 
-	log.Printf("Dispatcher starting with server ID: %s", d.serverID)
+	d.logger.Info("dispatcher starting", "server_id", d.serverID)
 
 	if d.singleNode {
 		// SQLite is single-host: become leader immediately and clean up
@@ -101,13 +124,13 @@ func (d *Service) Start(parentCtx context.Context) {
 		d.isLeaderMu.Lock()
 		d.isLeader = true
 		d.isLeaderMu.Unlock()
-		log.Printf("Single-node mode (SQLite): immediately became leader (server: %s)", d.serverID)
+		d.logger.Info("single-node mode (SQLite): immediately became leader", "server_id", d.serverID)
 
 		count, err := d.store.CleanupStaleJobs(d.ctx, 0)
 		if err != nil {
-			log.Printf("Stale job cleanup on startup error: %v", err)
+			d.logger.Error("stale job cleanup on startup failed", "error", err)
 		} else if count > 0 {
-			log.Printf("Reset %d stale jobs on startup", count)
+			d.logger.Info("reset stale jobs on startup", "count", count)
 		}
 	} else {
 		// Multi-node: start leader election loop
@@ -122,11 +145,17 @@ func (d *Service) Start(parentCtx context.Context) {
 	// Start stale job cleanup loop
 	d.wg.Add(1)
 	go d.staleJobCleanupLoop()
+
+	// Start scheduler loop
+	if d.scheduler != nil && d.cfg.ScheduleCheckInterval > 0 {
+		d.wg.Add(1)
+		go d.schedulerLoop()
+	}
 }
 
 // Stop gracefully stops the dispatcher.
 func (d *Service) Stop() {
-	log.Println("Dispatcher stopping...")
+	d.logger.Info("dispatcher stopping")
 
 	// Signal all goroutines to stop
 	d.cancel()
@@ -140,9 +169,9 @@ func (d *Service) Stop() {
 
 	select {
 	case <-done:
-		log.Println("All dispatcher goroutines stopped")
+		d.logger.Info("all dispatcher goroutines stopped")
 	case <-time.After(30 * time.Second):
-		log.Println("Timeout waiting for dispatcher goroutines")
+		d.logger.Warn("timeout waiting for dispatcher goroutines")
 	}
 
 	// Release leadership (skip for single-node since we never wrote a DB row)
@@ -150,9 +179,9 @@ func (d *Service) Stop() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := d.store.ReleaseLeadership(ctx, d.serverID); err != nil {
-			log.Printf("Failed to release leadership: %v", err)
+			d.logger.Error("failed to release leadership", "error", err)
 		} else {
-			log.Println("Leadership released")
+			d.logger.Info("leadership released")
 		}
 	}
 }
@@ -185,14 +214,14 @@ func (d *Service) tryAcquireLeadership() {
 		d.cfg.DispatcherHeartbeatTimeout,
 	)
 	if err != nil {
-		log.Printf("Leader election error: %v", err)
+		d.logger.Error("leader election failed", "error", err)
 		// On error, we can't confirm we own the lock, so stop acting as leader
 		d.isLeaderMu.Lock()
 		wasLeader := d.isLeader
 		d.isLeader = false
 		d.isLeaderMu.Unlock()
 		if wasLeader {
-			log.Printf("Relinquished leadership due to error (server: %s)", d.serverID)
+			d.logger.Warn("relinquished leadership due to error", "server_id", d.serverID)
 		}
 		return
 	}
@@ -203,9 +232,9 @@ func (d *Service) tryAcquireLeadership() {
 	d.isLeaderMu.Unlock()
 
 	if acquired && !wasLeader {
-		log.Printf("Became leader (server: %s)", d.serverID)
+		d.logger.Info("became leader", "server_id", d.serverID)
 	} else if !acquired && wasLeader {
-		log.Printf("Lost leadership (server: %s)", d.serverID)
+		d.logger.Info("lost leadership", "server_id", d.serverID)
 	}
 }
 
@@ -247,7 +276,7 @@ func (d *Service) processAvailableJobs() {
 		// Try to claim any job of the available types (single query)
 		job, err := d.store.ClaimJobOfTypes(d.ctx, availableTypes, d.serverID)
 		if err != nil {
-			log.Printf("Failed to claim job: %v", err)
+			d.logger.Error("failed to claim job", "error", err)
 			return
 		}
 
@@ -261,6 +290,7 @@ func (d *Service) processAvailableJobs() {
 		d.runningJobsMu.Lock()
 		d.runningJobs[jobType]++
 		d.runningJobsMu.Unlock()
+		metrics.JobsRunning.WithLabelValues(string(jobType)).Inc()
 
 		// Process job in goroutine
 		d.wg.Add(1)
@@ -290,36 +320,60 @@ func (d *Service) getAvailableJobTypes() []string {
 
 // executeJob processes a single job.
 func (d *Service) executeJob(job *model.Job) {
-	log.Printf("Processing job %s (type: %s)", job.ID, job.Type)
+	start := time.Now()
+
+	// Continue the trace of the request that enqueued the job, and
+	// correlate everything the job logs with it
+	jobCtx := logging.NewContext(logging.With(d.ctx, d.jobLogAttrs(job)...), d.logger)
+	if job.TraceParent != nil {
+		jobCtx = tracing.ContextWithTraceParent(jobCtx, *job.TraceParent)
+	}
+	jobCtx, span := tracing.Tracer().Start(jobCtx, "job "+job.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", job.Type),
+			attribute.Int("job.attempt", job.Attempts),
+		),
+	)
+	var jobErr error
+	defer func() { tracing.End(span, jobErr) }()
+	d.logger.InfoContext(jobCtx, "processing job", "attempt", job.Attempts)
 
 	executor, ok := d.executors[jobs.JobType(job.Type)]
 	if !ok {
 		errMsg := "no executor registered for job type"
-		log.Printf("Job %s failed: %s", job.ID, errMsg)
+		jobErr = errors.New(errMsg)
+		d.logger.ErrorContext(jobCtx, "job failed", "error", errMsg)
+		metrics.JobFailures.WithLabelValues(job.Type).Inc()
 		if err := d.store.FailJob(d.ctx, job.ID, errMsg, d.cfg.JobRetryBackoff); err != nil {
-			log.Printf("Failed to mark job %s as failed: %v", job.ID, err)
+			d.logger.ErrorContext(jobCtx, "failed to mark job as failed", "error", err)
 		}
 		return
 	}
 
 	// Execute with timeout
-	ctx, cancel := context.WithTimeout(d.ctx, d.cfg.DispatcherJobTimeout)
+	ctx, cancel := context.WithTimeout(jobCtx, d.cfg.DispatcherJobTimeout)
 	defer cancel()
 
 	err := executor.Execute(ctx, job)
 	if err != nil {
-		log.Printf("Job %s failed: %v", job.ID, err)
+		jobErr = err
+		d.logger.ErrorContext(jobCtx, "job failed", "error", err, "duration", time.Since(start))
+		metrics.JobFailures.WithLabelValues(job.Type).Inc()
+		metrics.JobDuration.WithLabelValues(job.Type, "failed").Observe(time.Since(start).Seconds())
 		if err := d.store.FailJob(d.ctx, job.ID, err.Error(), d.cfg.JobRetryBackoff); err != nil {
-			log.Printf("Failed to mark job %s as failed: %v", job.ID, err)
+			d.logger.ErrorContext(jobCtx, "failed to mark job as failed", "error", err)
 		}
 		// Publish job completion event (failure)
 		d.publishJobCompletionEvent(job, "failed", err.Error())
 		return
 	}
 
-	log.Printf("Job %s completed successfully", job.ID)
+	d.logger.InfoContext(jobCtx, "job completed", "duration", time.Since(start))
+	metrics.JobDuration.WithLabelValues(job.Type, "completed").Observe(time.Since(start).Seconds())
 	if err := d.store.CompleteJob(d.ctx, job.ID); err != nil {
-		log.Printf("Failed to mark job %s as completed: %v", job.ID, err)
+		d.logger.ErrorContext(jobCtx, "failed to mark job as completed", "error", err)
 	}
 	// Publish job completion event (success)
 	d.publishJobCompletionEvent(job, "completed", "")
@@ -330,6 +384,7 @@ func (d *Service) decrementRunning(jobType jobs.JobType) {
 	d.runningJobsMu.Lock()
 	d.runningJobs[jobType]--
 	d.runningJobsMu.Unlock()
+	metrics.JobsRunning.WithLabelValues(string(jobType)).Dec()
 }
 
 // staleJobCleanupLoop periodically cleans up stale running jobs.
@@ -350,9 +405,32 @@ func (d *Service) staleJobCleanupLoop() {
 
 			count, err := d.store.CleanupStaleJobs(d.ctx, d.cfg.DispatcherStaleJobTimeout)
 			if err != nil {
-				log.Printf("Stale job cleanup error: %v", err)
+				d.logger.Error("stale job cleanup failed", "error", err)
 			} else if count > 0 {
-				log.Printf("Reset %d stale jobs", count)
+				d.logger.Info("reset stale jobs", "count", count)
+			}
+		}
+	}
+}
+
+// schedulerLoop periodically enqueues due scheduled work.
+func (d *Service) schedulerLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.cfg.ScheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			if !d.IsLeader() {
+				continue
+			}
+
+			if err := d.scheduler.EnqueueDue(d.ctx, time.Now()); err != nil {
+				d.logger.Error("scheduler failed", "error", err)
 			}
 		}
 	}
@@ -377,7 +455,7 @@ func (d *Service) publishJobCompletionEvent(job *model.Job, status, errorMsg str
 	// Extract project ID from job payload
 	projectID := d.extractProjectIDFromJob(job)
 	if projectID == "" {
-		log.Printf("Warning: could not extract projectId from job %s, skipping event publish", job.ID)
+		d.logger.Warn("could not extract projectId from job, skipping event publish", "job_id", job.ID)
 		return
 	}
 
@@ -391,8 +469,26 @@ func (d *Service) publishJobCompletionEvent(job *model.Job, status, errorMsg str
 		status,
 		errorMsg,
 	); err != nil {
-		log.Printf("Failed to publish job completion event for job %s: %v", job.ID, err)
+		d.logger.Error("failed to publish job completion event", "job_id", job.ID, "error", err)
+	}
+}
+
+// jobLogAttrs returns the log attributes that correlate a job's records with
+// its job, project and resource.
+func (d *Service) jobLogAttrs(job *model.Job) []any {
+	attrs := []any{"job_id", job.ID, "job_type", job.Type}
+	if projectID := d.extractProjectIDFromJob(job); projectID != "" {
+		attrs = append(attrs, "project_id", projectID)
+	}
+	if job.ResourceType != nil && job.ResourceID != nil {
+		switch *job.ResourceType {
+		case jobs.ResourceTypeSession:
+			attrs = append(attrs, "session_id", *job.ResourceID)
+		case jobs.ResourceTypeWorkspace:
+			attrs = append(attrs, "workspace_id", *job.ResourceID)
+		}
 	}
+	return attrs
 }
 
 // extractProjectIDFromJob extracts the projectId from the job payload.
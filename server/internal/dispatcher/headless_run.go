@@ -0,0 +1,47 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// HeadlessRunExecutor handles headless_run jobs.
+type HeadlessRunExecutor struct {
+	headlessRunService *service.HeadlessRunService
+}
+
+// NewHeadlessRunExecutor creates a new headless run executor.
+func NewHeadlessRunExecutor(headlessRunSvc *service.HeadlessRunService) *HeadlessRunExecutor {
+	return &HeadlessRunExecutor{headlessRunService: headlessRunSvc}
+}
+
+// Type returns the job type this executor handles.
+func (e *HeadlessRunExecutor) Type() jobs.JobType {
+	return jobs.JobTypeHeadlessRun
+}
+
+// Execute processes the job.
+func (e *HeadlessRunExecutor) Execute(ctx context.Context, job *model.Job) error {
+	if e.headlessRunService == nil {
+		return fmt.Errorf("headless run service not available")
+	}
+
+	var payload jobs.HeadlessRunPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if payload.RunID == "" {
+		return fmt.Errorf("runId is required")
+	}
+	if payload.ProjectID == "" {
+		return fmt.Errorf("projectId is required")
+	}
+
+	return e.headlessRunService.RunHeadlessRun(ctx, payload.ProjectID, payload.RunID)
+}
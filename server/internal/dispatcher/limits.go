@@ -7,6 +7,9 @@ import "github.com/obot-platform/discobot/server/internal/jobs"
 var ConcurrencyLimits = map[jobs.JobType]int{
 	jobs.JobTypeSessionInit:   2, // Max 2 session inits at once
 	jobs.JobTypeSessionDelete: 2, // Max 2 session deletes at once
+	jobs.JobTypeBatchRunItem:  8, // Max 8 batch run items at once across all runs
+	jobs.JobTypeScheduleRun:   4, // Max 4 scheduled runs at once
+	jobs.JobTypeHeadlessRun:   4, // Max 4 headless runs at once
 }
 
 // DefaultConcurrencyLimit is used for job types not in ConcurrencyLimits.
@@ -0,0 +1,50 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// ScheduleRunExecutor handles schedule_run jobs.
+type ScheduleRunExecutor struct {
+	scheduleService *service.ScheduleService
+}
+
+// NewScheduleRunExecutor creates a new schedule run executor.
+func NewScheduleRunExecutor(scheduleSvc *service.ScheduleService) *ScheduleRunExecutor {
+	return &ScheduleRunExecutor{scheduleService: scheduleSvc}
+}
+
+// Type returns the job type this executor handles.
+func (e *ScheduleRunExecutor) Type() jobs.JobType {
+	return jobs.JobTypeScheduleRun
+}
+
+// Execute processes the job.
+func (e *ScheduleRunExecutor) Execute(ctx context.Context, job *model.Job) error {
+	if e.scheduleService == nil {
+		return fmt.Errorf("schedule service not available")
+	}
+
+	var payload jobs.ScheduleRunPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if payload.RunID == "" {
+		return fmt.Errorf("runId is required")
+	}
+	if payload.ScheduleID == "" {
+		return fmt.Errorf("scheduleId is required")
+	}
+	if payload.ProjectID == "" {
+		return fmt.Errorf("projectId is required")
+	}
+
+	return e.scheduleService.RunSchedule(ctx, payload.ProjectID, payload.ScheduleID, payload.RunID)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/obot-platform/discobot/server/internal/git"
 	"github.com/obot-platform/discobot/server/internal/jobs"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/service"
@@ -43,5 +44,18 @@ func (e *SessionCommitExecutor) Execute(ctx context.Context, job *model.Job) err
 		return fmt.Errorf("projectId is required")
 	}
 
-	return e.sessionService.PerformCommit(ctx, payload.ProjectID, payload.SessionID)
+	switch payload.Action {
+	case jobs.CommitActionApply:
+		var selection git.PatchSelection
+		if payload.Selection != nil {
+			selection = *payload.Selection
+		}
+		return e.sessionService.PerformCommitApply(ctx, payload.ProjectID, payload.SessionID, selection, payload.Feedback)
+	case jobs.CommitActionReject:
+		return e.sessionService.PerformCommitReject(ctx, payload.ProjectID, payload.SessionID, payload.Feedback)
+	case "":
+		return e.sessionService.PerformCommit(ctx, payload.ProjectID, payload.SessionID)
+	default:
+		return fmt.Errorf("unknown commit action %q", payload.Action)
+	}
 }
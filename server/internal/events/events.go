@@ -23,6 +23,16 @@ const (
 	EventTypeWorkspaceUpdated EventType = "workspace_updated"
 	// EventTypeJobCompleted indicates a job has completed (success or failure)
 	EventTypeJobCompleted EventType = "job_completed"
+	// EventTypeSessionBehind indicates a session's base commit is behind the workspace's tracked branch
+	EventTypeSessionBehind EventType = "session_behind"
+	// EventTypeBatchRunUpdated indicates a batch run or one of its items has changed
+	EventTypeBatchRunUpdated EventType = "batch_run_updated"
+	// EventTypeScheduleRunUpdated indicates a scheduled run has started or finished
+	EventTypeScheduleRunUpdated EventType = "schedule_run_updated"
+	// EventTypeQuestionAutoAnswered indicates a question policy answered an AskUserQuestion
+	EventTypeQuestionAutoAnswered EventType = "question_auto_answered"
+	// EventTypeQuestionTimedOut indicates a question went unanswered and its completion was cancelled
+	EventTypeQuestionTimedOut EventType = "question_timed_out"
 )
 
 // Event represents a server-sent event
@@ -50,6 +60,8 @@ type SessionUpdatedData struct {
 	SessionID    string `json:"sessionId"`
 	Status       string `json:"status"`
 	CommitStatus string `json:"commitStatus,omitempty"`
+	// SubStatus is the sandbox startup step currently running, if any
+	SubStatus string `json:"subStatus,omitempty"`
 }
 
 // WorkspaceUpdatedData is the payload for workspace_updated events
@@ -68,6 +80,48 @@ type JobCompletedData struct {
 	Error        string `json:"error,omitempty"`
 }
 
+// SessionBehindData is the payload for session_behind events
+type SessionBehindData struct {
+	SessionID      string `json:"sessionId"`
+	WorkspaceID    string `json:"workspaceId"`
+	Upstream       string `json:"upstream"`
+	UpstreamCommit string `json:"upstreamCommit"`
+	Behind         int    `json:"behind"`
+}
+
+// BatchRunUpdatedData is the payload for batch_run_updated events
+type BatchRunUpdatedData struct {
+	BatchRunID string `json:"batchRunId"`
+	Status     string `json:"status"`
+	ItemID     string `json:"itemId,omitempty"`
+	ItemStatus string `json:"itemStatus,omitempty"`
+	SessionID  string `json:"sessionId,omitempty"`
+}
+
+// ScheduleRunUpdatedData is the payload for schedule_run_updated events
+type ScheduleRunUpdatedData struct {
+	ScheduleID string `json:"scheduleId"`
+	RunID      string `json:"runId"`
+	Status     string `json:"status"`
+	SessionID  string `json:"sessionId,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// QuestionAutoAnsweredData is the payload for question_auto_answered events
+type QuestionAutoAnsweredData struct {
+	SessionID string            `json:"sessionId"`
+	ToolUseID string            `json:"toolUseId"`
+	Policy    string            `json:"policy"`
+	Answers   map[string]string `json:"answers"` // Keyed by question text
+}
+
+// QuestionTimedOutData is the payload for question_timed_out events
+type QuestionTimedOutData struct {
+	SessionID      string `json:"sessionId"`
+	ToolUseID      string `json:"toolUseId"`
+	TimeoutMinutes int    `json:"timeoutMinutes"`
+}
+
 // Subscriber represents a client subscribed to events for a specific project.
 type Subscriber struct {
 	ID        string
@@ -169,6 +223,30 @@ func (b *Broker) PublishSessionUpdated(ctx context.Context, projectID, sessionID
 	return b.Publish(ctx, projectID, event)
 }
 
+// PublishSessionSubStatus publishes a session_updated event carrying the
+// sandbox startup step currently running. The session status is unchanged.
+func (b *Broker) PublishSessionSubStatus(ctx context.Context, projectID, sessionID, status, subStatus string) error {
+	data := SessionUpdatedData{
+		SessionID: sessionID,
+		Status:    status,
+		SubStatus: subStatus,
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	event := &Event{
+		ID:        generateEventID(),
+		Type:      EventTypeSessionUpdated,
+		Timestamp: time.Now(),
+		Data:      dataBytes,
+	}
+
+	return b.Publish(ctx, projectID, event)
+}
+
 // PublishWorkspaceUpdated is a convenience method to publish workspace update events.
 func (b *Broker) PublishWorkspaceUpdated(ctx context.Context, projectID, workspaceID, status string) error {
 	data := WorkspaceUpdatedData{
@@ -217,6 +295,91 @@ func (b *Broker) PublishJobCompleted(ctx context.Context, projectID, jobID, jobT
 	return b.Publish(ctx, projectID, event)
 }
 
+// PublishSessionBehind is a convenience method to publish session behind events.
+func (b *Broker) PublishSessionBehind(ctx context.Context, projectID string, data SessionBehindData) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	event := &Event{
+		ID:        generateEventID(),
+		Type:      EventTypeSessionBehind,
+		Timestamp: time.Now(),
+		Data:      dataBytes,
+	}
+
+	return b.Publish(ctx, projectID, event)
+}
+
+// PublishBatchRunUpdated is a convenience method to publish batch run update events.
+func (b *Broker) PublishBatchRunUpdated(ctx context.Context, projectID string, data BatchRunUpdatedData) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	event := &Event{
+		ID:        generateEventID(),
+		Type:      EventTypeBatchRunUpdated,
+		Timestamp: time.Now(),
+		Data:      dataBytes,
+	}
+
+	return b.Publish(ctx, projectID, event)
+}
+
+// PublishScheduleRunUpdated is a convenience method to publish schedule run update events.
+func (b *Broker) PublishScheduleRunUpdated(ctx context.Context, projectID string, data ScheduleRunUpdatedData) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	event := &Event{
+		ID:        generateEventID(),
+		Type:      EventTypeScheduleRunUpdated,
+		Timestamp: time.Now(),
+		Data:      dataBytes,
+	}
+
+	return b.Publish(ctx, projectID, event)
+}
+
+// PublishQuestionAutoAnswered is a convenience method to publish question auto-answered events.
+func (b *Broker) PublishQuestionAutoAnswered(ctx context.Context, projectID string, data QuestionAutoAnsweredData) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	event := &Event{
+		ID:        generateEventID(),
+		Type:      EventTypeQuestionAutoAnswered,
+		Timestamp: time.Now(),
+		Data:      dataBytes,
+	}
+
+	return b.Publish(ctx, projectID, event)
+}
+
+// PublishQuestionTimedOut is a convenience method to publish question timed out events.
+func (b *Broker) PublishQuestionTimedOut(ctx context.Context, projectID string, data QuestionTimedOutData) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	event := &Event{
+		ID:        generateEventID(),
+		Type:      EventTypeQuestionTimedOut,
+		Timestamp: time.Now(),
+		Data:      dataBytes,
+	}
+
+	return b.Publish(ctx, projectID, event)
+}
+
 // GetEventsSince returns all persisted events for a project since the given time.
 func (b *Broker) GetEventsSince(ctx context.Context, projectID string, since time.Time) ([]*Event, error) {
 	modelEvents, err := b.store.ListProjectEventsSince(ctx, projectID, since)
@@ -306,6 +306,55 @@ func TestBroker_PublishSessionUpdated(t *testing.T) {
 	}
 }
 
+func TestBroker_PublishSessionSubStatus(t *testing.T) {
+	env := testSetup(t)
+	defer env.Cleanup()
+
+	ctx := context.Background()
+
+	// Start poller
+	pollerCfg := DefaultPollerConfig()
+	pollerCfg.PollInterval = 10 * time.Millisecond
+	poller := NewPoller(env.Store, pollerCfg)
+	if err := poller.Start(ctx); err != nil {
+		t.Fatalf("Failed to start poller: %v", err)
+	}
+	defer poller.Stop()
+
+	// Create broker
+	broker := NewBroker(env.Store, poller)
+
+	// Subscribe
+	sub := broker.Subscribe(env.ProjectID)
+	defer broker.Unsubscribe(sub)
+
+	// Publish the running startup step
+	if err := broker.PublishSessionSubStatus(ctx, env.ProjectID, "session-789", "ready", "session hook: install"); err != nil {
+		t.Fatalf("Failed to publish session sub-status: %v", err)
+	}
+
+	// Wait for event
+	select {
+	case received := <-sub.Events:
+		if received.Type != EventTypeSessionUpdated {
+			t.Errorf("Expected type %s, got %s", EventTypeSessionUpdated, received.Type)
+		}
+
+		var data SessionUpdatedData
+		if err := json.Unmarshal(received.Data, &data); err != nil {
+			t.Fatalf("Failed to unmarshal data: %v", err)
+		}
+		if data.Status != "ready" {
+			t.Errorf("Expected status 'ready', got '%s'", data.Status)
+		}
+		if data.SubStatus != "session hook: install" {
+			t.Errorf("Expected subStatus 'session hook: install', got '%s'", data.SubStatus)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Timeout waiting for event")
+	}
+}
+
 func TestBroker_PublishSessionUpdated_WithCommitStatus(t *testing.T) {
 	env := testSetup(t)
 	defer env.Cleanup()
@@ -2,13 +2,16 @@ package events
 
 import (
 	"context"
-	"log"
 	"sync"
 	"time"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
 
+// pollerLog is the logger for event poller messages.
+var pollerLog = logging.Component("events")
+
 // PollerConfig contains configuration for the event poller.
 type PollerConfig struct {
 	// PollInterval is how often to poll for new events when there are no notifications.
@@ -70,7 +73,7 @@ func (p *Poller) Start(parentCtx context.Context) error {
 	}
 	p.lastSeq = maxSeq
 
-	log.Printf("Event poller starting (last seq: %d)", p.lastSeq)
+	pollerLog.InfoContext(p.ctx, "event poller starting", "last_seq", p.lastSeq)
 
 	// Start polling loop
 	p.wg.Add(1)
@@ -81,7 +84,7 @@ func (p *Poller) Start(parentCtx context.Context) error {
 
 // Stop gracefully stops the poller.
 func (p *Poller) Stop() {
-	log.Println("Event poller stopping...")
+	pollerLog.Info("event poller stopping")
 	p.cancel()
 
 	// Wait for poll loop to finish
@@ -93,9 +96,9 @@ func (p *Poller) Stop() {
 
 	select {
 	case <-done:
-		log.Println("Event poller stopped")
+		pollerLog.Info("event poller stopped")
 	case <-time.After(5 * time.Second):
-		log.Println("Timeout waiting for event poller to stop")
+		pollerLog.Warn("timeout waiting for event poller to stop")
 	}
 
 	// Close all subscribers
@@ -204,7 +207,7 @@ func (p *Poller) pollAndBroadcast() {
 
 	events, err := p.store.ListEventsAfterSeq(p.ctx, afterSeq, p.config.BatchSize)
 	if err != nil {
-		log.Printf("Failed to poll events: %v", err)
+		pollerLog.ErrorContext(p.ctx, "failed to poll events", "error", err)
 		return
 	}
 
@@ -236,7 +239,7 @@ func (p *Poller) pollAndBroadcast() {
 				case sub.Events <- event:
 				default:
 					// Channel full, skip this event for this subscriber
-					log.Printf("Event channel full for subscriber %s, dropping event %s", sub.ID, event.ID)
+					pollerLog.WarnContext(p.ctx, "event channel full, dropping event", "subscriber_id", sub.ID, "event_id", event.ID)
 				}
 			}
 			sub.mu.Unlock()
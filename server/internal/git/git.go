@@ -18,6 +18,7 @@ var (
 	ErrFetchFailed    = errors.New("fetch failed")
 	ErrCheckoutFailed = errors.New("checkout failed")
 	ErrDirtyWorkTree  = errors.New("working tree has uncommitted changes")
+	ErrNoPatches      = errors.New("no patches selected")
 )
 
 // WorkspaceSource provides workspace information to the git provider.
@@ -92,6 +93,23 @@ type Provider interface {
 	// GetUserConfig retrieves the global git user name and email configuration.
 	// Returns empty strings if not configured.
 	GetUserConfig(ctx context.Context) (name, email string)
+
+	// SelectPatches rewrites an mbox patch series according to a selection.
+	// The patches are replayed onto baseCommit in a temporary worktree so the
+	// workspace's working tree is never touched. Returns the rewritten series.
+	SelectPatches(ctx context.Context, workspaceID, baseCommit string, patches []byte, selection PatchSelection) ([]byte, error)
+
+	// ComparePatches replays two patch series onto their base commits in a
+	// temporary worktree and returns the diff from the result of a to the result of b.
+	ComparePatches(ctx context.Context, workspaceID string, a, b PatchSeries) ([]FileDiff, error)
+
+	// CompareUpstream compares a commit (or HEAD if empty) against the workspace's
+	// tracked branch. When HEAD has no upstream configured, HEAD itself is tracked.
+	CompareUpstream(ctx context.Context, workspaceID, ref string) (*UpstreamStatus, error)
+
+	// FastForward fast-forwards HEAD to its upstream branch.
+	// Returns the resulting HEAD commit SHA. It is a no-op if HEAD has no upstream.
+	FastForward(ctx context.Context, workspaceID string) (commit string, err error)
 }
 
 // Status represents the git status of a repository.
@@ -108,6 +126,14 @@ type Status struct {
 	HasConflicts bool         `json:"hasConflicts"` // Merge conflicts present
 }
 
+// UpstreamStatus describes how a commit relates to the workspace's tracked branch.
+type UpstreamStatus struct {
+	Upstream       string `json:"upstream"`       // Tracked ref (e.g. "origin/main", or "HEAD" without an upstream)
+	UpstreamCommit string `json:"upstreamCommit"` // Commit SHA at the tip of the tracked ref
+	Ahead          int    `json:"ahead"`          // Commits reachable from the compared commit but not the tracked ref
+	Behind         int    `json:"behind"`         // Commits on the tracked ref not reachable from the compared commit
+}
+
 // FileStatus represents the status of a single file.
 type FileStatus struct {
 	Path    string `json:"path"`
@@ -188,6 +214,14 @@ type LogOptions struct {
 	Skip int
 }
 
+// ShortSHA abbreviates a commit SHA for messages and display.
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
 // IsGitURL returns true if the source looks like a git URL.
 func IsGitURL(source string) bool {
 	// Check common git URL patterns
@@ -422,7 +422,7 @@ func (p *LocalProvider) Diff(ctx context.Context, workspaceID string, opts DiffO
 		return nil, err
 	}
 
-	return p.parseDiff(output), nil
+	return parseDiff(output), nil
 }
 
 // Branches lists all branches.
@@ -720,6 +720,204 @@ func (p *LocalProvider) ApplyPatches(ctx context.Context, workspaceID string, pa
 	return strings.TrimSpace(finalCommit), nil
 }
 
+// SelectPatches rewrites an mbox patch series according to a selection.
+// Each selected commit is replayed with git am in a temporary detached worktree at
+// baseCommit, so files can be excluded per commit and messages amended without
+// touching the workspace. The result is re-exported with git format-patch.
+func (p *LocalProvider) SelectPatches(ctx context.Context, workspaceID, baseCommit string, patches []byte, selection PatchSelection) ([]byte, error) {
+	workDir := p.GetWorkDir(ctx, workspaceID)
+	if workDir == "" {
+		return nil, fmt.Errorf("%w: workspace %s", ErrNotFound, workspaceID)
+	}
+
+	parsed, err := ParsePatches(string(patches))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patches: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "discobot-patches-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	worktree := filepath.Join(tmpDir, "worktree")
+	if err := p.runGit(ctx, workDir, "worktree", "add", "--detach", worktree, baseCommit); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	defer func() {
+		_ = p.runGit(context.WithoutCancel(ctx), workDir, "worktree", "remove", "--force", worktree)
+	}()
+
+	var applied []Patch
+	var messages []string
+	for _, patch := range parsed {
+		if selection.excludesCommit(patch.SHA) || len(selection.selectedFiles(patch)) == 0 {
+			continue
+		}
+
+		args := []string{"am", "--keep-cr", "--no-gpg-sign"}
+		for _, path := range selection.ExcludeFiles[patch.SHA] {
+			args = append(args, "--exclude="+path)
+		}
+		if err := p.runGitWithStdin(ctx, worktree, []byte(patch.Raw), args...); err != nil {
+			_ = p.runGit(ctx, worktree, "am", "--abort")
+			return nil, fmt.Errorf("commit %s (%s) does not apply with this selection: %w", ShortSHA(patch.SHA), patch.Subject, err)
+		}
+
+		message := patch.Message
+		if edited := strings.TrimSpace(selection.Messages[patch.SHA]); edited != "" {
+			message = edited
+			if err := p.runGit(ctx, worktree, "commit", "--amend", "--no-verify", "--no-gpg-sign", "-m", message); err != nil {
+				return nil, fmt.Errorf("failed to edit message of commit %s: %w", ShortSHA(patch.SHA), err)
+			}
+		}
+
+		applied = append(applied, patch)
+		messages = append(messages, message)
+	}
+
+	if len(applied) == 0 {
+		return nil, ErrNoPatches
+	}
+
+	if selection.Squash && len(applied) > 1 {
+		message := strings.TrimSpace(selection.SquashMessage)
+		if message == "" {
+			message = strings.Join(messages, "\n\n")
+		}
+		author := fmt.Sprintf("%s <%s>", applied[0].Author, applied[0].AuthorEmail)
+		if err := p.runGit(ctx, worktree, "reset", "--soft", baseCommit); err != nil {
+			return nil, fmt.Errorf("failed to squash commits: %w", err)
+		}
+		if err := p.runGit(ctx, worktree, "commit", "--no-verify", "--no-gpg-sign", "--author", author, "-m", message); err != nil {
+			return nil, fmt.Errorf("failed to squash commits: %w", err)
+		}
+	}
+
+	output, err := p.runGitOutput(ctx, worktree, "format-patch", "--stdout", baseCommit+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export patches: %w", err)
+	}
+
+	return []byte(output), nil
+}
+
+// ComparePatches replays two patch series onto their base commits and diffs the
+// resulting trees against each other. Both series are applied in a temporary
+// detached worktree, so the workspace is never touched. Empty series leave the
+// base commit unchanged.
+func (p *LocalProvider) ComparePatches(ctx context.Context, workspaceID string, a, b PatchSeries) ([]FileDiff, error) {
+	workDir := p.GetWorkDir(ctx, workspaceID)
+	if workDir == "" {
+		return nil, fmt.Errorf("%w: workspace %s", ErrNotFound, workspaceID)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "discobot-compare-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	worktree := filepath.Join(tmpDir, "worktree")
+	if err := p.runGit(ctx, workDir, "worktree", "add", "--detach", worktree, a.BaseCommit); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	defer func() {
+		_ = p.runGit(context.WithoutCancel(ctx), workDir, "worktree", "remove", "--force", worktree)
+	}()
+
+	heads := make([]string, 0, 2)
+	for i, series := range []PatchSeries{a, b} {
+		if i > 0 {
+			if err := p.runGit(ctx, worktree, "checkout", "--detach", series.BaseCommit); err != nil {
+				return nil, fmt.Errorf("failed to checkout %s: %w", ShortSHA(series.BaseCommit), err)
+			}
+		}
+		if len(series.Patches) > 0 {
+			// The replayed commits are discarded, so a fixed committer identity is fine
+			args := []string{"-c", "user.name=discobot", "-c", "user.email=discobot@localhost", "am", "--keep-cr", "--no-gpg-sign"}
+			if err := p.runGitWithStdin(ctx, worktree, series.Patches, args...); err != nil {
+				_ = p.runGit(ctx, worktree, "am", "--abort")
+				return nil, fmt.Errorf("patches do not apply to %s: %w", ShortSHA(series.BaseCommit), err)
+			}
+		}
+		head, err := p.runGitOutput(ctx, worktree, "rev-parse", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		heads = append(heads, strings.TrimSpace(head))
+	}
+
+	output, err := p.runGitOutput(ctx, worktree, "diff", "--no-color", heads[0], heads[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDiff(output), nil
+}
+
+// CompareUpstream compares a commit against the workspace's tracked branch.
+func (p *LocalProvider) CompareUpstream(ctx context.Context, workspaceID, ref string) (*UpstreamStatus, error) {
+	workDir := p.GetWorkDir(ctx, workspaceID)
+	if workDir == "" {
+		return nil, fmt.Errorf("%w: workspace %s", ErrNotFound, workspaceID)
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	// Track the upstream of HEAD if one is configured, otherwise HEAD itself
+	upstream := "HEAD"
+	if name, err := p.runGitOutput(ctx, workDir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+		upstream = strings.TrimSpace(name)
+	}
+
+	upstreamCommit, err := p.runGitOutput(ctx, workDir, "rev-parse", upstream)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRef, upstream)
+	}
+
+	status := &UpstreamStatus{
+		Upstream:       upstream,
+		UpstreamCommit: strings.TrimSpace(upstreamCommit),
+	}
+
+	revList, err := p.runGitOutput(ctx, workDir, "rev-list", "--left-right", "--count", ref+"..."+status.UpstreamCommit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRef, ref)
+	}
+	parts := strings.Fields(strings.TrimSpace(revList))
+	if len(parts) == 2 {
+		status.Ahead, _ = strconv.Atoi(parts[0])
+		status.Behind, _ = strconv.Atoi(parts[1])
+	}
+
+	return status, nil
+}
+
+// FastForward fast-forwards HEAD to its upstream branch.
+func (p *LocalProvider) FastForward(ctx context.Context, workspaceID string) (string, error) {
+	workDir := p.GetWorkDir(ctx, workspaceID)
+	if workDir == "" {
+		return "", fmt.Errorf("%w: workspace %s", ErrNotFound, workspaceID)
+	}
+
+	if _, err := p.runGitOutput(ctx, workDir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+		if err := p.runGit(ctx, workDir, "merge", "--ff-only", "@{upstream}"); err != nil {
+			return "", fmt.Errorf("fast-forward failed: %w", err)
+		}
+	}
+
+	commit, err := p.runGitOutput(ctx, workDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	return strings.TrimSpace(commit), nil
+}
+
 // --- Internal helpers ---
 
 // cleanGitEnv returns the current environment with GIT_* variables removed that
@@ -816,7 +1014,7 @@ func (p *LocalProvider) statusCodeToString(code byte) string {
 }
 
 // parseDiff parses unified diff output into FileDiff structs.
-func (p *LocalProvider) parseDiff(output string) []FileDiff {
+func parseDiff(output string) []FileDiff {
 	var diffs []FileDiff
 	var current *FileDiff
 	var patchLines []string
@@ -1215,3 +1215,110 @@ func TestGetUserConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestCompareUpstream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports commits behind upstream after fetch", func(t *testing.T) {
+		baseDir := t.TempDir()
+		provider, _ := NewLocalProvider(baseDir)
+		sourceRepo := createTestRepo(t)
+
+		_, initialCommit, err := provider.EnsureWorkspace(ctx, "project1", "ws1", sourceRepo, "")
+		if err != nil {
+			t.Fatalf("EnsureWorkspace failed: %v", err)
+		}
+
+		// Advance the source repository
+		if err := os.WriteFile(filepath.Join(sourceRepo, "new.txt"), []byte("new\n"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		runGit(t, sourceRepo, "add", ".")
+		runGit(t, sourceRepo, "commit", "-m", "Add new.txt")
+		latest := strings.TrimSpace(runGit(t, sourceRepo, "rev-parse", "HEAD"))
+
+		if err := provider.Fetch(ctx, "ws1"); err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+
+		status, err := provider.CompareUpstream(ctx, "ws1", initialCommit)
+		if err != nil {
+			t.Fatalf("CompareUpstream failed: %v", err)
+		}
+		if !strings.HasPrefix(status.Upstream, "origin/") {
+			t.Errorf("Expected origin upstream, got %q", status.Upstream)
+		}
+		if status.UpstreamCommit != latest {
+			t.Errorf("Expected upstream commit %s, got %s", latest, status.UpstreamCommit)
+		}
+		if status.Behind != 1 || status.Ahead != 0 {
+			t.Errorf("Expected behind=1 ahead=0, got behind=%d ahead=%d", status.Behind, status.Ahead)
+		}
+	})
+
+	t.Run("tracks HEAD without upstream", func(t *testing.T) {
+		baseDir := t.TempDir()
+		provider, _ := NewLocalProvider(baseDir)
+		repo := createTestRepo(t)
+		initialCommit := strings.TrimSpace(runGit(t, repo, "rev-parse", "HEAD"))
+
+		if _, _, err := provider.registerLocalWorkspace(ctx, "ws1", "project1", repo); err != nil {
+			t.Fatalf("registerLocalWorkspace failed: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(repo, "new.txt"), []byte("new\n"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		runGit(t, repo, "add", ".")
+		runGit(t, repo, "commit", "-m", "Add new.txt")
+
+		status, err := provider.CompareUpstream(ctx, "ws1", initialCommit)
+		if err != nil {
+			t.Fatalf("CompareUpstream failed: %v", err)
+		}
+		if status.Upstream != "HEAD" {
+			t.Errorf("Expected HEAD upstream, got %q", status.Upstream)
+		}
+		if status.Behind != 1 {
+			t.Errorf("Expected behind=1, got %d", status.Behind)
+		}
+	})
+
+	t.Run("fails for unknown workspace", func(t *testing.T) {
+		provider, _ := NewLocalProvider(t.TempDir())
+
+		if _, err := provider.CompareUpstream(ctx, "nonexistent", ""); err == nil {
+			t.Error("Expected error for unknown workspace")
+		}
+	})
+}
+
+func TestFastForward(t *testing.T) {
+	ctx := context.Background()
+	baseDir := t.TempDir()
+	provider, _ := NewLocalProvider(baseDir)
+	sourceRepo := createTestRepo(t)
+
+	if _, _, err := provider.EnsureWorkspace(ctx, "project1", "ws1", sourceRepo, ""); err != nil {
+		t.Fatalf("EnsureWorkspace failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(sourceRepo, "new.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runGit(t, sourceRepo, "add", ".")
+	runGit(t, sourceRepo, "commit", "-m", "Add new.txt")
+	latest := strings.TrimSpace(runGit(t, sourceRepo, "rev-parse", "HEAD"))
+
+	if err := provider.Fetch(ctx, "ws1"); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	commit, err := provider.FastForward(ctx, "ws1")
+	if err != nil {
+		t.Fatalf("FastForward failed: %v", err)
+	}
+	if commit != latest {
+		t.Errorf("Expected HEAD %s after fast-forward, got %s", latest, commit)
+	}
+}
@@ -0,0 +1,185 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Patch is a single commit parsed from git format-patch (mbox) output.
+type Patch struct {
+	SHA         string     `json:"sha"`
+	Author      string     `json:"author"`
+	AuthorEmail string     `json:"authorEmail"`
+	AuthorDate  time.Time  `json:"authorDate"`
+	Subject     string     `json:"subject"`
+	Message     string     `json:"message"` // Full commit message (subject and body)
+	Files       []FileDiff `json:"files"`
+	Raw         string     `json:"-"` // Original mbox text for this commit
+}
+
+// PatchSelection describes which parts of a patch series should be applied.
+// Commits are identified by the SHA from their mbox "From" line.
+type PatchSelection struct {
+	ExcludeCommits []string            `json:"excludeCommits,omitempty"` // Commits to drop entirely
+	ExcludeFiles   map[string][]string `json:"excludeFiles,omitempty"`   // Commit SHA -> paths to drop from that commit
+	Messages       map[string]string   `json:"messages,omitempty"`       // Commit SHA -> replacement commit message
+	Squash         bool                `json:"squash,omitempty"`         // Combine the selected commits into one
+	SquashMessage  string              `json:"squashMessage,omitempty"`  // Message for the squashed commit (default: joined messages)
+}
+
+// PatchSeries is an mbox patch series together with the commit it applies to.
+type PatchSeries struct {
+	BaseCommit string
+	Patches    []byte
+}
+
+// mboxFromLine matches the separator line git format-patch writes before each commit.
+var mboxFromLine = regexp.MustCompile(`^From ([0-9a-f]{40}) `)
+
+// patchSubjectPrefix matches the "[PATCH n/m]" prefix added by git format-patch.
+var patchSubjectPrefix = regexp.MustCompile(`^\[PATCH[^\]]*\]\s*`)
+
+// ParsePatches parses git format-patch (mbox) output into individual commits.
+func ParsePatches(mbox string) ([]Patch, error) {
+	var chunks []string
+	var current []string
+	for _, line := range strings.SplitAfter(mbox, "\n") {
+		if mboxFromLine.MatchString(line) && current != nil {
+			chunks = append(chunks, strings.Join(current, ""))
+			current = nil
+		}
+		if current == nil && !mboxFromLine.MatchString(line) {
+			// Skip anything before the first separator
+			continue
+		}
+		current = append(current, line)
+	}
+	if current != nil {
+		chunks = append(chunks, strings.Join(current, ""))
+	}
+
+	patches := make([]Patch, 0, len(chunks))
+	for i, chunk := range chunks {
+		patch, err := parsePatch(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i+1, err)
+		}
+		patches = append(patches, *patch)
+	}
+	return patches, nil
+}
+
+// parsePatch parses a single mbox entry.
+func parsePatch(raw string) (*Patch, error) {
+	firstLine, rest, _ := strings.Cut(raw, "\n")
+	matches := mboxFromLine.FindStringSubmatch(firstLine)
+	if matches == nil {
+		return nil, fmt.Errorf("missing mbox separator")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(rest))
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch headers: %w", err)
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch body: %w", err)
+	}
+
+	patch := &Patch{
+		SHA: matches[1],
+		Raw: raw,
+	}
+
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		patch.Author = from.Name
+		patch.AuthorEmail = from.Address
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		patch.AuthorDate = date
+	}
+
+	subject := msg.Header.Get("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	patch.Subject = patchSubjectPrefix.ReplaceAllString(subject, "")
+
+	// The commit message body ends at the first "---" line; the diffstat and
+	// the diff follow it, and the diff ends at the "-- " signature line.
+	text := string(body)
+	description, diffPart, _ := strings.Cut(text, "\n---\n")
+	if strings.HasPrefix(text, "---\n") {
+		description, diffPart = "", strings.TrimPrefix(text, "---\n")
+	}
+	if idx := strings.Index(diffPart, "diff --git "); idx >= 0 {
+		diffPart = diffPart[idx:]
+	} else {
+		diffPart = ""
+	}
+	if idx := strings.LastIndex(diffPart, "\n-- \n"); idx >= 0 {
+		diffPart = diffPart[:idx+1]
+	}
+
+	patch.Message = patch.Subject
+	if description = strings.TrimSpace(description); description != "" {
+		patch.Message += "\n\n" + description
+	}
+	patch.Files = parseDiff(diffPart)
+	if patch.Files == nil {
+		patch.Files = []FileDiff{}
+	}
+
+	return patch, nil
+}
+
+// selectedFiles returns the paths in a patch that are not excluded by the selection.
+func (s PatchSelection) selectedFiles(patch Patch) []string {
+	excluded := make(map[string]bool)
+	for _, path := range s.ExcludeFiles[patch.SHA] {
+		excluded[path] = true
+	}
+	var files []string
+	for _, f := range patch.Files {
+		if !excluded[f.Path] {
+			files = append(files, f.Path)
+		}
+	}
+	return files
+}
+
+// excludesCommit reports whether the selection drops a commit entirely.
+func (s PatchSelection) excludesCommit(sha string) bool {
+	for _, excluded := range s.ExcludeCommits {
+		if excluded == sha {
+			return true
+		}
+	}
+	return false
+}
+
+// Rejected returns the commits that are dropped entirely and, for the remaining
+// commits, the files that are dropped. This is used to report back to the agent.
+func (s PatchSelection) Rejected(patches []Patch) (commits []Patch, files map[string][]string) {
+	files = make(map[string][]string)
+	for _, patch := range patches {
+		if s.excludesCommit(patch.SHA) || len(s.selectedFiles(patch)) == 0 {
+			commits = append(commits, patch)
+			continue
+		}
+		for _, path := range s.ExcludeFiles[patch.SHA] {
+			for _, f := range patch.Files {
+				if f.Path == path {
+					files[patch.SHA] = append(files[patch.SHA], path)
+					break
+				}
+			}
+		}
+	}
+	return commits, files
+}
@@ -0,0 +1,304 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createPatchSeries sets up a workspace and a three-commit patch series on top of it.
+// Returns the provider, workspace work dir, base commit, and format-patch output.
+func createPatchSeries(t *testing.T) (*LocalProvider, string, string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	provider, _ := NewLocalProvider(t.TempDir())
+	sourceRepo := createTestRepo(t)
+
+	workDir, _, err := provider.EnsureWorkspace(ctx, "project1", "ws1", sourceRepo, "")
+	if err != nil {
+		t.Fatalf("EnsureWorkspace failed: %v", err)
+	}
+	runGit(t, workDir, "config", "user.email", "committer@example.com")
+	runGit(t, workDir, "config", "user.name", "Test Committer")
+	baseCommit := strings.TrimSpace(runGit(t, workDir, "rev-parse", "HEAD"))
+
+	patchRepo := t.TempDir()
+	runGit(t, patchRepo, "init")
+	runGit(t, patchRepo, "config", "user.email", "patch@example.com")
+	runGit(t, patchRepo, "config", "user.name", "Patch Author")
+	runGit(t, patchRepo, "fetch", workDir, "HEAD")
+	runGit(t, patchRepo, "reset", "--hard", "FETCH_HEAD")
+
+	commits := []struct {
+		files   map[string]string
+		message string
+	}{
+		{map[string]string{"a.txt": "a\n"}, "Add a\n\nFirst file."},
+		{map[string]string{"b.txt": "b\n", "secret.txt": "do not commit\n"}, "Add b"},
+		{map[string]string{"c.txt": "c\n"}, "Add c"},
+	}
+	for _, c := range commits {
+		for name, content := range c.files {
+			if err := os.WriteFile(filepath.Join(patchRepo, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+		runGit(t, patchRepo, "add", ".")
+		runGit(t, patchRepo, "commit", "-m", c.message)
+	}
+
+	patches := runGit(t, patchRepo, "format-patch", "--stdout", baseCommit+"..HEAD")
+	return provider, workDir, baseCommit, patches
+}
+
+func TestParsePatches(t *testing.T) {
+	_, _, _, mbox := createPatchSeries(t)
+
+	patches, err := ParsePatches(mbox)
+	if err != nil {
+		t.Fatalf("ParsePatches failed: %v", err)
+	}
+	if len(patches) != 3 {
+		t.Fatalf("Expected 3 patches, got %d", len(patches))
+	}
+
+	first := patches[0]
+	if len(first.SHA) != 40 {
+		t.Errorf("Expected full SHA, got %q", first.SHA)
+	}
+	if first.Subject != "Add a" {
+		t.Errorf("Expected subject 'Add a', got %q", first.Subject)
+	}
+	if first.Message != "Add a\n\nFirst file." {
+		t.Errorf("Unexpected message: %q", first.Message)
+	}
+	if first.Author != "Patch Author" || first.AuthorEmail != "patch@example.com" {
+		t.Errorf("Unexpected author: %s <%s>", first.Author, first.AuthorEmail)
+	}
+	if first.AuthorDate.IsZero() {
+		t.Error("Expected author date to be set")
+	}
+	if len(first.Files) != 1 || first.Files[0].Path != "a.txt" || first.Files[0].Status != "added" {
+		t.Errorf("Unexpected files: %+v", first.Files)
+	}
+
+	if len(patches[1].Files) != 2 {
+		t.Errorf("Expected 2 files in second patch, got %d", len(patches[1].Files))
+	}
+
+	t.Run("empty input", func(t *testing.T) {
+		patches, err := ParsePatches("")
+		if err != nil {
+			t.Fatalf("ParsePatches failed: %v", err)
+		}
+		if len(patches) != 0 {
+			t.Errorf("Expected no patches, got %d", len(patches))
+		}
+	})
+}
+
+func TestPatchSelectionRejected(t *testing.T) {
+	_, _, _, mbox := createPatchSeries(t)
+	patches, err := ParsePatches(mbox)
+	if err != nil {
+		t.Fatalf("ParsePatches failed: %v", err)
+	}
+
+	selection := PatchSelection{
+		ExcludeCommits: []string{patches[2].SHA},
+		ExcludeFiles: map[string][]string{
+			patches[0].SHA: {"a.txt"},
+			patches[1].SHA: {"secret.txt", "missing.txt"},
+		},
+	}
+
+	commits, files := selection.Rejected(patches)
+	if len(commits) != 2 || commits[0].SHA != patches[0].SHA || commits[1].SHA != patches[2].SHA {
+		t.Errorf("Expected first commit (all files excluded) and third commit rejected, got %+v", commits)
+	}
+	if got := files[patches[1].SHA]; len(got) != 1 || got[0] != "secret.txt" {
+		t.Errorf("Expected secret.txt rejected from second commit, got %v", got)
+	}
+}
+
+func TestSelectPatches(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("excludes commits and files", func(t *testing.T) {
+		provider, workDir, baseCommit, mbox := createPatchSeries(t)
+		patches, _ := ParsePatches(mbox)
+
+		selection := PatchSelection{
+			ExcludeCommits: []string{patches[0].SHA},
+			ExcludeFiles:   map[string][]string{patches[1].SHA: {"secret.txt"}},
+		}
+		selected, err := provider.SelectPatches(ctx, "ws1", baseCommit, []byte(mbox), selection)
+		if err != nil {
+			t.Fatalf("SelectPatches failed: %v", err)
+		}
+
+		result, err := ParsePatches(string(selected))
+		if err != nil {
+			t.Fatalf("ParsePatches failed: %v", err)
+		}
+		if len(result) != 2 {
+			t.Fatalf("Expected 2 patches, got %d", len(result))
+		}
+		if result[0].Subject != "Add b" || result[1].Subject != "Add c" {
+			t.Errorf("Unexpected subjects: %q, %q", result[0].Subject, result[1].Subject)
+		}
+		if len(result[0].Files) != 1 || result[0].Files[0].Path != "b.txt" {
+			t.Errorf("Expected only b.txt in first patch, got %+v", result[0].Files)
+		}
+		if result[0].Author != "Patch Author" {
+			t.Errorf("Expected author to be preserved, got %q", result[0].Author)
+		}
+
+		// The workspace itself must be untouched
+		head := strings.TrimSpace(runGit(t, workDir, "rev-parse", "HEAD"))
+		if head != baseCommit {
+			t.Errorf("Workspace HEAD moved from %s to %s", baseCommit, head)
+		}
+		if worktrees := runGit(t, workDir, "worktree", "list"); strings.Count(worktrees, "\n") != 1 {
+			t.Errorf("Expected temporary worktree to be removed, got:\n%s", worktrees)
+		}
+	})
+
+	t.Run("edits commit messages", func(t *testing.T) {
+		provider, _, baseCommit, mbox := createPatchSeries(t)
+		patches, _ := ParsePatches(mbox)
+
+		selection := PatchSelection{
+			Messages: map[string]string{patches[1].SHA: "Add b file\n\nReworded by reviewer."},
+		}
+		selected, err := provider.SelectPatches(ctx, "ws1", baseCommit, []byte(mbox), selection)
+		if err != nil {
+			t.Fatalf("SelectPatches failed: %v", err)
+		}
+
+		result, _ := ParsePatches(string(selected))
+		if len(result) != 3 {
+			t.Fatalf("Expected 3 patches, got %d", len(result))
+		}
+		if result[1].Message != "Add b file\n\nReworded by reviewer." {
+			t.Errorf("Unexpected message: %q", result[1].Message)
+		}
+		if result[0].Message != "Add a\n\nFirst file." {
+			t.Errorf("Expected unedited message to be preserved, got %q", result[0].Message)
+		}
+	})
+
+	t.Run("squashes commits", func(t *testing.T) {
+		provider, _, baseCommit, mbox := createPatchSeries(t)
+
+		selection := PatchSelection{Squash: true, SquashMessage: "Add a, b and c"}
+		selected, err := provider.SelectPatches(ctx, "ws1", baseCommit, []byte(mbox), selection)
+		if err != nil {
+			t.Fatalf("SelectPatches failed: %v", err)
+		}
+
+		result, _ := ParsePatches(string(selected))
+		if len(result) != 1 {
+			t.Fatalf("Expected 1 squashed patch, got %d", len(result))
+		}
+		if result[0].Subject != "Add a, b and c" {
+			t.Errorf("Unexpected subject: %q", result[0].Subject)
+		}
+		if len(result[0].Files) != 4 {
+			t.Errorf("Expected 4 files in squashed patch, got %d", len(result[0].Files))
+		}
+		if result[0].Author != "Patch Author" {
+			t.Errorf("Expected first commit's author, got %q", result[0].Author)
+		}
+
+		// The squashed series must apply to the workspace
+		if _, err := provider.ApplyPatches(ctx, "ws1", selected); err != nil {
+			t.Fatalf("ApplyPatches failed: %v", err)
+		}
+	})
+
+	t.Run("returns ErrNoPatches when everything is excluded", func(t *testing.T) {
+		provider, _, baseCommit, mbox := createPatchSeries(t)
+		patches, _ := ParsePatches(mbox)
+
+		selection := PatchSelection{ExcludeCommits: []string{patches[0].SHA, patches[1].SHA, patches[2].SHA}}
+		_, err := provider.SelectPatches(ctx, "ws1", baseCommit, []byte(mbox), selection)
+		if !errors.Is(err, ErrNoPatches) {
+			t.Errorf("Expected ErrNoPatches, got %v", err)
+		}
+	})
+}
+
+func TestComparePatches(t *testing.T) {
+	ctx := context.Background()
+	provider, workDir, baseCommit, mbox := createPatchSeries(t)
+
+	// A second series from the same base that makes overlapping changes
+	otherRepo := t.TempDir()
+	runGit(t, otherRepo, "init")
+	runGit(t, otherRepo, "config", "user.email", "other@example.com")
+	runGit(t, otherRepo, "config", "user.name", "Other Author")
+	runGit(t, otherRepo, "fetch", workDir, "HEAD")
+	runGit(t, otherRepo, "reset", "--hard", "FETCH_HEAD")
+	for name, content := range map[string]string{"a.txt": "a\n", "b.txt": "different b\n"} {
+		if err := os.WriteFile(filepath.Join(otherRepo, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	runGit(t, otherRepo, "add", ".")
+	runGit(t, otherRepo, "commit", "-m", "Add a and b")
+	other := runGit(t, otherRepo, "format-patch", "--stdout", baseCommit+"..HEAD")
+
+	t.Run("diffs results of two series", func(t *testing.T) {
+		files, err := provider.ComparePatches(ctx, "ws1",
+			PatchSeries{BaseCommit: baseCommit, Patches: []byte(mbox)},
+			PatchSeries{BaseCommit: baseCommit, Patches: []byte(other)})
+		if err != nil {
+			t.Fatalf("ComparePatches failed: %v", err)
+		}
+
+		statuses := make(map[string]string)
+		for _, f := range files {
+			statuses[f.Path] = f.Status
+		}
+		// a.txt is identical in both; b.txt differs; c.txt and secret.txt only exist in the first
+		want := map[string]string{"b.txt": "modified", "c.txt": "deleted", "secret.txt": "deleted"}
+		if len(statuses) != len(want) {
+			t.Fatalf("Expected %d files, got %v", len(want), statuses)
+		}
+		for path, status := range want {
+			if statuses[path] != status {
+				t.Errorf("Expected %s to be %s, got %q", path, status, statuses[path])
+			}
+		}
+	})
+
+	t.Run("empty series compares against base", func(t *testing.T) {
+		files, err := provider.ComparePatches(ctx, "ws1",
+			PatchSeries{BaseCommit: baseCommit},
+			PatchSeries{BaseCommit: baseCommit, Patches: []byte(other)})
+		if err != nil {
+			t.Fatalf("ComparePatches failed: %v", err)
+		}
+		if len(files) != 2 {
+			t.Errorf("Expected 2 added files, got %+v", files)
+		}
+
+		if head := strings.TrimSpace(runGit(t, workDir, "rev-parse", "HEAD")); head != baseCommit {
+			t.Errorf("Workspace HEAD moved from %s to %s", baseCommit, head)
+		}
+	})
+
+	t.Run("fails when a series does not apply", func(t *testing.T) {
+		_, err := provider.ComparePatches(ctx, "ws1",
+			PatchSeries{BaseCommit: baseCommit, Patches: []byte(mbox)},
+			PatchSeries{BaseCommit: baseCommit, Patches: []byte(mbox + other)})
+		if err == nil {
+			t.Error("Expected error for conflicting series")
+		}
+	})
+}
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/middleware"
 	"github.com/obot-platform/discobot/server/internal/providers"
 )
@@ -168,7 +168,7 @@ func (h *Handler) CreateAgent(w http.ResponseWriter, r *http.Request) {
 
 	agent, err := h.agentService.CreateAgent(r.Context(), projectID, req.AgentType)
 	if err != nil {
-		log.Printf("Failed to create agent: %v", err)
+		logging.FromContext(r.Context()).Error("failed to create agent", "error", err)
 		h.Error(w, http.StatusInternalServerError, "Failed to create agent")
 		return
 	}
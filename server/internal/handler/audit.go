@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/service"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// audit records a security-relevant action performed by the caller of r.
+func (h *Handler) audit(r *http.Request, action, targetType, targetID string, metadata map[string]any) {
+	ctx := r.Context()
+	h.auditService.Record(ctx, service.AuditEntry{
+		ProjectID:  middleware.GetProjectID(ctx),
+		ActorID:    middleware.GetUserID(ctx),
+		ActorEmail: middleware.GetUserEmail(ctx),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         middleware.ClientIP(r),
+		UserAgent:  r.UserAgent(),
+		Metadata:   metadata,
+	})
+}
+
+// ListAuditLogs returns a page of the project's audit log, newest first.
+// GET /api/projects/{projectId}/audit-logs?action=...&actorId=...&since=...&until=...&limit=...
+func (h *Handler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditLogFilter(r)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := h.auditService.List(r.Context(), filter)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list audit logs")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// ExportAuditLogs downloads the project's audit log as JSONL or CSV.
+// GET /api/projects/{projectId}/audit-logs/export?format=jsonl|csv&...
+func (h *Handler) ExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditLogFilter(r)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	var contentType string
+	switch format {
+	case "jsonl":
+		contentType = "application/x-ndjson"
+	case "csv":
+		contentType = "text/csv"
+	default:
+		h.Error(w, http.StatusBadRequest, "format must be jsonl or csv")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-log-%s.%s"`, filter.ProjectID, format))
+	if err := h.auditService.Export(r.Context(), w, format, filter); err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to export audit logs")
+	}
+}
+
+// auditLogFilter builds an audit log filter from the request's project and
+// query parameters.
+func auditLogFilter(r *http.Request) (store.AuditLogFilter, error) {
+	query := r.URL.Query()
+	filter := store.AuditLogFilter{
+		ProjectID: middleware.GetProjectID(r.Context()),
+		ActorID:   query.Get("actorId"),
+		Action:    query.Get("action"),
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*dst = t
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -27,8 +27,10 @@ func (h *Handler) AuthLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Store state in cookie
+	// Store state and PKCE verifier in cookies
+	verifier := service.GeneratePKCEVerifier()
 	h.setStateCookie(w, state)
+	h.setOAuthCookie(w, verifierCookieName, verifier)
 
 	// Build redirect URL
 	scheme := "http"
@@ -38,7 +40,7 @@ func (h *Handler) AuthLogin(w http.ResponseWriter, r *http.Request) {
 	redirectURL := fmt.Sprintf("%s://%s/auth/callback/%s", scheme, r.Host, provider)
 
 	// Get authorization URL
-	authURL, err := h.authService.GetAuthURL(provider, redirectURL, state)
+	authURL, err := h.authService.GetAuthURL(r.Context(), provider, redirectURL, state, verifier)
 	if err != nil {
 		h.Error(w, http.StatusBadRequest, err.Error())
 		return
@@ -60,6 +62,7 @@ func (h *Handler) AuthCallback(w http.ResponseWriter, r *http.Request) {
 	// Verify state
 	state := r.URL.Query().Get("state")
 	savedState := h.getStateCookie(w, r)
+	verifier := h.takeOAuthCookie(w, r, verifierCookieName)
 	if state == "" || state != savedState {
 		h.Error(w, http.StatusBadRequest, "Invalid state parameter")
 		return
@@ -87,7 +90,7 @@ func (h *Handler) AuthCallback(w http.ResponseWriter, r *http.Request) {
 	redirectURL := fmt.Sprintf("%s://%s/auth/callback/%s", scheme, r.Host, provider)
 
 	// Exchange code for user info
-	providerUser, err := h.authService.ExchangeCode(r.Context(), provider, redirectURL, code)
+	providerUser, err := h.authService.ExchangeCode(r.Context(), provider, redirectURL, code, state, verifier)
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, fmt.Sprintf("Failed to exchange code: %v", err))
 		return
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// ListBatchRuns returns all batch runs for a project
+func (h *Handler) ListBatchRuns(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	runs, err := h.batchRunService.ListBatchRuns(r.Context(), projectID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list batch runs")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"batchRuns": runs})
+}
+
+// CreateBatchRun creates a batch run and enqueues one session per matrix combination
+func (h *Handler) CreateBatchRun(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	var req service.CreateBatchRunRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	run, err := h.batchRunService.CreateBatchRun(r.Context(), projectID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBatchRun) {
+			h.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logging.FromContext(r.Context()).Error("failed to create batch run", "error", err)
+		h.Error(w, http.StatusInternalServerError, "Failed to create batch run")
+		return
+	}
+
+	h.JSON(w, http.StatusCreated, run)
+}
+
+// GetBatchRun returns a batch run with its items and aggregate status
+func (h *Handler) GetBatchRun(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	batchRunID := chi.URLParam(r, "batchRunId")
+
+	run, err := h.batchRunService.GetBatchRun(r.Context(), projectID, batchRunID)
+	if err != nil {
+		h.batchRunError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, run)
+}
+
+// CancelBatchRun cancels the items of a batch run that have not started
+func (h *Handler) CancelBatchRun(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	batchRunID := chi.URLParam(r, "batchRunId")
+
+	run, err := h.batchRunService.CancelBatchRun(r.Context(), projectID, batchRunID)
+	if err != nil {
+		h.batchRunError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, run)
+}
+
+// batchRunError maps batch run service errors to HTTP responses.
+func (h *Handler) batchRunError(w http.ResponseWriter, r *http.Request, err error) {
+	if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not belong") {
+		h.Error(w, http.StatusNotFound, "Batch run not found")
+		return
+	}
+	logging.FromContext(r.Context()).Error("batch run request failed", "error", err)
+	h.Error(w, http.StatusInternalServerError, err.Error())
+}
@@ -5,10 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/metrics"
 	"github.com/obot-platform/discobot/server/internal/middleware"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
@@ -68,6 +69,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sessionID := req.ID
+	logging.Add(ctx, "session_id", sessionID)
 
 	// Check if session exists
 	existingSession, err := h.chatService.GetSessionByID(ctx, sessionID)
@@ -123,6 +125,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
 	w.Header().Set("x-vercel-ai-ui-message-stream", "v1")
+	defer metrics.TrackSSE("chat")()
 
 	// Create a context that won't be cancelled when the client disconnects.
 	// This ensures sandbox creation and message sending complete even if the
@@ -148,6 +151,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	// Only reset session status to "ready" when it actually finishes.
 	// If the client disconnects early, status stays "running" because
 	// the sandbox is still processing the completion.
+	logger := logging.FromContext(ctx)
 	completionDone := false
 	defer func() {
 		streamCancel()
@@ -155,10 +159,10 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 			// Reset session status to ready after chat completion
 			// Use sendCtx (not request ctx) since the request may already be cancelled
 			if _, err := h.sessionService.UpdateStatus(sendCtx, projectID, sessionID, model.SessionStatusReady, nil); err != nil {
-				log.Printf("[Chat] Warning: failed to reset session %s status to ready: %v", sessionID, err)
+				logger.Warn("failed to reset session status to ready", "error", err)
 			}
 		} else {
-			log.Printf("[Chat] Client disconnected before completion finished for session %s, status remains running", sessionID)
+			logger.Info("client disconnected before completion finished, status remains running")
 		}
 	}()
 
@@ -173,7 +177,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		select {
 		case <-ctx.Done():
 			// Client disconnected
-			log.Printf("[Chat] Client disconnected, stopping SSE stream")
+			logger.Debug("client disconnected, stopping SSE stream")
 			return
 		case line, ok := <-sseCh:
 			if !ok {
@@ -186,14 +190,14 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 			if line.Done {
 				// Container sent [DONE] signal
 				completionDone = true
-				log.Printf("[Chat] Received [DONE] signal from sandbox")
+				logger.Debug("received [DONE] signal from sandbox")
 				_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
 				flusher.Flush()
 				return
 			}
 			// Log error events for debugging
 			if strings.Contains(line.Data, `"type":"error"`) {
-				log.Printf("[Chat] Passing through error event: %s", line.Data)
+				logger.Warn("passing through error event", "data", line.Data)
 			}
 			// Check for 'start' event — may carry messageMetadata.model (the actual model used)
 			if strings.Contains(line.Data, `"type":"start"`) {
@@ -208,9 +212,9 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 					modelID := startEvent.MessageMetadata.Model
 					go func() {
 						if err := h.chatService.UpdateSessionModel(sendCtx, sessionID, modelID); err != nil {
-							log.Printf("[Chat] Warning: failed to update session model for %s: %v", sessionID, err)
+							logger.Warn("failed to update session model", "error", err)
 						} else {
-							log.Printf("[Chat] Updated session %s with actual model: %s", sessionID, modelID)
+							logger.Info("updated session with actual model", "model", modelID)
 						}
 					}()
 				}
@@ -251,7 +255,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 	sseCh, err := h.chatService.GetStream(ctx, projectID, sessionID)
 	if err != nil {
 		// Sandbox unavailable or error - return 204 (no active stream)
-		log.Printf("[ChatStream] Error getting stream: %v", err)
+		logging.FromContext(ctx).Debug("no active stream", "error", err)
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
@@ -276,7 +280,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 	// handles resetting back to ready once the agent-api completion finishes.
 	if existingSession.Status != model.SessionStatusRunning {
 		if _, err := h.sessionService.UpdateStatus(ctx, projectID, sessionID, model.SessionStatusRunning, nil); err != nil {
-			log.Printf("[ChatStream] Warning: failed to update session %s status to running: %v", sessionID, err)
+			logging.FromContext(ctx).Warn("failed to update session status to running", "error", err)
 		}
 	}
 
@@ -286,6 +290,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 	w.Header().Set("x-vercel-ai-ui-message-stream", "v1")
+	defer metrics.TrackSSE("chat_resume")()
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -296,7 +301,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 	// Send the first message if we consumed one during the check
 	if firstLine != nil {
 		if firstLine.Done {
-			log.Printf("[ChatStream] Received [DONE] signal from sandbox (first line)")
+			logging.FromContext(ctx).Debug("received [DONE] signal from sandbox (first line)")
 			_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
 			flusher.Flush()
 			return
@@ -310,7 +315,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 		select {
 		case <-ctx.Done():
 			// Client disconnected
-			log.Printf("[ChatStream] Client disconnected, stopping SSE stream")
+			logging.FromContext(ctx).Debug("client disconnected, stopping SSE stream")
 			return
 		case line, ok := <-sseCh:
 			if !ok {
@@ -320,7 +325,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			if line.Done {
-				log.Printf("[ChatStream] Received [DONE] signal from sandbox")
+				logging.FromContext(ctx).Debug("received [DONE] signal from sandbox")
 				_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
 				flusher.Flush()
 				return
@@ -353,7 +358,7 @@ func (h *Handler) ChatQuestion(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.chatService.GetQuestion(ctx, projectID, sessionID, toolUseID)
 	if err != nil {
-		log.Printf("[ChatQuestion] Error: %v", err)
+		logging.FromContext(ctx).Error("failed to get pending question", "error", err)
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -394,7 +399,7 @@ func (h *Handler) ChatAnswer(w http.ResponseWriter, r *http.Request) {
 			h.Error(w, http.StatusNotFound, "no pending question for this toolUseID")
 			return
 		}
-		log.Printf("[ChatAnswer] Error: %v", err)
+		logging.FromContext(ctx).Error("failed to answer question", "error", err)
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -450,7 +455,7 @@ func (h *Handler) ChatCancel(w http.ResponseWriter, r *http.Request) {
 	// Update session status to ready after successful cancellation
 	// UpdateStatus now automatically publishes SSE event
 	if _, err := h.sessionService.UpdateStatus(ctx, projectID, sessionID, model.SessionStatusReady, nil); err != nil {
-		log.Printf("[ChatCancel] Warning: failed to reset session %s status to ready: %v", sessionID, err)
+		logging.FromContext(ctx).Warn("failed to reset session status to ready", "error", err)
 	}
 
 	h.JSON(w, http.StatusOK, result)
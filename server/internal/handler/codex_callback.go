@@ -3,16 +3,19 @@ package handler
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/oauth"
 	"github.com/obot-platform/discobot/server/internal/service"
 )
 
+// codexCallbackLog is the logger for the Codex OAuth callback server.
+var codexCallbackLog = logging.Component("codex-callback")
+
 const (
 	codexCallbackPort = 1455
 	codexCallbackAddr = "127.0.0.1:1455"
@@ -59,7 +62,7 @@ func (s *CodexCallbackServer) Start() bool {
 	// Try to listen on the port
 	listener, err := net.Listen("tcp", codexCallbackAddr)
 	if err != nil {
-		log.Printf("Codex callback server: could not listen on %s: %v (manual code entry will be required)", codexCallbackAddr, err)
+		codexCallbackLog.Warn("could not listen, manual code entry will be required", "addr", codexCallbackAddr, "error", err)
 		return false
 	}
 
@@ -81,9 +84,9 @@ func (s *CodexCallbackServer) Start() bool {
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Codex callback server listening on %s", codexCallbackAddr)
+		codexCallbackLog.Info("Codex callback server listening", "addr", codexCallbackAddr)
 		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			log.Printf("Codex callback server error: %v", err)
+			codexCallbackLog.Error("Codex callback server failed", "error", err)
 		}
 	}()
 
@@ -112,7 +115,7 @@ func (s *CodexCallbackServer) Stop() {
 	}
 
 	s.running = false
-	log.Printf("Codex callback server stopped")
+	codexCallbackLog.Info("Codex callback server stopped")
 }
 
 // RegisterPending registers a pending OAuth session
@@ -9,6 +9,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/oauth"
 	"github.com/obot-platform/discobot/server/internal/service"
 )
@@ -71,6 +72,7 @@ func (h *Handler) CreateCredential(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		h.audit(r, model.AuditActionCredentialCreate, "credential", req.Provider, map[string]any{"authType": service.AuthTypeAPIKey})
 		h.JSON(w, http.StatusOK, info)
 		return
 	}
@@ -106,6 +108,7 @@ func (h *Handler) DeleteCredential(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionCredentialDelete, "credential", provider, nil)
 	h.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -124,6 +127,8 @@ func (h *Handler) RefreshCredential(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionCredentialRefresh, "credential", provider, nil)
+
 	// Return success response with new expiration time
 	response := map[string]any{
 		"success":   true,
@@ -217,6 +222,8 @@ func (h *Handler) AnthropicExchange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionCredentialCreate, "credential", service.ProviderAnthropic, map[string]any{"authType": service.AuthTypeOAuth})
+
 	// Return success response with credential info
 	response := map[string]any{
 		"success":    true,
@@ -382,6 +389,8 @@ func (h *Handler) GitHubCopilotPoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionCredentialCreate, "credential", service.ProviderGitHubCopilot, map[string]any{"authType": service.AuthTypeOAuth})
+
 	h.JSON(w, http.StatusOK, map[string]any{
 		"status":     "success",
 		"credential": info,
@@ -477,6 +486,8 @@ func (h *Handler) CodexExchange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionCredentialCreate, "credential", service.ProviderCodex, map[string]any{"authType": service.AuthTypeOAuth})
+
 	// Return credential info with token expiration
 	response := map[string]any{
 		"success":    true,
@@ -2,13 +2,16 @@ package handler
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"net/http/httputil"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
+// debugDockerLog is the logger for the debug Docker proxy.
+var debugDockerLog = logging.Component("debug-docker")
+
 // DebugDockerServer runs a standalone HTTP server that proxies Docker API requests
 // to the Docker daemon inside a VZ VM. This allows using standard Docker CLI:
 //
@@ -47,8 +50,8 @@ func NewDebugDockerServer(sandboxManager *sandbox.Manager, projectID string, por
 			provider:  proxyProvider,
 			projectID: projectID,
 		},
-		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
-			log.Printf("Debug Docker proxy error: %v", err)
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			debugDockerLog.WarnContext(r.Context(), "proxy error", "error", err)
 			http.Error(w, err.Error(), http.StatusBadGateway)
 		},
 	}
@@ -65,10 +68,10 @@ func NewDebugDockerServer(sandboxManager *sandbox.Manager, projectID string, por
 // Start starts the debug Docker proxy server in the background.
 func (s *DebugDockerServer) Start() {
 	go func() {
-		log.Printf("Debug Docker proxy listening on %s (project: %s)", s.server.Addr, s.projectID)
-		log.Printf("  Usage: DOCKER_HOST=tcp://localhost%s docker ps", s.server.Addr)
+		debugDockerLog.Info("debug Docker proxy listening", "addr", s.server.Addr, "project_id", s.projectID,
+			"usage", "DOCKER_HOST=tcp://localhost"+s.server.Addr+" docker ps")
 		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Debug Docker proxy error: %v", err)
+			debugDockerLog.Error("debug Docker proxy failed", "error", err)
 		}
 	}()
 }
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/metrics"
 )
 
 // Events handles SSE event streaming for a project.
@@ -39,6 +41,7 @@ func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	defer metrics.TrackSSE("events")()
 
 	// Subscribe to events for this project BEFORE sending historical events
 	// This ensures we don't miss any events between fetching history and subscribing
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -9,7 +10,9 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+	"github.com/obot-platform/discobot/server/internal/service"
 )
 
 // Suggestion represents an autocomplete suggestion
@@ -261,6 +264,8 @@ func (h *Handler) WriteSessionFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionFileWrite, "session", sessionID, map[string]any{"path": req.Path})
+
 	h.JSON(w, http.StatusOK, result)
 }
 
@@ -301,6 +306,8 @@ func (h *Handler) DeleteSessionFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionFileDelete, "session", sessionID, map[string]any{"path": req.Path})
+
 	h.JSON(w, http.StatusOK, result)
 }
 
@@ -345,6 +352,8 @@ func (h *Handler) RenameSessionFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionFileRename, "session", sessionID, map[string]any{"oldPath": req.OldPath, "newPath": req.NewPath})
+
 	h.JSON(w, http.StatusOK, result)
 }
 
@@ -377,3 +386,28 @@ func (h *Handler) GetSessionDiff(w http.ResponseWriter, r *http.Request) {
 
 	h.JSON(w, http.StatusOK, result)
 }
+
+// CompareSessions compares the changes, hook results and token usage of two sessions.
+// GET /api/projects/{projectId}/sessions/{sessionId}/compare/{otherSessionId}?mode=worktree|commits
+func (h *Handler) CompareSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+	sessionID := chi.URLParam(r, "sessionId")
+	otherSessionID := chi.URLParam(r, "otherSessionId")
+
+	mode := r.URL.Query().Get("mode")
+
+	result, err := h.chatService.CompareSessions(ctx, projectID, sessionID, otherSessionID, mode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not belong") {
+			status = http.StatusNotFound
+		} else if errors.Is(err, service.ErrCompareWorkspaceMismatch) || strings.Contains(err.Error(), "invalid compare mode") {
+			status = http.StatusBadRequest
+		}
+		h.Error(w, status, err.Error())
+		return
+	}
+
+	h.JSON(w, http.StatusOK, result)
+}
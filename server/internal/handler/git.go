@@ -6,6 +6,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/model"
 )
 
 // GetWorkspaceGitStatus returns the git status for a workspace
@@ -301,6 +302,7 @@ func (h *Handler) CommitWorkspace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionWorkspaceCommit, "workspace", workspaceID, map[string]any{"message": req.Message})
 	h.JSON(w, http.StatusCreated, commit)
 }
 
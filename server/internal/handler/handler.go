@@ -8,6 +8,7 @@ import (
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/git"
 	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/notify"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/service"
 	"github.com/obot-platform/discobot/server/internal/startup"
@@ -15,8 +16,9 @@ import (
 )
 
 const (
-	sessionCookieName = "discobot_session"
-	stateCookieName   = "discobot_oauth_state"
+	sessionCookieName  = "discobot_session"
+	stateCookieName    = "discobot_oauth_state"
+	verifierCookieName = "discobot_oauth_verifier"
 )
 
 // Handler contains all HTTP handlers
@@ -37,6 +39,12 @@ type Handler struct {
 	workspaceService    *service.WorkspaceService
 	projectService      *service.ProjectService
 	preferenceService   *service.PreferenceService
+	batchRunService     *service.BatchRunService
+	scheduleService     *service.ScheduleService
+	headlessRunService  *service.HeadlessRunService
+	tokenService        *service.TokenService
+	shareService        *service.ShareService
+	auditService        *service.AuditService
 	jobQueue            *jobs.Queue
 	eventBroker         *events.Broker
 	codexCallbackServer *CodexCallbackServer
@@ -83,7 +91,10 @@ func New(s *store.Store, cfg *config.Config, gitProvider git.Provider, sandboxPr
 	agentSvc := service.NewAgentService(s)
 	workspaceSvc := service.NewWorkspaceService(s, gitProvider, eventBroker)
 	projectSvc := service.NewProjectService(s, sandboxProvider)
+	projectSvc.SetNotifier(notify.New(cfg))
 	preferenceSvc := service.NewPreferenceService(s)
+	batchRunSvc := service.NewBatchRunService(s, sessionSvc, chatSvc, eventBroker, jobQueue)
+	scheduleSvc := service.NewScheduleService(s, sessionSvc, chatSvc, eventBroker, jobQueue)
 
 	// Convert agentTypes for models service
 	serviceAgentTypes := make([]service.AgentType, len(agentTypes))
@@ -97,25 +108,31 @@ func New(s *store.Store, cfg *config.Config, gitProvider git.Provider, sandboxPr
 	modelsSvc := service.NewModelsService(s, agentSvc, credSvc, sandboxSvc, serviceAgentTypes)
 
 	h := &Handler{
-		store:             s,
-		cfg:               cfg,
-		authService:       service.NewAuthService(s, cfg),
-		credentialService: credSvc,
-		gitService:        gitSvc,
-		gitProvider:       gitProvider,
-		sandboxProvider:   sandboxProvider,
-		sandboxManager:    sandboxManager,
-		sandboxService:    sandboxSvc,
-		sessionService:    sessionSvc,
-		chatService:       chatSvc,
-		agentService:      agentSvc,
-		modelsService:     modelsSvc,
-		workspaceService:  workspaceSvc,
-		projectService:    projectSvc,
-		preferenceService: preferenceSvc,
-		jobQueue:          jobQueue,
-		eventBroker:       eventBroker,
-		systemManager:     systemManager,
+		store:              s,
+		cfg:                cfg,
+		authService:        service.NewAuthService(s, cfg),
+		credentialService:  credSvc,
+		gitService:         gitSvc,
+		gitProvider:        gitProvider,
+		sandboxProvider:    sandboxProvider,
+		sandboxManager:     sandboxManager,
+		sandboxService:     sandboxSvc,
+		sessionService:     sessionSvc,
+		chatService:        chatSvc,
+		agentService:       agentSvc,
+		modelsService:      modelsSvc,
+		workspaceService:   workspaceSvc,
+		projectService:     projectSvc,
+		preferenceService:  preferenceSvc,
+		batchRunService:    batchRunSvc,
+		scheduleService:    scheduleSvc,
+		headlessRunService: service.NewHeadlessRunService(s, sessionSvc, chatSvc, jobQueue, cfg.DispatcherJobTimeout),
+		tokenService:       service.NewTokenService(s),
+		shareService:       service.NewShareService(s),
+		auditService:       service.NewAuditService(s),
+		jobQueue:           jobQueue,
+		eventBroker:        eventBroker,
+		systemManager:      systemManager,
 	}
 
 	// Create Codex callback server (will be started on first use)
@@ -196,9 +213,19 @@ func (h *Handler) getSessionToken(r *http.Request) string {
 
 // setStateCookie sets the OAuth state cookie
 func (h *Handler) setStateCookie(w http.ResponseWriter, state string) {
+	h.setOAuthCookie(w, stateCookieName, state)
+}
+
+// getStateCookie gets and clears the OAuth state cookie
+func (h *Handler) getStateCookie(w http.ResponseWriter, r *http.Request) string {
+	return h.takeOAuthCookie(w, r, stateCookieName)
+}
+
+// setOAuthCookie stores a short-lived value for the duration of an OAuth login
+func (h *Handler) setOAuthCookie(w http.ResponseWriter, name, value string) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     stateCookieName,
-		Value:    state,
+		Name:     name,
+		Value:    value,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   false,
@@ -207,15 +234,15 @@ func (h *Handler) setStateCookie(w http.ResponseWriter, state string) {
 	})
 }
 
-// getStateCookie gets and clears the OAuth state cookie
-func (h *Handler) getStateCookie(w http.ResponseWriter, r *http.Request) string {
-	cookie, err := r.Cookie(stateCookieName)
+// takeOAuthCookie gets and clears a cookie set by setOAuthCookie
+func (h *Handler) takeOAuthCookie(w http.ResponseWriter, r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
 	if err != nil {
 		return ""
 	}
 	// Clear the cookie
 	http.SetCookie(w, &http.Cookie{
-		Name:     stateCookieName,
+		Name:     name,
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
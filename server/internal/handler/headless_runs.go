@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// maxHeadlessRunWait caps how long GetHeadlessRunResult blocks, so long polls
+// stay below typical proxy and client timeouts.
+const maxHeadlessRunWait = 5 * time.Minute
+
+// ListHeadlessRuns returns the recent headless runs of a project
+func (h *Handler) ListHeadlessRuns(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	runs, err := h.headlessRunService.ListHeadlessRuns(r.Context(), projectID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list headless runs")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"runs": runs})
+}
+
+// CreateHeadlessRun starts a headless run
+func (h *Handler) CreateHeadlessRun(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	var req service.CreateHeadlessRunRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Committing needs more than the session creation the route requires
+	if req.Commit && !middleware.HasProjectPermission(r.Context(), model.PermissionSessionCommit) {
+		h.Error(w, http.StatusForbidden, "Permission denied")
+		return
+	}
+
+	run, err := h.headlessRunService.CreateHeadlessRun(r.Context(), projectID, req)
+	if err != nil {
+		h.headlessRunError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusCreated, run)
+}
+
+// GetHeadlessRun returns a headless run
+func (h *Handler) GetHeadlessRun(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	runID := chi.URLParam(r, "runId")
+
+	run, err := h.headlessRunService.GetHeadlessRun(r.Context(), projectID, runID)
+	if err != nil {
+		h.headlessRunError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, run)
+}
+
+// GetHeadlessRunResult returns a headless run once it has finished. The
+// optional wait query parameter (a duration such as "30s", capped at five
+// minutes) long-polls for completion. Responds 200 when the run has finished
+// and 202 with its current state otherwise.
+func (h *Handler) GetHeadlessRunResult(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	runID := chi.URLParam(r, "runId")
+
+	var wait time.Duration
+	if v := r.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			h.Error(w, http.StatusBadRequest, "wait must be a duration such as 30s")
+			return
+		}
+		wait = min(d, maxHeadlessRunWait)
+	}
+
+	run, err := h.headlessRunService.WaitHeadlessRun(r.Context(), projectID, runID, wait)
+	if err != nil {
+		h.headlessRunError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !run.IsTerminal() {
+		status = http.StatusAccepted
+	}
+	h.JSON(w, status, run)
+}
+
+// headlessRunError maps headless run service errors to HTTP responses.
+func (h *Handler) headlessRunError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidHeadlessRun):
+		h.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrHeadlessRunNotFound):
+		h.Error(w, http.StatusNotFound, "Headless run not found")
+	default:
+		logging.FromContext(r.Context()).Error("headless run request failed", "error", err)
+		h.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
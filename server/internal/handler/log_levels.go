@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+)
+
+// LogLevels is the body of the log level endpoints. Levels are slog level
+// names: "debug", "info", "warn" or "error".
+type LogLevels struct {
+	// Level applies to components without their own level.
+	Level string `json:"level"`
+	// Components maps component names to levels.
+	Components map[string]string `json:"components"`
+}
+
+// GetLogLevels returns the current log levels.
+// GET /api/admin/log-levels
+func (h *Handler) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	h.JSON(w, http.StatusOK, currentLogLevels())
+}
+
+// SetLogLevels replaces the log levels. Components not in the request return
+// to the default level.
+// PUT /api/admin/log-levels
+func (h *Handler) SetLogLevels(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req LogLevels
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var def slog.Level
+	if err := def.UnmarshalText([]byte(req.Level)); err != nil {
+		h.Error(w, http.StatusBadRequest, "invalid level: "+req.Level)
+		return
+	}
+	components := make(map[string]slog.Level, len(req.Components))
+	for name, value := range req.Components {
+		var level slog.Level
+		if name == "" || level.UnmarshalText([]byte(value)) != nil {
+			h.Error(w, http.StatusBadRequest, "invalid level for component "+name+": "+value)
+			return
+		}
+		components[name] = level
+	}
+
+	logging.SetLevels(def, components)
+	logging.Component("http").InfoContext(r.Context(), "log levels changed", "level", def, "components", req.Components)
+	h.JSON(w, http.StatusOK, currentLogLevels())
+}
+
+func currentLogLevels() LogLevels {
+	def, components := logging.Levels()
+	resp := LogLevels{Level: strings.ToLower(def.String()), Components: make(map[string]string, len(components))}
+	for name, level := range components {
+		resp.Components[name] = strings.ToLower(level.String())
+	}
+	return resp
+}
+
+// authorizeAdmin checks a request to an /api/admin route. When ADMIN_TOKEN
+// is set, requests must send it as a bearer token. Without one, admin routes
+// are only open when authentication is disabled (single-user mode).
+func (h *Handler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.cfg.AdminToken == "" {
+		if h.cfg.AuthEnabled {
+			h.Error(w, http.StatusForbidden, "Admin endpoints are disabled; set ADMIN_TOKEN to enable them")
+			return false
+		}
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.AdminToken)) != 1 {
+		h.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
+)
+
+func TestSetLogLevels(t *testing.T) {
+	def, components := logging.Levels()
+	t.Cleanup(func() { logging.SetLevels(def, components) })
+
+	h := &Handler{cfg: &config.Config{AuthEnabled: true, AdminToken: "admin-secret"}}
+
+	body := `{"level":"warn","components":{"dispatcher":"debug"}}`
+	put := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/admin/log-levels", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.SetLogLevels(w, req)
+		return w
+	}
+
+	if w := put("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status = %d, want 401", w.Code)
+	}
+
+	w := put("admin-secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var resp LogLevels
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Level != "warn" || resp.Components["dispatcher"] != "debug" {
+		t.Errorf("response = %+v", resp)
+	}
+	if got, comps := logging.Levels(); got != slog.LevelWarn || comps["dispatcher"] != slog.LevelDebug {
+		t.Errorf("levels = %v %v", got, comps)
+	}
+
+	req := httptest.NewRequest("PUT", "/api/admin/log-levels", strings.NewReader(`{"level":"loud"}`))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	h.SetLogLevels(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid level: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAdminRoutesRequireTokenWithAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want int
+	}{
+		{"auth enabled without token", config.Config{AuthEnabled: true}, http.StatusForbidden},
+		{"auth disabled", config.Config{}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{cfg: &tt.cfg}
+			w := httptest.NewRecorder()
+			h.GetLogLevels(w, httptest.NewRequest("GET", "/api/admin/log-levels", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
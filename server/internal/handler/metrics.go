@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/obot-platform/discobot/server/internal/metrics"
+)
+
+// Metrics serves Prometheus metrics in the text exposition format.
+// GET /metrics
+// When METRICS_TOKEN is set, requests must send it as a bearer token.
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.MetricsToken != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.MetricsToken)) != 1 {
+			h.Error(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+	}
+	metrics.Handler().ServeHTTP(w, r)
+}
@@ -1,12 +1,17 @@
 package handler
 
 import (
-	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/service"
 )
 
 // ListProjects returns all projects for the current user
@@ -72,14 +77,6 @@ func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "projectId")
 
-	// Check if user is admin or owner
-	userID := middleware.GetUserID(r.Context())
-	role, err := h.projectService.GetMemberRole(r.Context(), projectID, userID)
-	if err != nil || (role != "owner" && role != "admin") {
-		h.Error(w, http.StatusForbidden, "Admin access required")
-		return
-	}
-
 	var req struct {
 		Name string `json:"name"`
 	}
@@ -101,19 +98,12 @@ func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "projectId")
 
-	// Check if user is owner
-	userID := middleware.GetUserID(r.Context())
-	role, err := h.projectService.GetMemberRole(r.Context(), projectID, userID)
-	if err != nil || role != "owner" {
-		h.Error(w, http.StatusForbidden, "Owner access required")
-		return
-	}
-
 	if err := h.projectService.DeleteProject(r.Context(), projectID); err != nil {
 		h.Error(w, http.StatusInternalServerError, "Failed to delete project")
 		return
 	}
 
+	h.audit(r, model.AuditActionProjectDelete, "project", projectID, nil)
 	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
@@ -135,17 +125,9 @@ func (h *Handler) RemoveProjectMember(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "projectId")
 	targetUserID := chi.URLParam(r, "userId")
 
-	// Check if current user is admin or owner
-	userID := middleware.GetUserID(r.Context())
-	role, err := h.projectService.GetMemberRole(r.Context(), projectID, userID)
-	if err != nil || (role != "owner" && role != "admin") {
-		h.Error(w, http.StatusForbidden, "Admin access required")
-		return
-	}
-
 	// Cannot remove owner
 	targetRole, _ := h.projectService.GetMemberRole(r.Context(), projectID, targetUserID)
-	if targetRole == "owner" {
+	if targetRole == model.RoleOwner {
 		h.Error(w, http.StatusForbidden, "Cannot remove project owner")
 		return
 	}
@@ -155,20 +137,14 @@ func (h *Handler) RemoveProjectMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionMemberRemove, "user", targetUserID, map[string]any{"role": targetRole})
 	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
 // CreateInvitation creates a project invitation
 func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "projectId")
-
-	// Check if user is admin or owner
 	userID := middleware.GetUserID(r.Context())
-	role, err := h.projectService.GetMemberRole(r.Context(), projectID, userID)
-	if err != nil || (role != "owner" && role != "admin") {
-		h.Error(w, http.StatusForbidden, "Admin access required")
-		return
-	}
 
 	var req struct {
 		Email string `json:"email"`
@@ -183,18 +159,87 @@ func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if req.Role == "" {
-		req.Role = "member"
+		req.Role = model.RoleMember
+	}
+	if !model.IsAssignableRole(req.Role) {
+		h.Error(w, http.StatusBadRequest, "Role must be one of admin, developer, member or viewer")
+		return
 	}
 
-	invitation, err := h.projectService.CreateInvitation(r.Context(), projectID, userID, req.Email, req.Role)
+	invitation, err := h.projectService.CreateInvitation(r.Context(), projectID, userID, req.Email, req.Role, h.publicBaseURL(r))
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, "Failed to create invitation")
 		return
 	}
 
+	h.audit(r, model.AuditActionInvitationCreate, "invitation", invitation.ID, map[string]any{"email": req.Email, "role": req.Role})
 	h.JSON(w, http.StatusCreated, invitation)
 }
 
+// ListInvitations lists a project's pending invitations
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "projectId")
+
+	invitations, err := h.projectService.ListInvitations(r.Context(), projectID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list invitations")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"invitations": invitations})
+}
+
+// ResendInvitation extends a pending invitation and emails it again
+func (h *Handler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "projectId")
+	invitationID := chi.URLParam(r, "invitationId")
+
+	invitation, err := h.projectService.ResendInvitation(r.Context(), projectID, invitationID, h.publicBaseURL(r))
+	if err != nil {
+		if errors.Is(err, service.ErrInvitationNotFound) {
+			h.Error(w, http.StatusNotFound, "Invitation not found")
+			return
+		}
+		h.Error(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.audit(r, model.AuditActionInvitationResend, "invitation", invitation.ID, map[string]any{"email": invitation.Email})
+	h.JSON(w, http.StatusOK, invitation)
+}
+
+// RevokeInvitation deletes a pending invitation
+func (h *Handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "projectId")
+	invitationID := chi.URLParam(r, "invitationId")
+
+	invitation, err := h.projectService.RevokeInvitation(r.Context(), projectID, invitationID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvitationNotFound) {
+			h.Error(w, http.StatusNotFound, "Invitation not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+
+	h.audit(r, model.AuditActionInvitationRevoke, "invitation", invitation.ID, map[string]any{"email": invitation.Email})
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// publicBaseURL returns the externally reachable base URL used in links sent
+// to users, preferring PUBLIC_URL over the request's host.
+func (h *Handler) publicBaseURL(r *http.Request) string {
+	if h.cfg.PublicURL != "" {
+		return h.cfg.PublicURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
 // AcceptInvitation accepts a project invitation
 func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
@@ -208,59 +253,89 @@ func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
 	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
-// ListProjectCacheVolumes lists cache volumes for a project
-func (h *Handler) ListProjectCacheVolumes(w http.ResponseWriter, r *http.Request) {
+// GetProjectCache returns per-entry usage of the project's cache volume and
+// the project's cache limits
+func (h *Handler) GetProjectCache(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "projectId")
 
-	// Check if provider supports cache volume listing
-	type cacheVolumeManager interface {
-		ListCacheVolumes(ctx context.Context, projectID string) (interface{}, error)
+	cache, err := h.projectService.GetCache(r.Context(), projectID)
+	if err != nil {
+		h.cacheError(w, "Failed to get cache usage", err)
+		return
 	}
 
-	cvm, ok := h.sandboxProvider.(cacheVolumeManager)
-	if !ok {
-		h.Error(w, http.StatusNotImplemented, "Cache volumes not supported by provider")
+	h.JSON(w, http.StatusOK, cache)
+}
+
+// UpdateProjectCacheSettings sets the project's cache quota and max age
+func (h *Handler) UpdateProjectCacheSettings(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "projectId")
+
+	var req service.CacheSettings
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	volumes, err := cvm.ListCacheVolumes(r.Context(), projectID)
+	settings, err := h.projectService.UpdateCacheSettings(r.Context(), projectID, req)
 	if err != nil {
-		h.Error(w, http.StatusInternalServerError, "Failed to list cache volumes")
+		if errors.Is(err, service.ErrInvalidCacheSettings) {
+			h.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Failed to update cache settings")
 		return
 	}
 
-	h.JSON(w, http.StatusOK, map[string]interface{}{
-		"volumes": volumes,
-	})
+	h.JSON(w, http.StatusOK, settings)
 }
 
-// DeleteProjectCacheVolume deletes the cache volume for a project
-func (h *Handler) DeleteProjectCacheVolume(w http.ResponseWriter, r *http.Request) {
+// PruneProjectCache clears cache entries that exceed the project's cache
+// limits, or the limits given in the request
+func (h *Handler) PruneProjectCache(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "projectId")
 
-	// Check if user is admin or owner
-	userID := middleware.GetUserID(r.Context())
-	role, err := h.projectService.GetMemberRole(r.Context(), projectID, userID)
-	if err != nil || (role != "owner" && role != "admin") {
-		h.Error(w, http.StatusForbidden, "Admin access required")
+	var req struct {
+		Entries []string `json:"entries"`
+		service.CacheSettings
+	}
+	if err := h.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Check if provider supports cache volume removal
-	type cacheVolumeManager interface {
-		RemoveCacheVolume(ctx context.Context, projectID string) error
+	result, err := h.projectService.PruneCache(r.Context(), projectID, req.Entries, req.CacheSettings)
+	if err != nil {
+		h.cacheError(w, "Failed to prune cache", err)
+		return
 	}
 
-	cvm, ok := h.sandboxProvider.(cacheVolumeManager)
-	if !ok {
-		h.Error(w, http.StatusNotImplemented, "Cache volumes not supported by provider")
+	h.JSON(w, http.StatusOK, result)
+}
+
+// ClearProjectCache clears the project's cache. Repeated "entry" query
+// parameters (e.g. ?entry=go/pkg/mod) limit it to those cache entries.
+func (h *Handler) ClearProjectCache(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "projectId")
+
+	result, err := h.projectService.ClearCache(r.Context(), projectID, r.URL.Query()["entry"])
+	if err != nil {
+		h.cacheError(w, "Failed to clear cache", err)
 		return
 	}
 
-	if err := cvm.RemoveCacheVolume(r.Context(), projectID); err != nil {
-		h.Error(w, http.StatusInternalServerError, "Failed to delete cache volume")
+	h.JSON(w, http.StatusOK, result)
+}
+
+// cacheError writes the response for a failed cache operation.
+func (h *Handler) cacheError(w http.ResponseWriter, msg string, err error) {
+	if errors.Is(err, sandbox.ErrCacheNotSupported) {
+		h.Error(w, http.StatusNotImplemented, "Cache volumes not supported by provider")
 		return
 	}
-
-	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+	if errors.Is(err, sandbox.ErrInvalidCacheEntry) {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.Error(w, http.StatusInternalServerError, fmt.Sprintf("%s: %v", msg, err))
 }
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// GetWorkspaceQuestionPolicy returns a workspace's question policy
+func (h *Handler) GetWorkspaceQuestionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	workspaceID := chi.URLParam(r, "workspaceId")
+
+	policy, err := h.workspaceService.GetQuestionPolicy(r.Context(), projectID, workspaceID)
+	if err != nil {
+		h.questionPolicyError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"policy": policy})
+}
+
+// SetWorkspaceQuestionPolicy sets a workspace's question policy
+func (h *Handler) SetWorkspaceQuestionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	workspaceID := chi.URLParam(r, "workspaceId")
+
+	var req model.QuestionPolicy
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	policy, err := h.workspaceService.SetQuestionPolicy(r.Context(), projectID, workspaceID, &req)
+	if err != nil {
+		h.questionPolicyError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"policy": policy})
+}
+
+// DeleteWorkspaceQuestionPolicy clears a workspace's question policy
+func (h *Handler) DeleteWorkspaceQuestionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	workspaceID := chi.URLParam(r, "workspaceId")
+
+	if _, err := h.workspaceService.SetQuestionPolicy(r.Context(), projectID, workspaceID, nil); err != nil {
+		h.questionPolicyError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetSessionQuestionPolicy returns a session's question policy and the policy in effect
+func (h *Handler) GetSessionQuestionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+
+	policy, err := h.sessionService.GetQuestionPolicy(r.Context(), projectID, sessionID)
+	if err != nil {
+		h.questionPolicyError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, policy)
+}
+
+// SetSessionQuestionPolicy sets a session's question policy
+func (h *Handler) SetSessionQuestionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+
+	var req model.QuestionPolicy
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	policy, err := h.sessionService.SetQuestionPolicy(r.Context(), projectID, sessionID, &req)
+	if err != nil {
+		h.questionPolicyError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, policy)
+}
+
+// DeleteSessionQuestionPolicy clears a session's question policy so its workspace's applies
+func (h *Handler) DeleteSessionQuestionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+
+	policy, err := h.sessionService.SetQuestionPolicy(r.Context(), projectID, sessionID, nil)
+	if err != nil {
+		h.questionPolicyError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, policy)
+}
+
+// questionPolicyError maps question policy errors to HTTP responses.
+func (h *Handler) questionPolicyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidQuestionPolicy):
+		h.Error(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		h.Error(w, http.StatusNotFound, err.Error())
+	default:
+		logging.FromContext(r.Context()).Error("question policy request failed", "error", err)
+		h.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
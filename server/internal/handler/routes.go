@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/obot-platform/discobot/server/internal/routes"
+	"github.com/obot-platform/discobot/server/internal/version"
 )
 
 // GetRoutes returns all registered API routes with their metadata.
@@ -11,3 +12,13 @@ import (
 func (h *Handler) GetRoutes(w http.ResponseWriter, _ *http.Request) {
 	h.JSON(w, http.StatusOK, routes.All())
 }
+
+// GetOpenAPI returns an OpenAPI 3.1 document generated from the route
+// registry, for generating typed API clients.
+func (h *Handler) GetOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	h.JSON(w, http.StatusOK, routes.GetRegistry().OpenAPI(routes.OpenAPIInfo{
+		Title:       "Discobot API",
+		Version:     version.Get(),
+		Description: "Discobot server API. Project routes require the permission listed in x-permission.",
+	}))
+}
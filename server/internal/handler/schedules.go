@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// ListSchedules returns all schedules for a project
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	schedules, err := h.scheduleService.ListSchedules(r.Context(), projectID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list schedules")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"schedules": schedules})
+}
+
+// CreateSchedule creates a schedule
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	var req service.CreateScheduleRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(r.Context(), projectID, req)
+	if err != nil {
+		h.scheduleError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusCreated, schedule)
+}
+
+// GetSchedule returns a single schedule
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	scheduleID := chi.URLParam(r, "scheduleId")
+
+	schedule, err := h.scheduleService.GetSchedule(r.Context(), projectID, scheduleID)
+	if err != nil {
+		h.scheduleError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, schedule)
+}
+
+// UpdateSchedule updates a schedule
+func (h *Handler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	scheduleID := chi.URLParam(r, "scheduleId")
+
+	var req service.UpdateScheduleRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	schedule, err := h.scheduleService.UpdateSchedule(r.Context(), projectID, scheduleID, req)
+	if err != nil {
+		h.scheduleError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, schedule)
+}
+
+// DeleteSchedule deletes a schedule
+func (h *Handler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	scheduleID := chi.URLParam(r, "scheduleId")
+
+	if err := h.scheduleService.DeleteSchedule(r.Context(), projectID, scheduleID); err != nil {
+		h.scheduleError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// TriggerSchedule starts a run of a schedule immediately
+func (h *Handler) TriggerSchedule(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	scheduleID := chi.URLParam(r, "scheduleId")
+
+	run, err := h.scheduleService.TriggerSchedule(r.Context(), projectID, scheduleID)
+	if err != nil {
+		h.scheduleError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusAccepted, run)
+}
+
+// ListScheduleRuns returns the recent runs of a schedule
+func (h *Handler) ListScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	scheduleID := chi.URLParam(r, "scheduleId")
+
+	runs, err := h.scheduleService.ListScheduleRuns(r.Context(), projectID, scheduleID)
+	if err != nil {
+		h.scheduleError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"runs": runs})
+}
+
+// scheduleError maps schedule service errors to HTTP responses.
+func (h *Handler) scheduleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSchedule):
+		h.Error(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not belong"):
+		h.Error(w, http.StatusNotFound, "Schedule not found")
+	default:
+		logging.FromContext(r.Context()).Error("schedule request failed", "error", err)
+		h.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -2,12 +2,13 @@ package handler
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/metrics"
 	"github.com/obot-platform/discobot/server/internal/middleware"
 )
 
@@ -126,6 +127,7 @@ func (h *Handler) GetServiceOutput(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	defer metrics.TrackSSE("service_output")()
 
 	// Get the stream from sandbox
 	sseCh, err := h.chatService.GetServiceOutput(ctx, projectID, sessionID, serviceID)
@@ -143,7 +145,7 @@ func (h *Handler) GetServiceOutput(w http.ResponseWriter, r *http.Request) {
 	// Pass through raw SSE lines from sandbox
 	for line := range sseCh {
 		if line.Done {
-			log.Printf("[ServiceOutput] Received [DONE] signal from sandbox")
+			logging.FromContext(r.Context()).Debug("received [DONE] signal from sandbox")
 			_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
 			flusher.Flush()
 			return
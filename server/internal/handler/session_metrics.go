@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/metrics"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+const (
+	// defaultMetricsInterval is the default time between streamed samples.
+	defaultMetricsInterval = 5 * time.Second
+	// minMetricsInterval keeps clients from sampling continuously.
+	minMetricsInterval = time.Second
+)
+
+// GetSessionMetrics returns a resource usage sample of the session's sandbox.
+// GET /api/projects/{projectId}/sessions/{sessionId}/metrics
+func (h *Handler) GetSessionMetrics(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	sample, err := h.sessionService.GetMetrics(ctx, projectID, sessionID)
+	if err != nil {
+		h.metricsError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, sample)
+}
+
+// StreamSessionMetrics streams resource usage samples of the session's
+// sandbox as server-sent "metrics" events until the client disconnects.
+// GET /api/projects/{projectId}/sessions/{sessionId}/metrics/stream
+// Query parameters:
+//   - interval: time between samples (e.g. "5s", default 5s, minimum 1s)
+//
+// While the sandbox is not running, "unavailable" events are sent instead.
+func (h *Handler) StreamSessionMetrics(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	interval := defaultMetricsInterval
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			h.Error(w, http.StatusBadRequest, "invalid interval parameter")
+			return
+		}
+		interval = max(d, minMetricsInterval)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.Error(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	// Fail before switching to SSE if the session doesn't exist
+	sample, err := h.sessionService.GetMetrics(ctx, projectID, sessionID)
+	if err != nil && !errors.Is(err, service.ErrMetricsUnavailable) {
+		h.metricsError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	defer metrics.TrackSSE("session_metrics")()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err != nil {
+			data, _ := json.Marshal(map[string]string{"error": err.Error()})
+			_, _ = fmt.Fprintf(w, "event: unavailable\ndata: %s\n\n", data)
+		} else {
+			data, _ := json.Marshal(sample)
+			_, _ = fmt.Fprintf(w, "event: metrics\ndata: %s\n\n", data)
+		}
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sample, err = h.sessionService.GetMetrics(ctx, projectID, sessionID)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// GetProjectMetrics returns resource usage of every active session in the
+// project, busiest first, with totals.
+// GET /api/projects/{projectId}/metrics
+func (h *Handler) GetProjectMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	summary, err := h.sessionService.GetProjectMetrics(ctx, projectID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to get project metrics")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, summary)
+}
+
+// metricsError writes the response for a failed metrics request.
+func (h *Handler) metricsError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrMetricsUnavailable):
+		h.Error(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		h.Error(w, http.StatusNotFound, "Session not found")
+	default:
+		h.Error(w, http.StatusInternalServerError, "Failed to get session metrics")
+	}
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/obot-platform/discobot/server/internal/git"
 	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/service"
 )
 
@@ -74,6 +78,7 @@ func (h *Handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionSessionDelete, "session", sessionID, nil)
 	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
@@ -111,7 +116,19 @@ func (h *Handler) CommitSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	projectID := middleware.GetProjectID(ctx)
 
-	if err := h.sessionService.CommitSession(ctx, projectID, sessionID, h.jobQueue); err != nil {
+	// The body is optional; {"review": true} stops the commit for review before applying
+	var req CommitSessionRequest
+	if err := h.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	commit := h.sessionService.CommitSession
+	if req.Review {
+		commit = h.sessionService.StartCommitReview
+	}
+
+	if err := commit(ctx, projectID, sessionID, h.jobQueue); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			h.Error(w, http.StatusNotFound, "Session not found")
 			return
@@ -120,6 +137,136 @@ func (h *Handler) CommitSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionSessionCommit, "session", sessionID, map[string]any{"review": req.Review})
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// CommitSessionRequest is the optional request body for committing a session.
+type CommitSessionRequest struct {
+	Review bool `json:"review"`
+}
+
+// GetCommitReview returns the commits awaiting review for a session.
+// GET /api/projects/{projectId}/sessions/{sessionId}/commit/patches
+func (h *Handler) GetCommitReview(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	review, err := h.sessionService.GetCommitReview(ctx, projectID, sessionID)
+	if err != nil {
+		h.commitReviewError(w, err, "Failed to get commit review")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, review)
+}
+
+// ApplyCommitReviewRequest is the request body for applying reviewed commits.
+type ApplyCommitReviewRequest struct {
+	git.PatchSelection
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// ApplyCommitReview applies the selected commits and files from a commit review.
+// POST /api/projects/{projectId}/sessions/{sessionId}/commit/apply
+func (h *Handler) ApplyCommitReview(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	var req ApplyCommitReviewRequest
+	if err := h.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.sessionService.ApplyCommitReview(ctx, projectID, sessionID, req.PatchSelection, req.Feedback, h.jobQueue); err != nil {
+		h.commitReviewError(w, err, "Failed to apply commit review")
+		return
+	}
+
+	h.audit(r, model.AuditActionSessionCommitApply, "session", sessionID, nil)
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// RejectCommitReviewRequest is the request body for rejecting reviewed commits.
+type RejectCommitReviewRequest struct {
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// RejectCommitReview discards the commits awaiting review, optionally sending feedback to the agent.
+// POST /api/projects/{projectId}/sessions/{sessionId}/commit/reject
+func (h *Handler) RejectCommitReview(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	var req RejectCommitReviewRequest
+	if err := h.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.sessionService.RejectCommitReview(ctx, projectID, sessionID, req.Feedback, h.jobQueue); err != nil {
+		h.commitReviewError(w, err, "Failed to reject commit review")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// commitReviewError maps commit review errors to HTTP responses.
+func (h *Handler) commitReviewError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrNoCommitReview):
+		h.Error(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		h.Error(w, http.StatusNotFound, "Session not found")
+	default:
+		h.Error(w, http.StatusInternalServerError, message)
+	}
+}
+
+// GetSessionStartup returns the setup steps of the session's sandbox, with their timing and outcome.
+// GET /api/projects/{projectId}/sessions/{sessionId}/startup
+func (h *Handler) GetSessionStartup(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	timeline, err := h.sessionService.GetStartupTimeline(ctx, projectID, sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrStartupTimelineUnavailable):
+			h.Error(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			h.Error(w, http.StatusNotFound, "Session not found")
+		default:
+			h.Error(w, http.StatusInternalServerError, "Failed to get startup timeline")
+		}
+		return
+	}
+
+	h.JSON(w, http.StatusOK, timeline)
+}
+
+// RebaseSession initiates async rebase of a session onto the latest upstream commit.
+// POST /api/projects/{projectId}/sessions/{sessionId}/rebase
+func (h *Handler) RebaseSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+
+	if err := h.sessionService.RebaseSession(ctx, projectID, sessionID, h.jobQueue); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.Error(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Failed to initiate session rebase")
+		return
+	}
+
 	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
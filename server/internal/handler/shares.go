@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// ListSessionShares lists a session's share links
+func (h *Handler) ListSessionShares(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+
+	shares, err := h.shareService.ListShares(r.Context(), projectID, sessionID)
+	if err != nil {
+		h.shareError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"shares": shares})
+}
+
+// CreateSessionShare creates a read-only share link for a session
+func (h *Handler) CreateSessionShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := middleware.GetProjectID(ctx)
+	sessionID := chi.URLParam(r, "sessionId")
+
+	// The body is optional; the link expires after the default lifetime
+	var req service.CreateShareRequest
+	if err := h.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	share, err := h.shareService.CreateShare(ctx, projectID, sessionID, middleware.GetUserID(ctx), req, h.publicBaseURL(r))
+	if err != nil {
+		h.shareError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionSessionShareCreate, "session", sessionID, map[string]any{"shareId": share.ID, "expiresAt": share.ExpiresAt})
+	h.JSON(w, http.StatusCreated, share)
+}
+
+// RevokeSessionShare deletes a session share link
+func (h *Handler) RevokeSessionShare(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+	shareID := chi.URLParam(r, "shareId")
+
+	share, err := h.shareService.RevokeShare(r.Context(), projectID, sessionID, shareID)
+	if err != nil {
+		h.shareError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionSessionShareRevoke, "session", sessionID, map[string]any{"shareId": share.ID})
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// The handlers below serve /api/share/{shareToken}/... The SessionShare
+// middleware has already pinned {sessionId} to the shared session, so they
+// delegate to the project handlers for the same data.
+
+// GetSharedSession returns the session a share link points to
+func (h *Handler) GetSharedSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.shareService.GetSharedSession(r.Context(), middleware.GetSessionShare(r.Context()))
+	if err != nil {
+		h.shareError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, session)
+}
+
+// ListSharedMessages returns a shared session's transcript
+func (h *Handler) ListSharedMessages(w http.ResponseWriter, r *http.Request) {
+	h.ListMessages(w, r)
+}
+
+// GetSharedSessionDiff returns a shared session's diff
+func (h *Handler) GetSharedSessionDiff(w http.ResponseWriter, r *http.Request) {
+	h.GetSessionDiff(w, r)
+}
+
+// GetSharedHooksStatus returns a shared session's hook status
+func (h *Handler) GetSharedHooksStatus(w http.ResponseWriter, r *http.Request) {
+	h.GetHooksStatus(w, r)
+}
+
+// GetSharedHookOutput returns the output of one of a shared session's hooks
+func (h *Handler) GetSharedHookOutput(w http.ResponseWriter, r *http.Request) {
+	h.GetHookOutput(w, r)
+}
+
+// ListSharedServices lists a shared session's services
+func (h *Handler) ListSharedServices(w http.ResponseWriter, r *http.Request) {
+	h.ListServices(w, r)
+}
+
+// GetSharedServiceOutput streams the output of one of a shared session's services
+func (h *Handler) GetSharedServiceOutput(w http.ResponseWriter, r *http.Request) {
+	h.GetServiceOutput(w, r)
+}
+
+// shareError maps share errors to HTTP responses.
+func (h *Handler) shareError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidShareRequest):
+		h.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrShareNotFound), strings.Contains(err.Error(), "not found"):
+		h.Error(w, http.StatusNotFound, err.Error())
+	default:
+		logging.FromContext(r.Context()).Error("share request failed", "error", err)
+		h.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
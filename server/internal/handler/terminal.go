@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,6 +12,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
@@ -76,7 +77,7 @@ func (h *Handler) TerminalWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Get sandbox client (ensures sandbox is ready and container is running)
 	client, err := h.sandboxService.GetClient(ctx, sessionID)
 	if err != nil {
-		log.Printf("failed to ensure sandbox ready for session %s: %v", sessionID, err)
+		logging.FromContext(ctx).Error("failed to ensure sandbox ready", "error", err)
 		h.Error(w, http.StatusInternalServerError, "failed to start sandbox")
 		return
 	}
@@ -89,7 +90,7 @@ func (h *Handler) TerminalWebSocket(w http.ResponseWriter, r *http.Request) {
 		// Get default user from sandbox (uses UID:GID format for compatibility)
 		userInfo, err := client.GetUserInfo(ctx)
 		if err != nil {
-			log.Printf("failed to get user info, falling back to root: %v", err)
+			logging.FromContext(ctx).Warn("failed to get user info, falling back to root", "error", err)
 			user = "root"
 		} else {
 			user = strconv.Itoa(userInfo.UID) + ":" + strconv.Itoa(userInfo.GID)
@@ -99,7 +100,7 @@ func (h *Handler) TerminalWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("failed to upgrade websocket: %v", err)
+		logging.FromContext(ctx).Warn("failed to upgrade websocket", "error", err)
 		return
 	}
 	defer func() { _ = conn.Close() }()
@@ -107,12 +108,14 @@ func (h *Handler) TerminalWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Attach to sandbox PTY
 	pty, err := h.sandboxService.Attach(ctx, sessionID, rows, cols, user)
 	if err != nil {
-		log.Printf("failed to attach to sandbox PTY: %v", err)
+		logging.FromContext(ctx).Error("failed to attach to sandbox PTY", "error", err)
 		sendError(conn, "failed to attach to terminal")
 		return
 	}
 	defer func() { _ = pty.Close() }()
 
+	h.audit(r, model.AuditActionTerminalOpen, "session", sessionID, map[string]any{"root": runAsRoot, "user": user})
+
 	// Handle the terminal session (core logic extracted for testability)
 	handleTerminalSession(ctx, pty, conn)
 }
@@ -128,6 +131,8 @@ func (h *Handler) TerminalWebSocket(w http.ResponseWriter, r *http.Request) {
 //   - If client stops writing, input goroutine exits but output continues.
 //   - If PTY exits, both goroutines eventually exit and connection closes.
 func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket.Conn) {
+	logger := logging.FromContext(ctx)
+
 	// Done channel to signal when PTY output is fully drained
 	outputDone := make(chan struct{})
 
@@ -139,7 +144,7 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 			if err := conn.ReadJSON(&msg); err != nil {
 				// Client closed or network error - stop reading input
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					log.Printf("WebSocket read error: %v", err)
+					logger.Warn("websocket read error", "error", err)
 				}
 				return
 			}
@@ -148,18 +153,18 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 			case "input":
 				var input string
 				if err := json.Unmarshal(msg.Data, &input); err != nil {
-					log.Printf("failed to unmarshal input: %v", err)
+					logger.Warn("failed to unmarshal input", "error", err)
 					continue
 				}
 				if _, err := pty.Write([]byte(input)); err != nil {
-					log.Printf("PTY write error: %v", err)
+					logger.Warn("PTY write error", "error", err)
 					return
 				}
 
 			case "resize":
 				var resize ResizeData
 				if err := json.Unmarshal(msg.Data, &resize); err != nil {
-					log.Printf("failed to unmarshal resize: %v", err)
+					logger.Warn("failed to unmarshal resize", "error", err)
 					continue
 				}
 				// Enforce minimum terminal size to prevent zero-dimension PTY
@@ -170,7 +175,7 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 					resize.Rows = minTermRows
 				}
 				if err := pty.Resize(ctx, resize.Rows, resize.Cols); err != nil {
-					log.Printf("PTY resize error: %v", err)
+					logger.Warn("PTY resize error", "error", err)
 				}
 			}
 		}
@@ -185,7 +190,7 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 			n, err := pty.Read(buf)
 			if err != nil {
 				if err != io.EOF {
-					log.Printf("PTY read error: %v", err)
+					logger.Warn("PTY read error", "error", err)
 				}
 				return
 			}
@@ -193,7 +198,7 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 				// Properly JSON-encode the data to preserve ANSI escape codes
 				data, err := json.Marshal(string(buf[:n]))
 				if err != nil {
-					log.Printf("JSON marshal error: %v", err)
+					logger.Error("JSON marshal error", "error", err)
 					return
 				}
 				msg := TerminalMessage{
@@ -201,7 +206,7 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 					Data: json.RawMessage(data),
 				}
 				if err := conn.WriteJSON(msg); err != nil {
-					log.Printf("WebSocket write error: %v", err)
+					logger.Warn("websocket write error", "error", err)
 					return
 				}
 			}
@@ -210,7 +215,7 @@ func handleTerminalSession(ctx context.Context, pty sandbox.PTY, conn *websocket
 
 	// Wait for PTY to exit (shell exits)
 	exitCode, _ := pty.Wait(ctx)
-	log.Printf("PTY exited with code: %d", exitCode)
+	logger.Info("PTY exited", "exit_code", exitCode)
 
 	// Wait for output to be fully drained before closing
 	<-outputDone
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+)
+
+// ListTokens returns the current user's API tokens
+func (h *Handler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+
+	tokens, err := h.tokenService.ListTokens(r.Context(), userID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list tokens")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"tokens": tokens})
+}
+
+// CreateToken creates an API token for the current user.
+// The plaintext token is only included in this response.
+func (h *Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+
+	var req service.CreateTokenRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	token, err := h.tokenService.CreateToken(r.Context(), userID, req)
+	if err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionTokenCreate, "token", token.ID, map[string]any{"scopes": token.Scopes})
+	h.JSON(w, http.StatusCreated, token)
+}
+
+// RevokeToken deletes one of the current user's API tokens
+func (h *Handler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	tokenID := chi.URLParam(r, "tokenId")
+
+	if err := h.tokenService.RevokeToken(r.Context(), userID, tokenID); err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionTokenRevoke, "token", tokenID, nil)
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListServiceAccounts returns the service accounts of a project (admin+)
+func (h *Handler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+
+	accounts, err := h.tokenService.ListServiceAccounts(r.Context(), projectID)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to list service accounts")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"serviceAccounts": accounts})
+}
+
+// CreateServiceAccount creates a service account in a project (admin+)
+func (h *Handler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+
+	var req service.CreateServiceAccountRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	account, err := h.tokenService.CreateServiceAccount(r.Context(), projectID, userID, req)
+	if err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionServiceAccountCreate, "service_account", account.ID, map[string]any{"name": account.Name, "role": account.Role})
+	h.JSON(w, http.StatusCreated, account)
+}
+
+// DeleteServiceAccount deletes a service account and its tokens (admin+)
+func (h *Handler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	accountID := chi.URLParam(r, "serviceAccountId")
+
+	if err := h.tokenService.DeleteServiceAccount(r.Context(), projectID, accountID); err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionServiceAccountDelete, "service_account", accountID, nil)
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListServiceAccountTokens returns a service account's API tokens (admin+)
+func (h *Handler) ListServiceAccountTokens(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	accountID := chi.URLParam(r, "serviceAccountId")
+
+	tokens, err := h.tokenService.ListServiceAccountTokens(r.Context(), projectID, accountID)
+	if err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]any{"tokens": tokens})
+}
+
+// CreateServiceAccountToken creates an API token for a service account (admin+)
+func (h *Handler) CreateServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	accountID := chi.URLParam(r, "serviceAccountId")
+
+	var req service.CreateTokenRequest
+	if err := h.DecodeJSON(r, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	token, err := h.tokenService.CreateServiceAccountToken(r.Context(), projectID, accountID, req)
+	if err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionTokenCreate, "token", token.ID, map[string]any{"serviceAccountId": accountID, "scopes": token.Scopes})
+	h.JSON(w, http.StatusCreated, token)
+}
+
+// RevokeServiceAccountToken deletes a service account's API token (admin+)
+func (h *Handler) RevokeServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	projectID := middleware.GetProjectID(r.Context())
+	accountID := chi.URLParam(r, "serviceAccountId")
+	tokenID := chi.URLParam(r, "tokenId")
+
+	if err := h.tokenService.RevokeServiceAccountToken(r.Context(), projectID, accountID, tokenID); err != nil {
+		h.tokenError(w, r, err)
+		return
+	}
+
+	h.audit(r, model.AuditActionTokenRevoke, "token", tokenID, map[string]any{"serviceAccountId": accountID})
+	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// tokenError maps token service errors to HTTP responses.
+func (h *Handler) tokenError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTokenRequest):
+		h.Error(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not belong"):
+		h.Error(w, http.StatusNotFound, "Not found")
+	default:
+		logging.FromContext(r.Context()).Error("token request failed", "error", err)
+		h.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
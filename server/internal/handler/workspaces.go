@@ -7,6 +7,7 @@ import (
 
 	"github.com/obot-platform/discobot/server/internal/jobs"
 	"github.com/obot-platform/discobot/server/internal/middleware"
+	"github.com/obot-platform/discobot/server/internal/model"
 )
 
 // ListWorkspaces returns all workspaces for a project
@@ -169,6 +170,7 @@ func (h *Handler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.audit(r, model.AuditActionWorkspaceDelete, "workspace", workspaceID, map[string]any{"deleteFiles": deleteFiles})
 	h.JSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
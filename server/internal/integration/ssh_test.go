@@ -11,6 +11,7 @@ import (
 
 	gossh "golang.org/x/crypto/ssh"
 
+	"github.com/obot-platform/discobot/agent/tunnel"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/sandbox/mock"
 	"github.com/obot-platform/discobot/server/internal/ssh"
@@ -565,7 +566,7 @@ func (s *execStream) Wait(_ context.Context) (int, error) {
 	return s.exitCode, nil
 }
 
-// testStream is a mock stream that simulates socat for port forwarding tests.
+// testStream is a mock stream for long-lived commands such as sftp-server.
 // It blocks until Close is called, simulating a long-lived connection.
 type testStream struct {
 	// Input received from SSH channel (to be forwarded to "remote")
@@ -646,12 +647,17 @@ func (s *testStream) Wait(_ context.Context) (int, error) {
 	return s.exitCode, nil
 }
 
-func (s *testStream) GetInput() []byte {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return append([]byte(nil), s.inputBuf...)
+// tunnelStream is a sandbox.Stream backed by an in-memory connection to a
+// tunnel server, simulating "discobot-agent tunnel stdio" in the sandbox.
+type tunnelStream struct {
+	net.Conn
 }
 
+func (s *tunnelStream) Stderr() io.Reader                        { return emptyReader{} }
+func (s *tunnelStream) Resize(_ context.Context, _, _ int) error { return nil }
+func (s *tunnelStream) CloseWrite() error                        { return nil }
+func (s *tunnelStream) Wait(_ context.Context) (int, error)      { return 0, nil }
+
 func TestSSHServer_Integration_PortForwarding(t *testing.T) {
 	SkipIfShort(t) // SSH integration test
 	provider := mock.NewProvider()
@@ -666,22 +672,40 @@ func TestSSHServer_Integration_PortForwarding(t *testing.T) {
 		t.Fatalf("failed to start sandbox: %v", err)
 	}
 
-	// Create a test stream that simulates socat
-	// When data is written, it echoes it back (simulating a simple TCP echo server)
-	var testStreamInstance *testStream
-
-	// Hook ExecStreamFunc to intercept socat calls
+	// Serve a tunnel whose targets answer "PING" with "PONG", recording
+	// what was dialed and received.
+	var (
+		mu       sync.Mutex
+		dialed   string
+		received []byte
+	)
 	provider.ExecStreamFunc = func(_ context.Context, sid string, cmd []string, _ sandbox.ExecStreamOptions) (sandbox.Stream, error) {
 		if sid != sessionID {
 			return nil, sandbox.ErrNotFound
 		}
-		// Verify the command looks like a socat call
-		if len(cmd) < 2 || cmd[0] != "socat" {
+		if len(cmd) != 3 || cmd[1] != "tunnel" || cmd[2] != "stdio" {
 			return nil, fmt.Errorf("unexpected command: %v", cmd)
 		}
-		// Return a stream that echoes "PONG" response
-		testStreamInstance = newTestStream([]byte("PONG"), 0)
-		return testStreamInstance, nil
+		clientEnd, serverEnd := net.Pipe()
+		tunnel.Server(serverEnd, &tunnel.Config{
+			Dial: func(_ context.Context, target string) (io.ReadWriteCloser, error) {
+				local, remote := net.Pipe()
+				go func() {
+					defer remote.Close()
+					buf := make([]byte, 4)
+					if _, err := io.ReadFull(remote, buf); err != nil {
+						return
+					}
+					mu.Lock()
+					dialed = target
+					received = buf
+					mu.Unlock()
+					_, _ = remote.Write([]byte("PONG"))
+				}()
+				return local, nil
+			},
+		})
+		return &tunnelStream{Conn: clientEnd}, nil
 	}
 
 	sshServer, err := ssh.New(&ssh.Config{
@@ -735,16 +759,13 @@ func TestSSHServer_Integration_PortForwarding(t *testing.T) {
 		t.Errorf("response = %q, want %q", response, "PONG")
 	}
 
-	// Close the channel and wait for stream to clean up
-	channel.Close()
-	time.Sleep(50 * time.Millisecond)
-
-	// Verify the input was received
-	if testStreamInstance != nil {
-		input := testStreamInstance.GetInput()
-		if string(input) != "PING" {
-			t.Errorf("forwarded input = %q, want %q", string(input), "PING")
-		}
+	mu.Lock()
+	defer mu.Unlock()
+	if dialed != "localhost:8080" {
+		t.Errorf("dialed target = %q, want %q", dialed, "localhost:8080")
+	}
+	if string(received) != "PING" {
+		t.Errorf("forwarded input = %q, want %q", string(received), "PING")
 	}
 }
 
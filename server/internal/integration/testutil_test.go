@@ -902,6 +902,10 @@ func cleanTables(db *database.DB) {
 	tables := []string{
 		"terminal_history",
 		"messages",
+		"batch_run_items",
+		"batch_runs",
+		"schedule_runs",
+		"schedules",
 		"sessions",
 		"workspaces",
 		"agent_mcp_servers",
@@ -909,9 +913,11 @@ func cleanTables(db *database.DB) {
 		"credentials",
 		"project_invitations",
 		"project_members",
+		"service_accounts",
 		"projects",
 		"user_preferences",
 		"user_sessions",
+		"api_tokens",
 		"users",
 	}
 
@@ -8,6 +8,7 @@ import (
 	"github.com/obot-platform/discobot/server/internal/config"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/store"
+	"github.com/obot-platform/discobot/server/internal/tracing"
 )
 
 // Queue provides helper methods for enqueueing jobs.
@@ -37,8 +38,11 @@ func (q *Queue) notify() {
 
 // Resource type constants for job deduplication.
 const (
-	ResourceTypeSession   = "session"
-	ResourceTypeWorkspace = "workspace"
+	ResourceTypeSession      = "session"
+	ResourceTypeWorkspace    = "workspace"
+	ResourceTypeBatchRunSlot = "batch_run_slot"
+	ResourceTypeSchedule     = "schedule"
+	ResourceTypeHeadlessRun  = "headless_run"
 )
 
 // ErrJobAlreadyExists is returned when a job for the resource already exists.
@@ -91,6 +95,9 @@ func (q *Queue) Enqueue(ctx context.Context, payload JobPayload) error {
 		ResourceType: &resType,
 		ResourceID:   &resID,
 	}
+	if tp := tracing.TraceParent(ctx); tp != "" {
+		job.TraceParent = &tp
+	}
 
 	if err := q.store.CreateJob(ctx, job); err != nil {
 		return err
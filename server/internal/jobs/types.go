@@ -1,6 +1,12 @@
 // Package jobs defines job types and payloads for background job processing.
 package jobs
 
+import (
+	"fmt"
+
+	"github.com/obot-platform/discobot/server/internal/git"
+)
+
 // JobType represents the type of job.
 type JobType string
 
@@ -8,7 +14,11 @@ const (
 	JobTypeSessionInit   JobType = "session_init"
 	JobTypeSessionDelete JobType = "session_delete"
 	JobTypeSessionCommit JobType = "session_commit"
+	JobTypeSessionRebase JobType = "session_rebase"
 	JobTypeWorkspaceInit JobType = "workspace_init"
+	JobTypeBatchRunItem  JobType = "batch_run_item"
+	JobTypeScheduleRun   JobType = "schedule_run"
+	JobTypeHeadlessRun   JobType = "headless_run"
 )
 
 // JobPayload is implemented by all job payloads. The payload struct itself
@@ -67,11 +77,20 @@ func (p SessionDeletePayload) JobType() JobType              { return JobTypeSes
 func (p SessionDeletePayload) ResourceKey() (string, string) { return ResourceTypeSession, p.SessionID }
 func (p SessionDeletePayload) Priority() int                 { return 5 }
 
+// Commit actions for session_commit jobs. An empty action starts a new commit.
+const (
+	CommitActionApply  = "apply"  // Apply reviewed patches using Selection
+	CommitActionReject = "reject" // Discard reviewed patches
+)
+
 // SessionCommitPayload is the payload for session_commit jobs.
 type SessionCommitPayload struct {
-	ProjectID   string `json:"projectId"`
-	SessionID   string `json:"sessionId"`
-	WorkspaceID string `json:"workspaceId"`
+	ProjectID   string              `json:"projectId"`
+	SessionID   string              `json:"sessionId"`
+	WorkspaceID string              `json:"workspaceId"`
+	Action      string              `json:"action,omitempty"`
+	Selection   *git.PatchSelection `json:"selection,omitempty"`
+	Feedback    string              `json:"feedback,omitempty"` // Sent to the agent along with any rejected commits
 }
 
 func (p SessionCommitPayload) JobType() JobType { return JobTypeSessionCommit }
@@ -80,3 +99,62 @@ func (p SessionCommitPayload) ResourceKey() (string, string) {
 }
 func (p SessionCommitPayload) MaxAttempts() int      { return 1 }
 func (p SessionCommitPayload) AllowDuplicates() bool { return true }
+
+// SessionRebasePayload is the payload for session_rebase jobs.
+type SessionRebasePayload struct {
+	ProjectID   string `json:"projectId"`
+	SessionID   string `json:"sessionId"`
+	WorkspaceID string `json:"workspaceId"`
+}
+
+func (p SessionRebasePayload) JobType() JobType { return JobTypeSessionRebase }
+func (p SessionRebasePayload) ResourceKey() (string, string) {
+	return ResourceTypeWorkspace, p.WorkspaceID
+}
+func (p SessionRebasePayload) MaxAttempts() int      { return 1 }
+func (p SessionRebasePayload) AllowDuplicates() bool { return true }
+
+// BatchRunItemPayload is the payload for batch_run_item jobs.
+// Slot is the item's position modulo the batch run's concurrency. Only one job
+// per slot is queued at a time: the next item of a slot is enqueued when the
+// previous one finishes, which caps how many items of a run execute at once.
+type BatchRunItemPayload struct {
+	ProjectID  string `json:"projectId"`
+	BatchRunID string `json:"batchRunId"`
+	ItemID     string `json:"itemId"`
+	Slot       int    `json:"slot"`
+}
+
+func (p BatchRunItemPayload) JobType() JobType { return JobTypeBatchRunItem }
+func (p BatchRunItemPayload) ResourceKey() (string, string) {
+	return ResourceTypeBatchRunSlot, fmt.Sprintf("%s/%d", p.BatchRunID, p.Slot)
+}
+func (p BatchRunItemPayload) MaxAttempts() int      { return 1 }
+func (p BatchRunItemPayload) AllowDuplicates() bool { return true }
+
+// ScheduleRunPayload is the payload for schedule_run jobs.
+// Runs of the same schedule never overlap: a run is skipped if the previous
+// one is still pending or running.
+type ScheduleRunPayload struct {
+	ProjectID  string `json:"projectId"`
+	ScheduleID string `json:"scheduleId"`
+	RunID      string `json:"runId"`
+}
+
+func (p ScheduleRunPayload) JobType() JobType { return JobTypeScheduleRun }
+func (p ScheduleRunPayload) ResourceKey() (string, string) {
+	return ResourceTypeSchedule, p.ScheduleID
+}
+func (p ScheduleRunPayload) MaxAttempts() int { return 1 }
+
+// HeadlessRunPayload is the payload for headless_run jobs.
+type HeadlessRunPayload struct {
+	ProjectID string `json:"projectId"`
+	RunID     string `json:"runId"`
+}
+
+func (p HeadlessRunPayload) JobType() JobType { return JobTypeHeadlessRun }
+func (p HeadlessRunPayload) ResourceKey() (string, string) {
+	return ResourceTypeHeadlessRun, p.RunID
+}
+func (p HeadlessRunPayload) MaxAttempts() int { return 1 }
@@ -0,0 +1,316 @@
+// Package logging provides the server's structured logger.
+//
+// Records are written by a single slog handler in text or JSON format.
+// Attributes attached to a context with With or Add (request ID, user,
+// project, session, job) are added to every record logged with that context,
+// as are the trace and span IDs of the context's span. Each component logs
+// through its own logger whose level can be changed at runtime.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Format is a log output format.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// Options configures Setup.
+type Options struct {
+	Format Format
+	// Level is the minimum level of components without their own level.
+	Level slog.Level
+	// Components holds per-component minimum levels.
+	Components map[string]slog.Level
+}
+
+// SensitiveKeys are attribute keys and query parameters whose values are
+// redacted. Keys ending in one of them (e.g. "shared_secret") are redacted
+// too.
+var SensitiveKeys = []string{"token", "password", "api_key", "secret", "apiKey"}
+
+// Redacted replaces sensitive values.
+const Redacted = "[REDACTED]"
+
+// levelConfig is an immutable snapshot of the configured levels.
+type levelConfig struct {
+	def        slog.Level
+	components map[string]slog.Level
+}
+
+var (
+	levels atomic.Pointer[levelConfig]
+	output atomic.Pointer[slog.Handler]
+)
+
+func init() {
+	levels.Store(&levelConfig{def: slog.LevelInfo})
+	h := newOutput(os.Stderr, FormatText)
+	output.Store(&h)
+}
+
+// Setup configures the output and levels and makes the result the default
+// slog logger. Standard library log calls are written through it as well.
+// Loggers created before Setup, e.g. in package variables, switch to the new
+// output.
+func Setup(w io.Writer, opts Options) error {
+	switch opts.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q (want text or json)", opts.Format)
+	}
+	h := newOutput(w, opts.Format)
+	output.Store(&h)
+	SetLevels(opts.Level, opts.Components)
+
+	slog.SetDefault(slog.New(&handler{level: componentLevel("")}))
+	return nil
+}
+
+func newOutput(w io.Writer, format Format) slog.Handler {
+	opts := &slog.HandlerOptions{
+		// Levels are enforced by handler; let everything through here.
+		Level:       slog.Level(-100),
+		ReplaceAttr: redact,
+	}
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Component returns the logger for a component. Its records carry a
+// "component" attribute and are filtered by the component's level.
+func Component(name string) *slog.Logger {
+	h := &handler{level: componentLevel(name)}
+	return slog.New(h.WithAttrs([]slog.Attr{slog.String("component", name)}))
+}
+
+// SetLevels replaces the default level and the per-component levels.
+// Components not in components log at the default level.
+func SetLevels(def slog.Level, components map[string]slog.Level) {
+	levels.Store(&levelConfig{def: def, components: maps.Clone(components)})
+}
+
+// Levels returns the default level and a copy of the per-component levels.
+func Levels() (slog.Level, map[string]slog.Level) {
+	cfg := levels.Load()
+	components := maps.Clone(cfg.components)
+	if components == nil {
+		components = map[string]slog.Level{}
+	}
+	return cfg.def, components
+}
+
+// ParseLevels parses per-component levels written as
+// "component=level,component=level", e.g. "dispatcher=debug,ssh=warn".
+func ParseLevels(s string) (map[string]slog.Level, error) {
+	components := make(map[string]slog.Level)
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected component=level, got %q", entry)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return nil, fmt.Errorf("component %s: %w", name, err)
+		}
+		components[name] = level
+	}
+	return components, nil
+}
+
+// componentLevel reports the current level of a component.
+type componentLevel string
+
+func (c componentLevel) Level() slog.Level {
+	cfg := levels.Load()
+	if level, ok := cfg.components[string(c)]; ok {
+		return level
+	}
+	return cfg.def
+}
+
+// attrSet holds the log attributes of a context. Add mutates it in place so
+// that attributes added by inner middleware reach the access log written by
+// outer middleware.
+type attrSet struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+type attrsKey struct{}
+
+// With returns a context whose records carry args (alternating keys and
+// values, or slog.Attrs) in addition to the attributes of ctx.
+func With(ctx context.Context, args ...any) context.Context {
+	set := &attrSet{attrs: contextAttrs(ctx)}
+	set.add(args)
+	return context.WithValue(ctx, attrsKey{}, set)
+}
+
+// Add adds args to the attributes of ctx's innermost With scope, replacing
+// attributes with the same key. It does nothing if ctx has no With scope.
+func Add(ctx context.Context, args ...any) {
+	if set, ok := ctx.Value(attrsKey{}).(*attrSet); ok {
+		set.add(args)
+	}
+}
+
+func (s *attrSet) add(args []any) {
+	r := slog.Record{}
+	r.Add(args...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.Attrs(func(a slog.Attr) bool {
+		s.attrs = slices.DeleteFunc(s.attrs, func(b slog.Attr) bool { return b.Key == a.Key })
+		s.attrs = append(s.attrs, a)
+		return true
+	})
+}
+
+// contextAttrs returns a copy of the attributes of ctx.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	set, ok := ctx.Value(attrsKey{}).(*attrSet)
+	if !ok {
+		return nil
+	}
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	return slices.Clone(set.attrs)
+}
+
+type loggerKey struct{}
+
+// NewContext returns a context that carries logger, typically the logger of
+// the component serving a request or job, for FromContext.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+// Records logged through it carry the attributes and trace IDs of ctx even
+// when they are logged without a context.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	return slog.New(&boundHandler{Handler: logger.Handler(), ctx: ctx})
+}
+
+// boundHandler logs records without a context of their own with ctx.
+type boundHandler struct {
+	slog.Handler
+	ctx context.Context
+}
+
+func (h *boundHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil || ctx == context.Background() {
+		ctx = h.ctx
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *boundHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &boundHandler{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h *boundHandler) WithGroup(name string) slog.Handler {
+	return &boundHandler{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
+
+// handler filters records by level and adds context attributes before
+// passing them to the output handler. It applies its WithAttrs and
+// WithGroup calls to the current output, so it follows Setup.
+type handler struct {
+	level slog.Leveler
+	ops   []func(slog.Handler) slog.Handler
+	cache atomic.Pointer[builtOutput]
+}
+
+// builtOutput is an output handler with a handler's ops applied.
+type builtOutput struct {
+	base *slog.Handler
+	out  slog.Handler
+}
+
+func (h *handler) out() slog.Handler {
+	base := output.Load()
+	if b := h.cache.Load(); b != nil && b.base == base {
+		return b.out
+	}
+	out := *base
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	h.cache.Store(&builtOutput{base: base, out: out})
+	return out
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) *handler {
+	return &handler{level: h.level, ops: append(slices.Clip(h.ops), op)}
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(contextAttrs(ctx)...)
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()),
+			)
+		}
+	}
+	return h.out().Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+// redact replaces the values of sensitive attributes.
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// IsSensitive reports whether values under key must not be logged.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range SensitiveKeys {
+		if strings.HasSuffix(key, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
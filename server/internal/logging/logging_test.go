@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setup directs logging to a buffer for the duration of the test.
+func setup(t *testing.T, opts Options) *bytes.Buffer {
+	t.Helper()
+	prevOut, prevLevels, prevDefault := output.Load(), levels.Load(), slog.Default()
+	t.Cleanup(func() {
+		output.Store(prevOut)
+		levels.Store(prevLevels)
+		slog.SetDefault(prevDefault)
+	})
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// records decodes the JSON records written to buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid JSON record %q: %v", line, err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestContextAttributes(t *testing.T) {
+	// Created before Setup, as package-level loggers are
+	logger := Component("jobs")
+	buf := setup(t, Options{Format: FormatJSON, Level: slog.LevelInfo})
+
+	ctx := With(context.Background(), "request_id", "req-1")
+	inner := With(ctx, "project_id", "proj-1")
+	Add(ctx, "user_id", "user-1")
+	Add(inner, "session_id", "sess-1")
+
+	logger.InfoContext(ctx, "outer")
+	logger.InfoContext(inner, "inner")
+
+	recs := records(t, buf)
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	outer, in := recs[0], recs[1]
+	if outer["component"] != "jobs" || outer["request_id"] != "req-1" || outer["user_id"] != "user-1" {
+		t.Errorf("outer record = %v", outer)
+	}
+	if _, ok := outer["session_id"]; ok {
+		t.Errorf("attribute added to inner scope leaked to outer: %v", outer)
+	}
+	if in["request_id"] != "req-1" || in["project_id"] != "proj-1" || in["session_id"] != "sess-1" {
+		t.Errorf("inner record = %v", in)
+	}
+	if _, ok := in["user_id"]; ok {
+		t.Errorf("attribute added to outer scope after With reached inner: %v", in)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	buf := setup(t, Options{Format: FormatJSON, Level: slog.LevelInfo})
+
+	ctx := With(context.Background(), "request_id", "req-1")
+	FromContext(ctx).Info("default")
+	ctx = NewContext(ctx, Component("http"))
+	FromContext(ctx).With("status", 200).Info("component")
+	Add(ctx, "user_id", "user-1")
+	FromContext(ctx).InfoContext(With(context.Background(), "job_id", "job-1"), "explicit")
+
+	recs := records(t, buf)
+	if len(recs) != 3 {
+		t.Fatalf("got %d records, want 3", len(recs))
+	}
+	if recs[0]["request_id"] != "req-1" || recs[0]["component"] != nil {
+		t.Errorf("default record = %v", recs[0])
+	}
+	if recs[1]["request_id"] != "req-1" || recs[1]["component"] != "http" || recs[1]["status"] != float64(200) {
+		t.Errorf("component record = %v", recs[1])
+	}
+	if recs[2]["job_id"] != "job-1" || recs[2]["request_id"] != nil {
+		t.Errorf("record logged with its own context = %v", recs[2])
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	buf := setup(t, Options{
+		Format:     FormatJSON,
+		Level:      slog.LevelWarn,
+		Components: map[string]slog.Level{"dispatcher": slog.LevelDebug},
+	})
+	dispatcher, ssh := Component("dispatcher"), Component("ssh")
+
+	dispatcher.Debug("shown")
+	ssh.Info("hidden")
+	ssh.Warn("shown")
+
+	SetLevels(slog.LevelInfo, map[string]slog.Level{"ssh": slog.LevelError})
+	dispatcher.Debug("hidden")
+	dispatcher.Info("shown")
+	ssh.Warn("hidden")
+
+	var msgs []string
+	for _, rec := range records(t, buf) {
+		msgs = append(msgs, rec["component"].(string)+":"+rec["msg"].(string))
+	}
+	want := "dispatcher:shown ssh:shown dispatcher:shown"
+	if got := strings.Join(msgs, " "); got != want {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+
+	def, components := Levels()
+	if def != slog.LevelInfo || len(components) != 1 || components["ssh"] != slog.LevelError {
+		t.Errorf("Levels() = %v, %v", def, components)
+	}
+}
+
+func TestRedactsSensitiveAttributes(t *testing.T) {
+	buf := setup(t, Options{Format: FormatText, Level: slog.LevelInfo})
+
+	slog.Info("connect", "token", "abc", "shared_secret", "def", "user", "alice")
+
+	out := buf.String()
+	if strings.Contains(out, "abc") || strings.Contains(out, "def") {
+		t.Errorf("secret logged: %s", out)
+	}
+	if !strings.Contains(out, "token="+Redacted) || !strings.Contains(out, "user=alice") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestTraceCorrelation(t *testing.T) {
+	buf := setup(t, Options{Format: FormatJSON, Level: slog.LevelInfo})
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	slog.InfoContext(ctx, "traced")
+
+	rec := records(t, buf)[0]
+	if rec["trace_id"] != sc.TraceID().String() || rec["span_id"] != sc.SpanID().String() {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	got, err := ParseLevels(" dispatcher=debug, ssh=WARN ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["dispatcher"] != slog.LevelDebug || got["ssh"] != slog.LevelWarn {
+		t.Errorf("ParseLevels = %v", got)
+	}
+	for _, bad := range []string{"dispatcher", "=debug", "ssh=loud"} {
+		if _, err := ParseLevels(bad); err == nil {
+			t.Errorf("ParseLevels(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package metrics defines the server's Prometheus metrics and serves them
+// in the Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// metricsLog is the logger for metric collection messages.
+var metricsLog = logging.Component("metrics")
+
+const namespace = "discobot"
+
+// collectTimeout bounds the store and provider queries made during a scrape.
+const collectTimeout = 5 * time.Second
+
+// Registry holds every server metric. It is separate from the global
+// Prometheus registry so that only Discobot, Go runtime and process metrics
+// are exported.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration observes API request latency. The route label is
+	// the registered route pattern, or "unmatched".
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	// JobDuration observes how long dispatched jobs run.
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "duration_seconds",
+		Help:      "Job execution time by job type and outcome (completed or failed).",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"type", "outcome"})
+
+	// JobFailures counts failed job executions, including ones that will be
+	// retried.
+	JobFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "failures_total",
+		Help:      "Failed job executions by job type.",
+	}, []string{"type"})
+
+	// JobsRunning is the number of jobs this server is executing.
+	JobsRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "running",
+		Help:      "Jobs executing on this server by job type.",
+	}, []string{"type"})
+
+	// SSESubscribers is the number of open server-sent event streams.
+	SSESubscribers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "sse",
+		Name:      "subscribers",
+		Help:      "Open server-sent event streams by stream.",
+	}, []string{"stream"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		JobDuration,
+		JobFailures,
+		JobsRunning,
+		SSESubscribers,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// TrackSSE counts an open SSE stream until the returned function is called.
+func TrackSSE(stream string) func() {
+	gauge := SSESubscribers.WithLabelValues(stream)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// jobQueueCollector reports queue depth from the jobs table at scrape time,
+// so every server reports the shared queue and not only its own jobs.
+type jobQueueCollector struct {
+	store *store.Store
+	depth *prometheus.Desc
+}
+
+// NewJobQueueCollector returns a collector for job queue depth.
+func NewJobQueueCollector(s *store.Store) prometheus.Collector {
+	return &jobQueueCollector{
+		store: s,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "jobs", "queue_depth"),
+			"Queued jobs by job type and status (pending or running).",
+			[]string{"type", "status"}, nil,
+		),
+	}
+}
+
+func (c *jobQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+}
+
+func (c *jobQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.store.CountJobsByTypeAndStatus(ctx, []model.JobStatus{model.JobStatusPending, model.JobStatusRunning})
+	if err != nil {
+		metricsLog.WarnContext(ctx, "failed to count jobs", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.depth, err)
+		return
+	}
+	for _, jc := range counts {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(jc.Count), jc.Type, jc.Status)
+	}
+}
+
+// sandboxCollector reports sandbox counts from each provider at scrape time.
+type sandboxCollector struct {
+	manager *sandbox.Manager
+	count   *prometheus.Desc
+}
+
+// NewSandboxCollector returns a collector for sandbox counts by provider
+// and status.
+func NewSandboxCollector(manager *sandbox.Manager) prometheus.Collector {
+	return &sandboxCollector{
+		manager: manager,
+		count: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sandboxes", ""),
+			"Sandboxes by provider and status.",
+			[]string{"provider", "status"}, nil,
+		),
+	}
+}
+
+func (c *sandboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+}
+
+func (c *sandboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	for _, name := range c.manager.ListProviders() {
+		if status, _ := c.manager.GetProviderStatus(name); status.State != "ready" {
+			continue
+		}
+		provider, err := c.manager.GetProvider(name)
+		if err != nil {
+			continue
+		}
+		sandboxes, err := provider.List(ctx)
+		if err != nil {
+			metricsLog.WarnContext(ctx, "failed to list sandboxes", "provider", name, "error", err)
+			continue
+		}
+		counts := make(map[sandbox.Status]int)
+		for _, sb := range sandboxes {
+			counts[sb.Status]++
+		}
+		for status, n := range counts {
+			ch <- prometheus.MustNewConstMetric(c.count, prometheus.GaugeValue, float64(n), name, string(status))
+		}
+	}
+}
@@ -3,10 +3,16 @@ package middleware
 import (
 	"context"
 	"crypto/subtle"
+	"net"
 	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/routes"
 	"github.com/obot-platform/discobot/server/internal/service"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
@@ -17,6 +23,12 @@ const (
 	UserKey      contextKey = "user"
 	UserIDKey    contextKey = "userID"
 	UserEmailKey contextKey = "userEmail"
+
+	// TokenScopesKey holds the scopes of the API token used to authenticate
+	// the request. It is unset for cookie-authenticated requests.
+	TokenScopesKey contextKey = "tokenScopes"
+	// TokenIDKey holds the ID of that API token.
+	TokenIDKey contextKey = "tokenID"
 )
 
 const sessionCookieName = "discobot_session"
@@ -63,8 +75,12 @@ func TauriAuth(cfg *config.Config) func(http.Handler) http.Handler {
 
 // Auth middleware validates user authentication.
 // If auth is disabled (cfg.AuthEnabled == false), it uses the anonymous user.
+// Requests may authenticate with the session cookie or with an API token in
+// the "Authorization: Bearer" header; TokenScopeGuard limits token requests
+// to the token's scopes.
 func Auth(s *store.Store, cfg *config.Config) func(http.Handler) http.Handler {
 	authService := service.NewAuthService(s, cfg)
+	tokenService := service.NewTokenService(s)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -78,6 +94,24 @@ func Auth(s *store.Store, cfg *config.Config) func(http.Handler) http.Handler {
 				ctx := context.WithValue(r.Context(), UserKey, anonUser)
 				ctx = context.WithValue(ctx, UserIDKey, anonUser.ID)
 				ctx = context.WithValue(ctx, UserEmailKey, anonUser.Email)
+				logging.Add(ctx, "user_id", anonUser.ID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// API tokens take precedence over the session cookie
+			if raw, ok := bearerToken(r); ok {
+				auth, err := tokenService.ValidateToken(r.Context(), raw)
+				if err != nil {
+					http.Error(w, `{"error":"Invalid or expired token"}`, http.StatusUnauthorized)
+					return
+				}
+				ctx := context.WithValue(r.Context(), UserKey, auth.User)
+				ctx = context.WithValue(ctx, UserIDKey, auth.User.ID)
+				ctx = context.WithValue(ctx, UserEmailKey, auth.User.Email)
+				ctx = context.WithValue(ctx, TokenScopesKey, auth.Scopes)
+				ctx = context.WithValue(ctx, TokenIDKey, auth.TokenID)
+				logging.Add(ctx, "user_id", auth.User.ID)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
@@ -108,12 +142,76 @@ func Auth(s *store.Store, cfg *config.Config) func(http.Handler) http.Handler {
 			ctx := context.WithValue(r.Context(), UserKey, user)
 			ctx = context.WithValue(ctx, UserIDKey, user.ID)
 			ctx = context.WithValue(ctx, UserEmailKey, user.Email)
+			logging.Add(ctx, "user_id", user.ID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// bearerToken returns the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// ClientIP returns the caller's IP address without the port. The RealIP
+// middleware has already applied any X-Forwarded-For/X-Real-IP header.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// TokenScopeGuard returns a routes.Guard that refuses token requests to
+// routes outside the token's scopes. The scope a route needs follows from
+// its declared permission (see service.RequiredTokenScope). Refusals are
+// audited.
+func TokenScopeGuard(s *store.Store) routes.Guard {
+	auditService := service.NewAuditService(s)
+
+	return func(w http.ResponseWriter, r *http.Request, permission string) bool {
+		scopes, ok := r.Context().Value(TokenScopesKey).([]string)
+		if !ok {
+			return true
+		}
+		required := service.RequiredTokenScope(r.Method, permission)
+		if service.TokenScopesAllow(scopes, required) {
+			return true
+		}
+
+		tokenID, _ := r.Context().Value(TokenIDKey).(string)
+		auditService.Record(r.Context(), service.AuditEntry{
+			ProjectID:  chi.URLParam(r, "projectId"),
+			ActorID:    GetUserID(r.Context()),
+			ActorEmail: GetUserEmail(r.Context()),
+			Action:     model.AuditActionTokenScopeDenied,
+			TargetType: "token",
+			TargetID:   tokenID,
+			IP:         ClientIP(r),
+			UserAgent:  r.UserAgent(),
+			Metadata:   map[string]any{"method": r.Method, "path": r.URL.Path, "requiredScope": required},
+		})
+		http.Error(w, `{"error":"Token scope does not permit this request"}`, http.StatusForbidden)
+		return false
+	}
+}
+
+// GetTokenScopes extracts the API token scopes from context. Returns nil for
+// requests that were not authenticated with a token.
+func GetTokenScopes(ctx context.Context) []string {
+	if scopes, ok := ctx.Value(TokenScopesKey).([]string); ok {
+		return scopes
+	}
+	return nil
+}
+
 // GetUser extracts user from context
 func GetUser(ctx context.Context) *service.User {
 	if user, ok := ctx.Value(UserKey).(*service.User); ok {
@@ -164,6 +262,7 @@ func OptionalAuth(s *store.Store, cfg *config.Config) func(http.Handler) http.Ha
 			ctx := context.WithValue(r.Context(), UserKey, user)
 			ctx = context.WithValue(ctx, UserIDKey, user.ID)
 			ctx = context.WithValue(ctx, UserEmailKey, user.Email)
+			logging.Add(ctx, "user_id", user.ID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/routes"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+func TestTokenScopeGuard(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(model.AllModels()...); err != nil {
+		t.Fatal(err)
+	}
+	s := store.New(db, nil)
+
+	r := chi.NewRouter()
+	reg := routes.NewRegistry()
+	reg.SetAuthorizer(func(context.Context, string) bool { return true })
+	reg.SetGuard(TokenScopeGuard(s))
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	for _, route := range []routes.Route{
+		{Method: "GET", Pattern: "/api/projects", Handler: ok},
+		{Method: "POST", Pattern: "/api/tokens", Handler: ok},
+		{Method: "GET", Pattern: "/api/projects/{projectId}/sessions/{sessionId}/messages", Handler: ok, Meta: routes.Meta{Permission: model.PermissionProjectView}},
+		{Method: "POST", Pattern: "/api/projects/{projectId}/chat", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionChat}},
+		{Method: "POST", Pattern: "/api/projects/{projectId}/sessions", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionCreate}},
+		{Method: "DELETE", Pattern: "/api/projects/{projectId}/sessions/{sessionId}", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionDelete}},
+		{Method: "PUT", Pattern: "/api/projects/{projectId}/sessions/{sessionId}/files/write", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionFiles}},
+		{Method: "POST", Pattern: "/api/projects/{projectId}/sessions/{sessionId}/files/delete", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionFiles}},
+		{Method: "POST", Pattern: "/api/projects/{projectId}/sessions/{sessionId}/commit", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionCommit}},
+		{Method: "GET", Pattern: "/api/projects/{projectId}/sessions/{sessionId}/terminal/ws", Handler: ok, Meta: routes.Meta{Permission: model.PermissionSessionTerminal}},
+	} {
+		reg.Register(r, route)
+	}
+
+	tests := []struct {
+		scopes []string
+		method string
+		path   string
+		want   int
+	}{
+		{nil, "PUT", "/api/projects/p1/sessions/s1/files/write", http.StatusOK},
+		{[]string{"read"}, "GET", "/api/projects", http.StatusOK},
+		{[]string{"read"}, "GET", "/api/projects/p1/sessions/s1/messages", http.StatusOK},
+		{[]string{"read"}, "POST", "/api/projects/p1/chat", http.StatusForbidden},
+		{[]string{"chat"}, "POST", "/api/projects/p1/chat", http.StatusOK},
+		{[]string{"chat"}, "POST", "/api/projects/p1/sessions", http.StatusOK},
+		{[]string{"chat"}, "PUT", "/api/projects/p1/sessions/s1/files/write", http.StatusForbidden},
+		{[]string{"chat"}, "POST", "/api/projects/p1/sessions/s1/files/delete", http.StatusForbidden},
+		{[]string{"chat"}, "DELETE", "/api/projects/p1/sessions/s1", http.StatusForbidden},
+		{[]string{"chat"}, "POST", "/api/projects/p1/sessions/s1/commit", http.StatusForbidden},
+		{[]string{"chat"}, "GET", "/api/projects/p1/sessions/s1/terminal/ws", http.StatusForbidden},
+		{[]string{"chat"}, "POST", "/api/tokens", http.StatusForbidden},
+		{[]string{"admin"}, "PUT", "/api/projects/p1/sessions/s1/files/write", http.StatusOK},
+		{[]string{"admin"}, "POST", "/api/tokens", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s %s", tt.scopes, tt.method, tt.path), func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.scopes != nil {
+				ctx := context.WithValue(req.Context(), TokenScopesKey, tt.scopes)
+				req = req.WithContext(context.WithValue(ctx, TokenIDKey, "tok1"))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	denied, err := s.ListAuditLogs(context.Background(), store.AuditLogFilter{Action: model.AuditActionTokenScopeDenied})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(denied) != 7 {
+		t.Errorf("recorded %d scope denials, want 7", len(denied))
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer dsc_abc", "dsc_abc", true},
+		{"bearer dsc_abc", "dsc_abc", true},
+		{"Basic dXNlcjpwYXNz", "", false},
+		{"Bearer ", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/projects", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		token, ok := bearerToken(r)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("bearerToken(%q) = (%q, %v), want (%q, %v)", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
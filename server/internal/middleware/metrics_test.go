@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/obot-platform/discobot/server/internal/metrics"
+	"github.com/obot-platform/discobot/server/internal/routes"
+)
+
+func TestMetricsLabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Metrics)
+	routes.NewRegistry().Register(r, routes.Route{
+		Method: "GET", Pattern: "/api/widgets/{widgetId}",
+		Handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) },
+		Meta:    routes.Meta{Group: "Widgets", Description: "Get widget"},
+	})
+
+	for _, path := range []string{"/api/widgets/1", "/api/widgets/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if n := requestCount(t, "GET", "/api/widgets/{widgetId}", "418"); n != 2 {
+		t.Errorf("matched requests = %d, want 2", n)
+	}
+	if n := requestCount(t, "GET", "unmatched", "404"); n != 1 {
+		t.Errorf("unmatched requests = %d, want 1", n)
+	}
+}
+
+// requestCount returns the number of requests observed in one series.
+func requestCount(t *testing.T, labels ...string) uint64 {
+	t.Helper()
+	observer, err := metrics.HTTPRequestDuration.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m dto.Metric
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/service"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
@@ -67,13 +68,8 @@ func GetProjectRole(ctx context.Context) string {
 	return ""
 }
 
-// IsProjectOwner checks if user is project owner
-func IsProjectOwner(ctx context.Context) bool {
-	return GetProjectRole(ctx) == "owner"
-}
-
-// IsProjectAdmin checks if user is project admin or owner
-func IsProjectAdmin(ctx context.Context) bool {
-	role := GetProjectRole(ctx)
-	return role == "owner" || role == "admin"
+// HasProjectPermission reports whether the user's project role grants
+// permission. It is the authorizer for the route registry.
+func HasProjectPermission(ctx context.Context, permission string) bool {
+	return model.RoleHasPermission(GetProjectRole(ctx), permission)
 }
@@ -1,50 +1,50 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
 )
 
 // SensitiveQueryParams are query parameters that should be redacted in logs
-var SensitiveQueryParams = []string{"token", "password", "api_key", "secret", "apiKey"}
+var SensitiveQueryParams = logging.SensitiveKeys
 
-// SanitizedLogger is a middleware that logs HTTP requests with sensitive query params redacted
+// SanitizedLogger is a middleware that starts the request's log context,
+// carrying its request ID and the "http" logger, and writes an access log record for each request
+// with sensitive query params redacted. Inner middleware and handlers add
+// attributes (user, project, session) to the context with logging.Add; they
+// are included in the access log.
 func SanitizedLogger(next http.Handler) http.Handler {
+	logger := logging.Component("http")
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 		t1 := time.Now()
+		ctx := logging.With(r.Context(), "request_id", middleware.GetReqID(r.Context()))
+		ctx = logging.NewContext(ctx, logger)
 
 		defer func() {
-			// Redact sensitive query parameters
-			sanitizedURL := redactSensitiveParams(r.URL)
-
 			scheme := "http"
 			if r.TLS != nil {
 				scheme = "https"
 			}
 
-			reqID := middleware.GetReqID(r.Context())
-			timestamp := time.Now().Format("2006/01/02 15:04:05")
-			fmt.Printf("%s [%s] \"%s %s://%s%s %s\" from %s - %d %dB in %v\n",
-				timestamp,
-				reqID,
-				r.Method,
-				scheme,
-				r.Host,
-				sanitizedURL,
-				r.Proto,
-				r.RemoteAddr,
-				ww.Status(),
-				ww.BytesWritten(),
-				time.Since(t1),
+			logger.InfoContext(ctx, "request",
+				"method", r.Method,
+				"url", scheme+"://"+r.Host+redactSensitiveParams(r.URL),
+				"proto", r.Proto,
+				"remote", r.RemoteAddr,
+				"status", ww.Status(),
+				"bytes", ww.BytesWritten(),
+				"duration", time.Since(t1),
 			)
 		}()
 
-		next.ServeHTTP(ww, r)
+		next.ServeHTTP(ww, r.WithContext(ctx))
 	})
 }
 
@@ -59,7 +59,7 @@ func redactSensitiveParams(u *url.URL) string {
 
 	for _, param := range SensitiveQueryParams {
 		if query.Has(param) {
-			query.Set(param, "[REDACTED]")
+			query.Set(param, logging.Redacted)
 			hasRedacted = true
 		}
 	}
@@ -3,13 +3,13 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"regexp"
 	"strings"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
@@ -45,6 +45,8 @@ func findSessionID(ctx context.Context, provider sandbox.Provider, urlSessionID
 
 // ServiceProxy creates middleware that intercepts requests to service subdomains
 // and proxies them to the agent-api's HTTP proxy endpoint using httputil.ReverseProxy.
+// Connections to the agent-api are streams on the sandbox's multiplexed agent
+// tunnel (see sandbox.Tunnels).
 //
 // Subdomain format: {session-id}-svc-{service-id}.{base-domain}
 // Example: 01HXYZ123456789ABCDEFGHIJ-svc-myservice.localhost:3000
@@ -59,6 +61,7 @@ func findSessionID(ctx context.Context, provider sandbox.Provider, urlSessionID
 // - Chunked transfer encoding
 // - Request/response streaming
 func ServiceProxy(provider sandbox.Provider) func(http.Handler) http.Handler {
+	tunnels := sandbox.NewTunnels(provider)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Check both Host and X-Forwarded-Host for service subdomains.
@@ -103,15 +106,22 @@ func ServiceProxy(provider sandbox.Provider) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Get HTTP client for the sandbox (handles transport-level routing)
-			client, err := provider.HTTPClient(ctx, sessionID)
-			if err != nil {
-				writeJSONError(w, http.StatusBadGateway, "Failed to connect to sandbox", map[string]string{
-					"sessionId": sessionID,
-					"serviceId": serviceID,
-					"message":   err.Error(),
-				})
-				return
+			// Reach the agent-api over the sandbox's agent tunnel. Providers
+			// without tunnels (local) are reached with their HTTP client.
+			var transport http.RoundTripper
+			if sandbox.SupportsTunnel(ctx, provider, sessionID) {
+				transport = tunnels.AgentTransport(sessionID)
+			} else {
+				client, err := provider.HTTPClient(ctx, sessionID)
+				if err != nil {
+					writeJSONError(w, http.StatusBadGateway, "Failed to connect to sandbox", map[string]string{
+						"sessionId": sessionID,
+						"serviceId": serviceID,
+						"message":   err.Error(),
+					})
+					return
+				}
+				transport = client.Transport
 			}
 
 			// Target URL for the agent-api
@@ -151,9 +161,9 @@ func ServiceProxy(provider sandbox.Provider) func(http.Handler) http.Handler {
 						req.Header.Set("X-Forwarded-For", clientIP)
 					}
 				},
-				Transport: client.Transport,
+				Transport: transport,
 				ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
-					log.Printf("[ServiceProxy] Error proxying request to %s: %v", r.URL.String(), err)
+					logging.FromContext(r.Context()).Warn("service proxy request failed", "url", r.URL.String(), "error", err)
 					writeJSONError(w, http.StatusBadGateway, "Service unavailable", map[string]string{
 						"sessionId": sessionID,
 						"serviceId": serviceID,
@@ -2,13 +2,17 @@ package middleware
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/obot-platform/discobot/agent/tunnel"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
@@ -131,6 +135,14 @@ func TestServiceSubdomainPattern(t *testing.T) {
 type mockSandboxProvider struct {
 	sandboxes map[string]*sandbox.Sandbox
 	client    *http.Client
+
+	// backend is where streams of the agent tunnel connect; noTunnel makes
+	// the provider report that it has no tunnel, like the local provider.
+	backend  string
+	noTunnel bool
+
+	mu     sync.Mutex
+	dialed []string
 }
 
 func (m *mockSandboxProvider) ImageExists(_ context.Context) bool {
@@ -184,8 +196,25 @@ func (m *mockSandboxProvider) Attach(_ context.Context, _ string, _ sandbox.Atta
 	return nil, nil
 }
 
-func (m *mockSandboxProvider) ExecStream(_ context.Context, _ string, _ []string, _ sandbox.ExecStreamOptions) (sandbox.Stream, error) {
-	return nil, nil
+func (m *mockSandboxProvider) ExecStream(_ context.Context, _ string, cmd []string, _ sandbox.ExecStreamOptions) (sandbox.Stream, error) {
+	if len(cmd) != 3 || cmd[0] != sandbox.AgentBinary || cmd[1] != "tunnel" || cmd[2] != "stdio" {
+		return nil, fmt.Errorf("unexpected command: %v", cmd)
+	}
+	clientEnd, serverEnd := net.Pipe()
+	tunnel.Server(serverEnd, &tunnel.Config{
+		Dial: func(ctx context.Context, target string) (io.ReadWriteCloser, error) {
+			m.mu.Lock()
+			m.dialed = append(m.dialed, target)
+			m.mu.Unlock()
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", m.backend)
+		},
+	})
+	return &tunnelStream{Conn: clientEnd}, nil
+}
+
+func (m *mockSandboxProvider) SupportsTunnel(_ context.Context, _ string) bool {
+	return !m.noTunnel
 }
 
 func (m *mockSandboxProvider) HTTPClient(_ context.Context, _ string) (*http.Client, error) {
@@ -290,23 +319,13 @@ func TestServiceProxyNestedSubdomains(t *testing.T) {
 	}))
 	defer backend.Close()
 
-	// Create a transport that redirects "sandbox" to the test backend
+	// The sandbox's tunnel connects to the test backend
 	backendURL, _ := url.Parse(backend.URL)
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{}).DialContext,
-	}
-	// Use a custom RoundTripper that rewrites the host
 	provider := &mockSandboxProvider{
 		sandboxes: map[string]*sandbox.Sandbox{
 			outerSessionID: {SessionID: outerSessionID},
 		},
-		client: &http.Client{
-			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
-				req.URL.Scheme = backendURL.Scheme
-				req.URL.Host = backendURL.Host
-				return transport.RoundTrip(req)
-			}),
-		},
+		backend: backendURL.Host,
 	}
 
 	next := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
@@ -332,39 +351,85 @@ func TestServiceProxyNestedSubdomains(t *testing.T) {
 	if proxiedPath != wantPath {
 		t.Errorf("proxied path = %q, want %q", proxiedPath, wantPath)
 	}
+	// The request reached the agent-api through the agent tunnel
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if len(provider.dialed) != 1 || provider.dialed[0] != "localhost:3002" {
+		t.Errorf("tunnel dialed %v, want [localhost:3002]", provider.dialed)
+	}
 }
 
-// TestServiceProxyXForwardedHost verifies that X-Forwarded-Host is checked
-// when the Host header doesn't contain a valid service subdomain.
-func TestServiceProxyXForwardedHost(t *testing.T) {
+// TestServiceProxyWithoutTunnel verifies that sandboxes whose provider has no
+// agent tunnel are reached with the provider's HTTP client.
+func TestServiceProxyWithoutTunnel(t *testing.T) {
 	sessionID := "zivnuflwywnlfxkr"
 
 	var proxiedPath string
-	var proxiedXFwdHost string
 	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		proxiedPath = r.URL.Path
-		proxiedXFwdHost = r.Header.Get("X-Forwarded-Host")
 		w.WriteHeader(http.StatusOK)
 	}))
 	defer backend.Close()
 
 	backendURL, _ := url.Parse(backend.URL)
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{}).DialContext,
-	}
 	provider := &mockSandboxProvider{
 		sandboxes: map[string]*sandbox.Sandbox{
 			sessionID: {SessionID: sessionID},
 		},
+		noTunnel: true,
 		client: &http.Client{
 			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
 				req.URL.Scheme = backendURL.Scheme
 				req.URL.Host = backendURL.Host
-				return transport.RoundTrip(req)
+				return http.DefaultTransport.RoundTrip(req)
 			}),
 		},
 	}
 
+	next := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("next handler should not be called for a service subdomain")
+	})
+
+	host := sessionID + "-svc-api.localhost:3001"
+	req := httptest.NewRequest("GET", "http://"+host+"/some/path", nil)
+	req.Host = host
+	rr := httptest.NewRecorder()
+
+	ServiceProxy(provider)(next).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if proxiedPath != "/services/api/http/some/path" {
+		t.Errorf("proxied path = %q", proxiedPath)
+	}
+	if len(provider.dialed) != 0 {
+		t.Errorf("tunnel used without tunnel support: %v", provider.dialed)
+	}
+}
+
+// TestServiceProxyXForwardedHost verifies that X-Forwarded-Host is checked
+// when the Host header doesn't contain a valid service subdomain.
+func TestServiceProxyXForwardedHost(t *testing.T) {
+	sessionID := "zivnuflwywnlfxkr"
+
+	var proxiedPath string
+	var proxiedXFwdHost string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedPath = r.URL.Path
+		proxiedXFwdHost = r.Header.Get("X-Forwarded-Host")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	backendURL, _ := url.Parse(backend.URL)
+	provider := &mockSandboxProvider{
+		sandboxes: map[string]*sandbox.Sandbox{
+			sessionID: {SessionID: sessionID},
+		},
+		backend: backendURL.Host,
+	}
+
 	next := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
 		t.Error("next handler should not be called when X-Forwarded-Host has valid service subdomain")
 	})
@@ -395,6 +460,17 @@ func TestServiceProxyXForwardedHost(t *testing.T) {
 	}
 }
 
+// tunnelStream is a sandbox.Stream backed by an in-memory connection to a
+// tunnel server, simulating "discobot-agent tunnel stdio" in the sandbox.
+type tunnelStream struct {
+	net.Conn
+}
+
+func (s *tunnelStream) Stderr() io.Reader                        { return nil }
+func (s *tunnelStream) Resize(_ context.Context, _, _ int) error { return nil }
+func (s *tunnelStream) CloseWrite() error                        { return nil }
+func (s *tunnelStream) Wait(_ context.Context) (int, error)      { return 0, nil }
+
 // roundTripperFunc adapts a function to http.RoundTripper.
 type roundTripperFunc func(*http.Request) (*http.Response, error)
 
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/service"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// SessionShareKey holds the share link that authorized a request.
+const SessionShareKey contextKey = "sessionShare"
+
+// SessionShare middleware authorizes requests with a session share token in
+// the {shareToken} path parameter. It replaces Auth and ProjectMember for the
+// share routes: the request gets the viewer role in the share's project and
+// the shared session as its {sessionId}, and only reads are allowed.
+func SessionShare(s *store.Store) func(http.Handler) http.Handler {
+	shareService := service.NewShareService(s)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, `{"error":"Share links are read-only"}`, http.StatusMethodNotAllowed)
+				return
+			}
+
+			share, err := shareService.ValidateShare(r.Context(), chi.URLParam(r, "shareToken"))
+			if err != nil {
+				http.Error(w, `{"error":"Share link not found or expired"}`, http.StatusNotFound)
+				return
+			}
+
+			// Handlers read the session from the path, so pin it to the share's
+			// session; a share link never reaches any other session.
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				rctx.URLParams.Add("sessionId", share.SessionID)
+			}
+
+			ctx := context.WithValue(r.Context(), ProjectIDKey, share.ProjectID)
+			ctx = context.WithValue(ctx, ProjectRoleKey, model.RoleViewer)
+			ctx = context.WithValue(ctx, SessionShareKey, share)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetSessionShare extracts the share link from context. Returns nil for
+// requests that were not authorized with a share link.
+func GetSessionShare(ctx context.Context) *model.SessionShare {
+	if share, ok := ctx.Value(SessionShareKey).(*model.SessionShare); ok {
+		return share
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/obot-platform/discobot/server/internal/routes"
+	"github.com/obot-platform/discobot/server/internal/tracing"
+)
+
+func TestTracingContinuesCallerTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.Install(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	// Stands in for the sandbox: records the trace context it receives
+	var received string
+	sandbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer sandbox.Close()
+	client := tracing.WrapClient(sandbox.Client())
+
+	r := chi.NewRouter()
+	r.Use(Metrics)
+	r.Use(Tracing)
+	routes.NewRegistry().Register(r, routes.Route{
+		Method: "GET", Pattern: "/api/widgets/{widgetId}",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			req, _ := http.NewRequestWithContext(r.Context(), "GET", sandbox.URL+"/chat", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Errorf("sandbox request: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+		Meta: routes.Meta{Group: "Widgets", Description: "Get widget"},
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/api/widgets/7", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	clientSpan, server := spans[0], spans[1]
+	if server.Name != "GET /api/widgets/{widgetId}" {
+		t.Errorf("server span name = %q", server.Name)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("server span trace = %s, want %s", got, traceID)
+	}
+	if got := server.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("server span parent = %s, want caller's span", got)
+	}
+	if !hasAttr(server.Attributes, attribute.Int("http.response.status_code", 500)) {
+		t.Errorf("server span attributes = %v", server.Attributes)
+	}
+	if server.Status.Code != codes.Error {
+		t.Errorf("server span status = %v, want Error", server.Status.Code)
+	}
+	if clientSpan.Name != "sandbox GET /chat" || clientSpan.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("client span = %q, parent %s", clientSpan.Name, clientSpan.Parent.SpanID())
+	}
+	want := "00-" + traceID + "-" + clientSpan.SpanContext.SpanID().String()
+	if !strings.HasPrefix(received, want) {
+		t.Errorf("sandbox received traceparent %q, want prefix %q", received, want)
+	}
+}
+
+func hasAttr(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if kv == want {
+			return true
+		}
+	}
+	return false
+}
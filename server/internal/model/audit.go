@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit action constants.
+const (
+	AuditActionCredentialCreate     = "credential.create"
+	AuditActionCredentialDelete     = "credential.delete"
+	AuditActionCredentialRefresh    = "credential.refresh"
+	AuditActionTerminalOpen         = "terminal.open"
+	AuditActionSSHConnect           = "ssh.connect"
+	AuditActionFileWrite            = "file.write"
+	AuditActionFileDelete           = "file.delete"
+	AuditActionFileRename           = "file.rename"
+	AuditActionSessionCommit        = "session.commit"
+	AuditActionSessionCommitApply   = "session.commit_apply"
+	AuditActionSessionDelete        = "session.delete"
+	AuditActionSessionShareCreate   = "session.share_create"
+	AuditActionSessionShareRevoke   = "session.share_revoke"
+	AuditActionWorkspaceCommit      = "workspace.commit"
+	AuditActionWorkspaceDelete      = "workspace.delete"
+	AuditActionMemberRemove         = "member.remove"
+	AuditActionInvitationCreate     = "invitation.create"
+	AuditActionInvitationResend     = "invitation.resend"
+	AuditActionInvitationRevoke     = "invitation.revoke"
+	AuditActionProjectDelete        = "project.delete"
+	AuditActionServiceAccountCreate = "service_account.create"
+	AuditActionServiceAccountDelete = "service_account.delete"
+	AuditActionTokenCreate          = "token.create"
+	AuditActionTokenRevoke          = "token.revoke"
+	AuditActionTokenScopeDenied     = "token.scope_denied"
+)
+
+// AuditLog is an append-only record of a security-relevant action.
+// Entries are never updated, only pruned once older than the retention period.
+type AuditLog struct {
+	ID         string          `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID  *string         `gorm:"column:project_id;type:text;index" json:"projectId,omitempty"` // Nil for actions outside a project
+	ActorID    *string         `gorm:"column:actor_id;type:text;index" json:"actorId,omitempty"`     // Nil for unauthenticated actors (e.g. SSH)
+	ActorEmail string          `gorm:"column:actor_email;type:text" json:"actorEmail,omitempty"`
+	Action     string          `gorm:"not null;type:text;index" json:"action"`
+	TargetType string          `gorm:"column:target_type;type:text" json:"targetType,omitempty"`
+	TargetID   string          `gorm:"column:target_id;type:text" json:"targetId,omitempty"`
+	IP         string          `gorm:"column:ip;type:text" json:"ip,omitempty"`
+	UserAgent  string          `gorm:"column:user_agent;type:text" json:"userAgent,omitempty"`
+	Metadata   json.RawMessage `gorm:"type:text" json:"metadata,omitempty"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime;index" json:"createdAt"`
+}
+
+// TableName returns the table name for AuditLog.
+func (AuditLog) TableName() string { return "audit_logs" }
+
+// BeforeCreate generates a UUID if not set.
+func (a *AuditLog) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
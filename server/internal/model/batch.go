@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Batch run status constants. A batch run's status is derived from its items.
+const (
+	BatchRunStatusPending   = "pending"   // No item has started yet
+	BatchRunStatusRunning   = "running"   // At least one item is pending or running
+	BatchRunStatusCompleted = "completed" // All items finished (succeeded, failed or cancelled)
+	BatchRunStatusCancelled = "cancelled" // All items finished and some were cancelled
+)
+
+// Batch run item status constants.
+const (
+	BatchRunItemStatusPending   = "pending"
+	BatchRunItemStatusRunning   = "running"
+	BatchRunItemStatusSucceeded = "succeeded"
+	BatchRunItemStatusFailed    = "failed"
+	BatchRunItemStatusCancelled = "cancelled"
+)
+
+// BatchRun fans a single prompt out across a matrix of agents, models,
+// reasoning modes and workspaces. Each combination is a BatchRunItem that
+// runs in its own session.
+type BatchRun struct {
+	ID          string    `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID   string    `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	Name        string    `gorm:"not null;type:text" json:"name"`
+	Prompt      string    `gorm:"not null;type:text" json:"prompt"`
+	Concurrency int       `gorm:"not null;default:1" json:"concurrency"`
+	AutoCommit  bool      `gorm:"column:auto_commit;not null;default:false" json:"autoCommit"`
+	Status      string    `gorm:"not null;type:text;default:pending" json:"status"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Items []BatchRunItem `gorm:"foreignKey:BatchRunID" json:"items,omitempty"`
+}
+
+// TableName returns the table name for BatchRun.
+func (BatchRun) TableName() string { return "batch_runs" }
+
+// BeforeCreate generates a UUID if not set.
+func (b *BatchRun) BeforeCreate(_ *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BatchRunItem is one cell of a batch run's matrix.
+type BatchRunItem struct {
+	ID          string    `gorm:"primaryKey;type:text" json:"id"`
+	BatchRunID  string    `gorm:"column:batch_run_id;not null;type:text;index" json:"batchRunId"`
+	Position    int       `gorm:"not null" json:"position"`
+	WorkspaceID string    `gorm:"column:workspace_id;not null;type:text" json:"workspaceId"`
+	AgentID     string    `gorm:"column:agent_id;not null;type:text" json:"agentId"`
+	Model       *string   `gorm:"type:text" json:"model,omitempty"`
+	Reasoning   *string   `gorm:"type:text" json:"reasoning,omitempty"`
+	SessionID   *string   `gorm:"column:session_id;type:text" json:"sessionId,omitempty"`
+	Status      string    `gorm:"not null;type:text;default:pending" json:"status"`
+	Error       *string   `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// TableName returns the table name for BatchRunItem.
+func (BatchRunItem) TableName() string { return "batch_run_items" }
+
+// BeforeCreate generates a UUID if not set.
+func (i *BatchRunItem) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IsTerminal returns true if the item will not run again.
+func (i *BatchRunItem) IsTerminal() bool {
+	switch i.Status {
+	case BatchRunItemStatusSucceeded, BatchRunItemStatusFailed, BatchRunItemStatusCancelled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Headless run status constants.
+const (
+	HeadlessRunStatusPending   = "pending"
+	HeadlessRunStatusRunning   = "running"
+	HeadlessRunStatusSucceeded = "succeeded"
+	HeadlessRunStatusFailed    = "failed"
+	HeadlessRunStatusTimedOut  = "timed_out" // The run exceeded its max duration
+)
+
+// HeadlessRun runs a single prompt in a new session to completion without a
+// client attached, for CI pipelines. Result holds the JSON-encoded outcome
+// (diff stats, hook results, usage, patch or commit) once the run finishes.
+type HeadlessRun struct {
+	ID           string          `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID    string          `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	WorkspaceID  string          `gorm:"column:workspace_id;not null;type:text" json:"workspaceId"`
+	AgentID      string          `gorm:"column:agent_id;not null;type:text" json:"agentId"`
+	Model        *string         `gorm:"type:text" json:"model,omitempty"`
+	Reasoning    *string         `gorm:"type:text" json:"reasoning,omitempty"`
+	Prompt       string          `gorm:"not null;type:text" json:"prompt"`
+	AnswerPolicy string          `gorm:"column:answer_policy;not null;type:text" json:"answerPolicy"` // QuestionPolicy mode for the session; empty inherits the workspace's
+	MaxDuration  int             `gorm:"column:max_duration;not null" json:"maxDurationSeconds"`
+	RequireHooks bool            `gorm:"column:require_hooks;not null" json:"requireHooks"`
+	Commit       bool            `gorm:"not null;default:false" json:"commit"` // Commit changes to the workspace on success
+	SessionID    *string         `gorm:"column:session_id;type:text" json:"sessionId,omitempty"`
+	Status       string          `gorm:"not null;type:text;default:pending" json:"status"`
+	Error        *string         `gorm:"type:text" json:"error,omitempty"`
+	Result       json.RawMessage `gorm:"type:text" json:"-"`
+	StartedAt    *time.Time      `gorm:"column:started_at" json:"startedAt,omitempty"`
+	CompletedAt  *time.Time      `gorm:"column:completed_at" json:"completedAt,omitempty"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// TableName returns the table name for HeadlessRun.
+func (HeadlessRun) TableName() string { return "headless_runs" }
+
+// BeforeCreate generates a UUID if not set.
+func (r *HeadlessRun) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IsTerminal returns true if the run has finished.
+func (r *HeadlessRun) IsTerminal() bool {
+	switch r.Status {
+	case HeadlessRunStatusSucceeded, HeadlessRunStatusFailed, HeadlessRunStatusTimedOut:
+		return true
+	}
+	return false
+}
@@ -40,6 +40,10 @@ type Job struct {
 	// Example: resource_type="session", resource_id="abc123"
 	ResourceType *string `gorm:"column:resource_type;type:text;index:idx_job_resource" json:"resource_type,omitempty"`
 	ResourceID   *string `gorm:"column:resource_id;type:text;index:idx_job_resource" json:"resource_id,omitempty"`
+
+	// TraceParent is the W3C traceparent of the span that enqueued the job,
+	// so the job's execution joins the enqueuing trace.
+	TraceParent *string `gorm:"column:trace_parent;type:text" json:"trace_parent,omitempty"`
 }
 
 // TableName returns the table name for Job.
@@ -59,6 +59,12 @@ type Project struct {
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
+	// CacheQuotaBytes caps the project's cache volume; least recently used
+	// cache entries are pruned when a sandbox starts (0 = no limit).
+	CacheQuotaBytes int64 `gorm:"column:cache_quota_bytes;not null;default:0" json:"cache_quota_bytes"`
+	// CacheMaxAgeDays prunes cache entries unused for longer (0 = no limit).
+	CacheMaxAgeDays int `gorm:"column:cache_max_age_days;not null;default:0" json:"cache_max_age_days"`
+
 	Members    []ProjectMember `gorm:"foreignKey:ProjectID" json:"-"`
 	Workspaces []Workspace     `gorm:"foreignKey:ProjectID" json:"-"`
 	Agents     []Agent         `gorm:"foreignKey:ProjectID" json:"-"`
@@ -104,8 +110,10 @@ type ProjectInvitation struct {
 	Role      string    `gorm:"not null;type:text;default:member" json:"role"`
 	InvitedBy *string   `gorm:"column:invited_by;type:text" json:"invited_by,omitempty"`
 	Token     string    `gorm:"uniqueIndex;not null;type:text" json:"token"`
-	ExpiresAt time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	// LastSentAt is when the invitation email was last delivered, nil if never.
+	LastSentAt *time.Time `gorm:"column:last_sent_at" json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
 
 	Project *Project `gorm:"foreignKey:ProjectID" json:"-"`
 }
@@ -159,16 +167,21 @@ const (
 
 // Workspace represents a working directory (local folder or git repo).
 type Workspace struct {
-	ID           string    `gorm:"primaryKey;type:text" json:"id"`
-	ProjectID    string    `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
-	Path         string    `gorm:"not null;type:text" json:"path"`
-	DisplayName  *string   `gorm:"column:display_name;type:text" json:"displayName,omitempty"`
-	SourceType   string    `gorm:"column:source_type;not null;type:text" json:"sourceType"`
-	Provider     string    `gorm:"type:text;default:''" json:"provider,omitempty"`
-	Status       string    `gorm:"not null;type:text;default:initializing" json:"status"`
-	ErrorMessage *string   `gorm:"column:error_message;type:text" json:"errorMessage,omitempty"`
-	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+	ID             string          `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID      string          `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	Path           string          `gorm:"not null;type:text" json:"path"`
+	DisplayName    *string         `gorm:"column:display_name;type:text" json:"displayName,omitempty"`
+	SourceType     string          `gorm:"column:source_type;not null;type:text" json:"sourceType"`
+	Provider       string          `gorm:"type:text;default:''" json:"provider,omitempty"`
+	Status         string          `gorm:"not null;type:text;default:initializing" json:"status"`
+	ErrorMessage   *string         `gorm:"column:error_message;type:text" json:"errorMessage,omitempty"`
+	QuestionPolicy json.RawMessage `gorm:"column:question_policy;type:text" json:"-"` // JSON-encoded QuestionPolicy
+	// SandboxImage is the image built from the workspace's devcontainer.json; nil uses the default
+	SandboxImage      *string         `gorm:"column:sandbox_image;type:text" json:"sandboxImage,omitempty"`
+	Devcontainer      json.RawMessage `gorm:"column:devcontainer;type:text" json:"-"` // JSON-encoded devcontainer.Spec
+	DevcontainerError *string         `gorm:"column:devcontainer_error;type:text" json:"devcontainerError,omitempty"`
+	CreatedAt         time.Time       `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt         time.Time       `gorm:"autoUpdateTime" json:"updatedAt"`
 
 	Project  *Project  `gorm:"foreignKey:ProjectID" json:"-"`
 	Sessions []Session `gorm:"foreignKey:WorkspaceID" json:"-"`
@@ -205,30 +218,36 @@ const (
 	CommitStatusCommitting = "committing" // Commit in progress
 	CommitStatusCompleted  = "completed"  // Commit completed successfully
 	CommitStatusFailed     = "failed"     // Commit failed
+	CommitStatusReview     = "review"     // Patches fetched, waiting for the user to review and apply
 )
 
 // Session represents a chat thread within a workspace.
 type Session struct {
-	ID              string    `gorm:"primaryKey;type:text" json:"id"`
-	ProjectID       string    `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
-	WorkspaceID     string    `gorm:"column:workspace_id;not null;type:text;index" json:"workspaceId"`
-	AgentID         *string   `gorm:"column:agent_id;type:text;index" json:"agentId,omitempty"`
-	Name            string    `gorm:"not null;type:text" json:"name"`
-	DisplayName     *string   `gorm:"column:display_name;type:text" json:"displayName,omitempty"`
-	Description     *string   `gorm:"type:text" json:"description,omitempty"`
-	Status          string    `gorm:"not null;type:text;default:initializing" json:"status"`
-	CommitStatus    string    `gorm:"column:commit_status;type:text;default:''" json:"commitStatus"`
-	CommitError     *string   `gorm:"column:commit_error;type:text" json:"commitError,omitempty"`
-	BaseCommit      *string   `gorm:"column:base_commit;type:text" json:"baseCommit,omitempty"`
-	AppliedCommit   *string   `gorm:"column:applied_commit;type:text" json:"appliedCommit,omitempty"`
-	ErrorMessage    *string   `gorm:"column:error_message;type:text" json:"errorMessage,omitempty"`
-	WorkspacePath   *string   `gorm:"column:workspace_path;type:text" json:"workspacePath,omitempty"`
-	WorkspaceCommit *string   `gorm:"column:workspace_commit;type:text" json:"workspaceCommit,omitempty"`
-	Model           *string   `gorm:"column:model;type:text" json:"model,omitempty"`
-	Reasoning       *string   `gorm:"column:reasoning;type:text" json:"reasoning,omitempty"`
-	Mode            *string   `gorm:"column:mode;type:text" json:"mode,omitempty"`
-	CreatedAt       time.Time `gorm:"autoCreateTime" json:"createdAt"`
-	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+	ID              string          `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID       string          `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	WorkspaceID     string          `gorm:"column:workspace_id;not null;type:text;index" json:"workspaceId"`
+	AgentID         *string         `gorm:"column:agent_id;type:text;index" json:"agentId,omitempty"`
+	Name            string          `gorm:"not null;type:text" json:"name"`
+	DisplayName     *string         `gorm:"column:display_name;type:text" json:"displayName,omitempty"`
+	Description     *string         `gorm:"type:text" json:"description,omitempty"`
+	Status          string          `gorm:"not null;type:text;default:initializing" json:"status"`
+	CommitStatus    string          `gorm:"column:commit_status;type:text;default:''" json:"commitStatus"`
+	CommitError     *string         `gorm:"column:commit_error;type:text" json:"commitError,omitempty"`
+	CommitReview    bool            `gorm:"column:commit_review;not null;default:false" json:"commitReview"`
+	CommitPatches   *string         `gorm:"column:commit_patches;type:text" json:"-"`
+	BaseCommit      *string         `gorm:"column:base_commit;type:text" json:"baseCommit,omitempty"`
+	AppliedCommit   *string         `gorm:"column:applied_commit;type:text" json:"appliedCommit,omitempty"`
+	ErrorMessage    *string         `gorm:"column:error_message;type:text" json:"errorMessage,omitempty"`
+	WorkspacePath   *string         `gorm:"column:workspace_path;type:text" json:"workspacePath,omitempty"`
+	WorkspaceCommit *string         `gorm:"column:workspace_commit;type:text" json:"workspaceCommit,omitempty"`
+	UpstreamCommit  *string         `gorm:"column:upstream_commit;type:text" json:"upstreamCommit,omitempty"`
+	BehindCount     int             `gorm:"column:behind_count;not null;default:0" json:"behindCount"`
+	Model           *string         `gorm:"column:model;type:text" json:"model,omitempty"`
+	Reasoning       *string         `gorm:"column:reasoning;type:text" json:"reasoning,omitempty"`
+	Mode            *string         `gorm:"column:mode;type:text" json:"mode,omitempty"`
+	QuestionPolicy  json.RawMessage `gorm:"column:question_policy;type:text" json:"-"` // JSON-encoded QuestionPolicy; overrides the workspace's
+	CreatedAt       time.Time       `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt       time.Time       `gorm:"autoUpdateTime" json:"updatedAt"`
 
 	Project   *Project   `gorm:"foreignKey:ProjectID" json:"-"`
 	Workspace *Workspace `gorm:"foreignKey:WorkspaceID" json:"-"`
@@ -377,6 +396,8 @@ func AllModels() []interface{} {
 	return []interface{}{
 		&User{},
 		&UserSession{},
+		&APIToken{},
+		&ServiceAccount{},
 		&Project{},
 		&ProjectMember{},
 		&ProjectInvitation{},
@@ -390,5 +411,12 @@ func AllModels() []interface{} {
 		&Job{},
 		&DispatcherLeader{},
 		&UserPreference{},
+		&BatchRun{},
+		&BatchRunItem{},
+		&Schedule{},
+		&ScheduleRun{},
+		&HeadlessRun{},
+		&SessionShare{},
+		&AuditLog{},
 	}
 }
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Question policy modes for AskUserQuestion prompts.
+const (
+	QuestionPolicyManual        = "manual"         // Wait for a user to answer
+	QuestionPolicyFirstOption   = "first_option"   // Pick the first option of every question
+	QuestionPolicyRecommended   = "recommended"    // Pick the option marked recommended, else the first
+	QuestionPolicyDefaultAnswer = "default_answer" // Answer every question with DefaultAnswer
+	QuestionPolicyFail          = "fail"           // Fail headless runs that ask; others wait like manual
+)
+
+// QuestionPolicy controls how a session's AskUserQuestion prompts are
+// answered when nobody is around to answer them. It is stored JSON-encoded on
+// workspaces and sessions; a session's policy overrides its workspace's.
+type QuestionPolicy struct {
+	Mode string `json:"mode"`
+	// DefaultAnswer answers every question under default_answer, and
+	// questions without options under first_option and recommended.
+	DefaultAnswer string `json:"defaultAnswer,omitempty"`
+	// TimeoutMinutes cancels the completion when a question is still
+	// unanswered after this long. Zero waits indefinitely.
+	TimeoutMinutes int `json:"timeoutMinutes,omitempty"`
+}
+
+// Validate checks the mode and its settings.
+func (p QuestionPolicy) Validate() error {
+	switch p.Mode {
+	case QuestionPolicyManual, QuestionPolicyFirstOption, QuestionPolicyRecommended, QuestionPolicyFail:
+	case QuestionPolicyDefaultAnswer:
+		if p.DefaultAnswer == "" {
+			return fmt.Errorf("defaultAnswer is required for the %s mode", QuestionPolicyDefaultAnswer)
+		}
+	default:
+		return fmt.Errorf("mode must be one of %s, %s, %s, %s or %s",
+			QuestionPolicyManual, QuestionPolicyFirstOption, QuestionPolicyRecommended, QuestionPolicyDefaultAnswer, QuestionPolicyFail)
+	}
+	if p.TimeoutMinutes < 0 {
+		return fmt.Errorf("timeoutMinutes must not be negative")
+	}
+	return nil
+}
+
+// AutoAnswers reports whether the policy answers questions itself rather than
+// leaving them for a user.
+func (p QuestionPolicy) AutoAnswers() bool {
+	return p.Mode != QuestionPolicyManual && p.Mode != QuestionPolicyFail
+}
+
+// DecodeQuestionPolicy decodes a stored question policy. It returns nil when
+// none is stored.
+func DecodeQuestionPolicy(data json.RawMessage) (*QuestionPolicy, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var policy QuestionPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid question policy: %w", err)
+	}
+	return &policy, nil
+}
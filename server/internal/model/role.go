@@ -0,0 +1,91 @@
+package model
+
+import "slices"
+
+// Project role constants, from most to least privileged. RoleMember is the
+// legacy default role and grants the same permissions as RoleDeveloper.
+const (
+	RoleOwner     = "owner"
+	RoleAdmin     = "admin"
+	RoleDeveloper = "developer"
+	RoleMember    = "member"
+	RoleViewer    = "viewer"
+)
+
+// Project permission constants. Every project-scoped route declares one of
+// these, and a member may call it if their role grants the permission.
+const (
+	PermissionProjectView      = "project:view"      // Read anything in the project
+	PermissionProjectManage    = "project:manage"    // Rename, members, invitations, service accounts, caches
+	PermissionProjectDelete    = "project:delete"    // Delete the project
+	PermissionWorkspaceWrite   = "workspace:write"   // Create/update workspaces and change their git state
+	PermissionWorkspaceDelete  = "workspace:delete"  // Delete a workspace
+	PermissionSessionCreate    = "session:create"    // Create, update and share sessions, batch runs and schedules
+	PermissionSessionDelete    = "session:delete"    // Delete a session
+	PermissionSessionChat      = "session:chat"      // Chat, run hooks and services
+	PermissionSessionFiles     = "session:files"     // Write, delete and rename session files
+	PermissionSessionTerminal  = "session:terminal"  // Open a terminal in a session sandbox
+	PermissionSessionCommit    = "session:commit"    // Commit, review and rebase session changes
+	PermissionAgentManage      = "agent:manage"      // Create, update and delete agents
+	PermissionCredentialManage = "credential:manage" // Create, refresh and delete credentials
+	PermissionAuditView        = "audit:view"        // Read and export the audit log
+)
+
+var developerPermissions = []string{
+	PermissionProjectView,
+	PermissionWorkspaceWrite,
+	PermissionSessionCreate,
+	PermissionSessionDelete,
+	PermissionSessionChat,
+	PermissionSessionFiles,
+	PermissionSessionTerminal,
+	PermissionSessionCommit,
+}
+
+var adminPermissions = append([]string{
+	PermissionProjectManage,
+	PermissionWorkspaceDelete,
+	PermissionAgentManage,
+	PermissionCredentialManage,
+	PermissionAuditView,
+}, developerPermissions...)
+
+// rolePermissions maps each role to the permissions it grants.
+var rolePermissions = map[string][]string{
+	RoleOwner:     append([]string{PermissionProjectDelete}, adminPermissions...),
+	RoleAdmin:     adminPermissions,
+	RoleDeveloper: developerPermissions,
+	RoleMember:    developerPermissions,
+	RoleViewer:    {PermissionProjectView},
+}
+
+// roleRanks orders roles by privilege; higher is more privileged.
+var roleRanks = map[string]int{
+	RoleViewer:    1,
+	RoleDeveloper: 2,
+	RoleMember:    2,
+	RoleAdmin:     3,
+	RoleOwner:     4,
+}
+
+// IsValidRole reports whether role is a known project role.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsAssignableRole reports whether role can be granted through invitations,
+// service accounts or group mappings. Ownership stays with the creator.
+func IsAssignableRole(role string) bool {
+	return IsValidRole(role) && role != RoleOwner
+}
+
+// RoleRank returns the privilege rank of a role, or 0 if it is unknown.
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
+// RoleHasPermission reports whether role grants permission.
+func RoleHasPermission(role, permission string) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Schedule run status constants.
+const (
+	ScheduleRunStatusPending   = "pending"
+	ScheduleRunStatusRunning   = "running"
+	ScheduleRunStatusSucceeded = "succeeded"
+	ScheduleRunStatusFailed    = "failed"
+	ScheduleRunStatusSkipped   = "skipped" // The previous run was still in progress
+)
+
+// Schedule runs a prompt in a new session of a workspace on a cron schedule.
+type Schedule struct {
+	ID          string     `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID   string     `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	WorkspaceID string     `gorm:"column:workspace_id;not null;type:text;index" json:"workspaceId"`
+	AgentID     string     `gorm:"column:agent_id;not null;type:text" json:"agentId"`
+	Name        string     `gorm:"not null;type:text" json:"name"`
+	Prompt      string     `gorm:"not null;type:text" json:"prompt"`
+	Model       *string    `gorm:"type:text" json:"model,omitempty"`
+	Reasoning   *string    `gorm:"type:text" json:"reasoning,omitempty"`
+	Cron        string     `gorm:"not null;type:text" json:"cron"`
+	Timezone    string     `gorm:"not null;type:text;default:UTC" json:"timezone"`
+	Enabled     bool       `gorm:"not null;default:true" json:"enabled"`
+	AutoCommit  bool       `gorm:"column:auto_commit;not null;default:false" json:"autoCommit"`
+	AutoDelete  bool       `gorm:"column:auto_delete;not null;default:false" json:"autoDelete"` // Delete the session after a successful run
+	NextRunAt   *time.Time `gorm:"column:next_run_at;index" json:"nextRunAt,omitempty"`
+	LastRunAt   *time.Time `gorm:"column:last_run_at" json:"lastRunAt,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// TableName returns the table name for Schedule.
+func (Schedule) TableName() string { return "schedules" }
+
+// BeforeCreate generates a UUID if not set.
+func (s *Schedule) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ScheduleRun records one execution of a schedule.
+type ScheduleRun struct {
+	ID            string     `gorm:"primaryKey;type:text" json:"id"`
+	ScheduleID    string     `gorm:"column:schedule_id;not null;type:text;index" json:"scheduleId"`
+	ProjectID     string     `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	SessionID     *string    `gorm:"column:session_id;type:text" json:"sessionId,omitempty"`
+	Status        string     `gorm:"not null;type:text;default:pending" json:"status"`
+	Error         *string    `gorm:"type:text" json:"error,omitempty"`
+	AppliedCommit *string    `gorm:"column:applied_commit;type:text" json:"appliedCommit,omitempty"` // Set when auto-commit applied changes
+	Manual        bool       `gorm:"not null;default:false" json:"manual"`                           // Triggered via the API rather than the schedule
+	StartedAt     *time.Time `gorm:"column:started_at" json:"startedAt,omitempty"`
+	CompletedAt   *time.Time `gorm:"column:completed_at" json:"completedAt,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// TableName returns the table name for ScheduleRun.
+func (ScheduleRun) TableName() string { return "schedule_runs" }
+
+// BeforeCreate generates a UUID if not set.
+func (r *ScheduleRun) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionShare is an expiring link that grants read-only access to one
+// session without project membership. Only a hash of the token is stored.
+type SessionShare struct {
+	ID         string     `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID  string     `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	SessionID  string     `gorm:"column:session_id;not null;type:text;index" json:"sessionId"`
+	Prefix     string     `gorm:"not null;type:text" json:"prefix"` // Leading characters of the token, for identification
+	TokenHash  string     `gorm:"column:token_hash;uniqueIndex;not null;type:text" json:"-"`
+	CreatedBy  string     `gorm:"column:created_by;not null;type:text" json:"createdBy"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null" json:"expiresAt"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// TableName returns the table name for SessionShare.
+func (SessionShare) TableName() string { return "session_shares" }
+
+// BeforeCreate generates a UUID if not set.
+func (s *SessionShare) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
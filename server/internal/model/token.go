@@ -0,0 +1,80 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// API token scope constants. A request is allowed if any of the token's
+// scopes permits it; chat implies read and admin implies everything.
+const (
+	TokenScopeRead  = "read"  // Read-only requests
+	TokenScopeChat  = "chat"  // Read plus chatting and managing sessions
+	TokenScopeAdmin = "admin" // Full API access
+)
+
+// ServiceAccountProvider is the User.Provider value for service account users.
+const ServiceAccountProvider = "service_account"
+
+// APIToken is a personal access token used with "Authorization: Bearer".
+// Only a hash of the token is stored.
+type APIToken struct {
+	ID         string     `gorm:"primaryKey;type:text" json:"id"`
+	UserID     string     `gorm:"column:user_id;not null;type:text;index" json:"userId"`
+	Name       string     `gorm:"not null;type:text" json:"name"`
+	Prefix     string     `gorm:"not null;type:text" json:"prefix"` // Leading characters of the token, for identification
+	TokenHash  string     `gorm:"column:token_hash;uniqueIndex;not null;type:text" json:"-"`
+	Scopes     string     `gorm:"not null;type:text" json:"-"` // Comma-separated list of scopes
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName returns the table name for APIToken.
+func (APIToken) TableName() string { return "api_tokens" }
+
+// BeforeCreate generates a UUID if not set.
+func (t *APIToken) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ScopeList returns the token's scopes as a slice.
+func (t *APIToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// ServiceAccount is a non-human project member that authenticates with API
+// tokens. It is backed by a User (Provider "service_account") so that its
+// project role is an ordinary ProjectMember row.
+type ServiceAccount struct {
+	ID        string    `gorm:"primaryKey;type:text" json:"id"`
+	ProjectID string    `gorm:"column:project_id;not null;type:text;index" json:"projectId"`
+	UserID    string    `gorm:"column:user_id;not null;type:text;uniqueIndex" json:"userId"`
+	Name      string    `gorm:"not null;type:text" json:"name"`
+	CreatedBy *string   `gorm:"column:created_by;type:text" json:"createdBy,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName returns the table name for ServiceAccount.
+func (ServiceAccount) TableName() string { return "service_accounts" }
+
+// BeforeCreate generates a UUID if not set.
+func (a *ServiceAccount) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
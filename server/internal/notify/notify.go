@@ -0,0 +1,52 @@
+// Package notify delivers notifications such as project invitation emails.
+// Backends implement Notifier; SMTP is used when configured and otherwise
+// messages are written to the server log so invitations still work locally.
+package notify
+
+import (
+	"context"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
+)
+
+// notifyLog is the logger for notification messages.
+var notifyLog = logging.Component("notify")
+
+// Message is an email with a plain text body and an optional HTML alternative.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier delivers messages.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the notifier configured in cfg: SMTP when SMTPHost is set,
+// otherwise a LogNotifier.
+func New(cfg *config.Config) Notifier {
+	if cfg.SMTPHost == "" {
+		return LogNotifier{}
+	}
+	return NewSMTPNotifier(SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+		TLS:      cfg.SMTPTLS,
+	})
+}
+
+// LogNotifier writes messages to the server log instead of delivering them.
+type LogNotifier struct{}
+
+// Send logs the message.
+func (LogNotifier) Send(ctx context.Context, msg Message) error {
+	notifyLog.InfoContext(ctx, "email delivery not configured (set SMTP_HOST)", "to", msg.To, "subject", msg.Subject, "text", msg.Text)
+	return nil
+}
@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal SMTP server that records the last message it
+// accepted.
+type fakeSMTPServer struct {
+	listener net.Listener
+	received chan receivedMail
+}
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: ln, received: make(chan receivedMail, 1)}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP ready")
+
+	var mail receivedMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			_ = tp.PrintfLine("250-localhost")
+			_ = tp.PrintfLine("250 8BITMIME")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			mail.from = smtpAddress(line[len("MAIL FROM:"):])
+			_ = tp.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			mail.to = append(mail.to, smtpAddress(line[len("RCPT TO:"):]))
+			_ = tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			s.received <- mail
+			_ = tp.PrintfLine("250 OK")
+		case cmd == "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// smtpAddress extracts the address from a MAIL FROM or RCPT TO argument,
+// ignoring any trailing ESMTP parameters.
+func smtpAddress(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if end := strings.Index(arg, ">"); end >= 0 {
+		arg = arg[:end]
+	}
+	return strings.TrimPrefix(arg, "<")
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	n := NewSMTPNotifier(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: server.port(),
+		From: "discobot@example.com",
+		TLS:  "starttls",
+	})
+
+	err := n.Send(context.Background(), Message{
+		To:      "dev@example.com",
+		Subject: "Hello",
+		Text:    "Plain body",
+		HTML:    "<p>HTML body</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	select {
+	case mail := <-server.received:
+		if mail.from != "discobot@example.com" {
+			t.Errorf("Expected sender discobot@example.com, got %q", mail.from)
+		}
+		if len(mail.to) != 1 || mail.to[0] != "dev@example.com" {
+			t.Errorf("Expected recipient dev@example.com, got %v", mail.to)
+		}
+		for _, want := range []string{"Subject: Hello", "multipart/alternative", "Plain body", "<p>HTML body</p>"} {
+			if !strings.Contains(mail.data, want) {
+				t.Errorf("Expected message to contain %q, got:\n%s", want, mail.data)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+}
+
+func TestSMTPNotifierConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	n := NewSMTPNotifier(SMTPConfig{Host: "127.0.0.1", Port: port, From: "discobot@example.com", TLS: "none"})
+	if err := n.Send(context.Background(), Message{To: "dev@example.com", Subject: "Hello", Text: "Body"}); err == nil {
+		t.Error("Expected error when the server is unreachable")
+	}
+}
+
+func TestInvitationMessage(t *testing.T) {
+	msg, err := InvitationMessage(InvitationData{
+		To:          "dev@example.com",
+		ProjectName: "Acme <Core>",
+		InviterName: "Sam",
+		Role:        "developer",
+		AcceptURL:   "https://discobot.example.com/?project=p1&invitation=abc",
+		ExpiresAt:   time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("InvitationMessage failed: %v", err)
+	}
+	if msg.To != "dev@example.com" {
+		t.Errorf("Expected recipient dev@example.com, got %q", msg.To)
+	}
+	if msg.Subject != "Sam invited you to join Acme <Core> on Discobot" {
+		t.Errorf("Unexpected subject %q", msg.Subject)
+	}
+	for _, want := range []string{"as developer", "https://discobot.example.com/?project=p1&invitation=abc", "January 2, 2026"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected text body to contain %q, got:\n%s", want, msg.Text)
+		}
+	}
+	if !strings.Contains(msg.HTML, "Acme &lt;Core&gt;") {
+		t.Errorf("Expected HTML body to escape project name, got:\n%s", msg.HTML)
+	}
+	if !strings.Contains(msg.HTML, `href="https://discobot.example.com/?project=p1&amp;invitation=abc"`) {
+		t.Errorf("Expected HTML body to link to accept URL, got:\n%s", msg.HTML)
+	}
+}
+
+func TestBuildMessagePlainText(t *testing.T) {
+	n := NewSMTPNotifier(SMTPConfig{From: "discobot@example.com"})
+	body, err := n.buildMessage(Message{To: "dev@example.com", Subject: "Hi", Text: "Just text"})
+	if err != nil {
+		t.Fatalf("buildMessage failed: %v", err)
+	}
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(string(body))))
+	header, err := r.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("Failed to parse headers: %v", err)
+	}
+	if got := header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Expected text/plain content type, got %q", got)
+	}
+	if !strings.Contains(string(body), "Just text") {
+		t.Errorf("Expected body to contain text, got:\n%s", body)
+	}
+}
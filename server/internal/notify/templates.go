@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"text/template"
+	"time"
+)
+
+// InvitationData is the data rendered into an invitation email.
+type InvitationData struct {
+	To          string
+	ProjectName string
+	InviterName string // Optional
+	Role        string
+	AcceptURL   string
+	ExpiresAt   time.Time
+}
+
+var invitationSubject = template.Must(template.New("subject").Parse(
+	`{{if .InviterName}}{{.InviterName}} invited you{{else}}You're invited{{end}} to join {{.ProjectName}} on Discobot`))
+
+var invitationText = template.Must(template.New("text").Parse(`Hi,
+
+{{if .InviterName}}{{.InviterName}} has invited you{{else}}You have been invited{{end}} to join the project "{{.ProjectName}}" on Discobot as {{.Role}}.
+
+Accept the invitation:
+{{.AcceptURL}}
+
+This invitation expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}. If you weren't expecting it, you can ignore this email.
+`))
+
+var invitationHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #111;">
+<p>Hi,</p>
+<p>{{if .InviterName}}{{.InviterName}} has invited you{{else}}You have been invited{{end}} to join the project <strong>{{.ProjectName}}</strong> on Discobot as {{.Role}}.</p>
+<p><a href="{{.AcceptURL}}" style="display: inline-block; padding: 8px 16px; background: #111; color: #fff; text-decoration: none; border-radius: 4px;">Accept invitation</a></p>
+<p style="color: #666; font-size: 12px;">This invitation expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}. If you weren't expecting it, you can ignore this email.</p>
+</body>
+</html>
+`))
+
+// InvitationMessage renders the project invitation email.
+func InvitationMessage(data InvitationData) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := invitationSubject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := invitationText.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := invitationHTML.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      data.To,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
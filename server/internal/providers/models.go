@@ -2,7 +2,6 @@ package providers
 
 import (
 	"encoding/json"
-	"log"
 	"sync"
 
 	"github.com/obot-platform/discobot/server/static"
@@ -55,13 +54,13 @@ func loadModelsData() {
 	modelsOnce.Do(func() {
 		data, err := static.Files.ReadFile("models-dev-api.json")
 		if err != nil {
-			log.Printf("Warning: Failed to load models-dev-api.json: %v", err)
+			providersLog.Warn("failed to load models-dev-api.json", "error", err)
 			modelsLoadErr = err
 			return
 		}
 
 		if err := json.Unmarshal(data, &cachedModels); err != nil {
-			log.Printf("Warning: Failed to parse models-dev-api.json: %v", err)
+			providersLog.Warn("failed to parse models-dev-api.json", "error", err)
 			modelsLoadErr = err
 			return
 		}
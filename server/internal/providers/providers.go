@@ -2,13 +2,17 @@ package providers
 
 import (
 	"encoding/json"
-	"log"
 	"sort"
 	"sync"
 
 	"github.com/obot-platform/discobot/server/static"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
 )
 
+// providersLog is the logger for provider catalog messages.
+var providersLog = logging.Component("providers")
+
 // Icon represents an icon with theme support
 type Icon struct {
 	Src        string   `json:"src"`
@@ -80,13 +84,13 @@ func loadProviders() {
 		// Load models.dev data
 		data, err := static.Files.ReadFile("models-dev-api.json")
 		if err != nil {
-			log.Printf("Warning: Failed to load models-dev-api.json: %v", err)
+			providersLog.Warn("failed to load models-dev-api.json", "error", err)
 			return
 		}
 
 		var providers map[string]modelsDevProvider
 		if err := json.Unmarshal(data, &providers); err != nil {
-			log.Printf("Warning: Failed to parse models-dev-api.json: %v", err)
+			providersLog.Warn("failed to parse models-dev-api.json", "error", err)
 			return
 		}
 
@@ -0,0 +1,438 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OpenAPIVersion is the OpenAPI specification version emitted by OpenAPI.
+const OpenAPIVersion = "3.1.0"
+
+// Security scheme names used in generated documents.
+const (
+	SecuritySessionCookie = "sessionCookie"
+	SecurityBearerToken   = "bearerToken"
+)
+
+// OpenAPIInfo is the document metadata written to the info object.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIDocument is an OpenAPI 3.1 document.
+type OpenAPIDocument struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       OpenAPIInfo                      `json:"info"`
+	Tags       []OpenAPITag                     `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// OpenAPITag groups operations in generated documentation.
+type OpenAPITag struct {
+	Name string `json:"name"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation is a single method on a path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Permission  string                `json:"x-permission,omitempty"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+	Example  string  `json:"example,omitempty"`
+}
+
+// RequestBody is an operation's JSON request body.
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is an operation response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType pairs a schema with an optional example.
+type MediaType struct {
+	Schema  *Schema `json:"schema"`
+	Example any     `json:"example,omitempty"`
+}
+
+// Schema is a JSON Schema (2020-12) object as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPI builds an OpenAPI 3.1 document describing every registered route.
+// Request and response schemas are reflected from Meta.Request and
+// Meta.Response; routes without a Request fall back to a schema inferred
+// from the Body example.
+func (reg *Registry) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: map[string]*Schema{
+				"Error": {
+					Type:       "object",
+					Properties: map[string]*Schema{"error": {Type: "string"}},
+					Required:   []string{"error"},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				SecuritySessionCookie: {Type: "apiKey", In: "cookie", Name: "discobot_session", Description: "Browser session set by the login flow"},
+				SecurityBearerToken:   {Type: "http", Scheme: "bearer", Description: "Personal access or service account token"},
+			},
+		},
+	}
+
+	gen := newSchemaGenerator(doc.Components.Schemas)
+	tags := make(map[string]bool)
+	operationIDs := make(map[string]bool)
+	for _, route := range reg.Routes() {
+		path := strings.TrimSuffix(route.Path, "/")
+		if path == "" {
+			path = "/"
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		op := gen.operation(route)
+		// Handlers registered for several methods (e.g. PUT and PATCH) get
+		// the method appended to keep operation IDs unique.
+		if operationIDs[op.OperationID] {
+			op.OperationID += strings.ToUpper(route.Method[:1]) + strings.ToLower(route.Method[1:])
+		}
+		operationIDs[op.OperationID] = true
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+		if route.Group != "" && !tags[route.Group] {
+			tags[route.Group] = true
+			doc.Tags = append(doc.Tags, OpenAPITag{Name: route.Group})
+		}
+	}
+	return doc
+}
+
+// Validate reports routes whose metadata is too incomplete to document:
+// every route needs a group and description, and PUT and PATCH routes must
+// describe their body with Request or a Body example.
+func (reg *Registry) Validate() error {
+	var problems []string
+	for _, route := range reg.Routes() {
+		name := route.Method + " " + route.Path
+		if route.Group == "" {
+			problems = append(problems, name+": missing group")
+		}
+		if route.Description == "" {
+			problems = append(problems, name+": missing description")
+		}
+		if (route.Method == "PUT" || route.Method == "PATCH") && route.request == nil && route.Body == nil {
+			problems = append(problems, name+": missing request body")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("routes: incomplete metadata:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func (g *schemaGenerator) operation(route RouteInfo) *Operation {
+	op := &Operation{
+		OperationID: operationID(route),
+		Summary:     route.Description,
+		Permission:  route.Permission,
+		Responses:   make(map[string]*Response),
+		Security:    []map[string][]string{},
+	}
+	if route.Group != "" {
+		op.Tags = []string{route.Group}
+	}
+	if route.Authenticated {
+		op.Security = []map[string][]string{
+			{SecuritySessionCookie: {}},
+			{SecurityBearerToken: {}},
+		}
+	}
+
+	for _, p := range route.Params {
+		in := p.In
+		if in == "" {
+			in = "query"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     p.Name,
+			In:       in,
+			Required: p.Required || in == "path",
+			Schema:   &Schema{Type: "string"},
+			Example:  p.Example,
+		})
+	}
+
+	var requestSchema *Schema
+	switch {
+	case route.request != nil:
+		requestSchema = g.schemaForValue(route.request)
+	case route.Body != nil:
+		requestSchema = g.schemaForValue(route.Body)
+	}
+	if requestSchema != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: requestSchema, Example: route.Body}},
+		}
+	}
+
+	status := route.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if route.response != nil {
+		success.Content = map[string]*MediaType{"application/json": {Schema: g.schemaForValue(route.response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}},
+	}
+	return op
+}
+
+// operationID returns the route's handler method name (e.g. "ListAgents"),
+// or a name derived from the method and path for anonymous handlers.
+func operationID(route RouteInfo) string {
+	if route.handler != nil {
+		name := runtime.FuncForPC(reflect.ValueOf(route.handler).Pointer()).Name()
+		name = strings.TrimSuffix(name, "-fm")
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if name != "" && unicode.IsUpper(rune(name[0])) {
+			return name
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaGenerator reflects Go types into JSON schemas, registering named
+// struct types as reusable components.
+type schemaGenerator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator(components map[string]*Schema) *schemaGenerator {
+	return &schemaGenerator{components: components, names: make(map[reflect.Type]string)}
+}
+
+// schemaForValue returns the schema for v. Maps with string keys are treated
+// as examples: each entry becomes a property whose schema is reflected from
+// the entry's dynamic type, so map[string]any{"agents": []service.Agent{}}
+// documents an object with an "agents" array.
+func (g *schemaGenerator) schemaForValue(v any) *Schema {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && rv.Len() > 0 {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, key := range rv.MapKeys() {
+			value := rv.MapIndex(key)
+			if value.Kind() == reflect.Interface {
+				value = value.Elem()
+			}
+			if !value.IsValid() {
+				schema.Properties[key.String()] = &Schema{}
+				continue
+			}
+			schema.Properties[key.String()] = g.schemaForValue(value.Interface())
+		}
+		return schema
+	}
+	if rv.Kind() == reflect.Slice && rv.Len() > 0 && rv.Type().Elem().Kind() == reflect.Interface {
+		return &Schema{Type: "array", Items: g.schemaForValue(rv.Index(0).Interface())}
+	}
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+// schemaFor returns the schema for t.
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	if t.Kind() != reflect.Struct && t.Implements(marshalerType) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// Interfaces and anything else accept any JSON value.
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t and returns its component name.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := sanitizeComponentName(t.Name())
+	if _, taken := g.components[name]; taken {
+		// Disambiguate same-named types from different packages,
+		// e.g. service.Session and model.Session.
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = sanitizeComponentName(strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name())
+		for i := 2; g.components[name] != nil; i++ {
+			name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+		}
+	}
+	g.names[t] = name
+	// Reserve the name before reflecting fields so recursive types terminate.
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.structSchema(t)
+	return name
+}
+
+// structSchema reflects the JSON-visible fields of t, flattening embedded
+// structs the way encoding/json does.
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := g.structSchema(ft)
+				for propName, prop := range embedded.Properties {
+					schema.Properties[propName] = prop
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := g.schemaFor(field.Type)
+		if strings.Contains(opts, "string") && prop.Type != "" {
+			prop = &Schema{Type: "string"}
+		}
+		schema.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func sanitizeComponentName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type testItem struct {
+	ID        string            `json:"id"`
+	Name      *string           `json:"name,omitempty"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Parent    *testItem         `json:"parent,omitempty"`
+	Secret    string            `json:"-"`
+	testEmbedded
+}
+
+type testEmbedded struct {
+	Count int64 `json:"count"`
+}
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func TestOpenAPIReflectsSchemas(t *testing.T) {
+	reg := NewRegistry()
+	r := chi.NewRouter()
+	reg.Authenticated().Register(r, Route{
+		Method: "POST", Pattern: "/items",
+		Handler: noop,
+		Meta: Meta{
+			Group:       "Items",
+			Description: "Create item",
+			Body:        map[string]any{"name": "widget", "tags": []string{"a"}},
+			Response:    testItem{},
+			Status:      http.StatusCreated,
+		},
+	})
+	reg.Register(r, Route{
+		Method: "GET", Pattern: "/items",
+		Handler: noop,
+		Meta: Meta{
+			Group:       "Items",
+			Description: "List items",
+			Params:      []Param{{Name: "limit", In: "query"}},
+			Response:    map[string]any{"items": []testItem{}},
+		},
+	})
+
+	doc := reg.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1"})
+	if doc.OpenAPI != OpenAPIVersion {
+		t.Errorf("openapi = %q, want %q", doc.OpenAPI, OpenAPIVersion)
+	}
+
+	create := doc.Paths["/items"]["post"]
+	if create.OperationID != "postItems" {
+		t.Errorf("OperationID = %q, want postItems for an unexported handler", create.OperationID)
+	}
+	if len(create.Security) != 2 {
+		t.Errorf("Expected authenticated route to list security schemes, got %v", create.Security)
+	}
+	body := create.RequestBody.Content["application/json"].Schema
+	if body.Properties["name"].Type != "string" || body.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Unexpected request schema inferred from example: %+v", body)
+	}
+	if create.Responses["201"].Content["application/json"].Schema.Ref != "#/components/schemas/testItem" {
+		t.Errorf("Expected 201 response to reference testItem, got %+v", create.Responses["201"])
+	}
+
+	item := doc.Components.Schemas["testItem"]
+	if item == nil {
+		t.Fatal("Expected testItem component")
+	}
+	if got := item.Properties["createdAt"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("createdAt schema = %+v", got)
+	}
+	if got := item.Properties["parent"]; got.Ref != "#/components/schemas/testItem" {
+		t.Errorf("parent schema = %+v", got)
+	}
+	if got := item.Properties["labels"]; got.Type != "object" || got.AdditionalProperties.Type != "string" {
+		t.Errorf("labels schema = %+v", got)
+	}
+	if _, ok := item.Properties["count"]; !ok {
+		t.Error("Expected embedded field count to be flattened")
+	}
+	if _, ok := item.Properties["Secret"]; ok {
+		t.Error("Expected json:\"-\" field to be skipped")
+	}
+	for _, name := range []string{"count", "createdAt", "id", "tags"} {
+		if !slices.Contains(item.Required, name) {
+			t.Errorf("Expected %s to be required, got %v", name, item.Required)
+		}
+	}
+	if slices.Contains(item.Required, "name") {
+		t.Error("Expected optional field name not to be required")
+	}
+
+	list := doc.Paths["/items"]["get"]
+	if len(list.Security) != 0 {
+		t.Errorf("Expected public route to have no security, got %v", list.Security)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].In != "query" {
+		t.Errorf("Unexpected parameters %+v", list.Parameters)
+	}
+	items := list.Responses["200"].Content["application/json"].Schema.Properties["items"]
+	if items.Type != "array" || items.Items.Ref != "#/components/schemas/testItem" {
+		t.Errorf("items schema = %+v", items)
+	}
+}
+
+func TestValidateReportsMissingMetadata(t *testing.T) {
+	reg := NewRegistry()
+	r := chi.NewRouter()
+	reg.Register(r, Route{Method: "GET", Pattern: "/ok", Handler: noop, Meta: Meta{Group: "G", Description: "OK"}})
+	if err := reg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	reg.Register(r, Route{Method: "PUT", Pattern: "/bad", Handler: noop, Meta: Meta{Group: "G"}})
+	err := reg.Validate()
+	if err == nil {
+		t.Fatal("Expected Validate to fail")
+	}
+	for _, want := range []string{"PUT /bad: missing description", "PUT /bad: missing request body"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+}
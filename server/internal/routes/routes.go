@@ -4,11 +4,16 @@
 package routes
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
 )
 
 // Route defines an HTTP route with its handler and metadata.
@@ -25,6 +30,16 @@ type Meta struct {
 	Description string  `json:"description"`
 	Params      []Param `json:"params,omitempty"`
 	Body        any     `json:"body,omitempty"`
+	// Permission is the project permission required to call the route.
+	// Routes under a {projectId} path must set it.
+	Permission string `json:"permission,omitempty"`
+	// Request and Response are values of the JSON request and success
+	// response body types, reflected into the OpenAPI document. A map with
+	// string keys documents an object with those properties.
+	Request  any `json:"-"`
+	Response any `json:"-"`
+	// Status is the success status code (default 200).
+	Status int `json:"-"`
 }
 
 // Param describes a route parameter.
@@ -43,13 +58,32 @@ type RouteInfo struct {
 	Description string  `json:"description"`
 	Params      []Param `json:"params,omitempty"`
 	Body        any     `json:"body,omitempty"`
+	Permission  string  `json:"permission,omitempty"`
+	// Authenticated is true for routes that require a signed-in caller.
+	Authenticated bool `json:"authenticated,omitempty"`
+
+	handler  http.HandlerFunc
+	request  any
+	response any
+	status   int
 }
 
+// Authorizer reports whether the caller of a request holds a permission.
+type Authorizer func(ctx context.Context, permission string) bool
+
+// Guard runs before the permission check of every registered route,
+// including routes without a permission (""). It writes the response and
+// returns false to reject the request.
+type Guard func(w http.ResponseWriter, r *http.Request, permission string) bool
+
 // Registry stores route metadata for documentation.
 type Registry struct {
-	mu     sync.RWMutex
-	routes *[]RouteInfo // pointer to shared slice
-	prefix string
+	mu            sync.RWMutex
+	routes        *[]RouteInfo // pointer to shared slice
+	prefix        string
+	authorizer    Authorizer
+	guard         Guard
+	authenticated bool
 }
 
 // NewRegistry creates a new route registry.
@@ -64,24 +98,30 @@ func NewRegistry() *Registry {
 var pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
 
 // Register adds a route to chi and stores its metadata.
+// It panics if a project route does not declare a permission.
 func (reg *Registry) Register(r chi.Router, route Route) {
+	// Build full path
+	fullPath := reg.prefix + route.Pattern
+
+	if route.Meta.Permission == "" && strings.Contains(fullPath, "{projectId}") {
+		panic(fmt.Sprintf("routes: %s %s must declare a permission", route.Method, fullPath))
+	}
+	handler := recordPattern(fullPath, reg.enforce(route.Meta.Permission, route.Handler))
+
 	// Register with chi
 	switch route.Method {
 	case "GET":
-		r.Get(route.Pattern, route.Handler)
+		r.Get(route.Pattern, handler)
 	case "POST":
-		r.Post(route.Pattern, route.Handler)
+		r.Post(route.Pattern, handler)
 	case "PUT":
-		r.Put(route.Pattern, route.Handler)
+		r.Put(route.Pattern, handler)
 	case "DELETE":
-		r.Delete(route.Pattern, route.Handler)
+		r.Delete(route.Pattern, handler)
 	case "PATCH":
-		r.Patch(route.Pattern, route.Handler)
+		r.Patch(route.Pattern, handler)
 	}
 
-	// Build full path
-	fullPath := reg.prefix + route.Pattern
-
 	// Extract path parameters from pattern
 	params := extractPathParams(fullPath)
 
@@ -91,21 +131,105 @@ func (reg *Registry) Register(r chi.Router, route Route) {
 	// Store metadata
 	reg.mu.Lock()
 	*reg.routes = append(*reg.routes, RouteInfo{
-		Method:      route.Method,
-		Path:        fullPath,
-		Group:       route.Meta.Group,
-		Description: route.Meta.Description,
-		Params:      params,
-		Body:        route.Meta.Body,
+		Method:        route.Method,
+		Path:          fullPath,
+		Group:         route.Meta.Group,
+		Description:   route.Meta.Description,
+		Params:        params,
+		Body:          route.Meta.Body,
+		Permission:    route.Meta.Permission,
+		Authenticated: reg.authenticated,
+		handler:       route.Handler,
+		request:       route.Meta.Request,
+		response:      route.Meta.Response,
+		status:        route.Meta.Status,
 	})
 	reg.mu.Unlock()
 }
 
+// SetAuthorizer sets the function used to check route permissions. It must
+// be called before routes are registered; sub-registries inherit it.
+func (reg *Registry) SetAuthorizer(authorizer Authorizer) {
+	reg.authorizer = authorizer
+}
+
+// SetGuard sets the guard run before route permission checks. Like
+// SetAuthorizer, it must be called before routes are registered.
+func (reg *Registry) SetGuard(guard Guard) {
+	reg.guard = guard
+}
+
+// enforce wraps handler so that requests rejected by the guard are refused
+// and callers lacking permission get a 403. Routes without a permission
+// are only guarded.
+func (reg *Registry) enforce(permission string, handler http.HandlerFunc) http.HandlerFunc {
+	authorizer, guard := reg.authorizer, reg.guard
+	if permission == "" && guard == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if guard != nil && !guard(w, r, permission) {
+			return
+		}
+		if permission != "" && (authorizer == nil || !authorizer(r.Context(), permission)) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = fmt.Fprintf(w, `{"error":"Permission denied: %s required"}`+"\n", permission)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// patternKey is the context key for the slot a route records its pattern in.
+type patternKey struct{}
+
+// TrackPattern returns a request whose registered route, if one serves it,
+// records its full pattern, and a function returning that pattern ("" when
+// no registered route matched). Middleware uses it to label requests by
+// route without the cardinality of raw paths. Nested calls share one slot.
+func TrackPattern(r *http.Request) (*http.Request, func() string) {
+	if pattern, ok := r.Context().Value(patternKey{}).(*string); ok {
+		return r, func() string { return *pattern }
+	}
+	pattern := new(string)
+	ctx := context.WithValue(r.Context(), patternKey{}, pattern)
+	return r.WithContext(ctx), func() string { return *pattern }
+}
+
+// logParams maps the URL parameters that identify resources to the log
+// attributes they are recorded under.
+var logParams = []struct{ param, attr string }{
+	{"projectId", "project_id"},
+	{"sessionId", "session_id"},
+	{"workspaceId", "workspace_id"},
+}
+
+// recordPattern wraps handler so that it records pattern for TrackPattern
+// and adds the IDs of the resources it addresses to the request's log
+// attributes.
+func recordPattern(pattern string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if slot, ok := r.Context().Value(patternKey{}).(*string); ok {
+			*slot = pattern
+		}
+		for _, p := range logParams {
+			if id := chi.URLParam(r, p.param); id != "" {
+				logging.Add(r.Context(), p.attr, id)
+			}
+		}
+		handler(w, r)
+	}
+}
+
 // Group creates a sub-registry with a path prefix for nested routes.
 func (reg *Registry) Group(pattern string) *Registry {
 	return &Registry{
-		routes: reg.routes, // share the same slice via pointer
-		prefix: reg.prefix + pattern,
+		routes:        reg.routes, // share the same slice via pointer
+		prefix:        reg.prefix + pattern,
+		authorizer:    reg.authorizer,
+		guard:         reg.guard,
+		authenticated: reg.authenticated,
 	}
 }
 
@@ -113,8 +237,24 @@ func (reg *Registry) Group(pattern string) *Registry {
 // Use this for chi.Route() groups.
 func (reg *Registry) WithPrefix(pattern string) *Registry {
 	return &Registry{
-		prefix: reg.prefix + pattern,
-		routes: reg.routes, // share the same slice via pointer
+		prefix:        reg.prefix + pattern,
+		routes:        reg.routes, // share the same slice via pointer
+		authorizer:    reg.authorizer,
+		guard:         reg.guard,
+		authenticated: reg.authenticated,
+	}
+}
+
+// Authenticated returns a registry that shares storage and prefix but marks
+// its routes as requiring a signed-in caller. Use it alongside the auth
+// middleware so the OpenAPI document lists the right security requirements.
+func (reg *Registry) Authenticated() *Registry {
+	return &Registry{
+		prefix:        reg.prefix,
+		routes:        reg.routes,
+		authorizer:    reg.authorizer,
+		guard:         reg.guard,
+		authenticated: true,
 	}
 }
 
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type permissionsKey struct{}
+
+func TestRegisterEnforcesPermission(t *testing.T) {
+	reg := NewRegistry()
+	reg.SetAuthorizer(func(ctx context.Context, permission string) bool {
+		granted, _ := ctx.Value(permissionsKey{}).([]string)
+		return slices.Contains(granted, permission)
+	})
+
+	r := chi.NewRouter()
+	r.Route("/projects/{projectId}", func(r chi.Router) {
+		projReg := reg.WithPrefix("/projects/{projectId}")
+		projReg.Register(r, Route{
+			Method: "DELETE", Pattern: "/",
+			Handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			Meta:    Meta{Group: "Projects", Description: "Delete project", Permission: "project:delete"},
+		})
+	})
+
+	tests := []struct {
+		name    string
+		granted []string
+		want    int
+	}{
+		{"no permissions", nil, http.StatusForbidden},
+		{"other permission", []string{"project:view"}, http.StatusForbidden},
+		{"required permission", []string{"project:view", "project:delete"}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/projects/p1/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), permissionsKey{}, tt.granted))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	if got := reg.Routes()[0].Permission; got != "project:delete" {
+		t.Errorf("route info permission = %q, want %q", got, "project:delete")
+	}
+}
+
+func TestRegisterRequiresPermissionForProjectRoutes(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for project route without a permission")
+		}
+	}()
+
+	reg := NewRegistry().WithPrefix("/projects/{projectId}")
+	reg.Register(chi.NewRouter(), Route{
+		Method: "GET", Pattern: "/",
+		Handler: func(http.ResponseWriter, *http.Request) {},
+		Meta:    Meta{Group: "Projects", Description: "Get project"},
+	})
+}
+
+func TestTrackPattern(t *testing.T) {
+	reg := NewRegistry()
+	r := chi.NewRouter()
+	r.Route("/api/items", func(r chi.Router) {
+		reg.WithPrefix("/api/items").Register(r, Route{
+			Method: "GET", Pattern: "/{itemId}",
+			Handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) },
+			Meta:    Meta{Group: "Items", Description: "Get item"},
+		})
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/items/42", "/api/items/{itemId}"},
+		{"/api/other", ""},
+	}
+	for _, tt := range tests {
+		req, pattern := TrackPattern(httptest.NewRequest("GET", tt.path, nil))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if got := pattern(); got != tt.want {
+			t.Errorf("%s: pattern = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -6,7 +6,6 @@ package docker
 import (
 	"context"
 	"fmt"
-	"log"
 
 	cerrdefs "github.com/containerd/errdefs"
 	containerTypes "github.com/docker/docker/api/types/container"
@@ -56,12 +55,12 @@ func (p *Provider) EnsureBuildKit(ctx context.Context, projectID string) (string
 			if startErr := p.client.ContainerStart(ctx, info.ID, containerTypes.StartOptions{}); startErr != nil {
 				return "", fmt.Errorf("failed to start buildkit container: %w", startErr)
 			}
-			log.Printf("Started existing BuildKit container %s for project %s", name, projectID)
+			dockerLog.InfoContext(ctx, "started existing BuildKit container", "name", name, "project_id", projectID)
 			return name, nil
 		}
 		// Wrong image — remove and recreate
-		log.Printf("BuildKit container %s uses outdated image %s (expected %s), recreating",
-			name, info.Config.Image, expectedImage)
+		dockerLog.InfoContext(ctx, "BuildKit container uses outdated image, recreating",
+			"name", name, "image", info.Config.Image, "expected", expectedImage)
 		if removeErr := p.client.ContainerRemove(ctx, info.ID, containerTypes.RemoveOptions{Force: true}); removeErr != nil {
 			return "", fmt.Errorf("failed to remove outdated buildkit container: %w", removeErr)
 		}
@@ -128,7 +127,7 @@ func (p *Provider) EnsureBuildKit(ctx context.Context, projectID string) (string
 		return "", fmt.Errorf("failed to start buildkit container: %w", err)
 	}
 
-	log.Printf("BuildKit container %s created and started for project %s (image: %s)", name, projectID, expectedImage)
+	dockerLog.InfoContext(ctx, "BuildKit container created and started", "name", name, "project_id", projectID, "image", expectedImage)
 	return name, nil
 }
 
@@ -154,7 +153,7 @@ func (p *Provider) ensureProjectNetwork(ctx context.Context, projectID string) (
 		return "", fmt.Errorf("failed to create project network: %w", err)
 	}
 
-	log.Printf("Created project network %s for project %s", networkName, projectID)
+	dockerLog.InfoContext(ctx, "created project network", "network", networkName, "project_id", projectID)
 	return networkName, nil
 }
 
@@ -174,7 +173,7 @@ func (p *Provider) RemoveBuildKit(ctx context.Context, projectID string) error {
 	networkName := buildkitNetworkName(projectID)
 	if err := p.client.NetworkRemove(ctx, networkName); err != nil {
 		if !cerrdefs.IsNotFound(err) {
-			log.Printf("Warning: failed to remove project network %s: %v", networkName, err)
+			dockerLog.WarnContext(ctx, "failed to remove project network", "network", networkName, "error", err)
 		}
 	}
 
@@ -205,14 +204,14 @@ func (p *Provider) ReconcileBuildKit(ctx context.Context) error {
 		}
 
 		if c.Image == expectedImage {
-			log.Printf("BuildKit container for project %s uses correct image", projectID)
+			dockerLog.DebugContext(ctx, "BuildKit container uses correct image", "project_id", projectID)
 			continue
 		}
 
-		log.Printf("BuildKit container for project %s uses outdated image %s (expected %s), removing",
-			projectID, c.Image, expectedImage)
+		dockerLog.InfoContext(ctx, "BuildKit container uses outdated image, removing",
+			"project_id", projectID, "image", c.Image, "expected", expectedImage)
 		if removeErr := p.client.ContainerRemove(ctx, c.ID, containerTypes.RemoveOptions{Force: true}); removeErr != nil {
-			log.Printf("Warning: failed to remove outdated BuildKit container for project %s: %v", projectID, removeErr)
+			dockerLog.WarnContext(ctx, "failed to remove outdated BuildKit container", "project_id", projectID, "error", removeErr)
 		}
 	}
 
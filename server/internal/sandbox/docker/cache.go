@@ -2,17 +2,38 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	cerrdefs "github.com/containerd/errdefs"
+	containerTypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
 	volumeTypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
 const (
 	// cacheVolumePrefix is the prefix for project-scoped cache volume names.
 	cacheVolumePrefix = "discobot-cache-"
+
+	// cacheAgentBinary is the agent binary in the sandbox image, which
+	// implements the "cache" subcommand used to inspect and prune volumes.
+	cacheAgentBinary = "/opt/discobot/bin/discobot-agent"
+
+	// cacheCommandTimeout bounds a single cache tool run.
+	cacheCommandTimeout = 10 * time.Minute
+
+	// cacheInvalidEntryExitCode is the cache subcommand's exit code for an
+	// entry that is not a cache path.
+	cacheInvalidEntryExitCode = 2
 )
 
 // cacheVolumeName generates a cache volume name from project ID.
@@ -83,3 +104,127 @@ func (p *Provider) ListCacheVolumes(ctx context.Context, projectID string) ([]*v
 
 	return resp.Volumes, nil
 }
+
+// CacheUsage reports per-entry usage of the project's cache volume.
+// Implements sandbox.CacheManager.
+func (p *Provider) CacheUsage(ctx context.Context, projectID string) (*sandbox.CacheUsage, error) {
+	usage := &sandbox.CacheUsage{Entries: []sandbox.CacheEntryUsage{}}
+	if err := p.runCacheCommand(ctx, projectID, []string{"usage"}, usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// PruneCache clears cache entries that are too old or exceed the size limit.
+// Implements sandbox.CacheManager.
+func (p *Provider) PruneCache(ctx context.Context, projectID string, opts sandbox.CachePruneOptions) (*sandbox.CachePruneResult, error) {
+	args := []string{"prune"}
+	if opts.MaxAge > 0 {
+		args = append(args, "--max-age", opts.MaxAge.String())
+	}
+	if opts.MaxBytes > 0 {
+		args = append(args, "--max-bytes", strconv.FormatInt(opts.MaxBytes, 10))
+	}
+	// Entries follow "--" so that one named like a flag is not read as one
+	args = append(args, "--")
+	args = append(args, opts.Entries...)
+
+	result := &sandbox.CachePruneResult{Cleared: []string{}}
+	if err := p.runCacheCommand(ctx, projectID, args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ClearCache empties the given cache entries, or all known entries.
+// Implements sandbox.CacheManager.
+func (p *Provider) ClearCache(ctx context.Context, projectID string, entries []string) (*sandbox.CachePruneResult, error) {
+	result := &sandbox.CachePruneResult{Cleared: []string{}}
+	if err := p.runCacheCommand(ctx, projectID, append([]string{"clear", "--"}, entries...), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runCacheCommand runs "discobot-agent cache <args>" in a short-lived
+// container with the project's cache volume mounted at /.data/cache, where
+// sandboxes mount it too, and decodes the JSON output into out. Nothing is
+// run if the project has no cache volume, leaving out unchanged.
+func (p *Provider) runCacheCommand(ctx context.Context, projectID string, args []string, out any) error {
+	volName := cacheVolumeName(projectID)
+	if _, err := p.client.VolumeInspect(ctx, volName); err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to inspect cache volume: %w", err)
+	}
+
+	if err := p.EnsureImage(ctx); err != nil {
+		return fmt.Errorf("failed to ensure sandbox image: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cacheCommandTimeout)
+	defer cancel()
+
+	resp, err := p.client.ContainerCreate(ctx, &containerTypes.Config{
+		Image: p.cfg.SandboxImage,
+		Cmd:   append([]string{cacheAgentBinary, "cache"}, args...),
+		Labels: map[string]string{
+			"discobot.managed":    "true",
+			"discobot.type":       "cache-tool",
+			"discobot.project.id": projectID,
+		},
+	}, &containerTypes.HostConfig{
+		Mounts: []mount.Mount{
+			{
+				Type:   mount.TypeVolume,
+				Source: volName,
+				Target: "/.data/cache",
+			},
+		},
+		NetworkMode: "none",
+	}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create cache tool container: %w", err)
+	}
+	defer func() {
+		removeCtx, removeCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer removeCancel()
+		_ = p.client.ContainerRemove(removeCtx, resp.ID, containerTypes.RemoveOptions{Force: true})
+	}()
+
+	waitCh, waitErrCh := p.client.ContainerWait(ctx, resp.ID, containerTypes.WaitConditionNextExit)
+	if err := p.client.ContainerStart(ctx, resp.ID, containerTypes.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start cache tool container: %w", err)
+	}
+
+	var exitCode int64
+	select {
+	case status := <-waitCh:
+		exitCode = status.StatusCode
+	case err := <-waitErrCh:
+		return fmt.Errorf("failed waiting for cache tool container: %w", err)
+	}
+
+	logs, err := p.client.ContainerLogs(ctx, resp.ID, containerTypes.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return fmt.Errorf("failed to read cache tool output: %w", err)
+	}
+	defer logs.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, logs); err != nil {
+		return fmt.Errorf("failed to read cache tool output: %w", err)
+	}
+
+	if exitCode == cacheInvalidEntryExitCode {
+		msg := strings.TrimPrefix(tailLines(stderr.String(), 1), "discobot-agent-cache: ")
+		return fmt.Errorf("%w: %s", sandbox.ErrInvalidCacheEntry, msg)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("cache %s failed (exit code %d): %s", args[0], exitCode, tailLines(stderr.String(), 5))
+	}
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return fmt.Errorf("invalid cache tool output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+)
+
+// buildImageScript runs inside the project's BuildKit container. It unpacks
+// the context archive from stdin, stages the discobot runtime from the
+// container's own filesystem (the BuildKit container runs the sandbox image)
+// as the RuntimeBuildContext, and builds with buildx against the local
+// buildkitd. The image is written to stdout as a docker image archive.
+//
+// Positional arguments are extra buildx flags (--build-arg ...); TAG holds
+// the image reference.
+var buildImageScript = `set -e
+dir=$(mktemp -d)
+trap 'rm -rf "$dir"' EXIT
+tar -x -C "$dir"
+mkdir -p "$dir/context"
+
+rt="$dir/` + sandbox.RuntimeBuildContext + `"
+mkdir -p "$rt/opt" "$rt/usr/bin" "$rt/usr/lib"
+cp -a /opt/discobot "$rt/opt/"
+cp -a /usr/bin/node "$rt/usr/bin/"
+cp -a /usr/lib/node_modules "$rt/usr/lib/"
+find /usr/bin -maxdepth 1 -type l -lname '*node_modules*' -exec cp -P {} "$rt/usr/bin/" \;
+
+builder=discobot-images
+/usr/bin/docker buildx inspect "$builder" >/dev/null 2>&1 \
+  || /usr/bin/docker buildx create --name "$builder" --driver remote tcp://127.0.0.1:` + fmt.Sprint(buildkitPort) + ` >/dev/null
+
+for d in "$dir"/*/; do
+  name=$(basename "$d")
+  [ "$name" = context ] || set -- "$@" --build-context "$name=$d"
+done
+
+/usr/bin/docker buildx build --builder "$builder" --progress plain \
+  -f "$dir/Dockerfile" "$@" \
+  --output "type=docker,name=$TAG,dest=-" \
+  "$dir/context"
+`
+
+// HasImage reports whether the image exists in the Docker daemon.
+func (p *Provider) HasImage(ctx context.Context, _ string, ref string) bool {
+	_, err := p.client.ImageInspect(ctx, ref)
+	return err == nil
+}
+
+// BuildImage builds an image in the project's BuildKit container, so builds
+// share the project's build cache, and loads the result into the Docker daemon.
+func (p *Provider) BuildImage(ctx context.Context, projectID string, req sandbox.ImageBuildRequest) error {
+	bkName, err := p.EnsureBuildKit(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to ensure buildkit container: %w", err)
+	}
+
+	cmd := []string{"sh", "-c", buildImageScript, "sh"}
+	for _, name := range slices.Sorted(maps.Keys(req.Args)) {
+		cmd = append(cmd, "--build-arg", name+"="+req.Args[name])
+	}
+
+	execCreate, err := p.client.ContainerExecCreate(ctx, bkName, containerTypes.ExecOptions{
+		Cmd:          cmd,
+		Env:          []string{"TAG=" + req.Tag},
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create build exec: %w", err)
+	}
+
+	resp, err := p.client.ContainerExecAttach(ctx, execCreate.ID, containerTypes.ExecStartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to attach to build exec: %w", err)
+	}
+	defer resp.Close()
+
+	go func() {
+		_, _ = io.Copy(resp.Conn, req.Context)
+		_ = resp.CloseWrite()
+	}()
+
+	// Stream the image archive from stdout straight into the daemon.
+	imageReader, imageWriter := io.Pipe()
+	var stderr bytes.Buffer
+	go func() {
+		_, copyErr := stdcopy.StdCopy(imageWriter, &stderr, resp.Reader)
+		imageWriter.CloseWithError(copyErr)
+	}()
+
+	loadResp, loadErr := p.client.ImageLoad(ctx, imageReader, client.ImageLoadWithQuiet(true))
+	if loadErr == nil {
+		_, _ = io.Copy(io.Discard, loadResp.Body)
+		loadResp.Body.Close()
+	}
+	// Drain any output left after a failed load so the exec can finish.
+	_, _ = io.Copy(io.Discard, imageReader)
+
+	inspect, err := p.client.ContainerExecInspect(ctx, execCreate.ID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect build exec: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("image build failed (exit code %d): %s", inspect.ExitCode, tailLines(stderr.String(), 20))
+	}
+	if loadErr != nil {
+		return fmt.Errorf("failed to load built image: %w", loadErr)
+	}
+
+	dockerLog.InfoContext(ctx, "built image in BuildKit container", "tag", req.Tag, "buildkit", bkName)
+	return nil
+}
+
+// tailLines returns the last n lines of s.
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
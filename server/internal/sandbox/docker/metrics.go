@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+)
+
+// statsTimeout bounds a stats request; the daemon's own sampling takes
+// about a second.
+const statsTimeout = 10 * time.Second
+
+// Metrics samples the container's resource usage through the stats API.
+// Implements sandbox.MetricsProvider.
+func (p *Provider) Metrics(ctx context.Context, sessionID string) (*sandbox.ResourceMetrics, error) {
+	containerID, err := p.getContainerID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+
+	// A non-streaming request makes the daemon take two samples about a
+	// second apart, so precpu_stats is populated for the CPU calculation.
+	resp, err := p.client.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var stats containerTypes.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+	// Stopped containers report an empty sample
+	if stats.Read.IsZero() {
+		return nil, sandbox.ErrNotRunning
+	}
+
+	return metricsFromStats(&stats), nil
+}
+
+// metricsFromStats converts a Docker stats sample, computing CPU and memory
+// the same way as `docker stats`.
+func metricsFromStats(stats *containerTypes.StatsResponse) *sandbox.ResourceMetrics {
+	m := &sandbox.ResourceMetrics{
+		Timestamp:        stats.Read,
+		CPUPercent:       cpuPercent(stats),
+		MemoryBytes:      memoryUsage(&stats.MemoryStats),
+		MemoryLimitBytes: stats.MemoryStats.Limit,
+		Processes:        stats.PidsStats.Current,
+	}
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			m.DiskReadBytes += entry.Value
+		case "write":
+			m.DiskWriteBytes += entry.Value
+		}
+	}
+	for _, nw := range stats.Networks {
+		m.NetworkRxBytes += nw.RxBytes
+		m.NetworkTxBytes += nw.TxBytes
+	}
+	return m
+}
+
+// cpuPercent returns the container's share of host CPU time between the
+// two samples, scaled so one fully busy core is 100.
+func cpuPercent(stats *containerTypes.StatsResponse) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// memoryUsage returns memory usage without the reclaimable page cache.
+func memoryUsage(mem *containerTypes.MemoryStats) uint64 {
+	// cgroup v2 reports inactive_file, cgroup v1 total_inactive_file
+	inactive, ok := mem.Stats["inactive_file"]
+	if !ok {
+		inactive = mem.Stats["total_inactive_file"]
+	}
+	if inactive > mem.Usage {
+		return mem.Usage
+	}
+	return mem.Usage - inactive
+}
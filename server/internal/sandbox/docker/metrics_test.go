@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestMetricsFromStats(t *testing.T) {
+	var stats containerTypes.StatsResponse
+	stats.Read = time.Now()
+	stats.PreCPUStats.CPUUsage.TotalUsage = 1_000
+	stats.PreCPUStats.SystemUsage = 10_000
+	stats.CPUStats.CPUUsage.TotalUsage = 3_000
+	stats.CPUStats.SystemUsage = 18_000
+	stats.CPUStats.OnlineCPUs = 4
+	stats.MemoryStats = containerTypes.MemoryStats{
+		Usage: 500,
+		Limit: 1_000,
+		Stats: map[string]uint64{"inactive_file": 100},
+	}
+	stats.PidsStats.Current = 7
+	stats.BlkioStats.IoServiceBytesRecursive = []containerTypes.BlkioStatEntry{
+		{Op: "Read", Value: 10},
+		{Op: "read", Value: 5},
+		{Op: "Write", Value: 20},
+		{Op: "Total", Value: 35},
+	}
+	stats.Networks = map[string]containerTypes.NetworkStats{
+		"eth0": {RxBytes: 1, TxBytes: 2},
+		"eth1": {RxBytes: 3, TxBytes: 4},
+	}
+
+	m := metricsFromStats(&stats)
+
+	// 2000 of 8000 system ns across 4 CPUs is one full core
+	if m.CPUPercent != 100 {
+		t.Errorf("CPUPercent = %v, want 100", m.CPUPercent)
+	}
+	if m.MemoryBytes != 400 || m.MemoryLimitBytes != 1_000 {
+		t.Errorf("memory = %d/%d, want 400/1000", m.MemoryBytes, m.MemoryLimitBytes)
+	}
+	if m.DiskReadBytes != 15 || m.DiskWriteBytes != 20 {
+		t.Errorf("disk = %d/%d, want 15/20", m.DiskReadBytes, m.DiskWriteBytes)
+	}
+	if m.NetworkRxBytes != 4 || m.NetworkTxBytes != 6 {
+		t.Errorf("network = %d/%d, want 4/6", m.NetworkRxBytes, m.NetworkTxBytes)
+	}
+	if m.Processes != 7 {
+		t.Errorf("Processes = %d, want 7", m.Processes)
+	}
+}
+
+func TestCPUPercentWithoutPreviousSample(t *testing.T) {
+	var stats containerTypes.StatsResponse
+	stats.CPUStats.CPUUsage.TotalUsage = 3_000
+	stats.CPUStats.SystemUsage = 18_000
+	stats.CPUStats.OnlineCPUs = 4
+	// Counters going backwards (container restarted) must not go negative
+	stats.PreCPUStats.CPUUsage.TotalUsage = 5_000
+	stats.PreCPUStats.SystemUsage = 10_000
+
+	if got := cpuPercent(&stats); got != 0 {
+		t.Errorf("cpuPercent = %v, want 0", got)
+	}
+}
@@ -10,10 +10,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"maps"
 	"net"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,9 +34,13 @@ import (
 	dockercontext "github.com/docker/go-sdk/context"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
+// dockerLog is the logger for Docker provider messages.
+var dockerLog = logging.Component("docker")
+
 const (
 	// labelSecret is the label key for storing the raw shared secret.
 	labelSecret = "discobot.secret"
@@ -62,7 +67,7 @@ func DetectDockerHost() string {
 		return ""
 	}
 	if host != "" {
-		log.Printf("Detected Docker host from context: %s", host)
+		dockerLog.Info("detected Docker host from context", "host", host)
 	}
 	return host
 }
@@ -203,13 +208,13 @@ func NewProvider(cfg *config.Config, sessionProjectResolver SessionProjectResolv
 		defer cleanupCancel()
 
 		if err := p.cleanupOldSandboxImages(cleanupCtx, cfg.SandboxImage); err != nil {
-			log.Printf("Warning: Failed to clean up old sandbox images: %v", err)
+			dockerLog.Warn("failed to clean up old sandbox images", "error", err)
 		}
 
-		log.Printf("Docker provider background initialization complete")
+		dockerLog.Info("Docker provider background initialization complete")
 	}()
 
-	log.Printf("Docker provider initialized, image pull running in background")
+	dockerLog.Info("Docker provider initialized, image pull running in background")
 	return p, nil
 }
 
@@ -236,6 +241,8 @@ func (p *Provider) Image() string {
 
 // Create creates a new Docker container for the given session.
 func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.CreateOptions) (*sandbox.Sandbox, error) {
+	ctx = logging.With(ctx, "session_id", sessionID)
+
 	// Check if sandbox already exists in cache
 	p.containerIDsMu.RLock()
 	cachedID, existsInCache := p.containerIDs[sessionID]
@@ -250,7 +257,7 @@ func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.Cr
 			return nil, sandbox.ErrAlreadyExists
 		}
 		// Otherwise, remove the stale container (force cleanup from previous runs)
-		log.Printf("Removing stale container %s (%s) before creating new sandbox", existing.ID[:12], name)
+		dockerLog.InfoContext(ctx, "removing stale container before creating new sandbox", "container_id", existing.ID[:12], "name", name)
 		if err := p.client.ContainerRemove(ctx, existing.ID, containerTypes.RemoveOptions{Force: true}); err != nil {
 			return nil, fmt.Errorf("failed to remove stale container: %w", err)
 		}
@@ -261,11 +268,16 @@ func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.Cr
 		p.clearContainerID(sessionID)
 	}
 
-	// Use the globally configured sandbox image
+	// Use the globally configured sandbox image unless the workspace has its own
 	image := p.cfg.SandboxImage
-
-	// Wait for image to be available (pulled on startup or by first caller)
-	if err := p.EnsureImage(ctx); err != nil {
+	if opts.Image != "" && opts.Image != image {
+		// Workspace images are built locally (see BuildImage), never pulled
+		image = opts.Image
+		if _, err := p.client.ImageInspect(ctx, image); err != nil {
+			return nil, fmt.Errorf("%w: workspace image %s: %v", sandbox.ErrInvalidImage, image, err)
+		}
+	} else if err := p.EnsureImage(ctx); err != nil {
+		// Wait for image to be available (pulled on startup or by first caller)
 		return nil, fmt.Errorf("%w: %v", sandbox.ErrInvalidImage, err)
 	}
 
@@ -294,8 +306,12 @@ func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.Cr
 		labels[k] = v
 	}
 
-	// Build environment variables
+	// Build environment variables, starting with the caller's so that the
+	// variables below take precedence (later entries win)
 	var env []string
+	for _, k := range slices.Sorted(maps.Keys(opts.Env)) {
+		env = append(env, fmt.Sprintf("%s=%s", k, opts.Env[k]))
+	}
 
 	// Add session ID (required by discobot-agent for filesystem setup)
 	env = append(env, fmt.Sprintf("SESSION_ID=%s", sessionID))
@@ -333,7 +349,7 @@ func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.Cr
 	// Ensure BuildKit container is running for the project (shared build cache)
 	var buildkitHost string
 	if bkName, bkErr := p.EnsureBuildKit(ctx, projectID); bkErr != nil {
-		log.Printf("Warning: failed to ensure BuildKit container for project %s: %v (Docker builds will use local cache)", projectID, bkErr)
+		dockerLog.WarnContext(ctx, "failed to ensure BuildKit container, Docker builds will use local cache", "project_id", projectID, "error", bkErr)
 	} else {
 		buildkitHost = bkName
 		env = append(env, fmt.Sprintf("BUILDKIT_HOST=tcp://%s:%d", bkName, buildkitPort))
@@ -421,7 +437,7 @@ func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.Cr
 		Source: cacheVolName,
 		Target: "/.data/cache",
 	})
-	log.Printf("Mounted cache volume %s at /.data/cache for session %s", cacheVolName, sessionID)
+	dockerLog.DebugContext(ctx, "mounted cache volume at /.data/cache", "volume", cacheVolName)
 
 	// Configure network
 	if p.cfg.DockerNetwork != "" {
@@ -452,7 +468,7 @@ func (p *Provider) Create(ctx context.Context, sessionID string, opts sandbox.Cr
 	if buildkitHost != "" {
 		networkName := buildkitNetworkName(projectID)
 		if netErr := p.client.NetworkConnect(ctx, networkName, resp.ID, nil); netErr != nil {
-			log.Printf("Warning: failed to connect session %s to project network %s: %v", sessionID, networkName, netErr)
+			dockerLog.WarnContext(ctx, "failed to connect session to project network", "network", networkName, "error", netErr)
 		}
 	}
 
@@ -542,9 +558,9 @@ func (p *Provider) doEnsureImage() {
 		cancel()
 
 		if err == nil {
-			log.Printf("Local sandbox image exists: %s", image)
+			dockerLog.Info("local sandbox image exists", "image", image)
 		} else {
-			log.Printf("Local sandbox image not yet available: %s (expected to be loaded externally)", image)
+			dockerLog.Info("local sandbox image not yet available, expected to be loaded externally", "image", image)
 		}
 		return
 	}
@@ -554,7 +570,7 @@ func (p *Provider) doEnsureImage() {
 	_, err := p.client.ImageInspect(checkCtx, image)
 	checkCancel()
 	if err == nil {
-		log.Printf("Sandbox image already exists: %s", image)
+		dockerLog.Info("sandbox image already exists", "image", image)
 		return
 	}
 
@@ -575,15 +591,14 @@ func (p *Provider) doEnsureImage() {
 		pullCancel()
 
 		if err == nil {
-			log.Printf("Successfully pulled sandbox image: %s", image)
+			dockerLog.Info("pulled sandbox image", "image", image)
 			if p.systemManager != nil {
 				p.systemManager.CompleteTask("docker-pull")
 			}
 			return
 		}
 
-		log.Printf("Warning: Failed to pull sandbox image (attempt %d): %v", attempt, err)
-		log.Printf("Retrying in %v...", backoff)
+		dockerLog.Warn("failed to pull sandbox image, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
 
 		time.Sleep(backoff)
 
@@ -608,7 +623,7 @@ func (p *Provider) pullSandboxImage(ctx context.Context, image string) error {
 	// Check if image already exists locally
 	_, err := p.client.ImageInspect(ctx, image)
 	if err == nil {
-		log.Printf("Sandbox image already exists locally, skipping pull: %s", image)
+		dockerLog.InfoContext(ctx, "sandbox image already exists locally, skipping pull", "image", image)
 		if p.systemManager != nil {
 			p.systemManager.UpdateTaskProgress("docker-pull", 100, "Image already exists")
 		}
@@ -617,12 +632,12 @@ func (p *Provider) pullSandboxImage(ctx context.Context, image string) error {
 
 	// Image doesn't exist locally. Check if it's a local-only image that can't be pulled.
 	if isLocalImage(image) {
-		log.Printf("Sandbox image is a local image and doesn't exist, cannot pull: %s", image)
+		dockerLog.WarnContext(ctx, "sandbox image is a local image and doesn't exist, cannot pull", "image", image)
 		return fmt.Errorf("local image %s not found and cannot be pulled from registry", image)
 	}
 
 	// Image doesn't exist, pull it (works for both tags and digest references)
-	log.Printf("Pulling sandbox image: %s", image)
+	dockerLog.InfoContext(ctx, "pulling sandbox image", "image", image)
 	reader, err := p.client.ImagePull(ctx, image, imageTypes.PullOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to pull sandbox image %s: %w", image, err)
@@ -641,7 +656,7 @@ func (p *Provider) pullSandboxImage(ctx context.Context, image string) error {
 		return fmt.Errorf("failed to complete sandbox image pull for %s: %w", image, err)
 	}
 
-	log.Printf("Successfully pulled sandbox image: %s", image)
+	dockerLog.InfoContext(ctx, "pulled sandbox image", "image", image)
 	return nil
 }
 
@@ -715,7 +730,7 @@ func (p *Provider) cleanupOldSandboxImages(ctx context.Context, currentImage str
 	// Get the current image ID to avoid deleting it
 	currentImageInfo, err := p.client.ImageInspect(ctx, currentImage)
 	if err != nil {
-		log.Printf("Warning: Failed to inspect current sandbox image %s: %v", currentImage, err)
+		dockerLog.WarnContext(ctx, "failed to inspect current sandbox image", "image", currentImage, "error", err)
 		currentImageInfo.ID = "" // Empty ID means nothing will match it in the cleanup loop
 	}
 
@@ -727,20 +742,20 @@ func (p *Provider) cleanupOldSandboxImages(ctx context.Context, currentImage str
 		}
 
 		// Delete the old image
-		log.Printf("Removing old sandbox image: %s (ID: %s)", img.RepoTags, img.ID)
+		dockerLog.InfoContext(ctx, "removing old sandbox image", "tags", img.RepoTags, "image_id", img.ID)
 		_, err := p.client.ImageRemove(ctx, img.ID, imageTypes.RemoveOptions{
 			Force:         true, // Force removal even if image has tags
 			PruneChildren: true,
 		})
 		if err != nil {
-			log.Printf("Warning: Failed to remove old sandbox image %s: %v", img.ID, err)
+			dockerLog.WarnContext(ctx, "failed to remove old sandbox image", "image_id", img.ID, "error", err)
 			continue
 		}
 		deletedCount++
 	}
 
 	if deletedCount > 0 {
-		log.Printf("Cleaned up %d old sandbox image(s)", deletedCount)
+		dockerLog.InfoContext(ctx, "cleaned up old sandbox images", "count", deletedCount)
 	}
 
 	return nil
@@ -751,12 +766,12 @@ func (p *Provider) cleanupOldSandboxImages(ctx context.Context, currentImage str
 func (p *Provider) Reconcile(ctx context.Context) error {
 	// Reconcile BuildKit containers (remove outdated, they're recreated on next session start)
 	if err := p.ReconcileBuildKit(ctx); err != nil {
-		log.Printf("Warning: Failed to reconcile BuildKit containers: %v", err)
+		dockerLog.WarnContext(ctx, "failed to reconcile BuildKit containers", "error", err)
 	}
 
 	// Clean up old sandbox images that are no longer in use
 	if err := p.cleanupOldSandboxImages(ctx, p.cfg.SandboxImage); err != nil {
-		log.Printf("Warning: Failed to clean up old sandbox images: %v", err)
+		dockerLog.WarnContext(ctx, "failed to clean up old sandbox images", "error", err)
 	}
 
 	return nil
@@ -766,12 +781,12 @@ func (p *Provider) Reconcile(ctx context.Context) error {
 func (p *Provider) RemoveProject(ctx context.Context, projectID string) error {
 	// Remove BuildKit container and project network
 	if err := p.RemoveBuildKit(ctx, projectID); err != nil {
-		log.Printf("Warning: failed to remove BuildKit for project %s: %v", projectID, err)
+		dockerLog.WarnContext(ctx, "failed to remove BuildKit", "project_id", projectID, "error", err)
 	}
 
 	// Remove project cache volume
 	if err := p.RemoveCacheVolume(ctx, projectID); err != nil {
-		log.Printf("Warning: failed to remove cache volume for project %s: %v", projectID, err)
+		dockerLog.WarnContext(ctx, "failed to remove cache volume", "project_id", projectID, "error", err)
 	}
 
 	return nil
@@ -1570,7 +1585,7 @@ func (p *Provider) Watch(ctx context.Context) (<-chan sandbox.StateEvent, error)
 		// First, replay current state of all managed sandboxes
 		sandboxes, err := p.List(ctx)
 		if err != nil {
-			log.Printf("Watch: failed to list sandboxes for replay: %v", err)
+			dockerLog.WarnContext(ctx, "failed to list sandboxes for watch replay", "error", err)
 			// Continue anyway - we can still watch for new events
 		} else {
 			for _, sb := range sandboxes {
@@ -1625,7 +1640,7 @@ func (p *Provider) watchDockerEvents(ctx context.Context, eventCh chan<- sandbox
 		case <-ctx.Done():
 			return
 		case <-time.After(5 * time.Second):
-			log.Printf("Watch: reconnecting to Docker events...")
+			dockerLog.InfoContext(ctx, "reconnecting to Docker events")
 		}
 	}
 }
@@ -1647,7 +1662,7 @@ func (p *Provider) processDockerEvents(ctx context.Context, eventCh chan<- sandb
 			if ctx.Err() != nil {
 				return false
 			}
-			log.Printf("Watch: Docker events error: %v, reconnecting...", err)
+			dockerLog.WarnContext(ctx, "Docker events error, reconnecting", "error", err)
 			return true
 
 		case msg := <-msgCh:
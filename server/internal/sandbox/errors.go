@@ -33,4 +33,13 @@ var (
 
 	// ErrResourceLimit indicates a resource limit was exceeded.
 	ErrResourceLimit = errors.New("resource limit exceeded")
+
+	// ErrMetricsNotSupported indicates the provider can't report resource usage.
+	ErrMetricsNotSupported = errors.New("resource metrics not supported by provider")
+
+	// ErrCacheNotSupported indicates no provider manages cache volumes.
+	ErrCacheNotSupported = errors.New("cache volumes not supported by provider")
+
+	// ErrInvalidCacheEntry indicates a cache entry that is not a cache path.
+	ErrInvalidCacheEntry = errors.New("invalid cache entry")
 )
@@ -0,0 +1,188 @@
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+)
+
+const (
+	// cpuSampleWindow is how long CPU time is measured for CPUPercent.
+	cpuSampleWindow = time.Second
+
+	// clockTicks is the kernel's USER_HZ, the unit of utime and stime in
+	// /proc/<pid>/stat. It is 100 on every mainstream Linux architecture.
+	clockTicks = 100
+)
+
+// Metrics samples the resource usage of the agent process and everything it
+// started, read from /proc. Network traffic is not attributable to a process
+// tree on the host network and is reported as zero.
+// Implements sandbox.MetricsProvider.
+func (p *Provider) Metrics(ctx context.Context, sessionID string) (*sandbox.ResourceMetrics, error) {
+	p.processesMu.RLock()
+	info, exists := p.processes[sessionID]
+	var pid int
+	if exists && info.status == sandbox.StatusRunning && info.cmd != nil && info.cmd.Process != nil {
+		pid = info.cmd.Process.Pid
+	}
+	p.processesMu.RUnlock()
+
+	if !exists {
+		return nil, sandbox.ErrNotFound
+	}
+	if pid == 0 {
+		return nil, sandbox.ErrNotRunning
+	}
+
+	first, err := sampleProcessTree("/proc", pid)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(cpuSampleWindow):
+	}
+
+	last, err := sampleProcessTree("/proc", pid)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start).Seconds()
+
+	m := &sandbox.ResourceMetrics{
+		Timestamp:      time.Now(),
+		MemoryBytes:    last.rssBytes,
+		DiskReadBytes:  last.readBytes,
+		DiskWriteBytes: last.writeBytes,
+		Processes:      uint64(last.processes),
+	}
+	// Processes that exited between samples take their CPU time with them
+	if last.cpuTicks > first.cpuTicks && elapsed > 0 {
+		m.CPUPercent = float64(last.cpuTicks-first.cpuTicks) / clockTicks / elapsed * 100
+	}
+	return m, nil
+}
+
+// processTreeSample is the summed usage of a process and its descendants.
+type processTreeSample struct {
+	processes  int
+	cpuTicks   uint64
+	rssBytes   uint64
+	readBytes  uint64
+	writeBytes uint64
+}
+
+// sampleProcessTree sums the usage of root and all of its descendants.
+func sampleProcessTree(procDir string, root int) (*processTreeSample, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procDir, err)
+	}
+
+	stats := make(map[int]*procStat)
+	children := make(map[int][]int)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		st, err := readProcStat(procDir, pid)
+		if err != nil {
+			// Exited while listing
+			continue
+		}
+		stats[pid] = st
+		children[st.ppid] = append(children[st.ppid], pid)
+	}
+	if _, ok := stats[root]; !ok {
+		return nil, sandbox.ErrNotRunning
+	}
+
+	pageSize := uint64(os.Getpagesize())
+	sample := &processTreeSample{}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		queue = append(queue, children[pid]...)
+
+		st := stats[pid]
+		sample.processes++
+		sample.cpuTicks += st.utime + st.stime
+		sample.rssBytes += st.rssPages * pageSize
+		if r, w, err := readProcIO(procDir, pid); err == nil {
+			sample.readBytes += r
+			sample.writeBytes += w
+		}
+	}
+	return sample, nil
+}
+
+// procStat holds the fields used from /proc/<pid>/stat.
+type procStat struct {
+	ppid     int
+	utime    uint64
+	stime    uint64
+	rssPages uint64
+}
+
+// readProcStat parses /proc/<pid>/stat. The command name may contain spaces
+// and parentheses, so fields are counted from the last ')'.
+func readProcStat(procDir string, pid int) (*procStat, error) {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	// Fields after the command: state(3) ppid(4) ... utime(14) stime(15) ... rss(24)
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	return &procStat{ppid: ppid, utime: utime, stime: stime, rssPages: uint64(max(rss, 0))}, nil
+}
+
+// readProcIO returns the bytes a process caused to be read from and written
+// to storage, from /proc/<pid>/io.
+func readProcIO(procDir string, pid int) (read, write uint64, err error) {
+	f, err := os.Open(filepath.Join(procDir, strconv.Itoa(pid), "io"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		switch key {
+		case "read_bytes":
+			read = n
+		case "write_bytes":
+			write = n
+		}
+	}
+	return read, write, scanner.Err()
+}
@@ -9,7 +9,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"log"
+	"maps"
 	"net"
 	"net/http"
 	"os"
@@ -20,9 +20,13 @@ import (
 	"time"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
+// localLog is the logger for local provider messages.
+var localLog = logging.Component("local")
+
 // Provider implements the sandbox.Provider interface using local processes.
 type Provider struct {
 	cfg        *config.Config
@@ -67,7 +71,7 @@ func NewProvider(cfg *config.Config) (*Provider, error) {
 		return nil, fmt.Errorf("agent API binary not found: %w (looking for: %s)", err, binaryPath)
 	}
 
-	log.Printf("Local provider using agent API binary: %s", resolvedPath)
+	localLog.Info("local provider using agent API binary", "path", resolvedPath)
 
 	p := &Provider{
 		cfg:        cfg,
@@ -131,11 +135,13 @@ func (p *Provider) Create(_ context.Context, sessionID string, opts sandbox.Crea
 		metadata[k] = v
 	}
 
-	// Build environment variables
-	env := map[string]string{
-		"SESSION_ID":     sessionID,
-		"WORKSPACE_PATH": workspacePath,
+	// Build environment variables; the provider's own take precedence over opts.Env
+	env := maps.Clone(opts.Env)
+	if env == nil {
+		env = make(map[string]string)
 	}
+	env["SESSION_ID"] = sessionID
+	env["WORKSPACE_PATH"] = workspacePath
 
 	// Add hashed secret if provided
 	if opts.SharedSecret != "" {
@@ -184,7 +190,7 @@ func (p *Provider) Create(_ context.Context, sessionID string, opts sandbox.Crea
 }
 
 // Start starts the agent API process for the given session.
-func (p *Provider) Start(_ context.Context, sessionID string) error {
+func (p *Provider) Start(ctx context.Context, sessionID string) error {
 	p.processesMu.Lock()
 	defer p.processesMu.Unlock()
 
@@ -217,7 +223,7 @@ func (p *Provider) Start(_ context.Context, sessionID string) error {
 	port := addr.Port
 	listener.Close() // Close the listener so the agent API can bind to it
 
-	log.Printf("Allocated port %d for session %s", port, sessionID)
+	localLog.DebugContext(ctx, "allocated port", "session_id", sessionID, "port", port)
 
 	// Build command using configured binary path
 	cmd := exec.Command(p.binaryPath)
@@ -260,7 +266,7 @@ func (p *Provider) Start(_ context.Context, sessionID string) error {
 		Timestamp: now,
 	})
 
-	log.Printf("Started agent API for session %s on port %d (PID %d)", sessionID, port, cmd.Process.Pid)
+	localLog.InfoContext(ctx, "started agent API", "session_id", sessionID, "port", port, "pid", cmd.Process.Pid)
 
 	return nil
 }
@@ -289,7 +295,7 @@ func (p *Provider) monitorProcess(sessionID string, cmd *exec.Cmd) {
 			Timestamp: now,
 			Error:     info.error,
 		})
-		log.Printf("Agent API process for session %s exited with error: %v", sessionID, err)
+		localLog.Warn("agent API process exited with error", "session_id", sessionID, "error", err)
 	} else {
 		info.status = sandbox.StatusStopped
 		p.broadcastEvent(sandbox.StateEvent{
@@ -297,12 +303,12 @@ func (p *Provider) monitorProcess(sessionID string, cmd *exec.Cmd) {
 			Status:    sandbox.StatusStopped,
 			Timestamp: now,
 		})
-		log.Printf("Agent API process for session %s stopped", sessionID)
+		localLog.Info("agent API process stopped", "session_id", sessionID)
 	}
 }
 
 // Stop stops the agent API process gracefully.
-func (p *Provider) Stop(_ context.Context, sessionID string, timeout time.Duration) error {
+func (p *Provider) Stop(ctx context.Context, sessionID string, timeout time.Duration) error {
 	p.processesMu.Lock()
 	defer p.processesMu.Unlock()
 
@@ -317,7 +323,7 @@ func (p *Provider) Stop(_ context.Context, sessionID string, timeout time.Durati
 
 	// Send SIGTERM for graceful shutdown
 	if err := info.cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		log.Printf("Failed to send SIGTERM to session %s: %v", sessionID, err)
+		localLog.WarnContext(ctx, "failed to send SIGTERM", "session_id", sessionID, "error", err)
 	}
 
 	// Wait for process to exit with timeout
@@ -337,11 +343,11 @@ func (p *Provider) Stop(_ context.Context, sessionID string, timeout time.Durati
 			Status:    sandbox.StatusStopped,
 			Timestamp: now,
 		})
-		log.Printf("Stopped agent API for session %s", sessionID)
+		localLog.InfoContext(ctx, "stopped agent API", "session_id", sessionID)
 	case <-time.After(timeout):
 		// Timeout - force kill
 		if err := info.cmd.Process.Kill(); err != nil {
-			log.Printf("Failed to kill process for session %s: %v", sessionID, err)
+			localLog.WarnContext(ctx, "failed to kill process", "session_id", sessionID, "error", err)
 		}
 		now := time.Now()
 		info.status = sandbox.StatusStopped
@@ -351,7 +357,7 @@ func (p *Provider) Stop(_ context.Context, sessionID string, timeout time.Durati
 			Status:    sandbox.StatusStopped,
 			Timestamp: now,
 		})
-		log.Printf("Force killed agent API for session %s after timeout", sessionID)
+		localLog.WarnContext(ctx, "force killed agent API after timeout", "session_id", sessionID)
 	}
 
 	return nil
@@ -381,7 +387,7 @@ func (p *Provider) Remove(ctx context.Context, sessionID string, _ ...sandbox.Re
 		Timestamp: time.Now(),
 	})
 
-	log.Printf("Removed sandbox for session %s", sessionID)
+	localLog.InfoContext(ctx, "removed sandbox", "session_id", sessionID)
 
 	return nil
 }
@@ -548,6 +554,13 @@ func (p *Provider) ExecStream(_ context.Context, sessionID string, _ []string, _
 	}, nil
 }
 
+// SupportsTunnel reports false: local sandboxes run on the host without the
+// agent's tunnel, so the server reaches them with HTTPClient.
+// Implements sandbox.TunnelSupport.
+func (p *Provider) SupportsTunnel(_ context.Context, _ string) bool {
+	return false
+}
+
 // HTTPClient returns an HTTP client configured to communicate with the sandbox.
 func (p *Provider) HTTPClient(_ context.Context, sessionID string) (*http.Client, error) {
 	p.processesMu.RLock()
@@ -656,7 +669,7 @@ func hashSecret(secret string) string {
 	// Generate a random 16-byte salt
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
-		log.Printf("Failed to generate salt: %v", err)
+		localLog.Error("failed to generate salt", "error", err)
 		return ""
 	}
 
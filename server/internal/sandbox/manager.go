@@ -1,14 +1,25 @@
 package sandbox
 
 import (
+	"cmp"
 	"context"
 	"fmt"
-	"log"
+	"maps"
 	"net/http"
 	"runtime"
+	"slices"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/tracing"
 )
 
+// managerLog is the logger for provider manager messages.
+var managerLog = logging.Component("sandbox")
+
 // PlatformDefaultProvider returns the default sandbox provider for the current OS.
 // On macOS (darwin), the default is "vz" (Virtualization.framework).
 // On all other platforms, the default is "docker".
@@ -152,6 +163,11 @@ func NewProviderProxy(manager *Manager, providerGetter func(ctx context.Context,
 	}
 }
 
+// startSpan starts a span for a provider operation on a session's sandbox.
+func startSpan(ctx context.Context, op, sessionID string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "sandbox."+op, attribute.String("session.id", sessionID))
+}
+
 // ListProviders returns the names of all available providers.
 func (p *ProviderProxy) ListProviders() []string {
 	return p.manager.ListProviders()
@@ -176,11 +192,15 @@ func (p *ProviderProxy) Image() string {
 }
 
 // Create creates a sandbox using the provider determined by providerGetter.
-func (p *ProviderProxy) Create(ctx context.Context, sessionID string, opts CreateOptions) (*Sandbox, error) {
+func (p *ProviderProxy) Create(ctx context.Context, sessionID string, opts CreateOptions) (_ *Sandbox, err error) {
+	ctx, span := startSpan(ctx, "Create", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -191,11 +211,15 @@ func (p *ProviderProxy) Create(ctx context.Context, sessionID string, opts Creat
 }
 
 // Start starts a sandbox using the provider determined by providerGetter.
-func (p *ProviderProxy) Start(ctx context.Context, sessionID string) error {
+func (p *ProviderProxy) Start(ctx context.Context, sessionID string) (err error) {
+	ctx, span := startSpan(ctx, "Start", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -206,11 +230,15 @@ func (p *ProviderProxy) Start(ctx context.Context, sessionID string) error {
 }
 
 // Stop stops a sandbox using the provider determined by providerGetter.
-func (p *ProviderProxy) Stop(ctx context.Context, sessionID string, timeout time.Duration) error {
+func (p *ProviderProxy) Stop(ctx context.Context, sessionID string, timeout time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "Stop", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -221,11 +249,15 @@ func (p *ProviderProxy) Stop(ctx context.Context, sessionID string, timeout time
 }
 
 // Remove removes a sandbox using the provider determined by providerGetter.
-func (p *ProviderProxy) Remove(ctx context.Context, sessionID string, opts ...RemoveOption) error {
+func (p *ProviderProxy) Remove(ctx context.Context, sessionID string, opts ...RemoveOption) (err error) {
+	ctx, span := startSpan(ctx, "Remove", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -281,11 +313,15 @@ func (p *ProviderProxy) List(ctx context.Context) ([]*Sandbox, error) {
 }
 
 // Exec executes a command using the provider determined by providerGetter.
-func (p *ProviderProxy) Exec(ctx context.Context, sessionID string, cmd []string, opts ExecOptions) (*ExecResult, error) {
+func (p *ProviderProxy) Exec(ctx context.Context, sessionID string, cmd []string, opts ExecOptions) (_ *ExecResult, err error) {
+	ctx, span := startSpan(ctx, "Exec", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -296,11 +332,15 @@ func (p *ProviderProxy) Exec(ctx context.Context, sessionID string, cmd []string
 }
 
 // Attach attaches to a sandbox using the provider determined by providerGetter.
-func (p *ProviderProxy) Attach(ctx context.Context, sessionID string, opts AttachOptions) (PTY, error) {
+func (p *ProviderProxy) Attach(ctx context.Context, sessionID string, opts AttachOptions) (_ PTY, err error) {
+	ctx, span := startSpan(ctx, "Attach", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -311,11 +351,15 @@ func (p *ProviderProxy) Attach(ctx context.Context, sessionID string, opts Attac
 }
 
 // ExecStream executes a streaming command using the provider determined by providerGetter.
-func (p *ProviderProxy) ExecStream(ctx context.Context, sessionID string, cmd []string, opts ExecStreamOptions) (Stream, error) {
+func (p *ProviderProxy) ExecStream(ctx context.Context, sessionID string, cmd []string, opts ExecStreamOptions) (_ Stream, err error) {
+	ctx, span := startSpan(ctx, "ExecStream", sessionID)
+	defer func() { tracing.End(span, err) }()
+
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider for session: %w", err)
 	}
+	span.SetAttributes(attribute.String("sandbox.provider", providerName))
 
 	provider, err := p.manager.GetProvider(providerName)
 	if err != nil {
@@ -326,6 +370,7 @@ func (p *ProviderProxy) ExecStream(ctx context.Context, sessionID string, cmd []
 }
 
 // HTTPClient returns an HTTP client using the provider determined by providerGetter.
+// Requests made with the client are traced and carry W3C trace context.
 func (p *ProviderProxy) HTTPClient(ctx context.Context, sessionID string) (*http.Client, error) {
 	providerName, err := p.providerGetter(ctx, sessionID)
 	if err != nil {
@@ -337,7 +382,45 @@ func (p *ProviderProxy) HTTPClient(ctx context.Context, sessionID string) (*http
 		return nil, err
 	}
 
-	return provider.HTTPClient(ctx, sessionID)
+	client, err := provider.HTTPClient(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.WrapClient(client), nil
+}
+
+// Metrics samples resource usage with the provider determined by providerGetter.
+// Implements MetricsProvider.
+func (p *ProviderProxy) Metrics(ctx context.Context, sessionID string) (*ResourceMetrics, error) {
+	providerName, err := p.providerGetter(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider for session: %w", err)
+	}
+
+	provider, err := p.manager.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	mp, ok := provider.(MetricsProvider)
+	if !ok {
+		return nil, ErrMetricsNotSupported
+	}
+	return mp.Metrics(ctx, sessionID)
+}
+
+// SupportsTunnel reports whether the session's provider supports agent
+// tunnels. Implements TunnelSupport.
+func (p *ProviderProxy) SupportsTunnel(ctx context.Context, sessionID string) bool {
+	providerName, err := p.providerGetter(ctx, sessionID)
+	if err != nil {
+		return true
+	}
+	provider, err := p.manager.GetProvider(providerName)
+	if err != nil {
+		return true
+	}
+	return SupportsTunnel(ctx, provider, sessionID)
 }
 
 // Watch watches all providers and merges events.
@@ -385,7 +468,7 @@ func (p *ProviderProxy) Watch(ctx context.Context) (<-chan StateEvent, error) {
 func (p *ProviderProxy) Reconcile(ctx context.Context) error {
 	for name, provider := range p.manager.providers {
 		if err := provider.Reconcile(ctx); err != nil {
-			log.Printf("Warning: Failed to reconcile provider %s: %v", name, err)
+			managerLog.WarnContext(ctx, "failed to reconcile provider", "provider", name, "error", err)
 		}
 	}
 	return nil
@@ -395,8 +478,120 @@ func (p *ProviderProxy) Reconcile(ctx context.Context) error {
 func (p *ProviderProxy) RemoveProject(ctx context.Context, projectID string) error {
 	for name, provider := range p.manager.providers {
 		if err := provider.RemoveProject(ctx, projectID); err != nil {
-			log.Printf("Warning: Failed to remove project resources for provider %s: %v", name, err)
+			managerLog.WarnContext(ctx, "failed to remove project resources", "provider", name, "project_id", projectID, "error", err)
 		}
 	}
 	return nil
 }
+
+// cacheManagers returns the available providers that implement CacheManager.
+func (p *ProviderProxy) cacheManagers() map[string]CacheManager {
+	managers := make(map[string]CacheManager)
+	for name, provider := range p.manager.providers {
+		cm, ok := provider.(CacheManager)
+		if !ok {
+			continue
+		}
+		if status, _ := p.manager.GetProviderStatus(name); !status.Available {
+			continue
+		}
+		managers[name] = cm
+	}
+	return managers
+}
+
+// forEachCacheManager calls fn for every cache-managing provider. Failures
+// are logged and only returned if no provider succeeded.
+func (p *ProviderProxy) forEachCacheManager(ctx context.Context, fn func(CacheManager) error) error {
+	managers := p.cacheManagers()
+	if len(managers) == 0 {
+		return ErrCacheNotSupported
+	}
+	var lastErr error
+	succeeded := false
+	for _, name := range slices.Sorted(maps.Keys(managers)) {
+		if err := fn(managers[name]); err != nil {
+			managerLog.WarnContext(ctx, "cache operation failed", "provider", name, "error", err)
+			lastErr = err
+			continue
+		}
+		succeeded = true
+	}
+	if !succeeded {
+		return lastErr
+	}
+	return nil
+}
+
+// CacheUsage merges the project's cache usage across providers.
+// Implements CacheManager.
+func (p *ProviderProxy) CacheUsage(ctx context.Context, projectID string) (*CacheUsage, error) {
+	merged := &CacheUsage{Entries: []CacheEntryUsage{}}
+	index := make(map[string]int)
+	err := p.forEachCacheManager(ctx, func(cm CacheManager) error {
+		usage, err := cm.CacheUsage(ctx, projectID)
+		if err != nil {
+			return err
+		}
+		merged.TotalBytes += usage.TotalBytes
+		for _, e := range usage.Entries {
+			i, ok := index[e.Name]
+			if !ok {
+				index[e.Name] = len(merged.Entries)
+				merged.Entries = append(merged.Entries, e)
+				continue
+			}
+			m := &merged.Entries[i]
+			m.Bytes += e.Bytes
+			m.Files += e.Files
+			if e.LastUsed != nil && (m.LastUsed == nil || e.LastUsed.After(*m.LastUsed)) {
+				m.LastUsed = e.LastUsed
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(merged.Entries, func(a, b CacheEntryUsage) int { return cmp.Compare(b.Bytes, a.Bytes) })
+	return merged, nil
+}
+
+// PruneCache prunes the project's cache volume in every provider.
+// Implements CacheManager.
+func (p *ProviderProxy) PruneCache(ctx context.Context, projectID string, opts CachePruneOptions) (*CachePruneResult, error) {
+	return p.collectCacheResults(ctx, func(cm CacheManager) (*CachePruneResult, error) {
+		return cm.PruneCache(ctx, projectID, opts)
+	})
+}
+
+// ClearCache clears entries of the project's cache volume in every provider.
+// Implements CacheManager.
+func (p *ProviderProxy) ClearCache(ctx context.Context, projectID string, entries []string) (*CachePruneResult, error) {
+	return p.collectCacheResults(ctx, func(cm CacheManager) (*CachePruneResult, error) {
+		return cm.ClearCache(ctx, projectID, entries)
+	})
+}
+
+// collectCacheResults runs a prune or clear on every provider and combines
+// the results.
+func (p *ProviderProxy) collectCacheResults(ctx context.Context, fn func(CacheManager) (*CachePruneResult, error)) (*CachePruneResult, error) {
+	combined := &CachePruneResult{Cleared: []string{}}
+	err := p.forEachCacheManager(ctx, func(cm CacheManager) error {
+		result, err := fn(cm)
+		if err != nil {
+			return err
+		}
+		for _, name := range result.Cleared {
+			if !slices.Contains(combined.Cleared, name) {
+				combined.Cleared = append(combined.Cleared, name)
+			}
+		}
+		combined.FreedBytes += result.FreedBytes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return combined, nil
+}
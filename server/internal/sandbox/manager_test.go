@@ -0,0 +1,75 @@
+package sandbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/mock"
+	"github.com/obot-platform/discobot/server/internal/tracing"
+)
+
+func TestProviderProxyTracesOperations(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.Install(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	provider := mock.NewProvider()
+	provider.StartFunc = func(context.Context, string) error { return errors.New("boom") }
+	manager := sandbox.NewManager()
+	manager.RegisterProvider("mock", provider)
+	proxy := sandbox.NewProviderProxy(manager, func(context.Context, string) (string, error) {
+		return "mock", nil
+	})
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+	if _, err := proxy.Create(ctx, "sess-1", sandbox.CreateOptions{}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := proxy.Start(ctx, "sess-1"); err == nil {
+		t.Fatal("Start: expected error")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	create, start := spans[0], spans[1]
+	if create.Name != "sandbox.Create" || start.Name != "sandbox.Start" {
+		t.Fatalf("span names = %q, %q", create.Name, start.Name)
+	}
+	if create.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Create span is not a child of the caller's span")
+	}
+	want := []attribute.KeyValue{
+		attribute.String("session.id", "sess-1"),
+		attribute.String("sandbox.provider", "mock"),
+	}
+	for _, kv := range want {
+		if !hasAttribute(create.Attributes, kv) {
+			t.Errorf("Create span missing attribute %s=%s", kv.Key, kv.Value.Emit())
+		}
+	}
+	if create.Status.Code == codes.Error {
+		t.Error("Create span has error status")
+	}
+	if start.Status.Code != codes.Error || start.Status.Description != "boom" {
+		t.Errorf("Start span status = %+v, want error boom", start.Status)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if kv == want {
+			return true
+		}
+	}
+	return false
+}
@@ -103,6 +103,13 @@ type DockerProxyProvider interface {
 	DockerTransport(projectID string) (http.RoundTripper, error)
 }
 
+// TunnelSupport is an optional interface for providers to report whether a
+// sandbox can serve agent tunnels (see Tunnels). Providers that don't
+// implement it are assumed to.
+type TunnelSupport interface {
+	SupportsTunnel(ctx context.Context, sessionID string) bool
+}
+
 // ProviderStatus represents the current status of a sandbox provider.
 type ProviderStatus struct {
 	Available bool   `json:"available"`
@@ -118,6 +125,117 @@ type StatusProvider interface {
 	Status() ProviderStatus
 }
 
+// RuntimeBuildContext is the named build context that ImageBuilder makes
+// available to every build. It holds the discobot runtime from the sandbox
+// image: /opt/discobot, /usr/bin/node, /usr/lib/node_modules and the global
+// npm command links in /usr/bin.
+const RuntimeBuildContext = "discobot-runtime"
+
+// ImageBuildRequest describes an image build for ImageBuilder.
+type ImageBuildRequest struct {
+	// Tag is the reference the built image is stored under.
+	Tag string
+
+	// Context is a tar archive with the Dockerfile at its root and the main
+	// build context under "context/". Every other top-level directory is
+	// passed to the build as a named build context of the same name.
+	Context io.Reader
+
+	// Args are build arguments (--build-arg).
+	Args map[string]string
+}
+
+// ImageBuilder is an optional interface for sandbox providers that can build
+// per-workspace sandbox images (e.g. from a devcontainer.json). Built images
+// are used through CreateOptions.Image.
+type ImageBuilder interface {
+	// HasImage reports whether the image is already available to the provider.
+	HasImage(ctx context.Context, projectID, ref string) bool
+
+	// BuildImage builds the image for the given project and makes it
+	// available to the provider under req.Tag.
+	BuildImage(ctx context.Context, projectID string, req ImageBuildRequest) error
+}
+
+// ResourceMetrics is a resource usage sample of a running sandbox. Disk and
+// network byte counts are cumulative since the sandbox started.
+type ResourceMetrics struct {
+	Timestamp time.Time `json:"timestamp"`
+	// CPUPercent is CPU usage over the sampling window; 100 means one fully
+	// busy core.
+	CPUPercent       float64 `json:"cpuPercent"`
+	MemoryBytes      uint64  `json:"memoryBytes"`
+	MemoryLimitBytes uint64  `json:"memoryLimitBytes,omitempty"`
+	DiskReadBytes    uint64  `json:"diskReadBytes"`
+	DiskWriteBytes   uint64  `json:"diskWriteBytes"`
+	NetworkRxBytes   uint64  `json:"networkRxBytes"`
+	NetworkTxBytes   uint64  `json:"networkTxBytes"`
+	Processes        uint64  `json:"processes"`
+}
+
+// MetricsProvider is an optional interface for providers that can report
+// live resource usage of their sandboxes.
+type MetricsProvider interface {
+	// Metrics samples the sandbox's resource usage. Sampling may take up
+	// to a couple of seconds, since CPU usage is measured over a window.
+	// Returns ErrNotFound or ErrNotRunning if there is nothing to measure.
+	Metrics(ctx context.Context, sessionID string) (*ResourceMetrics, error)
+}
+
+// CacheEntryUsage is the disk usage of one cache directory in a project's
+// cache volume, such as the npm or Go module cache.
+type CacheEntryUsage struct {
+	// Name is the short entry name, e.g. "npm", "go", "apt" or "proxy".
+	// Files outside any known entry are reported as "other".
+	Name string `json:"name"`
+	// Path is the directory's path inside the sandbox.
+	Path     string     `json:"path,omitempty"`
+	Bytes    int64      `json:"bytes"`
+	Files    int64      `json:"files"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// CacheUsage is the disk usage of a project's cache volume.
+type CacheUsage struct {
+	TotalBytes int64             `json:"totalBytes"`
+	Entries    []CacheEntryUsage `json:"entries"`
+}
+
+// CachePruneOptions selects which cache entries PruneCache clears. Entries
+// are cleared whole: first those unused for longer than MaxAge, then the
+// least recently used ones until the volume is at most MaxBytes.
+type CachePruneOptions struct {
+	// Entries limits pruning to these entry names or sandbox paths
+	// (e.g. "go" or "go/pkg/mod"). Empty means all known entries.
+	Entries []string
+	// MaxAge is the longest an entry may go unused (0 = no limit).
+	MaxAge time.Duration
+	// MaxBytes is the volume size to prune down to (0 = no limit).
+	MaxBytes int64
+}
+
+// CachePruneResult reports the entries a prune or clear removed.
+type CachePruneResult struct {
+	Cleared    []string `json:"cleared"`
+	FreedBytes int64    `json:"freedBytes"`
+}
+
+// CacheManager is an optional interface for providers that keep a
+// per-project cache volume. Clearing only empties the cache directories, so
+// running sandboxes keep working with a cold cache.
+type CacheManager interface {
+	// CacheUsage reports per-entry usage of the project's cache volume.
+	// A project without a cache volume has empty usage.
+	CacheUsage(ctx context.Context, projectID string) (*CacheUsage, error)
+
+	// PruneCache clears cache entries according to opts.
+	PruneCache(ctx context.Context, projectID string, opts CachePruneOptions) (*CachePruneResult, error)
+
+	// ClearCache empties the given entries (names or sandbox paths), or
+	// every known entry if none are given.
+	ClearCache(ctx context.Context, projectID string, entries []string) (*CachePruneResult, error)
+}
+
 // RemoveOption configures sandbox removal behavior.
 type RemoveOption func(*RemoveConfig)
 
@@ -179,6 +297,16 @@ const (
 	StatusFailed  Status = "failed"  // Sandbox failed to start or crashed
 )
 
+const (
+	// ShutdownHooksTimeout bounds how long the agent runs shutdown hooks when
+	// a sandbox is stopped. Matches shutdownHooksTimeout in the agent.
+	ShutdownHooksTimeout = 30 * time.Second
+
+	// StopTimeout is how long Stop should wait before force-killing a sandbox:
+	// the shutdown hooks plus the agent's own graceful shutdown.
+	StopTimeout = ShutdownHooksTimeout + 10*time.Second
+)
+
 // StateEvent represents a sandbox state change event.
 // These events are emitted when sandboxes are created, started, stopped, or removed.
 type StateEvent struct {
@@ -224,6 +352,15 @@ type CreateOptions struct {
 
 	// Resources defines resource limits for the sandbox.
 	Resources ResourceConfig
+
+	// Image overrides the provider's sandbox image for this sandbox, e.g. with
+	// an image built through ImageBuilder. Providers that don't run images
+	// ignore it.
+	Image string
+
+	// Env holds additional environment variables for the sandbox. They cannot
+	// override the variables the provider sets itself.
+	Env map[string]string
 }
 
 // ResourceConfig defines resource limits for the sandbox.
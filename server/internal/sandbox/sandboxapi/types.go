@@ -12,7 +12,10 @@
 //	DELETE /chat  - Clear session and messages
 package sandboxapi
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ============================================================================
 // Request Types
@@ -105,6 +108,7 @@ type UIMessage struct {
 	ID        string          `json:"id"`
 	Role      string          `json:"role"` // "user", "assistant", "system"
 	Parts     json.RawMessage `json:"parts"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"` // e.g. {"usage": {...}} on assistant messages
 	CreatedAt string          `json:"createdAt,omitempty"`
 }
 
@@ -339,6 +343,16 @@ type HookRunStatus struct {
 	RunCount            int    `json:"runCount"`
 	FailCount           int    `json:"failCount"`
 	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	// Attempts of the last run; session hooks with retries record several
+	Attempts []HookAttempt `json:"attempts,omitempty"`
+}
+
+// HookAttempt is one attempt of a hook run.
+type HookAttempt struct {
+	StartedAt  string `json:"startedAt"`
+	DurationMs int64  `json:"durationMs"`
+	ExitCode   int    `json:"exitCode"`
+	Result     string `json:"result"` // "success" or "failure"
 }
 
 // HooksStatusResponse is the GET /hooks/status response.
@@ -358,3 +372,50 @@ type HookRerunResponse struct {
 	Success  bool `json:"success"`
 	ExitCode int  `json:"exitCode"`
 }
+
+// ============================================================================
+// Startup Timeline Types
+// ============================================================================
+
+// StartupTimelinePath is where the sandbox agent records its setup steps.
+// It is written by discobot-agent before the HTTP API is up, so the server
+// reads it with an exec rather than an HTTP request.
+const StartupTimelinePath = "/run/discobot/startup.json"
+
+// Startup step and timeline outcomes.
+const (
+	StartupOutcomeRunning = "running"
+	StartupOutcomeSuccess = "success"
+	StartupOutcomeWarning = "warning" // Best-effort step failed; startup continued
+	StartupOutcomeFailure = "failure"
+)
+
+// StartupStep is one setup step of the sandbox startup timeline.
+type StartupStep struct {
+	Name      string     `json:"name"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Outcome   string     `json:"outcome"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// StartupTimeline is the sandbox startup timeline. Outcome leaves "running"
+// once setup finishes; background session hook steps may still be running.
+type StartupTimeline struct {
+	StartedAt   time.Time     `json:"startedAt"`
+	CompletedAt *time.Time    `json:"completedAt,omitempty"`
+	Outcome     string        `json:"outcome"`
+	Error       string        `json:"error,omitempty"`
+	Steps       []StartupStep `json:"steps"`
+}
+
+// CurrentStep returns the most recently started step that is still running,
+// or nil if none is.
+func (t *StartupTimeline) CurrentStep() *StartupStep {
+	for i := len(t.Steps) - 1; i >= 0; i-- {
+		if t.Steps[i].Outcome == StartupOutcomeRunning {
+			return &t.Steps[i]
+		}
+	}
+	return nil
+}
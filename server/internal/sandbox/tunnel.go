@@ -0,0 +1,193 @@
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/obot-platform/discobot/agent/tunnel"
+	"github.com/obot-platform/discobot/server/internal/logging"
+)
+
+// AgentBinary is the path of the discobot-agent binary inside sandboxes.
+const AgentBinary = "/opt/discobot/bin/discobot-agent"
+
+// agentAPIAddr is the agent-api's address as seen from inside a sandbox.
+const agentAPIAddr = "localhost:3002"
+
+var tunnelLog = logging.Component("tunnel")
+
+// Tunnels keeps one multiplexed tunnel per sandbox, served by
+// "discobot-agent tunnel stdio" over an exec stream, and dials connections
+// inside the sandbox over it. It works with any provider that supports
+// ExecStream.
+type Tunnels struct {
+	provider Provider
+
+	mu         sync.Mutex
+	sessions   map[string]*tunnel.Session
+	starting   map[string]*tunnelStart
+	transports map[string]*http.Transport
+}
+
+// tunnelStart is a tunnel being started. Callers that need the same
+// sandbox's tunnel meanwhile wait for done instead of starting another.
+type tunnelStart struct {
+	done chan struct{}
+	sess *tunnel.Session
+	err  error
+}
+
+// NewTunnels returns a Tunnels that starts tunnels through provider.
+func NewTunnels(provider Provider) *Tunnels {
+	return &Tunnels{
+		provider:   provider,
+		sessions:   make(map[string]*tunnel.Session),
+		starting:   make(map[string]*tunnelStart),
+		transports: make(map[string]*http.Transport),
+	}
+}
+
+// Dial connects to target (host:port, resolved inside the sandbox),
+// starting the sandbox's tunnel if needed.
+func (t *Tunnels) Dial(ctx context.Context, sessionID, target string) (net.Conn, error) {
+	sess, err := t.session(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := sess.Open(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("tunnel to %s: %w", target, err)
+	}
+	return stream, nil
+}
+
+// AgentTransport returns a transport whose connections reach the sandbox's
+// agent-api through the tunnel, whatever the request URL's host.
+func (t *Tunnels) AgentTransport(sessionID string) http.RoundTripper {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tr, ok := t.transports[sessionID]; ok {
+		return tr
+	}
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return t.Dial(ctx, sessionID, agentAPIAddr)
+		},
+		MaxIdleConns: 10,
+	}
+	t.transports[sessionID] = tr
+	return tr
+}
+
+// session returns the sandbox's tunnel, starting it on first use or after
+// the previous one ended (e.g. because the sandbox restarted). Starting can
+// take a while for a VM or a sandbox that is still booting, so it runs
+// without t.mu held; only callers for the same sandbox wait for it.
+func (t *Tunnels) session(ctx context.Context, sessionID string) (*tunnel.Session, error) {
+	t.mu.Lock()
+	if sess, ok := t.sessions[sessionID]; ok {
+		select {
+		case <-sess.Done():
+		default:
+			t.mu.Unlock()
+			return sess, nil
+		}
+	}
+	if st, ok := t.starting[sessionID]; ok {
+		t.mu.Unlock()
+		select {
+		case <-st.done:
+			return st.sess, st.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	st := &tunnelStart{done: make(chan struct{})}
+	t.starting[sessionID] = st
+	t.mu.Unlock()
+
+	st.sess, st.err = t.start(sessionID)
+
+	t.mu.Lock()
+	delete(t.starting, sessionID)
+	if st.err == nil {
+		t.sessions[sessionID] = st.sess
+		go t.forget(sessionID, st.sess)
+	}
+	t.mu.Unlock()
+	close(st.done)
+	return st.sess, st.err
+}
+
+// start runs "discobot-agent tunnel stdio" in the sandbox and returns the
+// client end of the tunnel it serves.
+func (t *Tunnels) start(sessionID string) (*tunnel.Session, error) {
+	// The tunnel outlives the request that started it
+	stream, err := t.provider.ExecStream(context.Background(), sessionID,
+		[]string{AgentBinary, "tunnel", "stdio"}, ExecStreamOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tunnel: %w", err)
+	}
+
+	// The tunnel logs per-stream statistics on stderr. Drain it so the
+	// exec stream never blocks on an unread pipe.
+	if stderr := stream.Stderr(); stderr != nil {
+		go func() {
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				tunnelLog.Debug("tunnel", "session_id", sessionID, "output", scanner.Text())
+			}
+		}()
+	}
+
+	return tunnel.Client(stream, nil), nil
+}
+
+// forget drops sess and the idle connections over it once it ends, so
+// removed sandboxes leave nothing behind.
+func (t *Tunnels) forget(sessionID string, sess *tunnel.Session) {
+	<-sess.Done()
+	if err := sess.Err(); err != nil {
+		tunnelLog.Debug("tunnel closed", "session_id", sessionID, "error", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessions[sessionID] != sess {
+		return
+	}
+	delete(t.sessions, sessionID)
+	if tr, ok := t.transports[sessionID]; ok {
+		tr.CloseIdleConnections()
+		delete(t.transports, sessionID)
+	}
+}
+
+// SupportsTunnel reports whether provider's sandbox for sessionID can serve
+// agent tunnels.
+func SupportsTunnel(ctx context.Context, provider Provider, sessionID string) bool {
+	if ts, ok := provider.(TunnelSupport); ok {
+		return ts.SupportsTunnel(ctx, sessionID)
+	}
+	return true
+}
+
+// Close closes all tunnels.
+func (t *Tunnels) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, sess := range t.sessions {
+		_ = sess.Close()
+		delete(t.sessions, id)
+	}
+	for id, tr := range t.transports {
+		tr.CloseIdleConnections()
+		delete(t.transports, id)
+	}
+}
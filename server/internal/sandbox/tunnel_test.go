@@ -0,0 +1,116 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/agent/tunnel"
+)
+
+// pipeStream is an exec stream backed by one end of a net.Pipe.
+type pipeStream struct{ net.Conn }
+
+func (s pipeStream) Stderr() io.Reader                      { return nil }
+func (s pipeStream) Resize(context.Context, int, int) error { return nil }
+func (s pipeStream) CloseWrite() error                      { return nil }
+func (s pipeStream) Wait(ctx context.Context) (int, error)  { <-ctx.Done(); return 0, ctx.Err() }
+
+// tunnelProvider serves tunnels in-process. Starting the tunnel of a session
+// listed in slow blocks until release is closed.
+type tunnelProvider struct {
+	Provider
+	slow    map[string]bool
+	release chan struct{}
+	execs   atomic.Int32
+}
+
+func (p *tunnelProvider) ExecStream(_ context.Context, sessionID string, _ []string, _ ExecStreamOptions) (Stream, error) {
+	p.execs.Add(1)
+	if p.slow[sessionID] {
+		<-p.release
+	}
+	client, server := net.Pipe()
+	tunnel.Server(server, nil)
+	return pipeStream{client}, nil
+}
+
+func TestTunnels_SlowStartDoesNotBlockOtherSandboxes(t *testing.T) {
+	provider := &tunnelProvider{slow: map[string]bool{"slow": true}, release: make(chan struct{})}
+	tunnels := NewTunnels(provider)
+	defer close(provider.release)
+
+	go func() { _, _ = tunnels.session(context.Background(), "slow") }()
+	for provider.execs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := tunnels.session(context.Background(), "fast")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("session failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Starting one sandbox's tunnel blocked another sandbox")
+	}
+
+	// Lookups for other sandboxes do not wait either
+	tunnels.AgentTransport("fast")
+}
+
+func TestTunnels_ConcurrentCallersShareOneStart(t *testing.T) {
+	provider := &tunnelProvider{slow: map[string]bool{"slow": true}, release: make(chan struct{})}
+	tunnels := NewTunnels(provider)
+
+	var wg sync.WaitGroup
+	sessions := make([]*tunnel.Session, 5)
+	for i := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess, err := tunnels.session(context.Background(), "slow")
+			if err != nil {
+				t.Errorf("session failed: %v", err)
+			}
+			sessions[i] = sess
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	if n := provider.execs.Load(); n != 1 {
+		t.Errorf("started %d tunnels, want 1", n)
+	}
+	for _, sess := range sessions[1:] {
+		if sess != sessions[0] {
+			t.Error("callers got different tunnels")
+		}
+	}
+}
+
+func TestTunnels_WaitHonoursContext(t *testing.T) {
+	provider := &tunnelProvider{slow: map[string]bool{"slow": true}, release: make(chan struct{})}
+	tunnels := NewTunnels(provider)
+	defer close(provider.release)
+
+	go func() { _, _ = tunnels.session(context.Background(), "slow") }()
+	for provider.execs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tunnels.session(ctx, "slow"); err != context.DeadlineExceeded {
+		t.Errorf("session error = %v, want deadline exceeded", err)
+	}
+}
@@ -19,8 +19,8 @@ type ProjectVM interface {
 	// The dialer is used to create a Docker client with custom transport.
 	DockerDialer() func(ctx context.Context, network, addr string) (net.Conn, error)
 
-	// PortDialer returns a dialer function for connecting to an arbitrary VSOCK port.
-	// This is used to reach forwarded ports (e.g., container published ports) inside the VM.
+	// PortDialer returns a dialer function for connecting to a port published
+	// inside the VM (e.g., a container's published agent port).
 	PortDialer(port uint32) func(ctx context.Context, network, addr string) (net.Conn, error)
 
 	// Shutdown gracefully stops the VM.
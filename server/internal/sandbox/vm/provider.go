@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,10 +12,14 @@ import (
 	dockerclient "github.com/docker/docker/client"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/sandbox/docker"
 )
 
+// vmLog is the logger for VM provider messages.
+var vmLog = logging.Component("vm")
+
 // SessionProjectResolver looks up the project ID for a session from the database.
 // Returns the project ID or an error if the session doesn't exist.
 type SessionProjectResolver func(ctx context.Context, sessionID string) (projectID string, err error)
@@ -120,7 +123,7 @@ func NewProvider(cfg *config.Config, vmManager ProjectVMManager, resolver Sessio
 			return
 		}
 		if _, err := p.getOrCreateDockerProvider(context.Background(), "local"); err != nil {
-			log.Printf("failed to warm VM for local project: %v", err)
+			vmLog.Warn("failed to warm VM for local project", "error", err)
 		}
 
 		// Start idle VM cleanup after ready
@@ -235,7 +238,7 @@ func (p *Provider) List(ctx context.Context) ([]*sandbox.Sandbox, error) {
 	for _, dockerProv := range providers {
 		sandboxes, err := dockerProv.List(ctx)
 		if err != nil {
-			log.Printf("Warning: Failed to list sandboxes from a VM Docker provider: %v", err)
+			vmLog.WarnContext(ctx, "failed to list sandboxes from a VM Docker provider", "error", err)
 			continue
 		}
 		allSandboxes = append(allSandboxes, sandboxes...)
@@ -350,7 +353,7 @@ func (p *Provider) Watch(ctx context.Context) (<-chan sandbox.StateEvent, error)
 
 // Close shuts down the provider and all project VMs.
 func (p *Provider) Close() error {
-	log.Printf("Shutting down VM+Docker provider")
+	vmLog.Info("shutting down VM+Docker provider")
 
 	close(p.stopCh)
 	p.vmManager.Shutdown()
@@ -381,7 +384,7 @@ func (p *Provider) Reconcile(ctx context.Context) error {
 
 	for _, dockerProv := range providers {
 		if err := dockerProv.Reconcile(ctx); err != nil {
-			log.Printf("Warning: Failed to reconcile VM Docker provider: %v", err)
+			vmLog.WarnContext(ctx, "failed to reconcile VM Docker provider", "error", err)
 		}
 	}
 	return nil
@@ -457,6 +460,89 @@ func (p *Provider) DockerTransport(projectID string) (http.RoundTripper, error)
 	}, nil
 }
 
+// HasImage reports whether the image exists in the project VM's Docker daemon.
+// Implements sandbox.ImageBuilder.
+func (p *Provider) HasImage(ctx context.Context, projectID, ref string) bool {
+	dockerProv, err := p.getOrCreateDockerProvider(ctx, projectID)
+	if err != nil {
+		return false
+	}
+	return dockerProv.HasImage(ctx, projectID, ref)
+}
+
+// BuildImage builds an image with the project VM's BuildKit container.
+// Implements sandbox.ImageBuilder.
+func (p *Provider) BuildImage(ctx context.Context, projectID string, req sandbox.ImageBuildRequest) error {
+	dockerProv, err := p.getOrCreateDockerProvider(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to get docker provider: %w", err)
+	}
+	return dockerProv.BuildImage(ctx, projectID, req)
+}
+
+// Metrics samples the container's resource usage inside the project VM.
+// Implements sandbox.MetricsProvider.
+func (p *Provider) Metrics(ctx context.Context, sessionID string) (*sandbox.ResourceMetrics, error) {
+	_, dockerProv, err := p.getDockerProviderForSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return dockerProv.Metrics(ctx, sessionID)
+}
+
+// CacheUsage reports usage of the cache volume in the project VM, or an
+// empty cache if the VM is not running. Implements sandbox.CacheManager.
+func (p *Provider) CacheUsage(ctx context.Context, projectID string) (*sandbox.CacheUsage, error) {
+	dockerProv, err := p.cacheDockerProvider(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if dockerProv == nil {
+		return &sandbox.CacheUsage{Entries: []sandbox.CacheEntryUsage{}}, nil
+	}
+	return dockerProv.CacheUsage(ctx, projectID)
+}
+
+// PruneCache prunes the cache volume in the project VM. Nothing is pruned
+// if the VM is not running. Implements sandbox.CacheManager.
+func (p *Provider) PruneCache(ctx context.Context, projectID string, opts sandbox.CachePruneOptions) (*sandbox.CachePruneResult, error) {
+	dockerProv, err := p.cacheDockerProvider(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if dockerProv == nil {
+		return &sandbox.CachePruneResult{Cleared: []string{}}, nil
+	}
+	return dockerProv.PruneCache(ctx, projectID, opts)
+}
+
+// ClearCache clears entries of the cache volume in the project VM. Nothing
+// is cleared if the VM is not running. Implements sandbox.CacheManager.
+func (p *Provider) ClearCache(ctx context.Context, projectID string, entries []string) (*sandbox.CachePruneResult, error) {
+	dockerProv, err := p.cacheDockerProvider(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if dockerProv == nil {
+		return &sandbox.CachePruneResult{Cleared: []string{}}, nil
+	}
+	return dockerProv.ClearCache(ctx, projectID, entries)
+}
+
+// cacheDockerProvider returns the Docker provider of the project's running
+// VM, or nil if the project has no VM. Cache requests never boot a VM just
+// to look at its cache volume.
+func (p *Provider) cacheDockerProvider(ctx context.Context, projectID string) (*docker.Provider, error) {
+	if _, ok := p.GetVMForProject(projectID); !ok {
+		return nil, nil
+	}
+	dockerProv, err := p.getOrCreateDockerProvider(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker provider: %w", err)
+	}
+	return dockerProv, nil
+}
+
 // IsReady returns true if the provider is ready to create VMs.
 func (p *Provider) IsReady() bool {
 	select {
@@ -517,10 +603,10 @@ func (p *Provider) ensureImageInVM(ctx context.Context, dockerProv *docker.Provi
 	vmClient := dockerProv.Client()
 	inspect, err := vmClient.ImageInspect(ctx, image)
 	if err == nil {
-		log.Printf("Image %s already exists in VM Docker (ID: %s)", image[:19], inspect.ID[:19])
+		vmLog.InfoContext(ctx, "image already exists in VM Docker", "image", image[:19], "image_id", inspect.ID[:19])
 		return nil
 	}
-	log.Printf("Image %s not found in VM Docker, will load from host: %v", image[:19], err)
+	vmLog.InfoContext(ctx, "image not found in VM Docker, will load from host", "image", image[:19], "error", err)
 
 	// Get host Docker client
 	hostClient, err := p.getHostDockerClient()
@@ -539,7 +625,7 @@ func (p *Provider) ensureImageInVM(ctx context.Context, dockerProv *docker.Provi
 		return fmt.Errorf("failed to inspect image on host: %w", err)
 	}
 	imageSize := inspectResult.Size
-	log.Printf("Loading image %s (%d MB) from host Docker into VM Docker...", image[:19], imageSize/(1024*1024))
+	vmLog.InfoContext(ctx, "loading image from host Docker into VM Docker", "image", image[:19], "size_mb", imageSize/(1024*1024))
 
 	// Register system manager task for UI progress
 	if p.systemManager != nil {
@@ -580,7 +666,7 @@ func (p *Provider) ensureImageInVM(ctx context.Context, dockerProv *docker.Provi
 		p.systemManager.CompleteTask("docker-load")
 	}
 
-	log.Printf("Successfully loaded image %s into VM Docker", image[:19])
+	vmLog.InfoContext(ctx, "loaded image into VM Docker", "image", image[:19])
 	return nil
 }
 
@@ -619,7 +705,7 @@ func (p *Provider) getOrCreateDockerProvider(ctx context.Context, projectID stri
 		return prov, nil
 	}
 
-	log.Printf("Creating Docker provider for project VM: %s", projectID)
+	vmLog.InfoContext(ctx, "creating Docker provider for project VM", "project_id", projectID)
 
 	// Create Docker provider with VM transport.
 	// The provider kicks off image pull in the background on creation.
@@ -651,7 +737,7 @@ func (p *Provider) getOrCreateDockerProvider(ctx context.Context, projectID stri
 	}
 
 	p.dockerProviders[projectID] = dockerProv
-	log.Printf("Docker provider created for project %s", projectID)
+	vmLog.InfoContext(ctx, "Docker provider created for project VM", "project_id", projectID)
 	return dockerProv, nil
 }
 
@@ -730,10 +816,10 @@ func (p *Provider) cleanupIdleVMs() {
 					continue
 				}
 
-				log.Printf("Shutting down idle project VM: %s (idle for %v)", projectID, time.Since(idleStart))
+				vmLog.Info("shutting down idle project VM", "project_id", projectID, "idle_for", time.Since(idleStart))
 
 				if err := p.vmManager.RemoveVM(projectID); err != nil {
-					log.Printf("Error removing idle VM %s: %v", projectID, err)
+					vmLog.Error("failed to remove idle VM", "project_id", projectID, "error", err)
 					continue
 				}
 
@@ -766,7 +852,7 @@ func (r *progressReader) Read(p []byte) (int, error) {
 
 	if r.read-r.lastLog >= r.logEvery {
 		pct := float64(r.read) / float64(r.total) * 100
-		log.Printf("Image transfer %s: %.1f%% (%d/%d MB)", r.label, pct, r.read/(1024*1024), r.total/(1024*1024))
+		vmLog.Info("image transfer progress", "image", r.label, "percent", fmt.Sprintf("%.1f", pct), "read_mb", r.read/(1024*1024), "total_mb", r.total/(1024*1024))
 		r.lastLog = r.read
 
 		if r.systemMgr != nil {
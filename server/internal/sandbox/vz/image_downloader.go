@@ -10,7 +10,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -24,8 +23,13 @@ import (
 	"github.com/klauspost/compress/zstd"
 	"github.com/ulikunitz/xz"
 	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
 )
 
+// vzLog is the logger for the VZ sandbox provider.
+var vzLog = logging.Component("vz")
+
 // DownloadState represents the current state of the image download process.
 type DownloadState int
 
@@ -108,7 +112,7 @@ func (d *ImageDownloader) Start(ctx context.Context) error {
 
 	// Check if already cached
 	if cached, kernelPath, baseDiskPath := d.checkCache(); cached {
-		log.Printf("VZ images already cached: kernel=%s, disk=%s", kernelPath, baseDiskPath)
+		vzLog.InfoContext(ctx, "VZ images already cached", "kernel", kernelPath, "disk", baseDiskPath)
 		d.kernelPath = kernelPath
 		d.baseDiskPath = baseDiskPath
 		d.updateState(DownloadStateReady)
@@ -211,7 +215,7 @@ func (d *ImageDownloader) checkCache() (cached bool, kernelPath, baseDiskPath st
 	diskInfo, diskErr := os.Stat(baseDiskPath)
 
 	if kernelErr == nil && diskErr == nil && kernelInfo.Size() > 0 && diskInfo.Size() > 0 {
-		log.Printf("Found cached VZ images in %s", cacheDir)
+		vzLog.Info("found cached VZ images", "dir", cacheDir)
 		return true, kernelPath, baseDiskPath
 	}
 
@@ -227,7 +231,7 @@ func (d *ImageDownloader) computeDigest() string {
 
 // download pulls the image from the registry and extracts the kernel and disk files.
 func (d *ImageDownloader) download(ctx context.Context) error {
-	log.Printf("Downloading VZ images from %s", d.cfg.ImageRef)
+	vzLog.InfoContext(ctx, "downloading VZ images", "image", d.cfg.ImageRef)
 
 	// Parse image reference
 	ref, err := name.ParseReference(d.cfg.ImageRef)
@@ -241,7 +245,7 @@ func (d *ImageDownloader) download(ctx context.Context) error {
 		OS:           "linux",
 		Architecture: runtime.GOARCH,
 	}
-	log.Printf("Pulling image for platform %s/%s", platform.OS, platform.Architecture)
+	vzLog.InfoContext(ctx, "pulling image", "platform", platform.OS+"/"+platform.Architecture)
 
 	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithPlatform(platform))
 	if err != nil {
@@ -303,7 +307,7 @@ func (d *ImageDownloader) download(ctx context.Context) error {
 			p.CurrentLayer = layerDigest.String()
 		})
 
-		log.Printf("Extracting layer %d/%d: %s", i+1, len(layers), layerDigest)
+		vzLog.InfoContext(ctx, "extracting layer", "layer", i+1, "layers", len(layers), "digest", layerDigest)
 
 		// Get layer reader
 		rc, err := layer.Compressed()
@@ -377,7 +381,7 @@ func (d *ImageDownloader) download(ctx context.Context) error {
 	d.kernelPath = filepath.Join(cacheDir, "vmlinuz")
 	d.baseDiskPath = filepath.Join(cacheDir, "discobot-rootfs.squashfs")
 
-	log.Printf("VZ images extracted successfully: kernel=%s, disk=%s", d.kernelPath, d.baseDiskPath)
+	vzLog.InfoContext(ctx, "VZ images extracted", "kernel", d.kernelPath, "disk", d.baseDiskPath)
 	return nil
 }
 
@@ -401,14 +405,14 @@ func (d *ImageDownloader) extractFiles(r io.Reader, destDir string, kernelFound,
 				return fmt.Errorf("failed to write kernel: %w", err)
 			}
 			*kernelFound = true
-			log.Printf("Extracted kernel: %s (%d bytes)", header.Name, header.Size)
+			vzLog.Info("extracted kernel", "name", header.Name, "bytes", header.Size)
 		} else if header.Name == "discobot-rootfs.squashfs" || strings.HasSuffix(header.Name, "/discobot-rootfs.squashfs") {
 			destPath := filepath.Join(destDir, "discobot-rootfs.squashfs")
 			if err := d.writeFile(tr, destPath, header.Mode); err != nil {
 				return fmt.Errorf("failed to write disk: %w", err)
 			}
 			*diskFound = true
-			log.Printf("Extracted disk: %s (%d bytes)", header.Name, header.Size)
+			vzLog.Info("extracted disk", "name", header.Name, "bytes", header.Size)
 		}
 	}
 
@@ -514,7 +518,7 @@ func (d *ImageDownloader) decompressKernel(path string) error {
 
 	// Case 1: already uncompressed kernel
 	if isKernelImage(data) {
-		log.Printf("Kernel is already uncompressed")
+		vzLog.Info("kernel is already uncompressed")
 		return nil
 	}
 
@@ -523,17 +527,17 @@ func (d *ImageDownloader) decompressKernel(path string) error {
 		if len(data) < len(cf.magic) || !bytes.Equal(data[:len(cf.magic)], cf.magic) {
 			continue
 		}
-		log.Printf("vmlinuz is directly %s compressed, decompressing", cf.name)
+		vzLog.Info("vmlinuz is directly compressed, decompressing", "format", cf.name)
 		decompressed, err := cf.decompress(data)
 		if err != nil {
-			log.Printf("Direct %s decompression failed: %v", cf.name, err)
+			vzLog.Warn("direct decompression failed", "format", cf.name, "error", err)
 			break // fall through to other methods
 		}
 		if isKernelImage(decompressed) {
-			log.Printf("Successfully decompressed %s kernel (%d bytes)", cf.name, len(decompressed))
+			vzLog.Info("decompressed kernel", "format", cf.name, "bytes", len(decompressed))
 			return os.WriteFile(path, decompressed, 0644)
 		}
-		log.Printf("Direct %s decompression produced non-kernel data, continuing", cf.name)
+		vzLog.Info("direct decompression produced non-kernel data, continuing", "format", cf.name)
 		break
 	}
 
@@ -550,19 +554,19 @@ func (d *ImageDownloader) decompressKernel(path string) error {
 		absOffset := protectedModeStart + payloadOffset
 		absEnd := absOffset + payloadLength
 
-		log.Printf("Linux boot protocol: setup_sects=%d, protected_mode_start=%d, payload_offset=%d, payload_length=%d, abs_offset=%d",
-			setupSects, protectedModeStart, payloadOffset, payloadLength, absOffset)
+		vzLog.Info("linux boot protocol header", "setup_sects", setupSects, "protected_mode_start", protectedModeStart,
+			"payload_offset", payloadOffset, "payload_length", payloadLength, "abs_offset", absOffset)
 
 		if absOffset > 0 && absEnd <= len(data) && payloadLength > 0 {
 			payload := data[absOffset:absEnd]
 			result, err := d.tryDecompress(payload)
 			if err == nil {
-				log.Printf("Successfully extracted kernel (%d bytes) via boot protocol header", len(result))
+				vzLog.Info("extracted kernel via boot protocol header", "bytes", len(result))
 				return os.WriteFile(path, result, 0644)
 			}
-			log.Printf("Boot protocol payload decompression failed: %v, falling back to magic scan", err)
+			vzLog.Warn("boot protocol payload decompression failed, falling back to magic scan", "error", err)
 		} else {
-			log.Printf("Boot protocol header has invalid offsets, falling back to magic scan")
+			vzLog.Warn("boot protocol header has invalid offsets, falling back to magic scan")
 		}
 	}
 
@@ -578,20 +582,20 @@ func (d *ImageDownloader) decompressKernel(path string) error {
 			offset := searchFrom + idx
 			searchFrom = offset + 1
 
-			log.Printf("Found %s signature at offset %d, attempting decompression", cf.name, offset)
+			vzLog.Info("found compression signature, attempting decompression", "format", cf.name, "offset", offset)
 
 			decompressed, err := cf.decompress(data[offset:])
 			if err != nil {
-				log.Printf("Failed to decompress %s at offset %d: %v", cf.name, offset, err)
+				vzLog.Warn("failed to decompress", "format", cf.name, "offset", offset, "error", err)
 				continue
 			}
 
 			if !isKernelImage(decompressed) {
-				log.Printf("Decompressed %s at offset %d did not produce valid kernel, skipping", cf.name, offset)
+				vzLog.Info("decompressed data is not a valid kernel, skipping", "format", cf.name, "offset", offset)
 				continue
 			}
 
-			log.Printf("Successfully extracted kernel (%d bytes) from %s at offset %d", len(decompressed), cf.name, offset)
+			vzLog.Info("extracted kernel", "bytes", len(decompressed), "format", cf.name, "offset", offset)
 			return os.WriteFile(path, decompressed, 0644)
 		}
 	}
@@ -606,7 +610,7 @@ func (d *ImageDownloader) tryDecompress(data []byte) ([]byte, error) {
 			continue
 		}
 
-		log.Printf("Payload matches %s format", cf.name)
+		vzLog.Info("payload matches compression format", "format", cf.name)
 		decompressed, err := cf.decompress(data)
 		if err != nil {
 			return nil, fmt.Errorf("%s decompress: %w", cf.name, err)
@@ -624,7 +628,7 @@ func (d *ImageDownloader) tryDecompress(data []byte) ([]byte, error) {
 		if idx < 0 || idx > 1024 {
 			continue
 		}
-		log.Printf("Found %s signature at payload offset %d", cf.name, idx)
+		vzLog.Info("found compression signature in payload", "format", cf.name, "offset", idx)
 		decompressed, err := cf.decompress(data[idx:])
 		if err != nil {
 			continue
@@ -5,7 +5,6 @@ package vz
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	containerTypes "github.com/docker/docker/api/types/container"
@@ -47,8 +46,8 @@ func NewProvider(cfg *config.Config, vmConfig *vm.Config, resolver vm.SessionPro
 }
 
 // startProxyContainer creates and starts the VSOCK port proxy container inside the VM.
-// The proxy watches Docker events for containers with published ports and creates
-// socat VSOCK listeners to forward those ports to the host.
+// The proxy serves a multiplexed tunnel on tunnel.VsockPort and watches Docker
+// events so it only forwards to ports published by managed containers.
 func startProxyContainer(ctx context.Context, projectID string, dockerProv *docker.Provider, sandboxImage string) error {
 	cli := dockerProv.Client()
 	suffix := projectID
@@ -65,11 +64,11 @@ func startProxyContainer(ctx context.Context, projectID string, dockerProv *dock
 			!existing.HostConfig.Privileged
 
 		if existing.State.Running && !needsRecreate {
-			log.Printf("Proxy container %s already running for project %s", name, projectID)
+			vzLog.InfoContext(ctx, "proxy container already running", "container", name, "project_id", projectID)
 			return nil
 		}
 		if needsRecreate {
-			log.Printf("Proxy container %s has stale config, recreating", name)
+			vzLog.InfoContext(ctx, "proxy container has stale config, recreating", "container", name)
 		}
 		_ = cli.ContainerRemove(ctx, existing.ID, containerTypes.RemoveOptions{Force: true})
 	}
@@ -107,6 +106,6 @@ func startProxyContainer(ctx context.Context, projectID string, dockerProv *dock
 		return fmt.Errorf("failed to start proxy container: %w", err)
 	}
 
-	log.Printf("Started proxy container %s (%s) for project %s", name, resp.ID[:12], projectID)
+	vzLog.InfoContext(ctx, "started proxy container", "container", name, "container_id", resp.ID[:12], "project_id", projectID)
 	return nil
 }
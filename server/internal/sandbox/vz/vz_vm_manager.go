@@ -5,12 +5,12 @@ package vz
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -19,6 +19,7 @@ import (
 
 	"github.com/Code-Hex/vz/v3"
 
+	"github.com/obot-platform/discobot/agent/tunnel"
 	"github.com/obot-platform/discobot/server/internal/config"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/sandbox/vm"
@@ -53,7 +54,7 @@ func getDefaultMemoryBytes() uint64 {
 
 	if errno != 0 {
 		// Fallback to 8GB if we can't get system memory
-		log.Printf("Failed to get system memory, using 8GB default: %v", errno)
+		vzLog.Warn("failed to get system memory, using 8GB default", "error", errno)
 		return 8 * 1024 * 1024 * 1024
 	}
 
@@ -64,9 +65,9 @@ func getDefaultMemoryBytes() uint64 {
 	oneGB := uint64(1024 * 1024 * 1024)
 	roundedMemory := (halfMemory / oneGB) * oneGB
 
-	log.Printf("System memory: %d GB, default VM memory: %d GB",
-		memSize/(1024*1024*1024),
-		roundedMemory/(1024*1024*1024))
+	vzLog.Info("system memory detected",
+		"system_gb", memSize/(1024*1024*1024),
+		"vm_memory_gb", roundedMemory/(1024*1024*1024))
 
 	return roundedMemory
 }
@@ -79,6 +80,11 @@ type vzProjectVM struct {
 	dataDiskPath string   // Data disk (writable)
 	consoleLog   *os.File // Console log file
 	mu           sync.RWMutex
+
+	// tunnel multiplexes PortDialer connections over one VSOCK connection
+	// to the port proxy. Guarded by tunnelMu.
+	tunnel   *tunnel.Session
+	tunnelMu sync.Mutex
 }
 
 // ProjectID returns the project ID this VM serves.
@@ -110,32 +116,80 @@ func (pvm *vzProjectVM) DockerDialer() func(ctx context.Context, network, addr s
 	}
 }
 
-// PortDialer returns a VSOCK dialer function for an arbitrary port.
+// PortDialer returns a dialer function for a port published inside the VM.
+// Connections are opened as streams on the multiplexed tunnel served by the
+// in-VM port proxy, so no per-connection process runs in the VM.
 func (pvm *vzProjectVM) PortDialer(port uint32) func(ctx context.Context, network, addr string) (net.Conn, error) {
-	return func(_ context.Context, _, _ string) (net.Conn, error) {
-		pvm.mu.RLock()
-		socketDevice := pvm.socketDevice
-		pvm.mu.RUnlock()
-
-		if socketDevice == nil {
-			return nil, fmt.Errorf("vsock not available for project VM %s", pvm.projectID)
+	target := net.JoinHostPort("localhost", strconv.FormatUint(uint64(port), 10))
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		sess, err := pvm.tunnelSession()
+		if err != nil {
+			return nil, err
 		}
-
-		conn, err := socketDevice.Connect(port)
+		stream, err := sess.Open(ctx, target)
 		if err != nil {
-			return nil, fmt.Errorf("vsock connect port %d: %w", port, err)
+			return nil, fmt.Errorf("tunnel to port %d: %w", port, err)
 		}
+		return stream, nil
+	}
+}
 
-		return &vsockConn{
-			VirtioSocketConnection: conn,
-			localAddr:              &vsockAddr{cid: 2, port: 0},
-			remoteAddr:             &vsockAddr{cid: 3, port: port},
-		}, nil
+// tunnelSession returns the tunnel session to the in-VM port proxy,
+// connecting (or reconnecting after the proxy restarted) as needed.
+func (pvm *vzProjectVM) tunnelSession() (*tunnel.Session, error) {
+	pvm.tunnelMu.Lock()
+	defer pvm.tunnelMu.Unlock()
+
+	if pvm.tunnel != nil {
+		select {
+		case <-pvm.tunnel.Done():
+			if err := pvm.tunnel.Err(); err != nil {
+				vzLog.Warn("tunnel to project VM failed, reconnecting", "project_id", pvm.projectID, "error", err)
+			}
+			pvm.tunnel = nil
+		default:
+			return pvm.tunnel, nil
+		}
+	}
+
+	pvm.mu.RLock()
+	socketDevice := pvm.socketDevice
+	pvm.mu.RUnlock()
+
+	if socketDevice == nil {
+		return nil, fmt.Errorf("vsock not available for project VM %s", pvm.projectID)
 	}
+
+	conn, err := socketDevice.Connect(tunnel.VsockPort)
+	if err != nil {
+		return nil, fmt.Errorf("vsock connect port %d: %w", tunnel.VsockPort, err)
+	}
+
+	projectID := pvm.projectID
+	pvm.tunnel = tunnel.Client(&vsockConn{
+		VirtioSocketConnection: conn,
+		localAddr:              &vsockAddr{cid: 2, port: 0},
+		remoteAddr:             &vsockAddr{cid: 3, port: tunnel.VsockPort},
+	}, &tunnel.Config{
+		OnStreamClose: func(s tunnel.StreamStats) {
+			if s.Err != nil {
+				vzLog.Warn("tunnel stream failed", "target", s.Target, "project_id", projectID,
+					"duration", s.Duration.Round(time.Millisecond), "error", s.Err)
+			}
+		},
+	})
+	return pvm.tunnel, nil
 }
 
 // Shutdown gracefully stops the VM.
 func (pvm *vzProjectVM) Shutdown() error {
+	pvm.tunnelMu.Lock()
+	if pvm.tunnel != nil {
+		_ = pvm.tunnel.Close()
+		pvm.tunnel = nil
+	}
+	pvm.tunnelMu.Unlock()
+
 	pvm.mu.Lock()
 	defer pvm.mu.Unlock()
 
@@ -277,7 +331,7 @@ func NewVMManager(cfg vm.Config, systemManager vm.SystemManager) (*VMManager, er
 			imageRef = config.DefaultVZImage()
 		}
 
-		log.Printf("VZ kernel or base disk not configured, will download from %s", imageRef)
+		vzLog.Info("VZ kernel or base disk not configured, downloading", "image", imageRef)
 
 		downloader := NewImageDownloader(DownloadConfig{
 			ImageRef: imageRef,
@@ -293,11 +347,11 @@ func NewVMManager(cfg vm.Config, systemManager vm.SystemManager) (*VMManager, er
 
 		go mgr.downloadAndInit(imageRef)
 
-		log.Printf("VZ VM manager created, images downloading in background")
+		vzLog.Info("VZ VM manager created, images downloading in background")
 	} else {
 		// Ready immediately
 		close(mgr.ready)
-		log.Printf("VZ VM manager initialized with manual configuration")
+		vzLog.Info("VZ VM manager initialized with manual configuration")
 	}
 
 	return mgr, nil
@@ -345,14 +399,14 @@ func (m *VMManager) downloadAndInit(imageRef string) {
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// Attempt download
 		if err := downloader.Start(ctx); err != nil {
-			log.Printf("VZ image download failed (attempt %d/%d): %v", attempt, maxRetries, err)
+			vzLog.WarnContext(ctx, "VZ image download failed", "attempt", attempt, "max_attempts", maxRetries, "error", err)
 
 			if attempt < maxRetries {
 				delay := baseDelay * time.Duration(1<<uint(attempt-1))
 				if delay > 5*time.Minute {
 					delay = 5 * time.Minute
 				}
-				log.Printf("Retrying in %v...", delay)
+				vzLog.InfoContext(ctx, "retrying VZ image download", "delay", delay)
 				time.Sleep(delay)
 
 				// Reset downloader for retry
@@ -367,7 +421,7 @@ func (m *VMManager) downloadAndInit(imageRef string) {
 			// Max retries exceeded
 			m.initErr = fmt.Errorf("download failed after %d attempts: %w", maxRetries, err)
 			downloader.RecordError(m.initErr)
-			log.Printf("VZ image download failed permanently after %d attempts", maxRetries)
+			vzLog.ErrorContext(ctx, "VZ image download failed permanently", "attempts", maxRetries)
 			close(m.ready)
 			return
 		}
@@ -376,14 +430,14 @@ func (m *VMManager) downloadAndInit(imageRef string) {
 		kernelPath, baseDiskPath, ok := downloader.GetPaths()
 		if !ok {
 			err := fmt.Errorf("failed to get VZ image paths after download")
-			log.Printf("%v", err)
+			vzLog.WarnContext(ctx, "VZ image paths unavailable after download", "error", err)
 
 			if attempt < maxRetries {
 				delay := baseDelay * time.Duration(1<<uint(attempt-1))
 				if delay > 5*time.Minute {
 					delay = 5 * time.Minute
 				}
-				log.Printf("Retrying in %v...", delay)
+				vzLog.InfoContext(ctx, "retrying VZ image download", "delay", delay)
 				time.Sleep(delay)
 
 				downloader = NewImageDownloader(DownloadConfig{
@@ -404,7 +458,7 @@ func (m *VMManager) downloadAndInit(imageRef string) {
 		m.config.KernelPath = kernelPath
 		m.config.BaseDiskPath = baseDiskPath
 
-		log.Printf("VZ VM manager initialized after image download")
+		vzLog.InfoContext(ctx, "VZ VM manager initialized after image download")
 
 		close(m.ready)
 		return
@@ -422,14 +476,14 @@ func (m *VMManager) GetOrCreateVM(ctx context.Context, projectID string) (vm.Pro
 	}
 
 	// Create new VM for project
-	log.Printf("Creating new project VM for project: %s", projectID)
+	vzLog.InfoContext(ctx, "creating project VM", "project_id", projectID)
 	pvm, err := m.createProjectVM(ctx, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create project VM: %w", err)
 	}
 
 	m.projectVMs[projectID] = pvm
-	log.Printf("Project VM %s created successfully", projectID)
+	vzLog.InfoContext(ctx, "project VM created", "project_id", projectID)
 	return pvm, nil
 }
 
@@ -482,9 +536,9 @@ func (m *VMManager) Shutdown() {
 	// Stop all VMs
 	m.projectVMMu.Lock()
 	for projectID, pvm := range m.projectVMs {
-		log.Printf("Shutting down project VM: %s", projectID)
+		vzLog.Info("shutting down project VM", "project_id", projectID)
 		if err := pvm.Shutdown(); err != nil {
-			log.Printf("Error stopping project VM %s: %v", projectID, err)
+			vzLog.Error("failed to stop project VM", "project_id", projectID, "error", err)
 		}
 	}
 	m.projectVMs = make(map[string]*vzProjectVM)
@@ -514,7 +568,7 @@ func (m *VMManager) createProjectVM(ctx context.Context, projectID string) (*vzP
 		if err := vz.CreateDiskImage(dataDiskPath, dataDiskSize); err != nil {
 			return nil, fmt.Errorf("failed to create data disk: %w", err)
 		}
-		log.Printf("Created data disk: %s", dataDiskPath)
+		vzLog.InfoContext(ctx, "created data disk", "path", dataDiskPath)
 	}
 
 	// Create console log file
@@ -528,7 +582,7 @@ func (m *VMManager) createProjectVM(ctx context.Context, projectID string) (*vzP
 		return nil, fmt.Errorf("failed to create console log file: %w", err)
 	}
 
-	log.Printf("Console log: %s", consoleLogPath)
+	vzLog.InfoContext(ctx, "project VM console log", "path", consoleLogPath)
 
 	// Build and start VM
 	vzVM, socketDevice, consoleRead, consoleWrite, err := m.buildAndStartVM(rootDiskPath, dataDiskPath, projectID)
@@ -537,7 +591,7 @@ func (m *VMManager) createProjectVM(ctx context.Context, projectID string) (*vzP
 		return nil, fmt.Errorf("failed to build and start VM: %w", err)
 	}
 
-	log.Printf("Started project VM for: %s", projectID)
+	vzLog.InfoContext(ctx, "started project VM", "project_id", projectID)
 
 	// Log console output to file and also to main log
 	go func() {
@@ -551,12 +605,12 @@ func (m *VMManager) createProjectVM(ctx context.Context, projectID string) (*vzP
 				// Write to file
 				_, _ = consoleLog.Write(buf[:n])
 				// Also log to main logger (with prefix)
-				log.Printf("[VM %s] %s", projectID, string(buf[:n]))
+				vzLog.InfoContext(ctx, "vm console", "project_id", projectID, "output", string(buf[:n]))
 			}
 		}
 	}()
 
-	log.Printf("Waiting for Docker daemon to be ready in VM: %s", projectID)
+	vzLog.InfoContext(ctx, "waiting for Docker daemon", "project_id", projectID)
 
 	// Wait for Docker daemon to be ready
 	if err := m.waitForDocker(ctx, socketDevice, projectID); err != nil {
@@ -567,7 +621,7 @@ func (m *VMManager) createProjectVM(ctx context.Context, projectID string) (*vzP
 		return nil, fmt.Errorf("docker daemon not ready: %w", err)
 	}
 
-	log.Printf("Docker daemon ready in VM: %s", projectID)
+	vzLog.InfoContext(ctx, "Docker daemon ready", "project_id", projectID)
 
 	pvm := &vzProjectVM{
 		projectID:    projectID,
@@ -751,7 +805,7 @@ func (m *VMManager) buildAndStartVM(rootDiskPath, dataDiskPath, _ string) (*vz.V
 			[]vz.DirectorySharingDeviceConfiguration{fsDeviceConfig},
 		)
 
-		log.Printf("VirtioFS: sharing %s as read-only (tag: home)", m.config.HomeDir)
+		vzLog.Info("VirtioFS sharing home directory read-only", "path", m.config.HomeDir, "tag", "home")
 	}
 
 	// Validate configuration
@@ -805,7 +859,7 @@ func (m *VMManager) waitForDocker(ctx context.Context, socketDevice *vz.VirtioSo
 			// Try to ping Docker API
 			conn, err := socketDevice.Connect(dockerSockPort)
 			if err != nil {
-				log.Printf("Project VM %s: waiting for Docker (connect failed: %v)", projectID, err)
+				vzLog.DebugContext(ctx, "waiting for Docker, connect failed", "project_id", projectID, "error", err)
 				continue
 			}
 
@@ -829,19 +883,19 @@ func (m *VMManager) waitForDocker(ctx context.Context, socketDevice *vz.VirtioSo
 			resp, err := client.Get("http://localhost/_ping")
 			if err != nil {
 				vsockConn.Close()
-				log.Printf("Project VM %s: waiting for Docker (ping failed: %v)", projectID, err)
+				vzLog.DebugContext(ctx, "waiting for Docker, ping failed", "project_id", projectID, "error", err)
 				continue
 			}
 			resp.Body.Close()
 
 			if resp.StatusCode == http.StatusOK {
 				vsockConn.Close()
-				log.Printf("Project VM %s: Docker daemon is ready", projectID)
+				vzLog.InfoContext(ctx, "Docker daemon is ready", "project_id", projectID)
 				return nil
 			}
 
 			vsockConn.Close()
-			log.Printf("Project VM %s: waiting for Docker (status: %d)", projectID, resp.StatusCode)
+			vzLog.DebugContext(ctx, "waiting for Docker", "project_id", projectID, "status", resp.StatusCode)
 		}
 	}
 }
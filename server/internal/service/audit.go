@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// auditLog is the logger for audit log messages.
+var auditLog = logging.Component("audit")
+
+// Audit log limits.
+const (
+	DefaultAuditLogLimit = 100
+	MaxAuditLogLimit     = 1000
+)
+
+// AuditEntry describes an action to record in the audit log.
+type AuditEntry struct {
+	ProjectID  string
+	ActorID    string
+	ActorEmail string
+	Action     string
+	TargetType string
+	TargetID   string
+	IP         string
+	UserAgent  string
+	Metadata   map[string]any
+}
+
+// AuditService records and queries the audit log.
+type AuditService struct {
+	store *store.Store
+}
+
+// NewAuditService creates a new audit service.
+func NewAuditService(s *store.Store) *AuditService {
+	return &AuditService{store: s}
+}
+
+// Record appends an entry to the audit log. Failures are logged rather than
+// returned so that auditing never blocks the action being audited.
+func (s *AuditService) Record(ctx context.Context, entry AuditEntry) {
+	row := &model.AuditLog{
+		ProjectID:  strPtr(entry.ProjectID),
+		ActorID:    strPtr(entry.ActorID),
+		ActorEmail: entry.ActorEmail,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+	}
+	if len(entry.Metadata) > 0 {
+		metadata, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			auditLog.WarnContext(ctx, "failed to encode audit metadata", "action", entry.Action, "error", err)
+		} else {
+			row.Metadata = metadata
+		}
+	}
+
+	// Detach from the request so a client disconnect doesn't drop the entry
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), row); err != nil {
+		auditLog.ErrorContext(ctx, "failed to write audit log entry", "action", entry.Action, "target_id", entry.TargetID, "error", err)
+	}
+}
+
+// List returns the audit log entries matching filter, newest first. The
+// limit defaults to DefaultAuditLogLimit and is capped at MaxAuditLogLimit.
+func (s *AuditService) List(ctx context.Context, filter store.AuditLogFilter) ([]*model.AuditLog, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLogLimit
+	}
+	filter.Limit = min(filter.Limit, MaxAuditLogLimit)
+	return s.store.ListAuditLogs(ctx, filter)
+}
+
+// Export writes every entry matching filter to w, newest first, as either
+// "jsonl" (one JSON object per line) or "csv".
+func (s *AuditService) Export(ctx context.Context, w io.Writer, format string, filter store.AuditLogFilter) error {
+	filter.Limit = 0
+	entries, err := s.store.ListAuditLogs(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"id", "created_at", "project_id", "actor_id", "actor_email", "action", "target_type", "target_id", "ip", "user_agent", "metadata"})
+		for _, entry := range entries {
+			_ = cw.Write([]string{
+				entry.ID,
+				entry.CreatedAt.UTC().Format(time.RFC3339),
+				ptrToString(entry.ProjectID),
+				ptrToString(entry.ActorID),
+				entry.ActorEmail,
+				entry.Action,
+				entry.TargetType,
+				entry.TargetID,
+				entry.IP,
+				entry.UserAgent,
+				string(entry.Metadata),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// Prune deletes entries older than retention and returns how many were removed.
+func (s *AuditService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.store.DeleteAuditLogsBefore(ctx, time.Now().Add(-retention))
+}
+
+// AuditRetentionMonitor periodically deletes audit log entries older than
+// the configured retention period.
+type AuditRetentionMonitor struct {
+	auditSvc      *AuditService
+	logger        *slog.Logger
+	retention     time.Duration
+	checkInterval time.Duration
+
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+	startOnce    sync.Once
+	shutdownOnce sync.Once
+}
+
+// NewAuditRetentionMonitor creates a new audit retention monitor.
+func NewAuditRetentionMonitor(auditSvc *AuditService, logger *slog.Logger, retention, checkInterval time.Duration) *AuditRetentionMonitor {
+	return &AuditRetentionMonitor{
+		auditSvc:      auditSvc,
+		logger:        logger.With("component", "audit_retention_monitor"),
+		retention:     retention,
+		checkInterval: checkInterval,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start prunes once immediately and then on every check interval.
+func (m *AuditRetentionMonitor) Start(ctx context.Context) {
+	m.startOnce.Do(func() {
+		m.wg.Add(1)
+		go m.monitorLoop(ctx)
+	})
+}
+
+// Shutdown gracefully stops the monitor.
+func (m *AuditRetentionMonitor) Shutdown(ctx context.Context) error {
+	var err error
+	m.shutdownOnce.Do(func() {
+		close(m.stopChan)
+
+		done := make(chan struct{})
+		go func() {
+			m.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = fmt.Errorf("shutdown timeout exceeded")
+		}
+	})
+	return err
+}
+
+func (m *AuditRetentionMonitor) monitorLoop(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		m.prune(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *AuditRetentionMonitor) prune(ctx context.Context) {
+	deleted, err := m.auditSvc.Prune(ctx, m.retention)
+	if err != nil {
+		m.logger.Error("failed to prune audit log", "error", err)
+		return
+	}
+	if deleted > 0 {
+		m.logger.Info("pruned audit log", "deleted", deleted, "retention", m.retention)
+	}
+}
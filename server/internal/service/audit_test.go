@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+func TestAuditRecordAndList(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	svc := NewAuditService(env.store)
+	ctx := context.Background()
+
+	svc.Record(ctx, AuditEntry{
+		ProjectID:  "p1",
+		ActorID:    "u1",
+		ActorEmail: "dev@example.com",
+		Action:     model.AuditActionCredentialCreate,
+		TargetType: "credential",
+		TargetID:   "anthropic",
+		IP:         "10.0.0.1",
+		UserAgent:  "curl/8.0",
+		Metadata:   map[string]any{"authType": "api_key"},
+	})
+	svc.Record(ctx, AuditEntry{ProjectID: "p1", ActorID: "u2", Action: model.AuditActionTerminalOpen, TargetType: "session", TargetID: "s1"})
+	svc.Record(ctx, AuditEntry{ProjectID: "p2", ActorID: "u1", Action: model.AuditActionSessionDelete, TargetType: "session", TargetID: "s2"})
+
+	entries, err := svc.List(ctx, store.AuditLogFilter{ProjectID: "p1"})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries for p1, got %d", len(entries))
+	}
+
+	entries, err = svc.List(ctx, store.AuditLogFilter{ProjectID: "p1", Action: model.AuditActionCredentialCreate})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 credential entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ActorEmail != "dev@example.com" || entry.IP != "10.0.0.1" || entry.UserAgent != "curl/8.0" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(entry.Metadata, &metadata); err != nil || metadata["authType"] != "api_key" {
+		t.Errorf("Unexpected metadata %s", entry.Metadata)
+	}
+}
+
+func TestAuditExport(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	svc := NewAuditService(env.store)
+	ctx := context.Background()
+
+	svc.Record(ctx, AuditEntry{ProjectID: "p1", ActorID: "u1", Action: model.AuditActionFileWrite, TargetType: "session", TargetID: "s1", Metadata: map[string]any{"path": "a.txt"}})
+	svc.Record(ctx, AuditEntry{ProjectID: "p1", ActorID: "u1", Action: model.AuditActionSessionCommit, TargetType: "session", TargetID: "s1"})
+
+	var jsonl bytes.Buffer
+	if err := svc.Export(ctx, &jsonl, "jsonl", store.AuditLogFilter{ProjectID: "p1"}); err != nil {
+		t.Fatalf("Export jsonl failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(jsonl.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSONL lines, got %d: %q", len(lines), jsonl.String())
+	}
+	for _, line := range lines {
+		var entry model.AuditLog
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.ProjectID == nil || *entry.ProjectID != "p1" {
+			t.Errorf("Unexpected JSONL line %q: %v", line, err)
+		}
+	}
+
+	var csvOut bytes.Buffer
+	if err := svc.Export(ctx, &csvOut, "csv", store.AuditLogFilter{ProjectID: "p1"}); err != nil {
+		t.Fatalf("Export csv failed: %v", err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][5] != "action" {
+		t.Fatalf("Expected header plus 2 rows, got %v", records)
+	}
+
+	if err := svc.Export(ctx, &bytes.Buffer{}, "xml", store.AuditLogFilter{}); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestAuditPrune(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	svc := NewAuditService(env.store)
+	ctx := context.Background()
+
+	old := &model.AuditLog{Action: model.AuditActionSessionDelete, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	if err := env.store.CreateAuditLog(ctx, old); err != nil {
+		t.Fatalf("Failed to create audit log: %v", err)
+	}
+	svc.Record(ctx, AuditEntry{Action: model.AuditActionSessionDelete})
+
+	deleted, err := svc.Prune(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 pruned entry, got %d", deleted)
+	}
+	entries, err := svc.List(ctx, store.AuditLogFilter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 remaining entry, got %d", len(entries))
+	}
+}
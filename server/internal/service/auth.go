@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -17,16 +18,21 @@ import (
 	"golang.org/x/oauth2/google"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
 
+// authLog is the logger for sign-in and API token messages.
+var authLog = logging.Component("auth")
+
 // AuthService handles authentication operations
 type AuthService struct {
 	store        *store.Store
 	cfg          *config.Config
 	githubConfig *oauth2.Config
 	googleConfig *oauth2.Config
+	oidc         *oidcProvider
 }
 
 // User represents an authenticated user (for API responses)
@@ -36,6 +42,9 @@ type User struct {
 	Name      string `json:"name"`
 	AvatarURL string `json:"avatarUrl,omitempty"`
 	Provider  string `json:"provider"`
+
+	// Groups reported by the identity provider, used for project auto-join.
+	Groups []string `json:"-"`
 }
 
 // NewAuthService creates a new auth service
@@ -65,11 +74,25 @@ func NewAuthService(s *store.Store, cfg *config.Config) *AuthService {
 		}
 	}
 
+	// Configure generic OIDC (endpoints are discovered on first use)
+	if cfg.OIDCEnabled() {
+		svc.oidc = newOIDCProvider(cfg)
+	}
+
 	return svc
 }
 
-// GetAuthURL returns the OAuth authorization URL for a provider
-func (s *AuthService) GetAuthURL(provider, redirectURL, state string) (string, error) {
+// GetAuthURL returns the OAuth authorization URL for a provider.
+// The PKCE verifier is used by providers that support it (OIDC) and must be
+// passed again to ExchangeCode.
+func (s *AuthService) GetAuthURL(ctx context.Context, provider, redirectURL, state, verifier string) (string, error) {
+	if provider == OIDCProviderName {
+		if s.oidc == nil {
+			return "", fmt.Errorf("OIDC not configured")
+		}
+		return s.oidc.AuthURL(ctx, redirectURL, state, verifier)
+	}
+
 	config, err := s.getOAuthConfig(provider, redirectURL)
 	if err != nil {
 		return "", err
@@ -78,7 +101,14 @@ func (s *AuthService) GetAuthURL(provider, redirectURL, state string) (string, e
 }
 
 // ExchangeCode exchanges an authorization code for user info
-func (s *AuthService) ExchangeCode(ctx context.Context, provider, redirectURL, code string) (*User, error) {
+func (s *AuthService) ExchangeCode(ctx context.Context, provider, redirectURL, code, state, verifier string) (*User, error) {
+	if provider == OIDCProviderName {
+		if s.oidc == nil {
+			return nil, fmt.Errorf("OIDC not configured")
+		}
+		return s.oidc.Exchange(ctx, redirectURL, code, state, verifier)
+	}
+
 	config, err := s.getOAuthConfig(provider, redirectURL)
 	if err != nil {
 		return nil, err
@@ -111,6 +141,7 @@ func (s *AuthService) CreateOrUpdateUser(ctx context.Context, user *User) (*User
 		if err := s.store.UpdateUser(ctx, existing); err != nil {
 			return nil, fmt.Errorf("failed to update user: %w", err)
 		}
+		s.applyGroupMappings(ctx, existing.ID, user.Groups)
 		return &User{
 			ID:        existing.ID,
 			Email:     existing.Email,
@@ -131,6 +162,7 @@ func (s *AuthService) CreateOrUpdateUser(ctx context.Context, user *User) (*User
 	if err := s.store.CreateUser(ctx, newUser); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.applyGroupMappings(ctx, newUser.ID, user.Groups)
 
 	return &User{
 		ID:        newUser.ID,
@@ -141,6 +173,53 @@ func (s *AuthService) CreateOrUpdateUser(ctx context.Context, user *User) (*User
 	}, nil
 }
 
+// applyGroupMappings adds a user to the projects mapped from their identity
+// provider groups. Existing memberships are only ever upgraded to a more
+// privileged role; owners and memberships whose group was removed are left
+// alone.
+func (s *AuthService) applyGroupMappings(ctx context.Context, userID string, groups []string) {
+	if len(groups) == 0 || len(s.cfg.OIDCGroupMappings) == 0 {
+		return
+	}
+
+	// Highest mapped role per project
+	roles := make(map[string]string)
+	for _, m := range s.cfg.OIDCGroupMappings {
+		if slices.Contains(groups, m.Group) && model.RoleRank(m.Role) > model.RoleRank(roles[m.ProjectID]) {
+			roles[m.ProjectID] = m.Role
+		}
+	}
+
+	for projectID, role := range roles {
+		if _, err := s.store.GetProjectByID(ctx, projectID); err != nil {
+			authLog.WarnContext(ctx, "OIDC group mapping refers to unknown project", "project_id", projectID, "error", err)
+			continue
+		}
+
+		member, err := s.store.GetProjectMember(ctx, projectID, userID)
+		if err == nil {
+			if model.RoleRank(role) > model.RoleRank(member.Role) {
+				member.Role = role
+				if err := s.store.UpdateProjectMember(ctx, member); err != nil {
+					authLog.ErrorContext(ctx, "failed to update project role", "user_id", userID, "project_id", projectID, "error", err)
+				}
+			}
+			continue
+		}
+
+		now := time.Now()
+		if err := s.store.CreateProjectMember(ctx, &model.ProjectMember{
+			ProjectID:  projectID,
+			UserID:     userID,
+			Role:       role,
+			InvitedAt:  &now,
+			AcceptedAt: &now,
+		}); err != nil {
+			authLog.ErrorContext(ctx, "failed to add user to project", "user_id", userID, "project_id", projectID, "error", err)
+		}
+	}
+}
+
 // CreateSession creates a new session for a user and returns the token
 func (s *AuthService) CreateSession(ctx context.Context, userID string) (string, error) {
 	// Generate random token
@@ -373,6 +452,11 @@ func (s *AuthService) getGoogleUser(ctx context.Context, token *oauth2.Token) (*
 	}, nil
 }
 
+// GeneratePKCEVerifier generates a PKCE code verifier for OAuth
+func GeneratePKCEVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
 // GenerateState generates a random state for OAuth
 func GenerateState() (string, error) {
 	b := make([]byte, 16)
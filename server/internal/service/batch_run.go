@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// batchRunLog is the logger for batch run messages.
+var batchRunLog = logging.Component("batch-run")
+
+// Batch run limits.
+const (
+	DefaultBatchRunConcurrency = 2
+	MaxBatchRunConcurrency     = 8
+	MaxBatchRunItems           = 50
+)
+
+// ErrInvalidBatchRun is returned when a batch run request fails validation.
+var ErrInvalidBatchRun = errors.New("invalid batch run")
+
+// BatchRunMatrix lists the values to fan a batch run's prompt out over.
+// Every combination becomes one session. Empty Models or Reasoning use the
+// agent's defaults; empty AgentIDs uses the project's default agent.
+type BatchRunMatrix struct {
+	WorkspaceIDs []string `json:"workspaceIds"`
+	AgentIDs     []string `json:"agentIds,omitempty"`
+	Models       []string `json:"models,omitempty"`
+	Reasoning    []string `json:"reasoning,omitempty"`
+}
+
+// CreateBatchRunRequest contains the parameters for creating a batch run.
+type CreateBatchRunRequest struct {
+	Name        string         `json:"name"`
+	Prompt      string         `json:"prompt"`
+	Matrix      BatchRunMatrix `json:"matrix"`
+	Concurrency int            `json:"concurrency,omitempty"`
+	AutoCommit  bool           `json:"autoCommit,omitempty"`
+}
+
+// BatchRunCounts summarizes the status of a batch run's items.
+type BatchRunCounts struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+// BatchRun is a batch run with its items and aggregate counts.
+type BatchRun struct {
+	*model.BatchRun
+	Counts BatchRunCounts `json:"counts"`
+}
+
+// BatchRunService manages batch runs.
+type BatchRunService struct {
+	store          *store.Store
+	sessionService *SessionService
+	chatService    *ChatService
+	eventBroker    *events.Broker
+	jobEnqueuer    JobEnqueuer
+}
+
+// NewBatchRunService creates a new batch run service.
+func NewBatchRunService(s *store.Store, sessionService *SessionService, chatService *ChatService, eventBroker *events.Broker, jobEnqueuer JobEnqueuer) *BatchRunService {
+	return &BatchRunService{
+		store:          s,
+		sessionService: sessionService,
+		chatService:    chatService,
+		eventBroker:    eventBroker,
+		jobEnqueuer:    jobEnqueuer,
+	}
+}
+
+// CreateBatchRun validates the request, creates one item per matrix combination
+// and enqueues the first item of each concurrency slot.
+func (b *BatchRunService) CreateBatchRun(ctx context.Context, projectID string, req CreateBatchRunRequest) (*BatchRun, error) {
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		return nil, fmt.Errorf("%w: prompt is required", ErrInvalidBatchRun)
+	}
+
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = DefaultBatchRunConcurrency
+	}
+	if concurrency < 1 || concurrency > MaxBatchRunConcurrency {
+		return nil, fmt.Errorf("%w: concurrency must be between 1 and %d", ErrInvalidBatchRun, MaxBatchRunConcurrency)
+	}
+
+	workspaceIDs, err := b.validateWorkspaces(ctx, projectID, req.Matrix.WorkspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+	agentIDs, err := b.validateAgents(ctx, projectID, req.Matrix.AgentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range req.Matrix.Reasoning {
+		if r != "" && r != "enabled" && r != "disabled" {
+			return nil, fmt.Errorf("%w: reasoning must be \"enabled\", \"disabled\" or empty", ErrInvalidBatchRun)
+		}
+	}
+
+	items := expandBatchRunMatrix(workspaceIDs, agentIDs, dedupe(req.Matrix.Models), dedupe(req.Matrix.Reasoning))
+	if len(items) > MaxBatchRunItems {
+		return nil, fmt.Errorf("%w: matrix has %d combinations, maximum is %d", ErrInvalidBatchRun, len(items), MaxBatchRunItems)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		messages, _ := buildCommitMessage("", prompt)
+		name = deriveSessionName(messages)
+	}
+
+	run := &model.BatchRun{
+		ProjectID:   projectID,
+		Name:        name,
+		Prompt:      prompt,
+		Concurrency: concurrency,
+		AutoCommit:  req.AutoCommit,
+		Status:      model.BatchRunStatusPending,
+		Items:       items,
+	}
+	if err := b.store.CreateBatchRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to create batch run: %w", err)
+	}
+
+	// Queue the first item of each slot; each finished item queues the next
+	// one of its slot. Queueing every item up front would fill the
+	// dispatcher's claim window with jobs waiting on busy slots and hold up
+	// every other job on the server.
+	for position := range min(concurrency, len(run.Items)) {
+		b.enqueueItem(ctx, run, position)
+	}
+
+	return b.GetBatchRun(ctx, projectID, run.ID)
+}
+
+// enqueueItem enqueues the job for the item at position. Items that are no
+// longer pending are skipped, and items whose job cannot be enqueued fail;
+// either way the next item of the same slot is tried instead.
+func (b *BatchRunService) enqueueItem(ctx context.Context, run *model.BatchRun, position int) {
+	for ; position < len(run.Items); position += run.Concurrency {
+		item := &run.Items[position]
+		if item.Status != model.BatchRunItemStatusPending {
+			continue
+		}
+		err := b.jobEnqueuer.Enqueue(ctx, jobs.BatchRunItemPayload{
+			ProjectID:  run.ProjectID,
+			BatchRunID: run.ID,
+			ItemID:     item.ID,
+			Slot:       position % run.Concurrency,
+		})
+		if err == nil {
+			return
+		}
+		batchRunLog.ErrorContext(ctx, "failed to enqueue batch run item", "batch_run_id", run.ID, "item_id", item.ID, "error", err)
+		b.finishItem(ctx, run, item, fmt.Errorf("failed to enqueue: %w", err))
+	}
+}
+
+// enqueueNext enqueues the item following position in its slot, once the
+// item at position is done.
+func (b *BatchRunService) enqueueNext(ctx context.Context, batchRunID string, position int) {
+	ctx = context.WithoutCancel(ctx)
+	run, err := b.store.GetBatchRunByID(ctx, batchRunID)
+	if err != nil {
+		batchRunLog.ErrorContext(ctx, "failed to get batch run", "batch_run_id", batchRunID, "error", err)
+		return
+	}
+	b.enqueueItem(ctx, run, position+run.Concurrency)
+}
+
+// validateWorkspaces checks that at least one workspace is given and that all
+// belong to the project. Returns the de-duplicated IDs.
+func (b *BatchRunService) validateWorkspaces(ctx context.Context, projectID string, ids []string) ([]string, error) {
+	ids = dedupe(ids)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: at least one workspace is required", ErrInvalidBatchRun)
+	}
+	for _, id := range ids {
+		workspace, err := b.store.GetWorkspaceByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: workspace %s not found", ErrInvalidBatchRun, id)
+		}
+		if workspace.ProjectID != projectID {
+			return nil, fmt.Errorf("%w: workspace %s does not belong to this project", ErrInvalidBatchRun, id)
+		}
+	}
+	return ids, nil
+}
+
+// validateAgents checks that all agents belong to the project. If none are
+// given, the project's default agent is used. Returns the de-duplicated IDs.
+func (b *BatchRunService) validateAgents(ctx context.Context, projectID string, ids []string) ([]string, error) {
+	ids = dedupe(ids)
+	if len(ids) == 0 {
+		agent, err := b.store.GetDefaultAgent(ctx, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: no agents given and no default agent is configured", ErrInvalidBatchRun)
+		}
+		return []string{agent.ID}, nil
+	}
+	for _, id := range ids {
+		agent, err := b.store.GetAgentByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: agent %s not found", ErrInvalidBatchRun, id)
+		}
+		if agent.ProjectID != projectID {
+			return nil, fmt.Errorf("%w: agent %s does not belong to this project", ErrInvalidBatchRun, id)
+		}
+	}
+	return ids, nil
+}
+
+// expandBatchRunMatrix returns one pending item per combination of the given
+// values. Empty models or reasoning expand to a single item using the default.
+func expandBatchRunMatrix(workspaceIDs, agentIDs, models, reasoning []string) []model.BatchRunItem {
+	if len(models) == 0 {
+		models = []string{""}
+	}
+	if len(reasoning) == 0 {
+		reasoning = []string{""}
+	}
+
+	var items []model.BatchRunItem
+	for _, workspaceID := range workspaceIDs {
+		for _, agentID := range agentIDs {
+			for _, m := range models {
+				for _, r := range reasoning {
+					items = append(items, model.BatchRunItem{
+						Position:    len(items),
+						WorkspaceID: workspaceID,
+						AgentID:     agentID,
+						Model:       nonEmptyPtr(m),
+						Reasoning:   nonEmptyPtr(r),
+						Status:      model.BatchRunItemStatusPending,
+					})
+				}
+			}
+		}
+	}
+	return items
+}
+
+// GetBatchRun returns a batch run and validates it belongs to the project.
+func (b *BatchRunService) GetBatchRun(ctx context.Context, projectID, batchRunID string) (*BatchRun, error) {
+	run, err := b.store.GetBatchRunByID(ctx, batchRunID)
+	if err != nil {
+		return nil, fmt.Errorf("batch run not found: %w", err)
+	}
+	if run.ProjectID != projectID {
+		return nil, fmt.Errorf("batch run does not belong to this project")
+	}
+	return mapBatchRun(run), nil
+}
+
+// ListBatchRuns returns all batch runs for a project, newest first.
+func (b *BatchRunService) ListBatchRuns(ctx context.Context, projectID string) ([]*BatchRun, error) {
+	runs, err := b.store.ListBatchRunsByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batch runs: %w", err)
+	}
+	result := make([]*BatchRun, len(runs))
+	for i, run := range runs {
+		result[i] = mapBatchRun(run)
+	}
+	return result, nil
+}
+
+// CancelBatchRun cancels all items that have not started yet.
+// Items that are already running are allowed to finish.
+func (b *BatchRunService) CancelBatchRun(ctx context.Context, projectID, batchRunID string) (*BatchRun, error) {
+	if _, err := b.GetBatchRun(ctx, projectID, batchRunID); err != nil {
+		return nil, err
+	}
+	if _, err := b.store.CancelPendingBatchRunItems(ctx, batchRunID); err != nil {
+		return nil, fmt.Errorf("failed to cancel batch run: %w", err)
+	}
+	b.refreshStatus(ctx, projectID, batchRunID, events.BatchRunUpdatedData{})
+	return b.GetBatchRun(ctx, projectID, batchRunID)
+}
+
+// RunItem runs a single batch run item synchronously: it creates and
+// initializes a session, sends the prompt, waits for the agent to finish and
+// records the outcome. This is called by the dispatcher when processing a
+// batch_run_item job.
+func (b *BatchRunService) RunItem(ctx context.Context, projectID, batchRunID, itemID string) error {
+	run, err := b.store.GetBatchRunByID(ctx, batchRunID)
+	if err != nil {
+		return fmt.Errorf("batch run not found: %w", err)
+	}
+	if run.ProjectID != projectID {
+		return fmt.Errorf("batch run does not belong to this project")
+	}
+
+	started, err := b.store.StartBatchRunItem(ctx, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to start batch run item: %w", err)
+	}
+	item, err := b.store.GetBatchRunItemByID(ctx, itemID)
+	if err != nil {
+		return fmt.Errorf("batch run item not found: %w", err)
+	}
+	if item.BatchRunID != batchRunID {
+		return fmt.Errorf("batch run item does not belong to this batch run")
+	}
+	if !started {
+		switch item.Status {
+		case model.BatchRunItemStatusRunning:
+			// A previous attempt died mid-run; the session may be in any state
+			// so don't send the prompt a second time.
+			b.finishItem(ctx, run, item, fmt.Errorf("interrupted before completion"))
+			b.enqueueNext(ctx, batchRunID, item.Position)
+		case model.BatchRunItemStatusCancelled:
+			b.enqueueNext(ctx, batchRunID, item.Position)
+		}
+		// A finished item already handed its slot on
+		return nil
+	}
+	b.refreshStatus(ctx, projectID, batchRunID, batchRunItemEvent(item))
+
+	runErr := b.runItem(ctx, run, item)
+	b.finishItem(ctx, run, item, runErr)
+	b.enqueueNext(ctx, batchRunID, item.Position)
+	return runErr
+}
+
+// runItem does the work for RunItem. item.SessionID is recorded as soon as
+// the session exists so it is kept even if a later step fails.
+func (b *BatchRunService) runItem(ctx context.Context, run *model.BatchRun, item *model.BatchRunItem) error {
+	sessionID, err := b.chatService.RunHeadless(ctx, HeadlessRunRequest{
+		ProjectID:   run.ProjectID,
+		WorkspaceID: item.WorkspaceID,
+		AgentID:     item.AgentID,
+		Model:       ptrToString(item.Model),
+		Reasoning:   ptrToString(item.Reasoning),
+		Name:        fmt.Sprintf("%s #%d", run.Name, item.Position+1),
+		Prompt:      run.Prompt,
+		OnSessionCreated: func(sessionID string) {
+			item.SessionID = &sessionID
+			if err := b.store.UpdateBatchRunItem(ctx, item); err != nil {
+				batchRunLog.ErrorContext(withSession(ctx, sessionID), "failed to record session for batch run item", "item_id", item.ID, "error", err)
+			}
+			b.refreshStatus(ctx, run.ProjectID, run.ID, batchRunItemEvent(item))
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if run.AutoCommit {
+		if err := b.sessionService.CommitSession(ctx, run.ProjectID, sessionID, b.jobEnqueuer); err != nil {
+			// The run itself succeeded; the commit's outcome is tracked on the session
+			batchRunLog.WarnContext(withSession(ctx, sessionID), "failed to start auto-commit for batch session", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// finishItem records an item's outcome and updates the batch run's status.
+func (b *BatchRunService) finishItem(ctx context.Context, run *model.BatchRun, item *model.BatchRunItem, runErr error) {
+	if runErr != nil {
+		item.Status = model.BatchRunItemStatusFailed
+		item.Error = ptrString(runErr.Error())
+	} else {
+		item.Status = model.BatchRunItemStatusSucceeded
+		item.Error = nil
+	}
+	// Use a fresh context so a timed out run is still recorded
+	ctx = context.WithoutCancel(ctx)
+	if err := b.store.UpdateBatchRunItem(ctx, item); err != nil {
+		batchRunLog.ErrorContext(ctx, "failed to update batch run item", "item_id", item.ID, "error", err)
+	}
+	b.refreshStatus(ctx, run.ProjectID, run.ID, batchRunItemEvent(item))
+}
+
+// refreshStatus recomputes the batch run's aggregate status, stores it if it
+// changed, and publishes a batch_run_updated event.
+func (b *BatchRunService) refreshStatus(ctx context.Context, projectID, batchRunID string, data events.BatchRunUpdatedData) {
+	run, err := b.store.GetBatchRunByID(ctx, batchRunID)
+	if err != nil {
+		batchRunLog.ErrorContext(ctx, "failed to get batch run", "batch_run_id", batchRunID, "error", err)
+		return
+	}
+
+	status := batchRunStatus(countBatchRunItems(run.Items))
+	if status != run.Status {
+		if err := b.store.UpdateBatchRunStatus(ctx, batchRunID, status); err != nil {
+			batchRunLog.ErrorContext(ctx, "failed to update batch run status", "batch_run_id", batchRunID, "error", err)
+		}
+	}
+
+	if b.eventBroker != nil {
+		data.BatchRunID = batchRunID
+		data.Status = status
+		if err := b.eventBroker.PublishBatchRunUpdated(ctx, projectID, data); err != nil {
+			batchRunLog.WarnContext(ctx, "failed to publish batch run update event", "batch_run_id", batchRunID, "error", err)
+		}
+	}
+}
+
+// batchRunItemEvent returns the event data describing an item's state.
+func batchRunItemEvent(item *model.BatchRunItem) events.BatchRunUpdatedData {
+	return events.BatchRunUpdatedData{
+		ItemID:     item.ID,
+		ItemStatus: item.Status,
+		SessionID:  ptrToString(item.SessionID),
+	}
+}
+
+// mapBatchRun converts a model batch run to the service type.
+func mapBatchRun(run *model.BatchRun) *BatchRun {
+	counts := countBatchRunItems(run.Items)
+	run.Status = batchRunStatus(counts)
+	return &BatchRun{BatchRun: run, Counts: counts}
+}
+
+// countBatchRunItems tallies items by status.
+func countBatchRunItems(items []model.BatchRunItem) BatchRunCounts {
+	counts := BatchRunCounts{Total: len(items)}
+	for _, item := range items {
+		switch item.Status {
+		case model.BatchRunItemStatusPending:
+			counts.Pending++
+		case model.BatchRunItemStatusRunning:
+			counts.Running++
+		case model.BatchRunItemStatusSucceeded:
+			counts.Succeeded++
+		case model.BatchRunItemStatusFailed:
+			counts.Failed++
+		case model.BatchRunItemStatusCancelled:
+			counts.Cancelled++
+		}
+	}
+	return counts
+}
+
+// batchRunStatus derives a batch run's status from its item counts.
+func batchRunStatus(counts BatchRunCounts) string {
+	switch {
+	case counts.Pending == counts.Total:
+		return model.BatchRunStatusPending
+	case counts.Pending > 0 || counts.Running > 0:
+		return model.BatchRunStatusRunning
+	case counts.Cancelled > 0:
+		return model.BatchRunStatusCancelled
+	default:
+		return model.BatchRunStatusCompleted
+	}
+}
+
+// dedupe returns the non-empty values in order with duplicates removed.
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
+
+// nonEmptyPtr returns a pointer to s, or nil if s is empty.
+func nonEmptyPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
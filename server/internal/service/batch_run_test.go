@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+// newTestBatchRunService returns a batch run service that records enqueued payloads.
+func newTestBatchRunService(env *testEnv) (*BatchRunService, *[]jobs.BatchRunItemPayload) {
+	var enqueued []jobs.BatchRunItemPayload
+	enqueuer := &mockJobEnqueuer{
+		enqueueFunc: func(_ context.Context, payload jobs.JobPayload) error {
+			if p, ok := payload.(jobs.BatchRunItemPayload); ok {
+				enqueued = append(enqueued, p)
+			}
+			return nil
+		},
+	}
+	return NewBatchRunService(env.store, nil, nil, env.eventBroker, enqueuer), &enqueued
+}
+
+func TestCreateBatchRun(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, enqueued := newTestBatchRunService(env)
+
+	run, err := svc.CreateBatchRun(context.Background(), project.ID, CreateBatchRunRequest{
+		Prompt: "Fix the bug",
+		Matrix: BatchRunMatrix{
+			WorkspaceIDs: []string{workspace.ID},
+			AgentIDs:     []string{agent.ID, agent.ID},
+			Models:       []string{"model-a", "model-b", "model-c"},
+			Reasoning:    []string{"enabled", "disabled"},
+		},
+		Concurrency: 4,
+		AutoCommit:  true,
+	})
+	if err != nil {
+		t.Fatalf("CreateBatchRun failed: %v", err)
+	}
+
+	if run.Name != "Fix the bug" {
+		t.Errorf("Expected name derived from prompt, got %q", run.Name)
+	}
+	if !run.AutoCommit {
+		t.Error("Expected auto-commit to be stored")
+	}
+	if run.Status != model.BatchRunStatusPending {
+		t.Errorf("Expected status %s, got %s", model.BatchRunStatusPending, run.Status)
+	}
+	// Duplicate agents are collapsed: 1 workspace x 1 agent x 3 models x 2 reasoning modes
+	if run.Counts.Total != 6 || run.Counts.Pending != 6 {
+		t.Errorf("Expected 6 pending items, got %+v", run.Counts)
+	}
+	// Only the first item of each slot is queued up front
+	if len(*enqueued) != 4 {
+		t.Fatalf("Expected 4 jobs, got %d", len(*enqueued))
+	}
+
+	slots := make(map[int]int)
+	for i, p := range *enqueued {
+		slots[p.Slot]++
+		if p.BatchRunID != run.ID || p.ProjectID != project.ID || p.ItemID != run.Items[i].ID {
+			t.Errorf("Unexpected payload: %+v", p)
+		}
+	}
+	if len(slots) != 4 {
+		t.Errorf("Expected one job in each of 4 slots, got %v", slots)
+	}
+
+	first := run.Items[0]
+	if ptrToString(first.Model) != "model-a" || ptrToString(first.Reasoning) != "enabled" {
+		t.Errorf("Unexpected first item: model=%v reasoning=%v", first.Model, first.Reasoning)
+	}
+
+	runs, err := svc.ListBatchRuns(context.Background(), project.ID)
+	if err != nil {
+		t.Fatalf("ListBatchRuns failed: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("Expected the batch run to be listed, got %d runs", len(runs))
+	}
+}
+
+func TestCreateBatchRun_Validation(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, enqueued := newTestBatchRunService(env)
+
+	tests := []struct {
+		name string
+		req  CreateBatchRunRequest
+	}{
+		{"missing prompt", CreateBatchRunRequest{Matrix: BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}}}},
+		{"missing workspaces", CreateBatchRunRequest{Prompt: "p"}},
+		{"unknown workspace", CreateBatchRunRequest{Prompt: "p", Matrix: BatchRunMatrix{WorkspaceIDs: []string{"missing"}}}},
+		{"no default agent", CreateBatchRunRequest{Prompt: "p", Matrix: BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}}}},
+		{"concurrency too high", CreateBatchRunRequest{Prompt: "p", Concurrency: MaxBatchRunConcurrency + 1, Matrix: BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}}}},
+		{"invalid reasoning", CreateBatchRunRequest{Prompt: "p", Matrix: BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}, Reasoning: []string{"maximum"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateBatchRun(context.Background(), project.ID, tt.req)
+			if !errors.Is(err, ErrInvalidBatchRun) {
+				t.Errorf("Expected ErrInvalidBatchRun, got %v", err)
+			}
+		})
+	}
+
+	t.Run("too many combinations", func(t *testing.T) {
+		agent := env.createTestAgent(t, project.ID)
+		models := make([]string, MaxBatchRunItems+1)
+		for i := range models {
+			models[i] = fmt.Sprintf("model-%d", i)
+		}
+		_, err := svc.CreateBatchRun(context.Background(), project.ID, CreateBatchRunRequest{
+			Prompt: "p",
+			Matrix: BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}, AgentIDs: []string{agent.ID}, Models: models},
+		})
+		if !errors.Is(err, ErrInvalidBatchRun) {
+			t.Errorf("Expected ErrInvalidBatchRun, got %v", err)
+		}
+	})
+
+	if len(*enqueued) != 0 {
+		t.Errorf("Expected no jobs for invalid requests, got %d", len(*enqueued))
+	}
+}
+
+func TestCancelBatchRun(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestBatchRunService(env)
+	ctx := context.Background()
+
+	run, err := svc.CreateBatchRun(ctx, project.ID, CreateBatchRunRequest{
+		Prompt: "p",
+		Matrix: BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}, AgentIDs: []string{agent.ID}, Models: []string{"a", "b"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateBatchRun failed: %v", err)
+	}
+
+	// First item is already running and must be left alone
+	if started, err := env.store.StartBatchRunItem(ctx, run.Items[0].ID); err != nil || !started {
+		t.Fatalf("StartBatchRunItem failed: started=%v err=%v", started, err)
+	}
+
+	run, err = svc.CancelBatchRun(ctx, project.ID, run.ID)
+	if err != nil {
+		t.Fatalf("CancelBatchRun failed: %v", err)
+	}
+	if run.Counts.Running != 1 || run.Counts.Cancelled != 1 {
+		t.Errorf("Expected 1 running and 1 cancelled item, got %+v", run.Counts)
+	}
+	if run.Status != model.BatchRunStatusRunning {
+		t.Errorf("Expected status %s while an item runs, got %s", model.BatchRunStatusRunning, run.Status)
+	}
+
+	// A cancelled item's job is a no-op
+	if err := svc.RunItem(ctx, project.ID, run.ID, run.Items[1].ID); err != nil {
+		t.Errorf("RunItem for cancelled item failed: %v", err)
+	}
+
+	// Re-running an item that was left running marks it as interrupted
+	if err := svc.RunItem(ctx, project.ID, run.ID, run.Items[0].ID); err != nil {
+		t.Errorf("RunItem for interrupted item failed: %v", err)
+	}
+
+	run, err = svc.GetBatchRun(ctx, project.ID, run.ID)
+	if err != nil {
+		t.Fatalf("GetBatchRun failed: %v", err)
+	}
+	if run.Items[0].Status != model.BatchRunItemStatusFailed {
+		t.Errorf("Expected interrupted item to fail, got %s", run.Items[0].Status)
+	}
+	if run.Status != model.BatchRunStatusCancelled {
+		t.Errorf("Expected status %s, got %s", model.BatchRunStatusCancelled, run.Status)
+	}
+
+	stored, err := env.store.GetBatchRunByID(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("Failed to get batch run: %v", err)
+	}
+	if stored.Status != model.BatchRunStatusCancelled {
+		t.Errorf("Expected stored status %s, got %s", model.BatchRunStatusCancelled, stored.Status)
+	}
+}
+
+func TestRunItem_EnqueuesNextInSlot(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, enqueued := newTestBatchRunService(env)
+	ctx := context.Background()
+
+	run, err := svc.CreateBatchRun(ctx, project.ID, CreateBatchRunRequest{
+		Prompt:      "p",
+		Matrix:      BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}, AgentIDs: []string{agent.ID}, Models: []string{"a", "b", "c"}},
+		Concurrency: 1,
+	})
+	if err != nil {
+		t.Fatalf("CreateBatchRun failed: %v", err)
+	}
+	if len(*enqueued) != 1 || (*enqueued)[0].ItemID != run.Items[0].ID {
+		t.Fatalf("Expected only the first item to be queued, got %+v", *enqueued)
+	}
+
+	// The second item was cancelled, so the slot passes to the third
+	cancelled, err := env.store.GetBatchRunItemByID(ctx, run.Items[1].ID)
+	if err != nil {
+		t.Fatalf("GetBatchRunItemByID failed: %v", err)
+	}
+	cancelled.Status = model.BatchRunItemStatusCancelled
+	if err := env.store.UpdateBatchRunItem(ctx, cancelled); err != nil {
+		t.Fatalf("UpdateBatchRunItem failed: %v", err)
+	}
+
+	// Finish the first item by interrupting it
+	if started, err := env.store.StartBatchRunItem(ctx, run.Items[0].ID); err != nil || !started {
+		t.Fatalf("StartBatchRunItem failed: started=%v err=%v", started, err)
+	}
+	if err := svc.RunItem(ctx, project.ID, run.ID, run.Items[0].ID); err != nil {
+		t.Fatalf("RunItem failed: %v", err)
+	}
+
+	if len(*enqueued) != 2 {
+		t.Fatalf("Expected 2 jobs, got %d", len(*enqueued))
+	}
+	if next := (*enqueued)[1]; next.ItemID != run.Items[2].ID || next.Slot != 0 {
+		t.Errorf("Expected the third item in slot 0 to be queued, got %+v", next)
+	}
+
+	// The last item of the slot has nothing to hand on to
+	if err := svc.RunItem(ctx, project.ID, run.ID, run.Items[0].ID); err != nil {
+		t.Fatalf("RunItem for a finished item failed: %v", err)
+	}
+	if len(*enqueued) != 2 {
+		t.Errorf("Expected a finished item not to queue again, got %d jobs", len(*enqueued))
+	}
+}
+
+func TestCreateBatchRun_DoesNotBlockQueue(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	queue := jobs.NewQueue(env.store, &config.Config{JobMaxAttempts: 3})
+	svc := NewBatchRunService(env.store, nil, nil, env.eventBroker, queue)
+	ctx := context.Background()
+
+	models := make([]string, 20)
+	for i := range models {
+		models[i] = fmt.Sprintf("model-%d", i)
+	}
+	if _, err := svc.CreateBatchRun(ctx, project.ID, CreateBatchRunRequest{
+		Prompt:      "p",
+		Matrix:      BatchRunMatrix{WorkspaceIDs: []string{workspace.ID}, AgentIDs: []string{agent.ID}, Models: models},
+		Concurrency: 2,
+	}); err != nil {
+		t.Fatalf("CreateBatchRun failed: %v", err)
+	}
+
+	jobTypes := []string{string(jobs.JobTypeBatchRunItem), string(jobs.JobTypeSessionInit)}
+	for range 2 {
+		job, err := env.store.ClaimJobOfTypes(ctx, jobTypes, "worker")
+		if err != nil || job == nil || job.Type != string(jobs.JobTypeBatchRunItem) {
+			t.Fatalf("Expected to claim a batch run item, got job=%+v err=%v", job, err)
+		}
+	}
+
+	// Both slots are busy; a session init queued after the batch must still run
+	if err := queue.Enqueue(ctx, jobs.SessionInitPayload{ProjectID: project.ID, SessionID: "session-1", WorkspaceID: workspace.ID}); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	job, err := env.store.ClaimJobOfTypes(ctx, jobTypes, "worker")
+	if err != nil {
+		t.Fatalf("ClaimJobOfTypes failed: %v", err)
+	}
+	if job == nil || job.Type != string(jobs.JobTypeSessionInit) {
+		t.Errorf("Expected the session init job to be claimed, got %+v", job)
+	}
+}
+
+func TestBatchRunStatus(t *testing.T) {
+	tests := []struct {
+		counts BatchRunCounts
+		want   string
+	}{
+		{BatchRunCounts{Total: 2, Pending: 2}, model.BatchRunStatusPending},
+		{BatchRunCounts{Total: 2, Pending: 1, Succeeded: 1}, model.BatchRunStatusRunning},
+		{BatchRunCounts{Total: 2, Running: 1, Cancelled: 1}, model.BatchRunStatusRunning},
+		{BatchRunCounts{Total: 2, Succeeded: 1, Failed: 1}, model.BatchRunStatusCompleted},
+		{BatchRunCounts{Total: 2, Succeeded: 1, Cancelled: 1}, model.BatchRunStatusCancelled},
+	}
+	for _, tt := range tests {
+		if got := batchRunStatus(tt.counts); got != tt.want {
+			t.Errorf("batchRunStatus(%+v) = %s, want %s", tt.counts, got, tt.want)
+		}
+	}
+}
@@ -5,17 +5,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
 
+// chatLog is the logger for chat messages.
+var chatLog = logging.Component("chat")
+
 // JobEnqueuer is an interface for enqueuing background jobs.
 // This breaks the import cycle between service and jobs packages.
 type JobEnqueuer interface {
@@ -184,9 +187,9 @@ func (c *ChatService) getGitConfig(ctx context.Context) (name, email string) {
 	c.gitConfigOnce.Do(func() {
 		if c.gitService != nil {
 			c.gitUserName, c.gitUserEmail = c.gitService.GetUserConfig(ctx)
-			log.Printf("[ChatService] Cached Git user config: name=%q email=%q", c.gitUserName, c.gitUserEmail)
+			chatLog.DebugContext(ctx, "cached git user config", "name", c.gitUserName, "email", c.gitUserEmail)
 		} else {
-			log.Printf("[ChatService] Git service not available, Git headers will not be sent")
+			chatLog.WarnContext(ctx, "git service not available, git headers will not be sent")
 		}
 	})
 	return c.gitUserName, c.gitUserEmail
@@ -201,6 +204,8 @@ func (c *ChatService) getGitConfig(ctx context.Context) (name, email string) {
 // reasoning can be "enabled", "disabled", or "" for default behavior.
 // mode can be "plan" for planning mode, or "" for default (build mode).
 func (c *ChatService) SendToSandbox(ctx context.Context, projectID, sessionID string, messages json.RawMessage, requestModel string, reasoning string, mode string) (<-chan SSELine, error) {
+	ctx = withSession(ctx, sessionID)
+
 	// Validate session belongs to project and get session for model
 	session, err := c.GetSession(ctx, projectID, sessionID)
 	if err != nil {
@@ -211,7 +216,7 @@ func (c *ChatService) SendToSandbox(ctx context.Context, projectID, sessionID st
 	if requestModel != "" {
 		session.Model = &requestModel
 		if err := c.store.UpdateSession(ctx, session); err != nil {
-			log.Printf("Warning: failed to update session model for %s: %v", sessionID, err)
+			chatLog.WarnContext(ctx, "failed to update session model", "error", err)
 		}
 	}
 
@@ -221,7 +226,7 @@ func (c *ChatService) SendToSandbox(ctx context.Context, projectID, sessionID st
 	if reasoning != "" {
 		session.Reasoning = &reasoning
 		if err := c.store.UpdateSession(ctx, session); err != nil {
-			log.Printf("Warning: failed to update session reasoning for %s: %v", sessionID, err)
+			chatLog.WarnContext(ctx, "failed to update session reasoning", "error", err)
 		}
 	} else if session.Reasoning != nil {
 		// Use session's saved reasoning if no reasoning provided in request
@@ -234,7 +239,7 @@ func (c *ChatService) SendToSandbox(ctx context.Context, projectID, sessionID st
 	if mode != "" {
 		session.Mode = &mode
 		if err := c.store.UpdateSession(ctx, session); err != nil {
-			log.Printf("Warning: failed to update session mode for %s: %v", sessionID, err)
+			chatLog.WarnContext(ctx, "failed to update session mode", "error", err)
 		}
 	} else if session.Mode != nil {
 		effectiveMode = *session.Mode
@@ -252,7 +257,7 @@ func (c *ChatService) SendToSandbox(ctx context.Context, projectID, sessionID st
 	// Set session status to running before starting chat
 	// UpdateStatus now automatically publishes SSE event
 	if _, err := c.sessionService.UpdateStatus(ctx, projectID, sessionID, model.SessionStatusRunning, nil); err != nil {
-		log.Printf("Warning: failed to update session status to running for %s: %v", sessionID, err)
+		chatLog.WarnContext(ctx, "failed to update session status to running", "error", err)
 	}
 	// (in the handler) to ensure the agent API has received the request
 	// before we start polling for status.
@@ -4,18 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/obot-platform/discobot/server/internal/config"
 	"github.com/obot-platform/discobot/server/internal/encryption"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/oauth"
 	"github.com/obot-platform/discobot/server/internal/providers"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
 
+// credentialLog is the logger for provider credential messages.
+var credentialLog = logging.Component("credentials")
+
 // Supported providers
 const (
 	ProviderAnthropic     = "anthropic"
@@ -274,15 +277,15 @@ func (s *CredentialService) GetOAuthTokens(ctx context.Context, projectID, provi
 
 			// If we failed within the last 5 minutes, don't try again
 			if hasFailed && time.Since(lastFail) < 5*time.Minute {
-				log.Printf("Token for provider %s is expired, but skipping refresh (last attempt failed %v ago)",
-					provider, time.Since(lastFail).Round(time.Second))
+				credentialLog.InfoContext(ctx, "token expired, skipping refresh after recent failure",
+					"provider", provider, "last_failure_ago", time.Since(lastFail).Round(time.Second))
 				return &tokens, nil
 			}
 
-			log.Printf("Token for provider %s is expired, attempting refresh", provider)
+			credentialLog.InfoContext(ctx, "token expired, attempting refresh", "provider", provider)
 			refreshed, err := s.RefreshOAuthTokens(ctx, projectID, provider)
 			if err != nil {
-				log.Printf("Failed to refresh token for provider %s: %v", provider, err)
+				credentialLog.WarnContext(ctx, "failed to refresh token", "provider", provider, "error", err)
 				// Record the failure time
 				s.refreshFailMutex.Lock()
 				s.lastRefreshFail[provider] = time.Now()
@@ -294,10 +297,10 @@ func (s *CredentialService) GetOAuthTokens(ctx context.Context, projectID, provi
 			s.refreshFailMutex.Lock()
 			delete(s.lastRefreshFail, provider)
 			s.refreshFailMutex.Unlock()
-			log.Printf("Successfully refreshed token for provider %s", provider)
+			credentialLog.InfoContext(ctx, "refreshed token", "provider", provider)
 			return refreshed, nil
 		}
-		log.Printf("Token for provider %s is expired but no refresh token available", provider)
+		credentialLog.WarnContext(ctx, "token expired and no refresh token available", "provider", provider)
 	}
 
 	return &tokens, nil
@@ -391,7 +394,7 @@ func (s *CredentialService) GetAllDecrypted(ctx context.Context, projectID strin
 		// Get env var names for this provider
 		envVars := providers.GetEnvVars(c.Provider)
 		if len(envVars) == 0 {
-			log.Printf("Warning: No env var configured for provider %s, skipping", c.Provider)
+			credentialLog.WarnContext(ctx, "no env var configured for provider, skipping", "provider", c.Provider)
 			continue
 		}
 
@@ -414,7 +417,7 @@ func (s *CredentialService) GetAllDecrypted(ctx context.Context, projectID strin
 			tokens, err := s.GetOAuthTokens(ctx, projectID, c.Provider)
 			if err != nil {
 				// Skip credentials that fail to decrypt or refresh
-				log.Printf("Warning: Failed to get OAuth tokens for provider %s: %v", c.Provider, err)
+				credentialLog.WarnContext(ctx, "failed to get OAuth tokens", "provider", c.Provider, "error", err)
 				continue
 			}
 			// Use OAuth-specific env var if defined, otherwise fall back to provider's first env var
@@ -113,6 +113,26 @@ func (s *GitService) ApplyPatches(ctx context.Context, workspaceID string, patch
 	return s.provider.ApplyPatches(ctx, workspaceID, patches)
 }
 
+// SelectPatches rewrites an mbox patch series according to a selection.
+func (s *GitService) SelectPatches(ctx context.Context, workspaceID, baseCommit string, patches []byte, selection git.PatchSelection) ([]byte, error) {
+	return s.provider.SelectPatches(ctx, workspaceID, baseCommit, patches, selection)
+}
+
+// ComparePatches diffs the results of applying two patch series.
+func (s *GitService) ComparePatches(ctx context.Context, workspaceID string, a, b git.PatchSeries) ([]git.FileDiff, error) {
+	return s.provider.ComparePatches(ctx, workspaceID, a, b)
+}
+
+// CompareUpstream compares a commit against the workspace's tracked branch.
+func (s *GitService) CompareUpstream(ctx context.Context, workspaceID, ref string) (*git.UpstreamStatus, error) {
+	return s.provider.CompareUpstream(ctx, workspaceID, ref)
+}
+
+// FastForward fast-forwards the workspace to its upstream branch.
+func (s *GitService) FastForward(ctx context.Context, workspaceID string) (string, error) {
+	return s.provider.FastForward(ctx, workspaceID)
+}
+
 // Provider returns the underlying git provider.
 // This allows direct access for advanced operations.
 func (s *GitService) Provider() git.Provider {
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+// headlessLog is the logger for headless session and run messages.
+var headlessLog = logging.Component("headless")
+
+// askUserQuestionTool is the tool the agent calls to ask the user clarifying
+// questions. The agent blocks until they are answered.
+const askUserQuestionTool = "AskUserQuestion"
+
+// headlessFreeTextAnswer answers questions without options when automatic
+// answering has no configured default answer.
+const headlessFreeTextAnswer = "Use your best judgement and continue."
+
+// headlessQuestionPollInterval is how often a headless run checks whether a
+// question announced in the stream is ready to be answered. A variable so
+// tests can shorten it.
+var headlessQuestionPollInterval = 250 * time.Millisecond
+
+// HeadlessRunRequest describes a session that runs a single prompt to
+// completion without a client attached.
+type HeadlessRunRequest struct {
+	ProjectID   string
+	WorkspaceID string
+	AgentID     string
+	Model       string
+	Reasoning   string
+	Name        string
+	Prompt      string
+
+	// QuestionPolicy is set as the session's question policy. If nil, the
+	// workspace's applies (see headlessQuestionPolicy).
+	QuestionPolicy *model.QuestionPolicy
+	// AllowHookFailures keeps failed hooks from failing the run.
+	AllowHookFailures bool
+
+	// OnSessionCreated is called once the session exists, before it is initialized.
+	OnSessionCreated func(sessionID string)
+}
+
+// RunHeadless creates a session, initializes it through the session_init job,
+// sends the prompt, and waits for the agent to finish. Questions are answered by the session's question
+// policy. The run fails if the agent reports an error, a question is asked
+// under the fail policy, or any hook fails unless
+// AllowHookFailures is set. The returned session ID is set whenever the session was created,
+// even if a later step failed.
+func (c *ChatService) RunHeadless(ctx context.Context, req HeadlessRunRequest) (string, error) {
+	messages, err := buildCommitMessage(uuid.New().String(), req.Prompt)
+	if err != nil {
+		return "", err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = deriveSessionName(messages)
+	}
+
+	sessionID := uuid.New().String()
+	if _, err := c.sessionService.CreateSessionWithID(ctx, sessionID, req.ProjectID, req.WorkspaceID, name, req.AgentID, req.Model, req.Reasoning, ""); err != nil {
+		return "", err
+	}
+	if req.OnSessionCreated != nil {
+		req.OnSessionCreated(sessionID)
+	}
+	if req.QuestionPolicy != nil {
+		data, err := encodeQuestionPolicy(req.QuestionPolicy)
+		if err != nil {
+			return sessionID, err
+		}
+		if err := c.store.UpdateSessionQuestionPolicy(ctx, sessionID, data); err != nil {
+			return sessionID, fmt.Errorf("failed to set question policy: %w", err)
+		}
+	}
+
+	if err := c.sessionService.InitializeAndWait(ctx, req.ProjectID, sessionID, req.WorkspaceID, req.AgentID, c.jobEnqueuer); err != nil {
+		return sessionID, fmt.Errorf("session initialization failed: %w", err)
+	}
+
+	streamCh, err := c.SendToSandbox(ctx, req.ProjectID, sessionID, messages, "", "", "")
+	if err != nil {
+		return sessionID, fmt.Errorf("failed to send prompt: %w", err)
+	}
+	var streamErr error
+	for line := range streamCh {
+		if line.Done {
+			break
+		}
+		if err := line.AgentError(); err != nil {
+			streamErr = err
+		}
+		if streamErr == nil && strings.Contains(line.Data, `"type":"tool-input-available"`) {
+			// Keep draining the stream after a failure so the sender isn't blocked
+			if err := c.answerHeadlessQuestion(ctx, req.ProjectID, sessionID, line.Data); err != nil {
+				streamErr = err
+				if _, err := c.CancelCompletion(context.WithoutCancel(ctx), req.ProjectID, sessionID); err != nil {
+					headlessLog.WarnContext(withSession(ctx, sessionID), "failed to cancel completion", "error", err)
+				}
+			}
+		}
+	}
+
+	// Nobody is watching this completion, so flip the session back to ready ourselves
+	if _, err := c.sessionService.UpdateStatus(ctx, req.ProjectID, sessionID, model.SessionStatusReady, nil); err != nil {
+		headlessLog.WarnContext(withSession(ctx, sessionID), "failed to update session status to ready", "error", err)
+	}
+	if streamErr != nil {
+		return sessionID, streamErr
+	}
+	if err := ctx.Err(); err != nil {
+		return sessionID, fmt.Errorf("completion did not finish: %w", err)
+	}
+
+	if req.AllowHookFailures {
+		return sessionID, nil
+	}
+
+	// If hook status is unavailable the agent's own result is trusted
+	if status, err := c.GetHooksStatus(ctx, req.ProjectID, sessionID); err != nil {
+		headlessLog.WarnContext(withSession(ctx, sessionID), "failed to get hooks status", "error", err)
+	} else if hooks := summarizeHooks(status); hooks.Failed > 0 {
+		return sessionID, fmt.Errorf("%d of %d hooks failed", hooks.Failed, hooks.Total)
+	}
+
+	return sessionID, nil
+}
+
+// answerHeadlessQuestion answers an AskUserQuestion tool call announced by a
+// stream chunk according to the session's question policy (see
+// headlessQuestionPolicy). Under manual the question is left for a user.
+// Other chunks are ignored.
+func (c *ChatService) answerHeadlessQuestion(ctx context.Context, projectID, sessionID, data string) error {
+	var chunk struct {
+		Type       string `json:"type"`
+		ToolName   string `json:"toolName"`
+		ToolCallID string `json:"toolCallId"`
+	}
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil || chunk.ToolName != askUserQuestionTool {
+		return nil
+	}
+
+	policy, err := headlessQuestionPolicy(ctx, c.store, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve question policy: %w", err)
+	}
+	if policy.Mode == model.QuestionPolicyManual {
+		return nil
+	}
+
+	// The stream can announce the tool call before the agent registers the
+	// question, so wait briefly for it to appear.
+	var question *sandboxapi.PendingQuestion
+	for attempt := 0; attempt < 20; attempt++ {
+		resp, err := c.GetQuestion(ctx, projectID, sessionID, chunk.ToolCallID)
+		if err != nil {
+			return fmt.Errorf("failed to get pending question: %w", err)
+		}
+		if resp.Status == "answered" {
+			return nil
+		}
+		if resp.Question != nil {
+			question = resp.Question
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(headlessQuestionPollInterval):
+		}
+	}
+	if question == nil {
+		return fmt.Errorf("agent asked a question that never became available to answer")
+	}
+
+	if policy.Mode == model.QuestionPolicyFail {
+		texts := make([]string, len(question.Questions))
+		for i, q := range question.Questions {
+			texts[i] = q.Question
+		}
+		return fmt.Errorf("agent asked a question: %s", strings.Join(texts, "; "))
+	}
+
+	answers := policyAnswers(policy, question)
+	if _, err := c.AnswerQuestion(ctx, projectID, sessionID, &sandboxapi.AnswerQuestionRequest{
+		ToolUseID: question.ToolUseID,
+		Answers:   answers,
+	}); err != nil {
+		// The session status poller may have answered it first
+		if resp, getErr := c.GetQuestion(ctx, projectID, sessionID, question.ToolUseID); getErr == nil && resp.Status == "answered" {
+			return nil
+		}
+		return fmt.Errorf("failed to answer question: %w", err)
+	}
+	ctx = withSession(ctx, sessionID)
+	headlessLog.InfoContext(ctx, "answered question", "tool_use_id", question.ToolUseID, "policy", policy.Mode, "answers", answers)
+	if c.eventBroker != nil {
+		if err := c.eventBroker.PublishQuestionAutoAnswered(ctx, projectID, events.QuestionAutoAnsweredData{
+			SessionID: sessionID,
+			ToolUseID: question.ToolUseID,
+			Policy:    policy.Mode,
+			Answers:   answers,
+		}); err != nil {
+			headlessLog.WarnContext(ctx, "failed to publish question auto-answered event", "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// ErrInvalidHeadlessRun is returned when a headless run request fails validation.
+var ErrInvalidHeadlessRun = errors.New("invalid headless run")
+
+// ErrHeadlessRunNotFound is returned when a headless run does not exist in the
+// requested project.
+var ErrHeadlessRunNotFound = errors.New("headless run not found")
+
+// headlessRunHistoryLimit is how many runs ListHeadlessRuns returns.
+const headlessRunHistoryLimit = 50
+
+// headlessRunCollectTimeout bounds how long collecting a finished run's
+// results may take, including after the run itself timed out.
+const headlessRunCollectTimeout = time.Minute
+
+// headlessRunPollInterval is how often WaitHeadlessRun and hook settling
+// re-check state. A variable so tests can shorten it.
+var headlessRunPollInterval = time.Second
+
+// CreateHeadlessRunRequest contains the parameters for a headless run.
+type CreateHeadlessRunRequest struct {
+	WorkspaceID        string `json:"workspaceId"`
+	AgentID            string `json:"agentId,omitempty"` // Defaults to the project's default agent
+	Model              string `json:"model,omitempty"`
+	Reasoning          string `json:"reasoning,omitempty"`
+	Prompt             string `json:"prompt"`
+	AnswerPolicy       string `json:"answerPolicy,omitempty"`       // Question policy mode for the session; defaults to the workspace's
+	MaxDurationSeconds int    `json:"maxDurationSeconds,omitempty"` // Defaults to, and may not exceed, the server's job timeout
+	RequireHooks       *bool  `json:"requireHooks,omitempty"`       // Defaults to true
+	Commit             bool   `json:"commit,omitempty"`             // Commit changes to the workspace on success
+}
+
+// HeadlessRunResult is the outcome of a finished headless run. Each part is
+// collected best effort; collection failures are listed in Errors.
+type HeadlessRunResult struct {
+	Diff        *sandboxapi.DiffStats               `json:"diff,omitempty"`
+	Hooks       *HookSummary                        `json:"hooks,omitempty"`
+	HookResults map[string]sandboxapi.HookRunStatus `json:"hookResults,omitempty"` // Keyed by hook ID
+	Usage       *TokenUsage                         `json:"usage,omitempty"`
+	Patch       string                              `json:"patch,omitempty"`     // Unified diff against the session's base commit
+	CommitSHA   string                              `json:"commitSha,omitempty"` // Set when the run committed its changes
+	Errors      []string                            `json:"errors,omitempty"`
+}
+
+// HeadlessRun is a headless run with its decoded result.
+type HeadlessRun struct {
+	*model.HeadlessRun
+	Result *HeadlessRunResult `json:"result,omitempty"`
+}
+
+// HeadlessRunService manages headless runs.
+type HeadlessRunService struct {
+	store          *store.Store
+	sessionService *SessionService
+	chatService    *ChatService
+	jobEnqueuer    JobEnqueuer
+	maxDuration    time.Duration
+}
+
+// NewHeadlessRunService creates a new headless run service. maxDuration caps
+// how long a run may take and should not exceed the dispatcher's job timeout.
+func NewHeadlessRunService(s *store.Store, sessionService *SessionService, chatService *ChatService, jobEnqueuer JobEnqueuer, maxDuration time.Duration) *HeadlessRunService {
+	return &HeadlessRunService{
+		store:          s,
+		sessionService: sessionService,
+		chatService:    chatService,
+		jobEnqueuer:    jobEnqueuer,
+		maxDuration:    maxDuration,
+	}
+}
+
+// CreateHeadlessRun validates the request, records the run and enqueues its job.
+func (h *HeadlessRunService) CreateHeadlessRun(ctx context.Context, projectID string, req CreateHeadlessRunRequest) (*HeadlessRun, error) {
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		return nil, fmt.Errorf("%w: prompt is required", ErrInvalidHeadlessRun)
+	}
+
+	workspace, err := h.store.GetWorkspaceByID(ctx, req.WorkspaceID)
+	if err != nil || workspace.ProjectID != projectID {
+		return nil, fmt.Errorf("%w: workspace %q not found", ErrInvalidHeadlessRun, req.WorkspaceID)
+	}
+
+	agentID := req.AgentID
+	if agentID == "" {
+		agent, err := h.store.GetDefaultAgent(ctx, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: no agent given and no default agent is configured", ErrInvalidHeadlessRun)
+		}
+		agentID = agent.ID
+	} else if agent, err := h.store.GetAgentByID(ctx, agentID); err != nil || agent.ProjectID != projectID {
+		return nil, fmt.Errorf("%w: agent %q not found", ErrInvalidHeadlessRun, agentID)
+	}
+
+	if req.Reasoning != "" && req.Reasoning != "enabled" && req.Reasoning != "disabled" {
+		return nil, fmt.Errorf("%w: reasoning must be \"enabled\", \"disabled\" or empty", ErrInvalidHeadlessRun)
+	}
+
+	if req.AnswerPolicy != "" {
+		switch req.AnswerPolicy {
+		case model.QuestionPolicyFirstOption, model.QuestionPolicyRecommended, model.QuestionPolicyFail:
+		default:
+			return nil, fmt.Errorf("%w: answerPolicy must be %q, %q, %q or empty", ErrInvalidHeadlessRun,
+				model.QuestionPolicyFirstOption, model.QuestionPolicyRecommended, model.QuestionPolicyFail)
+		}
+	}
+
+	limit := int(h.maxDuration / time.Second)
+	maxDuration := req.MaxDurationSeconds
+	if maxDuration == 0 {
+		maxDuration = limit
+	}
+	if maxDuration < 1 || maxDuration > limit {
+		return nil, fmt.Errorf("%w: maxDurationSeconds must be between 1 and %d", ErrInvalidHeadlessRun, limit)
+	}
+
+	requireHooks := true
+	if req.RequireHooks != nil {
+		requireHooks = *req.RequireHooks
+	}
+
+	run := &model.HeadlessRun{
+		ProjectID:    projectID,
+		WorkspaceID:  workspace.ID,
+		AgentID:      agentID,
+		Model:        nonEmptyPtr(req.Model),
+		Reasoning:    nonEmptyPtr(req.Reasoning),
+		Prompt:       prompt,
+		AnswerPolicy: req.AnswerPolicy,
+		MaxDuration:  maxDuration,
+		RequireHooks: requireHooks,
+		Commit:       req.Commit,
+		Status:       model.HeadlessRunStatusPending,
+	}
+	if err := h.store.CreateHeadlessRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to create headless run: %w", err)
+	}
+
+	if err := h.jobEnqueuer.Enqueue(ctx, jobs.HeadlessRunPayload{ProjectID: projectID, RunID: run.ID}); err != nil {
+		h.finishRun(ctx, run, model.HeadlessRunStatusFailed, nil, fmt.Errorf("failed to enqueue: %w", err))
+	}
+	return mapHeadlessRun(run), nil
+}
+
+// GetHeadlessRun returns a headless run and validates it belongs to the project.
+func (h *HeadlessRunService) GetHeadlessRun(ctx context.Context, projectID, runID string) (*HeadlessRun, error) {
+	run, err := h.getRun(ctx, projectID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return mapHeadlessRun(run), nil
+}
+
+// ListHeadlessRuns returns the project's most recent headless runs, newest first.
+func (h *HeadlessRunService) ListHeadlessRuns(ctx context.Context, projectID string) ([]*HeadlessRun, error) {
+	runs, err := h.store.ListHeadlessRunsByProject(ctx, projectID, headlessRunHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list headless runs: %w", err)
+	}
+	result := make([]*HeadlessRun, len(runs))
+	for i, run := range runs {
+		result[i] = mapHeadlessRun(run)
+	}
+	return result, nil
+}
+
+// WaitHeadlessRun returns the run once it has finished, or its current state
+// after wait has elapsed. Callers check IsTerminal to tell the two apart.
+func (h *HeadlessRunService) WaitHeadlessRun(ctx context.Context, projectID, runID string, wait time.Duration) (*HeadlessRun, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		run, err := h.getRun(ctx, projectID, runID)
+		if err != nil {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if run.IsTerminal() || remaining <= 0 {
+			return mapHeadlessRun(run), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return mapHeadlessRun(run), nil
+		case <-time.After(min(headlessRunPollInterval, remaining)):
+		}
+	}
+}
+
+func (h *HeadlessRunService) getRun(ctx context.Context, projectID, runID string) (*model.HeadlessRun, error) {
+	run, err := h.store.GetHeadlessRunByID(ctx, runID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrHeadlessRunNotFound
+		}
+		return nil, err
+	}
+	if run.ProjectID != projectID {
+		return nil, ErrHeadlessRunNotFound
+	}
+	return run, nil
+}
+
+// RunHeadlessRun executes a headless run synchronously: it runs the prompt in
+// a new session within the run's max duration, waits for hooks to settle,
+// optionally commits, and records the outcome. This is called by the
+// dispatcher when processing a headless_run job.
+func (h *HeadlessRunService) RunHeadlessRun(ctx context.Context, projectID, runID string) error {
+	started, err := h.store.StartHeadlessRun(ctx, runID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to start headless run: %w", err)
+	}
+	run, err := h.getRun(ctx, projectID, runID)
+	if err != nil {
+		return err
+	}
+	if !started {
+		if run.Status == model.HeadlessRunStatusRunning {
+			// A previous attempt died mid-run; don't send the prompt a second time
+			h.finishRun(ctx, run, model.HeadlessRunStatusFailed, nil, fmt.Errorf("interrupted before completion"))
+		}
+		return nil
+	}
+
+	result, runErr := h.execute(ctx, run)
+	status := model.HeadlessRunStatusSucceeded
+	switch {
+	case errors.Is(runErr, context.DeadlineExceeded):
+		status = model.HeadlessRunStatusTimedOut
+	case runErr != nil:
+		status = model.HeadlessRunStatusFailed
+	}
+	h.finishRun(ctx, run, status, result, runErr)
+	return runErr
+}
+
+// execute does the work for RunHeadlessRun. The result is returned whenever a
+// session was created, even if the run failed.
+func (h *HeadlessRunService) execute(ctx context.Context, run *model.HeadlessRun) (*HeadlessRunResult, error) {
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(run.MaxDuration)*time.Second)
+	defer cancel()
+
+	sessionID, runErr := h.chatService.RunHeadless(runCtx, HeadlessRunRequest{
+		ProjectID:         run.ProjectID,
+		WorkspaceID:       run.WorkspaceID,
+		AgentID:           run.AgentID,
+		Model:             ptrToString(run.Model),
+		Reasoning:         ptrToString(run.Reasoning),
+		Prompt:            run.Prompt,
+		QuestionPolicy:    runQuestionPolicy(run),
+		AllowHookFailures: true,
+		OnSessionCreated: func(sessionID string) {
+			run.SessionID = &sessionID
+			if err := h.store.UpdateHeadlessRun(ctx, run); err != nil {
+				headlessLog.ErrorContext(withSession(ctx, sessionID), "failed to record session for headless run", "run_id", run.ID, "error", err)
+			}
+		},
+	})
+	if sessionID == "" {
+		return nil, runErr
+	}
+
+	if runErr != nil && runCtx.Err() != nil {
+		// Stop the agent so a timed out run doesn't keep working
+		if _, err := h.chatService.CancelCompletion(context.WithoutCancel(ctx), run.ProjectID, sessionID); err != nil {
+			headlessLog.WarnContext(withSession(ctx, sessionID), "failed to cancel completion for headless run", "run_id", run.ID, "error", err)
+		}
+		runErr = fmt.Errorf("run exceeded its max duration of %ds: %w", run.MaxDuration, context.DeadlineExceeded)
+	}
+
+	if runErr == nil {
+		if hooks, err := h.settleHooks(runCtx, run.ProjectID, sessionID); err != nil {
+			runErr = fmt.Errorf("hooks did not finish: %w", err)
+		} else if run.RequireHooks && hooks.Failed > 0 {
+			runErr = fmt.Errorf("%d of %d hooks failed", hooks.Failed, hooks.Total)
+		}
+	}
+
+	// Results are collected even for failed or timed out runs
+	collectCtx, cancelCollect := context.WithTimeout(context.WithoutCancel(ctx), headlessRunCollectTimeout)
+	defer cancelCollect()
+	result := h.collectResult(collectCtx, run.ProjectID, sessionID)
+
+	if runErr == nil && run.Commit {
+		commit, err := h.sessionService.CommitAndWait(runCtx, run.ProjectID, sessionID, h.jobEnqueuer)
+		if err != nil {
+			return result, err
+		}
+		result.CommitSHA = ptrToString(commit)
+	}
+	return result, runErr
+}
+
+// settleHooks waits until no hooks are running or pending and returns their
+// summary.
+func (h *HeadlessRunService) settleHooks(ctx context.Context, projectID, sessionID string) (*HookSummary, error) {
+	for {
+		status, err := h.chatService.GetHooksStatus(ctx, projectID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		summary := summarizeHooks(status)
+		if summary.Running == 0 && summary.Pending == 0 {
+			return summary, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(headlessRunPollInterval):
+		}
+	}
+}
+
+// collectResult gathers the diff, hook results and token usage of a session.
+func (h *HeadlessRunService) collectResult(ctx context.Context, projectID, sessionID string) *HeadlessRunResult {
+	result := &HeadlessRunResult{}
+
+	if diff, err := h.chatService.GetDiff(ctx, projectID, sessionID, "", ""); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("diff: %v", err))
+	} else if diff, ok := diff.(*sandboxapi.DiffResponse); ok {
+		result.Diff = &diff.Stats
+		var patch strings.Builder
+		for _, f := range diff.Files {
+			patch.WriteString(f.Patch)
+			if f.Patch != "" && !strings.HasSuffix(f.Patch, "\n") {
+				patch.WriteString("\n")
+			}
+		}
+		result.Patch = patch.String()
+	}
+
+	if status, err := h.chatService.GetHooksStatus(ctx, projectID, sessionID); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("hooks: %v", err))
+	} else {
+		result.Hooks = summarizeHooks(status)
+		result.HookResults = status.Hooks
+	}
+
+	if messages, err := h.chatService.GetMessages(ctx, projectID, sessionID); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("usage: %v", err))
+	} else {
+		result.Usage = sumTokenUsage(messages)
+	}
+
+	return result
+}
+
+// finishRun records a run's outcome.
+func (h *HeadlessRunService) finishRun(ctx context.Context, run *model.HeadlessRun, status string, result *HeadlessRunResult, runErr error) {
+	// Use a fresh context so a timed out run is still recorded
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	run.Status = status
+	run.CompletedAt = &now
+	run.Error = nil
+	if runErr != nil {
+		run.Error = ptrString(runErr.Error())
+		headlessLog.InfoContext(ctx, "headless run finished", "run_id", run.ID, "status", status, "error", runErr)
+	}
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			headlessLog.ErrorContext(ctx, "failed to encode headless run result", "run_id", run.ID, "error", err)
+		} else {
+			run.Result = data
+		}
+	}
+	if err := h.store.UpdateHeadlessRun(ctx, run); err != nil {
+		headlessLog.ErrorContext(ctx, "failed to update headless run", "run_id", run.ID, "error", err)
+	}
+}
+
+// mapHeadlessRun converts a model headless run to the service type.
+func mapHeadlessRun(run *model.HeadlessRun) *HeadlessRun {
+	result := &HeadlessRun{HeadlessRun: run}
+	if len(run.Result) > 0 {
+		var decoded HeadlessRunResult
+		if err := json.Unmarshal(run.Result, &decoded); err != nil {
+			headlessLog.Warn("failed to decode headless run result", "run_id", run.ID, "error", err)
+		} else {
+			result.Result = &decoded
+		}
+	}
+	return result
+}
+
+// runQuestionPolicy returns the question policy a run sets on its session, or
+// nil to inherit the workspace's.
+func runQuestionPolicy(run *model.HeadlessRun) *model.QuestionPolicy {
+	if run.AnswerPolicy == "" {
+		return nil
+	}
+	return &model.QuestionPolicy{Mode: run.AnswerPolicy}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+// newTestHeadlessRunService returns a headless run service with a 30 minute
+// limit whose job queue is controlled by enqueueErr and records enqueued
+// payloads.
+func newTestHeadlessRunService(env *testEnv, enqueueErr error) (*HeadlessRunService, *[]jobs.HeadlessRunPayload) {
+	var enqueued []jobs.HeadlessRunPayload
+	enqueuer := &mockJobEnqueuer{
+		enqueueFunc: func(_ context.Context, payload jobs.JobPayload) error {
+			if enqueueErr != nil {
+				return enqueueErr
+			}
+			if p, ok := payload.(jobs.HeadlessRunPayload); ok {
+				enqueued = append(enqueued, p)
+			}
+			return nil
+		},
+	}
+	return NewHeadlessRunService(env.store, nil, nil, enqueuer, 30*time.Minute), &enqueued
+}
+
+func TestCreateHeadlessRun(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, enqueued := newTestHeadlessRunService(env, nil)
+	ctx := context.Background()
+
+	run, err := svc.CreateHeadlessRun(ctx, project.ID, CreateHeadlessRunRequest{
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		Prompt:      "  Fix the build  ",
+	})
+	if err != nil {
+		t.Fatalf("CreateHeadlessRun failed: %v", err)
+	}
+	if run.Status != model.HeadlessRunStatusPending || run.Prompt != "Fix the build" {
+		t.Errorf("Unexpected run %+v", run.HeadlessRun)
+	}
+	if run.AnswerPolicy != "" || run.MaxDuration != 1800 || !run.RequireHooks {
+		t.Errorf("Expected defaults inherited policy/1800/requireHooks, got %q/%d/%v", run.AnswerPolicy, run.MaxDuration, run.RequireHooks)
+	}
+	if len(*enqueued) != 1 || (*enqueued)[0].RunID != run.ID {
+		t.Fatalf("Expected one job for run %s, got %+v", run.ID, *enqueued)
+	}
+
+	// RequireHooks false must survive the round trip
+	requireHooks := false
+	run, err = svc.CreateHeadlessRun(ctx, project.ID, CreateHeadlessRunRequest{
+		WorkspaceID:  workspace.ID,
+		AgentID:      agent.ID,
+		Prompt:       "p",
+		RequireHooks: &requireHooks,
+	})
+	if err != nil {
+		t.Fatalf("CreateHeadlessRun failed: %v", err)
+	}
+	stored, err := svc.GetHeadlessRun(ctx, project.ID, run.ID)
+	if err != nil {
+		t.Fatalf("GetHeadlessRun failed: %v", err)
+	}
+	if stored.RequireHooks {
+		t.Error("Expected requireHooks to be false")
+	}
+
+	if _, err := svc.GetHeadlessRun(ctx, "other-project", run.ID); !errors.Is(err, ErrHeadlessRunNotFound) {
+		t.Errorf("Expected ErrHeadlessRunNotFound for another project, got %v", err)
+	}
+}
+
+func TestCreateHeadlessRun_Validation(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestHeadlessRunService(env, nil)
+
+	valid := CreateHeadlessRunRequest{WorkspaceID: workspace.ID, AgentID: agent.ID, Prompt: "p"}
+	tests := []struct {
+		name   string
+		modify func(*CreateHeadlessRunRequest)
+	}{
+		{"missing prompt", func(r *CreateHeadlessRunRequest) { r.Prompt = " " }},
+		{"unknown workspace", func(r *CreateHeadlessRunRequest) { r.WorkspaceID = "missing" }},
+		{"unknown agent", func(r *CreateHeadlessRunRequest) { r.AgentID = "missing" }},
+		{"invalid reasoning", func(r *CreateHeadlessRunRequest) { r.Reasoning = "maximum" }},
+		{"invalid answer policy", func(r *CreateHeadlessRunRequest) { r.AnswerPolicy = "ask_me" }},
+		{"manual answer policy", func(r *CreateHeadlessRunRequest) { r.AnswerPolicy = model.QuestionPolicyManual }},
+		{"negative duration", func(r *CreateHeadlessRunRequest) { r.MaxDurationSeconds = -1 }},
+		{"duration over limit", func(r *CreateHeadlessRunRequest) { r.MaxDurationSeconds = 3600 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			if _, err := svc.CreateHeadlessRun(context.Background(), project.ID, req); !errors.Is(err, ErrInvalidHeadlessRun) {
+				t.Errorf("Expected ErrInvalidHeadlessRun, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateHeadlessRun_EnqueueFailure(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestHeadlessRunService(env, errors.New("queue unavailable"))
+
+	run, err := svc.CreateHeadlessRun(context.Background(), project.ID, CreateHeadlessRunRequest{
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		Prompt:      "p",
+	})
+	if err != nil {
+		t.Fatalf("CreateHeadlessRun failed: %v", err)
+	}
+	if run.Status != model.HeadlessRunStatusFailed || run.Error == nil || run.CompletedAt == nil {
+		t.Errorf("Expected failed run, got %+v", run.HeadlessRun)
+	}
+}
+
+func TestRunHeadlessRun_Interrupted(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestHeadlessRunService(env, nil)
+	ctx := context.Background()
+
+	run, err := svc.CreateHeadlessRun(ctx, project.ID, CreateHeadlessRunRequest{
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		Prompt:      "p",
+	})
+	if err != nil {
+		t.Fatalf("CreateHeadlessRun failed: %v", err)
+	}
+
+	// Simulate a worker that died after starting the run
+	if started, err := env.store.StartHeadlessRun(ctx, run.ID, time.Now()); err != nil || !started {
+		t.Fatalf("StartHeadlessRun failed: started=%v err=%v", started, err)
+	}
+
+	if err := svc.RunHeadlessRun(ctx, project.ID, run.ID); err != nil {
+		t.Fatalf("RunHeadlessRun failed: %v", err)
+	}
+
+	stored, err := env.store.GetHeadlessRunByID(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("Failed to get run: %v", err)
+	}
+	if stored.Status != model.HeadlessRunStatusFailed || stored.CompletedAt == nil {
+		t.Errorf("Expected interrupted run to be failed and completed, got %+v", stored)
+	}
+}
+
+func TestWaitHeadlessRun(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	orig := headlessRunPollInterval
+	headlessRunPollInterval = 10 * time.Millisecond
+	defer func() { headlessRunPollInterval = orig }()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestHeadlessRunService(env, nil)
+	ctx := context.Background()
+
+	created, err := svc.CreateHeadlessRun(ctx, project.ID, CreateHeadlessRunRequest{
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		Prompt:      "p",
+	})
+	if err != nil {
+		t.Fatalf("CreateHeadlessRun failed: %v", err)
+	}
+
+	// Not finished within the wait: the current state is returned
+	run, err := svc.WaitHeadlessRun(ctx, project.ID, created.ID, 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitHeadlessRun failed: %v", err)
+	}
+	if run.IsTerminal() {
+		t.Fatalf("Expected pending run, got %s", run.Status)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		svc.finishRun(ctx, created.HeadlessRun, model.HeadlessRunStatusSucceeded, &HeadlessRunResult{CommitSHA: "abc123"}, nil)
+	}()
+
+	run, err = svc.WaitHeadlessRun(ctx, project.ID, created.ID, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitHeadlessRun failed: %v", err)
+	}
+	if run.Status != model.HeadlessRunStatusSucceeded {
+		t.Fatalf("Expected succeeded run, got %s", run.Status)
+	}
+	if run.Result == nil || run.Result.CommitSHA != "abc123" {
+		t.Errorf("Expected decoded result with commit SHA, got %+v", run.Result)
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/notify"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// InvitationTTL is how long an invitation stays valid after it is created or
+// resent.
+const InvitationTTL = 7 * 24 * time.Hour
+
+// ErrInvitationNotFound is returned when an invitation does not exist in the
+// requested project.
+var ErrInvitationNotFound = errors.New("invitation not found")
+
+// SetNotifier sets the notifier used to deliver invitation emails.
+func (s *ProjectService) SetNotifier(n notify.Notifier) {
+	s.notifier = n
+}
+
+// ListInvitations returns the project's pending invitations, newest first.
+// Tokens are omitted.
+func (s *ProjectService) ListInvitations(ctx context.Context, projectID string) ([]*ProjectInvitation, error) {
+	rows, err := s.store.ListInvitationsByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	invitations := make([]*ProjectInvitation, len(rows))
+	for i, row := range rows {
+		invitations[i] = toProjectInvitation(row)
+	}
+	return invitations, nil
+}
+
+// ResendInvitation extends the invitation's expiry and emails it again.
+// Unlike CreateInvitation, delivery failures are returned to the caller.
+func (s *ProjectService) ResendInvitation(ctx context.Context, projectID, invitationID, baseURL string) (*ProjectInvitation, error) {
+	inv, err := s.getInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.ExpiresAt = time.Now().Add(InvitationTTL)
+	if err := s.store.UpdateInvitation(ctx, inv); err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+	if err := s.sendInvitation(ctx, inv, baseURL); err != nil {
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+	return toProjectInvitation(inv), nil
+}
+
+// RevokeInvitation deletes a pending invitation so its token can no longer be
+// accepted.
+func (s *ProjectService) RevokeInvitation(ctx context.Context, projectID, invitationID string) (*ProjectInvitation, error) {
+	inv, err := s.getInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteInvitation(ctx, inv.ID); err != nil {
+		return nil, err
+	}
+	return toProjectInvitation(inv), nil
+}
+
+// DeleteExpiredInvitations removes invitations whose expiry has passed and
+// returns how many were deleted.
+func (s *ProjectService) DeleteExpiredInvitations(ctx context.Context) (int64, error) {
+	return s.store.DeleteExpiredInvitations(ctx, time.Now())
+}
+
+func (s *ProjectService) getInvitation(ctx context.Context, projectID, invitationID string) (*model.ProjectInvitation, error) {
+	inv, err := s.store.GetInvitationByID(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if inv.ProjectID != projectID {
+		return nil, ErrInvitationNotFound
+	}
+	return inv, nil
+}
+
+// sendInvitation emails the invitation and records when it was sent.
+func (s *ProjectService) sendInvitation(ctx context.Context, inv *model.ProjectInvitation, baseURL string) error {
+	project, err := s.store.GetProjectByID(ctx, inv.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to load project: %w", err)
+	}
+
+	data := notify.InvitationData{
+		To:          inv.Email,
+		ProjectName: project.Name,
+		Role:        inv.Role,
+		AcceptURL:   InvitationAcceptURL(baseURL, inv.ProjectID, inv.Token),
+		ExpiresAt:   inv.ExpiresAt,
+	}
+	if inv.InvitedBy != nil {
+		if inviter, err := s.store.GetUserByID(ctx, *inv.InvitedBy); err == nil {
+			data.InviterName = ptrToString(inviter.Name)
+			if data.InviterName == "" {
+				data.InviterName = inviter.Email
+			}
+		}
+	}
+
+	msg, err := notify.InvitationMessage(data)
+	if err != nil {
+		return fmt.Errorf("failed to render invitation email: %w", err)
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	inv.LastSentAt = &now
+	return s.store.UpdateInvitation(ctx, inv)
+}
+
+// InvitationAcceptURL returns the link sent to invitees. The web UI reads the
+// project and invitation query parameters and accepts the invitation once the
+// user has signed in.
+func InvitationAcceptURL(baseURL, projectID, token string) string {
+	query := url.Values{"project": {projectID}, "invitation": {token}}
+	return baseURL + "/?" + query.Encode()
+}
+
+func toProjectInvitation(inv *model.ProjectInvitation) *ProjectInvitation {
+	return &ProjectInvitation{
+		ID:         inv.ID,
+		ProjectID:  inv.ProjectID,
+		Email:      inv.Email,
+		Role:       inv.Role,
+		ExpiresAt:  inv.ExpiresAt,
+		LastSentAt: inv.LastSentAt,
+		CreatedAt:  inv.CreatedAt,
+	}
+}
+
+// InvitationCleanupMonitor periodically deletes expired invitations.
+type InvitationCleanupMonitor struct {
+	projectSvc    *ProjectService
+	logger        *slog.Logger
+	checkInterval time.Duration
+
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+	startOnce    sync.Once
+	shutdownOnce sync.Once
+}
+
+// NewInvitationCleanupMonitor creates a new invitation cleanup monitor.
+func NewInvitationCleanupMonitor(projectSvc *ProjectService, logger *slog.Logger, checkInterval time.Duration) *InvitationCleanupMonitor {
+	return &InvitationCleanupMonitor{
+		projectSvc:    projectSvc,
+		logger:        logger.With("component", "invitation_cleanup_monitor"),
+		checkInterval: checkInterval,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start cleans up once immediately and then on every check interval.
+func (m *InvitationCleanupMonitor) Start(ctx context.Context) {
+	m.startOnce.Do(func() {
+		m.wg.Add(1)
+		go m.monitorLoop(ctx)
+	})
+}
+
+// Shutdown gracefully stops the monitor.
+func (m *InvitationCleanupMonitor) Shutdown(ctx context.Context) error {
+	var err error
+	m.shutdownOnce.Do(func() {
+		close(m.stopChan)
+
+		done := make(chan struct{})
+		go func() {
+			m.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = fmt.Errorf("shutdown timeout exceeded")
+		}
+	})
+	return err
+}
+
+func (m *InvitationCleanupMonitor) monitorLoop(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		m.cleanup(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *InvitationCleanupMonitor) cleanup(ctx context.Context) {
+	deleted, err := m.projectSvc.DeleteExpiredInvitations(ctx)
+	if err != nil {
+		m.logger.Error("failed to delete expired invitations", "error", err)
+		return
+	}
+	if deleted > 0 {
+		m.logger.Info("deleted expired invitations", "deleted", deleted)
+	}
+}
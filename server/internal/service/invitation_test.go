@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/notify"
+)
+
+// recordingNotifier captures sent messages and optionally fails delivery.
+type recordingNotifier struct {
+	sent []notify.Message
+	err  error
+}
+
+func (n *recordingNotifier) Send(_ context.Context, msg notify.Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestCreateInvitationSendsEmail(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ctx := context.Background()
+
+	project := env.createTestProject(t)
+	name := "Sam"
+	inviter := &model.User{ID: "inviter", Email: "sam@example.com", Name: &name, Provider: "local", ProviderID: "sam"}
+	if err := env.store.CreateUser(ctx, inviter); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	notifier := &recordingNotifier{}
+	svc := NewProjectService(env.store, nil)
+	svc.SetNotifier(notifier)
+
+	inv, err := svc.CreateInvitation(ctx, project.ID, inviter.ID, "dev@example.com", model.RoleDeveloper, "https://discobot.example.com")
+	if err != nil {
+		t.Fatalf("CreateInvitation failed: %v", err)
+	}
+	if inv.LastSentAt == nil {
+		t.Error("Expected LastSentAt to be set after delivery")
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(notifier.sent))
+	}
+	msg := notifier.sent[0]
+	if msg.To != "dev@example.com" || !strings.Contains(msg.Subject, "Sam") || !strings.Contains(msg.Subject, project.Name) {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+	if !strings.Contains(msg.Text, InvitationAcceptURL("https://discobot.example.com", project.ID, inv.Token)) {
+		t.Errorf("Expected email to contain accept URL, got:\n%s", msg.Text)
+	}
+}
+
+func TestCreateInvitationDeliveryFailure(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ctx := context.Background()
+
+	project := env.createTestProject(t)
+	svc := NewProjectService(env.store, nil)
+	svc.SetNotifier(&recordingNotifier{err: errors.New("smtp down")})
+
+	inv, err := svc.CreateInvitation(ctx, project.ID, "inviter", "dev@example.com", model.RoleMember, "http://localhost:3001")
+	if err != nil {
+		t.Fatalf("CreateInvitation should succeed when delivery fails: %v", err)
+	}
+	if inv.LastSentAt != nil {
+		t.Error("Expected LastSentAt to be nil when delivery fails")
+	}
+
+	if _, err := svc.ResendInvitation(ctx, project.ID, inv.ID, "http://localhost:3001"); err == nil {
+		t.Error("Expected ResendInvitation to report delivery failure")
+	}
+}
+
+func TestListResendRevokeInvitation(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ctx := context.Background()
+
+	project := env.createTestProject(t)
+	notifier := &recordingNotifier{}
+	svc := NewProjectService(env.store, nil)
+	svc.SetNotifier(notifier)
+
+	inv, err := svc.CreateInvitation(ctx, project.ID, "inviter", "dev@example.com", model.RoleViewer, "http://localhost:3001")
+	if err != nil {
+		t.Fatalf("CreateInvitation failed: %v", err)
+	}
+
+	invitations, err := svc.ListInvitations(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("ListInvitations failed: %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].ID != inv.ID {
+		t.Fatalf("Expected the created invitation, got %+v", invitations)
+	}
+	if invitations[0].Token != "" {
+		t.Error("Expected ListInvitations to omit tokens")
+	}
+
+	resent, err := svc.ResendInvitation(ctx, project.ID, inv.ID, "http://localhost:3001")
+	if err != nil {
+		t.Fatalf("ResendInvitation failed: %v", err)
+	}
+	if !resent.ExpiresAt.After(inv.ExpiresAt) {
+		t.Error("Expected resend to extend the expiry")
+	}
+	if len(notifier.sent) != 2 {
+		t.Errorf("Expected 2 emails, got %d", len(notifier.sent))
+	}
+
+	if _, err := svc.RevokeInvitation(ctx, "other-project", inv.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("Expected ErrInvitationNotFound for another project, got %v", err)
+	}
+	if _, err := svc.RevokeInvitation(ctx, project.ID, inv.ID); err != nil {
+		t.Fatalf("RevokeInvitation failed: %v", err)
+	}
+	if err := svc.AcceptInvitation(ctx, inv.Token, "user"); err == nil {
+		t.Error("Expected revoked invitation to be unusable")
+	}
+}
+
+func TestDeleteExpiredInvitations(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ctx := context.Background()
+
+	project := env.createTestProject(t)
+	expired := &model.ProjectInvitation{ProjectID: project.ID, Email: "old@example.com", Role: model.RoleMember, Token: "expired", ExpiresAt: time.Now().Add(-time.Hour)}
+	pending := &model.ProjectInvitation{ProjectID: project.ID, Email: "new@example.com", Role: model.RoleMember, Token: "pending", ExpiresAt: time.Now().Add(time.Hour)}
+	for _, inv := range []*model.ProjectInvitation{expired, pending} {
+		if err := env.store.CreateInvitation(ctx, inv); err != nil {
+			t.Fatalf("Failed to create invitation: %v", err)
+		}
+	}
+
+	svc := NewProjectService(env.store, nil)
+	deleted, err := svc.DeleteExpiredInvitations(ctx)
+	if err != nil {
+		t.Fatalf("DeleteExpiredInvitations failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 deleted invitation, got %d", deleted)
+	}
+	invitations, err := svc.ListInvitations(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("ListInvitations failed: %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].Email != "new@example.com" {
+		t.Errorf("Expected only the pending invitation to remain, got %+v", invitations)
+	}
+}
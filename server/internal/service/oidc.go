@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+)
+
+// OIDCProviderName is the provider name used in login URLs and stored on
+// users that log in through the generic OIDC provider.
+const OIDCProviderName = "oidc"
+
+const (
+	// oidcClockSkew is the tolerance applied to ID token time claims.
+	oidcClockSkew = time.Minute
+	// oidcKeyRefreshInterval limits how often an unknown key ID triggers a
+	// JWKS refetch.
+	oidcKeyRefreshInterval = time.Minute
+)
+
+// oidcProvider implements login against a generic OpenID Connect issuer
+// using discovery, PKCE and ID token validation.
+type oidcProvider struct {
+	cfg        *config.Config
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// oidcDiscovery is the subset of the provider metadata document we use.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func newOIDCProvider(cfg *config.Config) *oidcProvider {
+	return &oidcProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// oidcNonce derives the nonce sent to the provider from the login state.
+// The state is already bound to the browser by a cookie, so the ID token's
+// nonce is bound to the same login attempt without storing it separately.
+func oidcNonce(state string) string {
+	hash := sha256.Sum256([]byte("oidc-nonce:" + state))
+	return hex.EncodeToString(hash[:])
+}
+
+// oauthConfig returns the OAuth2 config built from the discovery document.
+func (p *oidcProvider) oauthConfig(ctx context.Context, redirectURL string) (*oauth2.Config, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.OIDCClientID,
+		ClientSecret: p.cfg.OIDCClientSecret,
+		Scopes:       p.cfg.OIDCScopes,
+		RedirectURL:  redirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+	}, nil
+}
+
+// AuthURL returns the authorization URL with PKCE and nonce parameters.
+func (p *oidcProvider) AuthURL(ctx context.Context, redirectURL, state, verifier string) (string, error) {
+	oc, err := p.oauthConfig(ctx, redirectURL)
+	if err != nil {
+		return "", err
+	}
+	return oc.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", oidcNonce(state)),
+	), nil
+}
+
+// Exchange redeems the authorization code, validates the ID token and maps
+// its claims to a user.
+func (p *oidcProvider) Exchange(ctx context.Context, redirectURL, code, state, verifier string) (*User, error) {
+	oc, err := p.oauthConfig(ctx, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oc.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("token response did not include an id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken, oidcNonce(state))
+	if err != nil {
+		return nil, err
+	}
+
+	// Many providers keep the ID token small; fill in missing profile claims
+	// from the userinfo endpoint.
+	if p.missingMappedClaims(claims) {
+		if info, err := p.userinfo(ctx, oc, token); err == nil {
+			// The userinfo response must describe the same subject
+			if info["sub"] == claims["sub"] {
+				for k, v := range info {
+					if _, exists := claims[k]; !exists {
+						claims[k] = v
+					}
+				}
+			}
+		}
+	}
+
+	return p.userFromClaims(claims)
+}
+
+func (p *oidcProvider) missingMappedClaims(claims map[string]any) bool {
+	for _, name := range []string{p.cfg.OIDCEmailClaim, p.cfg.OIDCNameClaim, p.cfg.OIDCAvatarClaim, p.cfg.OIDCGroupsClaim} {
+		if _, ok := claims[name]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// userFromClaims maps ID token claims to a user using the configured claim names.
+func (p *oidcProvider) userFromClaims(claims map[string]any) (*User, error) {
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("id_token has no subject")
+	}
+
+	email, _ := claims[p.cfg.OIDCEmailClaim].(string)
+	if email == "" {
+		return nil, fmt.Errorf("id_token has no %q claim", p.cfg.OIDCEmailClaim)
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return nil, fmt.Errorf("email %s is not verified", email)
+	}
+
+	name, _ := claims[p.cfg.OIDCNameClaim].(string)
+	if name == "" {
+		name, _ = claims["preferred_username"].(string)
+	}
+	if name == "" {
+		name = email
+	}
+	avatar, _ := claims[p.cfg.OIDCAvatarClaim].(string)
+
+	return &User{
+		ID:        sub,
+		Email:     email,
+		Name:      name,
+		AvatarURL: avatar,
+		Provider:  OIDCProviderName,
+		Groups:    stringsClaim(claims[p.cfg.OIDCGroupsClaim]),
+	}, nil
+}
+
+// stringsClaim reads a claim that may be a single string or a list of strings.
+func stringsClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func (p *oidcProvider) userinfo(ctx context.Context, oc *oauth2.Config, token *oauth2.Token) (map[string]any, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("provider has no userinfo endpoint")
+	}
+
+	resp, err := oc.Client(ctx, token).Get(d.UserinfoEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("userinfo error: %s", string(body))
+	}
+
+	var info map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode user info: %w", err)
+	}
+	return info, nil
+}
+
+// --- Discovery and keys ---
+
+// getDiscovery returns the cached discovery document, fetching it on first
+// use. The fetch runs without p.mu held so a slow issuer does not block key
+// lookups; concurrent first callers may each fetch, and the first to finish
+// publishes its result.
+func (p *oidcProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	cached := p.discovery
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var d oidcDiscovery
+	if err := p.getJSON(ctx, p.cfg.OIDCIssuerURL+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.OIDCIssuerURL {
+		return nil, fmt.Errorf("OIDC discovery returned issuer %q, expected %q", d.Issuer, p.cfg.OIDCIssuerURL)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document is missing required endpoints")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery == nil {
+		p.discovery = &d
+	}
+	return p.discovery, nil
+}
+
+// getKey returns the signing key for kid, refetching the key set when the
+// key is unknown so that provider key rotation is picked up. Like discovery,
+// the key set is fetched without p.mu held and only published under it.
+func (p *oidcProvider) getKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	key, ok := p.lookupKey(kid)
+	fresh := time.Since(p.keysFetchedAt) < oidcKeyRefreshInterval && p.keys != nil
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID. A token without a key ID is accepted only when
+// the provider publishes a single key. Callers must hold p.mu.
+func (p *oidcProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// --- ID token validation ---
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry
+// and nonce, and returns its claims.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, raw, nonce string) (map[string]any, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed id_token header: %w", err)
+	}
+
+	key, err := p.getKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed id_token signature: %w", err)
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("invalid id_token signature: %w", err)
+	}
+
+	var claims map[string]any
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed id_token claims: %w", err)
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != p.cfg.OIDCIssuerURL {
+		return nil, fmt.Errorf("id_token issuer %q does not match %q", iss, p.cfg.OIDCIssuerURL)
+	}
+
+	audiences := stringsClaim(claims["aud"])
+	if !slices.Contains(audiences, p.cfg.OIDCClientID) {
+		return nil, fmt.Errorf("id_token audience does not include client %q", p.cfg.OIDCClientID)
+	}
+	if azp, ok := claims["azp"].(string); ok && len(audiences) > 1 && azp != p.cfg.OIDCClientID {
+		return nil, fmt.Errorf("id_token authorized party %q does not match client", azp)
+	}
+
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("id_token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("id_token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("id_token not yet valid")
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifyJWTSignature verifies a JWS signature for the RSA and ECDSA
+// algorithms that OIDC providers use.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256", "PS256":
+		hash = crypto.SHA256
+	case "RS384", "ES384", "PS384":
+		hash = crypto.SHA384
+	case "RS512", "PS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		case "PS":
+			return rsa.VerifyPSS(k, hash, digest, signature, nil)
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			break
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ECDSA signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("ECDSA verification failed")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %q does not match key type", alg)
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+)
+
+// mockOIDCIssuer is an in-process OpenID Connect provider. It issues an ID
+// token for any code, checking the PKCE verifier against the challenge sent
+// to the authorization endpoint.
+type mockOIDCIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu        sync.Mutex
+	challenge string
+	nonce     string
+	// claims are merged into the ID token; userinfo is served from /userinfo
+	claims   map[string]any
+	userinfo map[string]any
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	m := &mockOIDCIssuer{key: key, kid: "key-1", claims: map[string]any{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"userinfo_endpoint":      m.server.URL + "/userinfo",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		claims := map[string]any{
+			"iss":   m.server.URL,
+			"aud":   "discobot",
+			"sub":   "user-123",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": m.nonce,
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     m.sign(t, claims),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(m.userinfo)
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockOIDCIssuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": m.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// rotateKey replaces the signing key with a new key ID.
+func (m *mockOIDCIssuer) rotateKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	m.mu.Lock()
+	m.key, m.kid = key, kid
+	m.mu.Unlock()
+}
+
+func (m *mockOIDCIssuer) config() *config.Config {
+	return &config.Config{
+		OIDCIssuerURL:    m.server.URL,
+		OIDCClientID:     "discobot",
+		OIDCClientSecret: "secret",
+		OIDCScopes:       []string{"openid", "email", "profile"},
+		OIDCEmailClaim:   "email",
+		OIDCNameClaim:    "name",
+		OIDCAvatarClaim:  "picture",
+		OIDCGroupsClaim:  "groups",
+	}
+}
+
+// login runs the authorization code flow against the mock issuer, playing
+// the browser's part by recording what the authorization URL carried.
+func (m *mockOIDCIssuer) login(t *testing.T, svc *AuthService) (*User, error) {
+	t.Helper()
+	ctx := context.Background()
+	state, _ := GenerateState()
+	verifier := GeneratePKCEVerifier()
+
+	authURL, err := svc.GetAuthURL(ctx, OIDCProviderName, "http://localhost/auth/callback/oidc", state, verifier)
+	if err != nil {
+		t.Fatalf("GetAuthURL failed: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid auth URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("state") != state {
+		t.Fatalf("Auth URL missing PKCE or state: %s", authURL)
+	}
+
+	m.mu.Lock()
+	m.challenge = q.Get("code_challenge")
+	if _, ok := m.claims["nonce"]; !ok {
+		m.nonce = q.Get("nonce")
+	}
+	m.mu.Unlock()
+
+	return svc.ExchangeCode(ctx, OIDCProviderName, "http://localhost/auth/callback/oidc", "code", state, verifier)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	issuer.claims = map[string]any{
+		"email":  "dev@example.com",
+		"name":   "Dev",
+		"groups": []string{"engineering"},
+	}
+	svc := NewAuthService(nil, issuer.config())
+
+	user, err := issuer.login(t, svc)
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if user.ID != "user-123" || user.Email != "dev@example.com" || user.Name != "Dev" || user.Provider != OIDCProviderName {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+	if len(user.Groups) != 1 || user.Groups[0] != "engineering" {
+		t.Errorf("Unexpected groups: %v", user.Groups)
+	}
+}
+
+func TestOIDCLogin_ClaimMappingAndUserinfo(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	// The ID token only carries the subject; profile claims come from userinfo
+	issuer.userinfo = map[string]any{
+		"sub":         "user-123",
+		"mail":        "dev@example.com",
+		"displayName": "Dev From Userinfo",
+		"roles":       "platform",
+	}
+	cfg := issuer.config()
+	cfg.OIDCEmailClaim = "mail"
+	cfg.OIDCNameClaim = "displayName"
+	cfg.OIDCGroupsClaim = "roles"
+	svc := NewAuthService(nil, cfg)
+
+	user, err := issuer.login(t, svc)
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if user.Email != "dev@example.com" || user.Name != "Dev From Userinfo" {
+		t.Errorf("Claims not mapped from userinfo: %+v", user)
+	}
+	if len(user.Groups) != 1 || user.Groups[0] != "platform" {
+		t.Errorf("Expected single-string group claim, got %v", user.Groups)
+	}
+}
+
+func TestOIDCLogin_Rejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]any
+	}{
+		{"wrong nonce", map[string]any{"email": "a@b.c", "nonce": "other"}},
+		{"wrong audience", map[string]any{"email": "a@b.c", "aud": "someone-else"}},
+		{"wrong issuer", map[string]any{"email": "a@b.c", "iss": "https://evil.example.com"}},
+		{"expired", map[string]any{"email": "a@b.c", "exp": time.Now().Add(-time.Hour).Unix()}},
+		{"unverified email", map[string]any{"email": "a@b.c", "email_verified": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newMockOIDCIssuer(t)
+			issuer.claims = tt.claims
+			issuer.userinfo = map[string]any{"sub": "user-123"}
+			if _, err := issuer.login(t, NewAuthService(nil, issuer.config())); err == nil {
+				t.Error("Expected login to fail")
+			}
+		})
+	}
+}
+
+func TestOIDCLogin_BadSignature(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	issuer.claims = map[string]any{"email": "a@b.c"}
+	svc := NewAuthService(nil, issuer.config())
+	if _, err := issuer.login(t, svc); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	// Sign with a different key under the same key ID
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	issuer.mu.Lock()
+	realKey := issuer.key
+	issuer.key = other
+	issuer.mu.Unlock()
+	_, err := svc.oidc.verifyIDToken(context.Background(), issuer.sign(t, map[string]any{"iss": issuer.server.URL}), "")
+	issuer.key = realKey
+	if err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Expected signature error, got %v", err)
+	}
+}
+
+func TestOIDCLogin_KeyRotation(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	issuer.claims = map[string]any{"email": "a@b.c"}
+	svc := NewAuthService(nil, issuer.config())
+	if _, err := issuer.login(t, svc); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	// An unknown key ID triggers a refetch once the refresh interval passed
+	issuer.rotateKey(t, "key-2")
+	svc.oidc.keysFetchedAt = time.Time{}
+	if _, err := issuer.login(t, svc); err != nil {
+		t.Fatalf("Login after key rotation failed: %v", err)
+	}
+}
+
+func TestOIDCLogin_GroupMappings(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	project := env.createTestProject(t)
+
+	issuer := newMockOIDCIssuer(t)
+	issuer.claims = map[string]any{"email": "dev@example.com", "groups": []string{"engineering"}}
+	cfg := issuer.config()
+	cfg.OIDCGroupMappings = []config.OIDCGroupMapping{
+		{Group: "engineering", ProjectID: project.ID, Role: "member"},
+		{Group: "unrelated", ProjectID: "other", Role: "admin"},
+	}
+	svc := NewAuthService(env.store, cfg)
+	ctx := context.Background()
+
+	providerUser, err := issuer.login(t, svc)
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	user, err := svc.CreateOrUpdateUser(ctx, providerUser)
+	if err != nil {
+		t.Fatalf("CreateOrUpdateUser failed: %v", err)
+	}
+	member, err := env.store.GetProjectMember(ctx, project.ID, user.ID)
+	if err != nil {
+		t.Fatalf("Expected user to join mapped project: %v", err)
+	}
+	if member.Role != "member" {
+		t.Errorf("Expected member role, got %q", member.Role)
+	}
+
+	// A later login with an admin group upgrades the membership
+	cfg.OIDCGroupMappings = append(cfg.OIDCGroupMappings, config.OIDCGroupMapping{Group: "leads", ProjectID: project.ID, Role: "admin"})
+	providerUser.Groups = []string{"engineering", "leads"}
+	if _, err := svc.CreateOrUpdateUser(ctx, providerUser); err != nil {
+		t.Fatalf("CreateOrUpdateUser failed: %v", err)
+	}
+	member, _ = env.store.GetProjectMember(ctx, project.ID, user.ID)
+	if member.Role != "admin" {
+		t.Errorf("Expected role upgrade to admin, got %q", member.Role)
+	}
+
+	stored, err := env.store.GetUserByProviderID(ctx, OIDCProviderName, "user-123")
+	if err != nil || stored.Provider != OIDCProviderName {
+		t.Errorf("Expected stored OIDC user, got %+v (%v)", stored, err)
+	}
+}
+
+func TestOIDCDiscovery_FetchDoesNotHoldLock(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var issuer *httptest.Server
+	issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"jwks_uri":               issuer.URL + "/jwks",
+		})
+	}))
+	defer issuer.Close()
+
+	p := newOIDCProvider(&config.Config{OIDCIssuerURL: issuer.URL})
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.getDiscovery(context.Background())
+		errc <- err
+	}()
+
+	// While the issuer is slow to answer, the provider lock stays free
+	<-started
+	if !p.mu.TryLock() {
+		close(release)
+		t.Fatal("Expected provider lock to be free during discovery fetch")
+	}
+	p.mu.Unlock()
+	close(release)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("getDiscovery failed: %v", err)
+	}
+	d, err := p.getDiscovery(context.Background())
+	if err != nil || d.TokenEndpoint != issuer.URL+"/token" {
+		t.Errorf("Expected cached discovery, got %+v, %v", d, err)
+	}
+}
+
+func TestOIDCKeys_FetchDoesNotHoldLock(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		r.URL.Path = "/jwks"
+		issuer.server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer jwks.Close()
+
+	p := newOIDCProvider(issuer.config())
+	p.discovery = &oidcDiscovery{JWKSURI: jwks.URL}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.getKey(context.Background(), issuer.kid)
+		errc <- err
+	}()
+
+	// While the key set is slow to load, the provider lock stays free
+	<-started
+	if !p.mu.TryLock() {
+		close(release)
+		t.Fatal("Expected provider lock to be free during key set fetch")
+	}
+	p.mu.Unlock()
+	close(release)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("getKey failed: %v", err)
+	}
+	if _, ok := p.lookupKey(issuer.kid); !ok {
+		t.Error("Expected fetched key set to be published")
+	}
+}
@@ -215,6 +215,8 @@ type mockHandler struct {
 	commitsResponse *sandboxapi.CommitsResponse
 	commitsError    *sandboxapi.CommitsErrorResponse
 	commitsHTTPCode int
+	// chatStream is sent as SSE data lines before [DONE]
+	chatStream []string
 }
 
 func newMockHandler() *mockHandler {
@@ -238,7 +240,9 @@ func (h *mockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// GET returns SSE stream
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.WriteHeader(http.StatusOK)
-		// Send done signal immediately
+		for _, data := range h.chatStream {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+		}
 		_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
 		return
 
@@ -5,20 +5,25 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/notify"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
 
+// projectLog is the logger for project messages.
+var projectLog = logging.Component("project")
+
 // ProjectService handles project operations
 type ProjectService struct {
 	store    *store.Store
 	provider sandbox.Provider
+	notifier notify.Notifier
 }
 
 // Project represents a project (for API responses)
@@ -45,13 +50,14 @@ type ProjectMember struct {
 
 // ProjectInvitation represents a project invitation (for API responses)
 type ProjectInvitation struct {
-	ID        string    `json:"id"`
-	ProjectID string    `json:"projectId"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	Token     string    `json:"token,omitempty"`
-	ExpiresAt time.Time `json:"expiresAt"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         string     `json:"id"`
+	ProjectID  string     `json:"projectId"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Token      string     `json:"token,omitempty"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
 }
 
 // NewProjectService creates a new project service
@@ -59,6 +65,7 @@ func NewProjectService(s *store.Store, p sandbox.Provider) *ProjectService {
 	return &ProjectService{
 		store:    s,
 		provider: p,
+		notifier: notify.LogNotifier{},
 	}
 }
 
@@ -161,7 +168,7 @@ func (s *ProjectService) DeleteProject(ctx context.Context, projectID string) er
 	// Clean up provider-managed resources (cache volumes, BuildKit containers, networks, etc.)
 	if s.provider != nil {
 		if err := s.provider.RemoveProject(ctx, projectID); err != nil {
-			log.Printf("Warning: failed to remove provider resources for project %s: %v", projectID, err)
+			projectLog.WarnContext(ctx, "failed to remove provider resources", "project_id", projectID, "error", err)
 		}
 	}
 
@@ -204,8 +211,10 @@ func (s *ProjectService) ListMembers(ctx context.Context, projectID string) ([]P
 	return members, nil
 }
 
-// CreateInvitation creates a project invitation
-func (s *ProjectService) CreateInvitation(ctx context.Context, projectID, inviterID, email, role string) (*ProjectInvitation, error) {
+// CreateInvitation creates a project invitation and emails it to the invitee.
+// Delivery is best-effort: the invitation is created even if sending fails,
+// in which case LastSentAt is nil and the invitation can be resent.
+func (s *ProjectService) CreateInvitation(ctx context.Context, projectID, inviterID, email, role, baseURL string) (*ProjectInvitation, error) {
 	// Generate token
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -213,28 +222,25 @@ func (s *ProjectService) CreateInvitation(ctx context.Context, projectID, invite
 	}
 	token := hex.EncodeToString(tokenBytes)
 
-	expiresAt := time.Now().Add(7 * 24 * time.Hour) // 7 days
-
 	inv := &model.ProjectInvitation{
 		ProjectID: projectID,
 		Email:     email,
 		Role:      role,
 		InvitedBy: &inviterID,
 		Token:     token,
-		ExpiresAt: expiresAt,
+		ExpiresAt: time.Now().Add(InvitationTTL),
 	}
 	if err := s.store.CreateInvitation(ctx, inv); err != nil {
 		return nil, err
 	}
-	return &ProjectInvitation{
-		ID:        inv.ID,
-		ProjectID: inv.ProjectID,
-		Email:     inv.Email,
-		Role:      inv.Role,
-		Token:     inv.Token,
-		ExpiresAt: inv.ExpiresAt,
-		CreatedAt: inv.CreatedAt,
-	}, nil
+
+	if err := s.sendInvitation(ctx, inv, baseURL); err != nil {
+		projectLog.WarnContext(ctx, "failed to send invitation email", "email", email, "error", err)
+	}
+
+	result := toProjectInvitation(inv)
+	result.Token = inv.Token
+	return result, nil
 }
 
 // AcceptInvitation accepts a project invitation
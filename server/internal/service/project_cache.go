@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// ErrInvalidCacheSettings is returned for negative cache limits.
+var ErrInvalidCacheSettings = errors.New("cache quota and max age must not be negative")
+
+// CacheSettings are a project's cache volume limits (for API requests and
+// responses). Zero means no limit.
+type CacheSettings struct {
+	QuotaBytes int64 `json:"quotaBytes"`
+	MaxAgeDays int   `json:"maxAgeDays"`
+}
+
+// ProjectCache is a project's cache usage together with its limits.
+type ProjectCache struct {
+	sandbox.CacheUsage
+	Settings CacheSettings `json:"settings"`
+}
+
+// cacheManager returns the provider's cache management, if it has any.
+func (s *ProjectService) cacheManager() (sandbox.CacheManager, error) {
+	cm, ok := s.provider.(sandbox.CacheManager)
+	if !ok {
+		return nil, sandbox.ErrCacheNotSupported
+	}
+	return cm, nil
+}
+
+// GetCache returns per-entry usage of the project's cache volume and the
+// project's cache limits.
+func (s *ProjectService) GetCache(ctx context.Context, projectID string) (*ProjectCache, error) {
+	project, err := s.store.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := s.cacheManager()
+	if err != nil {
+		return nil, err
+	}
+	usage, err := cm.CacheUsage(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectCache{CacheUsage: *usage, Settings: projectCacheSettings(project)}, nil
+}
+
+// UpdateCacheSettings sets the project's cache limits. They are enforced
+// when a sandbox starts while no other sandbox of the project is using the
+// cache, and by PruneCache.
+func (s *ProjectService) UpdateCacheSettings(ctx context.Context, projectID string, settings CacheSettings) (*CacheSettings, error) {
+	if settings.QuotaBytes < 0 || settings.MaxAgeDays < 0 {
+		return nil, ErrInvalidCacheSettings
+	}
+	project, err := s.store.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	project.CacheQuotaBytes = settings.QuotaBytes
+	project.CacheMaxAgeDays = settings.MaxAgeDays
+	if err := s.store.UpdateProject(ctx, project); err != nil {
+		return nil, err
+	}
+	updated := projectCacheSettings(project)
+	return &updated, nil
+}
+
+// PruneCache clears cache entries that exceed the project's limits. Limits
+// left zero in overrides fall back to the project's settings; entries
+// restricts pruning to the named cache entries.
+func (s *ProjectService) PruneCache(ctx context.Context, projectID string, entries []string, overrides CacheSettings) (*sandbox.CachePruneResult, error) {
+	project, err := s.store.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := s.cacheManager()
+	if err != nil {
+		return nil, err
+	}
+
+	limits := projectCacheSettings(project)
+	if overrides.QuotaBytes > 0 {
+		limits.QuotaBytes = overrides.QuotaBytes
+	}
+	if overrides.MaxAgeDays > 0 {
+		limits.MaxAgeDays = overrides.MaxAgeDays
+	}
+	opts := limits.pruneOptions()
+	opts.Entries = entries
+	return cm.PruneCache(ctx, projectID, opts)
+}
+
+// ClearCache empties the given cache entries (names such as "go" or paths
+// such as "go/pkg/mod"), or every known entry if none are given.
+func (s *ProjectService) ClearCache(ctx context.Context, projectID string, entries []string) (*sandbox.CachePruneResult, error) {
+	cm, err := s.cacheManager()
+	if err != nil {
+		return nil, err
+	}
+	return cm.ClearCache(ctx, projectID, entries)
+}
+
+func projectCacheSettings(project *model.Project) CacheSettings {
+	return CacheSettings{QuotaBytes: project.CacheQuotaBytes, MaxAgeDays: project.CacheMaxAgeDays}
+}
+
+func (c CacheSettings) pruneOptions() sandbox.CachePruneOptions {
+	return sandbox.CachePruneOptions{
+		MaxAge:   time.Duration(c.MaxAgeDays) * 24 * time.Hour,
+		MaxBytes: c.QuotaBytes,
+	}
+}
+
+// cacheLimitEnv returns the environment variables through which the agent
+// enforces the project's cache limits when a sandbox starts.
+func cacheLimitEnv(project *model.Project) map[string]string {
+	opts := projectCacheSettings(project).pruneOptions()
+	env := make(map[string]string)
+	if opts.MaxBytes > 0 {
+		env["CACHE_MAX_BYTES"] = strconv.FormatInt(opts.MaxBytes, 10)
+	}
+	if opts.MaxAge > 0 {
+		env["CACHE_MAX_AGE"] = opts.MaxAge.String()
+	}
+	return env
+}
+
+// cacheUsingStatuses are the session statuses in which a session's sandbox
+// may be running and using the project cache volume.
+var cacheUsingStatuses = []string{
+	model.SessionStatusInitializing,
+	model.SessionStatusReinitializing,
+	model.SessionStatusCloning,
+	model.SessionStatusPullingImage,
+	model.SessionStatusCreatingSandbox,
+	model.SessionStatusReady,
+	model.SessionStatusRunning,
+}
+
+// projectCacheInUse reports whether a session of the project other than
+// sessionID may have a sandbox using the cache volume. Pruning at sandbox
+// start is skipped then, since it would delete entries from under it.
+func projectCacheInUse(ctx context.Context, s *store.Store, projectID, sessionID string) (bool, error) {
+	sessions, err := s.ListSessionsByProjectAndStatuses(ctx, projectID, cacheUsingStatuses)
+	if err != nil {
+		return false, err
+	}
+	for _, sess := range sessions {
+		if sess.ID != sessionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
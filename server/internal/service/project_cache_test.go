@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/mock"
+)
+
+// cacheProvider adds sandbox.CacheManager to the mock provider and records
+// the options it was called with.
+type cacheProvider struct {
+	*mock.Provider
+	pruneOpts    sandbox.CachePruneOptions
+	clearEntries []string
+}
+
+func (p *cacheProvider) CacheUsage(context.Context, string) (*sandbox.CacheUsage, error) {
+	return &sandbox.CacheUsage{
+		TotalBytes: 300,
+		Entries:    []sandbox.CacheEntryUsage{{Name: "go", Bytes: 200}, {Name: "npm", Bytes: 100}},
+	}, nil
+}
+
+func (p *cacheProvider) PruneCache(_ context.Context, _ string, opts sandbox.CachePruneOptions) (*sandbox.CachePruneResult, error) {
+	p.pruneOpts = opts
+	return &sandbox.CachePruneResult{Cleared: []string{"npm"}, FreedBytes: 100}, nil
+}
+
+func (p *cacheProvider) ClearCache(_ context.Context, _ string, entries []string) (*sandbox.CachePruneResult, error) {
+	p.clearEntries = entries
+	return &sandbox.CachePruneResult{Cleared: entries}, nil
+}
+
+func TestProjectCacheSettings(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+	if err := s.CreateProject(ctx, &model.Project{ID: "p1", Name: "P1", Slug: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	provider := &cacheProvider{Provider: mock.NewProvider()}
+	svc := NewProjectService(s, provider)
+
+	if _, err := svc.UpdateCacheSettings(ctx, "p1", CacheSettings{QuotaBytes: -1}); !errors.Is(err, ErrInvalidCacheSettings) {
+		t.Fatalf("negative quota error = %v, want ErrInvalidCacheSettings", err)
+	}
+	if _, err := svc.UpdateCacheSettings(ctx, "p1", CacheSettings{QuotaBytes: 1 << 30, MaxAgeDays: 14}); err != nil {
+		t.Fatalf("UpdateCacheSettings: %v", err)
+	}
+
+	cache, err := svc.GetCache(ctx, "p1")
+	if err != nil {
+		t.Fatalf("GetCache: %v", err)
+	}
+	if cache.Settings.QuotaBytes != 1<<30 || cache.Settings.MaxAgeDays != 14 {
+		t.Errorf("settings = %+v, want quota 1GiB and 14 days", cache.Settings)
+	}
+	if cache.TotalBytes != 300 || len(cache.Entries) != 2 {
+		t.Errorf("usage = %+v", cache.CacheUsage)
+	}
+
+	// Pruning defaults to the project's limits
+	if _, err := svc.PruneCache(ctx, "p1", nil, CacheSettings{}); err != nil {
+		t.Fatalf("PruneCache: %v", err)
+	}
+	if provider.pruneOpts.MaxBytes != 1<<30 || provider.pruneOpts.MaxAge != 14*24*time.Hour {
+		t.Errorf("prune options = %+v, want project limits", provider.pruneOpts)
+	}
+
+	// Request limits override them
+	if _, err := svc.PruneCache(ctx, "p1", []string{"go"}, CacheSettings{MaxAgeDays: 1}); err != nil {
+		t.Fatalf("PruneCache: %v", err)
+	}
+	if provider.pruneOpts.MaxAge != 24*time.Hour || provider.pruneOpts.MaxBytes != 1<<30 {
+		t.Errorf("prune options = %+v, want 1 day and project quota", provider.pruneOpts)
+	}
+	if len(provider.pruneOpts.Entries) != 1 || provider.pruneOpts.Entries[0] != "go" {
+		t.Errorf("prune entries = %v, want [go]", provider.pruneOpts.Entries)
+	}
+
+	if _, err := svc.ClearCache(ctx, "p1", []string{"go/pkg/mod"}); err != nil {
+		t.Fatalf("ClearCache: %v", err)
+	}
+	if len(provider.clearEntries) != 1 || provider.clearEntries[0] != "go/pkg/mod" {
+		t.Errorf("clear entries = %v, want [go/pkg/mod]", provider.clearEntries)
+	}
+}
+
+func TestProjectCacheNotSupported(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+	if err := s.CreateProject(ctx, &model.Project{ID: "p1", Name: "P1", Slug: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewProjectService(s, mock.NewProvider())
+
+	if _, err := svc.GetCache(ctx, "p1"); !errors.Is(err, sandbox.ErrCacheNotSupported) {
+		t.Errorf("GetCache error = %v, want ErrCacheNotSupported", err)
+	}
+	if _, err := svc.ClearCache(ctx, "p1", nil); !errors.Is(err, sandbox.ErrCacheNotSupported) {
+		t.Errorf("ClearCache error = %v, want ErrCacheNotSupported", err)
+	}
+}
+
+func TestCacheLimitEnv(t *testing.T) {
+	if env := cacheLimitEnv(&model.Project{}); len(env) != 0 {
+		t.Errorf("env without limits = %v, want none", env)
+	}
+
+	env := cacheLimitEnv(&model.Project{CacheQuotaBytes: 5 << 30, CacheMaxAgeDays: 30})
+	if env["CACHE_MAX_BYTES"] != "5368709120" {
+		t.Errorf("CACHE_MAX_BYTES = %q", env["CACHE_MAX_BYTES"])
+	}
+	if d, err := time.ParseDuration(env["CACHE_MAX_AGE"]); err != nil || d != 30*24*time.Hour {
+		t.Errorf("CACHE_MAX_AGE = %q, want 720h", env["CACHE_MAX_AGE"])
+	}
+}
+
+func TestProjectCacheInUse(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+	if err := s.CreateProject(ctx, &model.Project{ID: "p1", Name: "P1", Slug: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, sess := range []*model.Session{
+		{ID: "starting", ProjectID: "p1", Name: "Starting", Status: model.SessionStatusInitializing},
+		{ID: "other", ProjectID: "p1", Name: "Other", Status: model.SessionStatusStopped},
+	} {
+		if err := s.CreateSession(ctx, sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The starting session's own sandbox and stopped sandboxes don't count
+	if inUse, err := projectCacheInUse(ctx, s, "p1", "starting"); err != nil || inUse {
+		t.Errorf("projectCacheInUse = %v, %v; want false", inUse, err)
+	}
+
+	if err := s.UpdateSessionStatus(ctx, "other", model.SessionStatusRunning, nil); err != nil {
+		t.Fatal(err)
+	}
+	if inUse, err := projectCacheInUse(ctx, s, "p1", "starting"); err != nil || !inUse {
+		t.Errorf("projectCacheInUse = %v, %v; want true", inUse, err)
+	}
+}
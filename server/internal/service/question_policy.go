@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// ErrInvalidQuestionPolicy is returned when a question policy fails validation.
+var ErrInvalidQuestionPolicy = errors.New("invalid question policy")
+
+// Sources of a session's effective question policy.
+const (
+	QuestionPolicySourceSession   = "session"
+	QuestionPolicySourceWorkspace = "workspace"
+	QuestionPolicySourceDefault   = "default"
+)
+
+// SessionQuestionPolicy is a session's own question policy and the policy
+// that applies to it.
+type SessionQuestionPolicy struct {
+	Policy    *model.QuestionPolicy `json:"policy"` // The session's override, nil if it inherits
+	Effective model.QuestionPolicy  `json:"effective"`
+	Source    string                `json:"source"` // "session", "workspace" or "default"
+}
+
+// questionSighting records when the poller first saw a pending question.
+type questionSighting struct {
+	toolUseID string
+	seenAt    time.Time
+}
+
+// GetQuestionPolicy returns a workspace's question policy, or nil if none is set.
+func (s *WorkspaceService) GetQuestionPolicy(ctx context.Context, projectID, workspaceID string) (*model.QuestionPolicy, error) {
+	ws, err := s.store.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil || ws.ProjectID != projectID {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	return model.DecodeQuestionPolicy(ws.QuestionPolicy)
+}
+
+// SetQuestionPolicy sets a workspace's question policy, which applies to all
+// of its sessions that don't set their own. A nil policy clears it.
+func (s *WorkspaceService) SetQuestionPolicy(ctx context.Context, projectID, workspaceID string, policy *model.QuestionPolicy) (*model.QuestionPolicy, error) {
+	ws, err := s.store.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil || ws.ProjectID != projectID {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	data, err := encodeQuestionPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateWorkspaceQuestionPolicy(ctx, workspaceID, data); err != nil {
+		return nil, fmt.Errorf("failed to update question policy: %w", err)
+	}
+	return policy, nil
+}
+
+// GetQuestionPolicy returns a session's question policy and the policy in effect.
+func (s *SessionService) GetQuestionPolicy(ctx context.Context, projectID, sessionID string) (*SessionQuestionPolicy, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil || sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	own, err := model.DecodeQuestionPolicy(sess.QuestionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	effective, source, err := resolveQuestionPolicy(ctx, s.store, sess)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionQuestionPolicy{Policy: own, Effective: effective, Source: source}, nil
+}
+
+// SetQuestionPolicy sets a session's question policy, overriding its
+// workspace's. A nil policy clears it.
+func (s *SessionService) SetQuestionPolicy(ctx context.Context, projectID, sessionID string, policy *model.QuestionPolicy) (*SessionQuestionPolicy, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil || sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	data, err := encodeQuestionPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateSessionQuestionPolicy(ctx, sessionID, data); err != nil {
+		return nil, fmt.Errorf("failed to update question policy: %w", err)
+	}
+	return s.GetQuestionPolicy(ctx, projectID, sessionID)
+}
+
+// encodeQuestionPolicy validates and encodes a policy for storage. A nil
+// policy encodes to nil.
+func encodeQuestionPolicy(policy *model.QuestionPolicy) (json.RawMessage, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuestionPolicy, err)
+	}
+	return json.Marshal(policy)
+}
+
+// resolveQuestionPolicy returns the policy that applies to a session: its
+// own, else its workspace's, else manual answering.
+func resolveQuestionPolicy(ctx context.Context, s *store.Store, sess *model.Session) (model.QuestionPolicy, string, error) {
+	policy, err := model.DecodeQuestionPolicy(sess.QuestionPolicy)
+	if err != nil {
+		return model.QuestionPolicy{}, "", err
+	}
+	if policy != nil {
+		return *policy, QuestionPolicySourceSession, nil
+	}
+
+	ws, err := s.GetWorkspaceByID(ctx, sess.WorkspaceID)
+	if err != nil {
+		return model.QuestionPolicy{}, "", fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if policy, err = model.DecodeQuestionPolicy(ws.QuestionPolicy); err != nil {
+		return model.QuestionPolicy{}, "", err
+	}
+	if policy != nil {
+		return *policy, QuestionPolicySourceWorkspace, nil
+	}
+	return model.QuestionPolicy{Mode: model.QuestionPolicyManual}, QuestionPolicySourceDefault, nil
+}
+
+// headlessQuestionPolicy returns the policy a session without a client
+// attached answers questions by. Unless the session or its workspace sets a
+// policy, that is first_option rather than manual, since nobody is around to
+// answer.
+func headlessQuestionPolicy(ctx context.Context, s *store.Store, sessionID string) (model.QuestionPolicy, error) {
+	sess, err := s.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return model.QuestionPolicy{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	policy, source, err := resolveQuestionPolicy(ctx, s, sess)
+	if err != nil {
+		return model.QuestionPolicy{}, err
+	}
+	if source == QuestionPolicySourceDefault {
+		policy.Mode = model.QuestionPolicyFirstOption
+	}
+	return policy, nil
+}
+
+// policyAnswers answers every question of a pending AskUserQuestion according
+// to an automatic policy. Questions without options get the policy's default
+// answer, or a generic instruction to continue.
+func policyAnswers(policy model.QuestionPolicy, question *sandboxapi.PendingQuestion) map[string]string {
+	freeText := policy.DefaultAnswer
+	if freeText == "" {
+		freeText = headlessFreeTextAnswer
+	}
+
+	answers := make(map[string]string, len(question.Questions))
+	for _, q := range question.Questions {
+		answer := freeText
+		if policy.Mode != model.QuestionPolicyDefaultAnswer && len(q.Options) > 0 {
+			answer = q.Options[0].Label
+			if policy.Mode == model.QuestionPolicyRecommended {
+				if option, ok := recommendedOption(q.Options); ok {
+					answer = option.Label
+				}
+			}
+		}
+		answers[q.Question] = answer
+	}
+	return answers
+}
+
+// recommendedOption returns the first option the agent marked as recommended
+// in its label or description.
+func recommendedOption(options []sandboxapi.AskUserQuestionOption) (sandboxapi.AskUserQuestionOption, bool) {
+	for _, option := range options {
+		if strings.Contains(strings.ToLower(option.Label), "recommended") ||
+			strings.Contains(strings.ToLower(option.Description), "recommended") {
+			return option, true
+		}
+	}
+	return sandboxapi.AskUserQuestionOption{}, false
+}
+
+// applyQuestionPolicy answers a running session's pending question, or
+// cancels its completion once the question has waited longer than the
+// policy's timeout.
+func (p *SessionStatusPoller) applyQuestionPolicy(ctx context.Context, session *model.Session, client *SessionClient) error {
+	policy, _, err := resolveQuestionPolicy(ctx, p.store, session)
+	if err != nil {
+		return err
+	}
+	if !policy.AutoAnswers() && policy.TimeoutMinutes == 0 {
+		delete(p.questionSightings, session.ID)
+		return nil
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+
+	resp, err := client.GetQuestion(checkCtx, "")
+	if err != nil {
+		return err
+	}
+	question := resp.Question
+	if question == nil {
+		delete(p.questionSightings, session.ID)
+		return nil
+	}
+
+	if policy.AutoAnswers() {
+		answers := policyAnswers(policy, question)
+		_, err := client.AnswerQuestion(checkCtx, &sandboxapi.AnswerQuestionRequest{
+			ToolUseID: question.ToolUseID,
+			Answers:   answers,
+		})
+		if err == nil {
+			delete(p.questionSightings, session.ID)
+			p.logger.Info("auto-answered question", "session_id", session.ID, "tool_use_id", question.ToolUseID, "policy", policy.Mode)
+			if p.eventBroker != nil {
+				if err := p.eventBroker.PublishQuestionAutoAnswered(ctx, session.ProjectID, events.QuestionAutoAnsweredData{
+					SessionID: session.ID,
+					ToolUseID: question.ToolUseID,
+					Policy:    policy.Mode,
+					Answers:   answers,
+				}); err != nil {
+					p.logger.Error("failed to publish question auto-answered event", "session_id", session.ID, "error", err)
+				}
+			}
+			return nil
+		}
+		// Leave the question for the timeout, if any
+		p.logger.Warn("failed to auto-answer question", "session_id", session.ID, "tool_use_id", question.ToolUseID, "error", err)
+	}
+
+	if policy.TimeoutMinutes == 0 {
+		return nil
+	}
+	sighting, ok := p.questionSightings[session.ID]
+	if !ok || sighting.toolUseID != question.ToolUseID {
+		p.questionSightings[session.ID] = questionSighting{toolUseID: question.ToolUseID, seenAt: time.Now()}
+		return nil
+	}
+	if time.Since(sighting.seenAt) < time.Duration(policy.TimeoutMinutes)*time.Minute {
+		return nil
+	}
+
+	if _, err := client.CancelCompletion(checkCtx); err != nil {
+		return fmt.Errorf("failed to cancel completion after question timeout: %w", err)
+	}
+	delete(p.questionSightings, session.ID)
+	p.logger.Info("cancelled completion after unanswered question", "session_id", session.ID, "tool_use_id", question.ToolUseID, "timeout_minutes", policy.TimeoutMinutes)
+	if p.eventBroker != nil {
+		if err := p.eventBroker.PublishQuestionTimedOut(ctx, session.ProjectID, events.QuestionTimedOutData{
+			SessionID:      session.ID,
+			ToolUseID:      question.ToolUseID,
+			TimeoutMinutes: policy.TimeoutMinutes,
+		}); err != nil {
+			p.logger.Error("failed to publish question timed out event", "session_id", session.ID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+func TestPolicyAnswers(t *testing.T) {
+	question := &sandboxapi.PendingQuestion{
+		Questions: []sandboxapi.AskUserQuestion{
+			{Question: "Which database?", Options: []sandboxapi.AskUserQuestionOption{
+				{Label: "Postgres"},
+				{Label: "SQLite", Description: "Recommended for local development"},
+			}},
+			{Question: "Project name?"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   model.QuestionPolicy
+		database string
+		name2    string
+	}{
+		{"first option", model.QuestionPolicy{Mode: model.QuestionPolicyFirstOption}, "Postgres", headlessFreeTextAnswer},
+		{"recommended", model.QuestionPolicy{Mode: model.QuestionPolicyRecommended}, "SQLite", headlessFreeTextAnswer},
+		{"default answer", model.QuestionPolicy{Mode: model.QuestionPolicyDefaultAnswer, DefaultAnswer: "Ask later"}, "Ask later", "Ask later"},
+		{"default for free text", model.QuestionPolicy{Mode: model.QuestionPolicyFirstOption, DefaultAnswer: "demo"}, "Postgres", "demo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answers := policyAnswers(tt.policy, question)
+			if answers["Which database?"] != tt.database || answers["Project name?"] != tt.name2 {
+				t.Errorf("Unexpected answers %v", answers)
+			}
+		})
+	}
+}
+
+func TestHeadlessQuestionPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, "")
+	workspaceSvc := NewWorkspaceService(env.store, nil, env.eventBroker)
+	ctx := context.Background()
+
+	// Without a policy, nobody would answer, so the first option is picked
+	policy, err := headlessQuestionPolicy(ctx, env.store, session.ID)
+	if err != nil {
+		t.Fatalf("headlessQuestionPolicy failed: %v", err)
+	}
+	if policy.Mode != model.QuestionPolicyFirstOption {
+		t.Errorf("Expected first_option without a policy, got %+v", policy)
+	}
+
+	for _, mode := range []string{model.QuestionPolicyRecommended, model.QuestionPolicyManual, model.QuestionPolicyFail} {
+		if _, err := workspaceSvc.SetQuestionPolicy(ctx, project.ID, workspace.ID, &model.QuestionPolicy{Mode: mode}); err != nil {
+			t.Fatalf("SetQuestionPolicy(%s) failed: %v", mode, err)
+		}
+		policy, err := headlessQuestionPolicy(ctx, env.store, session.ID)
+		if err != nil {
+			t.Fatalf("headlessQuestionPolicy failed: %v", err)
+		}
+		if policy.Mode != mode {
+			t.Errorf("Expected the workspace's %s policy, got %+v", mode, policy)
+		}
+	}
+}
+
+func TestQuestionPolicy_SessionOverridesWorkspace(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, "")
+	workspaceSvc := NewWorkspaceService(env.store, nil, env.eventBroker)
+	sessionSvc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, nil)
+	ctx := context.Background()
+
+	policy, err := sessionSvc.GetQuestionPolicy(ctx, project.ID, session.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionPolicy failed: %v", err)
+	}
+	if policy.Source != QuestionPolicySourceDefault || policy.Effective.Mode != model.QuestionPolicyManual {
+		t.Errorf("Expected default manual policy, got %+v", policy)
+	}
+
+	if _, err := workspaceSvc.SetQuestionPolicy(ctx, project.ID, workspace.ID, &model.QuestionPolicy{Mode: model.QuestionPolicyRecommended, TimeoutMinutes: 10}); err != nil {
+		t.Fatalf("SetQuestionPolicy on workspace failed: %v", err)
+	}
+	policy, err = sessionSvc.GetQuestionPolicy(ctx, project.ID, session.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionPolicy failed: %v", err)
+	}
+	if policy.Source != QuestionPolicySourceWorkspace || policy.Effective.Mode != model.QuestionPolicyRecommended || policy.Policy != nil {
+		t.Errorf("Expected inherited workspace policy, got %+v", policy)
+	}
+
+	policy, err = sessionSvc.SetQuestionPolicy(ctx, project.ID, session.ID, &model.QuestionPolicy{Mode: model.QuestionPolicyFirstOption})
+	if err != nil {
+		t.Fatalf("SetQuestionPolicy on session failed: %v", err)
+	}
+	if policy.Source != QuestionPolicySourceSession || policy.Effective.Mode != model.QuestionPolicyFirstOption {
+		t.Errorf("Expected session policy, got %+v", policy)
+	}
+
+	policy, err = sessionSvc.SetQuestionPolicy(ctx, project.ID, session.ID, nil)
+	if err != nil {
+		t.Fatalf("Clearing session policy failed: %v", err)
+	}
+	if policy.Source != QuestionPolicySourceWorkspace {
+		t.Errorf("Expected workspace policy after clearing, got %+v", policy)
+	}
+
+	invalid := []model.QuestionPolicy{
+		{Mode: "ask_slack"},
+		{Mode: model.QuestionPolicyDefaultAnswer},
+		{Mode: model.QuestionPolicyManual, TimeoutMinutes: -1},
+	}
+	for _, p := range invalid {
+		if _, err := sessionSvc.SetQuestionPolicy(ctx, project.ID, session.ID, &p); !errors.Is(err, ErrInvalidQuestionPolicy) {
+			t.Errorf("Expected ErrInvalidQuestionPolicy for %+v, got %v", p, err)
+		}
+	}
+	if _, err := sessionSvc.GetQuestionPolicy(ctx, "other-project", session.ID); err == nil {
+		t.Error("Expected an error for a session in another project")
+	}
+}
+
+// newQuestionPolicyPoller returns a poller for a running session whose agent
+// has a pending question. Answers and cancellations are sent on the returned
+// channels.
+func newQuestionPolicyPoller(t *testing.T, sessionPolicy *model.QuestionPolicy) (*SessionStatusPoller, *model.Session, chan sandboxapi.AnswerQuestionRequest, chan struct{}) {
+	t.Helper()
+	ctx := context.Background()
+	testStore := setupTestStoreForPoller(t)
+
+	answered := make(chan sandboxapi.AnswerQuestionRequest, 1)
+	cancelled := make(chan struct{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/chat/status"):
+			_ = json.NewEncoder(w).Encode(sandboxapi.ChatStatusResponse{IsRunning: true})
+		case strings.HasSuffix(r.URL.Path, "/chat/question"):
+			_ = json.NewEncoder(w).Encode(sandboxapi.PendingQuestionResponse{Question: &sandboxapi.PendingQuestion{
+				ToolUseID: "tool-1",
+				Questions: []sandboxapi.AskUserQuestion{{
+					Question: "Which database?",
+					Options:  []sandboxapi.AskUserQuestionOption{{Label: "Postgres"}, {Label: "SQLite (Recommended)"}},
+				}},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/chat/answer"):
+			var req sandboxapi.AnswerQuestionRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			answered <- req
+			_ = json.NewEncoder(w).Encode(sandboxapi.AnswerQuestionResponse{Success: true})
+		case strings.HasSuffix(r.URL.Path, "/chat/cancel"):
+			cancelled <- struct{}{}
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mockProvider := &mockSandboxProvider{secret: "test-secret", handler: handler}
+
+	sandboxSvc := NewSandboxService(testStore, mockProvider, &config.Config{}, nil, nil, nil)
+	poller := NewSessionStatusPoller(testStore, sandboxSvc, nil, slog.Default())
+
+	project := &model.Project{ID: "test-project", Name: "Test"}
+	workspace := &model.Workspace{ID: "test-ws", ProjectID: project.ID, Path: "/test", SourceType: "local"}
+	session := &model.Session{ID: "test-session", ProjectID: project.ID, WorkspaceID: workspace.ID, Status: model.SessionStatusRunning}
+	if sessionPolicy != nil {
+		session.QuestionPolicy, _ = json.Marshal(sessionPolicy)
+	}
+	if err := testStore.CreateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	if err := testStore.CreateWorkspace(ctx, workspace); err != nil {
+		t.Fatal(err)
+	}
+	if err := testStore.CreateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mockProvider.Create(ctx, session.ID, sandbox.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	return poller, session, answered, cancelled
+}
+
+func TestSessionStatusPoller_AutoAnswersQuestion(t *testing.T) {
+	poller, _, answered, _ := newQuestionPolicyPoller(t, &model.QuestionPolicy{Mode: model.QuestionPolicyRecommended})
+
+	hasRunning, err := poller.checkRunningSessions(context.Background())
+	if err != nil {
+		t.Fatalf("checkRunningSessions failed: %v", err)
+	}
+	if !hasRunning {
+		t.Error("Expected session to still be running")
+	}
+
+	select {
+	case req := <-answered:
+		want := "SQLite (Recommended)"
+		if req.ToolUseID != "tool-1" || req.Answers["Which database?"] != want {
+			t.Errorf("Unexpected answer %+v", req)
+		}
+	default:
+		t.Fatal("Expected the question to be answered")
+	}
+}
+
+func TestSessionStatusPoller_QuestionTimeout(t *testing.T) {
+	poller, session, answered, cancelled := newQuestionPolicyPoller(t, &model.QuestionPolicy{Mode: model.QuestionPolicyManual, TimeoutMinutes: 5})
+	ctx := context.Background()
+
+	// The first sighting starts the clock
+	if _, err := poller.checkRunningSessions(ctx); err != nil {
+		t.Fatalf("checkRunningSessions failed: %v", err)
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("Expected no cancellation before the timeout")
+	default:
+	}
+
+	poller.questionSightings[session.ID] = questionSighting{toolUseID: "tool-1", seenAt: time.Now().Add(-6 * time.Minute)}
+	if _, err := poller.checkRunningSessions(ctx); err != nil {
+		t.Fatalf("checkRunningSessions failed: %v", err)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("Expected the completion to be cancelled after the timeout")
+	}
+	select {
+	case req := <-answered:
+		t.Errorf("Expected manual policy not to answer, got %+v", req)
+	default:
+	}
+}
+
+func TestSessionStatusPoller_FailPolicyLeavesQuestion(t *testing.T) {
+	poller, _, answered, cancelled := newQuestionPolicyPoller(t, &model.QuestionPolicy{Mode: model.QuestionPolicyFail})
+
+	if _, err := poller.checkRunningSessions(context.Background()); err != nil {
+		t.Fatalf("checkRunningSessions failed: %v", err)
+	}
+	select {
+	case req := <-answered:
+		t.Errorf("Expected fail policy not to answer, got %+v", req)
+	case <-cancelled:
+		t.Error("Expected fail policy without a timeout not to cancel")
+	default:
+	}
+}
@@ -3,22 +3,29 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
+	"maps"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/devcontainer"
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/store"
+	"github.com/obot-platform/discobot/server/internal/tracing"
 )
 
+// sandboxLog is the logger for sandbox lifecycle and reconciliation messages.
+var sandboxLog = logging.Component("sandbox")
+
 // SandboxService manages sandbox lifecycle for sessions.
 type SandboxService struct {
 	store              *store.Store
@@ -112,7 +119,7 @@ func (s *SandboxService) ensureSandboxReady(ctx context.Context, sessionID strin
 		// This fast-path check avoids expensive reconciliation when everything is healthy.
 		sb, err := s.provider.Get(ctx, sessionID)
 		if errors.Is(err, sandbox.ErrNotFound) || (err == nil && sb.Status != sandbox.StatusRunning) {
-			log.Printf("Session %s status is %s but container not running, reconciling", sessionID, sess.Status)
+			sandboxLog.InfoContext(withSession(ctx, sessionID), "container not running, reconciling", "status", sess.Status)
 			return s.ReconcileSandbox(ctx, sessionID)
 		}
 		if err != nil {
@@ -122,7 +129,7 @@ func (s *SandboxService) ensureSandboxReady(ctx context.Context, sessionID strin
 		// This catches cases where the container is mid-shutdown (SIGTERM received,
 		// Docker still reports "running", but internal services are dead).
 		if err := s.probeSandboxHealth(ctx, sessionID); err != nil {
-			log.Printf("Session %s container running but health check failed (%v), reconciling", sessionID, err)
+			sandboxLog.WarnContext(withSession(ctx, sessionID), "container running but health check failed, reconciling", "error", err)
 			return s.ReconcileSandbox(ctx, sessionID)
 		}
 		return nil
@@ -131,7 +138,7 @@ func (s *SandboxService) ensureSandboxReady(ctx context.Context, sessionID strin
 	case model.SessionStatusInitializing, model.SessionStatusReinitializing,
 		model.SessionStatusCloning, model.SessionStatusPullingImage, model.SessionStatusCreatingSandbox:
 		if err := s.waitForSessionReady(ctx, sessionID); err != nil {
-			log.Printf("Session %s wait failed (%v), attempting reconciliation", sessionID, err)
+			sandboxLog.WarnContext(withSession(ctx, sessionID), "wait for session failed, attempting reconciliation", "error", err)
 			return s.ReconcileSandbox(ctx, sessionID)
 		}
 		return nil
@@ -178,7 +185,8 @@ func (s *SandboxService) waitForSessionReady(ctx context.Context, sessionID stri
 
 // ReconcileSandbox reinitializes the sandbox by enqueuing a job and waiting for completion.
 func (s *SandboxService) ReconcileSandbox(ctx context.Context, sessionID string) error {
-	log.Printf("Reconciling sandbox for session %s", sessionID)
+	ctx = withSession(ctx, sessionID)
+	sandboxLog.InfoContext(ctx, "reconciling sandbox")
 
 	// Look up projectID from session
 	sess, err := s.store.GetSessionByID(ctx, sessionID)
@@ -189,19 +197,19 @@ func (s *SandboxService) ReconcileSandbox(ctx context.Context, sessionID string)
 
 	// Update status to reinitializing
 	if err := s.store.UpdateSessionStatus(ctx, sessionID, model.SessionStatusReinitializing, nil); err != nil {
-		log.Printf("Warning: failed to update session status for %s: %v", sessionID, err)
+		sandboxLog.WarnContext(ctx, "failed to update session status", "error", err)
 	}
 
 	// Emit SSE event for status change
 	if s.eventBroker != nil {
 		if err := s.eventBroker.PublishSessionUpdated(ctx, projectID, sessionID, model.SessionStatusReinitializing, ""); err != nil {
-			log.Printf("Warning: failed to publish session update event: %v", err)
+			sandboxLog.WarnContext(ctx, "failed to publish session update event", "error", err)
 		}
 	}
 
 	// If job enqueuer is not available (e.g., in tests), fall back to direct initialization
 	if s.jobEnqueuer == nil {
-		log.Printf("Job enqueuer not available, falling back to direct initialization for session %s", sessionID)
+		sandboxLog.InfoContext(ctx, "job enqueuer not available, falling back to direct initialization")
 		if s.sessionInitializer == nil {
 			return fmt.Errorf("no session initializer available for session %s", sessionID)
 		}
@@ -225,7 +233,7 @@ func (s *SandboxService) ReconcileSandbox(ctx context.Context, sessionID string)
 		AgentID:     agentID,
 	})
 	if err != nil {
-		log.Printf("Note: session init job may already exist for %s: %v", sessionID, err)
+		sandboxLog.InfoContext(ctx, "session init job may already exist", "error", err)
 	}
 
 	// Wait for job to complete
@@ -241,7 +249,7 @@ func (s *SandboxService) ReconcileSandbox(ctx context.Context, sessionID string)
 		return fmt.Errorf("session initialization failed: %s", errorMsg)
 	}
 
-	log.Printf("Session %s initialized successfully via job", sessionID)
+	sandboxLog.InfoContext(ctx, "session initialized via job")
 	return nil
 }
 
@@ -280,7 +288,8 @@ func (s *SandboxService) CreateForSession(ctx context.Context, sessionID string)
 	sharedSecret := generateSandboxSecret(32)
 
 	// Create sandbox with session configuration
-	// Note: The sandbox image is configured globally on the provider via SANDBOX_IMAGE env var
+	// Note: The sandbox image is configured globally on the provider via SANDBOX_IMAGE env var,
+	// unless the workspace has a devcontainer image of its own
 	opts := sandbox.CreateOptions{
 		SharedSecret: sharedSecret,
 		Labels: map[string]string{
@@ -295,6 +304,21 @@ func (s *SandboxService) CreateForSession(ctx context.Context, sessionID string)
 			Timeout: s.cfg.SandboxIdleTimeout,
 		},
 	}
+	applyWorkspaceSandboxOptions(&opts, workspace)
+	applyTracingOptions(&opts)
+
+	// Let the agent enforce the project's cache limits before mounting
+	// caches, unless another sandbox of the project is using them
+	projectCtx := logging.With(ctx, "project_id", session.ProjectID)
+	if project, err := s.store.GetProjectByID(ctx, session.ProjectID); err != nil {
+		sandboxLog.WarnContext(projectCtx, "failed to get project for cache limits", "error", err)
+	} else if inUse, err := projectCacheInUse(ctx, s.store, session.ProjectID, sessionID); err != nil {
+		sandboxLog.WarnContext(projectCtx, "failed to check project cache use", "error", err)
+	} else if inUse {
+		sandboxLog.DebugContext(projectCtx, "skipping cache pruning while other sandboxes use the cache")
+	} else {
+		applyCacheLimitOptions(&opts, project)
+	}
 
 	// Create the sandbox
 	_, err = s.provider.Create(ctx, sessionID, opts)
@@ -343,9 +367,48 @@ func (s *SandboxService) Attach(ctx context.Context, sessionID string, rows, col
 	return s.provider.Attach(ctx, sessionID, opts)
 }
 
-// StopForSession stops the sandbox for a session.
+// StopForSession stops the sandbox for a session, giving its shutdown hooks time to run.
 func (s *SandboxService) StopForSession(ctx context.Context, sessionID string) error {
-	return s.provider.Stop(ctx, sessionID, 10*time.Second)
+	return s.provider.Stop(ctx, sessionID, sandbox.StopTimeout)
+}
+
+// applyWorkspaceSandboxOptions sets the sandbox image and environment derived
+// from the workspace's devcontainer.json, if it has one.
+func applyWorkspaceSandboxOptions(opts *sandbox.CreateOptions, workspace *model.Workspace) {
+	if workspace.SandboxImage != nil {
+		opts.Image = *workspace.SandboxImage
+	}
+	if len(workspace.Devcontainer) > 0 {
+		if opts.Env == nil {
+			opts.Env = make(map[string]string)
+		}
+		opts.Env[devcontainer.EnvVar] = base64.StdEncoding.EncodeToString(workspace.Devcontainer)
+	}
+}
+
+// applyCacheLimitOptions passes the project's cache limits to the sandbox.
+func applyCacheLimitOptions(opts *sandbox.CreateOptions, project *model.Project) {
+	env := cacheLimitEnv(project)
+	if len(env) == 0 {
+		return
+	}
+	if opts.Env == nil {
+		opts.Env = make(map[string]string)
+	}
+	maps.Copy(opts.Env, env)
+}
+
+// applyTracingOptions passes the server's OTEL_* configuration to the
+// sandbox so the sandbox proxy's spans are exported to the same collector.
+func applyTracingOptions(opts *sandbox.CreateOptions) {
+	env := tracing.SandboxEnv()
+	if len(env) == 0 {
+		return
+	}
+	if opts.Env == nil {
+		opts.Env = make(map[string]string)
+	}
+	maps.Copy(opts.Env, env)
 }
 
 // probeSandboxHealth does a fast, single-attempt HTTP health check against the
@@ -398,7 +461,7 @@ func (s *SandboxService) Provider() sandbox.Provider {
 func (s *SandboxService) ReconcileSandboxes(ctx context.Context) error {
 	expectedImage := s.provider.Image()
 	if expectedImage == "" {
-		log.Printf("No sandbox image configured, skipping reconciliation")
+		sandboxLog.InfoContext(ctx, "no sandbox image configured, skipping reconciliation")
 		return nil
 	}
 
@@ -407,48 +470,49 @@ func (s *SandboxService) ReconcileSandboxes(ctx context.Context) error {
 		return fmt.Errorf("failed to list sandboxes: %w", err)
 	}
 
-	log.Printf("Reconciling %d sandboxes (expected image: %s)", len(sandboxes), expectedImage)
+	sandboxLog.InfoContext(ctx, "reconciling sandboxes", "count", len(sandboxes), "image", expectedImage)
 
 	for _, sb := range sandboxes {
+		sbCtx := withSession(ctx, sb.SessionID)
+
 		// Check if the sandbox uses the expected image
 		if sb.Image == expectedImage {
-			log.Printf("Sandbox for session %s uses correct image", sb.SessionID)
+			sandboxLog.DebugContext(sbCtx, "sandbox uses correct image")
 			continue
 		}
 
-		log.Printf("Sandbox for session %s uses outdated image %s (expected %s), recreating...",
-			sb.SessionID, sb.Image, expectedImage)
+		sandboxLog.InfoContext(sbCtx, "sandbox uses outdated image, recreating", "image", sb.Image)
 
 		// Check if the session exists; if not, remove orphaned sandbox
 		_, err := s.store.GetSessionByID(ctx, sb.SessionID)
 		if err != nil {
-			log.Printf("Failed to get session %s, removing orphaned sandbox: %v", sb.SessionID, err)
+			sandboxLog.WarnContext(sbCtx, "failed to get session, removing orphaned sandbox", "error", err)
 			// Preserve volumes for orphaned sandboxes in case of recovery
 			if err := s.provider.Remove(ctx, sb.SessionID); err != nil {
-				log.Printf("Failed to remove orphaned sandbox for session %s: %v", sb.SessionID, err)
+				sandboxLog.ErrorContext(sbCtx, "failed to remove orphaned sandbox", "error", err)
 			}
 			continue
 		}
 
 		// Remove the old sandbox (preserve volume for image update)
 		if err := s.provider.Remove(ctx, sb.SessionID); err != nil {
-			log.Printf("Failed to remove sandbox for session %s: %v", sb.SessionID, err)
+			sandboxLog.ErrorContext(sbCtx, "failed to remove sandbox", "error", err)
 			continue
 		}
 
 		// Recreate the sandbox with the correct image via job system
 		// This ensures proper serialization with any concurrent user operations
 		if err := s.ReconcileSandbox(ctx, sb.SessionID); err != nil {
-			log.Printf("Failed to recreate sandbox for session %s: %v", sb.SessionID, err)
+			sandboxLog.ErrorContext(sbCtx, "failed to recreate sandbox", "error", err)
 			continue
 		}
 
-		log.Printf("Successfully recreated sandbox for session %s with image %s", sb.SessionID, expectedImage)
+		sandboxLog.InfoContext(sbCtx, "recreated sandbox", "image", expectedImage)
 	}
 
 	// Run provider-specific reconciliation (BuildKit containers, image cleanup, etc.)
 	if err := s.provider.Reconcile(ctx); err != nil {
-		log.Printf("Warning: Provider reconciliation failed: %v", err)
+		sandboxLog.WarnContext(ctx, "provider reconciliation failed", "error", err)
 	}
 
 	return nil
@@ -485,38 +549,40 @@ func (s *SandboxService) ReconcileSessionStates(ctx context.Context) error {
 		return fmt.Errorf("failed to list active sessions: %w", err)
 	}
 
-	log.Printf("Reconciling state for %d active/in-progress sessions", len(activeSessions))
+	sandboxLog.InfoContext(ctx, "reconciling active and in-progress session states", "count", len(activeSessions))
 
 	for _, session := range activeSessions {
+		sessCtx := logging.With(ctx, "project_id", session.ProjectID, "session_id", session.ID, "status", session.Status)
+
 		sb, err := s.provider.Get(ctx, session.ID)
 		if errors.Is(err, sandbox.ErrNotFound) {
 			// Sandbox doesn't exist - mark as stopped, will be recreated on demand
-			log.Printf("Session %s (status: %s) has no sandbox, marking as stopped", session.ID, session.Status)
+			sandboxLog.InfoContext(sessCtx, "session has no sandbox, marking as stopped")
 			if err := s.store.UpdateSessionStatus(ctx, session.ID, model.SessionStatusStopped, nil); err != nil {
-				log.Printf("Failed to update session %s status: %v", session.ID, err)
+				sandboxLog.ErrorContext(sessCtx, "failed to update session status", "error", err)
 			}
 			continue
 		}
 		if err != nil {
-			log.Printf("Failed to get sandbox for session %s: %v", session.ID, err)
+			sandboxLog.ErrorContext(sessCtx, "failed to get sandbox", "error", err)
 			continue
 		}
 
 		// Check if sandbox is in a failed state
 		if sb.Status == sandbox.StatusFailed {
-			log.Printf("Session %s has failed sandbox (error: %s), marking session as error", session.ID, sb.Error)
+			sandboxLog.WarnContext(sessCtx, "session has failed sandbox, marking as error", "error", sb.Error)
 			errMsg := fmt.Sprintf("Sandbox failed: %s", sb.Error)
 			if err := s.store.UpdateSessionStatus(ctx, session.ID, model.SessionStatusError, &errMsg); err != nil {
-				log.Printf("Failed to update session %s status: %v", session.ID, err)
+				sandboxLog.ErrorContext(sessCtx, "failed to update session status", "error", err)
 			}
 			continue
 		}
 
 		// Check if sandbox is stopped or just created (not running)
 		if sb.Status == sandbox.StatusStopped || sb.Status == sandbox.StatusCreated {
-			log.Printf("Session %s has %s sandbox, marking as stopped", session.ID, sb.Status)
+			sandboxLog.InfoContext(sessCtx, "sandbox not running, marking session as stopped", "sandbox_status", sb.Status)
 			if err := s.store.UpdateSessionStatus(ctx, session.ID, model.SessionStatusStopped, nil); err != nil {
-				log.Printf("Failed to update session %s status: %v", session.ID, err)
+				sandboxLog.ErrorContext(sessCtx, "failed to update session status", "error", err)
 			}
 			continue
 		}
@@ -536,41 +602,40 @@ func (s *SandboxService) ReconcileSessionStates(ctx context.Context) error {
 					// Failed to get chat status - assume chat is not running
 					// This handles cases where the sandbox doesn't have the agent API
 					// or the agent API is not responding
-					log.Printf("Failed to get chat status for session %s (assuming not running): %v", session.ID, err)
-					log.Printf("Session %s marked as running but chat status unavailable, updating to ready", session.ID)
+					sandboxLog.WarnContext(sessCtx, "chat status unavailable, updating running session to ready", "error", err)
 					if err := s.store.UpdateSessionStatus(ctx, session.ID, model.SessionStatusReady, nil); err != nil {
-						log.Printf("Failed to update session %s status: %v", session.ID, err)
+						sandboxLog.ErrorContext(sessCtx, "failed to update session status", "error", err)
 					}
 					continue
 				}
 
 				if !chatStatus.IsRunning {
 					// Chat is not actually running - reset to ready
-					log.Printf("Session %s marked as running but chat not active, updating to ready", session.ID)
+					sandboxLog.InfoContext(sessCtx, "chat not active, updating running session to ready")
 					if err := s.store.UpdateSessionStatus(ctx, session.ID, model.SessionStatusReady, nil); err != nil {
-						log.Printf("Failed to update session %s status: %v", session.ID, err)
+						sandboxLog.ErrorContext(sessCtx, "failed to update session status", "error", err)
 					}
 				} else {
 					completionID := "unknown"
 					if chatStatus.CompletionID != nil {
 						completionID = *chatStatus.CompletionID
 					}
-					log.Printf("Session %s chat is running (completion: %s)", session.ID, completionID)
+					sandboxLog.InfoContext(sessCtx, "chat is running", "completion_id", completionID)
 				}
 				continue
 			}
 
 			// Update session status if it was in intermediate state
 			if session.Status != model.SessionStatusReady {
-				log.Printf("Session %s was in %s state but sandbox is running, updating to ready", session.ID, session.Status)
+				sandboxLog.InfoContext(sessCtx, "sandbox is running, updating session to ready")
 				if err := s.store.UpdateSessionStatus(ctx, session.ID, model.SessionStatusReady, nil); err != nil {
-					log.Printf("Failed to update session %s status: %v", session.ID, err)
+					sandboxLog.ErrorContext(sessCtx, "failed to update session status", "error", err)
 				}
 			}
 			continue
 		}
 
-		log.Printf("Session %s (status: %s) sandbox status: %s", session.ID, session.Status, sb.Status)
+		sandboxLog.InfoContext(sessCtx, "unhandled sandbox status", "sandbox_status", sb.Status)
 	}
 
 	return nil
@@ -150,6 +150,24 @@ type SSELine struct {
 	Done bool
 }
 
+// AgentError returns the error reported by an agent "error" chunk, or nil
+// for any other chunk. The chunk is decoded rather than matched as text so
+// that errors quoted inside other chunks (tool output, say) are not mistaken
+// for the agent failing.
+func (l SSELine) AgentError() error {
+	var chunk struct {
+		Type      string `json:"type"`
+		ErrorText string `json:"errorText"`
+	}
+	if err := json.Unmarshal([]byte(l.Data), &chunk); err != nil || chunk.Type != "error" {
+		return nil
+	}
+	if chunk.ErrorText == "" {
+		return fmt.Errorf("agent error: %s", l.Data)
+	}
+	return fmt.Errorf("agent error: %s", chunk.ErrorText)
+}
+
 // getHTTPClient returns an HTTP client configured for the sandbox.
 // This uses the provider's HTTPClient which handles transport-level details
 // (TCP for Docker, vsock for vz, mock transport for testing).
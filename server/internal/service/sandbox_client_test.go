@@ -735,3 +735,32 @@ func TestSandboxChatClient_GetDiff_ReturnsCorrectResponseType(t *testing.T) {
 		})
 	}
 }
+
+func TestSSELine_AgentError(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "error chunk", data: `{"type":"error","errorText":"boom"}`, want: "agent error: boom"},
+		{name: "error chunk with spacing", data: `{"type": "error", "errorText": "boom"}`, want: "agent error: boom"},
+		{name: "error chunk without text", data: `{"type":"error"}`, want: `agent error: {"type":"error"}`},
+		{name: "text quoting an error", data: `{"type":"text-delta","delta":"{\"type\":\"error\"}"}`},
+		{name: "tool output quoting an error", data: `{"type":"tool-output-available","output":"{\"type\":\"error\"}"}`},
+		{name: "not JSON", data: `"type":"error"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SSELine{Data: tt.data}.AgentError()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -21,6 +21,12 @@ type SandboxIdleMonitor struct {
 	idleTimeout   time.Duration
 	checkInterval time.Duration
 
+	// Sustained CPU usage at or above cpuThreshold percent for cpuSustain
+	// counts as activity. Disabled when cpuThreshold is 0.
+	cpuThreshold float64
+	cpuSustain   time.Duration
+	busySince    map[string]time.Time
+
 	mu           sync.Mutex
 	running      bool
 	stopChan     chan struct{}
@@ -44,10 +50,21 @@ func NewSandboxIdleMonitor(
 		logger:        logger.With("component", "sandbox_idle_monitor"),
 		idleTimeout:   idleTimeout,
 		checkInterval: checkInterval,
+		busySince:     make(map[string]time.Time),
 		stopChan:      make(chan struct{}),
 	}
 }
 
+// SetCPUActivity makes sandboxes that keep using at least thresholdPercent
+// CPU (100 = one core) for the sustain duration count as active, so long
+// builds and test runs aren't stopped for lack of API calls. Sandboxes are
+// only sampled once they have been quiet for a check interval, and only if
+// the provider implements sandbox.MetricsProvider. Must be called before Start.
+func (m *SandboxIdleMonitor) SetCPUActivity(thresholdPercent float64, sustain time.Duration) {
+	m.cpuThreshold = thresholdPercent
+	m.cpuSustain = sustain
+}
+
 // Start begins the idle monitoring loop.
 func (m *SandboxIdleMonitor) Start(ctx context.Context) {
 	m.mu.Lock()
@@ -63,7 +80,9 @@ func (m *SandboxIdleMonitor) Start(ctx context.Context) {
 
 	m.logger.Info("sandbox idle monitor started",
 		"idle_timeout", m.idleTimeout,
-		"check_interval", m.checkInterval)
+		"check_interval", m.checkInterval,
+		"cpu_threshold", m.cpuThreshold,
+		"cpu_sustain", m.cpuSustain)
 }
 
 // Shutdown gracefully stops the idle monitor.
@@ -129,6 +148,10 @@ func (m *SandboxIdleMonitor) checkIdleSessions(ctx context.Context) error {
 
 	m.logger.Debug("checking sessions for idle timeout", "count", len(sessions))
 
+	if m.cpuThreshold > 0 {
+		m.recordCPUActivity(ctx, sessions)
+	}
+
 	stoppedCount := 0
 	for _, session := range sessions {
 		// Get last activity time from in-memory tracking
@@ -199,3 +222,68 @@ func (m *SandboxIdleMonitor) shouldStopSession(ctx context.Context, session *mod
 
 	return true
 }
+
+// recordCPUActivity samples the sandboxes without recent activity and
+// records activity for those that have stayed above the CPU threshold for the
+// sustain duration. Sandboxes that weren't sampled keep their busy state.
+func (m *SandboxIdleMonitor) recordCPUActivity(ctx context.Context, sessions []*model.Session) {
+	now := time.Now()
+	sampled := make([]bool, len(sessions))
+	busy := make([]bool, len(sessions))
+	sem := make(chan struct{}, projectMetricsConcurrency)
+	var wg sync.WaitGroup
+	for i, session := range sessions {
+		lastActivity := m.sandboxSvc.GetLastActivity(session.ID)
+		if lastActivity.IsZero() {
+			lastActivity = session.UpdatedAt
+		}
+		// Half an interval leaves room for ticker jitter after the activity
+		// this method itself records
+		if now.Sub(lastActivity) < m.checkInterval/2 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			metrics, err := m.sessionSvc.sampleMetrics(ctx, session.ID)
+			if err != nil {
+				m.logger.Debug("failed to sample sandbox CPU for idle check",
+					"session_id", session.ID, "error", err)
+				return
+			}
+			sampled[i] = true
+			busy[i] = metrics.CPUPercent >= m.cpuThreshold
+		}()
+	}
+	wg.Wait()
+
+	// Only the monitor loop touches busySince
+	seen := make(map[string]bool, len(sessions))
+	for i, session := range sessions {
+		seen[session.ID] = true
+		if !sampled[i] {
+			continue
+		}
+		if !busy[i] {
+			delete(m.busySince, session.ID)
+			continue
+		}
+		since, ok := m.busySince[session.ID]
+		if !ok {
+			since = now
+			m.busySince[session.ID] = now
+		}
+		if now.Sub(since) >= m.cpuSustain {
+			m.logger.Debug("recording sustained CPU usage as activity",
+				"session_id", session.ID, "busy_since", since)
+			m.sandboxSvc.RecordActivity(session.ID)
+		}
+	}
+	for id := range m.busySince {
+		if !seen[id] {
+			delete(m.busySince, id)
+		}
+	}
+}
@@ -571,3 +571,86 @@ func TestSandboxIdleMonitor_MultipleIdleSessions(t *testing.T) {
 		}
 	}
 }
+
+// metricsSandboxProvider adds sandbox.MetricsProvider to mockSandboxProvider.
+type metricsSandboxProvider struct {
+	*mockSandboxProvider
+	cpuPercent float64
+}
+
+func (m *metricsSandboxProvider) Metrics(_ context.Context, _ string) (*sandbox.ResourceMetrics, error) {
+	return &sandbox.ResourceMetrics{Timestamp: time.Now(), CPUPercent: m.cpuPercent}, nil
+}
+
+// TestSandboxIdleMonitor_SustainedCPUActivity verifies that sandboxes busy
+// for the sustain duration count as active.
+func TestSandboxIdleMonitor_SustainedCPUActivity(t *testing.T) {
+	tests := []struct {
+		name       string
+		cpuPercent float64
+		sustain    time.Duration
+		wantStop   bool
+	}{
+		{name: "busy", cpuPercent: 80, sustain: 0, wantStop: false},
+		{name: "below threshold", cpuPercent: 5, sustain: 0, wantStop: true},
+		{name: "not busy long enough", cpuPercent: 80, sustain: time.Hour, wantStop: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			testStore := setupTestStoreForIdleMonitor(t)
+
+			var stopCalled atomic.Bool
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/chat/status") {
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(sandboxapi.ChatStatusResponse{IsRunning: false})
+					return
+				}
+				http.NotFound(w, r)
+			})
+			mockProvider := &metricsSandboxProvider{
+				mockSandboxProvider: &mockSandboxProvider{
+					secret:  "test-secret",
+					handler: handler,
+					onStop:  func(string) { stopCalled.Store(true) },
+				},
+				cpuPercent: tt.cpuPercent,
+			}
+
+			cfg := &config.Config{}
+			sandboxSvc := NewSandboxService(testStore, mockProvider, cfg, nil, nil, nil)
+			sessionSvc := NewSessionService(testStore, nil, mockProvider, sandboxSvc, nil, nil)
+			monitor := NewSandboxIdleMonitor(testStore, sandboxSvc, sessionSvc, slog.Default(),
+				time.Second, 100*time.Millisecond)
+			monitor.SetCPUActivity(50, tt.sustain)
+
+			project := &model.Project{ID: "test-project", Name: "Test"}
+			workspace := &model.Workspace{ID: "test-ws", ProjectID: project.ID, Path: "/test", SourceType: "local"}
+			session := &model.Session{
+				ID:          "test-session",
+				ProjectID:   project.ID,
+				WorkspaceID: workspace.ID,
+				Status:      model.SessionStatusReady,
+				UpdatedAt:   time.Now().Add(-2 * time.Second),
+			}
+			if err := testStore.CreateProject(ctx, project); err != nil {
+				t.Fatal(err)
+			}
+			if err := testStore.CreateWorkspace(ctx, workspace); err != nil {
+				t.Fatal(err)
+			}
+			if err := testStore.CreateSession(ctx, session); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := monitor.checkIdleSessions(ctx); err != nil {
+				t.Fatalf("checkIdleSessions failed: %v", err)
+			}
+			if stopCalled.Load() != tt.wantStop {
+				t.Errorf("stopped = %v, want %v", stopCalled.Load(), tt.wantStop)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/obot-platform/discobot/server/internal/sandbox"
@@ -42,7 +41,7 @@ func withReconciliation[T any](ctx context.Context, c *SessionClient, operation
 	}
 
 	if errors.Is(err, sandbox.ErrNotFound) || errors.Is(err, sandbox.ErrNotRunning) || isSandboxUnavailableError(err) {
-		log.Printf("Sandbox unavailable for session %s, reconciling: %v", c.sessionID, err)
+		sandboxLog.WarnContext(withSession(ctx, c.sessionID), "sandbox unavailable, reconciling", "error", err)
 
 		if reconcileErr := c.sandboxSvc.ReconcileSandbox(ctx, c.sessionID); reconcileErr != nil {
 			var zero T
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"log"
 
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/model"
@@ -37,17 +36,17 @@ func (w *SandboxWatcher) Start(ctx context.Context) error {
 		return err
 	}
 
-	log.Printf("SandboxWatcher: started watching sandbox events")
+	sandboxLog.InfoContext(ctx, "sandbox watcher started")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("SandboxWatcher: stopped")
+			sandboxLog.InfoContext(ctx, "sandbox watcher stopped")
 			return ctx.Err()
 
 		case event, ok := <-eventCh:
 			if !ok {
-				log.Printf("SandboxWatcher: event channel closed")
+				sandboxLog.InfoContext(ctx, "sandbox watcher event channel closed")
 				return nil
 			}
 			w.handleEvent(ctx, event)
@@ -57,12 +56,14 @@ func (w *SandboxWatcher) Start(ctx context.Context) error {
 
 // handleEvent processes a sandbox state change event.
 func (w *SandboxWatcher) handleEvent(ctx context.Context, event sandbox.StateEvent) {
+	ctx = withSession(ctx, event.SessionID)
+
 	// Get the session to check if it exists and get its project ID
 	session, err := w.store.GetSessionByID(ctx, event.SessionID)
 	if err != nil {
 		// Session doesn't exist - the sandbox is orphaned
 		// This can happen if a session was deleted but the sandbox wasn't cleaned up
-		log.Printf("SandboxWatcher: session %s not found for sandbox event (status: %s)", event.SessionID, event.Status)
+		sandboxLog.InfoContext(ctx, "session not found for sandbox event", "status", event.Status)
 		return
 	}
 
@@ -102,7 +103,7 @@ func (w *SandboxWatcher) handleEvent(ctx context.Context, event sandbox.StateEve
 			session.Status == model.SessionStatusInitializing ||
 			session.Status == model.SessionStatusCreatingSandbox {
 			newStatus = model.SessionStatusStopped
-			log.Printf("SandboxWatcher: sandbox for session %s was removed, marking session as stopped", event.SessionID)
+			sandboxLog.InfoContext(ctx, "sandbox was removed, marking session as stopped")
 		}
 
 	case sandbox.StatusCreated:
@@ -110,23 +111,23 @@ func (w *SandboxWatcher) handleEvent(ctx context.Context, event sandbox.StateEve
 		// No action needed for session
 
 	default:
-		log.Printf("SandboxWatcher: unknown sandbox status: %s for session %s", event.Status, event.SessionID)
+		sandboxLog.WarnContext(ctx, "unknown sandbox status", "status", event.Status)
 		return
 	}
 
 	// Update session status if needed
 	if newStatus != "" {
-		log.Printf("SandboxWatcher: updating session %s status from %s to %s", event.SessionID, session.Status, newStatus)
+		sandboxLog.InfoContext(ctx, "updating session status", "from", session.Status, "to", newStatus)
 
 		if err := w.store.UpdateSessionStatus(ctx, event.SessionID, newStatus, errMsg); err != nil {
-			log.Printf("SandboxWatcher: failed to update session %s status: %v", event.SessionID, err)
+			sandboxLog.ErrorContext(ctx, "failed to update session status", "error", err)
 			return
 		}
 
 		// Publish session update event
 		if w.broker != nil {
 			if err := w.broker.PublishSessionUpdated(ctx, session.ProjectID, event.SessionID, newStatus, session.CommitStatus); err != nil {
-				log.Printf("SandboxWatcher: failed to publish session update event: %v", err)
+				sandboxLog.WarnContext(ctx, "failed to publish session update event", "error", err)
 			}
 		}
 	}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/cron"
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/logging"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// scheduleLog is the logger for scheduled run messages.
+var scheduleLog = logging.Component("schedule")
+
+// ErrInvalidSchedule is returned when a schedule request fails validation.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// scheduleRunHistoryLimit is how many runs ListScheduleRuns returns.
+const scheduleRunHistoryLimit = 50
+
+// CreateScheduleRequest contains the parameters for creating a schedule.
+type CreateScheduleRequest struct {
+	Name        string `json:"name"`
+	WorkspaceID string `json:"workspaceId"`
+	AgentID     string `json:"agentId,omitempty"` // Defaults to the project's default agent
+	Model       string `json:"model,omitempty"`
+	Reasoning   string `json:"reasoning,omitempty"`
+	Prompt      string `json:"prompt"`
+	Cron        string `json:"cron"`
+	Timezone    string `json:"timezone,omitempty"` // IANA name, defaults to UTC
+	Enabled     *bool  `json:"enabled,omitempty"`  // Defaults to true
+	AutoCommit  bool   `json:"autoCommit,omitempty"`
+	AutoDelete  bool   `json:"autoDelete,omitempty"`
+}
+
+// UpdateScheduleRequest contains the fields to change on a schedule.
+// Nil fields are left unchanged.
+type UpdateScheduleRequest struct {
+	Name       *string `json:"name,omitempty"`
+	AgentID    *string `json:"agentId,omitempty"`
+	Model      *string `json:"model,omitempty"`
+	Reasoning  *string `json:"reasoning,omitempty"`
+	Prompt     *string `json:"prompt,omitempty"`
+	Cron       *string `json:"cron,omitempty"`
+	Timezone   *string `json:"timezone,omitempty"`
+	Enabled    *bool   `json:"enabled,omitempty"`
+	AutoCommit *bool   `json:"autoCommit,omitempty"`
+	AutoDelete *bool   `json:"autoDelete,omitempty"`
+}
+
+// ScheduleService manages scheduled sessions.
+type ScheduleService struct {
+	store          *store.Store
+	sessionService *SessionService
+	chatService    *ChatService
+	eventBroker    *events.Broker
+	jobEnqueuer    JobEnqueuer
+}
+
+// NewScheduleService creates a new schedule service.
+func NewScheduleService(s *store.Store, sessionService *SessionService, chatService *ChatService, eventBroker *events.Broker, jobEnqueuer JobEnqueuer) *ScheduleService {
+	return &ScheduleService{
+		store:          s,
+		sessionService: sessionService,
+		chatService:    chatService,
+		eventBroker:    eventBroker,
+		jobEnqueuer:    jobEnqueuer,
+	}
+}
+
+// CreateSchedule validates and creates a schedule.
+func (s *ScheduleService) CreateSchedule(ctx context.Context, projectID string, req CreateScheduleRequest) (*model.Schedule, error) {
+	workspace, err := s.store.GetWorkspaceByID(ctx, req.WorkspaceID)
+	if err != nil || workspace.ProjectID != projectID {
+		return nil, fmt.Errorf("%w: workspace %q not found", ErrInvalidSchedule, req.WorkspaceID)
+	}
+
+	schedule := &model.Schedule{
+		ProjectID:   projectID,
+		WorkspaceID: req.WorkspaceID,
+		AgentID:     req.AgentID,
+		Name:        strings.TrimSpace(req.Name),
+		Prompt:      strings.TrimSpace(req.Prompt),
+		Model:       nonEmptyPtr(req.Model),
+		Reasoning:   nonEmptyPtr(req.Reasoning),
+		Cron:        strings.TrimSpace(req.Cron),
+		Timezone:    req.Timezone,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		AutoCommit:  req.AutoCommit,
+		AutoDelete:  req.AutoDelete,
+	}
+	if schedule.AgentID == "" {
+		agent, err := s.store.GetDefaultAgent(ctx, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: no agent given and no default agent is configured", ErrInvalidSchedule)
+		}
+		schedule.AgentID = agent.ID
+	}
+	if err := s.validate(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CreateSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// validate checks a schedule's fields and computes its next run time.
+func (s *ScheduleService) validate(ctx context.Context, schedule *model.Schedule) error {
+	if schedule.Prompt == "" {
+		return fmt.Errorf("%w: prompt is required", ErrInvalidSchedule)
+	}
+	if schedule.Name == "" {
+		messages, _ := buildCommitMessage("", schedule.Prompt)
+		schedule.Name = deriveSessionName(messages)
+	}
+	if r := ptrToString(schedule.Reasoning); r != "" && r != "enabled" && r != "disabled" {
+		return fmt.Errorf("%w: reasoning must be \"enabled\", \"disabled\" or empty", ErrInvalidSchedule)
+	}
+
+	agent, err := s.store.GetAgentByID(ctx, schedule.AgentID)
+	if err != nil || agent.ProjectID != schedule.ProjectID {
+		return fmt.Errorf("%w: agent %q not found", ErrInvalidSchedule, schedule.AgentID)
+	}
+
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	next, err := nextScheduleRun(schedule, time.Now())
+	if err != nil {
+		return err
+	}
+	if next.IsZero() {
+		return fmt.Errorf("%w: cron expression %q never fires", ErrInvalidSchedule, schedule.Cron)
+	}
+	if schedule.Enabled {
+		schedule.NextRunAt = &next
+	} else {
+		schedule.NextRunAt = nil
+	}
+	return nil
+}
+
+// nextScheduleRun returns the schedule's next fire time after the given time,
+// evaluated in the schedule's timezone and returned in UTC.
+func nextScheduleRun(schedule *model.Schedule, after time.Time) (time.Time, error) {
+	expr, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, schedule.Timezone)
+	}
+	next := expr.Next(after.In(loc))
+	if next.IsZero() {
+		return next, nil
+	}
+	return next.UTC(), nil
+}
+
+// GetSchedule returns a schedule and validates it belongs to the project.
+func (s *ScheduleService) GetSchedule(ctx context.Context, projectID, scheduleID string) (*model.Schedule, error) {
+	schedule, err := s.store.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("schedule not found: %w", err)
+	}
+	if schedule.ProjectID != projectID {
+		return nil, fmt.Errorf("schedule does not belong to this project")
+	}
+	return schedule, nil
+}
+
+// ListSchedules returns all schedules for a project.
+func (s *ScheduleService) ListSchedules(ctx context.Context, projectID string) ([]*model.Schedule, error) {
+	schedules, err := s.store.ListSchedulesByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule applies the non-nil fields of req and recomputes the next run.
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, projectID, scheduleID string, req UpdateScheduleRequest) (*model.Schedule, error) {
+	schedule, err := s.GetSchedule(ctx, projectID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		schedule.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.AgentID != nil {
+		schedule.AgentID = *req.AgentID
+	}
+	if req.Model != nil {
+		schedule.Model = nonEmptyPtr(*req.Model)
+	}
+	if req.Reasoning != nil {
+		schedule.Reasoning = nonEmptyPtr(*req.Reasoning)
+	}
+	if req.Prompt != nil {
+		schedule.Prompt = strings.TrimSpace(*req.Prompt)
+	}
+	if req.Cron != nil {
+		schedule.Cron = strings.TrimSpace(*req.Cron)
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = *req.Timezone
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	if req.AutoCommit != nil {
+		schedule.AutoCommit = *req.AutoCommit
+	}
+	if req.AutoDelete != nil {
+		schedule.AutoDelete = *req.AutoDelete
+	}
+	if err := s.validate(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule deletes a schedule and its run history. Sessions created by
+// past runs are left alone.
+func (s *ScheduleService) DeleteSchedule(ctx context.Context, projectID, scheduleID string) error {
+	if _, err := s.GetSchedule(ctx, projectID, scheduleID); err != nil {
+		return err
+	}
+	return s.store.DeleteSchedule(ctx, scheduleID)
+}
+
+// ListScheduleRuns returns the most recent runs of a schedule, newest first.
+func (s *ScheduleService) ListScheduleRuns(ctx context.Context, projectID, scheduleID string) ([]*model.ScheduleRun, error) {
+	if _, err := s.GetSchedule(ctx, projectID, scheduleID); err != nil {
+		return nil, err
+	}
+	runs, err := s.store.ListScheduleRuns(ctx, scheduleID, scheduleRunHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+	return runs, nil
+}
+
+// TriggerSchedule starts a run immediately, regardless of the schedule's
+// cron expression or enabled state.
+func (s *ScheduleService) TriggerSchedule(ctx context.Context, projectID, scheduleID string) (*model.ScheduleRun, error) {
+	schedule, err := s.GetSchedule(ctx, projectID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startRun(ctx, schedule, true)
+}
+
+// EnqueueDue starts a run for every enabled schedule that is due at now and
+// advances it to its next fire time. Occurrences missed while the server was
+// down are collapsed into a single run. Called periodically by the dispatcher
+// leader.
+func (s *ScheduleService) EnqueueDue(ctx context.Context, now time.Time) error {
+	schedules, err := s.store.ListDueSchedules(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to list due schedules: %w", err)
+	}
+
+	for _, schedule := range schedules {
+		var next *time.Time
+		if t, err := nextScheduleRun(schedule, now); err != nil {
+			scheduleLog.WarnContext(ctx, "schedule has an invalid cron expression and will not run again", "schedule_id", schedule.ID, "error", err)
+		} else if !t.IsZero() {
+			next = &t
+		}
+
+		claimed, err := s.store.AdvanceSchedule(ctx, schedule.ID, *schedule.NextRunAt, next, now)
+		if err != nil {
+			scheduleLog.ErrorContext(ctx, "failed to advance schedule", "schedule_id", schedule.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := s.startRun(ctx, schedule, false); err != nil {
+			scheduleLog.ErrorContext(ctx, "failed to start schedule run", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// startRun records a new run and enqueues its job. If a previous run of the
+// schedule is still in progress the new run is recorded as skipped.
+func (s *ScheduleService) startRun(ctx context.Context, schedule *model.Schedule, manual bool) (*model.ScheduleRun, error) {
+	run := &model.ScheduleRun{
+		ScheduleID: schedule.ID,
+		ProjectID:  schedule.ProjectID,
+		Status:     model.ScheduleRunStatusPending,
+		Manual:     manual,
+	}
+	if err := s.store.CreateScheduleRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to create schedule run: %w", err)
+	}
+
+	err := s.jobEnqueuer.Enqueue(ctx, jobs.ScheduleRunPayload{
+		ProjectID:  schedule.ProjectID,
+		ScheduleID: schedule.ID,
+		RunID:      run.ID,
+	})
+	if err != nil {
+		status := model.ScheduleRunStatusFailed
+		if errors.Is(err, jobs.ErrJobAlreadyExists) {
+			status = model.ScheduleRunStatusSkipped
+			err = fmt.Errorf("previous run still in progress")
+		}
+		s.finishRun(ctx, schedule, run, status, err)
+		return run, nil
+	}
+
+	s.publishRunUpdated(ctx, run)
+	return run, nil
+}
+
+// RunSchedule executes a schedule run synchronously: it runs the prompt in a
+// new session, optionally commits and deletes the session, and records the
+// outcome. This is called by the dispatcher when processing a schedule_run job.
+func (s *ScheduleService) RunSchedule(ctx context.Context, projectID, scheduleID, runID string) error {
+	schedule, err := s.GetSchedule(ctx, projectID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	started, err := s.store.StartScheduleRun(ctx, runID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to start schedule run: %w", err)
+	}
+	run, err := s.store.GetScheduleRunByID(ctx, runID)
+	if err != nil {
+		return fmt.Errorf("schedule run not found: %w", err)
+	}
+	if run.ScheduleID != scheduleID {
+		return fmt.Errorf("schedule run does not belong to this schedule")
+	}
+	if !started {
+		if run.Status == model.ScheduleRunStatusRunning {
+			// A previous attempt died mid-run; don't send the prompt a second time
+			s.finishRun(ctx, schedule, run, model.ScheduleRunStatusFailed, fmt.Errorf("interrupted before completion"))
+		}
+		return nil
+	}
+	s.publishRunUpdated(ctx, run)
+
+	runErr := s.execute(ctx, schedule, run)
+	status := model.ScheduleRunStatusSucceeded
+	if runErr != nil {
+		status = model.ScheduleRunStatusFailed
+	}
+	s.finishRun(ctx, schedule, run, status, runErr)
+	return runErr
+}
+
+// execute does the work for RunSchedule.
+func (s *ScheduleService) execute(ctx context.Context, schedule *model.Schedule, run *model.ScheduleRun) error {
+	sessionID, err := s.chatService.RunHeadless(ctx, HeadlessRunRequest{
+		ProjectID:   schedule.ProjectID,
+		WorkspaceID: schedule.WorkspaceID,
+		AgentID:     schedule.AgentID,
+		Model:       ptrToString(schedule.Model),
+		Reasoning:   ptrToString(schedule.Reasoning),
+		Name:        fmt.Sprintf("%s (%s)", schedule.Name, time.Now().UTC().Format("2006-01-02 15:04")),
+		Prompt:      schedule.Prompt,
+		OnSessionCreated: func(sessionID string) {
+			run.SessionID = &sessionID
+			if err := s.store.UpdateScheduleRun(ctx, run); err != nil {
+				scheduleLog.ErrorContext(withSession(ctx, sessionID), "failed to record session for schedule run", "run_id", run.ID, "error", err)
+			}
+			s.publishRunUpdated(ctx, run)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if schedule.AutoCommit {
+		commit, err := s.sessionService.CommitAndWait(ctx, schedule.ProjectID, sessionID, s.jobEnqueuer)
+		if err != nil {
+			return err
+		}
+		run.AppliedCommit = commit
+	}
+
+	if schedule.AutoDelete {
+		if err := s.sessionService.DeleteSession(ctx, schedule.ProjectID, sessionID, s.jobEnqueuer); err != nil {
+			scheduleLog.WarnContext(withSession(ctx, sessionID), "failed to delete session after schedule run", "run_id", run.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// finishRun records a run's outcome and publishes a schedule_run_updated event.
+func (s *ScheduleService) finishRun(ctx context.Context, schedule *model.Schedule, run *model.ScheduleRun, status string, runErr error) {
+	// Use a fresh context so a timed out run is still recorded
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	run.Status = status
+	run.CompletedAt = &now
+	run.Error = nil
+	if runErr != nil {
+		run.Error = ptrString(runErr.Error())
+		scheduleLog.InfoContext(ctx, "schedule run finished", "schedule_id", schedule.ID, "run_id", run.ID, "status", status, "error", runErr)
+	}
+	if err := s.store.UpdateScheduleRun(ctx, run); err != nil {
+		scheduleLog.ErrorContext(ctx, "failed to update schedule run", "run_id", run.ID, "error", err)
+	}
+	s.publishRunUpdated(ctx, run)
+}
+
+// publishRunUpdated publishes a schedule_run_updated event for the run's current state.
+func (s *ScheduleService) publishRunUpdated(ctx context.Context, run *model.ScheduleRun) {
+	if s.eventBroker == nil {
+		return
+	}
+	if err := s.eventBroker.PublishScheduleRunUpdated(ctx, run.ProjectID, events.ScheduleRunUpdatedData{
+		ScheduleID: run.ScheduleID,
+		RunID:      run.ID,
+		Status:     run.Status,
+		SessionID:  ptrToString(run.SessionID),
+		Error:      ptrToString(run.Error),
+	}); err != nil {
+		scheduleLog.WarnContext(ctx, "failed to publish schedule run update event", "run_id", run.ID, "error", err)
+	}
+}
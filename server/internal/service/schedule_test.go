@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+// newTestScheduleService returns a schedule service whose job queue is
+// controlled by enqueueErr and records enqueued payloads.
+func newTestScheduleService(env *testEnv, enqueueErr *error) (*ScheduleService, *[]jobs.ScheduleRunPayload) {
+	var enqueued []jobs.ScheduleRunPayload
+	enqueuer := &mockJobEnqueuer{
+		enqueueFunc: func(_ context.Context, payload jobs.JobPayload) error {
+			if enqueueErr != nil && *enqueueErr != nil {
+				return *enqueueErr
+			}
+			if p, ok := payload.(jobs.ScheduleRunPayload); ok {
+				enqueued = append(enqueued, p)
+			}
+			return nil
+		},
+	}
+	return NewScheduleService(env.store, nil, nil, env.eventBroker, enqueuer), &enqueued
+}
+
+func TestCreateSchedule(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestScheduleService(env, nil)
+	ctx := context.Background()
+
+	schedule, err := svc.CreateSchedule(ctx, project.ID, CreateScheduleRequest{
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		Prompt:      "Bump dependencies",
+		Cron:        "0 2 * * *",
+		Timezone:    "UTC",
+		AutoCommit:  true,
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule failed: %v", err)
+	}
+	if schedule.Name != "Bump dependencies" {
+		t.Errorf("Expected name derived from prompt, got %q", schedule.Name)
+	}
+	if !schedule.Enabled {
+		t.Error("Expected schedule to be enabled by default")
+	}
+	if schedule.NextRunAt == nil || !schedule.NextRunAt.After(time.Now()) {
+		t.Fatalf("Expected next run in the future, got %v", schedule.NextRunAt)
+	}
+	if schedule.NextRunAt.Hour() != 2 || schedule.NextRunAt.Minute() != 0 {
+		t.Errorf("Expected next run at 02:00 UTC, got %s", schedule.NextRunAt)
+	}
+
+	disabled := false
+	updated, err := svc.UpdateSchedule(ctx, project.ID, schedule.ID, UpdateScheduleRequest{Enabled: &disabled})
+	if err != nil {
+		t.Fatalf("UpdateSchedule failed: %v", err)
+	}
+	if updated.NextRunAt != nil {
+		t.Errorf("Expected no next run for disabled schedule, got %s", updated.NextRunAt)
+	}
+}
+
+func TestCreateSchedule_Validation(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	svc, _ := newTestScheduleService(env, nil)
+
+	valid := CreateScheduleRequest{WorkspaceID: workspace.ID, AgentID: agent.ID, Prompt: "p", Cron: "@daily"}
+	tests := []struct {
+		name   string
+		modify func(*CreateScheduleRequest)
+	}{
+		{"missing prompt", func(r *CreateScheduleRequest) { r.Prompt = " " }},
+		{"unknown workspace", func(r *CreateScheduleRequest) { r.WorkspaceID = "missing" }},
+		{"unknown agent", func(r *CreateScheduleRequest) { r.AgentID = "missing" }},
+		{"invalid cron", func(r *CreateScheduleRequest) { r.Cron = "every day" }},
+		{"cron never fires", func(r *CreateScheduleRequest) { r.Cron = "0 0 31 2 *" }},
+		{"unknown timezone", func(r *CreateScheduleRequest) { r.Timezone = "Mars/Olympus" }},
+		{"invalid reasoning", func(r *CreateScheduleRequest) { r.Reasoning = "maximum" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			if _, err := svc.CreateSchedule(context.Background(), project.ID, req); !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+			}
+		})
+	}
+}
+
+// makeScheduleDue creates a schedule whose next run is in the past.
+func makeScheduleDue(t *testing.T, env *testEnv, svc *ScheduleService) *model.Schedule {
+	t.Helper()
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+
+	schedule, err := svc.CreateSchedule(context.Background(), project.ID, CreateScheduleRequest{
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		Prompt:      "p",
+		Cron:        "*/5 * * * *",
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule failed: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour).UTC()
+	schedule.NextRunAt = &past
+	if err := env.store.UpdateSchedule(context.Background(), schedule); err != nil {
+		t.Fatalf("Failed to update schedule: %v", err)
+	}
+	return schedule
+}
+
+func TestEnqueueDue(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	svc, enqueued := newTestScheduleService(env, nil)
+	schedule := makeScheduleDue(t, env, svc)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := svc.EnqueueDue(ctx, now); err != nil {
+		t.Fatalf("EnqueueDue failed: %v", err)
+	}
+	if len(*enqueued) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(*enqueued))
+	}
+	if (*enqueued)[0].ScheduleID != schedule.ID {
+		t.Errorf("Unexpected payload: %+v", (*enqueued)[0])
+	}
+
+	updated, err := env.store.GetScheduleByID(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("Failed to get schedule: %v", err)
+	}
+	if updated.NextRunAt == nil || !updated.NextRunAt.After(now) {
+		t.Errorf("Expected next run to advance past now, got %v", updated.NextRunAt)
+	}
+	if updated.LastRunAt == nil {
+		t.Error("Expected last run time to be recorded")
+	}
+
+	// Missed occurrences are collapsed: a second check in the same window does nothing
+	if err := svc.EnqueueDue(ctx, now); err != nil {
+		t.Fatalf("EnqueueDue failed: %v", err)
+	}
+	if len(*enqueued) != 1 {
+		t.Errorf("Expected no additional jobs, got %d", len(*enqueued))
+	}
+
+	runs, err := svc.ListScheduleRuns(ctx, schedule.ProjectID, schedule.ID)
+	if err != nil {
+		t.Fatalf("ListScheduleRuns failed: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != model.ScheduleRunStatusPending || runs[0].Manual {
+		t.Errorf("Expected one pending scheduled run, got %+v", runs)
+	}
+}
+
+func TestTriggerSchedule_SkipsWhileRunning(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	enqueueErr := jobs.ErrJobAlreadyExists
+	errPtr := &enqueueErr
+	svc, _ := newTestScheduleService(env, errPtr)
+	schedule := makeScheduleDue(t, env, svc)
+
+	run, err := svc.TriggerSchedule(context.Background(), schedule.ProjectID, schedule.ID)
+	if err != nil {
+		t.Fatalf("TriggerSchedule failed: %v", err)
+	}
+	if run.Status != model.ScheduleRunStatusSkipped {
+		t.Errorf("Expected run to be skipped, got %s", run.Status)
+	}
+	if !run.Manual {
+		t.Error("Expected run to be marked manual")
+	}
+	if run.Error == nil {
+		t.Error("Expected skip reason to be recorded")
+	}
+}
+
+func TestRunSchedule_Interrupted(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	svc, enqueued := newTestScheduleService(env, nil)
+	schedule := makeScheduleDue(t, env, svc)
+	ctx := context.Background()
+
+	run, err := svc.TriggerSchedule(ctx, schedule.ProjectID, schedule.ID)
+	if err != nil {
+		t.Fatalf("TriggerSchedule failed: %v", err)
+	}
+	if len(*enqueued) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(*enqueued))
+	}
+
+	// Simulate a worker that died after starting the run
+	if started, err := env.store.StartScheduleRun(ctx, run.ID, time.Now()); err != nil || !started {
+		t.Fatalf("StartScheduleRun failed: started=%v err=%v", started, err)
+	}
+
+	if err := svc.RunSchedule(ctx, schedule.ProjectID, schedule.ID, run.ID); err != nil {
+		t.Fatalf("RunSchedule failed: %v", err)
+	}
+
+	stored, err := env.store.GetScheduleRunByID(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("Failed to get run: %v", err)
+	}
+	if stored.Status != model.ScheduleRunStatusFailed || stored.CompletedAt == nil {
+		t.Errorf("Expected interrupted run to be failed and completed, got %+v", stored)
+	}
+}
@@ -7,13 +7,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"regexp"
 	"time"
 
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/git"
 	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/store"
@@ -22,9 +22,20 @@ import (
 // SessionIDMaxLength is the maximum allowed length for a session ID.
 const SessionIDMaxLength = 65
 
+// sessionLog is the logger for session lifecycle and commit flow messages.
+var sessionLog = logging.Component("session")
+
 // sessionIDRegex matches valid session IDs (alphanumeric and hyphens only).
 var sessionIDRegex = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
 
+// commitPollInterval is how often CommitAndWait checks whether the commit has
+// finished. A variable so tests can shorten it.
+var commitPollInterval = 2 * time.Second
+
+// initPollInterval is how often InitializeAndWait checks whether the session
+// has finished initializing. A variable so tests can shorten it.
+var initPollInterval = time.Second
+
 // ValidateSessionID validates that a session ID meets format requirements:
 // - Only alphanumeric characters (a-z, A-Z, 0-9) and hyphens (-) are allowed
 // - Maximum length is 65 characters
@@ -52,6 +63,7 @@ type Session struct {
 	Status          string     `json:"status"`
 	CommitStatus    string     `json:"commitStatus,omitempty"`
 	CommitError     string     `json:"commitError,omitempty"`
+	CommitReview    bool       `json:"commitReview,omitempty"`
 	BaseCommit      string     `json:"baseCommit,omitempty"`
 	AppliedCommit   string     `json:"appliedCommit,omitempty"`
 	ErrorMessage    string     `json:"errorMessage,omitempty"`
@@ -63,6 +75,8 @@ type Session struct {
 	Mode            string     `json:"mode,omitempty"`
 	WorkspacePath   string     `json:"workspacePath,omitempty"`
 	WorkspaceCommit string     `json:"workspaceCommit,omitempty"`
+	UpstreamCommit  string     `json:"upstreamCommit,omitempty"`
+	BehindCount     int        `json:"behindCount,omitempty"`
 }
 
 // FileNode represents a file in a session
@@ -151,7 +165,7 @@ func (s *SessionService) CreateSession(ctx context.Context, projectID, workspace
 		}
 		if err := s.store.CreateMessage(ctx, msg); err != nil {
 			// Log the error but don't fail session creation
-			log.Printf("Warning: failed to create initial message for session %s: %v", sess.ID, err)
+			sessionLog.WarnContext(ctx, "failed to create initial message", "session_id", sess.ID, "error", err)
 		}
 	}
 
@@ -228,7 +242,7 @@ func (s *SessionService) UpdateStatus(ctx context.Context, projectID, sessionID,
 	// Always publish SSE event for status changes
 	if s.eventBroker != nil {
 		if err := s.eventBroker.PublishSessionUpdated(ctx, projectID, sessionID, status, commitStatusChanged); err != nil {
-			log.Printf("Failed to publish session update event: %v", err)
+			sessionLog.WarnContext(withSession(ctx, sessionID), "failed to publish session update event", "error", err)
 		}
 	}
 
@@ -286,7 +300,7 @@ func (s *SessionService) DeleteSession(ctx context.Context, projectID, sessionID
 	// Emit SSE event
 	if s.eventBroker != nil {
 		if err := s.eventBroker.PublishSessionUpdated(ctx, projectID, sessionID, model.SessionStatusRemoving, sess.CommitStatus); err != nil {
-			log.Printf("Failed to publish session removing event: %v", err)
+			sessionLog.WarnContext(withSession(ctx, sessionID), "failed to publish session removing event", "error", err)
 		}
 	}
 
@@ -294,7 +308,7 @@ func (s *SessionService) DeleteSession(ctx context.Context, projectID, sessionID
 	if err := jobQueue.Enqueue(ctx, jobs.SessionDeletePayload{ProjectID: projectID, SessionID: sessionID}); err != nil {
 		// If job enqueueing fails, log but don't fail - the session is marked as removing
 		// and can be cleaned up later by reconciliation
-		log.Printf("Failed to enqueue session delete job for %s: %v", sessionID, err)
+		sessionLog.ErrorContext(withSession(ctx, sessionID), "failed to enqueue session delete job", "error", err)
 	}
 
 	return nil
@@ -304,6 +318,90 @@ func (s *SessionService) DeleteSession(ctx context.Context, projectID, sessionID
 // It enqueues a commit job unconditionally. Multiple commit jobs can be queued
 // for the same workspace and will be executed sequentially by the job queue.
 func (s *SessionService) CommitSession(ctx context.Context, projectID, sessionID string, jobQueue JobEnqueuer) error {
+	return s.startCommit(ctx, projectID, sessionID, false, jobQueue)
+}
+
+// CommitAndWait starts a commit of the session and waits for it to finish.
+// Returns the applied commit, if any.
+func (s *SessionService) CommitAndWait(ctx context.Context, projectID, sessionID string, jobQueue JobEnqueuer) (*string, error) {
+	if err := s.CommitSession(ctx, projectID, sessionID, jobQueue); err != nil {
+		return nil, fmt.Errorf("failed to start commit: %w", err)
+	}
+
+	ticker := time.NewTicker(commitPollInterval)
+	defer ticker.Stop()
+	for {
+		sess, err := s.store.GetSessionByID(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check commit status: %w", err)
+		}
+		switch sess.CommitStatus {
+		case model.CommitStatusCompleted:
+			return sess.AppliedCommit, nil
+		case model.CommitStatusFailed:
+			return nil, fmt.Errorf("commit failed: %s", ptrToString(sess.CommitError))
+		case model.CommitStatusNone:
+			// The commit was reset before it finished
+			return nil, fmt.Errorf("commit was cancelled")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("commit did not finish: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// InitializeAndWait enqueues a session_init job for the session and waits for
+// the session to become ready. Going through the job queue keeps the
+// dispatcher's limit on concurrent initializations in force.
+func (s *SessionService) InitializeAndWait(ctx context.Context, projectID, sessionID, workspaceID, agentID string, jobQueue JobEnqueuer) error {
+	if err := jobQueue.Enqueue(ctx, jobs.SessionInitPayload{
+		ProjectID:   projectID,
+		SessionID:   sessionID,
+		WorkspaceID: workspaceID,
+		AgentID:     agentID,
+	}); err != nil && !errors.Is(err, jobs.ErrJobAlreadyExists) {
+		return fmt.Errorf("failed to enqueue session init job: %w", err)
+	}
+
+	ticker := time.NewTicker(initPollInterval)
+	defer ticker.Stop()
+	for {
+		sess, err := s.store.GetSessionByID(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to check session status: %w", err)
+		}
+		switch sess.Status {
+		case model.SessionStatusReady:
+			return nil
+		case model.SessionStatusError:
+			return fmt.Errorf("%s", ptrToString(sess.ErrorMessage))
+		case model.SessionStatusRemoving, model.SessionStatusRemoved:
+			return fmt.Errorf("session was deleted")
+		}
+
+		// Not every failure sets the session's status, so check the job too
+		job, err := s.store.GetJobByResourceID(ctx, jobs.ResourceTypeSession, sessionID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to check session init job: %w", err)
+		}
+		if job != nil && job.Type == string(jobs.JobTypeSessionInit) && job.Status == string(model.JobStatusFailed) {
+			return fmt.Errorf("%s", ptrToString(job.Error))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("session did not finish initializing: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// startCommit enqueues a commit job. When review is true, the patches are stored
+// on the session for review instead of being applied to the workspace.
+func (s *SessionService) startCommit(ctx context.Context, projectID, sessionID string, review bool, jobQueue JobEnqueuer) error {
 	// Get session to verify it exists and get workspace ID
 	sess, err := s.store.GetSessionByID(ctx, sessionID)
 	if err != nil {
@@ -313,12 +411,18 @@ func (s *SessionService) CommitSession(ctx context.Context, projectID, sessionID
 	// Set commit status to pending immediately so the UI reflects the state
 	// before the job queue picks it up. PerformCommit will re-set this with
 	// additional fields (baseCommit, etc.) when it starts.
-	if sess.CommitStatus == model.CommitStatusNone {
-		sess.CommitStatus = model.CommitStatusPending
+	statusChanged := sess.CommitStatus == model.CommitStatusNone
+	if statusChanged || sess.CommitReview != review {
+		if statusChanged {
+			sess.CommitStatus = model.CommitStatusPending
+		}
+		sess.CommitReview = review
 		if err := s.store.UpdateSession(ctx, sess); err != nil {
 			return fmt.Errorf("failed to update session commit status: %w", err)
 		}
-		s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusPending)
+		if statusChanged {
+			s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusPending)
+		}
 	}
 
 	// Enqueue commit job (multiple jobs for same workspace are allowed and serialized)
@@ -334,7 +438,7 @@ func (s *SessionService) publishCommitStatusChanged(ctx context.Context, project
 	if s.eventBroker != nil {
 		// Send empty string for session status since only commit status changed
 		if err := s.eventBroker.PublishSessionUpdated(ctx, projectID, sessionID, "", commitStatus); err != nil {
-			log.Printf("Failed to publish session commit status event: %v", err)
+			sessionLog.WarnContext(withSession(ctx, sessionID), "failed to publish session commit status event", "error", err)
 		}
 	}
 }
@@ -350,29 +454,31 @@ func (s *SessionService) ReconcileCommitStates(ctx context.Context) error {
 	}
 
 	if len(sessions) == 0 {
-		log.Println("No sessions with stuck commit states found")
+		sessionLog.InfoContext(ctx, "no sessions with stuck commit states found")
 		return nil
 	}
 
-	log.Printf("Reconciling %d sessions with stuck commit states", len(sessions))
+	sessionLog.InfoContext(ctx, "reconciling sessions with stuck commit states", "count", len(sessions))
 
 	// For each session, check if job exists and re-enqueue if needed
 	var enqueuedCount int
 	for _, sess := range sessions {
+		sessCtx := logging.With(ctx, "project_id", sess.ProjectID, "session_id", sess.ID, "commit_status", sess.CommitStatus)
+
 		// Check if active job already exists
 		hasJob, err := s.store.HasActiveJobForResource(ctx, jobs.ResourceTypeWorkspace, sess.WorkspaceID)
 		if err != nil {
-			log.Printf("Failed to check job for session %s: %v", sess.ID, err)
+			sessionLog.ErrorContext(sessCtx, "failed to check for active commit job", "error", err)
 			continue
 		}
 
 		if hasJob {
-			log.Printf("Session %s (commit_status: %s) already has active job, skipping", sess.ID, sess.CommitStatus)
+			sessionLog.InfoContext(sessCtx, "session already has active commit job, skipping")
 			continue
 		}
 
 		// Re-enqueue commit job
-		log.Printf("Re-enqueueing commit job for session %s (commit_status: %s)", sess.ID, sess.CommitStatus)
+		sessionLog.InfoContext(sessCtx, "re-enqueueing commit job")
 		payload := jobs.SessionCommitPayload{
 			ProjectID:   sess.ProjectID,
 			SessionID:   sess.ID,
@@ -382,24 +488,30 @@ func (s *SessionService) ReconcileCommitStates(ctx context.Context) error {
 		if s.jobEnqueuer != nil {
 			if err := s.jobEnqueuer.Enqueue(ctx, payload); err != nil {
 				// Log but continue - this session remains stuck but others proceed
-				log.Printf("Failed to enqueue commit job for session %s: %v", sess.ID, err)
+				sessionLog.ErrorContext(sessCtx, "failed to enqueue commit job", "error", err)
 				continue
 			}
 			enqueuedCount++
 		} else {
-			log.Printf("Job enqueuer not available for session %s, skipping", sess.ID)
+			sessionLog.WarnContext(sessCtx, "job enqueuer not available, skipping")
 		}
 	}
 
-	log.Printf("Reconciled commit states: %d jobs re-enqueued", enqueuedCount)
+	sessionLog.InfoContext(ctx, "reconciled commit states", "enqueued", enqueuedCount)
 	return nil
 }
 
 // PerformDeletion performs the actual session deletion work.
 // This is called by the SessionDeleteExecutor job handler.
 func (s *SessionService) PerformDeletion(ctx context.Context, projectID, sessionID string) error {
-	// Step 1: Destroy sandbox and associated volumes (idempotent - handles not found)
+	ctx = withSession(ctx, sessionID)
+
+	// Step 1: Destroy sandbox and associated volumes (idempotent - handles not found).
+	// Stopping first lets shutdown hooks run; removal force-kills the sandbox.
 	if s.sandboxProvider != nil {
+		if err := s.sandboxProvider.Stop(ctx, sessionID, sandbox.StopTimeout); err != nil && !errors.Is(err, sandbox.ErrNotFound) {
+			sessionLog.WarnContext(ctx, "failed to stop sandbox before deletion", "error", err)
+		}
 		if err := s.sandboxProvider.Remove(ctx, sessionID, sandbox.RemoveVolumes()); err != nil {
 			if !errors.Is(err, sandbox.ErrNotFound) {
 				return fmt.Errorf("failed to remove sandbox with volumes: %w", err)
@@ -416,11 +528,11 @@ func (s *SessionService) PerformDeletion(ctx context.Context, projectID, session
 	// Step 3: Emit "removed" event to notify clients
 	if s.eventBroker != nil {
 		if err := s.eventBroker.PublishSessionUpdated(ctx, projectID, sessionID, model.SessionStatusRemoved, ""); err != nil {
-			log.Printf("Failed to publish session removed event: %v", err)
+			sessionLog.WarnContext(ctx, "failed to publish session removed event", "error", err)
 		}
 	}
 
-	log.Printf("Session %s deleted successfully", sessionID)
+	sessionLog.InfoContext(ctx, "session deleted")
 	return nil
 }
 
@@ -471,6 +583,11 @@ func (s *SessionService) mapSession(sess *model.Session) *Session {
 		workspaceCommit = *sess.WorkspaceCommit
 	}
 
+	upstreamCommit := ""
+	if sess.UpstreamCommit != nil {
+		upstreamCommit = *sess.UpstreamCommit
+	}
+
 	model := ""
 	if sess.Model != nil {
 		model = *sess.Model
@@ -501,6 +618,7 @@ func (s *SessionService) mapSession(sess *model.Session) *Session {
 		Status:          sess.Status,
 		CommitStatus:    sess.CommitStatus,
 		CommitError:     commitError,
+		CommitReview:    sess.CommitReview,
 		BaseCommit:      baseCommit,
 		AppliedCommit:   appliedCommit,
 		ErrorMessage:    errorMessage,
@@ -512,6 +630,8 @@ func (s *SessionService) mapSession(sess *model.Session) *Session {
 		Mode:            mode,
 		WorkspacePath:   workspacePath,
 		WorkspaceCommit: workspaceCommit,
+		UpstreamCommit:  upstreamCommit,
+		BehindCount:     sess.BehindCount,
 	}
 }
 
@@ -529,6 +649,8 @@ func (s *SessionService) Initialize(
 	ctx context.Context,
 	sessionID string,
 ) error {
+	ctx = withSession(ctx, sessionID)
+
 	// Get session from store (model)
 	sessionModel, err := s.store.GetSessionByID(ctx, sessionID)
 	if err != nil {
@@ -563,7 +685,7 @@ func (s *SessionService) Initialize(
 
 	// If we need to fallback, try to get and assign the default agent
 	if needsAgentFallback {
-		log.Printf("Session %s: %s, attempting to use default agent", sessionID, fallbackReason)
+		sessionLog.InfoContext(ctx, "attempting to use default agent", "reason", fallbackReason)
 
 		defaultAgent, err := s.store.GetDefaultAgent(ctx, sessionModel.ProjectID)
 		if err != nil {
@@ -574,7 +696,7 @@ func (s *SessionService) Initialize(
 		}
 
 		// Update session to use default agent
-		log.Printf("Session %s: assigning default agent %s (type: %s)", sessionID, defaultAgent.ID, defaultAgent.AgentType)
+		sessionLog.InfoContext(ctx, "assigning default agent", "agent_id", defaultAgent.ID, "agent_type", defaultAgent.AgentType)
 		sessionModel.AgentID = &defaultAgent.ID
 		if err := s.store.UpdateSession(ctx, sessionModel); err != nil {
 			return fmt.Errorf("failed to update session with default agent: %w", err)
@@ -614,7 +736,7 @@ func (s *SessionService) initializeSync(
 		var err error
 		workspacePath, currentCommit, err = s.gitService.EnsureWorkspaceRepo(ctx, workspace.ID)
 		if err != nil {
-			log.Printf("Git setup failed for session %s: %v", sessionID, err)
+			sessionLog.ErrorContext(ctx, "git setup failed", "error", err)
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("git setup failed: "+err.Error()))
 			return fmt.Errorf("git setup failed: %w", err)
 		}
@@ -635,7 +757,7 @@ func (s *SessionService) initializeSync(
 		// First initialization - save workspace path and commit
 		workspaceCommit = currentCommit
 		if err := s.store.UpdateSessionWorkspace(ctx, sessionID, workspacePath, workspaceCommit); err != nil {
-			log.Printf("Failed to update session workspace info for %s: %v", sessionID, err)
+			sessionLog.ErrorContext(ctx, "failed to update session workspace info", "error", err)
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("failed to save workspace info: "+err.Error()))
 			return fmt.Errorf("failed to save workspace info: %w", err)
 		}
@@ -645,14 +767,16 @@ func (s *SessionService) initializeSync(
 	// First check if sandbox already exists (from a previous failed attempt)
 	existingSandbox, err := s.sandboxProvider.Get(ctx, sessionID)
 	if err != nil && !errors.Is(err, sandbox.ErrNotFound) {
-		log.Printf("Failed to check for existing sandbox for session %s: %v", sessionID, err)
+		sessionLog.ErrorContext(ctx, "failed to check for existing sandbox", "error", err)
 		s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("failed to check sandbox: "+err.Error()))
 		return fmt.Errorf("failed to check sandbox: %w", err)
 	}
 
+	// startedAt is set when the sandbox is (re)started here, to follow its startup
+	var startedAt time.Time
 	needsCreation := true
 	if existingSandbox != nil {
-		log.Printf("Sandbox already exists for session %s (status: %s)", sessionID, existingSandbox.Status)
+		sessionLog.InfoContext(ctx, "sandbox already exists", "status", existingSandbox.Status)
 
 		switch existingSandbox.Status {
 		case sandbox.StatusRunning:
@@ -661,25 +785,26 @@ func (s *SessionService) initializeSync(
 			// (e.g., idle monitor sent SIGTERM but Docker still reports "running").
 			if s.sandboxService != nil {
 				if err := s.sandboxService.probeSandboxHealth(ctx, sessionID); err != nil {
-					log.Printf("Sandbox for session %s reports running but health check failed: %v, removing", sessionID, err)
+					sessionLog.WarnContext(ctx, "sandbox reports running but health check failed, removing", "error", err)
 					if rmErr := s.sandboxProvider.Remove(ctx, sessionID); rmErr != nil {
-						log.Printf("Failed to remove unhealthy sandbox for session %s: %v", sessionID, rmErr)
+						sessionLog.ErrorContext(ctx, "failed to remove unhealthy sandbox", "error", rmErr)
 					}
 					needsCreation = true
 					break
 				}
 			}
-			log.Printf("Sandbox for session %s is already running (verified healthy)", sessionID)
+			sessionLog.InfoContext(ctx, "sandbox already running (verified healthy)")
 			needsCreation = false
 
 		case sandbox.StatusCreated, sandbox.StatusStopped:
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusCreatingSandbox, nil)
+			startedAt = time.Now()
 			if err := s.sandboxProvider.Start(ctx, sessionID); err != nil {
 				if !errors.Is(err, sandbox.ErrAlreadyRunning) {
-					log.Printf("Sandbox start failed for session %s: %v, will attempt to remove and recreate", sessionID, err)
+					sessionLog.WarnContext(ctx, "sandbox start failed, will attempt to remove and recreate", "error", err)
 					// Start failed - try to remove and recreate
 					if rmErr := s.sandboxProvider.Remove(ctx, sessionID); rmErr != nil {
-						log.Printf("Failed to remove failed sandbox for session %s: %v", sessionID, rmErr)
+						sessionLog.ErrorContext(ctx, "failed to remove failed sandbox", "error", rmErr)
 						s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("sandbox start failed and removal failed: "+rmErr.Error()))
 						return fmt.Errorf("sandbox start failed and removal failed: %w", rmErr)
 					}
@@ -687,6 +812,7 @@ func (s *SessionService) initializeSync(
 					needsCreation = true
 				} else {
 					// Already running (race condition), treat as success
+					startedAt = time.Time{}
 					needsCreation = false
 				}
 			} else {
@@ -696,9 +822,9 @@ func (s *SessionService) initializeSync(
 
 		default:
 			// Sandbox is in failed state - remove and recreate (preserve volumes)
-			log.Printf("Removing failed sandbox for session %s", sessionID)
+			sessionLog.InfoContext(ctx, "removing failed sandbox")
 			if err := s.sandboxProvider.Remove(ctx, sessionID); err != nil {
-				log.Printf("Failed to remove old sandbox for session %s: %v", sessionID, err)
+				sessionLog.ErrorContext(ctx, "failed to remove old sandbox", "error", err)
 				s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("failed to remove old sandbox: "+err.Error()))
 				return fmt.Errorf("failed to remove old sandbox: %w", err)
 			}
@@ -709,7 +835,7 @@ func (s *SessionService) initializeSync(
 		// Check if image needs to be pulled and notify if so
 		if !s.sandboxProvider.ImageExists(ctx) {
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusPullingImage, nil)
-			log.Printf("Pulling sandbox image %s for session %s", s.sandboxProvider.Image(), sessionID)
+			sessionLog.InfoContext(ctx, "pulling sandbox image", "image", s.sandboxProvider.Image())
 		} else {
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusCreatingSandbox, nil)
 		}
@@ -726,17 +852,20 @@ func (s *SessionService) initializeSync(
 			WorkspaceSource: workspace.Path, // Original source (git URL or local path) for WORKSPACE_PATH env var
 			WorkspaceCommit: workspaceCommit,
 		}
+		applyWorkspaceSandboxOptions(&opts, workspace)
+		applyTracingOptions(&opts)
 
 		_, err := s.sandboxProvider.Create(ctx, sessionID, opts)
 		if err != nil {
-			log.Printf("Sandbox creation failed for session %s: %v", sessionID, err)
+			sessionLog.ErrorContext(ctx, "sandbox creation failed", "error", err)
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("sandbox creation failed: "+err.Error()))
 			return fmt.Errorf("sandbox creation failed: %w", err)
 		}
 
 		// Start the sandbox
+		startedAt = time.Now()
 		if err := s.sandboxProvider.Start(ctx, sessionID); err != nil {
-			log.Printf("Sandbox start failed for session %s: %v", sessionID, err)
+			sessionLog.ErrorContext(ctx, "sandbox start failed", "error", err)
 			s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusError, ptrString("sandbox start failed: "+err.Error()))
 			return fmt.Errorf("sandbox start failed: %w", err)
 		}
@@ -744,7 +873,12 @@ func (s *SessionService) initializeSync(
 
 	// Success! Update status to running
 	s.updateStatusWithEvent(ctx, projectID, sessionID, model.SessionStatusReady, nil)
-	log.Printf("Session %s initialized successfully", sessionID)
+	sessionLog.InfoContext(ctx, "session initialized")
+
+	// The agent keeps setting up after the container starts; report its steps
+	if !startedAt.IsZero() {
+		go s.watchStartup(projectID, sessionID, startedAt)
+	}
 	return nil
 }
 
@@ -753,10 +887,15 @@ func (s *SessionService) initializeSync(
 func (s *SessionService) updateStatusWithEvent(ctx context.Context, projectID, sessionID, status string, errorMsg *string) {
 	_, err := s.UpdateStatus(ctx, projectID, sessionID, status, errorMsg)
 	if err != nil {
-		log.Printf("Failed to update session %s status to %s: %v", sessionID, status, err)
+		sessionLog.ErrorContext(withSession(ctx, sessionID), "failed to update session status", "status", status, "error", err)
 	}
 }
 
+// withSession scopes ctx's log attributes to sessionID.
+func withSession(ctx context.Context, sessionID string) context.Context {
+	return logging.With(ctx, "session_id", sessionID)
+}
+
 // generateSecret generates a cryptographically secure random hex string.
 func generateSecret(length int) string {
 	bytes := make([]byte, length)
@@ -784,6 +923,8 @@ func ptrString(s string) *string {
 // 4. If appliedCommit not set: fetch patches from agent-api, apply to workspace
 // 5. Transition to completed
 func (s *SessionService) PerformCommit(ctx context.Context, projectID, sessionID string) (retErr error) {
+	ctx = withSession(ctx, sessionID)
+
 	// Get session
 	sess, err := s.store.GetSessionByID(ctx, sessionID)
 	if err != nil {
@@ -798,10 +939,10 @@ func (s *SessionService) PerformCommit(ctx context.Context, projectID, sessionID
 
 	// If PerformCommit returns an error (e.g. context deadline exceeded),
 	// mark the commit as failed so it doesn't get stuck in "pending" or "committing".
-	// Use a background context since the original ctx may have been cancelled.
+	// Detach from ctx's cancellation since it may already have been cancelled.
 	defer func() {
 		if retErr != nil {
-			failCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			failCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
 			defer cancel()
 			s.setCommitFailed(failCtx, projectID, workspace, sess, retErr.Error())
 			retErr = nil
@@ -819,6 +960,7 @@ func (s *SessionService) PerformCommit(ctx context.Context, projectID, sessionID
 	sess.BaseCommit = ptrString(gitStatus.Commit)
 	sess.AppliedCommit = nil
 	sess.CommitError = nil
+	sess.CommitPatches = nil
 	if err := s.store.UpdateSession(ctx, sess); err != nil {
 		return fmt.Errorf("failed to update session for commit: %w", err)
 	}
@@ -837,7 +979,7 @@ func (s *SessionService) PerformCommit(ctx context.Context, projectID, sessionID
 		if err := s.tryApplyExistingPatches(ctx, projectID, workspace, sess); err != nil {
 			return err
 		}
-		if sess.CommitStatus == model.CommitStatusFailed {
+		if sess.CommitStatus == model.CommitStatusFailed || sess.CommitStatus == model.CommitStatusReview {
 			return nil
 		}
 	}
@@ -857,13 +999,13 @@ func (s *SessionService) PerformCommit(ctx context.Context, projectID, sessionID
 		if err := s.fetchAndApplyPatches(ctx, projectID, workspace, sess); err != nil {
 			return err
 		}
-		if sess.CommitStatus == model.CommitStatusFailed {
+		if sess.CommitStatus == model.CommitStatusFailed || sess.CommitStatus == model.CommitStatusReview {
 			return nil
 		}
 	}
 
 	// Step 4: Complete
-	log.Printf("Session %s: commit completed with applied commit %s", sess.ID, *sess.AppliedCommit)
+	sessionLog.InfoContext(ctx, "commit completed", "applied_commit", *sess.AppliedCommit)
 
 	sess.CommitStatus = model.CommitStatusCompleted
 	sess.CommitError = nil
@@ -872,7 +1014,7 @@ func (s *SessionService) PerformCommit(ctx context.Context, projectID, sessionID
 	}
 	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusCompleted)
 
-	log.Printf("Workspace %s committed successfully via session %s", workspace.ID, sess.ID)
+	sessionLog.InfoContext(ctx, "workspace committed", "workspace_id", workspace.ID)
 	return nil
 }
 
@@ -890,7 +1032,7 @@ func (s *SessionService) syncBaseCommit(ctx context.Context, projectID string, w
 		return nil
 	}
 
-	log.Printf("Session %s: workspace commit changed from %s to %s, updating baseCommit", sess.ID, *sess.BaseCommit, gitStatus.Commit)
+	sessionLog.InfoContext(ctx, "workspace commit changed, updating base commit", "from", *sess.BaseCommit, "to", gitStatus.Commit)
 	sess.BaseCommit = ptrString(gitStatus.Commit)
 	if err := s.store.UpdateSession(ctx, sess); err != nil {
 		return fmt.Errorf("failed to update session baseCommit: %w", err)
@@ -906,26 +1048,26 @@ func (s *SessionService) tryApplyExistingPatches(ctx context.Context, projectID
 		return nil
 	}
 
-	log.Printf("Session %s: checking if agent has existing patches for commit %s", sess.ID, *sess.BaseCommit)
+	sessionLog.InfoContext(ctx, "checking if agent has existing patches", "base_commit", *sess.BaseCommit)
 
 	client, err := s.sandboxService.GetClient(ctx, sess.ID)
 	if err != nil {
-		log.Printf("Session %s: no existing patches available (error: %v), continuing with prompt", sess.ID, err)
+		sessionLog.InfoContext(ctx, "no existing patches available, continuing with prompt", "error", err)
 		return nil
 	}
 
 	commitsResp, err := client.GetCommits(ctx, *sess.BaseCommit)
 	if err != nil {
-		log.Printf("Session %s: no existing patches available (error: %v), continuing with prompt", sess.ID, err)
+		sessionLog.InfoContext(ctx, "no existing patches available, continuing with prompt", "error", err)
 		return nil
 	}
 	if commitsResp.CommitCount == 0 {
-		log.Printf("Session %s: no existing patches available (commit count: 0), continuing with prompt", sess.ID)
+		sessionLog.InfoContext(ctx, "no existing patches available, continuing with prompt", "commit_count", 0)
 		return nil
 	}
 
 	// Agent has patches ready - apply them directly
-	log.Printf("Session %s: agent has %d existing commits, skipping prompt and applying patches", sess.ID, commitsResp.CommitCount)
+	sessionLog.InfoContext(ctx, "agent has existing commits, skipping prompt and applying patches", "commit_count", commitsResp.CommitCount)
 	return s.applyPatches(ctx, projectID, workspace, sess, commitsResp.Patches, commitsResp.CommitCount)
 }
 
@@ -936,7 +1078,7 @@ func (s *SessionService) sendCommitPrompt(ctx context.Context, projectID string,
 		return nil
 	}
 
-	log.Printf("Session %s: sending /discobot-commit %s to agent", sess.ID, *sess.BaseCommit)
+	sessionLog.InfoContext(ctx, "sending /discobot-commit to agent", "base_commit", *sess.BaseCommit)
 
 	commitMessage := fmt.Sprintf("/discobot-commit %s", *sess.BaseCommit)
 	messages, err := buildCommitMessage(sess.ID+"-commit", commitMessage)
@@ -985,7 +1127,7 @@ func (s *SessionService) sendCommitPrompt(ctx context.Context, projectID string,
 		}
 	}
 
-	log.Printf("Session %s: /discobot-commit message completed", sess.ID)
+	sessionLog.InfoContext(ctx, "/discobot-commit message completed")
 	return nil
 }
 
@@ -996,7 +1138,7 @@ func (s *SessionService) fetchAndApplyPatches(ctx context.Context, projectID str
 		return nil
 	}
 
-	log.Printf("Session %s: fetching commits from agent-api (parent=%s)", sess.ID, *sess.BaseCommit)
+	sessionLog.InfoContext(ctx, "fetching commits from agent-api", "parent", *sess.BaseCommit)
 
 	client, err := s.sandboxService.GetClient(ctx, sess.ID)
 	if err != nil {
@@ -1015,12 +1157,17 @@ func (s *SessionService) fetchAndApplyPatches(ctx context.Context, projectID str
 		return nil
 	}
 
-	log.Printf("Session %s: received %d commits from agent, applying patches to workspace", sess.ID, commitsResp.CommitCount)
+	sessionLog.InfoContext(ctx, "received commits from agent, applying patches to workspace", "commit_count", commitsResp.CommitCount)
 	return s.applyPatches(ctx, projectID, workspace, sess, commitsResp.Patches, commitsResp.CommitCount)
 }
 
 // applyPatches applies the given patches to the workspace and updates the session.
+// If the session requested a review, the patches are stored for review instead.
 func (s *SessionService) applyPatches(ctx context.Context, projectID string, workspace *model.Workspace, sess *model.Session, patches string, commitCount int) error {
+	if sess.CommitReview {
+		return s.stageCommitReview(ctx, projectID, sess, patches, commitCount)
+	}
+
 	if sess.CommitStatus != model.CommitStatusCommitting {
 		sess.CommitStatus = model.CommitStatusCommitting
 		if err := s.store.UpdateSession(ctx, sess); err != nil {
@@ -1040,24 +1187,79 @@ func (s *SessionService) applyPatches(ctx context.Context, projectID string, wor
 		return fmt.Errorf("failed to update session applied commit: %w", err)
 	}
 	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusCommitting)
-	log.Printf("Session %s: %d patches applied, final commit=%s", sess.ID, commitCount, finalCommit)
+	sessionLog.InfoContext(ctx, "patches applied", "commit_count", commitCount, "final_commit", finalCommit)
 	return nil
 }
 
 // setCommitFailed sets the commit status to failed with an error message.
 func (s *SessionService) setCommitFailed(ctx context.Context, projectID string, workspace *model.Workspace, sess *model.Session, errorMsg string) {
-	log.Printf("Workspace %s commit failed (via session %s): %s", workspace.ID, sess.ID, errorMsg)
+	sessionLog.WarnContext(ctx, "workspace commit failed", "workspace_id", workspace.ID, "error", errorMsg)
 
 	sess.CommitStatus = model.CommitStatusFailed
 	sess.CommitError = ptrString(errorMsg)
 	if err := s.store.UpdateSession(ctx, sess); err != nil {
-		log.Printf("Failed to update session %s commit status to failed: %v", sess.ID, err)
+		sessionLog.ErrorContext(ctx, "failed to update session commit status to failed", "error", err)
 		return
 	}
 
 	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusFailed)
 }
 
+// sendAgentMessage sends a single user message to the session's agent and waits
+// for the resulting completion to finish. The server's git identity is passed
+// along so any commits the agent makes are attributed correctly. An error chunk
+// in the completion's stream is returned as an error.
+func (s *SessionService) sendAgentMessage(ctx context.Context, sess *model.Session, msgID, text string) error {
+	if s.sandboxService == nil {
+		return fmt.Errorf("sandbox service not available")
+	}
+
+	messages, err := buildCommitMessage(msgID, text)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	gitUserName, gitUserEmail := s.gitService.GetUserConfig(ctx)
+	opts := &RequestOptions{
+		GitUserName:  gitUserName,
+		GitUserEmail: gitUserEmail,
+	}
+
+	client, err := s.sandboxService.GetClient(ctx, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get sandbox client: %w", err)
+	}
+
+	modelID := ""
+	if sess.Model != nil {
+		modelID = *sess.Model
+	}
+
+	streamCh, err := client.SendMessages(ctx, messages, modelID, opts)
+	if err != nil {
+		return err
+	}
+
+	// Drain the stream until complete, remembering any error the agent reports
+	var streamErr error
+	for line := range streamCh {
+		if line.Done {
+			break
+		}
+		if streamErr == nil {
+			streamErr = line.AgentError()
+		}
+	}
+	if streamErr != nil {
+		return streamErr
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("completion did not finish: %w", err)
+	}
+
+	return nil
+}
+
 // buildCommitMessage creates a UIMessage array for the /discobot-commit command.
 // Returns json.RawMessage that can be passed to SendMessages.
 func buildCommitMessage(msgID, text string) (json.RawMessage, error) {
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+// ErrNoCommitReview is returned when a session has no patches awaiting review.
+var ErrNoCommitReview = errors.New("no commit awaiting review")
+
+// CommitReview is the set of commits fetched from the agent that are waiting
+// for the user to review before they are applied to the workspace.
+type CommitReview struct {
+	SessionID  string      `json:"sessionId"`
+	BaseCommit string      `json:"baseCommit"`
+	Commits    []git.Patch `json:"commits"`
+}
+
+// StartCommitReview initiates async commit of a session in review mode.
+// The agent's patches are fetched and stored on the session, and the commit
+// stops in the "review" state until ApplyCommitReview or RejectCommitReview.
+func (s *SessionService) StartCommitReview(ctx context.Context, projectID, sessionID string, jobQueue JobEnqueuer) error {
+	return s.startCommit(ctx, projectID, sessionID, true, jobQueue)
+}
+
+// GetCommitReview returns the parsed commits awaiting review for a session.
+func (s *SessionService) GetCommitReview(ctx context.Context, projectID, sessionID string) (*CommitReview, error) {
+	sess, err := s.getReviewSession(ctx, projectID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := git.ParsePatches(*sess.CommitPatches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patches: %w", err)
+	}
+
+	return &CommitReview{
+		SessionID:  sess.ID,
+		BaseCommit: sessionBaseRef(sess),
+		Commits:    commits,
+	}, nil
+}
+
+// ApplyCommitReview enqueues applying the reviewed patches with the given selection.
+// If feedback is non-empty or any commits or files are excluded, the agent is told
+// which changes were rejected.
+func (s *SessionService) ApplyCommitReview(ctx context.Context, projectID, sessionID string, selection git.PatchSelection, feedback string, jobQueue JobEnqueuer) error {
+	sess, err := s.getReviewSession(ctx, projectID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	payload := jobs.SessionCommitPayload{
+		ProjectID:   projectID,
+		SessionID:   sessionID,
+		WorkspaceID: sess.WorkspaceID,
+		Action:      jobs.CommitActionApply,
+		Selection:   &selection,
+		Feedback:    feedback,
+	}
+	if err := jobQueue.Enqueue(ctx, payload); err != nil {
+		return fmt.Errorf("failed to enqueue commit job: %w", err)
+	}
+	return nil
+}
+
+// RejectCommitReview enqueues discarding the reviewed patches. If feedback is
+// non-empty it is sent to the agent.
+func (s *SessionService) RejectCommitReview(ctx context.Context, projectID, sessionID, feedback string, jobQueue JobEnqueuer) error {
+	sess, err := s.getReviewSession(ctx, projectID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	payload := jobs.SessionCommitPayload{
+		ProjectID:   projectID,
+		SessionID:   sessionID,
+		WorkspaceID: sess.WorkspaceID,
+		Action:      jobs.CommitActionReject,
+		Feedback:    feedback,
+	}
+	if err := jobQueue.Enqueue(ctx, payload); err != nil {
+		return fmt.Errorf("failed to enqueue commit job: %w", err)
+	}
+	return nil
+}
+
+// PerformCommitApply applies reviewed patches to the workspace.
+// This is called by the dispatcher when processing an apply session_commit job.
+// If the selection cannot be applied, the session stays in review with the error
+// recorded so the user can adjust the selection and try again.
+func (s *SessionService) PerformCommitApply(ctx context.Context, projectID, sessionID string, selection git.PatchSelection, feedback string) error {
+	sess, err := s.getReviewSession(ctx, projectID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	patches := []byte(*sess.CommitPatches)
+	baseCommit := sessionBaseRef(sess)
+
+	selected, err := s.gitService.SelectPatches(ctx, sess.WorkspaceID, baseCommit, patches, selection)
+	if err != nil {
+		s.setCommitReviewError(ctx, projectID, sess, fmt.Sprintf("Failed to prepare selected patches: %v", err))
+		return nil
+	}
+
+	sess.CommitStatus = model.CommitStatusCommitting
+	sess.CommitError = nil
+	if err := s.store.UpdateSession(ctx, sess); err != nil {
+		return fmt.Errorf("failed to update session status: %w", err)
+	}
+	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusCommitting)
+
+	finalCommit, err := s.gitService.ApplyPatches(ctx, sess.WorkspaceID, selected)
+	if err != nil {
+		s.setCommitReviewError(ctx, projectID, sess, fmt.Sprintf("Failed to apply patches to workspace: %v", err))
+		return nil
+	}
+
+	sessionLog.InfoContext(withSession(ctx, sess.ID), "reviewed patches applied", "final_commit", finalCommit)
+
+	sess.AppliedCommit = ptrString(finalCommit)
+	sess.CommitStatus = model.CommitStatusCompleted
+	sess.CommitPatches = nil
+	if err := s.store.UpdateSession(ctx, sess); err != nil {
+		return fmt.Errorf("failed to update session commit status: %w", err)
+	}
+	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusCompleted)
+
+	if parsed, err := git.ParsePatches(string(patches)); err == nil {
+		rejectedCommits, rejectedFiles := selection.Rejected(parsed)
+		if text := buildCommitFeedback(rejectedCommits, rejectedFiles, feedback); text != "" {
+			if err := s.sendAgentMessage(ctx, sess, sess.ID+"-commit-feedback", text); err != nil {
+				sessionLog.WarnContext(withSession(ctx, sess.ID), "failed to send commit feedback to agent", "error", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PerformCommitReject discards reviewed patches and sends any feedback to the agent.
+// This is called by the dispatcher when processing a reject session_commit job.
+func (s *SessionService) PerformCommitReject(ctx context.Context, projectID, sessionID, feedback string) error {
+	sess, err := s.getReviewSession(ctx, projectID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	parsed, _ := git.ParsePatches(*sess.CommitPatches)
+
+	sess.CommitStatus = model.CommitStatusNone
+	sess.CommitError = nil
+	sess.CommitPatches = nil
+	if err := s.store.UpdateSession(ctx, sess); err != nil {
+		return fmt.Errorf("failed to update session commit status: %w", err)
+	}
+	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusNone)
+
+	sessionLog.InfoContext(withSession(ctx, sess.ID), "reviewed patches rejected")
+
+	if strings.TrimSpace(feedback) != "" {
+		text := buildCommitFeedback(parsed, nil, feedback)
+		if err := s.sendAgentMessage(ctx, sess, sess.ID+"-commit-feedback", text); err != nil {
+			sessionLog.WarnContext(withSession(ctx, sess.ID), "failed to send commit feedback to agent", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// stageCommitReview stores fetched patches on the session for review.
+func (s *SessionService) stageCommitReview(ctx context.Context, projectID string, sess *model.Session, patches string, commitCount int) error {
+	sess.CommitStatus = model.CommitStatusReview
+	sess.CommitPatches = ptrString(patches)
+	sess.CommitError = nil
+	if err := s.store.UpdateSession(ctx, sess); err != nil {
+		return fmt.Errorf("failed to store patches for review: %w", err)
+	}
+	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusReview)
+	sessionLog.InfoContext(withSession(ctx, sess.ID), "commits stored for review", "count", commitCount)
+	return nil
+}
+
+// setCommitReviewError records an apply failure while keeping the patches for review.
+func (s *SessionService) setCommitReviewError(ctx context.Context, projectID string, sess *model.Session, errorMsg string) {
+	ctx = withSession(ctx, sess.ID)
+	sessionLog.ErrorContext(ctx, "reviewed commit failed", "error", errorMsg)
+
+	sess.CommitStatus = model.CommitStatusReview
+	sess.CommitError = ptrString(errorMsg)
+	if err := s.store.UpdateSession(ctx, sess); err != nil {
+		sessionLog.ErrorContext(ctx, "failed to update session commit error", "error", err)
+		return
+	}
+	s.publishCommitStatusChanged(ctx, projectID, sess.ID, model.CommitStatusReview)
+}
+
+// getReviewSession loads a session and verifies it has patches awaiting review.
+func (s *SessionService) getReviewSession(ctx context.Context, projectID, sessionID string) (*model.Session, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	if sess.CommitStatus != model.CommitStatusReview || sess.CommitPatches == nil {
+		return nil, ErrNoCommitReview
+	}
+	return sess, nil
+}
+
+// buildCommitFeedback describes rejected commits and files for the agent.
+// Returns an empty string if there is nothing to report.
+func buildCommitFeedback(rejectedCommits []git.Patch, rejectedFiles map[string][]string, feedback string) string {
+	feedback = strings.TrimSpace(feedback)
+	if len(rejectedCommits) == 0 && len(rejectedFiles) == 0 && feedback == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("The user reviewed your commits before applying them to the workspace.")
+
+	if len(rejectedCommits) > 0 {
+		b.WriteString("\n\nThese commits were rejected and not applied:")
+		for _, c := range rejectedCommits {
+			fmt.Fprintf(&b, "\n- %s %s", git.ShortSHA(c.SHA), c.Subject)
+		}
+	}
+
+	if len(rejectedFiles) > 0 {
+		b.WriteString("\n\nChanges to these files were excluded:")
+		for sha, paths := range rejectedFiles {
+			for _, path := range paths {
+				fmt.Fprintf(&b, "\n- %s (from %s)", path, git.ShortSHA(sha))
+			}
+		}
+	}
+
+	if feedback != "" {
+		b.WriteString("\n\nFeedback from the user:\n")
+		b.WriteString(feedback)
+	}
+
+	return b.String()
+}
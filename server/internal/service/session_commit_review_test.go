@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+// buildAgentPatches creates commits on top of baseCommit in a scratch clone of the
+// workspace and returns them as format-patch output, like the agent would.
+func buildAgentPatches(t *testing.T, wsPath, baseCommit string, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.email", "agent@example.com")
+	runGit(t, dir, "config", "user.name", "Agent")
+	runGit(t, dir, "fetch", wsPath, baseCommit)
+	runGit(t, dir, "reset", "--hard", "FETCH_HEAD")
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		runGit(t, dir, "add", name)
+		runGit(t, dir, "commit", "-m", "Add "+name)
+	}
+	return runGit(t, dir, "format-patch", "--stdout", baseCommit+"..HEAD")
+}
+
+// setupCommitReview creates a session in review mode and runs PerformCommit so the
+// agent's patches are staged for review.
+func setupCommitReview(t *testing.T, env *testEnv) (*SessionService, *model.Session, *model.Workspace, string, *mockHandler) {
+	t.Helper()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+	session.CommitReview = true
+	if err := env.store.UpdateSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to update session: %v", err)
+	}
+
+	handler := newMockHandler()
+	handler.commitsResponse = &sandboxapi.CommitsResponse{
+		Patches:     buildAgentPatches(t, workspace.Path, initialCommit, "keep.txt", "drop.txt"),
+		CommitCount: 2,
+	}
+	env.mockSandbox.HTTPHandler = handler
+
+	if _, err := env.mockSandbox.Create(context.Background(), session.ID, sandbox.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	if err := env.mockSandbox.Start(context.Background(), session.ID); err != nil {
+		t.Fatalf("Failed to start sandbox: %v", err)
+	}
+
+	sandboxSvc := NewSandboxService(env.store, env.mockSandbox, &config.Config{}, nil, env.eventBroker, nil)
+	sandboxSvc.SetSessionInitializer(&testSessionInitializer{})
+	sessionSvc := NewSessionService(env.store, env.gitService, env.mockSandbox, sandboxSvc, env.eventBroker, nil)
+
+	if err := sessionSvc.PerformCommit(context.Background(), project.ID, session.ID); err != nil {
+		t.Fatalf("PerformCommit failed: %v", err)
+	}
+
+	return sessionSvc, session, workspace, initialCommit, handler
+}
+
+func TestCommitReview_StagesPatches(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	sessionSvc, session, workspace, initialCommit, _ := setupCommitReview(t, env)
+
+	updated, err := env.store.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if updated.CommitStatus != model.CommitStatusReview {
+		t.Errorf("Expected commit status %s, got %s", model.CommitStatusReview, updated.CommitStatus)
+	}
+	if updated.CommitPatches == nil {
+		t.Fatal("Expected patches to be stored for review")
+	}
+	if updated.AppliedCommit != nil {
+		t.Errorf("Expected no applied commit while in review, got %s", *updated.AppliedCommit)
+	}
+
+	// The workspace must not change until the review is applied
+	if head := strings.TrimSpace(runGit(t, workspace.Path, "rev-parse", "HEAD")); head != initialCommit {
+		t.Errorf("Expected workspace HEAD %s, got %s", initialCommit, head)
+	}
+
+	review, err := sessionSvc.GetCommitReview(context.Background(), session.ProjectID, session.ID)
+	if err != nil {
+		t.Fatalf("GetCommitReview failed: %v", err)
+	}
+	if review.BaseCommit != initialCommit {
+		t.Errorf("Expected base commit %s, got %s", initialCommit, review.BaseCommit)
+	}
+	if len(review.Commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(review.Commits))
+	}
+	if review.Commits[0].Subject != "Add keep.txt" || review.Commits[1].Subject != "Add drop.txt" {
+		t.Errorf("Unexpected subjects: %q, %q", review.Commits[0].Subject, review.Commits[1].Subject)
+	}
+}
+
+func TestCommitReview_ApplySelection(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	sessionSvc, session, workspace, _, handler := setupCommitReview(t, env)
+
+	review, err := sessionSvc.GetCommitReview(context.Background(), session.ProjectID, session.ID)
+	if err != nil {
+		t.Fatalf("GetCommitReview failed: %v", err)
+	}
+	chatRequests := handler.getChatRequestCount()
+
+	selection := git.PatchSelection{ExcludeCommits: []string{review.Commits[1].SHA}}
+	if err := sessionSvc.PerformCommitApply(context.Background(), session.ProjectID, session.ID, selection, ""); err != nil {
+		t.Fatalf("PerformCommitApply failed: %v", err)
+	}
+
+	updated, err := env.store.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if updated.CommitStatus != model.CommitStatusCompleted {
+		t.Errorf("Expected commit status %s, got %s (error: %v)", model.CommitStatusCompleted, updated.CommitStatus, updated.CommitError)
+	}
+	if updated.CommitPatches != nil {
+		t.Error("Expected stored patches to be cleared")
+	}
+
+	if _, err := os.Stat(filepath.Join(workspace.Path, "keep.txt")); err != nil {
+		t.Errorf("Expected keep.txt to be applied: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workspace.Path, "drop.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected drop.txt to be excluded, stat err: %v", err)
+	}
+
+	// The agent is told which commit was rejected
+	if handler.getChatRequestCount() != chatRequests+1 {
+		t.Errorf("Expected one feedback chat request, got %d", handler.getChatRequestCount()-chatRequests)
+	}
+
+	if _, err := sessionSvc.GetCommitReview(context.Background(), session.ProjectID, session.ID); !errors.Is(err, ErrNoCommitReview) {
+		t.Errorf("Expected ErrNoCommitReview after apply, got %v", err)
+	}
+}
+
+func TestCommitReview_Reject(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	sessionSvc, session, workspace, initialCommit, handler := setupCommitReview(t, env)
+	chatRequests := handler.getChatRequestCount()
+
+	if err := sessionSvc.PerformCommitReject(context.Background(), session.ProjectID, session.ID, "Please use a single commit"); err != nil {
+		t.Fatalf("PerformCommitReject failed: %v", err)
+	}
+
+	updated, err := env.store.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if updated.CommitStatus != model.CommitStatusNone {
+		t.Errorf("Expected commit status to be reset, got %s", updated.CommitStatus)
+	}
+	if updated.CommitPatches != nil {
+		t.Error("Expected stored patches to be cleared")
+	}
+	if head := strings.TrimSpace(runGit(t, workspace.Path, "rev-parse", "HEAD")); head != initialCommit {
+		t.Errorf("Expected workspace HEAD %s, got %s", initialCommit, head)
+	}
+	if handler.getChatRequestCount() != chatRequests+1 {
+		t.Errorf("Expected one feedback chat request, got %d", handler.getChatRequestCount()-chatRequests)
+	}
+}
+
+func TestBuildCommitFeedback(t *testing.T) {
+	if got := buildCommitFeedback(nil, nil, "  "); got != "" {
+		t.Errorf("Expected no feedback, got %q", got)
+	}
+
+	got := buildCommitFeedback(
+		[]git.Patch{{SHA: "0123456789abcdef", Subject: "Add drop.txt"}},
+		map[string][]string{"fedcba9876543210": {"secret.txt"}},
+		"Keep secrets out of git",
+	)
+	for _, want := range []string{"- 0123456 Add drop.txt", "- secret.txt (from fedcba9)", "Keep secrets out of git"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected feedback to contain %q, got:\n%s", want, got)
+		}
+	}
+}
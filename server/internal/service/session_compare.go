@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+// Session comparison modes
+const (
+	// CompareModeWorktree compares each session's working tree changes against its base.
+	CompareModeWorktree = "worktree"
+	// CompareModeCommits compares the commits each session made since its base commit.
+	CompareModeCommits = "commits"
+)
+
+// ErrCompareWorkspaceMismatch is returned when comparing sessions from different workspaces.
+var ErrCompareWorkspaceMismatch = errors.New("sessions must belong to the same workspace")
+
+// SessionComparison is the result of comparing two sessions side by side.
+type SessionComparison struct {
+	Mode  string                  `json:"mode"`
+	A     SessionCompareSummary   `json:"a"`
+	B     SessionCompareSummary   `json:"b"`
+	Files []SessionCompareFile    `json:"files"`
+	Stats SessionCompareFileStats `json:"stats"`
+	// Diff is the diff from A's result to B's result. Only set in commits mode,
+	// where both commit ranges can be replayed onto the workspace repository.
+	Diff []git.FileDiff `json:"diff,omitempty"`
+}
+
+// SessionCompareSummary summarizes one side of a session comparison.
+type SessionCompareSummary struct {
+	SessionID    string       `json:"sessionId"`
+	Name         string       `json:"name"`
+	AgentID      string       `json:"agentId,omitempty"`
+	Model        string       `json:"model,omitempty"`
+	BaseCommit   string       `json:"baseCommit,omitempty"`
+	CommitCount  int          `json:"commitCount,omitempty"` // Commits mode only
+	FilesChanged int          `json:"filesChanged"`
+	Additions    int          `json:"additions"`
+	Deletions    int          `json:"deletions"`
+	Hooks        *HookSummary `json:"hooks,omitempty"` // nil if hook status is unavailable
+	Usage        *TokenUsage  `json:"usage,omitempty"` // nil if the agent doesn't report usage
+	Errors       []string     `json:"errors,omitempty"`
+	diff         []git.FileDiff
+}
+
+// HookSummary counts hook results for a session.
+type HookSummary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Running int `json:"running"`
+	Pending int `json:"pending"`
+}
+
+// TokenUsage is the token usage reported by the agent, summed over all messages.
+type TokenUsage struct {
+	InputTokens              int `json:"inputTokens"`
+	OutputTokens             int `json:"outputTokens"`
+	CacheReadInputTokens     int `json:"cacheReadInputTokens"`
+	CacheCreationInputTokens int `json:"cacheCreationInputTokens"`
+}
+
+// SessionCompareFile compares one path across the two sessions.
+type SessionCompareFile struct {
+	Path string `json:"path"`
+	// StatusA and StatusB are the change status in each session
+	// ("added", "modified", "deleted", "renamed"), or empty if unchanged.
+	StatusA    string `json:"statusA,omitempty"`
+	StatusB    string `json:"statusB,omitempty"`
+	AdditionsA int    `json:"additionsA"`
+	DeletionsA int    `json:"deletionsA"`
+	AdditionsB int    `json:"additionsB"`
+	DeletionsB int    `json:"deletionsB"`
+	// Identical is true if both sessions made exactly the same change.
+	Identical bool   `json:"identical"`
+	PatchA    string `json:"patchA,omitempty"`
+	PatchB    string `json:"patchB,omitempty"`
+}
+
+// SessionCompareFileStats counts how the changed files overlap.
+type SessionCompareFileStats struct {
+	OnlyA     int `json:"onlyA"`
+	OnlyB     int `json:"onlyB"`
+	Identical int `json:"identical"`
+	Different int `json:"different"`
+}
+
+// CompareSessions compares the changes, hook results and token usage of two
+// sessions in the same workspace. In worktree mode each session's sandbox diff
+// is compared file by file. In commits mode the commits each session made since
+// its base are fetched and replayed onto the workspace repository, which also
+// produces a direct diff between the two results.
+func (c *ChatService) CompareSessions(ctx context.Context, projectID, sessionIDA, sessionIDB, mode string) (*SessionComparison, error) {
+	if mode == "" {
+		mode = CompareModeWorktree
+	}
+	if mode != CompareModeWorktree && mode != CompareModeCommits {
+		return nil, fmt.Errorf("invalid compare mode: %s", mode)
+	}
+	if c.sandboxService == nil {
+		return nil, fmt.Errorf("sandbox provider not available")
+	}
+	if mode == CompareModeCommits && c.gitService == nil {
+		return nil, fmt.Errorf("git service not available")
+	}
+
+	sessA, err := c.GetSession(ctx, projectID, sessionIDA)
+	if err != nil {
+		return nil, err
+	}
+	sessB, err := c.GetSession(ctx, projectID, sessionIDB)
+	if err != nil {
+		return nil, err
+	}
+	if sessA.WorkspaceID != sessB.WorkspaceID {
+		return nil, ErrCompareWorkspaceMismatch
+	}
+
+	result := &SessionComparison{Mode: mode}
+	sides := []*SessionCompareSummary{&result.A, &result.B}
+	series := make([]git.PatchSeries, 2)
+
+	for i, sess := range []*model.Session{sessA, sessB} {
+		summary := sides[i]
+		*summary = newSessionCompareSummary(sess)
+
+		switch mode {
+		case CompareModeWorktree:
+			summary.diff, err = c.sessionWorktreeDiff(ctx, sess.ID)
+		case CompareModeCommits:
+			series[i], summary.CommitCount, err = c.sessionCommits(ctx, sess)
+			if err == nil {
+				// The net change of each session is its commits against its own base
+				base := git.PatchSeries{BaseCommit: series[i].BaseCommit}
+				summary.diff, err = c.gitService.ComparePatches(ctx, sess.WorkspaceID, base, series[i])
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changes for session %s: %w", sess.ID, err)
+		}
+
+		for _, f := range summary.diff {
+			summary.Additions += f.Additions
+			summary.Deletions += f.Deletions
+		}
+		summary.FilesChanged = len(summary.diff)
+
+		c.fillSessionCompareStatus(ctx, projectID, sess.ID, summary)
+	}
+
+	result.Files, result.Stats = compareFileDiffs(result.A.diff, result.B.diff)
+
+	if mode == CompareModeCommits {
+		result.Diff, err = c.gitService.ComparePatches(ctx, sessA.WorkspaceID, series[0], series[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff sessions: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// newSessionCompareSummary creates a summary with the session's identifying fields.
+func newSessionCompareSummary(sess *model.Session) SessionCompareSummary {
+	summary := SessionCompareSummary{
+		SessionID:  sess.ID,
+		Name:       sess.Name,
+		BaseCommit: sessionBaseRef(sess),
+	}
+	if sess.DisplayName != nil && *sess.DisplayName != "" {
+		summary.Name = *sess.DisplayName
+	}
+	if sess.AgentID != nil {
+		summary.AgentID = *sess.AgentID
+	}
+	if sess.Model != nil {
+		summary.Model = *sess.Model
+	}
+	return summary
+}
+
+// sessionWorktreeDiff returns the sandbox diff of a session's working tree against its base.
+func (c *ChatService) sessionWorktreeDiff(ctx context.Context, sessionID string) ([]git.FileDiff, error) {
+	client, err := c.sandboxService.GetClient(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.GetDiff(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	diff, ok := result.(*sandboxapi.DiffResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected diff response type %T", result)
+	}
+
+	files := make([]git.FileDiff, 0, len(diff.Files))
+	for _, f := range diff.Files {
+		files = append(files, git.FileDiff{
+			Path:      f.Path,
+			OldPath:   f.OldPath,
+			Status:    f.Status,
+			Binary:    f.Binary,
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+			Patch:     f.Patch,
+		})
+	}
+	return files, nil
+}
+
+// sessionCommits fetches the commits a session made since its base commit.
+// A session without commits yields an empty series at its base.
+func (c *ChatService) sessionCommits(ctx context.Context, sess *model.Session) (git.PatchSeries, int, error) {
+	series := git.PatchSeries{BaseCommit: sessionBaseRef(sess)}
+	if series.BaseCommit == "" {
+		return series, 0, fmt.Errorf("session has no base commit")
+	}
+
+	client, err := c.sandboxService.GetClient(ctx, sess.ID)
+	if err != nil {
+		return series, 0, err
+	}
+	commits, err := client.GetCommits(ctx, series.BaseCommit)
+	if err != nil {
+		if strings.Contains(err.Error(), "(no_commits)") {
+			return series, 0, nil
+		}
+		return series, 0, err
+	}
+
+	series.Patches = []byte(commits.Patches)
+	return series, commits.CommitCount, nil
+}
+
+// fillSessionCompareStatus adds hook results and token usage to a summary.
+// Both are best effort: failures are recorded on the summary instead of failing
+// the comparison, since the diff is the essential part.
+func (c *ChatService) fillSessionCompareStatus(ctx context.Context, projectID, sessionID string, summary *SessionCompareSummary) {
+	if hooks, err := c.GetHooksStatus(ctx, projectID, sessionID); err != nil {
+		sessionLog.WarnContext(withSession(ctx, sessionID), "failed to get hooks status for comparison", "error", err)
+		summary.Errors = append(summary.Errors, fmt.Sprintf("hooks: %v", err))
+	} else {
+		summary.Hooks = summarizeHooks(hooks)
+	}
+
+	if messages, err := c.GetMessages(ctx, projectID, sessionID); err != nil {
+		sessionLog.WarnContext(withSession(ctx, sessionID), "failed to get messages for comparison", "error", err)
+		summary.Errors = append(summary.Errors, fmt.Sprintf("usage: %v", err))
+	} else {
+		summary.Usage = sumTokenUsage(messages)
+	}
+}
+
+// summarizeHooks counts hook results by state.
+func summarizeHooks(status *sandboxapi.HooksStatusResponse) *HookSummary {
+	summary := &HookSummary{Total: len(status.Hooks)}
+	for _, hook := range status.Hooks {
+		switch hook.LastResult {
+		case "success":
+			summary.Passed++
+		case "failure":
+			summary.Failed++
+		case "running":
+			summary.Running++
+		}
+	}
+	summary.Pending = len(status.PendingHooks)
+	return summary
+}
+
+// messageUsageMetadata is the usage the agent reports in assistant message metadata.
+type messageUsageMetadata struct {
+	Usage *TokenUsage `json:"usage"`
+}
+
+// sumTokenUsage sums the usage reported in message metadata.
+// Returns nil if no message reports usage.
+func sumTokenUsage(messages []sandboxapi.UIMessage) *TokenUsage {
+	var total *TokenUsage
+	for _, msg := range messages {
+		if len(msg.Metadata) == 0 {
+			continue
+		}
+		var meta messageUsageMetadata
+		if err := json.Unmarshal(msg.Metadata, &meta); err != nil || meta.Usage == nil {
+			continue
+		}
+		if total == nil {
+			total = &TokenUsage{}
+		}
+		total.InputTokens += meta.Usage.InputTokens
+		total.OutputTokens += meta.Usage.OutputTokens
+		total.CacheReadInputTokens += meta.Usage.CacheReadInputTokens
+		total.CacheCreationInputTokens += meta.Usage.CacheCreationInputTokens
+	}
+	return total
+}
+
+// compareFileDiffs matches the changed files of two sessions by path.
+func compareFileDiffs(a, b []git.FileDiff) ([]SessionCompareFile, SessionCompareFileStats) {
+	byPath := make(map[string]*SessionCompareFile)
+	var paths []string
+	get := func(path string) *SessionCompareFile {
+		f, ok := byPath[path]
+		if !ok {
+			f = &SessionCompareFile{Path: path}
+			byPath[path] = f
+			paths = append(paths, path)
+		}
+		return f
+	}
+
+	for _, d := range a {
+		f := get(d.Path)
+		f.StatusA, f.AdditionsA, f.DeletionsA, f.PatchA = d.Status, d.Additions, d.Deletions, d.Patch
+	}
+	for _, d := range b {
+		f := get(d.Path)
+		f.StatusB, f.AdditionsB, f.DeletionsB, f.PatchB = d.Status, d.Additions, d.Deletions, d.Patch
+	}
+
+	sort.Strings(paths)
+	var stats SessionCompareFileStats
+	files := make([]SessionCompareFile, 0, len(paths))
+	for _, path := range paths {
+		f := byPath[path]
+		switch {
+		case f.StatusB == "":
+			stats.OnlyA++
+		case f.StatusA == "":
+			stats.OnlyB++
+		case f.StatusA == f.StatusB && normalizePatch(f.PatchA) == normalizePatch(f.PatchB):
+			f.Identical = true
+			stats.Identical++
+		default:
+			stats.Different++
+		}
+		files = append(files, *f)
+	}
+	return files, stats
+}
+
+// normalizePatch strips the "index" header line, which differs between
+// otherwise identical changes made on top of different base commits.
+func normalizePatch(patch string) string {
+	lines := strings.Split(strings.TrimRight(patch, "\n"), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "index ") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+func TestCompareFileDiffs(t *testing.T) {
+	a := []git.FileDiff{
+		{Path: "same.go", Status: "modified", Additions: 1, Patch: "diff --git a/same.go b/same.go\nindex 111..222 100644\n+x"},
+		{Path: "differs.go", Status: "modified", Additions: 2, Patch: "diff --git a/differs.go b/differs.go\n+a"},
+		{Path: "only-a.go", Status: "added", Additions: 3},
+	}
+	b := []git.FileDiff{
+		{Path: "same.go", Status: "modified", Additions: 1, Patch: "diff --git a/same.go b/same.go\nindex 333..222 100644\n+x"},
+		{Path: "differs.go", Status: "modified", Deletions: 1, Patch: "diff --git a/differs.go b/differs.go\n-a"},
+		{Path: "only-b.go", Status: "deleted", Deletions: 4},
+	}
+
+	files, stats := compareFileDiffs(a, b)
+
+	want := SessionCompareFileStats{OnlyA: 1, OnlyB: 1, Identical: 1, Different: 1}
+	if stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+
+	if len(files) != 4 {
+		t.Fatalf("Expected 4 files, got %d", len(files))
+	}
+	// Files are sorted by path
+	paths := []string{"differs.go", "only-a.go", "only-b.go", "same.go"}
+	for i, path := range paths {
+		if files[i].Path != path {
+			t.Errorf("Expected files[%d] to be %s, got %s", i, path, files[i].Path)
+		}
+	}
+	if !files[3].Identical {
+		t.Error("Expected same.go to be identical (index line ignored)")
+	}
+	if files[0].Identical || files[0].AdditionsA != 2 || files[0].DeletionsB != 1 {
+		t.Errorf("Unexpected differs.go comparison: %+v", files[0])
+	}
+	if files[1].StatusB != "" || files[2].StatusA != "" {
+		t.Errorf("Expected one-sided statuses, got %+v and %+v", files[1], files[2])
+	}
+}
+
+func TestSummarizeHooks(t *testing.T) {
+	status := &sandboxapi.HooksStatusResponse{
+		Hooks: map[string]sandboxapi.HookRunStatus{
+			"lint":  {LastResult: "success"},
+			"test":  {LastResult: "failure"},
+			"build": {LastResult: "running"},
+			"fmt":   {LastResult: "success"},
+		},
+		PendingHooks: []string{"test"},
+	}
+
+	got := summarizeHooks(status)
+	want := HookSummary{Total: 4, Passed: 2, Failed: 1, Running: 1, Pending: 1}
+	if *got != want {
+		t.Errorf("Expected %+v, got %+v", want, *got)
+	}
+}
+
+func TestSumTokenUsage(t *testing.T) {
+	usage := func(in, out int) json.RawMessage {
+		data, _ := json.Marshal(map[string]any{"usage": TokenUsage{InputTokens: in, OutputTokens: out, CacheReadInputTokens: 1}})
+		return data
+	}
+
+	t.Run("sums usage across messages", func(t *testing.T) {
+		messages := []sandboxapi.UIMessage{
+			{ID: "1", Role: "user"},
+			{ID: "2", Role: "assistant", Metadata: usage(100, 10)},
+			{ID: "3", Role: "user"},
+			{ID: "4", Role: "assistant", Metadata: usage(200, 20)},
+			{ID: "5", Role: "assistant", Metadata: json.RawMessage(`{"other":true}`)},
+		}
+
+		got := sumTokenUsage(messages)
+		want := TokenUsage{InputTokens: 300, OutputTokens: 30, CacheReadInputTokens: 2}
+		if got == nil || *got != want {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("nil when no usage is reported", func(t *testing.T) {
+		if got := sumTokenUsage([]sandboxapi.UIMessage{{ID: "1", Role: "assistant"}}); got != nil {
+			t.Errorf("Expected nil usage, got %+v", got)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+)
+
+// ErrMetricsUnavailable is returned when a session's sandbox has no resource
+// metrics: it is not running, or its provider can't measure it.
+var ErrMetricsUnavailable = errors.New("resource metrics not available")
+
+// projectMetricsConcurrency bounds how many sandboxes are sampled at once
+// for a project summary. Each sample takes about a second.
+const projectMetricsConcurrency = 8
+
+// SessionMetrics is a session's resource usage (for API responses).
+type SessionMetrics struct {
+	SessionID string                   `json:"sessionId"`
+	Name      string                   `json:"name"`
+	Status    string                   `json:"status"`
+	Metrics   *sandbox.ResourceMetrics `json:"metrics,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// ProjectMetrics summarizes resource usage across a project's active
+// sessions (for API responses).
+type ProjectMetrics struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Sessions are ordered by CPU usage, busiest first.
+	Sessions []SessionMetrics `json:"sessions"`
+	// Total sums the sessions' metrics; MemoryLimitBytes is left empty.
+	Total sandbox.ResourceMetrics `json:"total"`
+}
+
+// GetMetrics samples the resource usage of a session's sandbox.
+func (s *SessionService) GetMetrics(ctx context.Context, projectID, sessionID string) (*sandbox.ResourceMetrics, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	return s.sampleMetrics(ctx, sessionID)
+}
+
+// GetProjectMetrics samples every active session in the project.
+func (s *SessionService) GetProjectMetrics(ctx context.Context, projectID string) (*ProjectMetrics, error) {
+	statuses := []string{model.SessionStatusReady, model.SessionStatusRunning}
+	sessions, err := s.store.ListSessionsByProjectAndStatuses(ctx, projectID, statuses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	results := make([]SessionMetrics, len(sessions))
+	sem := make(chan struct{}, projectMetricsConcurrency)
+	var wg sync.WaitGroup
+	for i, sess := range sessions {
+		results[i] = SessionMetrics{SessionID: sess.ID, Name: sess.Name, Status: sess.Status}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			m, err := s.sampleMetrics(ctx, sess.ID)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Metrics = m
+		}()
+	}
+	wg.Wait()
+
+	summary := &ProjectMetrics{Timestamp: time.Now(), Sessions: results}
+	for _, r := range results {
+		if m := r.Metrics; m != nil {
+			summary.Total.CPUPercent += m.CPUPercent
+			summary.Total.MemoryBytes += m.MemoryBytes
+			summary.Total.DiskReadBytes += m.DiskReadBytes
+			summary.Total.DiskWriteBytes += m.DiskWriteBytes
+			summary.Total.NetworkRxBytes += m.NetworkRxBytes
+			summary.Total.NetworkTxBytes += m.NetworkTxBytes
+			summary.Total.Processes += m.Processes
+		}
+	}
+	summary.Total.Timestamp = summary.Timestamp
+	slices.SortStableFunc(summary.Sessions, func(a, b SessionMetrics) int {
+		return cmp.Compare(cpuOf(b), cpuOf(a))
+	})
+	return summary, nil
+}
+
+func cpuOf(m SessionMetrics) float64 {
+	if m.Metrics == nil {
+		return -1
+	}
+	return m.Metrics.CPUPercent
+}
+
+// sampleMetrics samples a sandbox without checking its project.
+func (s *SessionService) sampleMetrics(ctx context.Context, sessionID string) (*sandbox.ResourceMetrics, error) {
+	mp, ok := s.sandboxProvider.(sandbox.MetricsProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %w", ErrMetricsUnavailable, sandbox.ErrMetricsNotSupported)
+	}
+	m, err := mp.Metrics(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sandbox.ErrNotFound) || errors.Is(err, sandbox.ErrNotRunning) || errors.Is(err, sandbox.ErrMetricsNotSupported) {
+			return nil, fmt.Errorf("%w: %w", ErrMetricsUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to sample metrics: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/jobs"
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+// sessionBaseRef returns the commit a session's work is currently based on.
+// BaseCommit is preferred since it is updated by commits and rebases; the
+// WorkspaceCommit the sandbox was created from is used as a fallback.
+func sessionBaseRef(sess *model.Session) string {
+	if sess.BaseCommit != nil && *sess.BaseCommit != "" {
+		return *sess.BaseCommit
+	}
+	if sess.WorkspaceCommit != nil {
+		return *sess.WorkspaceCommit
+	}
+	return ""
+}
+
+// RefreshUpstream compares a session's base commit against its workspace's tracked
+// branch and records the result. A session_behind event is published when the
+// upstream commit or behind count changes. Returns the computed status.
+func (s *SessionService) RefreshUpstream(ctx context.Context, sess *model.Session) (*git.UpstreamStatus, error) {
+	if s.gitService == nil {
+		return nil, fmt.Errorf("git service not available")
+	}
+
+	ref := sessionBaseRef(sess)
+	if ref == "" {
+		return nil, fmt.Errorf("session %s has no base commit", sess.ID)
+	}
+
+	status, err := s.gitService.CompareUpstream(ctx, sess.WorkspaceID, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare with upstream: %w", err)
+	}
+
+	previousCommit := ""
+	if sess.UpstreamCommit != nil {
+		previousCommit = *sess.UpstreamCommit
+	}
+	if previousCommit == status.UpstreamCommit && sess.BehindCount == status.Behind {
+		return status, nil
+	}
+
+	if err := s.store.UpdateSessionUpstream(ctx, sess.ID, status.UpstreamCommit, status.Behind); err != nil {
+		return nil, fmt.Errorf("failed to update session upstream: %w", err)
+	}
+	sess.UpstreamCommit = ptrString(status.UpstreamCommit)
+	sess.BehindCount = status.Behind
+
+	s.publishSessionBehind(ctx, sess, status)
+	return status, nil
+}
+
+// publishSessionBehind publishes an SSE event describing how far a session is behind.
+func (s *SessionService) publishSessionBehind(ctx context.Context, sess *model.Session, status *git.UpstreamStatus) {
+	if s.eventBroker == nil {
+		return
+	}
+	data := events.SessionBehindData{
+		SessionID:      sess.ID,
+		WorkspaceID:    sess.WorkspaceID,
+		Upstream:       status.Upstream,
+		UpstreamCommit: status.UpstreamCommit,
+		Behind:         status.Behind,
+	}
+	if err := s.eventBroker.PublishSessionBehind(ctx, sess.ProjectID, data); err != nil {
+		sessionLog.WarnContext(withSession(ctx, sess.ID), "failed to publish session behind event", "error", err)
+	}
+}
+
+// RebaseSession initiates an async rebase of a session onto the latest commit
+// of its workspace's tracked branch.
+func (s *SessionService) RebaseSession(ctx context.Context, projectID, sessionID string, jobQueue JobEnqueuer) error {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ProjectID != projectID {
+		return fmt.Errorf("session not found")
+	}
+
+	if err := jobQueue.Enqueue(ctx, jobs.SessionRebasePayload{ProjectID: projectID, SessionID: sessionID, WorkspaceID: sess.WorkspaceID}); err != nil {
+		return fmt.Errorf("failed to enqueue rebase job: %w", err)
+	}
+
+	return nil
+}
+
+// PerformRebase moves a session onto the latest commit of its workspace's tracked branch.
+// This is called by the dispatcher when processing a session_rebase job.
+// The workspace is fetched (and fast-forwarded if it is a managed clone) and the agent
+// is sent /discobot-rebase so it can rebase its work inside the sandbox. The session's
+// base commit is updated once the agent reports success.
+func (s *SessionService) PerformRebase(ctx context.Context, projectID, sessionID string) error {
+	if s.gitService == nil {
+		return fmt.Errorf("git service not available")
+	}
+	if s.sandboxService == nil {
+		return fmt.Errorf("sandbox service not available")
+	}
+
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ProjectID != projectID {
+		return fmt.Errorf("session does not belong to project")
+	}
+
+	workspace, err := s.store.GetWorkspaceByID(ctx, sess.WorkspaceID)
+	if err != nil {
+		return fmt.Errorf("workspace not found: %w", err)
+	}
+
+	ctx = withSession(ctx, sess.ID)
+	if err := s.gitService.Fetch(ctx, workspace.ID); err != nil {
+		sessionLog.WarnContext(ctx, "fetch before rebase failed, using local refs", "error", err)
+	}
+
+	// Managed clones of remote repositories are fast-forwarded so that later commits
+	// are applied on top of the same commit the agent rebases onto. Local workspaces
+	// belong to the user and are left untouched.
+	if workspace.SourceType == "git" || git.IsGitURL(workspace.Path) {
+		if _, err := s.gitService.FastForward(ctx, workspace.ID); err != nil {
+			return fmt.Errorf("failed to update workspace: %w", err)
+		}
+	}
+
+	status, err := s.gitService.CompareUpstream(ctx, workspace.ID, sessionBaseRef(sess))
+	if err != nil {
+		return fmt.Errorf("failed to compare with upstream: %w", err)
+	}
+
+	if status.Behind == 0 {
+		sessionLog.InfoContext(ctx, "session already up to date", "upstream", status.Upstream)
+		_, err := s.RefreshUpstream(ctx, sess)
+		return err
+	}
+
+	target := status.UpstreamCommit
+	sessionLog.InfoContext(ctx, "rebasing session", "target", target, "upstream", status.Upstream, "behind", status.Behind)
+
+	if err := s.sendAgentMessage(ctx, sess, sess.ID+"-rebase", fmt.Sprintf("/discobot-rebase %s", target)); err != nil {
+		return fmt.Errorf("agent rebase failed: %w", err)
+	}
+
+	// Only move the session's base once the agent has rebased onto it, so a
+	// failed rebase leaves the session describing the work it actually holds
+	sess.BaseCommit = ptrString(target)
+	sess.WorkspaceCommit = ptrString(target)
+	if err := s.store.UpdateSession(ctx, sess); err != nil {
+		return fmt.Errorf("failed to update session base commit: %w", err)
+	}
+
+	if _, err := s.RefreshUpstream(ctx, sess); err != nil {
+		sessionLog.WarnContext(ctx, "failed to refresh upstream after rebase", "error", err)
+	}
+
+	sessionLog.InfoContext(ctx, "/discobot-rebase message completed")
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/config"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+)
+
+// setupRebase creates a session whose workspace has moved one commit past the
+// session's base. It returns the session service, the session and the new
+// workspace commit.
+func setupRebase(t *testing.T, env *testEnv, handler *mockHandler) (*SessionService, *model.Session, string) {
+	t.Helper()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+	target := env.addCommitToWorkspace(t, workspace.Path, "upstream.txt", "upstream\n")
+
+	env.mockSandbox.HTTPHandler = handler
+	if _, err := env.mockSandbox.Create(context.Background(), session.ID, sandbox.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	if err := env.mockSandbox.Start(context.Background(), session.ID); err != nil {
+		t.Fatalf("Failed to start sandbox: %v", err)
+	}
+
+	sandboxSvc := NewSandboxService(env.store, env.mockSandbox, &config.Config{}, nil, env.eventBroker, nil)
+	sandboxSvc.SetSessionInitializer(&testSessionInitializer{})
+	sessionSvc := NewSessionService(env.store, env.gitService, env.mockSandbox, sandboxSvc, env.eventBroker, nil)
+	return sessionSvc, session, target
+}
+
+func TestPerformRebase_UpdatesBaseCommit(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	handler := newMockHandler()
+	sessionSvc, session, target := setupRebase(t, env, handler)
+
+	if err := sessionSvc.PerformRebase(context.Background(), session.ProjectID, session.ID); err != nil {
+		t.Fatalf("PerformRebase failed: %v", err)
+	}
+	if handler.getChatRequestCount() != 1 {
+		t.Errorf("Expected one rebase chat request, got %d", handler.getChatRequestCount())
+	}
+
+	updated, err := env.store.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if updated.BaseCommit == nil || *updated.BaseCommit != target {
+		t.Errorf("Expected base commit %s, got %v", target, updated.BaseCommit)
+	}
+}
+
+func TestPerformRebase_AgentErrorKeepsBaseCommit(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	handler := newMockHandler()
+	handler.chatStream = []string{`{"type":"error","errorText":"rebase conflict"}`}
+	sessionSvc, session, _ := setupRebase(t, env, handler)
+
+	err := sessionSvc.PerformRebase(context.Background(), session.ProjectID, session.ID)
+	if err == nil || !strings.Contains(err.Error(), "rebase conflict") {
+		t.Fatalf("Expected agent error, got %v", err)
+	}
+
+	updated, err := env.store.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if updated.BaseCommit == nil || *updated.BaseCommit != *session.BaseCommit {
+		t.Errorf("Expected base commit to stay %s, got %v", *session.BaseCommit, updated.BaseCommit)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+// ErrStartupTimelineUnavailable is returned when a session's sandbox has no
+// startup timeline: it is not running, or its image predates the timeline.
+var ErrStartupTimelineUnavailable = errors.New("startup timeline not available")
+
+const (
+	// startupWatchInterval is how often the timeline is read while starting
+	startupWatchInterval = time.Second
+	// startupWatchFirstRead bounds the wait for the agent to write a timeline
+	startupWatchFirstRead = 2 * time.Minute
+	// startupWatchTimeout bounds the whole watch, including background hooks
+	startupWatchTimeout = 30 * time.Minute
+	// startupClockSkew tolerates clock differences between host and sandbox
+	// when discarding a timeline left over from a previous container start
+	startupClockSkew = 5 * time.Second
+)
+
+// GetStartupTimeline returns the setup steps recorded by the session's
+// sandbox agent, with their timing and outcome.
+func (s *SessionService) GetStartupTimeline(ctx context.Context, projectID, sessionID string) (*sandboxapi.StartupTimeline, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	return s.readStartupTimeline(ctx, sessionID)
+}
+
+// readStartupTimeline reads the timeline file from the sandbox. The agent
+// writes it before the sandbox HTTP API is up, so it is read with an exec.
+func (s *SessionService) readStartupTimeline(ctx context.Context, sessionID string) (*sandboxapi.StartupTimeline, error) {
+	result, err := s.sandboxProvider.Exec(ctx, sessionID, []string{"cat", sandboxapi.StartupTimelinePath}, sandbox.ExecOptions{})
+	if err != nil {
+		if errors.Is(err, sandbox.ErrNotFound) || errors.Is(err, sandbox.ErrNotRunning) {
+			return nil, fmt.Errorf("%w: %w", ErrStartupTimelineUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to read startup timeline: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, ErrStartupTimelineUnavailable
+	}
+
+	var timeline sandboxapi.StartupTimeline
+	if err := json.Unmarshal(result.Stdout, &timeline); err != nil {
+		return nil, fmt.Errorf("invalid startup timeline: %w", err)
+	}
+	return &timeline, nil
+}
+
+// watchStartup follows the startup timeline of a sandbox started at
+// startedAt, publishing a session_updated event with the running step as its
+// sub-status each time it changes. The final event has an empty sub-status.
+// It returns once setup has completed and no step is running, or when the
+// timeline cannot be read.
+func (s *SessionService) watchStartup(projectID, sessionID string, startedAt time.Time) {
+	if s.eventBroker == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupWatchTimeout)
+	defer cancel()
+	ticker := time.NewTicker(startupWatchInterval)
+	defer ticker.Stop()
+
+	seen := false
+	current := ""
+	for {
+		timeline, err := s.readStartupTimeline(ctx, sessionID)
+		switch {
+		case err == nil && timeline.StartedAt.After(startedAt.Add(-startupClockSkew)):
+			seen = true
+			subStatus := ""
+			if step := timeline.CurrentStep(); step != nil {
+				subStatus = step.Name
+			}
+			if subStatus != current {
+				current = subStatus
+				s.publishSubStatus(ctx, projectID, sessionID, subStatus)
+			}
+			if timeline.Outcome != sandboxapi.StartupOutcomeRunning && subStatus == "" {
+				return
+			}
+		case err == nil:
+			// Left over from a previous start; the agent has not rewritten it yet
+		case errors.Is(err, sandbox.ErrNotFound) || !errors.Is(err, ErrStartupTimelineUnavailable):
+			return
+		}
+
+		if !seen && time.Since(startedAt) > startupWatchFirstRead {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishSubStatus publishes the running startup step alongside the
+// session's current status.
+func (s *SessionService) publishSubStatus(ctx context.Context, projectID, sessionID, subStatus string) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return
+	}
+	if err := s.eventBroker.PublishSessionSubStatus(ctx, projectID, sessionID, sess.Status, subStatus); err != nil {
+		sessionLog.WarnContext(withSession(ctx, sessionID), "failed to publish startup step", "error", err)
+	}
+}
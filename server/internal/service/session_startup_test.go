@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/sandboxapi"
+)
+
+// startupTimelineJSON builds a timeline file with the given steps' outcomes.
+func startupTimelineJSON(t *testing.T, startedAt time.Time, outcome string, steps ...sandboxapi.StartupStep) []byte {
+	t.Helper()
+	data, err := json.Marshal(sandboxapi.StartupTimeline{StartedAt: startedAt, Outcome: outcome, Steps: steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGetStartupTimeline(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+	svc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, nil)
+	ctx := context.Background()
+
+	file := startupTimelineJSON(t, time.Now(), sandboxapi.StartupOutcomeFailure,
+		sandboxapi.StartupStep{Name: "workspace", Outcome: sandboxapi.StartupOutcomeFailure, Error: "clone failed"})
+	env.mockSandbox.ExecFunc = func(_ context.Context, _ string, cmd []string, _ sandbox.ExecOptions) (*sandbox.ExecResult, error) {
+		if len(cmd) != 2 || cmd[1] != sandboxapi.StartupTimelinePath {
+			t.Errorf("unexpected command %v", cmd)
+		}
+		return &sandbox.ExecResult{Stdout: file}, nil
+	}
+
+	timeline, err := svc.GetStartupTimeline(ctx, project.ID, session.ID)
+	if err != nil {
+		t.Fatalf("GetStartupTimeline failed: %v", err)
+	}
+	if timeline.Outcome != sandboxapi.StartupOutcomeFailure || len(timeline.Steps) != 1 || timeline.Steps[0].Error != "clone failed" {
+		t.Errorf("unexpected timeline %+v", timeline)
+	}
+
+	if _, err := svc.GetStartupTimeline(ctx, "other-project", session.ID); err == nil {
+		t.Error("expected an error for a session in another project")
+	}
+
+	env.mockSandbox.ExecFunc = func(context.Context, string, []string, sandbox.ExecOptions) (*sandbox.ExecResult, error) {
+		return &sandbox.ExecResult{ExitCode: 1}, nil
+	}
+	if _, err := svc.GetStartupTimeline(ctx, project.ID, session.ID); !errors.Is(err, ErrStartupTimelineUnavailable) {
+		t.Errorf("expected ErrStartupTimelineUnavailable without a file, got %v", err)
+	}
+}
+
+func TestWatchStartupPublishesSubStatus(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+	svc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, nil)
+
+	startedAt := time.Now()
+	stale := startedAt.Add(-time.Hour)
+	running := sandboxapi.StartupOutcomeRunning
+	success := sandboxapi.StartupOutcomeSuccess
+	reads := [][]byte{
+		// Left over from the previous container start
+		startupTimelineJSON(t, stale, success),
+		startupTimelineJSON(t, startedAt, running,
+			sandboxapi.StartupStep{Name: "workspace", Outcome: running}),
+		startupTimelineJSON(t, startedAt, success,
+			sandboxapi.StartupStep{Name: "workspace", Outcome: success},
+			sandboxapi.StartupStep{Name: "session hook: install", Outcome: running}),
+		startupTimelineJSON(t, startedAt, success,
+			sandboxapi.StartupStep{Name: "workspace", Outcome: success},
+			sandboxapi.StartupStep{Name: "session hook: install", Outcome: success}),
+	}
+	var mu sync.Mutex
+	calls := 0
+	env.mockSandbox.ExecFunc = func(context.Context, string, []string, sandbox.ExecOptions) (*sandbox.ExecResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		read := reads[min(calls, len(reads)-1)]
+		calls++
+		return &sandbox.ExecResult{Stdout: read}, nil
+	}
+
+	svc.watchStartup(project.ID, session.ID, startedAt)
+
+	if calls != len(reads) {
+		t.Errorf("timeline read %d times, want %d", calls, len(reads))
+	}
+	stored, err := env.store.ListEventsAfterSeq(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	var subStatuses []string
+	for _, e := range stored {
+		var data events.SessionUpdatedData
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			t.Fatalf("invalid event data: %v", err)
+		}
+		if data.SessionID == session.ID && data.Status == session.Status {
+			subStatuses = append(subStatuses, data.SubStatus)
+		}
+	}
+	want := []string{"workspace", "session hook: install", ""}
+	if len(subStatuses) != len(want) {
+		t.Fatalf("sub-statuses = %q, want %q", subStatuses, want)
+	}
+	for i := range want {
+		if subStatuses[i] != want[i] {
+			t.Errorf("sub-statuses = %q, want %q", subStatuses, want)
+			break
+		}
+	}
+}
+
+func TestWatchStartupStopsWhenSandboxRemoved(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	svc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, nil)
+	done := make(chan struct{})
+	go func() {
+		svc.watchStartup("test-project", "missing-session", time.Now())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchStartup kept polling a removed sandbox")
+	}
+}
@@ -34,6 +34,10 @@ type SessionStatusPoller struct {
 	stopChan     chan struct{}
 	wg           sync.WaitGroup
 	shutdownOnce sync.Once
+
+	// questionSightings tracks pending questions by session ID for question
+	// policy timeouts. Only the poll loop touches it.
+	questionSightings map[string]questionSighting
 }
 
 // NewSessionStatusPoller creates a new session status poller
@@ -49,6 +53,8 @@ func NewSessionStatusPoller(
 		eventBroker: eventBroker,
 		logger:      logger.With("component", "session_status_poller"),
 		stopChan:    make(chan struct{}),
+
+		questionSightings: make(map[string]questionSighting),
 	}
 }
 
@@ -138,6 +144,17 @@ func (p *SessionStatusPoller) checkRunningSessions(ctx context.Context) (bool, e
 
 	p.logger.Debug("checking running sessions", "count", len(sessions))
 
+	// Forget questions of sessions that are no longer running
+	running := make(map[string]bool, len(sessions))
+	for _, session := range sessions {
+		running[session.ID] = true
+	}
+	for sessionID := range p.questionSightings {
+		if !running[sessionID] {
+			delete(p.questionSightings, sessionID)
+		}
+	}
+
 	stillRunning := 0
 	for _, session := range sessions {
 		err := p.checkSession(ctx, &session)
@@ -197,6 +214,11 @@ func (p *SessionStatusPoller) checkSession(ctx context.Context, session *model.S
 	}
 
 	logger.Debug("session completion still running", "completion_id", status.CompletionID)
+
+	// Answer or time out questions nobody is answering
+	if err := p.applyQuestionPolicy(ctx, session, client); err != nil {
+		logger.Warn("failed to apply question policy", "error", err)
+	}
 	return nil
 }
 
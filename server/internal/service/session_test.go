@@ -1,11 +1,16 @@
 package service
 
 import (
+	"context"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/obot-platform/discobot/server/internal/jobs"
 	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/store"
 )
 
 func TestValidateSessionID(t *testing.T) {
@@ -147,6 +152,10 @@ func TestMapSessionFieldCoverage(t *testing.T) {
 		ErrorMessage:    strPtr("error message"),
 		WorkspacePath:   strPtr("/path/to/workspace"),
 		WorkspaceCommit: strPtr("commit789"),
+		UpstreamCommit:  strPtr("upstream012"),
+		BehindCount:     3,
+		CommitReview:    true,
+		CommitPatches:   strPtr("From abc"),
 		Model:           strPtr("claude-opus-4-6"),
 		Reasoning:       strPtr("enabled"),
 		Mode:            strPtr("plan"),
@@ -176,6 +185,9 @@ func TestMapSessionFieldCoverage(t *testing.T) {
 		"ErrorMessage":    "ErrorMessage",
 		"WorkspacePath":   "WorkspacePath",
 		"WorkspaceCommit": "WorkspaceCommit",
+		"UpstreamCommit":  "UpstreamCommit",
+		"BehindCount":     "BehindCount",
+		"CommitReview":    "CommitReview",
 		"Model":           "Model",
 		"Reasoning":       "Reasoning",
 		"Mode":            "Mode",
@@ -183,6 +195,8 @@ func TestMapSessionFieldCoverage(t *testing.T) {
 		// - CreatedAt, UpdatedAt: mapped to Timestamp
 		// - Project, Workspace, Agent, Messages: relationships, not serialized
 		// - Files: always initialized as empty array in mapSession
+		// - CommitPatches: raw patches for review, served by GetCommitReview
+		// - QuestionPolicy: served by GetQuestionPolicy
 	}
 
 	// Use reflection to verify all documented fields are mapped
@@ -197,7 +211,8 @@ func TestMapSessionFieldCoverage(t *testing.T) {
 		// Skip GORM metadata fields and relationship fields
 		if modelFieldName == "CreatedAt" || modelFieldName == "UpdatedAt" ||
 			modelFieldName == "Project" || modelFieldName == "Workspace" ||
-			modelFieldName == "Agent" || modelFieldName == "Messages" {
+			modelFieldName == "Agent" || modelFieldName == "Messages" ||
+			modelFieldName == "CommitPatches" || modelFieldName == "QuestionPolicy" {
 			continue
 		}
 
@@ -249,6 +264,9 @@ func TestMapSessionFieldCoverage(t *testing.T) {
 	if result.DisplayName != "Test Display" {
 		t.Errorf("DisplayName = %q, want %q", result.DisplayName, "Test Display")
 	}
+	if result.BehindCount != 3 {
+		t.Errorf("BehindCount = %d, want %d", result.BehindCount, 3)
+	}
 	if result.AgentID != "test-agent" {
 		t.Errorf("AgentID = %q, want %q", result.AgentID, "test-agent")
 	}
@@ -258,3 +276,120 @@ func TestMapSessionFieldCoverage(t *testing.T) {
 		t.Error("Files should be initialized to empty array, got nil")
 	}
 }
+
+func TestPerformDeletionStopsSandboxFirst(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+	svc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, nil)
+
+	var calls []string
+	env.mockSandbox.StopFunc = func(_ context.Context, _ string, timeout time.Duration) error {
+		calls = append(calls, "stop")
+		if timeout != sandbox.StopTimeout {
+			t.Errorf("stop timeout = %v, want %v to leave time for shutdown hooks", timeout, sandbox.StopTimeout)
+		}
+		return nil
+	}
+	env.mockSandbox.RemoveFunc = func(context.Context, string, ...sandbox.RemoveOption) error {
+		calls = append(calls, "remove")
+		return nil
+	}
+
+	if err := svc.PerformDeletion(context.Background(), project.ID, session.ID); err != nil {
+		t.Fatalf("PerformDeletion failed: %v", err)
+	}
+	if strings.Join(calls, ",") != "stop,remove" {
+		t.Errorf("sandbox calls = %v, want stop then remove", calls)
+	}
+}
+
+func TestInitializeAndWait(t *testing.T) {
+	orig := initPollInterval
+	initPollInterval = 10 * time.Millisecond
+	defer func() { initPollInterval = orig }()
+
+	tests := []struct {
+		name    string
+		init    func(ctx context.Context, s *store.Store, sessionID string) error
+		wantErr string
+	}{
+		{
+			name: "ready",
+			init: func(ctx context.Context, s *store.Store, sessionID string) error {
+				return s.UpdateSessionStatus(ctx, sessionID, model.SessionStatusReady, nil)
+			},
+		},
+		{
+			name: "session error",
+			init: func(ctx context.Context, s *store.Store, sessionID string) error {
+				return s.UpdateSessionStatus(ctx, sessionID, model.SessionStatusError, ptrString("git setup failed"))
+			},
+			wantErr: "git setup failed",
+		},
+		{
+			name: "job failed",
+			init: func(ctx context.Context, s *store.Store, sessionID string) error {
+				return s.CreateJob(ctx, &model.Job{
+					ID:           "job-1",
+					Type:         string(jobs.JobTypeSessionInit),
+					Payload:      []byte("{}"),
+					Status:       string(model.JobStatusFailed),
+					Error:        ptrString("no default agent"),
+					ScheduledAt:  time.Now(),
+					ResourceType: ptrString(jobs.ResourceTypeSession),
+					ResourceID:   ptrString(sessionID),
+				})
+			},
+			wantErr: "no default agent",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			defer env.cleanup()
+
+			project := env.createTestProject(t)
+			agent := env.createTestAgent(t, project.ID)
+			workspace, _ := env.createTestWorkspace(t, project.ID)
+			session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, "")
+			if err := env.store.UpdateSessionStatus(context.Background(), session.ID, model.SessionStatusInitializing, nil); err != nil {
+				t.Fatalf("UpdateSessionStatus failed: %v", err)
+			}
+
+			// Stand in for the dispatcher running the session_init job
+			var enqueued []jobs.SessionInitPayload
+			enqueuer := &mockJobEnqueuer{
+				enqueueFunc: func(ctx context.Context, payload jobs.JobPayload) error {
+					if p, ok := payload.(jobs.SessionInitPayload); ok {
+						enqueued = append(enqueued, p)
+						go func() {
+							if err := tt.init(context.WithoutCancel(ctx), env.store, p.SessionID); err != nil {
+								t.Errorf("init failed: %v", err)
+							}
+						}()
+					}
+					return nil
+				},
+			}
+			svc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, enqueuer)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := svc.InitializeAndWait(ctx, project.ID, session.ID, workspace.ID, agent.ID, enqueuer)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("InitializeAndWait failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if len(enqueued) != 1 || enqueued[0].SessionID != session.ID || enqueued[0].WorkspaceID != workspace.ID {
+				t.Errorf("Expected one session init job for the session, got %+v", enqueued)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// Session share link limits.
+const (
+	DefaultShareLifetimeDays = 7
+	MaxShareLifetimeDays     = 30
+
+	// sessionSharePrefix marks share tokens so they are distinguishable from
+	// API tokens in logs and secret scanners.
+	sessionSharePrefix = "dss_"
+)
+
+// Session share errors.
+var (
+	ErrInvalidShareRequest = errors.New("invalid share request")
+	ErrShareNotFound       = errors.New("share not found")
+)
+
+// ShareService manages read-only share links for sessions.
+type ShareService struct {
+	store *store.Store
+}
+
+// NewShareService creates a new share service.
+func NewShareService(s *store.Store) *ShareService {
+	return &ShareService{store: s}
+}
+
+// SessionShare represents a session share link (for API responses). Token
+// and URL are only set in the response that creates it.
+type SessionShare struct {
+	ID         string     `json:"id"`
+	SessionID  string     `json:"sessionId"`
+	Prefix     string     `json:"prefix"`
+	CreatedBy  string     `json:"createdBy"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Token      string     `json:"token,omitempty"`
+	URL        string     `json:"url,omitempty"`
+}
+
+// CreateShareRequest contains the parameters for creating a share link.
+type CreateShareRequest struct {
+	ExpiresInDays int `json:"expiresInDays,omitempty"` // Defaults to DefaultShareLifetimeDays
+}
+
+// SharedSession is the subset of a session visible through a share link.
+type SharedSession struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	DisplayName  *string   `json:"displayName,omitempty"`
+	Description  *string   `json:"description,omitempty"`
+	Status       string    `json:"status"`
+	CommitStatus string    `json:"commitStatus"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"` // When the share link expires
+}
+
+// CreateShare creates a share link for a session. The plaintext token is
+// returned once and only its hash is stored. baseURL is used to build the
+// link's URL.
+func (s *ShareService) CreateShare(ctx context.Context, projectID, sessionID, userID string, req CreateShareRequest, baseURL string) (*SessionShare, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil || sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = DefaultShareLifetimeDays
+	}
+	if days < 0 || days > MaxShareLifetimeDays {
+		return nil, fmt.Errorf("%w: expiresInDays must be between 1 and %d", ErrInvalidShareRequest, MaxShareLifetimeDays)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	raw := sessionSharePrefix + base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	share := &model.SessionShare{
+		ProjectID: projectID,
+		SessionID: sessionID,
+		Prefix:    raw[:len(sessionSharePrefix)+8],
+		TokenHash: hashToken(raw),
+		CreatedBy: userID,
+		ExpiresAt: time.Now().AddDate(0, 0, days),
+	}
+	if err := s.store.CreateSessionShare(ctx, share); err != nil {
+		return nil, fmt.Errorf("failed to create share: %w", err)
+	}
+
+	result := toSessionShare(share)
+	result.Token = raw
+	result.URL = strings.TrimRight(baseURL, "/") + "/api/share/" + raw
+	return result, nil
+}
+
+// ListShares returns a session's share links, including expired ones.
+func (s *ShareService) ListShares(ctx context.Context, projectID, sessionID string) ([]*SessionShare, error) {
+	sess, err := s.store.GetSessionByID(ctx, sessionID)
+	if err != nil || sess.ProjectID != projectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	shares, err := s.store.ListSessionSharesBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SessionShare, len(shares))
+	for i, share := range shares {
+		result[i] = toSessionShare(share)
+	}
+	return result, nil
+}
+
+// RevokeShare deletes one of a session's share links.
+func (s *ShareService) RevokeShare(ctx context.Context, projectID, sessionID, shareID string) (*SessionShare, error) {
+	share, err := s.store.GetSessionShareByID(ctx, shareID)
+	if err != nil || share.ProjectID != projectID || share.SessionID != sessionID {
+		return nil, ErrShareNotFound
+	}
+	if err := s.store.DeleteSessionShare(ctx, shareID); err != nil {
+		return nil, fmt.Errorf("failed to revoke share: %w", err)
+	}
+	return toSessionShare(share), nil
+}
+
+// ValidateShare resolves a plaintext share token and records its use. It
+// returns ErrShareNotFound for unknown, revoked and expired tokens alike.
+func (s *ShareService) ValidateShare(ctx context.Context, raw string) (*model.SessionShare, error) {
+	if !strings.HasPrefix(raw, sessionSharePrefix) {
+		return nil, ErrShareNotFound
+	}
+
+	share, err := s.store.GetSessionShareByHash(ctx, hashToken(raw))
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+
+	now := time.Now()
+	if share.ExpiresAt.Before(now) {
+		return nil, ErrShareNotFound
+	}
+
+	if share.LastUsedAt == nil || now.Sub(*share.LastUsedAt) >= tokenLastUsedResolution {
+		if err := s.store.TouchSessionShare(ctx, share.ID, now); err != nil {
+			authLog.WarnContext(ctx, "failed to record use of session share", "share_id", share.ID, "error", err)
+		}
+	}
+	return share, nil
+}
+
+// GetSharedSession returns the session a share link points to.
+func (s *ShareService) GetSharedSession(ctx context.Context, share *model.SessionShare) (*SharedSession, error) {
+	sess, err := s.store.GetSessionByID(ctx, share.SessionID)
+	if err != nil || sess.ProjectID != share.ProjectID {
+		return nil, fmt.Errorf("session not found")
+	}
+	return &SharedSession{
+		ID:           sess.ID,
+		Name:         sess.Name,
+		DisplayName:  sess.DisplayName,
+		Description:  sess.Description,
+		Status:       sess.Status,
+		CommitStatus: sess.CommitStatus,
+		CreatedAt:    sess.CreatedAt,
+		UpdatedAt:    sess.UpdatedAt,
+		ExpiresAt:    share.ExpiresAt,
+	}, nil
+}
+
+func toSessionShare(share *model.SessionShare) *SessionShare {
+	return &SessionShare{
+		ID:         share.ID,
+		SessionID:  share.SessionID,
+		Prefix:     share.Prefix,
+		CreatedBy:  share.CreatedBy,
+		ExpiresAt:  share.ExpiresAt,
+		LastUsedAt: share.LastUsedAt,
+		CreatedAt:  share.CreatedAt,
+	}
+}
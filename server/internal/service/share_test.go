@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+func TestCreateShare(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, "")
+	svc := NewShareService(env.store)
+	ctx := context.Background()
+
+	share, err := svc.CreateShare(ctx, project.ID, session.ID, "user-1", CreateShareRequest{}, "https://discobot.example.com/")
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+	if !strings.HasPrefix(share.Token, sessionSharePrefix) || !strings.HasPrefix(share.Token, share.Prefix) {
+		t.Errorf("Unexpected token %q with prefix %q", share.Token, share.Prefix)
+	}
+	if share.URL != "https://discobot.example.com/api/share/"+share.Token {
+		t.Errorf("Unexpected URL %q", share.URL)
+	}
+	if d := time.Until(share.ExpiresAt); d < (DefaultShareLifetimeDays-1)*24*time.Hour || d > DefaultShareLifetimeDays*24*time.Hour {
+		t.Errorf("Expected default lifetime, got expiry in %s", d)
+	}
+
+	shares, err := svc.ListShares(ctx, project.ID, session.ID)
+	if err != nil {
+		t.Fatalf("ListShares failed: %v", err)
+	}
+	if len(shares) != 1 || shares[0].Token != "" || shares[0].URL != "" {
+		t.Errorf("Expected one share without its token, got %+v", shares)
+	}
+
+	for _, days := range []int{-1, MaxShareLifetimeDays + 1} {
+		if _, err := svc.CreateShare(ctx, project.ID, session.ID, "user-1", CreateShareRequest{ExpiresInDays: days}, ""); !errors.Is(err, ErrInvalidShareRequest) {
+			t.Errorf("Expected ErrInvalidShareRequest for %d days, got %v", days, err)
+		}
+	}
+	if _, err := svc.CreateShare(ctx, "other-project", session.ID, "user-1", CreateShareRequest{}, ""); err == nil {
+		t.Error("Expected an error for a session in another project")
+	}
+}
+
+func TestValidateShare(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, _ := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, "")
+	svc := NewShareService(env.store)
+	ctx := context.Background()
+
+	created, err := svc.CreateShare(ctx, project.ID, session.ID, "user-1", CreateShareRequest{ExpiresInDays: 1}, "")
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+
+	share, err := svc.ValidateShare(ctx, created.Token)
+	if err != nil {
+		t.Fatalf("ValidateShare failed: %v", err)
+	}
+	if share.SessionID != session.ID || share.ProjectID != project.ID {
+		t.Errorf("Share resolved to %s/%s", share.ProjectID, share.SessionID)
+	}
+	stored, err := env.store.GetSessionShareByID(ctx, share.ID)
+	if err != nil || stored.LastUsedAt == nil {
+		t.Errorf("Expected last use to be recorded, got %+v (err %v)", stored, err)
+	}
+
+	shared, err := svc.GetSharedSession(ctx, share)
+	if err != nil {
+		t.Fatalf("GetSharedSession failed: %v", err)
+	}
+	if shared.ID != session.ID || !shared.ExpiresAt.Equal(share.ExpiresAt) {
+		t.Errorf("Unexpected shared session %+v", shared)
+	}
+
+	for _, raw := range []string{"", "dsc_" + created.Token[4:], created.Token + "x"} {
+		if _, err := svc.ValidateShare(ctx, raw); !errors.Is(err, ErrShareNotFound) {
+			t.Errorf("Expected ErrShareNotFound for %q, got %v", raw, err)
+		}
+	}
+
+	// Expired links are rejected
+	expired := &model.SessionShare{
+		ProjectID: project.ID,
+		SessionID: session.ID,
+		Prefix:    "dss_expired",
+		TokenHash: hashToken("dss_expired"),
+		CreatedBy: "user-1",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	if err := env.store.CreateSessionShare(ctx, expired); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if _, err := svc.ValidateShare(ctx, "dss_expired"); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("Expected ErrShareNotFound for an expired share, got %v", err)
+	}
+
+	// Revoked links are rejected
+	if _, err := svc.RevokeShare(ctx, "other-project", session.ID, created.ID); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("Expected ErrShareNotFound revoking from another project, got %v", err)
+	}
+	if _, err := svc.RevokeShare(ctx, project.ID, session.ID, created.ID); err != nil {
+		t.Fatalf("RevokeShare failed: %v", err)
+	}
+	if _, err := svc.ValidateShare(ctx, created.Token); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("Expected ErrShareNotFound after revoking, got %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// API token limits.
+const (
+	DefaultTokenLifetimeDays = 90
+	MaxTokenLifetimeDays     = 365
+
+	// apiTokenPrefix marks discobot tokens so they are recognizable in logs
+	// and secret scanners.
+	apiTokenPrefix = "dsc_"
+
+	// tokenLastUsedResolution limits how often last-used timestamps are
+	// written for a busy token.
+	tokenLastUsedResolution = time.Minute
+)
+
+// ErrInvalidTokenRequest is returned when a token or service account request
+// fails validation.
+var ErrInvalidTokenRequest = errors.New("invalid token request")
+
+// validTokenScopes lists the scopes a token may be created with.
+var validTokenScopes = []string{model.TokenScopeRead, model.TokenScopeChat, model.TokenScopeAdmin}
+
+// TokenService manages personal access tokens and service accounts.
+type TokenService struct {
+	store *store.Store
+}
+
+// NewTokenService creates a new token service.
+func NewTokenService(s *store.Store) *TokenService {
+	return &TokenService{store: s}
+}
+
+// APIToken represents an API token (for API responses). Token is only set
+// in the response that creates it.
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Token      string     `json:"token,omitempty"`
+}
+
+// CreateTokenRequest contains the parameters for creating an API token.
+type CreateTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays,omitempty"` // Defaults to DefaultTokenLifetimeDays
+}
+
+// ServiceAccount represents a service account (for API responses).
+type ServiceAccount struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"projectId"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateServiceAccountRequest contains the parameters for creating a service account.
+type CreateServiceAccountRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role,omitempty"` // Any role except "owner"; defaults to "member"
+}
+
+// TokenAuth is the result of authenticating a request with an API token.
+type TokenAuth struct {
+	TokenID string
+	User    *User
+	Scopes  []string
+}
+
+// --- Personal access tokens ---
+
+// CreateToken creates a token for a user. The plaintext token is returned
+// once and only its hash is stored.
+func (s *TokenService) CreateToken(ctx context.Context, userID string, req CreateTokenRequest) (*APIToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTokenRequest)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidTokenRequest)
+	}
+	scopes := dedupe(req.Scopes)
+	for _, scope := range scopes {
+		if !slices.Contains(validTokenScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q (valid scopes: %s)", ErrInvalidTokenRequest, scope, strings.Join(validTokenScopes, ", "))
+		}
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = DefaultTokenLifetimeDays
+	}
+	if days < 0 || days > MaxTokenLifetimeDays {
+		return nil, fmt.Errorf("%w: expiresInDays must be between 1 and %d", ErrInvalidTokenRequest, MaxTokenLifetimeDays)
+	}
+	expiresAt := time.Now().AddDate(0, 0, days)
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	raw := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	token := &model.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(apiTokenPrefix)+8],
+		TokenHash: hashToken(raw),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: &expiresAt,
+	}
+	if err := s.store.CreateAPIToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	result := toAPIToken(token)
+	result.Token = raw
+	return result, nil
+}
+
+// ListTokens returns a user's tokens.
+func (s *TokenService) ListTokens(ctx context.Context, userID string) ([]*APIToken, error) {
+	tokens, err := s.store.ListAPITokensByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*APIToken, len(tokens))
+	for i, token := range tokens {
+		result[i] = toAPIToken(token)
+	}
+	return result, nil
+}
+
+// RevokeToken deletes one of a user's tokens.
+func (s *TokenService) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	token, err := s.store.GetAPITokenByID(ctx, tokenID)
+	if err != nil {
+		return fmt.Errorf("token not found: %w", err)
+	}
+	if token.UserID != userID {
+		return fmt.Errorf("token does not belong to this user")
+	}
+	return s.store.DeleteAPIToken(ctx, tokenID)
+}
+
+// ValidateToken authenticates a plaintext token and records its use.
+func (s *TokenService) ValidateToken(ctx context.Context, raw string) (*TokenAuth, error) {
+	if !strings.HasPrefix(raw, apiTokenPrefix) {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	token, err := s.store.GetAPITokenByHash(ctx, hashToken(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(now) {
+		return nil, fmt.Errorf("token expired")
+	}
+
+	user := token.User
+	if user == nil {
+		user, err = s.store.GetUserByID(ctx, token.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenLastUsedResolution {
+		if err := s.store.TouchAPIToken(ctx, token.ID, now); err != nil {
+			authLog.WarnContext(ctx, "failed to record use of API token", "token_id", token.ID, "error", err)
+		}
+	}
+
+	return &TokenAuth{
+		TokenID: token.ID,
+		User: &User{
+			ID:        user.ID,
+			Email:     user.Email,
+			Name:      ptrToString(user.Name),
+			AvatarURL: ptrToString(user.AvatarURL),
+			Provider:  user.Provider,
+		},
+		Scopes: token.ScopeList(),
+	}, nil
+}
+
+// RequiredTokenScope returns the scope an API token needs to call a route
+// that requires permission. Viewing needs "read"; creating sessions and
+// chatting need "chat"; every other permission, such as editing files,
+// committing or opening a terminal, needs "admin". Routes without a
+// permission need "read" for reads and "admin" otherwise.
+func RequiredTokenScope(method, permission string) string {
+	switch permission {
+	case model.PermissionProjectView, model.PermissionAuditView:
+		return model.TokenScopeRead
+	case model.PermissionSessionCreate, model.PermissionSessionChat:
+		return model.TokenScopeChat
+	case "":
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return model.TokenScopeRead
+		}
+	}
+	return model.TokenScopeAdmin
+}
+
+// TokenScopesAllow reports whether any of scopes grants the required scope.
+// Chat implies read, and admin implies everything.
+func TokenScopesAllow(scopes []string, required string) bool {
+	for _, scope := range scopes {
+		switch {
+		case scope == model.TokenScopeAdmin:
+			return true
+		case scope == required:
+			return true
+		case scope == model.TokenScopeChat && required == model.TokenScopeRead:
+			return true
+		}
+	}
+	return false
+}
+
+// --- Service accounts ---
+
+// CreateServiceAccount creates a service account with the given project role.
+func (s *TokenService) CreateServiceAccount(ctx context.Context, projectID, createdBy string, req CreateServiceAccountRequest) (*ServiceAccount, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTokenRequest)
+	}
+	role := req.Role
+	if role == "" {
+		role = model.RoleMember
+	}
+	// Ownership stays with humans
+	if !model.IsAssignableRole(role) {
+		return nil, fmt.Errorf("%w: role must be one of admin, developer, member or viewer", ErrInvalidTokenRequest)
+	}
+
+	account := &model.ServiceAccount{
+		ProjectID: projectID,
+		Name:      name,
+		CreatedBy: strPtr(createdBy),
+	}
+	// The backing user's identity is derived from a fresh ID so it can never
+	// collide with an OAuth login.
+	providerID := uuid.New().String()
+	user := &model.User{
+		Email:      fmt.Sprintf("%s@service-accounts.discobot.local", providerID),
+		Name:       &name,
+		Provider:   model.ServiceAccountProvider,
+		ProviderID: providerID,
+	}
+	now := time.Now()
+	member := &model.ProjectMember{
+		ProjectID:  projectID,
+		Role:       role,
+		InvitedBy:  strPtr(createdBy),
+		InvitedAt:  &now,
+		AcceptedAt: &now,
+	}
+	if err := s.store.CreateServiceAccount(ctx, account, user, member); err != nil {
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	return toServiceAccount(account, role), nil
+}
+
+// ListServiceAccounts returns the service accounts of a project.
+func (s *TokenService) ListServiceAccounts(ctx context.Context, projectID string) ([]*ServiceAccount, error) {
+	accounts, err := s.store.ListServiceAccountsByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ServiceAccount, 0, len(accounts))
+	for _, account := range accounts {
+		role := ""
+		if member, err := s.store.GetProjectMember(ctx, projectID, account.UserID); err == nil {
+			role = member.Role
+		}
+		result = append(result, toServiceAccount(account, role))
+	}
+	return result, nil
+}
+
+// DeleteServiceAccount deletes a service account and revokes its tokens.
+func (s *TokenService) DeleteServiceAccount(ctx context.Context, projectID, accountID string) error {
+	if _, err := s.getServiceAccount(ctx, projectID, accountID); err != nil {
+		return err
+	}
+	return s.store.DeleteServiceAccount(ctx, accountID)
+}
+
+// CreateServiceAccountToken creates a token for a service account.
+func (s *TokenService) CreateServiceAccountToken(ctx context.Context, projectID, accountID string, req CreateTokenRequest) (*APIToken, error) {
+	account, err := s.getServiceAccount(ctx, projectID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateToken(ctx, account.UserID, req)
+}
+
+// ListServiceAccountTokens returns a service account's tokens.
+func (s *TokenService) ListServiceAccountTokens(ctx context.Context, projectID, accountID string) ([]*APIToken, error) {
+	account, err := s.getServiceAccount(ctx, projectID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListTokens(ctx, account.UserID)
+}
+
+// RevokeServiceAccountToken deletes one of a service account's tokens.
+func (s *TokenService) RevokeServiceAccountToken(ctx context.Context, projectID, accountID, tokenID string) error {
+	account, err := s.getServiceAccount(ctx, projectID, accountID)
+	if err != nil {
+		return err
+	}
+	return s.RevokeToken(ctx, account.UserID, tokenID)
+}
+
+func (s *TokenService) getServiceAccount(ctx context.Context, projectID, accountID string) (*model.ServiceAccount, error) {
+	account, err := s.store.GetServiceAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("service account not found: %w", err)
+	}
+	if account.ProjectID != projectID {
+		return nil, fmt.Errorf("service account does not belong to this project")
+	}
+	return account, nil
+}
+
+func hashToken(raw string) string {
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
+
+func toAPIToken(token *model.APIToken) *APIToken {
+	return &APIToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func toServiceAccount(account *model.ServiceAccount, role string) *ServiceAccount {
+	return &ServiceAccount{
+		ID:        account.ID,
+		ProjectID: account.ProjectID,
+		UserID:    account.UserID,
+		Name:      account.Name,
+		Role:      role,
+		CreatedBy: ptrToString(account.CreatedBy),
+		CreatedAt: account.CreatedAt,
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+)
+
+func createTestUser(t *testing.T, env *testEnv) *model.User {
+	t.Helper()
+	user := &model.User{Email: "dev@example.com", Provider: "github", ProviderID: "42"}
+	if err := env.store.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func TestCreateToken(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	user := createTestUser(t, env)
+	svc := NewTokenService(env.store)
+	ctx := context.Background()
+
+	token, err := svc.CreateToken(ctx, user.ID, CreateTokenRequest{Name: "CI", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if !strings.HasPrefix(token.Token, apiTokenPrefix) || !strings.HasPrefix(token.Token, token.Prefix) {
+		t.Errorf("Unexpected token %q with prefix %q", token.Token, token.Prefix)
+	}
+	if token.ExpiresAt == nil {
+		t.Error("Expected token to expire")
+	}
+
+	// The plaintext token is never stored or listed
+	stored, err := env.store.GetAPITokenByID(ctx, token.ID)
+	if err != nil {
+		t.Fatalf("Failed to get token: %v", err)
+	}
+	if stored.TokenHash == token.Token || stored.TokenHash != hashToken(token.Token) {
+		t.Error("Expected only the token hash to be stored")
+	}
+	tokens, err := svc.ListTokens(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("ListTokens failed: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].Token != "" {
+		t.Errorf("Expected one listed token without plaintext, got %+v", tokens)
+	}
+
+	auth, err := svc.ValidateToken(ctx, token.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if auth.User.ID != user.ID || len(auth.Scopes) != 1 || auth.Scopes[0] != model.TokenScopeRead {
+		t.Errorf("Unexpected auth result: %+v", auth)
+	}
+	stored, _ = env.store.GetAPITokenByID(ctx, token.ID)
+	if stored.LastUsedAt == nil {
+		t.Error("Expected last used time to be recorded")
+	}
+
+	if err := svc.RevokeToken(ctx, "someone-else", token.ID); err == nil {
+		t.Error("Expected revoking another user's token to fail")
+	}
+	if err := svc.RevokeToken(ctx, user.ID, token.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := svc.ValidateToken(ctx, token.Token); err == nil {
+		t.Error("Expected revoked token to be rejected")
+	}
+}
+
+func TestCreateToken_Validation(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	user := createTestUser(t, env)
+	svc := NewTokenService(env.store)
+
+	tests := []struct {
+		name string
+		req  CreateTokenRequest
+	}{
+		{"missing name", CreateTokenRequest{Scopes: []string{"read"}}},
+		{"missing scopes", CreateTokenRequest{Name: "t"}},
+		{"unknown scope", CreateTokenRequest{Name: "t", Scopes: []string{"write"}}},
+		{"lifetime too long", CreateTokenRequest{Name: "t", Scopes: []string{"read"}, ExpiresInDays: MaxTokenLifetimeDays + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CreateToken(context.Background(), user.ID, tt.req); !errors.Is(err, ErrInvalidTokenRequest) {
+				t.Errorf("Expected ErrInvalidTokenRequest, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateToken_Expired(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	user := createTestUser(t, env)
+	svc := NewTokenService(env.store)
+	ctx := context.Background()
+
+	token, err := svc.CreateToken(ctx, user.ID, CreateTokenRequest{Name: "t", Scopes: []string{"admin"}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if err := env.store.DB().Model(&model.APIToken{}).Where("id = ?", token.ID).
+		Update("expires_at", token.CreatedAt.AddDate(0, 0, -1)).Error; err != nil {
+		t.Fatalf("Failed to expire token: %v", err)
+	}
+
+	if _, err := svc.ValidateToken(ctx, token.Token); err == nil {
+		t.Error("Expected expired token to be rejected")
+	}
+}
+
+func TestTokenScopesAllow(t *testing.T) {
+	tests := []struct {
+		scopes   []string
+		required string
+		want     bool
+	}{
+		{[]string{"read"}, model.TokenScopeRead, true},
+		{[]string{"read"}, model.TokenScopeChat, false},
+		{[]string{"chat"}, model.TokenScopeRead, true},
+		{[]string{"chat"}, model.TokenScopeChat, true},
+		{[]string{"chat"}, model.TokenScopeAdmin, false},
+		{[]string{"read", "chat"}, model.TokenScopeChat, true},
+		{[]string{"admin"}, model.TokenScopeChat, true},
+		{nil, model.TokenScopeRead, false},
+	}
+	for _, tt := range tests {
+		if got := TokenScopesAllow(tt.scopes, tt.required); got != tt.want {
+			t.Errorf("TokenScopesAllow(%v, %q) = %v, want %v", tt.scopes, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestServiceAccount(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	svc := NewTokenService(env.store)
+	ctx := context.Background()
+
+	if _, err := svc.CreateServiceAccount(ctx, project.ID, "owner", CreateServiceAccountRequest{Name: "bot", Role: "owner"}); !errors.Is(err, ErrInvalidTokenRequest) {
+		t.Errorf("Expected owner role to be rejected, got %v", err)
+	}
+
+	account, err := svc.CreateServiceAccount(ctx, project.ID, "owner", CreateServiceAccountRequest{Name: "ci-bot"})
+	if err != nil {
+		t.Fatalf("CreateServiceAccount failed: %v", err)
+	}
+	if account.Role != "member" {
+		t.Errorf("Expected default member role, got %q", account.Role)
+	}
+
+	// The service account is an ordinary project member
+	member, err := env.store.GetProjectMember(ctx, project.ID, account.UserID)
+	if err != nil {
+		t.Fatalf("Expected project membership: %v", err)
+	}
+	if member.Role != "member" {
+		t.Errorf("Unexpected member role %q", member.Role)
+	}
+
+	token, err := svc.CreateServiceAccountToken(ctx, project.ID, account.ID, CreateTokenRequest{Name: "deploy", Scopes: []string{"chat"}})
+	if err != nil {
+		t.Fatalf("CreateServiceAccountToken failed: %v", err)
+	}
+	auth, err := svc.ValidateToken(ctx, token.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if auth.User.ID != account.UserID || auth.User.Provider != model.ServiceAccountProvider {
+		t.Errorf("Expected token to authenticate as the service account, got %+v", auth.User)
+	}
+
+	if _, err := svc.ListServiceAccountTokens(ctx, "other-project", account.ID); err == nil {
+		t.Error("Expected access from another project to fail")
+	}
+
+	accounts, err := svc.ListServiceAccounts(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("ListServiceAccounts failed: %v", err)
+	}
+	if len(accounts) != 1 || accounts[0].Role != "member" {
+		t.Errorf("Unexpected service accounts: %+v", accounts)
+	}
+
+	if err := svc.DeleteServiceAccount(ctx, project.ID, account.ID); err != nil {
+		t.Fatalf("DeleteServiceAccount failed: %v", err)
+	}
+	if _, err := svc.ValidateToken(ctx, token.Token); err == nil {
+		t.Error("Expected token of deleted service account to be rejected")
+	}
+	if _, err := env.store.GetProjectMember(ctx, project.ID, account.UserID); err == nil {
+		t.Error("Expected project membership to be removed")
+	}
+}
@@ -3,19 +3,30 @@ package service
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/obot-platform/discobot/server/internal/devcontainer"
 	"github.com/obot-platform/discobot/server/internal/events"
 	"github.com/obot-platform/discobot/server/internal/git"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
 	"github.com/obot-platform/discobot/server/internal/store"
 )
 
+// workspaceLog is the logger for workspace setup messages.
+var workspaceLog = logging.Component("workspace")
+
+// withWorkspace returns ctx with the workspace ID added to its log attributes.
+func withWorkspace(ctx context.Context, workspaceID string) context.Context {
+	return logging.With(ctx, "workspace_id", workspaceID)
+}
+
 // expandPath expands ~ to the user's home directory and cleans the path.
 // For paths starting with ~, it replaces ~ with $HOME.
 // All paths are cleaned using filepath.Clean to remove redundant elements.
@@ -32,22 +43,26 @@ func expandPath(path string) (string, error) {
 
 // Workspace represents a workspace with its sessions (for API responses)
 type Workspace struct {
-	ID           string     `json:"id"`
-	Path         string     `json:"path"`
-	DisplayName  *string    `json:"displayName,omitempty"`
-	SourceType   string     `json:"sourceType"`
-	Provider     string     `json:"provider,omitempty"`
-	Status       string     `json:"status"`
-	ErrorMessage string     `json:"errorMessage,omitempty"`
-	WorkDir      string     `json:"workDir,omitempty"`
-	Sessions     []*Session `json:"sessions"`
+	ID                string     `json:"id"`
+	Path              string     `json:"path"`
+	DisplayName       *string    `json:"displayName,omitempty"`
+	SourceType        string     `json:"sourceType"`
+	Provider          string     `json:"provider,omitempty"`
+	Status            string     `json:"status"`
+	ErrorMessage      string     `json:"errorMessage,omitempty"`
+	WorkDir           string     `json:"workDir,omitempty"`
+	SandboxImage      string     `json:"sandboxImage,omitempty"`      // Image built from devcontainer.json
+	DevcontainerError string     `json:"devcontainerError,omitempty"` // Why devcontainer.json was not (fully) applied
+	Sessions          []*Session `json:"sessions"`
 }
 
 // WorkspaceService handles workspace operations
 type WorkspaceService struct {
-	store       *store.Store
-	gitProvider git.Provider
-	eventBroker *events.Broker
+	store          *store.Store
+	gitProvider    git.Provider
+	eventBroker    *events.Broker
+	sandboxManager *sandbox.Manager
+	featureFetcher *devcontainer.FeatureFetcher
 }
 
 // NewWorkspaceService creates a new workspace service
@@ -59,6 +74,13 @@ func NewWorkspaceService(s *store.Store, gitProvider git.Provider, eventBroker *
 	}
 }
 
+// SetSandboxManager sets the sandbox manager used to build workspace images
+// from devcontainer.json during Initialize. Without one, devcontainer images
+// are not built and sessions use the default sandbox image.
+func (s *WorkspaceService) SetSandboxManager(m *sandbox.Manager) {
+	s.sandboxManager = m
+}
+
 // ListWorkspaces returns all workspaces for a project
 func (s *WorkspaceService) ListWorkspaces(ctx context.Context, projectID string) ([]*Workspace, error) {
 	dbWorkspaces, err := s.store.ListWorkspacesByProject(ctx, projectID)
@@ -170,7 +192,7 @@ func (s *WorkspaceService) CreateWorkspace(ctx context.Context, projectID, path,
 				return nil, fmt.Errorf("failed to create initial commit: %v: %s", err, commitStderr.String())
 			}
 
-			log.Printf("Initialized new git repository: %s", path)
+			workspaceLog.InfoContext(ctx, "initialized new git repository", "path", path)
 		} else {
 			// Directory exists and is non-empty — require a .git folder
 			gitDir := filepath.Join(path, ".git")
@@ -235,6 +257,12 @@ func (s *WorkspaceService) mapWorkspace(ctx context.Context, ws *model.Workspace
 	if ws.ErrorMessage != nil {
 		result.ErrorMessage = *ws.ErrorMessage
 	}
+	if ws.SandboxImage != nil {
+		result.SandboxImage = *ws.SandboxImage
+	}
+	if ws.DevcontainerError != nil {
+		result.DevcontainerError = *ws.DevcontainerError
+	}
 	// Get working directory path from git provider if available
 	if s.gitProvider != nil {
 		result.WorkDir = s.gitProvider.GetWorkDir(ctx, ws.ID)
@@ -248,7 +276,7 @@ func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, workspaceID stri
 	// Delete files first if requested (before removing DB record)
 	if deleteFiles && s.gitProvider != nil {
 		if err := s.gitProvider.RemoveWorkspace(ctx, workspaceID); err != nil {
-			log.Printf("Warning: failed to remove workspace files for %s: %v", workspaceID, err)
+			workspaceLog.WarnContext(withWorkspace(ctx, workspaceID), "failed to remove workspace files", "error", err)
 			// Continue with DB deletion even if file deletion fails
 		}
 	}
@@ -282,6 +310,8 @@ func (s *WorkspaceService) Initialize(ctx context.Context, workspaceID string) e
 		return fmt.Errorf("git provider not configured")
 	}
 
+	ctx = withWorkspace(ctx, workspaceID)
+
 	// Get workspace
 	ws, err := s.store.GetWorkspaceByID(ctx, workspaceID)
 	if err != nil {
@@ -294,50 +324,135 @@ func (s *WorkspaceService) Initialize(ctx context.Context, workspaceID string) e
 	}
 
 	// Initialize the workspace (clone/setup git repo)
-	_, commit, err := s.gitProvider.EnsureWorkspace(ctx, ws.ProjectID, workspaceID, ws.Path, "")
+	workDir, commit, err := s.gitProvider.EnsureWorkspace(ctx, ws.ProjectID, workspaceID, ws.Path, "")
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to initialize workspace: %v", err)
 		s.updateStatusWithEvent(ctx, ws.ProjectID, workspaceID, model.WorkspaceStatusError, &errMsg)
 		return fmt.Errorf("workspace initialization failed: %w", err)
 	}
 
+	// Apply devcontainer.json (failures are recorded on the workspace, not fatal)
+	s.applyDevcontainer(ctx, ws, workDir)
+
 	// Update workspace to ready status
 	ws.Status = model.WorkspaceStatusReady
 	ws.ErrorMessage = nil
 	if err := s.store.UpdateWorkspace(ctx, ws); err != nil {
-		log.Printf("Failed to update workspace %s: %v", workspaceID, err)
+		workspaceLog.ErrorContext(ctx, "failed to update workspace", "error", err)
 	}
 
 	// Emit success event
 	if s.eventBroker != nil {
 		if err := s.eventBroker.PublishWorkspaceUpdated(ctx, ws.ProjectID, workspaceID, model.WorkspaceStatusReady); err != nil {
-			log.Printf("Failed to publish workspace update event: %v", err)
+			workspaceLog.WarnContext(ctx, "failed to publish workspace update event", "error", err)
 		}
 	}
 
-	log.Printf("Workspace %s initialized successfully (commit: %s)", workspaceID, commit)
+	workspaceLog.InfoContext(ctx, "workspace initialized", "commit", commit)
 	return nil
 }
 
+// applyDevcontainer reads devcontainer.json from the workspace and records the
+// resulting sandbox image and devcontainer spec on ws. If the config is invalid
+// or the image cannot be built, the error is recorded in DevcontainerError and
+// sessions fall back to the default sandbox image.
+func (s *WorkspaceService) applyDevcontainer(ctx context.Context, ws *model.Workspace, workDir string) {
+	ws.SandboxImage = nil
+	ws.Devcontainer = nil
+	ws.DevcontainerError = nil
+
+	fail := func(err error) {
+		errMsg := err.Error()
+		ws.DevcontainerError = &errMsg
+		workspaceLog.WarnContext(withWorkspace(ctx, ws.ID), "devcontainer.json not applied", "error", err)
+	}
+
+	cfg, err := devcontainer.Load(workDir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if cfg == nil {
+		return
+	}
+
+	spec, err := cfg.Spec()
+	if err != nil {
+		fail(err)
+		return
+	}
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		fail(err)
+		return
+	}
+	ws.Devcontainer = specJSON
+
+	image, err := s.buildDevcontainerImage(ctx, ws, cfg)
+	if err != nil {
+		fail(fmt.Errorf("failed to build devcontainer image, using the default sandbox image: %w", err))
+		return
+	}
+	ws.SandboxImage = &image
+}
+
+// buildDevcontainerImage builds the sandbox image for a devcontainer config
+// with the workspace's sandbox provider, reusing an existing image when the
+// config is unchanged.
+func (s *WorkspaceService) buildDevcontainerImage(ctx context.Context, ws *model.Workspace, cfg *devcontainer.Config) (string, error) {
+	if s.sandboxManager == nil {
+		return "", fmt.Errorf("no sandbox provider available")
+	}
+	provider, err := s.sandboxManager.GetProvider(ws.Provider)
+	if err != nil {
+		return "", err
+	}
+	builder, ok := provider.(sandbox.ImageBuilder)
+	if !ok {
+		return "", fmt.Errorf("sandbox provider does not support building images")
+	}
+
+	build, err := cfg.PrepareBuild(ctx, s.featureFetcher, provider.Image())
+	if err != nil {
+		return "", err
+	}
+	if builder.HasImage(ctx, ws.ProjectID, build.Tag) {
+		return build.Tag, nil
+	}
+
+	workspaceLog.InfoContext(withWorkspace(ctx, ws.ID), "building devcontainer image", "tag", build.Tag)
+	err = builder.BuildImage(ctx, ws.ProjectID, sandbox.ImageBuildRequest{
+		Tag:     build.Tag,
+		Context: bytes.NewReader(build.Context),
+		Args:    build.Args,
+	})
+	if err != nil {
+		return "", err
+	}
+	return build.Tag, nil
+}
+
 // updateStatusWithEvent updates workspace status and emits an SSE event.
 func (s *WorkspaceService) updateStatusWithEvent(ctx context.Context, projectID, workspaceID, status string, errorMsg *string) {
+	ctx = withWorkspace(ctx, workspaceID)
+
 	// Update workspace in database
 	ws, err := s.store.GetWorkspaceByID(ctx, workspaceID)
 	if err != nil {
-		log.Printf("Failed to get workspace %s for status update: %v", workspaceID, err)
+		workspaceLog.ErrorContext(ctx, "failed to get workspace for status update", "error", err)
 		return
 	}
 
 	ws.Status = status
 	ws.ErrorMessage = errorMsg
 	if err := s.store.UpdateWorkspace(ctx, ws); err != nil {
-		log.Printf("Failed to update workspace %s status to %s: %v", workspaceID, status, err)
+		workspaceLog.ErrorContext(ctx, "failed to update workspace status", "status", status, "error", err)
 	}
 
 	// Emit SSE event
 	if s.eventBroker != nil {
 		if err := s.eventBroker.PublishWorkspaceUpdated(ctx, projectID, workspaceID, status); err != nil {
-			log.Printf("Failed to publish workspace update event: %v", err)
+			workspaceLog.WarnContext(ctx, "failed to publish workspace update event", "error", err)
 		}
 	}
 }
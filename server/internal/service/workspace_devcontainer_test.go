@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/discobot/server/internal/devcontainer"
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/sandbox"
+	"github.com/obot-platform/discobot/server/internal/sandbox/mock"
+)
+
+// imageBuilderProvider is a mock provider that also implements sandbox.ImageBuilder.
+type imageBuilderProvider struct {
+	*mock.Provider
+	built []string
+}
+
+func (p *imageBuilderProvider) HasImage(_ context.Context, _, ref string) bool {
+	for _, tag := range p.built {
+		if tag == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *imageBuilderProvider) BuildImage(_ context.Context, _ string, req sandbox.ImageBuildRequest) error {
+	p.built = append(p.built, req.Tag)
+	return nil
+}
+
+func TestApplyDevcontainer(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, ".devcontainer.json"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	builder := &imageBuilderProvider{Provider: mock.NewProvider()}
+	manager := sandbox.NewManager()
+	manager.RegisterProvider("mock", builder)
+	svc := NewWorkspaceService(nil, nil, nil)
+	svc.SetSandboxManager(manager)
+
+	// No devcontainer.json: nothing recorded
+	ws := &model.Workspace{ID: "ws-1", ProjectID: "proj-1", Provider: "mock"}
+	svc.applyDevcontainer(ctx, ws, dir)
+	if ws.SandboxImage != nil || ws.Devcontainer != nil || ws.DevcontainerError != nil {
+		t.Fatalf("expected no devcontainer settings, got %+v", ws)
+	}
+
+	// Valid config: image built once, spec recorded
+	writeConfig(`{"image": "ubuntu", "postCreateCommand": "make setup", "forwardPorts": [3000]}`)
+	svc.applyDevcontainer(ctx, ws, dir)
+	if ws.DevcontainerError != nil {
+		t.Fatalf("unexpected error: %s", *ws.DevcontainerError)
+	}
+	if ws.SandboxImage == nil || !strings.HasPrefix(*ws.SandboxImage, devcontainer.ImageRepository+":") {
+		t.Fatalf("SandboxImage = %v", ws.SandboxImage)
+	}
+	var spec devcontainer.Spec
+	if err := json.Unmarshal(ws.Devcontainer, &spec); err != nil {
+		t.Fatalf("invalid spec JSON: %v", err)
+	}
+	if len(spec.PostCreate) != 1 || spec.PostCreate[0].Script != "make setup" || len(spec.ForwardPorts) != 1 {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+	svc.applyDevcontainer(ctx, ws, dir)
+	if len(builder.built) != 1 {
+		t.Errorf("expected the unchanged config to reuse the image, built %v", builder.built)
+	}
+
+	// Provider without image builds: spec kept, default image used
+	manager.RegisterProvider("plain", mock.NewProvider())
+	ws.Provider = "plain"
+	svc.applyDevcontainer(ctx, ws, dir)
+	if ws.SandboxImage != nil || ws.Devcontainer == nil || ws.DevcontainerError == nil {
+		t.Errorf("expected spec with a build error, got image=%v error=%v", ws.SandboxImage, ws.DevcontainerError)
+	}
+
+	// Invalid config: error recorded, previous settings cleared
+	ws.Provider = "mock"
+	writeConfig(`{"remoteUser": "vscode"}`)
+	svc.applyDevcontainer(ctx, ws, dir)
+	if ws.DevcontainerError == nil || ws.SandboxImage != nil || ws.Devcontainer != nil {
+		t.Errorf("expected only an error, got %+v", ws)
+	}
+}
+
+func TestApplyWorkspaceSandboxOptions(t *testing.T) {
+	opts := sandbox.CreateOptions{}
+	applyWorkspaceSandboxOptions(&opts, &model.Workspace{})
+	if opts.Image != "" || opts.Env != nil {
+		t.Errorf("expected no overrides without a devcontainer, got %+v", opts)
+	}
+
+	image := "discobot-devcontainer:abc"
+	applyWorkspaceSandboxOptions(&opts, &model.Workspace{
+		SandboxImage: &image,
+		Devcontainer: []byte(`{"remoteUser":"root"}`),
+	})
+	if opts.Image != image {
+		t.Errorf("Image = %q, want %q", opts.Image, image)
+	}
+	if got := opts.Env[devcontainer.EnvVar]; got != "eyJyZW1vdGVVc2VyIjoicm9vdCJ9" {
+		t.Errorf("%s = %q", devcontainer.EnvVar, got)
+	}
+}
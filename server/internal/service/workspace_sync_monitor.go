@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/model"
+	"github.com/obot-platform/discobot/server/internal/store"
+)
+
+// WorkspaceSyncMonitor periodically fetches workspace remotes and records how far
+// each active session's base commit is behind the workspace's tracked branch.
+type WorkspaceSyncMonitor struct {
+	store         *store.Store
+	gitSvc        *GitService
+	sessionSvc    *SessionService
+	logger        *slog.Logger
+	checkInterval time.Duration
+
+	mu           sync.Mutex
+	running      bool
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+	shutdownOnce sync.Once
+}
+
+// NewWorkspaceSyncMonitor creates a new workspace sync monitor.
+func NewWorkspaceSyncMonitor(
+	store *store.Store,
+	gitSvc *GitService,
+	sessionSvc *SessionService,
+	logger *slog.Logger,
+	checkInterval time.Duration,
+) *WorkspaceSyncMonitor {
+	return &WorkspaceSyncMonitor{
+		store:         store,
+		gitSvc:        gitSvc,
+		sessionSvc:    sessionSvc,
+		logger:        logger.With("component", "workspace_sync_monitor"),
+		checkInterval: checkInterval,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop.
+func (m *WorkspaceSyncMonitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go m.monitorLoop(ctx)
+
+	m.logger.Info("workspace sync monitor started", "check_interval", m.checkInterval)
+}
+
+// Shutdown gracefully stops the sync monitor.
+func (m *WorkspaceSyncMonitor) Shutdown(ctx context.Context) error {
+	var err error
+	m.shutdownOnce.Do(func() {
+		m.logger.Info("shutting down workspace sync monitor")
+		close(m.stopChan)
+
+		done := make(chan struct{})
+		go func() {
+			m.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			m.logger.Info("workspace sync monitor shutdown complete")
+		case <-ctx.Done():
+			err = fmt.Errorf("shutdown timeout exceeded")
+			m.logger.Error("workspace sync monitor shutdown timeout")
+		}
+	})
+	return err
+}
+
+// monitorLoop is the main loop that periodically syncs workspaces.
+func (m *WorkspaceSyncMonitor) monitorLoop(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("monitor loop stopped: context cancelled")
+			return
+		case <-m.stopChan:
+			m.logger.Info("monitor loop stopped: shutdown signal")
+			return
+		case <-ticker.C:
+			if err := m.syncWorkspaces(ctx); err != nil {
+				m.logger.Error("error syncing workspaces", "error", err)
+			}
+		}
+	}
+}
+
+// syncWorkspaces fetches every workspace that has active sessions and refreshes
+// the upstream status of those sessions.
+func (m *WorkspaceSyncMonitor) syncWorkspaces(ctx context.Context) error {
+	statuses := []string{model.SessionStatusReady, model.SessionStatusRunning, model.SessionStatusStopped}
+	sessions, err := m.store.ListSessionsByStatuses(ctx, statuses)
+	if err != nil {
+		return fmt.Errorf("failed to list active sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	// Group sessions by workspace so each workspace is fetched only once
+	var workspaceIDs []string
+	byWorkspace := make(map[string][]*model.Session)
+	for _, session := range sessions {
+		if _, ok := byWorkspace[session.WorkspaceID]; !ok {
+			workspaceIDs = append(workspaceIDs, session.WorkspaceID)
+		}
+		byWorkspace[session.WorkspaceID] = append(byWorkspace[session.WorkspaceID], session)
+	}
+
+	m.logger.Debug("syncing workspaces", "workspaces", len(workspaceIDs), "sessions", len(sessions))
+
+	for _, workspaceID := range workspaceIDs {
+		m.syncWorkspace(ctx, workspaceID, byWorkspace[workspaceID])
+	}
+
+	return nil
+}
+
+// syncWorkspace fetches a single workspace and refreshes its sessions.
+func (m *WorkspaceSyncMonitor) syncWorkspace(ctx context.Context, workspaceID string, sessions []*model.Session) {
+	logger := m.logger.With("workspace_id", workspaceID)
+
+	workspace, err := m.store.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		logger.Warn("failed to get workspace", "error", err)
+		return
+	}
+	if workspace.Status != model.WorkspaceStatusReady {
+		return
+	}
+
+	// A failed fetch still leaves the last known remote refs to compare against
+	if err := m.gitSvc.Fetch(ctx, workspaceID); err != nil {
+		logger.Warn("failed to fetch workspace", "error", err)
+	}
+
+	for _, session := range sessions {
+		if sessionBaseRef(session) == "" {
+			continue
+		}
+		status, err := m.sessionSvc.RefreshUpstream(ctx, session)
+		if err != nil {
+			logger.Warn("failed to refresh session upstream", "session_id", session.ID, "error", err)
+			continue
+		}
+		if status.Behind > 0 {
+			logger.Debug("session is behind upstream",
+				"session_id", session.ID,
+				"upstream", status.Upstream,
+				"behind", status.Behind)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/discobot/server/internal/events"
+	"github.com/obot-platform/discobot/server/internal/jobs"
+)
+
+// TestWorkspaceSyncMonitor_RecordsBehindCount verifies that the monitor records
+// how far a session is behind its workspace and publishes a session_behind event.
+func TestWorkspaceSyncMonitor_RecordsBehindCount(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	ctx := context.Background()
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+
+	sessionSvc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, nil)
+	monitor := NewWorkspaceSyncMonitor(env.store, env.gitService, sessionSvc, slog.Default(), time.Minute)
+
+	// Workspace moves ahead by two commits
+	env.addCommitToWorkspace(t, workspace.Path, "a.txt", "a")
+	latest := env.addCommitToWorkspace(t, workspace.Path, "b.txt", "b")
+
+	if err := monitor.syncWorkspaces(ctx); err != nil {
+		t.Fatalf("syncWorkspaces failed: %v", err)
+	}
+
+	updated, err := env.store.GetSessionByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if updated.BehindCount != 2 {
+		t.Errorf("Expected behind count 2, got %d", updated.BehindCount)
+	}
+	if updated.UpstreamCommit == nil || *updated.UpstreamCommit != latest {
+		t.Errorf("Expected upstream commit %s, got %v", latest, updated.UpstreamCommit)
+	}
+	if !updated.UpdatedAt.Equal(session.UpdatedAt) {
+		t.Errorf("Expected updated_at to be unchanged, got %s (was %s)", updated.UpdatedAt, session.UpdatedAt)
+	}
+
+	evts, err := env.eventBroker.GetEventsSince(ctx, project.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	var behindEvents []events.SessionBehindData
+	for _, e := range evts {
+		if e.Type != events.EventTypeSessionBehind {
+			continue
+		}
+		var data events.SessionBehindData
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			t.Fatalf("failed to unmarshal event data: %v", err)
+		}
+		behindEvents = append(behindEvents, data)
+	}
+	if len(behindEvents) != 1 {
+		t.Fatalf("Expected 1 session_behind event, got %d", len(behindEvents))
+	}
+	if behindEvents[0].SessionID != session.ID || behindEvents[0].Behind != 2 {
+		t.Errorf("Unexpected event data: %+v", behindEvents[0])
+	}
+
+	// A second pass with no upstream changes should not publish again
+	if err := monitor.syncWorkspaces(ctx); err != nil {
+		t.Fatalf("syncWorkspaces failed: %v", err)
+	}
+	evts, _ = env.eventBroker.GetEventsSince(ctx, project.ID, time.Time{})
+	count := 0
+	for _, e := range evts {
+		if e.Type == events.EventTypeSessionBehind {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected no additional session_behind events, got %d total", count)
+	}
+}
+
+// TestRebaseSession_EnqueuesJob tests that RebaseSession enqueues a rebase job.
+func TestRebaseSession_EnqueuesJob(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	project := env.createTestProject(t)
+	agent := env.createTestAgent(t, project.ID)
+	workspace, initialCommit := env.createTestWorkspace(t, project.ID)
+	session := env.createTestSession(t, project.ID, workspace.ID, agent.ID, initialCommit)
+
+	var enqueued []string
+	mockEnqueuer := &mockJobEnqueuer{
+		enqueueFunc: func(_ context.Context, payload jobs.JobPayload) error {
+			enqueued = append(enqueued, string(payload.JobType()))
+			return nil
+		},
+	}
+
+	sessionSvc := NewSessionService(env.store, env.gitService, env.mockSandbox, nil, env.eventBroker, mockEnqueuer)
+	if err := sessionSvc.RebaseSession(context.Background(), project.ID, session.ID, mockEnqueuer); err != nil {
+		t.Fatalf("RebaseSession failed: %v", err)
+	}
+	if len(enqueued) != 1 || enqueued[0] != string(jobs.JobTypeSessionRebase) {
+		t.Errorf("Expected a session_rebase job, got %v", enqueued)
+	}
+
+	if err := sessionSvc.RebaseSession(context.Background(), "other-project", session.ID, mockEnqueuer); err == nil {
+		t.Error("Expected error for session in another project")
+	}
+}
@@ -4,6 +4,7 @@
 package ssh
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
@@ -12,15 +13,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"sync"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 
+	"github.com/obot-platform/discobot/agent/tunnel"
+	"github.com/obot-platform/discobot/server/internal/logging"
 	"github.com/obot-platform/discobot/server/internal/sandbox"
 )
 
@@ -47,6 +51,15 @@ type Config struct {
 	// UserInfoFetcher is used to get the default user for sandbox sessions.
 	// If nil, commands run as root.
 	UserInfoFetcher UserInfoFetcher
+
+	// ConnectionRecorder, if set, is notified of every accepted connection
+	// so it can be audited.
+	ConnectionRecorder ConnectionRecorder
+}
+
+// ConnectionRecorder records accepted SSH connections.
+type ConnectionRecorder interface {
+	RecordConnection(ctx context.Context, sessionID, remoteAddr string)
 }
 
 // Server is an SSH server that routes connections to sandbox containers.
@@ -54,8 +67,10 @@ type Server struct {
 	config          *ssh.ServerConfig
 	provider        sandbox.Provider
 	userInfoFetcher UserInfoFetcher
+	recorder        ConnectionRecorder
 	listener        net.Listener
 	addr            string
+	logger          *slog.Logger
 
 	mu       sync.Mutex
 	sessions map[string]*sessionHandler // sessionID -> handler
@@ -75,6 +90,7 @@ func New(cfg *Config) (*Server, error) {
 	}
 
 	// Configure SSH server
+	logger := logging.Component("ssh")
 	sshConfig := &ssh.ServerConfig{
 		// No authentication required - username is the session ID
 		NoClientAuth: true,
@@ -82,8 +98,8 @@ func New(cfg *Config) (*Server, error) {
 		// Optional: Log auth attempts
 		AuthLogCallback: func(conn ssh.ConnMetadata, method string, err error) {
 			if err != nil {
-				log.Printf("SSH auth failed for %s@%s: method=%s err=%v",
-					conn.User(), conn.RemoteAddr(), method, err)
+				logger.Warn("auth failed", "session_id", conn.User(),
+					"remote", conn.RemoteAddr().String(), "method", method, "error", err)
 			}
 		},
 	}
@@ -93,7 +109,9 @@ func New(cfg *Config) (*Server, error) {
 		config:          sshConfig,
 		provider:        cfg.SandboxProvider,
 		userInfoFetcher: cfg.UserInfoFetcher,
+		recorder:        cfg.ConnectionRecorder,
 		addr:            cfg.Address,
+		logger:          logger,
 		sessions:        make(map[string]*sessionHandler),
 	}, nil
 }
@@ -109,7 +127,7 @@ func (s *Server) Start() error {
 	s.listener = listener
 	s.mu.Unlock()
 
-	log.Printf("SSH server listening on %s", s.addr)
+	s.logger.Info("SSH server listening", "addr", s.addr)
 
 	for {
 		conn, err := listener.Accept()
@@ -120,7 +138,7 @@ func (s *Server) Start() error {
 			if closed {
 				return nil
 			}
-			log.Printf("SSH accept error: %v", err)
+			s.logger.Error("accept failed", "error", err)
 			continue
 		}
 
@@ -155,31 +173,36 @@ func (s *Server) handleConnection(netConn net.Conn) {
 	// Perform SSH handshake
 	sshConn, chans, reqs, err := ssh.NewServerConn(netConn, s.config)
 	if err != nil {
-		log.Printf("SSH handshake failed: %v", err)
+		s.logger.Warn("handshake failed", "remote", netConn.RemoteAddr().String(), "error", err)
 		netConn.Close()
 		return
 	}
 
 	// Username is the session ID
 	sessionID := sshConn.User()
-	log.Printf("SSH connection from %s for session %s", sshConn.RemoteAddr(), sessionID)
+	logger := s.logger.With("session_id", sessionID, "remote", sshConn.RemoteAddr().String())
+	logger.Info("connection opened")
 
 	// Verify sandbox exists and is running
 	ctx := context.Background()
 	sb, err := s.provider.Get(ctx, sessionID)
 	if err != nil {
-		log.Printf("SSH session %s: sandbox not found: %v", sessionID, err)
+		logger.Warn("sandbox not found", "error", err)
 		sshConn.Close()
 		return
 	}
 	if sb.Status != sandbox.StatusRunning {
-		log.Printf("SSH session %s: sandbox not running (status=%s)", sessionID, sb.Status)
+		logger.Warn("sandbox not running", "status", sb.Status)
 		sshConn.Close()
 		return
 	}
 
+	if s.recorder != nil {
+		s.recorder.RecordConnection(ctx, sessionID, sshConn.RemoteAddr().String())
+	}
+
 	// Create session handler
-	handler := newSessionHandler(sessionID, s.provider, s.userInfoFetcher)
+	handler := newSessionHandler(sessionID, s.provider, s.userInfoFetcher, logger)
 
 	s.mu.Lock()
 	s.sessions[sessionID] = handler
@@ -189,8 +212,9 @@ func (s *Server) handleConnection(netConn net.Conn) {
 		s.mu.Lock()
 		delete(s.sessions, sessionID)
 		s.mu.Unlock()
+		handler.close()
 		sshConn.Close()
-		log.Printf("SSH connection closed for session %s", sessionID)
+		logger.Info("connection closed")
 	}()
 
 	// Handle global requests (keepalive, etc.)
@@ -235,7 +259,7 @@ func loadOrGenerateHostKey(path string) (ssh.Signer, error) {
 		if err := os.WriteFile(path, keyBytes, 0600); err != nil {
 			return nil, fmt.Errorf("failed to save host key: %w", err)
 		}
-		log.Printf("Generated new SSH host key: %s", path)
+		logging.Component("ssh").Info("generated new SSH host key", "path", path)
 	}
 
 	return ssh.ParsePrivateKey(keyBytes)
@@ -246,13 +270,19 @@ type sessionHandler struct {
 	sessionID       string
 	provider        sandbox.Provider
 	userInfoFetcher UserInfoFetcher
+	logger          *slog.Logger
+
+	tunnelMu sync.Mutex
+	tunnel   *tunnel.Session // lazily started by getTunnel
+	closed   bool
 }
 
-func newSessionHandler(sessionID string, provider sandbox.Provider, userInfoFetcher UserInfoFetcher) *sessionHandler {
+func newSessionHandler(sessionID string, provider sandbox.Provider, userInfoFetcher UserInfoFetcher, logger *slog.Logger) *sessionHandler {
 	return &sessionHandler{
 		sessionID:       sessionID,
 		provider:        provider,
 		userInfoFetcher: userInfoFetcher,
+		logger:          logger,
 	}
 }
 
@@ -265,7 +295,7 @@ func (h *sessionHandler) getUser(ctx context.Context) string {
 
 	_, uid, gid, err := h.userInfoFetcher.GetUserInfo(ctx, h.sessionID)
 	if err != nil {
-		log.Printf("SSH session %s: failed to get user info, using default: %v", h.sessionID, err)
+		h.logger.Warn("failed to get user info, using default", "error", err)
 		return ""
 	}
 
@@ -279,8 +309,7 @@ func (h *sessionHandler) handleChannel(newChannel ssh.NewChannel) {
 	case "direct-tcpip":
 		h.handleDirectTCPIP(newChannel)
 	default:
-		log.Printf("SSH session %s: rejecting unknown channel type: %s",
-			h.sessionID, newChannel.ChannelType())
+		h.logger.Warn("rejecting unknown channel type", "type", newChannel.ChannelType())
 		_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
 	}
 }
@@ -293,7 +322,7 @@ type resizeable interface {
 func (h *sessionHandler) handleSessionChannel(newChannel ssh.NewChannel) {
 	channel, requests, err := newChannel.Accept()
 	if err != nil {
-		log.Printf("SSH session %s: failed to accept channel: %v", h.sessionID, err)
+		h.logger.Error("failed to accept channel", "error", err)
 		return
 	}
 	defer channel.Close()
@@ -390,7 +419,7 @@ func (h *sessionHandler) handleSessionChannel(newChannel ssh.NewChannel) {
 				ptyMu.Unlock()
 				if p != nil {
 					if resizeErr := p.Resize(context.Background(), int(rows), int(cols)); resizeErr != nil {
-						log.Printf("SSH session %s: PTY resize error: %v", h.sessionID, resizeErr)
+						h.logger.Warn("PTY resize failed", "error", resizeErr)
 					}
 				}
 				if req.WantReply {
@@ -398,7 +427,7 @@ func (h *sessionHandler) handleSessionChannel(newChannel ssh.NewChannel) {
 				}
 
 			default:
-				log.Printf("SSH session %s: unknown request type: %s", h.sessionID, req.Type)
+				h.logger.Debug("unknown request type", "type", req.Type)
 				if req.WantReply {
 					_ = req.Reply(false, nil)
 				}
@@ -424,7 +453,7 @@ func (h *sessionHandler) runShell(channel ssh.Channel, ptyReq *ptyRequest, envVa
 
 	pty, err := h.provider.Attach(ctx, h.sessionID, opts)
 	if err != nil {
-		log.Printf("SSH session %s: failed to attach: %v", h.sessionID, err)
+		h.logger.Error("failed to attach", "error", err)
 		sendExitStatus(channel, 1)
 		return
 	}
@@ -472,7 +501,7 @@ func (h *sessionHandler) runExec(channel ssh.Channel, command string, ptyReq *pt
 	})
 
 	if err != nil {
-		log.Printf("SSH session %s: exec failed: %v", h.sessionID, err)
+		h.logger.Error("exec failed", "error", err)
 		fmt.Fprintf(channel.Stderr(), "exec error: %v\n", err)
 		sendExitStatus(channel, 1)
 		return
@@ -530,7 +559,7 @@ func (h *sessionHandler) runSFTP(channel ssh.Channel) {
 		User: user,
 	})
 	if err != nil {
-		log.Printf("SSH session %s: sftp-server failed to start: %v", h.sessionID, err)
+		h.logger.Error("sftp-server failed to start", "error", err)
 		return
 	}
 	defer stream.Close()
@@ -557,59 +586,105 @@ func (h *sessionHandler) runSFTP(channel ssh.Channel) {
 	<-outputDone
 }
 
+// directTCPIPOpenTimeout bounds how long a direct-tcpip channel waits for
+// the sandbox to connect to its target.
+const directTCPIPOpenTimeout = 30 * time.Second
+
 func (h *sessionHandler) handleDirectTCPIP(newChannel ssh.NewChannel) {
 	// Parse direct-tcpip request
 	data := newChannel.ExtraData()
 	destHost, destPort, origHost, origPort := parseDirectTCPIPData(data)
 
-	log.Printf("SSH session %s: direct-tcpip %s:%d -> %s:%d",
-		h.sessionID, origHost, origPort, destHost, destPort)
+	h.logger.Info("direct-tcpip",
+		"origin", net.JoinHostPort(origHost, strconv.FormatUint(uint64(origPort), 10)),
+		"target", net.JoinHostPort(destHost, strconv.FormatUint(uint64(destPort), 10)))
+
+	// The tunnel outlives this channel, so it is started without a deadline
+	tun, err := h.getTunnel(context.Background())
+	if err != nil {
+		h.logger.Error("failed to start tunnel", "error", err)
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	// Connect inside the sandbox before accepting so dial failures reach
+	// the client as a channel rejection with the reason.
+	// An agent that never answers the open would otherwise leave the channel
+	// pending forever.
+	target := net.JoinHostPort(destHost, strconv.FormatUint(uint64(destPort), 10))
+	ctx, cancel := context.WithTimeout(context.Background(), directTCPIPOpenTimeout)
+	stream, err := tun.Open(ctx, target)
+	cancel()
+	if err != nil {
+		h.logger.Warn("direct-tcpip failed", "target", target, "error", err)
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
 
 	// Accept the channel
-	channel, _, err := newChannel.Accept()
+	channel, reqs, err := newChannel.Accept()
 	if err != nil {
-		log.Printf("SSH session %s: failed to accept direct-tcpip channel: %v", h.sessionID, err)
+		h.logger.Error("failed to accept direct-tcpip channel", "error", err)
+		_ = stream.Close()
 		return
 	}
-	defer channel.Close()
+	go ssh.DiscardRequests(reqs)
 
-	ctx := context.Background()
+	_, _, _ = tunnel.Join(channel, stream)
+}
 
-	// Get user for this session (uid:gid format)
-	user := h.getUser(ctx)
+// getTunnel returns the session's tunnel, starting "discobot-agent tunnel
+// stdio" in the sandbox on first use. All direct-tcpip channels of the SSH
+// connection are multiplexed over this one exec stream.
+func (h *sessionHandler) getTunnel(ctx context.Context) (*tunnel.Session, error) {
+	h.tunnelMu.Lock()
+	defer h.tunnelMu.Unlock()
 
-	// Use socat to forward the connection inside the container
-	// socat reads from stdin, writes to TCP; reads from TCP, writes to stdout
-	cmd := []string{"socat", "-", fmt.Sprintf("TCP:%s:%d", destHost, destPort)}
-	stream, err := h.provider.ExecStream(ctx, h.sessionID, cmd, sandbox.ExecStreamOptions{
-		User: user,
+	if h.tunnel != nil {
+		select {
+		case <-h.tunnel.Done():
+			h.tunnel = nil
+		default:
+			return h.tunnel, nil
+		}
+	}
+	if h.closed {
+		return nil, errors.New("ssh connection closed")
+	}
+
+	stream, err := h.provider.ExecStream(ctx, h.sessionID, []string{sandbox.AgentBinary, "tunnel", "stdio"}, sandbox.ExecStreamOptions{
+		User: h.getUser(ctx),
 	})
 	if err != nil {
-		log.Printf("SSH session %s: failed to start socat: %v", h.sessionID, err)
-		return
+		return nil, err
 	}
-	defer stream.Close()
 
-	// Done channel to signal when forwarding completes
-	outputDone := make(chan struct{})
-
-	// Channel -> socat stdin (to remote TCP)
-	go func() {
-		_, _ = io.Copy(stream, channel)
-		_ = stream.CloseWrite()
-	}()
+	// The tunnel logs per-stream statistics on stderr. Drain it so the
+	// exec stream never blocks on an unread pipe.
+	if stderr := stream.Stderr(); stderr != nil {
+		go func() {
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				h.logger.Info("tunnel", "output", scanner.Text())
+			}
+		}()
+	}
 
-	// socat stdout (from remote TCP) -> Channel
-	go func() {
-		_, _ = io.Copy(channel, stream)
-		close(outputDone)
-	}()
+	h.tunnel = tunnel.Client(stream, nil)
+	return h.tunnel, nil
+}
 
-	// Wait for socat to exit (connection closed from either end)
-	_, _ = stream.Wait(ctx)
+// close shuts down the session's tunnel, if any.
+func (h *sessionHandler) close() {
+	h.tunnelMu.Lock()
+	h.closed = true
+	tun := h.tunnel
+	h.tunnel = nil
+	h.tunnelMu.Unlock()
 
-	// Wait for output to drain
-	<-outputDone
+	if tun != nil {
+		_ = tun.Close()
+	}
 }
 
 // sendExitStatus sends the exit-status request to signal command completion.
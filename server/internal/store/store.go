@@ -3,6 +3,7 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -95,6 +96,151 @@ func (s *Store) DeleteExpiredUserSessions(ctx context.Context) error {
 	return s.writeDB.WithContext(ctx).Delete(&model.UserSession{}, "expires_at < ?", time.Now()).Error
 }
 
+// --- API Tokens ---
+
+func (s *Store) CreateAPIToken(ctx context.Context, token *model.APIToken) error {
+	return s.writeDB.WithContext(ctx).Create(token).Error
+}
+
+func (s *Store) GetAPITokenByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	var token model.APIToken
+	if err := s.readDB.WithContext(ctx).Preload("User").First(&token, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *Store) GetAPITokenByID(ctx context.Context, id string) (*model.APIToken, error) {
+	var token model.APIToken
+	if err := s.readDB.WithContext(ctx).First(&token, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *Store) ListAPITokensByUser(ctx context.Context, userID string) ([]*model.APIToken, error) {
+	var tokens []*model.APIToken
+	err := s.readDB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// TouchAPIToken records that a token was used.
+func (s *Store) TouchAPIToken(ctx context.Context, id string, usedAt time.Time) error {
+	return s.writeDB.WithContext(ctx).Model(&model.APIToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+func (s *Store) DeleteAPIToken(ctx context.Context, id string) error {
+	return s.writeDB.WithContext(ctx).Delete(&model.APIToken{}, "id = ?", id).Error
+}
+
+// --- Session Shares ---
+
+func (s *Store) CreateSessionShare(ctx context.Context, share *model.SessionShare) error {
+	return s.writeDB.WithContext(ctx).Create(share).Error
+}
+
+func (s *Store) GetSessionShareByHash(ctx context.Context, tokenHash string) (*model.SessionShare, error) {
+	var share model.SessionShare
+	if err := s.readDB.WithContext(ctx).First(&share, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (s *Store) GetSessionShareByID(ctx context.Context, id string) (*model.SessionShare, error) {
+	var share model.SessionShare
+	if err := s.readDB.WithContext(ctx).First(&share, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (s *Store) ListSessionSharesBySession(ctx context.Context, sessionID string) ([]*model.SessionShare, error) {
+	var shares []*model.SessionShare
+	err := s.readDB.WithContext(ctx).Where("session_id = ?", sessionID).Order("created_at DESC").Find(&shares).Error
+	return shares, err
+}
+
+// TouchSessionShare records that a share link was used.
+func (s *Store) TouchSessionShare(ctx context.Context, id string, usedAt time.Time) error {
+	return s.writeDB.WithContext(ctx).Model(&model.SessionShare{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+func (s *Store) DeleteSessionShare(ctx context.Context, id string) error {
+	return s.writeDB.WithContext(ctx).Delete(&model.SessionShare{}, "id = ?", id).Error
+}
+
+// --- Service Accounts ---
+
+// CreateServiceAccount creates the service account, its backing user and its
+// project membership in one transaction.
+func (s *Store) CreateServiceAccount(ctx context.Context, account *model.ServiceAccount, user *model.User, member *model.ProjectMember) error {
+	return s.writeDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		account.UserID = user.ID
+		member.UserID = user.ID
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+		return tx.Create(account).Error
+	})
+}
+
+func (s *Store) GetServiceAccountByID(ctx context.Context, id string) (*model.ServiceAccount, error) {
+	var account model.ServiceAccount
+	if err := s.readDB.WithContext(ctx).First(&account, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (s *Store) ListServiceAccountsByProject(ctx context.Context, projectID string) ([]*model.ServiceAccount, error) {
+	var accounts []*model.ServiceAccount
+	err := s.readDB.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// DeleteServiceAccount deletes a service account together with its tokens,
+// project membership and backing user.
+func (s *Store) DeleteServiceAccount(ctx context.Context, id string) error {
+	return s.writeDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var account model.ServiceAccount
+		if err := tx.First(&account, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Where("user_id = ?", account.UserID).Delete(&model.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", account.UserID).Delete(&model.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.ServiceAccount{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, "id = ?", account.UserID).Error
+	})
+}
+
 // --- Projects ---
 
 func (s *Store) GetProjectByID(ctx context.Context, id string) (*model.Project, error) {
@@ -174,6 +320,43 @@ func (s *Store) DeleteProject(ctx context.Context, id string) error {
 			return err
 		}
 
+		// Delete batch runs and their items
+		if err := tx.Where("batch_run_id IN (SELECT id FROM batch_runs WHERE project_id = ?)", id).Delete(&model.BatchRunItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&model.BatchRun{}).Error; err != nil {
+			return err
+		}
+
+		// Delete schedules and their run history
+		if err := tx.Where("project_id = ?", id).Delete(&model.ScheduleRun{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&model.Schedule{}).Error; err != nil {
+			return err
+		}
+
+		// Delete headless runs
+		if err := tx.Where("project_id = ?", id).Delete(&model.HeadlessRun{}).Error; err != nil {
+			return err
+		}
+
+		// Delete session share links
+		if err := tx.Where("project_id = ?", id).Delete(&model.SessionShare{}).Error; err != nil {
+			return err
+		}
+
+		// Delete service accounts along with their users and tokens
+		if err := tx.Where("user_id IN (SELECT user_id FROM service_accounts WHERE project_id = ?)", id).Delete(&model.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN (SELECT user_id FROM service_accounts WHERE project_id = ?)", id).Delete(&model.User{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&model.ServiceAccount{}).Error; err != nil {
+			return err
+		}
+
 		// Finally delete the project
 		return tx.Delete(&model.Project{}, "id = ?", id).Error
 	})
@@ -202,6 +385,10 @@ func (s *Store) CreateProjectMember(ctx context.Context, member *model.ProjectMe
 	return s.writeDB.WithContext(ctx).Create(member).Error
 }
 
+func (s *Store) UpdateProjectMember(ctx context.Context, member *model.ProjectMember) error {
+	return s.writeDB.WithContext(ctx).Save(member).Error
+}
+
 func (s *Store) DeleteProjectMember(ctx context.Context, projectID, userID string) error {
 	return s.writeDB.WithContext(ctx).Delete(&model.ProjectMember{}, "project_id = ? AND user_id = ?", projectID, userID).Error
 }
@@ -219,14 +406,42 @@ func (s *Store) GetInvitationByToken(ctx context.Context, token string) (*model.
 	return &invitation, nil
 }
 
+func (s *Store) GetInvitationByID(ctx context.Context, id string) (*model.ProjectInvitation, error) {
+	var invitation model.ProjectInvitation
+	if err := s.readDB.WithContext(ctx).First(&invitation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (s *Store) ListInvitationsByProject(ctx context.Context, projectID string) ([]*model.ProjectInvitation, error) {
+	var invitations []*model.ProjectInvitation
+	err := s.readDB.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
 func (s *Store) CreateInvitation(ctx context.Context, invitation *model.ProjectInvitation) error {
 	return s.writeDB.WithContext(ctx).Create(invitation).Error
 }
 
+func (s *Store) UpdateInvitation(ctx context.Context, invitation *model.ProjectInvitation) error {
+	return s.writeDB.WithContext(ctx).Save(invitation).Error
+}
+
 func (s *Store) DeleteInvitation(ctx context.Context, id string) error {
 	return s.writeDB.WithContext(ctx).Delete(&model.ProjectInvitation{}, "id = ?", id).Error
 }
 
+// DeleteExpiredInvitations deletes invitations that expired before cutoff and
+// returns how many were removed.
+func (s *Store) DeleteExpiredInvitations(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.writeDB.WithContext(ctx).Where("expires_at < ?", cutoff).Delete(&model.ProjectInvitation{})
+	return result.RowsAffected, result.Error
+}
+
 // --- Workspaces ---
 
 func (s *Store) GetWorkspaceByID(ctx context.Context, id string) (*model.Workspace, error) {
@@ -260,6 +475,12 @@ func (s *Store) UpdateWorkspace(ctx context.Context, workspace *model.Workspace)
 	return s.writeDB.WithContext(ctx).Save(workspace).Error
 }
 
+// UpdateWorkspaceQuestionPolicy sets only the question policy of a workspace.
+// A nil policy clears it.
+func (s *Store) UpdateWorkspaceQuestionPolicy(ctx context.Context, id string, policy json.RawMessage) error {
+	return s.writeDB.WithContext(ctx).Model(&model.Workspace{}).Where("id = ?", id).Update("question_policy", nullableJSON(policy)).Error
+}
+
 func (s *Store) DeleteWorkspace(ctx context.Context, id string) error {
 	return s.writeDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Delete messages and terminal history for all sessions in this workspace
@@ -307,6 +528,13 @@ func (s *Store) ListSessionsByStatuses(ctx context.Context, statuses []string) (
 	return sessions, err
 }
 
+// ListSessionsByProjectAndStatuses returns a project's sessions with any of the given statuses.
+func (s *Store) ListSessionsByProjectAndStatuses(ctx context.Context, projectID string, statuses []string) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := s.readDB.WithContext(ctx).Where("project_id = ? AND status IN ?", projectID, statuses).Find(&sessions).Error
+	return sessions, err
+}
+
 // ListSessionsByCommitStatuses returns sessions with the given commit statuses.
 func (s *Store) ListSessionsByCommitStatuses(ctx context.Context, commitStatuses []string) ([]*model.Session, error) {
 	var sessions []*model.Session
@@ -334,6 +562,20 @@ func (s *Store) UpdateSessionStatus(ctx context.Context, id, status string, erro
 	return s.writeDB.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// UpdateSessionQuestionPolicy sets only the question policy of a session. A
+// nil policy clears it, so the workspace's policy applies.
+func (s *Store) UpdateSessionQuestionPolicy(ctx context.Context, id string, policy json.RawMessage) error {
+	return s.writeDB.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).Update("question_policy", nullableJSON(policy)).Error
+}
+
+// nullableJSON returns nil for an empty JSON value so it is stored as NULL.
+func nullableJSON(data json.RawMessage) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return []byte(data)
+}
+
 func (s *Store) CreateSession(ctx context.Context, session *model.Session) error {
 	return s.writeDB.WithContext(ctx).Create(session).Error
 }
@@ -353,6 +595,16 @@ func (s *Store) UpdateSessionWorkspace(ctx context.Context, id, workspacePath, w
 	return s.writeDB.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// UpdateSessionUpstream records how far a session is behind its workspace's tracked branch.
+// It uses UpdateColumns so that updated_at is not bumped, since updated_at is used
+// as a fallback for idle detection.
+func (s *Store) UpdateSessionUpstream(ctx context.Context, id, upstreamCommit string, behindCount int) error {
+	return s.writeDB.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"upstream_commit": upstreamCommit,
+		"behind_count":    behindCount,
+	}).Error
+}
+
 func (s *Store) DeleteSession(ctx context.Context, id string) error {
 	return s.writeDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Delete messages
@@ -365,6 +617,11 @@ func (s *Store) DeleteSession(ctx context.Context, id string) error {
 			return err
 		}
 
+		// Delete share links
+		if err := tx.Where("session_id = ?", id).Delete(&model.SessionShare{}).Error; err != nil {
+			return err
+		}
+
 		// Delete the session
 		return tx.Delete(&model.Session{}, "id = ?", id).Error
 	})
@@ -679,6 +936,25 @@ func (s *Store) CountRunningJobsByType(ctx context.Context, jobType string) (int
 	return count, err
 }
 
+// JobCount is the number of jobs of one type in one status.
+type JobCount struct {
+	Type   string
+	Status string
+	Count  int64
+}
+
+// CountJobsByTypeAndStatus counts jobs in the given statuses, grouped by
+// type and status.
+func (s *Store) CountJobsByTypeAndStatus(ctx context.Context, statuses []model.JobStatus) ([]JobCount, error) {
+	var counts []JobCount
+	err := s.readDB.WithContext(ctx).Model(&model.Job{}).
+		Select("type, status, COUNT(*) AS count").
+		Where("status IN ?", statuses).
+		Group("type, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
 // CleanupStaleJobs resets jobs that have been running too long (worker died).
 // Returns the number of jobs reset.
 func (s *Store) CleanupStaleJobs(ctx context.Context, staleAfter time.Duration) (int64, error) {
@@ -911,3 +1187,286 @@ func (s *Store) DeleteUserPreference(ctx context.Context, userID, key string) er
 	}
 	return nil
 }
+
+// --- Batch Runs ---
+
+// CreateBatchRun creates a batch run together with its items.
+func (s *Store) CreateBatchRun(ctx context.Context, run *model.BatchRun) error {
+	return s.writeDB.WithContext(ctx).Create(run).Error
+}
+
+// GetBatchRunByID returns a batch run with its items ordered by position.
+func (s *Store) GetBatchRunByID(ctx context.Context, id string) (*model.BatchRun, error) {
+	var run model.BatchRun
+	err := s.readDB.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		First(&run, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListBatchRunsByProject returns all batch runs for a project, newest first.
+func (s *Store) ListBatchRunsByProject(ctx context.Context, projectID string) ([]*model.BatchRun, error) {
+	var runs []*model.BatchRun
+	err := s.readDB.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&runs).Error
+	return runs, err
+}
+
+// UpdateBatchRunStatus updates only the status column of a batch run.
+func (s *Store) UpdateBatchRunStatus(ctx context.Context, id, status string) error {
+	return s.writeDB.WithContext(ctx).Model(&model.BatchRun{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// GetBatchRunItemByID returns a single batch run item.
+func (s *Store) GetBatchRunItemByID(ctx context.Context, id string) (*model.BatchRunItem, error) {
+	var item model.BatchRunItem
+	if err := s.readDB.WithContext(ctx).First(&item, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateBatchRunItem saves a batch run item.
+func (s *Store) UpdateBatchRunItem(ctx context.Context, item *model.BatchRunItem) error {
+	return s.writeDB.WithContext(ctx).Save(item).Error
+}
+
+// CancelPendingBatchRunItems marks all pending items of a batch run as cancelled.
+// Returns the number of items cancelled.
+func (s *Store) CancelPendingBatchRunItems(ctx context.Context, batchRunID string) (int64, error) {
+	result := s.writeDB.WithContext(ctx).Model(&model.BatchRunItem{}).
+		Where("batch_run_id = ? AND status = ?", batchRunID, model.BatchRunItemStatusPending).
+		Update("status", model.BatchRunItemStatusCancelled)
+	return result.RowsAffected, result.Error
+}
+
+// StartBatchRunItem moves a pending item to running. Returns false if the item
+// was no longer pending (e.g. it was cancelled or already started).
+func (s *Store) StartBatchRunItem(ctx context.Context, id string) (bool, error) {
+	result := s.writeDB.WithContext(ctx).Model(&model.BatchRunItem{}).
+		Where("id = ? AND status = ?", id, model.BatchRunItemStatusPending).
+		Update("status", model.BatchRunItemStatusRunning)
+	return result.RowsAffected > 0, result.Error
+}
+
+// --- Schedules ---
+
+// CreateSchedule creates a schedule.
+func (s *Store) CreateSchedule(ctx context.Context, schedule *model.Schedule) error {
+	return s.writeDB.WithContext(ctx).Create(schedule).Error
+}
+
+// GetScheduleByID returns a schedule by ID.
+func (s *Store) GetScheduleByID(ctx context.Context, id string) (*model.Schedule, error) {
+	var schedule model.Schedule
+	if err := s.readDB.WithContext(ctx).First(&schedule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListSchedulesByProject returns all schedules for a project.
+func (s *Store) ListSchedulesByProject(ctx context.Context, projectID string) ([]*model.Schedule, error) {
+	var schedules []*model.Schedule
+	err := s.readDB.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// ListDueSchedules returns enabled schedules whose next run is at or before now.
+func (s *Store) ListDueSchedules(ctx context.Context, now time.Time) ([]*model.Schedule, error) {
+	var schedules []*model.Schedule
+	err := s.readDB.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// UpdateSchedule saves a schedule.
+func (s *Store) UpdateSchedule(ctx context.Context, schedule *model.Schedule) error {
+	return s.writeDB.WithContext(ctx).Save(schedule).Error
+}
+
+// AdvanceSchedule moves a schedule's next run from prev to next and records
+// lastRunAt. Returns false if another caller already advanced it, so each
+// occurrence fires at most once.
+func (s *Store) AdvanceSchedule(ctx context.Context, id string, prev time.Time, next *time.Time, lastRunAt time.Time) (bool, error) {
+	result := s.writeDB.WithContext(ctx).Model(&model.Schedule{}).
+		Where("id = ? AND next_run_at = ?", id, prev).
+		Updates(map[string]interface{}{
+			"next_run_at": next,
+			"last_run_at": lastRunAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteSchedule deletes a schedule and its run history.
+func (s *Store) DeleteSchedule(ctx context.Context, id string) error {
+	return s.writeDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&model.ScheduleRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Schedule{}, "id = ?", id).Error
+	})
+}
+
+// CreateScheduleRun creates a schedule run.
+func (s *Store) CreateScheduleRun(ctx context.Context, run *model.ScheduleRun) error {
+	return s.writeDB.WithContext(ctx).Create(run).Error
+}
+
+// GetScheduleRunByID returns a schedule run by ID.
+func (s *Store) GetScheduleRunByID(ctx context.Context, id string) (*model.ScheduleRun, error) {
+	var run model.ScheduleRun
+	if err := s.readDB.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListScheduleRuns returns the most recent runs of a schedule, newest first.
+func (s *Store) ListScheduleRuns(ctx context.Context, scheduleID string, limit int) ([]*model.ScheduleRun, error) {
+	var runs []*model.ScheduleRun
+	err := s.readDB.WithContext(ctx).
+		Where("schedule_id = ?", scheduleID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+// UpdateScheduleRun saves a schedule run.
+func (s *Store) UpdateScheduleRun(ctx context.Context, run *model.ScheduleRun) error {
+	return s.writeDB.WithContext(ctx).Save(run).Error
+}
+
+// StartScheduleRun moves a pending run to running. Returns false if the run
+// was no longer pending.
+func (s *Store) StartScheduleRun(ctx context.Context, id string, startedAt time.Time) (bool, error) {
+	result := s.writeDB.WithContext(ctx).Model(&model.ScheduleRun{}).
+		Where("id = ? AND status = ?", id, model.ScheduleRunStatusPending).
+		Updates(map[string]interface{}{
+			"status":     model.ScheduleRunStatusRunning,
+			"started_at": startedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// --- Headless runs ---
+
+// CreateHeadlessRun creates a headless run.
+func (s *Store) CreateHeadlessRun(ctx context.Context, run *model.HeadlessRun) error {
+	return s.writeDB.WithContext(ctx).Create(run).Error
+}
+
+// GetHeadlessRunByID returns a headless run by ID.
+func (s *Store) GetHeadlessRunByID(ctx context.Context, id string) (*model.HeadlessRun, error) {
+	var run model.HeadlessRun
+	if err := s.readDB.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListHeadlessRunsByProject returns the most recent headless runs of a project,
+// newest first.
+func (s *Store) ListHeadlessRunsByProject(ctx context.Context, projectID string, limit int) ([]*model.HeadlessRun, error) {
+	var runs []*model.HeadlessRun
+	err := s.readDB.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+// UpdateHeadlessRun saves a headless run.
+func (s *Store) UpdateHeadlessRun(ctx context.Context, run *model.HeadlessRun) error {
+	return s.writeDB.WithContext(ctx).Save(run).Error
+}
+
+// StartHeadlessRun moves a pending run to running. Returns false if the run
+// was no longer pending.
+func (s *Store) StartHeadlessRun(ctx context.Context, id string, startedAt time.Time) (bool, error) {
+	result := s.writeDB.WithContext(ctx).Model(&model.HeadlessRun{}).
+		Where("id = ? AND status = ?", id, model.HeadlessRunStatusPending).
+		Updates(map[string]interface{}{
+			"status":     model.HeadlessRunStatusRunning,
+			"started_at": startedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// --- Audit logs ---
+
+// AuditLogFilter selects audit log entries. Zero-valued fields match everything.
+type AuditLogFilter struct {
+	ProjectID string
+	ActorID   string
+	Action    string
+	Since     time.Time // Inclusive
+	Until     time.Time // Exclusive
+	Limit     int       // 0 means no limit
+}
+
+// CreateAuditLog appends an audit log entry.
+func (s *Store) CreateAuditLog(ctx context.Context, entry *model.AuditLog) error {
+	return s.writeDB.WithContext(ctx).Create(entry).Error
+}
+
+// ListAuditLogs returns the audit log entries matching filter, newest first.
+func (s *Store) ListAuditLogs(ctx context.Context, filter AuditLogFilter) ([]*model.AuditLog, error) {
+	query := s.readDB.WithContext(ctx).Model(&model.AuditLog{})
+	if filter.ProjectID != "" {
+		query = query.Where("project_id = ?", filter.ProjectID)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var entries []*model.AuditLog
+	err := query.Order("created_at DESC").Find(&entries).Error
+	return entries, err
+}
+
+// DeleteAuditLogsBefore deletes audit log entries created before cutoff and
+// returns how many were removed.
+func (s *Store) DeleteAuditLogsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.writeDB.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&model.AuditLog{})
+	return result.RowsAffected, result.Error
+}
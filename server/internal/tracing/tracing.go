@@ -0,0 +1,145 @@
+// Package tracing configures OpenTelemetry tracing for the server.
+//
+// Spans are exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set; the standard OTEL_* variables
+// configure the exporter, sampler and resource. Without an endpoint no spans
+// are recorded, but W3C trace context sent by callers still propagates to
+// sandboxes.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/obot-platform/discobot/server"
+
+// Tracer returns the server's tracer from the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if non-nil, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Enabled reports whether an OTLP endpoint is configured.
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs the W3C trace context and baggage propagators and, when an
+// OTLP endpoint is configured, a global tracer provider exporting to it. The
+// returned function flushes and stops the provider.
+func Setup(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	if !Enabled() {
+		setPropagator()
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return Install(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)), nil
+}
+
+// Install makes tp the global tracer provider, installs the propagators and
+// returns tp's shutdown function. Tests use it with an in-memory exporter.
+func Install(tp *sdktrace.TracerProvider) func(context.Context) error {
+	otel.SetTracerProvider(tp)
+	setPropagator()
+	return tp.Shutdown
+}
+
+func setPropagator() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// TraceParent returns the W3C traceparent header for the span in ctx, or ""
+// if ctx carries no span.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// ContextWithTraceParent returns ctx with the remote span described by a
+// W3C traceparent header as its parent. Invalid values are ignored.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	carrier := propagation.MapCarrier{"traceparent": traceParent}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// WrapClient returns a copy of client whose requests are traced and carry
+// the trace context of their request context.
+func WrapClient(client *http.Client) *http.Client {
+	if client == nil {
+		return nil
+	}
+	if _, ok := client.Transport.(*otelhttp.Transport); ok {
+		return client
+	}
+	wrapped := *client
+	wrapped.Transport = otelhttp.NewTransport(client.Transport,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "sandbox " + r.Method + " " + r.URL.Path
+		}),
+	)
+	return &wrapped
+}
+
+// SandboxEnv returns the OTEL_* environment variables of the server, to be
+// passed into sandboxes so the sandbox proxy exports to the same collector.
+func SandboxEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, "OTEL_") || key == "OTEL_SERVICE_NAME" {
+			continue
+		}
+		env[key] = value
+	}
+	return env
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	if got := TraceParent(context.Background()); got != "" {
+		t.Errorf("TraceParent without span = %q, want empty", got)
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := Install(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	ctx, span := Start(context.Background(), "enqueue")
+	traceParent := TraceParent(ctx)
+	span.End()
+
+	restored := trace.SpanContextFromContext(ContextWithTraceParent(context.Background(), traceParent))
+	if restored.TraceID() != span.SpanContext().TraceID() || restored.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("restored span context %v, want %v", restored, span.SpanContext())
+	}
+	if !restored.IsRemote() {
+		t.Error("restored span context should be remote")
+	}
+
+	if sc := trace.SpanContextFromContext(ContextWithTraceParent(context.Background(), "garbage")); sc.IsValid() {
+		t.Error("invalid traceparent produced a valid span context")
+	}
+}
+
+func TestSandboxEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("OTEL_SERVICE_NAME", "discobot-server")
+	t.Setenv("NOT_OTEL", "x")
+
+	env := SandboxEnv()
+	if env["OTEL_EXPORTER_OTLP_ENDPOINT"] != "http://collector:4318" {
+		t.Errorf("endpoint not forwarded: %v", env)
+	}
+	if _, ok := env["OTEL_SERVICE_NAME"]; ok {
+		t.Error("service name should not be forwarded")
+	}
+	if _, ok := env["NOT_OTEL"]; ok {
+		t.Error("unrelated variable forwarded")
+	}
+}